@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/sabith-th/games_with_go/noise"
+	"github.com/sabith-th/games_with_go/postfx"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
@@ -294,14 +295,20 @@ func main() {
 	cloudTexture := texture{cloudPixels, position{0, 0}, winWidth, winHeight, winWidth * 4, float32(1)}
 	balloonTextures := loadBalloons()
 	dir := [3]int{1, 1, 1}
+	bloom := false
+	var bloomPass postfx.Bloom
 
 	for {
 		frameStart := time.Now()
 
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch event.(type) {
+			switch e := event.(type) {
 			case *sdl.QuitEvent:
 				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == sdl.SCANCODE_B {
+					bloom = !bloom
+				}
 			}
 		}
 
@@ -315,6 +322,10 @@ func main() {
 			}
 		}
 
+		if bloom {
+			bloomPass.Apply(pixels, winWidth, winHeight, 200, 0.8)
+		}
+
 		tex.Update(nil, pixels, winWidth*4)
 		renderer.Copy(tex, nil, nil)
 		renderer.Present()
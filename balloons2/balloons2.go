@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/sabith-th/games_with_go/noise"
+	"github.com/sabith-th/games_with_go/postfx"
 	"github.com/sabith-th/games_with_go/vector3"
 	"github.com/veandco/go-sdl2/sdl"
 )
@@ -345,6 +346,11 @@ func main() {
 	cloudGradient := getGradient(rgba{0, 0, 255}, rgba{255, 255, 255})
 	cloudPixels := rescaleAndDraw(cloudNoise, min, max, cloudGradient, winWidth, winHeight)
 	cloudTexture := pixelsToTexture(renderer, cloudPixels, winWidth, winHeight)
+	// the background never changes, so bloom it once up front and toggle between the two
+	var bloomPass postfx.Bloom
+	bloomPass.Apply(cloudPixels, winWidth, winHeight, 200, 0.8)
+	bloomTexture := pixelsToTexture(renderer, cloudPixels, winWidth, winHeight)
+	bloom := false
 
 	balloons := loadBalloons(renderer, 20)
 	var elapsedTime float32
@@ -367,10 +373,18 @@ func main() {
 					currentMouseState.x, currentMouseState.y = touchX, touchY
 					currentMouseState.leftButton = true
 				}
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == sdl.SCANCODE_B {
+					bloom = !bloom
+				}
 			}
 		}
 
-		renderer.Copy(cloudTexture, nil, nil)
+		if bloom {
+			renderer.Copy(bloomTexture, nil, nil)
+		} else {
+			renderer.Copy(cloudTexture, nil, nil)
+		}
 
 		balloons = updateBalloons(balloons, elapsedTime, currentMouseState, prevMouseState, &audioState)
 
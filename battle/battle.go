@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sabith-th/games_with_go/config"
+	"github.com/sabith-th/games_with_go/rpg"
+)
+
+// newHero is the hero of a first run, later runs start from the last
+func newHero() *rpg.Entity {
+	return &rpg.Entity{Name: "Hero", HP: 90, MaxHP: 90, Attack: 14, Defense: 8, Speed: 10, Level: 1}
+}
+
+// defaultSavePath is where the hero is kept between runs, in the user's
+// config directory if there is one
+func defaultSavePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gameswithgo", "battle-hero.json")
+}
+
+func main() {
+	savePath := flag.String("save", defaultSavePath(), "keep the hero's stats in this file between runs")
+	fresh := flag.Bool("fresh", false, "start a new hero instead of the saved one")
+	dialogueFile := flag.String("dialogue", "dialogue/gate.json", "talk to the guard at the gate from this dialogue file first")
+	configFile := flag.String("config", "", "read flags not given on the command line from this JSON file")
+	flag.Parse()
+	if *configFile != "" {
+		err := config.LoadConfig(flag.CommandLine, *configFile)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	hero := newHero()
+	if !*fresh {
+		saved, err := rpg.LoadPlayer(*savePath)
+		switch {
+		case err == nil:
+			hero = saved
+		case !errors.Is(err, fs.ErrNotExist):
+			fmt.Println(err)
+		}
+	}
+	// every run starts rested
+	hero.HP = hero.MaxHP
+	fmt.Printf("%s, level %d, %d xp to the next\n", hero.Name, hero.Level, rpg.XPToLevel(hero.Level)-hero.XP)
+
+	// the guard has to be asked to open the gate, without a guard it is
+	// open already
+	descend := false
+	nodes, err := rpg.LoadDialogue(*dialogueFile, map[string]func(){
+		"descend": func() { descend = true },
+	})
+	if err != nil {
+		fmt.Println(err)
+		descend = true
+	} else {
+		err = rpg.Converse("The Gate", &rpg.DialogueSystem{Nodes: nodes}, "gate")
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+	if !descend {
+		return
+	}
+
+	// the dungeon goes down a level a battle, won or run from, until the
+	// hero loses or quits; the enemies are made up on the way
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for depth := 0; ; depth++ {
+		enemy := rpg.GenerateEnemy(rpg.Archetype(rng.Intn(int(rpg.NumArchetypes))), rng.Intn(1<<16), depth)
+		result := rpg.ATBBattle(hero, &enemy)
+		switch result {
+		case rpg.Won:
+			fmt.Printf("depth %d: beat the %s with %d hp left\n", depth, enemy.Name, hero.HP)
+		case rpg.Fled:
+			fmt.Printf("depth %d: ran from the %s\n", depth, enemy.Name)
+		case rpg.Lost:
+			fmt.Printf("depth %d: lost to the %s\n", depth, enemy.Name)
+		}
+		// the xp and levels are kept even when the run ends badly
+		err = rpg.SavePlayer(*savePath, hero)
+		if err != nil {
+			fmt.Println(err)
+		}
+		if result == rpg.Lost || result == rpg.Undecided {
+			return
+		}
+	}
+}
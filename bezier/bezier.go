@@ -0,0 +1,80 @@
+package bezier
+
+import "github.com/sabith-th/games_with_go/vector2"
+
+// maxDepth bounds the recursion of Flatten for degenerate control points
+const maxDepth = 16
+
+// CubicBezier evaluates the cubic curve with control points p0..p3 at t in [0, 1]
+func CubicBezier(p0, p1, p2, p3 vector2.Vector2, t float32) vector2.Vector2 {
+	u := 1 - t
+	b0 := u * u * u
+	b1 := 3 * u * u * t
+	b2 := 3 * u * t * t
+	b3 := t * t * t
+	return vector2.Vector2{
+		X: b0*p0.X + b1*p1.X + b2*p2.X + b3*p3.X,
+		Y: b0*p0.Y + b1*p1.Y + b2*p2.Y + b3*p3.Y,
+	}
+}
+
+// CubicDerivative returns the tangent of the cubic curve at t
+func CubicDerivative(p0, p1, p2, p3 vector2.Vector2, t float32) vector2.Vector2 {
+	u := 1 - t
+	a := vector2.Mult(vector2.Sub(p1, p0), 3*u*u)
+	b := vector2.Mult(vector2.Sub(p2, p1), 6*u*t)
+	c := vector2.Mult(vector2.Sub(p3, p2), 3*t*t)
+	return vector2.Add(vector2.Add(a, b), c)
+}
+
+// Split divides the cubic curve at t using de Casteljau's algorithm and
+// returns the control points of the two halves
+func Split(p0, p1, p2, p3 vector2.Vector2, t float32) (left, right [4]vector2.Vector2) {
+	p01 := vector2.Lerp(p0, p1, t)
+	p12 := vector2.Lerp(p1, p2, t)
+	p23 := vector2.Lerp(p2, p3, t)
+	p012 := vector2.Lerp(p01, p12, t)
+	p123 := vector2.Lerp(p12, p23, t)
+	mid := vector2.Lerp(p012, p123, t)
+	return [4]vector2.Vector2{p0, p01, p012, mid}, [4]vector2.Vector2{mid, p123, p23, p3}
+}
+
+// Flatten approximates the cubic curve by a polyline running from p0 to p3.
+// The curve is halved until the control polygon of each piece, which is
+// never shorter than its chord, is shorter than tolerance.
+func Flatten(p0, p1, p2, p3 vector2.Vector2, tolerance float32) []vector2.Vector2 {
+	points := []vector2.Vector2{p0}
+	return flatten(points, p0, p1, p2, p3, tolerance, 0)
+}
+
+func flatten(points []vector2.Vector2, p0, p1, p2, p3 vector2.Vector2, tolerance float32, depth int) []vector2.Vector2 {
+	polygon := vector2.Distance(p0, p1) + vector2.Distance(p1, p2) + vector2.Distance(p2, p3)
+	if polygon < tolerance || depth >= maxDepth {
+		return append(points, p3)
+	}
+	left, right := Split(p0, p1, p2, p3, 0.5)
+	points = flatten(points, left[0], left[1], left[2], left[3], tolerance, depth+1)
+	return flatten(points, right[0], right[1], right[2], right[3], tolerance, depth+1)
+}
+
+// ArcLength integrates the speed of the curve over [0, 1] with Simpson's
+// rule using n intervals, rounded up to an even number
+func ArcLength(p0, p1, p2, p3 vector2.Vector2, n int) float32 {
+	if n < 2 {
+		n = 2
+	}
+	if n%2 != 0 {
+		n++
+	}
+	h := 1 / float32(n)
+	sum := CubicDerivative(p0, p1, p2, p3, 0).Length() + CubicDerivative(p0, p1, p2, p3, 1).Length()
+	for i := 1; i < n; i++ {
+		speed := CubicDerivative(p0, p1, p2, p3, float32(i)*h).Length()
+		if i%2 == 1 {
+			sum += 4 * speed
+		} else {
+			sum += 2 * speed
+		}
+	}
+	return sum * h / 3
+}
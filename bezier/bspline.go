@@ -0,0 +1,94 @@
+package bezier
+
+import "github.com/sabith-th/games_with_go/vector2"
+
+// B-splines here use the uniform knot vector 0, 1, 2, ..., n+degree for n
+// control points. The curve is only defined between knots degree and n, so
+// it starts and ends near rather than on the end control points, but every
+// segment joins the next with continuous curvature.
+
+// bsplineSpan maps t in [0, 1] onto the valid knot range and returns the
+// knot position u along with the index k of the span containing it
+func bsplineSpan(n, degree int, t float32) (u float32, k int) {
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	u = float32(degree) + t*float32(n-degree)
+	k = int(u)
+	if k > n-1 {
+		k = n - 1
+	}
+	return u, k
+}
+
+// BSpline evaluates the uniform B-spline of the given degree through
+// controlPoints at t in [0, 1] with de Boor's algorithm. It needs more
+// control points than its degree and returns the zero vector otherwise.
+func BSpline(controlPoints []vector2.Vector2, degree int, t float32) vector2.Vector2 {
+	n := len(controlPoints)
+	if degree < 1 || n <= degree {
+		return vector2.Vector2{}
+	}
+	u, k := bsplineSpan(n, degree, t)
+
+	d := make([]vector2.Vector2, degree+1)
+	copy(d, controlPoints[k-degree:k+1])
+	for r := 1; r <= degree; r++ {
+		for j := degree; j >= r; j-- {
+			i := j + k - degree
+			// with uniform knots, knot i is just i
+			alpha := (u - float32(i)) / float32(degree+1-r)
+			d[j] = vector2.Lerp(d[j-1], d[j], alpha)
+		}
+	}
+	return d[degree]
+}
+
+// NURBS evaluates the rational form of BSpline, each control point pulling
+// the curve towards itself in proportion to its weight. With all weights
+// equal it matches BSpline.
+func NURBS(controlPoints []vector2.Vector2, weights []float32, degree int, t float32) vector2.Vector2 {
+	n := len(controlPoints)
+	if degree < 1 || n <= degree || len(weights) != n {
+		return vector2.Vector2{}
+	}
+	u, k := bsplineSpan(n, degree, t)
+
+	// run de Boor in homogeneous coordinates, then project back
+	type homogeneous struct{ x, y, w float32 }
+	d := make([]homogeneous, degree+1)
+	for j := range d {
+		p := controlPoints[k-degree+j]
+		w := weights[k-degree+j]
+		d[j] = homogeneous{p.X * w, p.Y * w, w}
+	}
+	for r := 1; r <= degree; r++ {
+		for j := degree; j >= r; j-- {
+			i := j + k - degree
+			alpha := (u - float32(i)) / float32(degree+1-r)
+			d[j] = homogeneous{
+				d[j-1].x + alpha*(d[j].x-d[j-1].x),
+				d[j-1].y + alpha*(d[j].y-d[j-1].y),
+				d[j-1].w + alpha*(d[j].w-d[j-1].w),
+			}
+		}
+	}
+	if d[degree].w == 0 {
+		return vector2.Vector2{}
+	}
+	return vector2.Vector2{X: d[degree].x / d[degree].w, Y: d[degree].y / d[degree].w}
+}
+
+// SampleBSpline evaluates BSpline at steps+1 evenly spaced values of t
+func SampleBSpline(controlPoints []vector2.Vector2, degree, steps int) []vector2.Vector2 {
+	if steps < 1 {
+		steps = 1
+	}
+	points := make([]vector2.Vector2, steps+1)
+	for i := range points {
+		points[i] = BSpline(controlPoints, degree, float32(i)/float32(steps))
+	}
+	return points
+}
@@ -0,0 +1,80 @@
+package bezier
+
+import (
+	"math"
+
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+// CatmullRomSpline is the curve through every one of Points, the tangent at
+// each being half the difference of its neighbours. A closed spline runs
+// from the last point back to the first, an open one repeats its end
+// points so it starts and ends on them.
+type CatmullRomSpline struct {
+	Points []vector2.Vector2
+	Closed bool
+}
+
+// segments is how many pieces of curve join the points
+func (s CatmullRomSpline) segments() int {
+	if s.Closed {
+		return len(s.Points)
+	}
+	return len(s.Points) - 1
+}
+
+// point is Points[i], wrapped around a closed spline and clamped to the
+// ends of an open one
+func (s CatmullRomSpline) point(i int) vector2.Vector2 {
+	n := len(s.Points)
+	if s.Closed {
+		return s.Points[(i%n+n)%n]
+	}
+	if i < 0 {
+		i = 0
+	} else if i > n-1 {
+		i = n - 1
+	}
+	return s.Points[i]
+}
+
+// Evaluate is the point t of the way along the spline, every segment
+// taking an equal share of t in [0, 1]. A closed spline wraps t around, so
+// it can be evaluated with a t that keeps growing. Without points it is the
+// zero vector.
+func (s CatmullRomSpline) Evaluate(t float32) vector2.Vector2 {
+	switch len(s.Points) {
+	case 0:
+		return vector2.Vector2{}
+	case 1:
+		return s.Points[0]
+	}
+	if s.Closed {
+		t -= float32(math.Floor(float64(t)))
+	} else if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	n := s.segments()
+	u := t * float32(n)
+	i := int(u)
+	if i > n-1 {
+		i = n - 1
+	}
+	return CatmullRom(s.point(i-1), s.point(i), s.point(i+1), s.point(i+2), u-float32(i))
+}
+
+// CatmullRom evaluates the segment from p1 to p2 at t in [0, 1], p0 and p3
+// being the points before and after it
+func CatmullRom(p0, p1, p2, p3 vector2.Vector2, t float32) vector2.Vector2 {
+	t2, t3 := t*t, t*t*t
+	b0 := -t3 + 2*t2 - t
+	b1 := 3*t3 - 5*t2 + 2
+	b2 := -3*t3 + 4*t2 + t
+	b3 := t3 - t2
+	return vector2.Vector2{
+		X: 0.5 * (b0*p0.X + b1*p1.X + b2*p2.X + b3*p3.X),
+		Y: 0.5 * (b0*p0.Y + b1*p1.Y + b2*p2.Y + b3*p3.Y),
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sabith-th/games_with_go/bezier"
+	"github.com/sabith-th/games_with_go/hull"
+	"github.com/sabith-th/games_with_go/vector2"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	maxControlPoints = 8
+	selectRadius     = 10
+	arcLengthSteps   = 100
+	saveFile         = "bezier.json"
+)
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	curveColor  = color{255, 255, 255}
+	handleColor = color{90, 90, 90}
+	pointColor  = color{255, 80, 40}
+	hullColor   = color{40, 200, 120}
+)
+
+type mouseState struct {
+	leftButton  bool
+	rightButton bool
+	x, y        int
+}
+
+func getMouseState() mouseState {
+	mouseX, mouseY, mouseButtonState := sdl.GetMouseState()
+	leftButton := mouseButtonState & sdl.ButtonLMask()
+	rightButton := mouseButtonState & sdl.ButtonRMask()
+	var result mouseState
+	result.x = int(mouseX)
+	result.y = int(mouseY)
+	result.leftButton = !(leftButton == 0)
+	result.rightButton = !(rightButton == 0)
+	return result
+}
+
+func clear(pixels []byte) {
+	for i := range pixels {
+		pixels[i] = 0
+	}
+}
+
+func setPixel(x, y int, c color, pixels []byte) {
+	if x < 0 || x >= winWidth || y < 0 || y >= winHeight {
+		return
+	}
+	index := (y*winWidth + x) * 4
+	pixels[index] = c.r
+	pixels[index+1] = c.g
+	pixels[index+2] = c.b
+}
+
+// drawLine draws a line between two points with Bresenham's algorithm
+func drawLine(a, b vector2.Vector2, c color, pixels []byte) {
+	x0, y0 := int(a.X), int(a.Y)
+	x1, y1 := int(b.X), int(b.Y)
+	dx := x1 - x0
+	if dx < 0 {
+		dx = -dx
+	}
+	dy := y1 - y0
+	if dy > 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		setPixel(x0, y0, c, pixels)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func drawPoint(p vector2.Vector2, c color, pixels []byte) {
+	for y := -2; y <= 2; y++ {
+		for x := -2; x <= 2; x++ {
+			setPixel(int(p.X)+x, int(p.Y)+y, c, pixels)
+		}
+	}
+}
+
+// curve is a chain of cubic segments sharing their end points, so it holds
+// 3n+1 control points for n segments
+type curve struct {
+	Points []vector2.Vector2 `json:"points"`
+}
+
+func newCurve() *curve {
+	return &curve{[]vector2.Vector2{{X: 150, Y: 400}, {X: 250, Y: 150}, {X: 450, Y: 150}, {X: 550, Y: 400}}}
+}
+
+func (c *curve) numSegments() int {
+	return (len(c.Points) - 1) / 3
+}
+
+func (c *curve) segment(i int) (p0, p1, p2, p3 vector2.Vector2) {
+	return c.Points[i*3], c.Points[i*3+1], c.Points[i*3+2], c.Points[i*3+3]
+}
+
+// addSegment appends a cubic segment that leaves the current end point in
+// the direction of its incoming tangent. It fails once the curve would go
+// over maxControlPoints.
+func (c *curve) addSegment() bool {
+	if len(c.Points)+3 > maxControlPoints {
+		return false
+	}
+	end := c.Points[len(c.Points)-1]
+	dir := vector2.Sub(end, c.Points[len(c.Points)-2])
+	p1 := vector2.Add(end, dir)
+	p2 := vector2.Add(p1, vector2.Vector2{X: dir.Y, Y: -dir.X})
+	p3 := vector2.Add(p2, dir)
+	c.Points = append(c.Points, p1, p2, p3)
+	return true
+}
+
+// nearest returns the index of the closest control point within radius of
+// pos, or -1 if there is none
+func (c *curve) nearest(pos vector2.Vector2, radius float32) int {
+	result := -1
+	best := radius * radius
+	for i, p := range c.Points {
+		d := vector2.DistanceSquared(p, pos)
+		if d <= best {
+			best = d
+			result = i
+		}
+	}
+	return result
+}
+
+func (c *curve) arcLength() float32 {
+	var length float32
+	for i := 0; i < c.numSegments(); i++ {
+		p0, p1, p2, p3 := c.segment(i)
+		length += bezier.ArcLength(p0, p1, p2, p3, arcLengthSteps)
+	}
+	return length
+}
+
+func drawPolyline(points []vector2.Vector2, c color, pixels []byte) {
+	for j := 1; j < len(points); j++ {
+		drawLine(points[j-1], points[j], c, pixels)
+	}
+}
+
+func (c *curve) draw(pixels []byte) {
+	for i := 0; i < c.numSegments(); i++ {
+		p0, p1, p2, p3 := c.segment(i)
+		drawLine(p0, p1, handleColor, pixels)
+		drawLine(p2, p3, handleColor, pixels)
+		drawPolyline(bezier.Flatten(p0, p1, p2, p3, 1), curveColor, pixels)
+	}
+	for _, p := range c.Points {
+		drawPoint(p, pointColor, pixels)
+	}
+}
+
+// drawBSpline renders the same control points as a uniform B-spline, with
+// the whole control polygon drawn since no point lies on the curve
+func (c *curve) drawBSpline(degree int, pixels []byte) {
+	var polygon float32
+	for i := 1; i < len(c.Points); i++ {
+		polygon += vector2.Distance(c.Points[i-1], c.Points[i])
+	}
+	drawPolyline(c.Points, handleColor, pixels)
+	drawPolyline(bezier.SampleBSpline(c.Points, degree, int(polygon)+1), curveColor, pixels)
+	for _, p := range c.Points {
+		drawPoint(p, pointColor, pixels)
+	}
+}
+
+// computeHull returns the convex hull of points with GrahamScan or GiftWrapping
+func computeHull(points []vector2.Vector2, graham bool) []vector2.Vector2 {
+	if graham {
+		return hull.GrahamScan(points)
+	}
+	return hull.GiftWrapping(points)
+}
+
+func drawHull(points []vector2.Vector2, pixels []byte) {
+	for i := range points {
+		drawLine(points[i], points[(i+1)%len(points)], hullColor, pixels)
+	}
+}
+
+func (c *curve) save(filename string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Bezier Editor", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	c := newCurve()
+	selected := -1
+	bspline := false
+	degree := 3
+	showHull := false
+	graham := true
+	currentMouseState := getMouseState()
+	prevMouseState := currentMouseState
+
+	for {
+		currentMouseState = getMouseState()
+
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 {
+					switch e.Keysym.Scancode {
+					case sdl.SCANCODE_A:
+						if !c.addSegment() {
+							fmt.Println("at most", maxControlPoints, "control points")
+						}
+					case sdl.SCANCODE_B:
+						bspline = !bspline
+					case sdl.SCANCODE_D:
+						degree = 5 - degree
+						fmt.Println("b-spline degree", degree)
+					case sdl.SCANCODE_H:
+						showHull = !showHull
+					case sdl.SCANCODE_G:
+						graham = !graham
+						startTime := time.Now()
+						computeHull(c.Points, graham)
+						elapsedTime := time.Since(startTime).Seconds() * 1000.0
+						if graham {
+							fmt.Println("graham scan:", elapsedTime, "ms")
+						} else {
+							fmt.Println("gift wrapping:", elapsedTime, "ms")
+						}
+					case sdl.SCANCODE_S:
+						err := c.save(saveFile)
+						if err != nil {
+							fmt.Println(err)
+						} else {
+							fmt.Println("saved", saveFile)
+						}
+					}
+				}
+			}
+		}
+
+		mousePos := vector2.Vector2{X: float32(currentMouseState.x), Y: float32(currentMouseState.y)}
+		if currentMouseState.leftButton && !prevMouseState.leftButton {
+			selected = c.nearest(mousePos, selectRadius)
+		} else if !currentMouseState.leftButton && prevMouseState.leftButton && selected >= 0 {
+			fmt.Println("arc length:", c.arcLength())
+			selected = -1
+		}
+		if selected >= 0 {
+			c.Points[selected] = mousePos
+		}
+
+		clear(pixels)
+		if bspline {
+			c.drawBSpline(degree, pixels)
+		} else {
+			c.draw(pixels)
+		}
+		if showHull {
+			drawHull(computeHull(c.Points, graham), pixels)
+		}
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+
+		prevMouseState = currentMouseState
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sabith-th/games_with_go/config"
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+// the window is the brick zone, the hud above it and room below for the
+// paddle
+const winWidth, winHeight = 800, 640
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	black = color{0, 0, 0}
+	white = color{255, 255, 255}
+	// brickColors are by hit points left
+	brickColors = [maxHitPoints + 1]color{{}, {80, 200, 120}, {240, 190, 60}, {220, 70, 60}}
+	// powerColors and powerLetters mark the falling power ups
+	powerColors  = [numPowers]color{{90, 140, 255}, {230, 230, 230}, {255, 80, 80}, {170, 110, 230}}
+	powerLetters = [numPowers]string{"W", "M", "L", "S"}
+	bulletColor  = color{255, 240, 120}
+)
+
+func fillRect(x, y, w, h int, c color, pixels []byte, pw, ph int) {
+	for py := y; py < y+h; py++ {
+		if py < 0 || py >= ph {
+			continue
+		}
+		for px := x; px < x+w; px++ {
+			if px < 0 || px >= pw {
+				continue
+			}
+			i := (py*pw + px) * 4
+			pixels[i], pixels[i+1], pixels[i+2] = c.r, c.g, c.b
+		}
+	}
+}
+
+func fillCircle(cx, cy, r int, c color, pixels []byte, pw, ph int) {
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			if x*x+y*y <= r*r {
+				fillRect(cx+x, cy+y, 1, 1, c, pixels, pw, ph)
+			}
+		}
+	}
+}
+
+func text(s string, x, y, scale int, c color, pixels []byte, w, h int) {
+	font.Draw(s, x, y, scale, font.Color{R: c.r, G: c.g, B: c.b}, pixels, w, h)
+}
+
+func centredText(s string, y, scale int, c color, pixels []byte, w, h int) {
+	tw, _ := font.Size(s, scale)
+	text(s, (w-tw)/2, y, scale, c, pixels, w, h)
+}
+
+// breakout plays games one after another, keeping the best score in
+// scorePath
+type breakout struct {
+	world     *world
+	highScore int
+	scorePath string
+	quit      bool
+}
+
+// record keeps the score if it is a new best, saving it straight away so a
+// crash does not lose it
+func (b *breakout) record() {
+	if b.world.score <= b.highScore {
+		return
+	}
+	b.highScore = b.world.score
+	err := saveHighScore(b.scorePath, b.highScore)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (b *breakout) Update(in gfx.Input, dt float64) {
+	b.quit = in.Pressed(gfx.KeyEscape)
+	click := in.Clicked&gfx.MouseLeft != 0
+	if b.world.over {
+		if click {
+			b.world = newWorld(time.Now().UnixNano())
+		}
+		return
+	}
+	b.world.update(float32(dt), in.MouseX, click)
+	if b.world.over {
+		b.record()
+	}
+}
+
+func (b *breakout) Draw(pixels []byte, w, h int) {
+	fillRect(0, 0, w, h, black, pixels, w, h)
+	wd := b.world
+	for r := range wd.bricks {
+		for c, hp := range wd.bricks[r] {
+			if hp > 0 {
+				fillRect(c*brickWidth+1, zoneTop+r*brickHeight+1, brickWidth-2, brickHeight-2, brickColors[hp], pixels, w, h)
+			}
+		}
+	}
+
+	px, pw := int(wd.paddleX), int(wd.paddleWidth())
+	fillRect(px, paddleY, pw, paddleHeight, white, pixels, w, h)
+	if wd.effects[laser] > 0 {
+		fillRect(px, paddleY-4, 8, paddleHeight+4, powerColors[laser], pixels, w, h)
+		fillRect(px+pw-8, paddleY-4, 8, paddleHeight+4, powerColors[laser], pixels, w, h)
+	}
+	for _, bl := range wd.balls {
+		fillCircle(int(bl.pos.X), int(bl.pos.Y), ballRadius, white, pixels, w, h)
+	}
+	for _, bu := range wd.bullets {
+		fillRect(int(bu.X), int(bu.Y), bulletWidth, bulletHeight, bulletColor, pixels, w, h)
+	}
+	for _, p := range wd.powerUps {
+		x, y := int(p.pos.X), int(p.pos.Y)
+		fillRect(x, y, powerUpWidth, powerUpHeight, powerColors[p.kind], pixels, w, h)
+		lw, lh := font.Size(powerLetters[p.kind], 1)
+		text(powerLetters[p.kind], x+(powerUpWidth-lw)/2, y+(powerUpHeight-lh)/2, 1, black, pixels, w, h)
+	}
+
+	hud := fmt.Sprintf("score %d  best %d  lives %d  level %d", wd.score, b.highScore, wd.lives, wd.level)
+	text(hud, 10, 12, 2, white, pixels, w, h)
+	switch {
+	case wd.over:
+		centredText("game over", zoneTop+zoneHeight+40, 4, white, pixels, w, h)
+		centredText("click to play again", zoneTop+zoneHeight+90, 2, white, pixels, w, h)
+	case len(wd.balls) > 0 && wd.balls[0].stuck:
+		centredText("click to launch", zoneTop+zoneHeight+60, 2, white, pixels, w, h)
+	}
+}
+
+func (b *breakout) Done() bool {
+	return b.quit
+}
+
+// loadHighScore reads the score saved by saveHighScore, a missing file is
+// a high score of 0
+func loadHighScore(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	score, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || score < 0 {
+		return 0, fmt.Errorf("%s: not a high score", path)
+	}
+	return score, nil
+}
+
+func saveHighScore(path string, score int) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(score)+"\n"), 0644)
+}
+
+// defaultScorePath is where the high score is kept, in the user's config
+// directory if there is one
+func defaultScorePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gameswithgo", "breakout-highscore")
+}
+
+func main() {
+	scorePath := flag.String("highscore", defaultScorePath(), "keep the high score in this file")
+	configFile := flag.String("config", "", "read flags not given on the command line from this JSON file")
+	flag.Parse()
+	if *configFile != "" {
+		err := config.LoadConfig(flag.CommandLine, *configFile)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	high, err := loadHighScore(*scorePath)
+	if err != nil {
+		fmt.Println(err)
+	}
+	b := &breakout{world: newWorld(time.Now().UnixNano()), highScore: high, scorePath: *scorePath}
+	err = gfx.Run("Breakout", winWidth, winHeight, b)
+	if err != nil {
+		fmt.Println(err)
+	}
+	// a game quit part way through can still have set a new best
+	b.record()
+}
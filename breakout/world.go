@@ -0,0 +1,438 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+const (
+	cols, rows = 10, 8
+	// the bricks fill a zoneWidth*zoneHeight zone below the hud
+	zoneTop                       = 40
+	zoneWidth, zoneHeight         = 800, 400
+	brickWidth                    = zoneWidth / cols
+	brickHeight                   = zoneHeight / rows
+	paddleY                       = winHeight - 40
+	basePaddleWidth               = 100
+	paddleHeight                  = 12
+	ballRadius                    = 6
+	ballSpeed                     = 420 // pixels per second
+	maxHitPoints                  = 3
+	startLives                    = 3
+	pointsPerHit                  = 10
+	powerUpChance                 = 0.15
+	powerUpWidth                  = 40
+	powerUpHeight                 = 14
+	powerUpSpeed                  = 150
+	bulletSpeed                   = 600
+	bulletWidth                   = 3
+	bulletHeight                  = 10
+	laserCooldown         float32 = 0.25
+	effectTime            float32 = 12
+	wideFactor                    = 1.6
+	slowFactor                    = 0.6
+	// brickChance is how likely each place in the grid gets a brick
+	brickChance = 0.85
+)
+
+// maxBounce is the angle from straight up the ball leaves the very end of
+// the paddle at, the middle sends it straight up
+var maxBounce = float32(60 * math.Pi / 180)
+
+// maxSpin is the most spin a moving paddle gives the ball, spinPerSpeed
+// is how much it gives for each pixel per second the paddle moves at. The
+// spin is added to the angle the ball leaves the paddle at.
+var (
+	maxSpin      = float32(45 * math.Pi / 180)
+	spinPerSpeed = maxSpin / 1000
+)
+
+// maxExit is the furthest from straight up the ball leaves the paddle at,
+// however it hit and spun, and the furthest spin curves it to
+var maxExit = float32(75 * math.Pi / 180)
+
+const (
+	// spinDecay is what is left of the spin after every frame
+	spinDecay = 0.95
+	// spinCurve is how fast spin turns the ball, in radians a second for
+	// each radian of spin
+	spinCurve = 1.0
+)
+
+// multiBallSpread is the angle the two extra balls of Multi-Ball leave
+// either side of each ball at
+var multiBallSpread = float32(20 * math.Pi / 180)
+
+type powerKind int
+
+const (
+	widePaddle powerKind = iota
+	multiBall
+	laser
+	slowBall
+	numPowers
+)
+
+// ball moves at ballSpeed, times slowFactor while Slow Ball is on, in the
+// direction of dir. spinAngle is the spin the paddle gave it, positive is
+// clockwise and curves it that way. A stuck ball rides on the paddle
+// until it is launched.
+type ball struct {
+	pos, dir  vector2.Vector2
+	spinAngle float32
+	stuck     bool
+}
+
+// powerUp falls from a destroyed brick until the paddle catches it
+type powerUp struct {
+	kind powerKind
+	pos  vector2.Vector2
+}
+
+// world is one game: the level's bricks, by row and column with their
+// hit points left, 0 where there is none, and everything moving
+type world struct {
+	rng    *rand.Rand
+	level  int
+	bricks [rows][cols]int
+	// left counts the bricks with hit points left
+	left int
+
+	// paddleVel is how fast the paddle moved over the last frame, in
+	// pixels a second, positive to the right
+	paddleX   float32
+	paddleVel float32
+	balls     []ball
+	powerUps  []powerUp
+	bullets   []vector2.Vector2
+	// effects are the seconds each timed power up has left, the laser can
+	// fire again once cooldown runs out
+	effects  [numPowers]float32
+	cooldown float32
+
+	score, lives int
+	over         bool
+}
+
+func newWorld(seed int64) *world {
+	w := &world{rng: rand.New(rand.NewSource(seed)), lives: startLives, paddleX: (winWidth - basePaddleWidth) / 2}
+	w.startLevel(1)
+	return w
+}
+
+// startLevel lays out a random level. Level n has bricks of up to n hit
+// points, at most maxHitPoints.
+func (w *world) startLevel(level int) {
+	w.level = level
+	top := level
+	if top > maxHitPoints {
+		top = maxHitPoints
+	}
+	w.left = 0
+	for w.left == 0 {
+		for r := range w.bricks {
+			for c := range w.bricks[r] {
+				w.bricks[r][c] = 0
+				if w.rng.Float64() < brickChance {
+					w.bricks[r][c] = 1 + w.rng.Intn(top)
+					w.left++
+				}
+			}
+		}
+	}
+	w.resetBall()
+}
+
+// resetBall puts a single ball on the paddle and takes away the power ups
+func (w *world) resetBall() {
+	w.balls = append(w.balls[:0], ball{pos: w.onPaddle(), stuck: true})
+	w.powerUps = w.powerUps[:0]
+	w.bullets = w.bullets[:0]
+	w.effects = [numPowers]float32{}
+}
+
+func (w *world) paddleWidth() float32 {
+	if w.effects[widePaddle] > 0 {
+		return basePaddleWidth * wideFactor
+	}
+	return basePaddleWidth
+}
+
+// onPaddle is where a ball rests on the middle of the paddle
+func (w *world) onPaddle() vector2.Vector2 {
+	return vector2.Vector2{X: w.paddleX + w.paddleWidth()/2, Y: paddleY - ballRadius}
+}
+
+func (w *world) speed() float32 {
+	if w.effects[slowBall] > 0 {
+		return ballSpeed * slowFactor
+	}
+	return ballSpeed
+}
+
+func clamp(min, max, v float32) float32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// fromAngle is the direction angle radians clockwise from straight up
+func fromAngle(angle float32) vector2.Vector2 {
+	s, c := math.Sincos(float64(angle))
+	return vector2.Vector2{X: float32(s), Y: float32(-c)}
+}
+
+// rotate turns v angle radians clockwise
+func rotate(v vector2.Vector2, angle float32) vector2.Vector2 {
+	s, c := math.Sincos(float64(angle))
+	return vector2.Vector2{
+		X: v.X*float32(c) - v.Y*float32(s),
+		Y: v.X*float32(s) + v.Y*float32(c),
+	}
+}
+
+// update moves the world on by dt seconds with the paddle centred on
+// mouseX. fire launches the balls on the paddle, or fires the laser.
+func (w *world) update(dt float32, mouseX int, fire bool) {
+	if w.over {
+		return
+	}
+	for i := range w.effects {
+		w.effects[i] -= dt
+	}
+	w.cooldown -= dt
+	pw := w.paddleWidth()
+	x := clamp(0, winWidth-pw, float32(mouseX)-pw/2)
+	if dt > 0 {
+		w.paddleVel = (x - w.paddleX) / dt
+	}
+	w.paddleX = x
+
+	if fire {
+		w.fire()
+	}
+	w.moveBalls(dt)
+	w.moveBullets(dt)
+	w.movePowerUps(dt)
+
+	if len(w.balls) == 0 {
+		w.lives--
+		if w.lives == 0 {
+			w.over = true
+			return
+		}
+		w.resetBall()
+	}
+	if w.left == 0 {
+		w.startLevel(w.level + 1)
+	}
+}
+
+// fire launches any ball on the paddle at a small random angle, or if
+// there is none fires a bullet from each end of the paddle
+func (w *world) fire() {
+	launched := false
+	for i := range w.balls {
+		if w.balls[i].stuck {
+			w.balls[i].stuck = false
+			w.balls[i].dir = fromAngle((w.rng.Float32()*2 - 1) * maxBounce / 3)
+			launched = true
+		}
+	}
+	if launched || w.effects[laser] <= 0 || w.cooldown > 0 {
+		return
+	}
+	w.cooldown = laserCooldown
+	y := float32(paddleY - bulletHeight)
+	w.bullets = append(w.bullets,
+		vector2.Vector2{X: w.paddleX + 4, Y: y},
+		vector2.Vector2{X: w.paddleX + w.paddleWidth() - 4 - bulletWidth, Y: y})
+}
+
+// moveBalls moves every ball in steps of at most its radius, so it cannot
+// pass through a brick or the paddle between two frames. Balls that fall
+// past the bottom are gone.
+func (w *world) moveBalls(dt float32) {
+	distance := w.speed() * dt
+	steps := int(math.Ceil(float64(distance / ballRadius)))
+	kept := w.balls[:0]
+	for _, b := range w.balls {
+		if b.stuck {
+			b.pos = w.onPaddle()
+			kept = append(kept, b)
+			continue
+		}
+		b.curve(dt)
+		for i := 0; i < steps; i++ {
+			b.pos = vector2.Add(b.pos, vector2.Mult(b.dir, distance/float32(steps)))
+			w.bounce(&b)
+		}
+		if b.pos.Y-ballRadius < winHeight {
+			kept = append(kept, b)
+		}
+	}
+	w.balls = kept
+}
+
+// bounce turns b back off the walls, the paddle and the first brick it
+// overlaps
+func (w *world) bounce(b *ball) {
+	if b.pos.X < ballRadius {
+		b.pos.X, b.dir.X = ballRadius, abs(b.dir.X)
+	} else if b.pos.X > winWidth-ballRadius {
+		b.pos.X, b.dir.X = winWidth-ballRadius, -abs(b.dir.X)
+	}
+	if b.pos.Y < zoneTop+ballRadius {
+		b.pos.Y, b.dir.Y = zoneTop+ballRadius, abs(b.dir.Y)
+	}
+
+	// off the paddle the angle depends on where it hit and how fast the
+	// paddle was moving, not on the angle it came in at. A paddle moving
+	// right spins the ball clockwise, so it leaves further right.
+	pw := w.paddleWidth()
+	if b.dir.Y > 0 && b.pos.Y+ballRadius >= paddleY && b.pos.Y < paddleY+paddleHeight &&
+		b.pos.X >= w.paddleX-ballRadius && b.pos.X <= w.paddleX+pw+ballRadius {
+		offset := clamp(-1, 1, (b.pos.X-(w.paddleX+pw/2))/(pw/2))
+		b.spinAngle = clamp(-maxSpin, maxSpin, w.paddleVel*spinPerSpeed)
+		b.dir = fromAngle(clamp(-maxExit, maxExit, offset*maxBounce+b.spinAngle))
+		b.pos.Y = paddleY - ballRadius
+		return
+	}
+
+	c0, c1 := int((b.pos.X-ballRadius)/brickWidth), int((b.pos.X+ballRadius)/brickWidth)
+	r0, r1 := int((b.pos.Y-ballRadius-zoneTop)/brickHeight), int((b.pos.Y+ballRadius-zoneTop)/brickHeight)
+	for r := r0; r <= r1; r++ {
+		for c := c0; c <= c1; c++ {
+			if r < 0 || r >= rows || c < 0 || c >= cols || w.bricks[r][c] == 0 {
+				continue
+			}
+			// the closest point of the brick to the ball decides the side it
+			// hit, a corner counts as the side the ball is further past
+			x0, y0 := float32(c*brickWidth), float32(zoneTop+r*brickHeight)
+			closest := vector2.Vector2{X: clamp(x0, x0+brickWidth, b.pos.X), Y: clamp(y0, y0+brickHeight, b.pos.Y)}
+			n := vector2.Sub(b.pos, closest)
+			if vector2.Dot(n, n) > ballRadius*ballRadius {
+				continue
+			}
+			if abs(n.X) > abs(n.Y) {
+				b.dir.X = sign(n.X) * abs(b.dir.X)
+			} else if n.Y != 0 {
+				b.dir.Y = sign(n.Y) * abs(b.dir.Y)
+			} else {
+				// the centre is inside the brick, send it back the way it came
+				b.dir = vector2.Mult(b.dir, -1)
+			}
+			w.hit(r, c)
+			return
+		}
+	}
+}
+
+// curve turns b by its spin for dt seconds, unless that would take it
+// further than maxExit from straight up or down, and lets the spin decay
+func (b *ball) curve(dt float32) {
+	turn := b.spinAngle * spinCurve * dt
+	b.spinAngle *= spinDecay
+	if turn == 0 {
+		return
+	}
+	dir := rotate(b.dir, turn)
+	if abs(dir.Y) >= float32(math.Cos(float64(maxExit))) {
+		b.dir = dir
+	}
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v float32) float32 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+// hit takes a hit point off brick r, c, a destroyed brick can drop a power
+// up
+func (w *world) hit(r, c int) {
+	w.bricks[r][c]--
+	w.score += pointsPerHit
+	if w.bricks[r][c] > 0 {
+		return
+	}
+	w.left--
+	if w.rng.Float64() < powerUpChance {
+		w.powerUps = append(w.powerUps, powerUp{
+			kind: powerKind(w.rng.Intn(int(numPowers))),
+			pos: vector2.Vector2{
+				X: float32(c*brickWidth + (brickWidth-powerUpWidth)/2),
+				Y: float32(zoneTop + r*brickHeight + (brickHeight-powerUpHeight)/2),
+			},
+		})
+	}
+}
+
+// moveBullets moves the laser's bullets up, each one is used up by the
+// first brick it reaches
+func (w *world) moveBullets(dt float32) {
+	kept := w.bullets[:0]
+	for _, b := range w.bullets {
+		b.Y -= bulletSpeed * dt
+		if b.Y+bulletHeight < zoneTop {
+			continue
+		}
+		r, c := int((b.Y-zoneTop)/brickHeight), int((b.X+bulletWidth/2)/brickWidth)
+		if b.Y >= zoneTop && r < rows && c >= 0 && c < cols && w.bricks[r][c] > 0 {
+			w.hit(r, c)
+			continue
+		}
+		kept = append(kept, b)
+	}
+	w.bullets = kept
+}
+
+// movePowerUps lets the power ups fall, one that lands on the paddle takes
+// effect and one that misses it is gone
+func (w *world) movePowerUps(dt float32) {
+	kept := w.powerUps[:0]
+	pw := w.paddleWidth()
+	for _, p := range w.powerUps {
+		p.pos.Y += powerUpSpeed * dt
+		if p.pos.Y+powerUpHeight >= paddleY && p.pos.Y <= paddleY+paddleHeight &&
+			p.pos.X+powerUpWidth >= w.paddleX && p.pos.X <= w.paddleX+pw {
+			w.apply(p.kind)
+			continue
+		}
+		if p.pos.Y < winHeight {
+			kept = append(kept, p)
+		}
+	}
+	w.powerUps = kept
+}
+
+// apply starts a power up, catching one that is already on starts its
+// time again. Multi-Ball adds two balls beside every ball in play.
+func (w *world) apply(kind powerKind) {
+	if kind != multiBall {
+		w.effects[kind] = effectTime
+		return
+	}
+	for _, b := range w.balls {
+		if b.stuck {
+			continue
+		}
+		for _, turn := range []float32{-multiBallSpread, multiBallSpread} {
+			w.balls = append(w.balls, ball{pos: b.pos, dir: rotate(b.dir, turn), spinAngle: b.spinAngle})
+		}
+	}
+}
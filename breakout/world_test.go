@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+// hitPaddle drops a ball straight down onto the middle of a paddle moving
+// at vel and returns it as it leaves
+func hitPaddle(vel float32) ball {
+	w := newWorld(1)
+	w.paddleVel = vel
+	b := ball{
+		pos: vector2.Vector2{X: w.paddleX + basePaddleWidth/2, Y: paddleY - ballRadius + 1},
+		dir: vector2.Vector2{X: 0, Y: 1},
+	}
+	w.bounce(&b)
+	return b
+}
+
+func TestPaddleSpin(t *testing.T) {
+	still := hitPaddle(0)
+	if still.dir.Y >= 0 {
+		t.Fatalf("ball did not bounce off the paddle: %+v", still)
+	}
+	if still.spinAngle != 0 || abs(still.dir.X) > 1e-6 {
+		t.Fatalf("a still paddle spun the ball: %+v", still)
+	}
+	right := hitPaddle(300)
+	if right.spinAngle <= 0 || right.dir.X <= still.dir.X {
+		t.Errorf("a paddle moving right left the ball at %v with spin %v, want right of %v", right.dir, right.spinAngle, still.dir)
+	}
+	left := hitPaddle(-300)
+	if left.spinAngle >= 0 || left.dir.X >= still.dir.X {
+		t.Errorf("a paddle moving left left the ball at %v with spin %v, want left of %v", left.dir, left.spinAngle, still.dir)
+	}
+	if d := right.dir.Length(); abs(d-1) > 1e-5 {
+		t.Errorf("spin changed the ball's speed, direction has length %v", d)
+	}
+}
+
+func TestPaddleSpinCapped(t *testing.T) {
+	for _, vel := range []float32{1e5, -1e5} {
+		b := hitPaddle(vel)
+		if abs(b.spinAngle) != maxSpin {
+			t.Errorf("paddle at %v spun the ball by %v, want at most %v", vel, b.spinAngle, maxSpin)
+		}
+		want := fromAngle(sign(vel) * maxSpin)
+		if abs(b.dir.X-want.X) > 1e-5 || abs(b.dir.Y-want.Y) > 1e-5 {
+			t.Errorf("paddle at %v left the ball at %v, want %v", vel, b.dir, want)
+		}
+	}
+}
+
+func TestSpinDecays(t *testing.T) {
+	b := ball{dir: fromAngle(0), spinAngle: 0.5}
+	b.curve(1.0 / 60)
+	if b.spinAngle != 0.5*spinDecay {
+		t.Errorf("spin after a frame is %v, want %v", b.spinAngle, 0.5*spinDecay)
+	}
+	if b.dir.X <= 0 {
+		t.Errorf("clockwise spin did not curve a rising ball right: %v", b.dir)
+	}
+}
@@ -0,0 +1,97 @@
+package caves
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/sabith-th/games_with_go/noise"
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+// noiseSpread is how far apart in noise space different seeds start, far
+// enough that two worms never read the same part of the field
+const noiseSpread = 10000
+
+// WormGenerator walks a Perlin worm: at every step it reads simplex noise at
+// its position and turns that into its direction of travel. Since the noise
+// changes slowly over space the path curves smoothly.
+type WormGenerator struct {
+	Position vector2.Vector2
+	// Heading is the direction in radians the worm drifts in on average
+	Heading float32
+	// Turn is the largest angle in radians the noise can steer the worm away
+	// from Heading. Below pi/2 the worm always keeps moving forward and
+	// never curls up on itself.
+	Turn float32
+	// Frequency scales positions before sampling the noise, lower values
+	// give longer, gentler curves
+	Frequency float32
+	// Step is the distance moved per step
+	Step float32
+
+	offset vector2.Vector2
+}
+
+// NewWormGenerator returns a worm starting at start. The seed picks the
+// heading and the part of the noise field it follows, so worms with
+// different seeds carve different tunnels from the same point.
+func NewWormGenerator(start vector2.Vector2, seed int64) *WormGenerator {
+	rng := rand.New(rand.NewSource(seed))
+	return &WormGenerator{
+		Position:  start,
+		Heading:   rng.Float32() * 2 * math.Pi,
+		Turn:      1.2,
+		Frequency: 0.02,
+		Step:      1,
+		offset:    vector2.Vector2{X: rng.Float32() * noiseSpread, Y: rng.Float32() * noiseSpread},
+	}
+}
+
+// Walk moves the worm steps times and returns every position on the way,
+// starting with the current one. The worm continues from the end on the
+// next call.
+func (w *WormGenerator) Walk(steps int) []vector2.Vector2 {
+	path := make([]vector2.Vector2, 0, steps+1)
+	path = append(path, w.Position)
+	for i := 0; i < steps; i++ {
+		n := noise.Simplex{}.At((w.Position.X+w.offset.X)*w.Frequency, (w.Position.Y+w.offset.Y)*w.Frequency)
+		angle := float64(w.Heading + n*w.Turn)
+		dir := vector2.Vector2{X: float32(math.Cos(angle)), Y: float32(math.Sin(angle))}
+		w.Position = vector2.Add(w.Position, vector2.Mult(dir, w.Step))
+		path = append(path, w.Position)
+	}
+	return path
+}
+
+// CarveTunnel sets every cell of grid, indexed grid[y][x], within radius of
+// a point on path to true. Points off the grid only carve the cells that
+// are on it.
+func CarveTunnel(grid [][]bool, path []vector2.Vector2, radius int) {
+	r2 := radius * radius
+	for _, p := range path {
+		cx, cy := int(math.Round(float64(p.X))), int(math.Round(float64(p.Y)))
+		for y := cy - radius; y <= cy+radius; y++ {
+			if y < 0 || y >= len(grid) {
+				continue
+			}
+			for x := cx - radius; x <= cx+radius; x++ {
+				if x < 0 || x >= len(grid[y]) {
+					continue
+				}
+				dx, dy := x-cx, y-cy
+				if dx*dx+dy*dy <= r2 {
+					grid[y][x] = true
+				}
+			}
+		}
+	}
+}
+
+// NewGrid returns a w*h grid with every cell solid
+func NewGrid(w, h int) [][]bool {
+	grid := make([][]bool, h)
+	for y := range grid {
+		grid[y] = make([]bool, w)
+	}
+	return grid
+}
@@ -0,0 +1,171 @@
+// Package chase is enemies hunting the player through a tile map. A chaser
+// goes straight for the player while it can see them, and one that heard a
+// gunshot finds its way round the walls to where the shot came from.
+//
+// The map is a grid of blocked tiles, grid[y][x] true for a tile that can
+// neither be seen nor walked through. Blocked turns a level of minimap tiles
+// into one.
+package chase
+
+import "github.com/sabith-th/games_with_go/minimap"
+
+// DefaultHearing is how many tiles away NewChaser's chasers hear a shot
+const DefaultHearing = 12
+
+// Blocked is the grid of level, level[y][x], with walls, closed doors and
+// the blank space around the level blocked
+func Blocked(level [][]minimap.TileType) [][]bool {
+	grid := make([][]bool, len(level))
+	for y, row := range level {
+		grid[y] = make([]bool, len(row))
+		for x, t := range row {
+			switch t {
+			case minimap.Blank, minimap.StoneWall, minimap.ClosedDoor:
+				grid[y][x] = true
+			}
+		}
+	}
+	return grid
+}
+
+// blocked reports whether p is blocked, everything off the grid is
+func blocked(grid [][]bool, p minimap.Point) bool {
+	if p.Y < 0 || p.Y >= len(grid) || p.X < 0 || p.X >= len(grid[p.Y]) {
+		return true
+	}
+	return grid[p.Y][p.X]
+}
+
+// State is what a chaser is doing
+type State int
+
+const (
+	// Idle chasers stay where they are
+	Idle State = iota
+	// Alerted chasers heard the player, or lost sight of them, and walk
+	// to where they last knew them to be
+	Alerted
+	// Chasing chasers can see the player and head straight for them
+	Chasing
+)
+
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Alerted:
+		return "alerted"
+	case Chasing:
+		return "chasing"
+	}
+	return "unknown"
+}
+
+// Chaser is one enemy. The game moves it a tile at a time with Update, as
+// often as it wants it to move, and tells it about shots with Hear.
+type Chaser struct {
+	Pos   minimap.Point
+	State State
+	// LastKnown is where the player was last seen or heard, where an
+	// alerted chaser is going
+	LastKnown minimap.Point
+	// Hearing is how many tiles away a shot is heard, through walls too
+	Hearing int
+
+	// path is the way to LastKnown, from the next tile on
+	path []minimap.Point
+}
+
+// NewChaser returns an idle chaser at pos that hears DefaultHearing tiles
+func NewChaser(pos minimap.Point) *Chaser {
+	return &Chaser{Pos: pos, Hearing: DefaultHearing}
+}
+
+// Hear tells c about a shot at shot, it reports whether c heard it. A
+// chaser that hears it and cannot already see the player goes to look.
+func (c *Chaser) Hear(shot minimap.Point) bool {
+	dx, dy := shot.X-c.Pos.X, shot.Y-c.Pos.Y
+	if dx*dx+dy*dy > c.Hearing*c.Hearing {
+		return false
+	}
+	if c.State != Chasing {
+		c.State = Alerted
+		c.LastKnown = shot
+	}
+	return true
+}
+
+// Update moves c a tile. With the player in sight it chases them, stopping
+// next to them, without it an alerted chaser follows a path to LastKnown.
+// A chaser that gets there, or finds no way there, gives up and idles.
+func (c *Chaser) Update(grid [][]bool, player minimap.Point) {
+	if RaycastLOS(grid, c.Pos, player) {
+		c.State = Chasing
+		c.LastKnown = player
+		c.path = nil
+		c.Pos = c.chaseStep(grid, player)
+		return
+	}
+	if c.State == Chasing {
+		// out of sight, the player was last seen where LastKnown is
+		c.State = Alerted
+	}
+	if c.State != Alerted {
+		return
+	}
+	if c.Pos == c.LastKnown {
+		c.State = Idle
+		return
+	}
+	// the path is planned again when the target moved or a door shut on it
+	if len(c.path) == 0 || c.path[len(c.path)-1] != c.LastKnown || blocked(grid, c.path[0]) {
+		c.path = FindPath(grid, c.Pos, c.LastKnown)
+		if c.path == nil {
+			c.State = Idle
+			return
+		}
+	}
+	c.Pos, c.path = c.path[0], c.path[1:]
+}
+
+// chaseStep is where c moves to get at player, who is in sight: along the
+// axis the player is furthest away on, or the other if that is blocked,
+// and round by a path if both are. Next to the player c stays put.
+func (c *Chaser) chaseStep(grid [][]bool, player minimap.Point) minimap.Point {
+	dx, dy := player.X-c.Pos.X, player.Y-c.Pos.Y
+	if abs(dx)+abs(dy) <= 1 {
+		return c.Pos
+	}
+	across := minimap.Point{X: c.Pos.X + sign(dx), Y: c.Pos.Y}
+	down := minimap.Point{X: c.Pos.X, Y: c.Pos.Y + sign(dy)}
+	steps := []minimap.Point{across, down}
+	if abs(dy) > abs(dx) {
+		steps = []minimap.Point{down, across}
+	}
+	for _, p := range steps {
+		if p != c.Pos && !blocked(grid, p) {
+			return p
+		}
+	}
+	if path := FindPath(grid, c.Pos, player); len(path) > 1 {
+		return path[0]
+	}
+	return c.Pos
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+package chase
+
+import "github.com/sabith-th/games_with_go/minimap"
+
+// RaycastLOS reports whether to can be seen from from: whether the line
+// from the middle of one tile to the middle of the other crosses no
+// blocked tile. The two tiles themselves are not looked at. It marches the
+// line a tile at a time, DDA style, always into the tile whose edge the
+// line meets first. A line through the corner where four tiles meet is
+// blocked if either tile beside it is, so nothing is seen through the gap
+// between two walls touching at their corners, and the answer is the same
+// both ways.
+func RaycastLOS(grid [][]bool, from, to minimap.Point) bool {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	ax, ay := abs(dx), abs(dy)
+	sx, sy := sign(dx), sign(dy)
+	// i and j are how many tile edges the line has crossed across and
+	// down. Starting mid tile it meets edge i across at t=(1+2i)/(2ax) of
+	// the way, so comparing (1+2i)*ay with (1+2j)*ax says which edge comes
+	// first without any rounding.
+	i, j := 0, 0
+	p := from
+	for p != to {
+		across, down := (1+2*i)*ay, (1+2*j)*ax
+		switch {
+		case across < down:
+			p.X += sx
+			i++
+		case across > down:
+			p.Y += sy
+			j++
+		default:
+			if blocked(grid, minimap.Point{X: p.X + sx, Y: p.Y}) || blocked(grid, minimap.Point{X: p.X, Y: p.Y + sy}) {
+				return false
+			}
+			p.X += sx
+			p.Y += sy
+			i++
+			j++
+		}
+		if p != to && blocked(grid, p) {
+			return false
+		}
+	}
+	return true
+}
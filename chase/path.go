@@ -0,0 +1,79 @@
+package chase
+
+import (
+	"container/heap"
+
+	"github.com/sabith-th/games_with_go/minimap"
+)
+
+// neighbours are the four tiles a chaser can step to
+var neighbours = []minimap.Point{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}}
+
+// openTile is a tile waiting to be looked at, cost is the steps to it plus
+// the fewest it could still take to the target
+type openTile struct {
+	p    minimap.Point
+	cost int
+}
+
+// openSet is the A* frontier, cheapest first
+type openSet []openTile
+
+func (s openSet) Len() int            { return len(s) }
+func (s openSet) Less(i, j int) bool  { return s[i].cost < s[j].cost }
+func (s openSet) Swap(i, j int)       { s[i], s[j] = s[j], s[i] }
+func (s *openSet) Push(x interface{}) { *s = append(*s, x.(openTile)) }
+func (s *openSet) Pop() interface{} {
+	old := *s
+	t := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return t
+}
+
+// FindPath is a shortest way from from to to a step at a time across and
+// down, found by A* with the tiles between them as its guide. It starts
+// with the first step and ends on to, nil if to cannot be reached or is
+// from.
+func FindPath(grid [][]bool, from, to minimap.Point) []minimap.Point {
+	if from == to || blocked(grid, to) {
+		return nil
+	}
+	guess := func(p minimap.Point) int {
+		return abs(to.X-p.X) + abs(to.Y-p.Y)
+	}
+	steps := map[minimap.Point]int{from: 0}
+	came := map[minimap.Point]minimap.Point{}
+	open := &openSet{{from, guess(from)}}
+	for open.Len() > 0 {
+		t := heap.Pop(open).(openTile)
+		if t.p == to {
+			break
+		}
+		// a tile can be in the set more than once, only the cheapest
+		// counts
+		if t.cost > steps[t.p]+guess(t.p) {
+			continue
+		}
+		for _, d := range neighbours {
+			n := minimap.Point{X: t.p.X + d.X, Y: t.p.Y + d.Y}
+			if blocked(grid, n) {
+				continue
+			}
+			s := steps[t.p] + 1
+			if old, ok := steps[n]; ok && old <= s {
+				continue
+			}
+			steps[n] = s
+			came[n] = t.p
+			heap.Push(open, openTile{n, s + guess(n)})
+		}
+	}
+	if _, ok := came[to]; !ok {
+		return nil
+	}
+	path := make([]minimap.Point, steps[to])
+	for p, i := to, len(path)-1; i >= 0; p, i = came[p], i-1 {
+		path[i] = p
+	}
+	return path
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sabith-th/games_with_go/config"
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/gfx"
+	"github.com/sabith-th/games_with_go/music"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	sampleRate = 44100
+	// every chord rings for chordTime seconds, or until the next one
+	chordTime = 2.5
+	// historyLength is how many of the last chords are listed below
+	historyLength = 8
+	nameScale     = 16
+)
+
+var (
+	background = font.Color{R: 18, G: 20, B: 32}
+	white      = font.Color{R: 255, G: 255, B: 255}
+	gold       = font.Color{R: 250, G: 210, B: 90}
+	grey       = font.Color{R: 130, G: 130, B: 150}
+)
+
+// numerals are the scale degrees as roman numerals
+var numerals = [7]string{"I", "II", "III", "IV", "V", "VI", "VII"}
+
+// numeral is how harmony writes v's degree: upper case for major chords,
+// lower case for minor, with an o for diminished
+func numeral(v music.ChordVoicing) string {
+	n := numerals[v.Degree]
+	switch v.Quality {
+	case music.MajorTriad, music.Major7, music.Dominant7:
+		return n
+	case music.Diminished, music.HalfDiminished7:
+		return strings.ToLower(n) + "o"
+	}
+	return strings.ToLower(n)
+}
+
+// pcm converts samples between -1 and 1 to signed 16 bit little endian
+func pcm(samples []float32) []byte {
+	out := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		v := int16(math.Max(-1, math.Min(1, float64(s))) * math.MaxInt16)
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(v))
+	}
+	return out
+}
+
+// chordsDemo shows and plays one chord of a progression at a time, space
+// moving on to the next
+type chordsDemo struct {
+	gen     *music.ChordProgressionGenerator
+	current music.ChordVoicing
+	history []string
+	device  sdl.AudioDeviceID
+	quit    bool
+}
+
+// next moves on to the next chord and plays it, cutting off the last
+func (d *chordsDemo) next() {
+	d.current = d.gen.Next()
+	d.history = append(d.history, d.current.Name())
+	if len(d.history) > historyLength {
+		d.history = d.history[1:]
+	}
+	fmt.Println(d.current.Name(), numeral(d.current), d.current.Notes)
+	if d.device == 0 {
+		return
+	}
+	sdl.ClearQueuedAudio(d.device)
+	err := sdl.QueueAudio(d.device, pcm(music.Render(d.current, sampleRate, chordTime)))
+	if err != nil {
+		fmt.Println(err)
+	}
+	sdl.PauseAudioDevice(d.device, false)
+}
+
+func (d *chordsDemo) Update(in gfx.Input, dt float64) {
+	if in.Pressed(gfx.KeyEscape) {
+		d.quit = true
+	}
+	if in.Pressed(gfx.KeySpace) {
+		d.next()
+	}
+}
+
+func (d *chordsDemo) Done() bool {
+	return d.quit
+}
+
+// drawCentered draws text at scale centered across the window with its top
+// at y
+func drawCentered(text string, y, scale int, c font.Color, pixels []byte) {
+	w, _ := font.Size(text, scale)
+	font.Draw(text, (winWidth-w)/2, y, scale, c, pixels, winWidth, winHeight)
+}
+
+func (d *chordsDemo) Draw(pixels []byte, w, h int) {
+	for i := 0; i < len(pixels); i += 4 {
+		pixels[i], pixels[i+1], pixels[i+2] = background.R, background.G, background.B
+	}
+	key := fmt.Sprintf("%s %s", music.NoteNames[d.gen.Root], d.gen.Scale)
+	drawCentered(key, 40, 4, grey, pixels)
+
+	_, nameHeight := font.Size(d.current.Name(), nameScale)
+	nameY := (winHeight - nameHeight) / 2
+	drawCentered(d.current.Name(), nameY-40, nameScale, white, pixels)
+	drawCentered(numeral(d.current), nameY+nameHeight-10, 5, gold, pixels)
+
+	drawCentered(strings.Join(d.history, " - "), winHeight-110, 2, grey, pixels)
+	drawCentered("space: next chord  esc: quit", winHeight-50, 2, grey, pixels)
+}
+
+func main() {
+	root := flag.String("root", "C", "key the progression is in, one of "+strings.Join(music.NoteNames[:], " "))
+	scaleName := flag.String("scale", "major", "scale of the key: major, minor, dorian, phrygian, lydian, mixolydian or locrian")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed of the progression, the same seed plays the same chords")
+	sevenths := flag.Float64("sevenths", 0.35, "chance of a chord having its seventh")
+	configFile := flag.String("config", "", "read flags not given on the command line from this JSON file")
+	flag.Parse()
+	if *configFile != "" {
+		err := config.LoadConfig(flag.CommandLine, *configFile)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	rootNote := -1
+	for i, n := range music.NoteNames {
+		if strings.EqualFold(n, *root) {
+			rootNote = i
+		}
+	}
+	if rootNote < 0 {
+		fmt.Println("unknown root", *root)
+		return
+	}
+	scale, err := music.ParseScale(*scaleName)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	win, err := gfx.New("Chords", winWidth, winHeight)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer win.Destroy()
+
+	demo := &chordsDemo{gen: music.NewChordProgressionGenerator(rootNote, scale, *seed)}
+	demo.gen.SeventhChance = *sevenths
+	// without sound the chords are still shown
+	device, err := sdl.OpenAudioDevice("", false, &sdl.AudioSpec{Freq: sampleRate, Format: sdl.AUDIO_S16LSB, Channels: 1, Samples: 2048}, nil, 0)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		demo.device = device
+		defer sdl.CloseAudioDevice(device)
+	}
+	demo.next()
+
+	err = win.Run(demo)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sabith-th/games_with_go/vector2"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	cols, rows = 30, 20
+	spacing    = 16
+	grabRadius = 20
+
+	// the simulation takes several small steps per frame, the springs are
+	// too stiff for one step of a whole frame to stay stable
+	frameTime = 1.0 / 60
+	substeps  = 16
+)
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	backgroundColor = color{20, 20, 30}
+	// the cloth's corners, every particle gets a blend of these
+	cornerColors = [4]color{{200, 40, 40}, {230, 180, 40}, {40, 160, 90}, {40, 70, 200}}
+)
+
+type mouseState struct {
+	leftButton bool
+	x, y       int
+}
+
+func getMouseState() mouseState {
+	mouseX, mouseY, mouseButtonState := sdl.GetMouseState()
+	leftButton := mouseButtonState & sdl.ButtonLMask()
+	var result mouseState
+	result.x = int(mouseX)
+	result.y = int(mouseY)
+	result.leftButton = !(leftButton == 0)
+	return result
+}
+
+func lerp(b1, b2 byte, pct float32) byte {
+	return byte(float32(b1) + pct*(float32(b2)-float32(b1)))
+}
+
+func colorlerp(c1, c2 color, pct float32) color {
+	return color{lerp(c1.r, c2.r, pct), lerp(c1.g, c2.g, pct), lerp(c1.b, c2.b, pct)}
+}
+
+// bilinear blends four corner colors, top left, top right, bottom right
+// and bottom left, at u across and v down
+func bilinear(c [4]color, u, v float32) color {
+	return colorlerp(colorlerp(c[0], c[1], u), colorlerp(c[3], c[2], u), v)
+}
+
+func clear(c color, pixels []byte) {
+	for i := 0; i < len(pixels); i += 4 {
+		pixels[i] = c.r
+		pixels[i+1] = c.g
+		pixels[i+2] = c.b
+	}
+}
+
+func setPixel(x, y int, c color, pixels []byte) {
+	if x < 0 || x >= winWidth || y < 0 || y >= winHeight {
+		return
+	}
+	index := (y*winWidth + x) * 4
+	pixels[index] = c.r
+	pixels[index+1] = c.g
+	pixels[index+2] = c.b
+}
+
+// invBilinear finds u, v such that bilinearly interpolating the quad
+// a, b, c, d (top left, top right, bottom right, bottom left) gives p. It
+// reports false if p is outside the quad.
+func invBilinear(p, a, b, c, d vector2.Vector2) (u, v float32, ok bool) {
+	e := vector2.Sub(b, a)
+	f := vector2.Sub(d, a)
+	g := vector2.Add(vector2.Sub(a, b), vector2.Sub(c, d))
+	h := vector2.Sub(p, a)
+
+	k2 := vector2.Cross(g, f)
+	k1 := vector2.Cross(e, f) + vector2.Cross(h, g)
+	k0 := vector2.Cross(h, e)
+
+	// p = a + e*u + f*v + g*u*v is quadratic in v, unless the quad is a
+	// parallelogram and g is parallel to f
+	solveU := func(v float32) float32 {
+		dx, dy := e.X+g.X*v, e.Y+g.Y*v
+		if math.Abs(float64(dx)) > math.Abs(float64(dy)) {
+			return (h.X - f.X*v) / dx
+		}
+		return (h.Y - f.Y*v) / dy
+	}
+	// the tolerance keeps pixels on an edge shared by two quads from
+	// falling through both
+	const eps = 1e-3
+	inside := func(u, v float32) bool {
+		return u >= -eps && u <= 1+eps && v >= -eps && v <= 1+eps
+	}
+
+	if k2 == 0 {
+		if k1 == 0 {
+			return 0, 0, false
+		}
+		v = -k0 / k1
+		u = solveU(v)
+		return u, v, inside(u, v)
+	}
+	disc := k1*k1 - 4*k0*k2
+	if disc < 0 {
+		return 0, 0, false
+	}
+	// the roots are taken as q/k2 and k0/q, which stays accurate when the
+	// quad is nearly a parallelogram and k2 is tiny
+	w := float32(math.Sqrt(float64(disc)))
+	if k1 < 0 {
+		w = -w
+	}
+	q := -(k1 + w) / 2
+	roots := [2]float32{q / k2, k0 / q}
+	for _, v := range roots {
+		u = solveU(v)
+		if inside(u, v) {
+			return u, v, true
+		}
+	}
+	return 0, 0, false
+}
+
+// drawQuad fills the quad with the bilinear blend of its corner colors
+func drawQuad(corners [4]vector2.Vector2, colors [4]color, pixels []byte) {
+	minX, minY := corners[0].X, corners[0].Y
+	maxX, maxY := minX, minY
+	for _, p := range corners[1:] {
+		minX = float32(math.Min(float64(minX), float64(p.X)))
+		minY = float32(math.Min(float64(minY), float64(p.Y)))
+		maxX = float32(math.Max(float64(maxX), float64(p.X)))
+		maxY = float32(math.Max(float64(maxY), float64(p.Y)))
+	}
+	x0, y0 := int(math.Floor(float64(minX))), int(math.Floor(float64(minY)))
+	x1, y1 := int(math.Ceil(float64(maxX))), int(math.Ceil(float64(maxY)))
+	if x0 < 0 {
+		x0 = 0
+	}
+	if y0 < 0 {
+		y0 = 0
+	}
+	if x1 > winWidth-1 {
+		x1 = winWidth - 1
+	}
+	if y1 > winHeight-1 {
+		y1 = winHeight - 1
+	}
+
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			p := vector2.Vector2{X: float32(x) + 0.5, Y: float32(y) + 0.5}
+			u, v, ok := invBilinear(p, corners[0], corners[1], corners[2], corners[3])
+			if ok {
+				setPixel(x, y, bilinear(colors, u, v), pixels)
+			}
+		}
+	}
+}
+
+// draw renders the cloth as one quad per grid cell, each particle's color
+// comes from where it sits on the cloth
+func (c *cloth) draw(pixels []byte) {
+	particleColor := func(x, y int) color {
+		return bilinear(cornerColors, float32(x)/float32(c.cols-1), float32(y)/float32(c.rows-1))
+	}
+	for y := 0; y < c.rows-1; y++ {
+		for x := 0; x < c.cols-1; x++ {
+			i := y*c.cols + x
+			corners := [4]vector2.Vector2{
+				c.particles[i].pos, c.particles[i+1].pos,
+				c.particles[i+c.cols+1].pos, c.particles[i+c.cols].pos,
+			}
+			colors := [4]color{particleColor(x, y), particleColor(x+1, y), particleColor(x+1, y+1), particleColor(x, y+1)}
+			drawQuad(corners, colors, pixels)
+		}
+	}
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Cloth", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	origin := vector2.Vector2{X: float32(winWidth-(cols-1)*spacing) / 2, Y: 60}
+	c := newCloth(cols, rows, origin, spacing)
+	grabbed := -1
+	wind := false
+	var elapsed float32
+	currentMouseState := getMouseState()
+	prevMouseState := currentMouseState
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == sdl.SCANCODE_W {
+					wind = !wind
+				}
+			}
+		}
+
+		currentMouseState = getMouseState()
+		mousePos := vector2.Vector2{X: float32(currentMouseState.x), Y: float32(currentMouseState.y)}
+		if currentMouseState.leftButton && !prevMouseState.leftButton {
+			grabbed = c.nearest(mousePos, grabRadius)
+		} else if !currentMouseState.leftButton {
+			grabbed = -1
+		}
+		if grabbed >= 0 {
+			// the grabbed particle follows the mouse and keeps its speed,
+			// so the cloth can be thrown
+			p := &c.particles[grabbed]
+			p.vel = vector2.Mult(vector2.Sub(mousePos, p.pos), 1/frameTime)
+			p.pos = mousePos
+		}
+		prevMouseState = currentMouseState
+
+		dt := float32(frameTime / substeps)
+		for i := 0; i < substeps; i++ {
+			c.step(dt, grabbed, wind, elapsed)
+			elapsed += dt
+		}
+
+		clear(backgroundColor, pixels)
+		c.draw(pixels)
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}
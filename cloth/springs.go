@@ -0,0 +1,131 @@
+package main
+
+import (
+	"github.com/sabith-th/games_with_go/noise"
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+const (
+	gravity = 400
+	// drag slows every particle a little so the cloth comes to rest
+	drag = 0.2
+
+	structuralStiffness, structuralDamping = 20000, 8
+	shearStiffness, shearDamping           = 8000, 4
+	bendingStiffness, bendingDamping       = 2000, 2
+
+	// wind pushes each particle sideways by windStrength times the noise
+	// at its position, the noise drifts with windSpeed so gusts move through
+	windStrength  = 2000
+	windFrequency = 0.01
+	windSpeed     = 0.3
+)
+
+type particle struct {
+	pos, vel vector2.Vector2
+	force    vector2.Vector2
+	pinned   bool
+}
+
+type spring struct {
+	a, b                     int
+	rest, stiffness, damping float32
+}
+
+// cloth is a cols*rows grid of unit mass particles, row by row, held
+// together by structural springs to the horizontal and vertical
+// neighbours, shear springs across the diagonals and bending springs to
+// the particles two along, which resist folding
+type cloth struct {
+	cols, rows int
+	particles  []particle
+	springs    []spring
+}
+
+func newCloth(cols, rows int, origin vector2.Vector2, spacing float32) *cloth {
+	c := &cloth{cols: cols, rows: rows, particles: make([]particle, cols*rows)}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			p := &c.particles[y*cols+x]
+			p.pos = vector2.Add(origin, vector2.Vector2{X: float32(x) * spacing, Y: float32(y) * spacing})
+			p.pinned = y == 0
+		}
+	}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			c.connect(x, y, x+1, y, structuralStiffness, structuralDamping)
+			c.connect(x, y, x, y+1, structuralStiffness, structuralDamping)
+			c.connect(x, y, x+1, y+1, shearStiffness, shearDamping)
+			c.connect(x+1, y, x, y+1, shearStiffness, shearDamping)
+			c.connect(x, y, x+2, y, bendingStiffness, bendingDamping)
+			c.connect(x, y, x, y+2, bendingStiffness, bendingDamping)
+		}
+	}
+	return c
+}
+
+// connect adds a spring at its current length between two grid positions,
+// if both are on the grid
+func (c *cloth) connect(x0, y0, x1, y1 int, stiffness, damping float32) {
+	if x0 < 0 || x0 >= c.cols || y0 < 0 || y0 >= c.rows || x1 < 0 || x1 >= c.cols || y1 < 0 || y1 >= c.rows {
+		return
+	}
+	a, b := y0*c.cols+x0, y1*c.cols+x1
+	rest := vector2.Distance(c.particles[a].pos, c.particles[b].pos)
+	c.springs = append(c.springs, spring{a, b, rest, stiffness, damping})
+}
+
+// nearest returns the index of the closest particle within radius of pos,
+// or -1 if there is none
+func (c *cloth) nearest(pos vector2.Vector2, radius float32) int {
+	result := -1
+	best := radius * radius
+	for i, p := range c.particles {
+		d := vector2.DistanceSquared(p.pos, pos)
+		if d <= best {
+			best = d
+			result = i
+		}
+	}
+	return result
+}
+
+// step advances the cloth by dt with semi-implicit Euler: velocities are
+// updated from the forces first and positions from the new velocities.
+// The grabbed particle, if any, is left for the caller to move. With wind
+// on, t is the time the wind noise has drifted for.
+func (c *cloth) step(dt float32, grabbed int, wind bool, t float32) {
+	for i := range c.particles {
+		p := &c.particles[i]
+		p.force = vector2.Vector2{X: -drag * p.vel.X, Y: gravity - drag*p.vel.Y}
+		if wind {
+			n := noise.Simplex{}.At(p.pos.X*windFrequency+t*windSpeed, p.pos.Y*windFrequency)
+			p.force.X += windStrength * n
+		}
+	}
+
+	for _, s := range c.springs {
+		a, b := &c.particles[s.a], &c.particles[s.b]
+		delta := vector2.Sub(b.pos, a.pos)
+		length := delta.Length()
+		if length == 0 {
+			continue
+		}
+		dir := vector2.Mult(delta, 1/length)
+		// the damping only acts along the spring, so it slows stretching
+		// without resisting the cloth swinging as a whole
+		relVel := vector2.Dot(vector2.Sub(b.vel, a.vel), dir)
+		f := vector2.Mult(dir, s.stiffness*(length-s.rest)+s.damping*relVel)
+		a.force = vector2.Add(a.force, f)
+		b.force = vector2.Sub(b.force, f)
+	}
+
+	for i := range c.particles {
+		p := &c.particles[i]
+		if p.pinned || i == grabbed {
+			continue
+		}
+		p.vel = vector2.Add(p.vel, vector2.Mult(p.force, dt))
+		p.pos = vector2.Add(p.pos, vector2.Mult(p.vel, dt))
+	}
+}
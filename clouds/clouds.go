@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/sabith-th/games_with_go/noise"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	// the clouds are evaluated once per cellSize*cellSize block of pixels
+	cellSize   = 2
+	gridWidth  = winWidth / cellSize
+	gridHeight = winHeight / cellSize
+
+	frameTime = 1.0 / 60
+	// wind is in pixels per second, W steps it up and wraps back to calm
+	// past maxWind
+	windStep = 20
+	maxWind  = 300
+	// R picks a new cover threshold between these
+	minThreshold = 0.15
+	maxThreshold = 0.45
+	// softness is the density range over which a layer fades in past its
+	// threshold, so the cloud edges are not hard
+	softness = 0.12
+)
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	skyTop    = color{35, 85, 170}
+	skyBottom = color{150, 195, 235}
+)
+
+// cloudLayer is one sheet of billow clouds. Its density is BillowNoise
+// moved to 0..1, and it covers the sky where that rises past threshold.
+// drift scales the wind so layers at different heights move apart.
+type cloudLayer struct {
+	frequency, lacunarity, gain float32
+	octaves                     int
+	threshold                   float32
+	drift                       float32
+	thin, thick                 color
+}
+
+// the light layer is fine and white, the storm layer is broad, dark and
+// needs a denser field before it shows
+var (
+	cover = cloudLayer{
+		frequency: 0.004, lacunarity: 2, gain: 0.5, octaves: 5,
+		threshold: 0.3, drift: 1,
+		thin: color{200, 220, 250}, thick: color{255, 255, 255},
+	}
+	storm = cloudLayer{
+		frequency: 0.0018, lacunarity: 2.2, gain: 0.6, octaves: 4,
+		threshold: 0.34, drift: 0.6,
+		thin: color{110, 115, 130}, thick: color{55, 58, 68},
+	}
+)
+
+func lerp(b1, b2 byte, pct float32) byte {
+	return byte(float32(b1) + pct*(float32(b2)-float32(b1)))
+}
+
+func colorlerp(c1, c2 color, pct float32) color {
+	return color{lerp(c1.r, c2.r, pct), lerp(c1.g, c2.g, pct), lerp(c1.b, c2.b, pct)}
+}
+
+func smoothstep(edge0, edge1, x float32) float32 {
+	t := (x - edge0) / (edge1 - edge0)
+	if t < 0 {
+		return 0
+	} else if t > 1 {
+		return 1
+	}
+	return t * t * (3 - 2*t)
+}
+
+// sample returns how much of the sky the layer covers at x, y, and its
+// color there. Denser parts are drawn in the thick color.
+func (l *cloudLayer) sample(x, y float32) (float32, color) {
+	density := (noise.BillowNoise(x, y, l.frequency, l.lacunarity, l.gain, l.octaves) + 1) / 2
+	alpha := smoothstep(l.threshold, l.threshold+softness, density)
+	return alpha, colorlerp(l.thin, l.thick, smoothstep(l.threshold, 1, density))
+}
+
+// shade blends both layers over the sky. The layers are added, each
+// weighted by its coverage, and only scaled back where together they would
+// cover more than all of the sky.
+func shade(x, y int, offset float32, sky color) color {
+	fx, fy := float32(x*cellSize), float32(y*cellSize)
+	a1, c1 := cover.sample(fx-offset*cover.drift, fy)
+	a2, c2 := storm.sample(fx-offset*storm.drift, fy)
+	total := a1 + a2
+	if total == 0 {
+		return sky
+	}
+	alpha := total
+	if alpha > 1 {
+		alpha = 1
+	}
+	w1, w2 := a1/total*alpha, a2/total*alpha
+	blend := func(s, a, b byte) byte {
+		return byte(float32(s)*(1-alpha) + float32(a)*w1 + float32(b)*w2)
+	}
+	return color{blend(sky.r, c1.r, c2.r), blend(sky.g, c1.g, c2.g), blend(sky.b, c1.b, c2.b)}
+}
+
+// draw renders the sky with the clouds moved offset pixels along x, each
+// goroutine taking a band of rows
+func draw(offset float32, pixels []byte) {
+	numRoutines := runtime.NumCPU()
+	var wg sync.WaitGroup
+	wg.Add(numRoutines)
+	for r := 0; r < numRoutines; r++ {
+		go func(r int) {
+			defer wg.Done()
+			for y := r * gridHeight / numRoutines; y < (r+1)*gridHeight/numRoutines; y++ {
+				sky := colorlerp(skyTop, skyBottom, float32(y)/float32(gridHeight-1))
+				for x := 0; x < gridWidth; x++ {
+					c := shade(x, y, offset, sky)
+					for py := y * cellSize; py < (y+1)*cellSize; py++ {
+						for px := x * cellSize; px < (x+1)*cellSize; px++ {
+							index := (py*winWidth + px) * 4
+							pixels[index] = c.r
+							pixels[index+1] = c.g
+							pixels[index+2] = c.b
+						}
+					}
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Clouds", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	wind := float32(40)
+	var offset float32
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN {
+					switch e.Keysym.Scancode {
+					case sdl.SCANCODE_W:
+						wind += windStep
+						if wind > maxWind {
+							wind = 0
+						}
+						fmt.Println("wind", wind)
+					case sdl.SCANCODE_R:
+						cover.threshold = minThreshold + rng.Float32()*(maxThreshold-minThreshold)
+						fmt.Println("cover threshold", cover.threshold)
+					}
+				}
+			}
+		}
+
+		offset += wind * frameTime
+		draw(offset, pixels)
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}
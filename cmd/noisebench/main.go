@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/sabith-th/games_with_go/config"
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// fractals are the ways each registered noise is measured: on its own and
+// summed by each of the fractals
+var fractals = []struct {
+	name string
+	sum  func(c *noise.FractalConfig) noise.Source
+}{
+	{"fbm", (*noise.FractalConfig).Fbm},
+	{"turbulence", (*noise.FractalConfig).Turbulence},
+	{"ridged", (*noise.FractalConfig).Ridged},
+	{"billow", (*noise.FractalConfig).Billow},
+}
+
+// standard are the fractal parameters every combination is measured with,
+// NewFractal's defaults at a frequency that shows some detail per pixel
+var standard = []noise.Option{noise.WithFrequency(0.01)}
+
+// bytesPerPixel is what a sample becomes in the demos' pixel buffers, MB/s
+// is of those
+const bytesPerPixel = 4
+
+// variant is one row of the table
+type variant struct {
+	name string
+	src  noise.Source
+}
+
+// variants are every registered noise and every fractal of it, in the
+// order of noise.Names
+func variants(w, h int) ([]variant, error) {
+	var vs []variant
+	for _, name := range noise.Names() {
+		base, err := noise.New(name, noise.Config{Width: w, Height: h, Step: 1})
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, variant{name, base})
+		c, err := noise.NewFractal(base, standard...)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fractals {
+			vs = append(vs, variant{name + "/" + f.name, f.sum(c)})
+		}
+	}
+	return vs, nil
+}
+
+// fill samples src at every pixel of a w*h field, the operation that is
+// timed
+func fill(src noise.Source, field []float32, w, h int) {
+	for y := 0; y < h; y++ {
+		row := field[y*w : (y+1)*w]
+		for x := range row {
+			row[x] = src.At(float32(x), float32(y))
+		}
+	}
+}
+
+// result is a variant's measurement, per sample rather than per field
+type result struct {
+	name         string
+	nsPerSample  float64
+	mbPerSecond  float64
+	allocsPerOp  int64
+	bytesPerFill int64
+}
+
+func measure(v variant, w, h int) result {
+	field := make([]float32, w*h)
+	r := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(w * h * bytesPerPixel))
+		for i := 0; i < b.N; i++ {
+			fill(v.src, field, w, h)
+		}
+	})
+	ns := float64(r.NsPerOp()) / float64(w*h)
+	return result{
+		name:         v.name,
+		nsPerSample:  ns,
+		mbPerSecond:  bytesPerPixel / ns * 1e3,
+		allocsPerOp:  r.AllocsPerOp(),
+		bytesPerFill: r.AllocedBytesPerOp(),
+	}
+}
+
+// writeTable writes the results as a markdown table, one row per variant
+// in the order given and the numbers at fixed precision, so two runs can
+// be diffed line by line
+func writeTable(out io.Writer, results []result) {
+	fmt.Fprintln(out, "| name | ns/sample | MB/s | allocs/op | B/op |")
+	fmt.Fprintln(out, "|---|---:|---:|---:|---:|")
+	for _, r := range results {
+		fmt.Fprintf(out, "| %s | %.2f | %.1f | %d | %d |\n", r.name, r.nsPerSample, r.mbPerSecond, r.allocsPerOp, r.bytesPerFill)
+	}
+}
+
+func main() {
+	// testing.Benchmark reads its settings from the test flags
+	testing.Init()
+	size := flag.Int("size", 256, "width and height of the field every variant fills per operation")
+	benchtime := flag.String("benchtime", "1s", "how long to time each variant, as go test's -benchtime")
+	run := flag.String("run", "", "only measure the variants whose names match this regular expression")
+	configFile := flag.String("config", "", "read flags not given on the command line from this JSON file")
+	flag.Parse()
+	if *configFile != "" {
+		err := config.LoadConfig(flag.CommandLine, *configFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	if *size < 1 {
+		fmt.Fprintln(os.Stderr, "size must be positive, got", *size)
+		os.Exit(2)
+	}
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	err = flag.Set("test.benchtime", *benchtime)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "benchtime:", err)
+		os.Exit(2)
+	}
+	vs, err := variants(*size, *size)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var results []result
+	for _, v := range vs {
+		if !filter.MatchString(v.name) {
+			continue
+		}
+		// progress goes to stderr, stdout is only the table
+		fmt.Fprintln(os.Stderr, "measuring", v.name)
+		results = append(results, measure(v, *size, *size))
+	}
+	fmt.Printf("%dx%d field per op, frequency 0.01, %s %s/%s\n\n", *size, *size, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	writeTable(os.Stdout, results)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/sabith-th/games_with_go/config"
+	"github.com/sabith-th/games_with_go/export"
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+var modeNames = []string{"turbulence", "fbm", "ridged"}
+
+type options struct {
+	out           string
+	count         int
+	width, height int
+	seedStart     int64
+	mode          string
+	noiseType     string
+	animate       bool
+	zStep         float32
+	fractal       []noise.Option
+}
+
+// source is the noise image i samples. Every image gets the seed after the
+// one before, unless the images are frames of one animation: those share
+// the first seed and step through the noise along z instead.
+func (o *options) source(i int) (noise.Source, error) {
+	var base noise.Source
+	if o.animate {
+		base = noise.SimplexSlice{Perm: noise.NewPerm(o.seedStart), Z: float32(i) * o.zStep}
+	} else {
+		var err error
+		base, err = noise.New(o.noiseType, noise.Config{Width: o.width, Height: o.height, Step: 1, Perm: noise.NewPerm(o.seedStart + int64(i))})
+		if err != nil {
+			return nil, err
+		}
+	}
+	c, err := noise.NewFractal(base, o.fractal...)
+	if err != nil {
+		return nil, err
+	}
+	switch o.mode {
+	case "fbm":
+		return c.Fbm(), nil
+	case "ridged":
+		return c.Ridged(), nil
+	default:
+		return c.Turbulence(), nil
+	}
+}
+
+// render samples src at every pixel and stretches the values it found over
+// black to white
+func render(src noise.Source, w, h int) []byte {
+	field := make([]float32, w*h)
+	min, max := float32(math.MaxFloat32), float32(-math.MaxFloat32)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := src.At(float32(x), float32(y))
+			field[y*w+x] = v
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+		}
+	}
+	scale := float32(0)
+	if max > min {
+		scale = 255 / (max - min)
+	}
+	pixels := make([]byte, w*h*4)
+	for i, v := range field {
+		b := (v - min) * scale
+		if b > 255 {
+			b = 255
+		}
+		pixels[i*4], pixels[i*4+1], pixels[i*4+2] = byte(b), byte(b), byte(b)
+	}
+	return pixels
+}
+
+// filename is noise_000.png and on, with enough digits that the names of
+// all count images sort in order
+func filename(i, count int) string {
+	digits := len(fmt.Sprint(count - 1))
+	if digits < 3 {
+		digits = 3
+	}
+	return fmt.Sprintf("noise_%0*d.png", digits, i)
+}
+
+func (o *options) generate(i int) error {
+	src, err := o.source(i)
+	if err != nil {
+		return err
+	}
+	return export.WritePNG(filepath.Join(o.out, filename(i, o.count)), render(src, o.width, o.height), o.width, o.height)
+}
+
+// generateAll renders the images on workers goroutines and reports each
+// one on stderr as it is written. It carries on past a failed image and
+// returns the first error.
+func generateAll(o *options, workers int) error {
+	jobs := make(chan int)
+	results := make(chan error)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				results <- o.generate(i)
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < o.count; i++ {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	var first error
+	generated := 0
+	for i := 0; i < o.count; i++ {
+		err := <-results
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		generated++
+		fmt.Fprintf(os.Stderr, "generated %d/%d\n", generated, o.count)
+	}
+	return first
+}
+
+func validMode(mode string) bool {
+	for _, name := range modeNames {
+		if mode == name {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	var o options
+	flag.StringVar(&o.out, "out", "", "directory the images are written to, created if needed")
+	flag.IntVar(&o.count, "count", 10, "number of images")
+	flag.IntVar(&o.width, "width", 256, "width of every image in pixels")
+	flag.IntVar(&o.height, "height", 256, "height of every image in pixels")
+	flag.Int64Var(&o.seedStart, "seed-start", 0, "seed of the first image, the others count up from it")
+	flag.StringVar(&o.mode, "mode", "turbulence", "fractal the octaves are summed with: turbulence, fbm or ridged")
+	flag.StringVar(&o.noiseType, "type", "simplex", "noise every octave samples, one of "+strings.Join(noise.Names(), ", "))
+	flag.BoolVar(&o.animate, "animate", false, "write frames of one animation with the first seed, moving through 3d noise by -z-step each frame")
+	zStep := flag.Float64("z-step", 0.05, "how far along z each -animate frame moves")
+	frequency := flag.Float64("frequency", 0.01, "frequency of the first octave")
+	lacunarity := flag.Float64("lacunarity", 3, "frequency multiplier between octaves")
+	gain := flag.Float64("gain", 0.2, "amplitude multiplier between octaves")
+	octaves := flag.Int("octaves", 3, "number of octaves summed")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of images rendered at once")
+	configFile := flag.String("config", "", "read flags not given on the command line from this JSON file")
+	flag.Parse()
+	if *configFile != "" {
+		err := config.LoadConfig(flag.CommandLine, *configFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	switch {
+	case o.out == "":
+		fmt.Fprintln(os.Stderr, "-out is required")
+		os.Exit(2)
+	case o.count < 1:
+		fmt.Fprintln(os.Stderr, "-count must be at least 1, got", o.count)
+		os.Exit(2)
+	case o.width < 1 || o.height < 1:
+		fmt.Fprintf(os.Stderr, "image size must be positive, got %dx%d\n", o.width, o.height)
+		os.Exit(2)
+	case !validMode(o.mode):
+		fmt.Fprintf(os.Stderr, "unknown mode %q, expected one of %v\n", o.mode, modeNames)
+		os.Exit(2)
+	case !noise.Registered(o.noiseType):
+		fmt.Fprintf(os.Stderr, "unknown type %q, expected one of %v\n", o.noiseType, noise.Names())
+		os.Exit(2)
+	case o.animate && o.noiseType != "simplex":
+		fmt.Fprintln(os.Stderr, "-animate moves through 3d simplex noise, it needs -type simplex")
+		os.Exit(2)
+	case *workers < 1:
+		fmt.Fprintln(os.Stderr, "-workers must be at least 1, got", *workers)
+		os.Exit(2)
+	}
+	o.zStep = float32(*zStep)
+	o.fractal = []noise.Option{
+		noise.WithFrequency(float32(*frequency)),
+		noise.WithLacunarity(float32(*lacunarity)),
+		noise.WithGain(float32(*gain)),
+		noise.WithOctaves(*octaves),
+	}
+	// the fractal options are checked once here rather than failing every
+	// image the same way
+	_, err := o.source(0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	err = os.MkdirAll(o.out, 0755)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *workers > o.count {
+		*workers = o.count
+	}
+	err = generateAll(&o, *workers)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// LoadConfig fills the flags of flagSet that were not given on the command
+// line from the JSON object in configFile, which maps flag names to their
+// values. Values may be JSON strings, numbers or booleans, each is passed to
+// the flag's Set as text. It has to be called after flagSet.Parse, so it
+// knows which flags the command line set. A missing file is not an error,
+// every flag keeps its default instead.
+func LoadConfig(flagSet *flag.FlagSet, configFile string) error {
+	data, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var values map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// numbers are kept as written, so large ints don't pass through a float
+	dec.UseNumber()
+	err = dec.Decode(&values)
+	if err != nil {
+		return fmt.Errorf("config: %s: %v", configFile, err)
+	}
+
+	given := make(map[string]bool)
+	flagSet.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	// sorted so the first bad entry reported is always the same one
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if flagSet.Lookup(name) == nil {
+			return fmt.Errorf("config: %s: no flag named %q", configFile, name)
+		}
+		if given[name] {
+			continue
+		}
+		var text string
+		switch v := values[name].(type) {
+		case string:
+			text = v
+		case json.Number:
+			text = v.String()
+		case bool:
+			text = strconv.FormatBool(v)
+		default:
+			return fmt.Errorf("config: %s: %s must be a string, number or boolean", configFile, name)
+		}
+		err = flagSet.Set(name, text)
+		if err != nil {
+			return fmt.Errorf("config: %s: %s: %v", configFile, name, err)
+		}
+	}
+	return nil
+}
+
+// Write saves every flag of flagSet except the ones named in skip to path
+// as a JSON object LoadConfig can read back. Booleans, numbers and strings
+// are written as those JSON types and anything else, such as durations, as
+// the text its String method gives. skip is for the flags that choose the
+// config file itself, which mean nothing inside it.
+func Write(flagSet *flag.FlagSet, path string, skip ...string) error {
+	skipped := make(map[string]bool)
+	for _, name := range skip {
+		skipped[name] = true
+	}
+
+	values := make(map[string]interface{})
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if skipped[f.Name] {
+			return
+		}
+		values[f.Name] = f.Value.String()
+		getter, ok := f.Value.(flag.Getter)
+		if !ok {
+			return
+		}
+		switch v := getter.Get().(type) {
+		case bool, int, int64, uint, uint64, float64, string:
+			values[f.Name] = v
+		}
+	})
+
+	// encoding/json sorts map keys, so the file is the same on every write
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
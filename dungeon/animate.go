@@ -0,0 +1,119 @@
+package dungeon
+
+import (
+	"math"
+
+	"github.com/sabith-th/games_with_go/minimap"
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+type color struct {
+	r, g, b byte
+}
+
+// flickerOctaves and flickerSpeed shape a torch's flame: a few octaves of
+// 1d noise, sampled flickerSpeed times faster than the clock runs
+const (
+	flickerOctaves = 3
+	flickerSpeed   = 4.0
+	// flickerLimit is the largest Fbm1 can get with gain 1/2
+	flickerLimit = 1 + 0.5 + 0.25
+)
+
+// AnimatedTile is the look of a tile type that changes over time. Frames
+// are its colors in order and FrameRate how many of them pass every
+// second, each animation keeps its own clock.
+type AnimatedTile struct {
+	Frames    []color
+	FrameRate float32
+
+	time float32
+}
+
+// Update moves the clock of a on by dt seconds
+func (a *AnimatedTile) Update(dt float32) {
+	a.time += dt
+}
+
+// Frame is the frame shown now when the frames are played in a loop
+func (a *AnimatedTile) Frame() color {
+	if len(a.Frames) == 0 {
+		return color{}
+	}
+	i := int(a.time*a.FrameRate) % len(a.Frames)
+	if i < 0 {
+		i += len(a.Frames)
+	}
+	return a.Frames[i]
+}
+
+// shade is the frame for f, the first one for 0 up to the last for 1, so
+// a smooth value picks from a handful of colors
+func (a *AnimatedTile) shade(f float32) color {
+	if len(a.Frames) == 0 {
+		return color{}
+	}
+	i := int(f * float32(len(a.Frames)))
+	if i < 0 {
+		i = 0
+	} else if i >= len(a.Frames) {
+		i = len(a.Frames) - 1
+	}
+	return a.Frames[i]
+}
+
+// clock is how many frames' worth of time has passed
+func (a *AnimatedTile) clock() float32 {
+	return a.time * a.FrameRate
+}
+
+// TileAnimator holds the animations of the tile types that move. Water
+// ripples with a sine wave running diagonally across the map and every
+// torch flickers on its own, the rest loop their frames.
+type TileAnimator struct {
+	tiles map[minimap.TileType]*AnimatedTile
+}
+
+// NewTileAnimator returns an animator for water and torches
+func NewTileAnimator() *TileAnimator {
+	return &TileAnimator{tiles: map[minimap.TileType]*AnimatedTile{
+		minimap.Water: {
+			Frames:    []color{{30, 65, 160}, {35, 75, 175}, {40, 85, 190}, {55, 105, 205}, {80, 130, 220}},
+			FrameRate: 1,
+		},
+		// dim embers up to a bright yellow flame
+		minimap.Torch: {
+			Frames:    []color{{120, 50, 20}, {170, 80, 25}, {210, 120, 35}, {235, 160, 50}, {250, 200, 90}, {255, 235, 150}},
+			FrameRate: 1,
+		},
+	}}
+}
+
+// animated reports whether tiles of type t change over time
+func (a *TileAnimator) animated(t minimap.TileType) bool {
+	_, ok := a.tiles[t]
+	return ok
+}
+
+// Update advances every animation by dt seconds
+func (a *TileAnimator) Update(dt float32) {
+	for _, tile := range a.tiles {
+		tile.Update(dt)
+	}
+}
+
+// color is how tile x, y of type t looks now. torch numbers the torches,
+// so two of them side by side do not flicker in step.
+func (a *TileAnimator) color(t minimap.TileType, x, y, torch int) color {
+	tile := a.tiles[t]
+	switch t {
+	case minimap.Water:
+		wave := math.Sin(float64(x+y) + float64(tile.clock()))
+		return tile.shade(float32(wave+1) / 2)
+	case minimap.Torch:
+		v := noise.Fbm1(tile.clock()*flickerSpeed+float32(torch), 1, 2, 0.5, flickerOctaves)
+		return tile.shade((v/flickerLimit + 1) / 2)
+	default:
+		return tile.Frame()
+	}
+}
@@ -0,0 +1,149 @@
+package dungeon
+
+import "github.com/sabith-th/games_with_go/minimap"
+
+var (
+	// voidColor is past the edge of the level
+	voidColor  = color{0, 0, 0}
+	tileColors = map[minimap.TileType]color{
+		minimap.StoneWall:  {90, 90, 100},
+		minimap.DirtFloor:  {60, 45, 30},
+		minimap.ClosedDoor: {140, 85, 35},
+		minimap.OpenDoor:   {185, 135, 70},
+	}
+)
+
+// Renderer draws a level TileSize pixels per tile, animating the tiles
+// the Animator moves. It is meant to be called every frame, but it keeps
+// the color every tile was last drawn in and only draws the tiles whose
+// color changed, so most of a frame is left as it was.
+type Renderer struct {
+	Animator *TileAnimator
+
+	width, height, tileSize int
+	level                   [][]minimap.TileType
+	// drawn[y][x] is the color tile x, y was last drawn in. moving are the
+	// animated tiles, the only ones that can change until the next SetLevel.
+	drawn   [][]color
+	moving  []minimap.Point
+	torches map[minimap.Point]int
+	// fresh is set by SetLevel, the next Render draws the whole frame
+	fresh bool
+}
+
+// NewRenderer returns a renderer for a width*height ABGR8888 frame
+func NewRenderer(width, height, tileSize int) *Renderer {
+	return &Renderer{Animator: NewTileAnimator(), width: width, height: height, tileSize: tileSize}
+}
+
+// SetLevel shows level, level[y][x], from the next Render on. The whole
+// frame is drawn again then, so it is also how a changed level, a door
+// opened, is shown. Torches are numbered in reading order so each
+// flickers differently.
+func (r *Renderer) SetLevel(level [][]minimap.TileType) {
+	r.level = level
+	r.drawn = make([][]color, len(level))
+	r.moving = r.moving[:0]
+	r.torches = make(map[minimap.Point]int)
+	for y, row := range level {
+		r.drawn[y] = make([]color, len(row))
+		for x, t := range row {
+			if !r.Animator.animated(t) {
+				continue
+			}
+			p := minimap.Point{X: x, Y: y}
+			r.moving = append(r.moving, p)
+			if t == minimap.Torch {
+				r.torches[p] = len(r.torches)
+			}
+		}
+	}
+	r.fresh = true
+}
+
+// color is how the tile at p looks now
+func (r *Renderer) color(p minimap.Point) color {
+	t := r.level[p.Y][p.X]
+	if r.Animator.animated(t) {
+		return r.Animator.color(t, p.X, p.Y, r.torches[p])
+	}
+	return tileColors[t]
+}
+
+// Render draws the tiles that changed since the last call into pixels and
+// returns the rows top up to bottom it touched, for updating only that
+// part of the window. Nothing changed if top == bottom.
+func (r *Renderer) Render(pixels []byte) (top, bottom int) {
+	top, bottom = r.height, 0
+	mark := func(p minimap.Point) {
+		y0, y1 := clip(p.Y*r.tileSize, r.tileSize, r.height)
+		if y0 >= y1 {
+			return
+		}
+		if y0 < top {
+			top = y0
+		}
+		if y1 > bottom {
+			bottom = y1
+		}
+	}
+
+	if r.fresh {
+		r.fresh = false
+		r.fill(0, 0, r.width, r.height, voidColor, pixels)
+		for y, row := range r.level {
+			for x := range row {
+				p := minimap.Point{X: x, Y: y}
+				c := r.color(p)
+				r.drawn[y][x] = c
+				r.drawTile(p, c, pixels)
+			}
+		}
+		return 0, r.height
+	}
+
+	for _, p := range r.moving {
+		c := r.color(p)
+		if c == r.drawn[p.Y][p.X] {
+			continue
+		}
+		r.drawn[p.Y][p.X] = c
+		r.drawTile(p, c, pixels)
+		mark(p)
+	}
+	if top >= bottom {
+		return 0, 0
+	}
+	return top, bottom
+}
+
+func (r *Renderer) drawTile(p minimap.Point, c color, pixels []byte) {
+	r.fill(p.X*r.tileSize, p.Y*r.tileSize, r.tileSize, r.tileSize, c, pixels)
+}
+
+// fill paints the w*h rectangle at x, y, clipped to the frame
+func (r *Renderer) fill(x, y, w, h int, c color, pixels []byte) {
+	x0, x1 := clip(x, w, r.width)
+	y0, y1 := clip(y, h, r.height)
+	for py := y0; py < y1; py++ {
+		index := py * r.width * 4
+		for px := x0; px < x1; px++ {
+			i := index + px*4
+			pixels[i] = c.r
+			pixels[i+1] = c.g
+			pixels[i+2] = c.b
+		}
+	}
+}
+
+// clip is the part of start..start+length that is within 0..limit
+func clip(start, length, limit int) (int, int) {
+	lo, hi := start, start+length
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > limit {
+		hi = limit
+	}
+	return lo, hi
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	. "github.com/sabith-th/games_with_go/evolvingpictures/apt"
+	"github.com/sabith-th/games_with_go/postfx"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
@@ -63,7 +64,7 @@ func pixelsToTexture(renderer *sdl.Renderer, pixels []byte, w, h int) *sdl.Textu
 	return tex
 }
 
-func aptToTexture(node Node, w, h int, renderer *sdl.Renderer) *sdl.Texture {
+func aptToPixels(node Node, w, h int) []byte {
 	scale := float32(255 / 2)
 	offset := float32(-1.0 * scale)
 	pixels := make([]byte, w*h*4)
@@ -82,7 +83,7 @@ func aptToTexture(node Node, w, h int, renderer *sdl.Renderer) *sdl.Texture {
 			pixelIndex++
 		}
 	}
-	return pixelsToTexture(renderer, pixels, w, h)
+	return pixels
 }
 
 func main() {
@@ -117,7 +118,14 @@ func main() {
 	plus.LeftChild = sine
 	plus.RightChild = y
 
-	tex := aptToTexture(plus, winDepth, winHeight, renderer)
+	pixels := aptToPixels(plus, winDepth, winHeight)
+	tex := pixelsToTexture(renderer, pixels, winDepth, winHeight)
+	// the picture never changes, so bloom it once up front and toggle
+	// between the two
+	var bloomPass postfx.Bloom
+	bloomPass.Apply(pixels, winDepth, winHeight, 160, 1.0)
+	bloomTexture := pixelsToTexture(renderer, pixels, winDepth, winHeight)
+	bloom := false
 
 	for {
 		frameStart := time.Now()
@@ -135,10 +143,18 @@ func main() {
 					currentMouseState.x, currentMouseState.y = touchX, touchY
 					currentMouseState.leftButton = true
 				}
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == sdl.SCANCODE_B {
+					bloom = !bloom
+				}
 			}
 		}
 
-		renderer.Copy(tex, nil, nil)
+		if bloom {
+			renderer.Copy(bloomTexture, nil, nil)
+		} else {
+			renderer.Copy(tex, nil, nil)
+		}
 		renderer.Present()
 
 		elapsedTime = float32(time.Since(frameStart).Seconds() * 1000)
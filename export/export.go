@@ -0,0 +1,103 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// ToImage wraps a w*h*4 r, g, b, a pixel buffer as an opaque image. The demos
+// leave the alpha byte at zero, so it is forced to 255 here.
+func ToImage(pixels []byte, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	copy(img.Pix, pixels)
+	for p := 3; p < len(img.Pix); p += 4 {
+		img.Pix[p] = 255
+	}
+	return img
+}
+
+// WritePNG encodes a pixel buffer as a png file
+func WritePNG(path string, pixels []byte, w, h int) error {
+	if len(pixels) < w*h*4 {
+		return fmt.Errorf("export: pixel buffer holds %d bytes, %dx%d needs %d", len(pixels), w, h, w*h*4)
+	}
+	outfile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(outfile, ToImage(pixels, w, h))
+	if closeErr := outfile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Tile is a single entry of the atlas index
+type Tile struct {
+	File string `json:"file"`
+	Col  int    `json:"col"`
+	Row  int    `json:"row"`
+	// X and Y are the pixel offset of the tile's top left corner in the full render
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Atlas indexes every tile written by Tiles
+type Atlas struct {
+	TileWidth  int    `json:"tileWidth"`
+	TileHeight int    `json:"tileHeight"`
+	Cols       int    `json:"cols"`
+	Rows       int    `json:"rows"`
+	Tiles      []Tile `json:"tiles"`
+}
+
+// AtlasFile is the name of the index Tiles writes next to the tile images
+const AtlasFile = "atlas.json"
+
+// Tiles cuts a w*h pixel buffer into cols*rows tiles of tileW*tileH pixels,
+// writes them to dir as tile_<n>.png numbered row by row, and writes the
+// atlas index as dir/atlas.json
+func Tiles(pixels []byte, w, h, tileW, tileH int, dir string) (*Atlas, error) {
+	if tileW <= 0 || tileH <= 0 {
+		return nil, fmt.Errorf("export: invalid tile size %dx%d", tileW, tileH)
+	}
+	if w%tileW != 0 || h%tileH != 0 {
+		return nil, fmt.Errorf("export: %dx%d image does not divide into %dx%d tiles", w, h, tileW, tileH)
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	atlas := &Atlas{TileWidth: tileW, TileHeight: tileH, Cols: w / tileW, Rows: h / tileH}
+	tilePixels := make([]byte, tileW*tileH*4)
+	for row := 0; row < atlas.Rows; row++ {
+		for col := 0; col < atlas.Cols; col++ {
+			x, y := col*tileW, row*tileH
+			for ty := 0; ty < tileH; ty++ {
+				src := ((y+ty)*w + x) * 4
+				copy(tilePixels[ty*tileW*4:(ty+1)*tileW*4], pixels[src:src+tileW*4])
+			}
+			tile := Tile{fmt.Sprintf("tile_%03d.png", len(atlas.Tiles)), col, row, x, y}
+			err = WritePNG(filepath.Join(dir, tile.File), tilePixels, tileW, tileH)
+			if err != nil {
+				return nil, err
+			}
+			atlas.Tiles = append(atlas.Tiles, tile)
+		}
+	}
+
+	index, err := json.MarshalIndent(atlas, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(filepath.Join(dir, AtlasFile), index, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return atlas, nil
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"encoding/json"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testPixels is a w*h buffer where no two pixels are alike, so a tile put
+// back in the wrong place shows
+func testPixels(w, h int) []byte {
+	pixels := make([]byte, w*h*4)
+	for i := 0; i < w*h; i++ {
+		pixels[i*4] = byte(i)
+		pixels[i*4+1] = byte(i >> 8)
+		pixels[i*4+2] = byte(i * 7)
+	}
+	return pixels
+}
+
+func readPNG(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// TestTilesReassemble puts the tiles back together where atlas.json says
+// they go and compares the result with the image they were cut from
+func TestTilesReassemble(t *testing.T) {
+	for _, tt := range []struct{ w, h, tileW, tileH int }{
+		{12, 9, 4, 3},
+		{64, 32, 16, 16},
+		{5, 7, 5, 7},
+		{6, 2, 1, 1},
+	} {
+		dir := t.TempDir()
+		pixels := testPixels(tt.w, tt.h)
+		atlas, err := Tiles(pixels, tt.w, tt.h, tt.tileW, tt.tileH, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, AtlasFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var index Atlas
+		err = json.Unmarshal(data, &index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&index, atlas) {
+			t.Fatalf("%s holds %+v, Tiles returned %+v", AtlasFile, index, *atlas)
+		}
+		if n := index.Cols * index.Rows; len(index.Tiles) != n || n != tt.w/tt.tileW*(tt.h/tt.tileH) {
+			t.Fatalf("%dx%d in %dx%d tiles: %d tiles in a %dx%d atlas", tt.w, tt.h, tt.tileW, tt.tileH, len(index.Tiles), index.Cols, index.Rows)
+		}
+
+		got := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+		for _, tile := range index.Tiles {
+			img := readPNG(t, filepath.Join(dir, tile.File))
+			if b := img.Bounds(); b.Dx() != tt.tileW || b.Dy() != tt.tileH {
+				t.Fatalf("%s is %dx%d, want %dx%d", tile.File, b.Dx(), b.Dy(), tt.tileW, tt.tileH)
+			}
+			if tile.X != tile.Col*tt.tileW || tile.Y != tile.Row*tt.tileH {
+				t.Fatalf("%s at column %d row %d has offset %d,%d", tile.File, tile.Col, tile.Row, tile.X, tile.Y)
+			}
+			draw.Draw(got, image.Rect(tile.X, tile.Y, tile.X+tt.tileW, tile.Y+tt.tileH), img, img.Bounds().Min, draw.Src)
+		}
+		if want := ToImage(pixels, tt.w, tt.h); !reflect.DeepEqual(got.Pix, want.Pix) {
+			t.Errorf("%dx%d in %dx%d tiles does not reassemble into the original", tt.w, tt.h, tt.tileW, tt.tileH)
+		}
+	}
+}
+
+func TestTilesInvalid(t *testing.T) {
+	pixels := testPixels(8, 8)
+	for _, tt := range []struct{ tileW, tileH int }{{0, 4}, {4, -1}, {3, 4}, {4, 5}} {
+		if _, err := Tiles(pixels, 8, 8, tt.tileW, tt.tileH, t.TempDir()); err == nil {
+			t.Errorf("8x8 was cut into %dx%d tiles", tt.tileW, tt.tileH)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxSamples caps the size of generated Go source when
+// GoSourceOptions.MaxSamples is left at zero. 256*256 values is already a
+// couple of megabytes of source.
+const DefaultMaxSamples = 256 * 256
+
+const valuesPerLine = 12
+
+// GoSourceOptions controls how GoSource writes a field
+type GoSourceOptions struct {
+	Package string // package clause of the generated file
+	Name    string // name of the generated array variable
+	Comment string // written above the package clause, e.g. the generation parameters
+	// Quantize stores the field as uint8 instead of float32. Values are
+	// expected to be normalized to [0, 1].
+	Quantize bool
+	// Downsample keeps every nth sample along both axes, 0 or 1 keeps all
+	Downsample int
+	// MaxSamples is the largest array GoSource will emit after downsampling
+	MaxSamples int
+}
+
+// GoSource renders a w*h field as a gofmt-formatted Go file declaring it as
+// a fixed size array literal along with its dimensions
+func GoSource(field []float32, w, h int, opts GoSourceOptions) ([]byte, error) {
+	if len(field) < w*h {
+		return nil, fmt.Errorf("export: field holds %d values, %dx%d needs %d", len(field), w, h, w*h)
+	}
+	step := opts.Downsample
+	if step < 1 {
+		step = 1
+	}
+	maxSamples := opts.MaxSamples
+	if maxSamples == 0 {
+		maxSamples = DefaultMaxSamples
+	}
+	outW, outH := (w+step-1)/step, (h+step-1)/step
+	if outW*outH > maxSamples {
+		return nil, fmt.Errorf("export: %dx%d samples exceeds the limit of %d, increase the downsample factor", outW, outH, maxSamples)
+	}
+
+	// NaN and the infinities have no literal, format.Source would only
+	// say the generated code does not parse
+	for y := 0; y < h; y += step {
+		for x := 0; x < w; x += step {
+			if v := float64(field[y*w+x]); math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("export: field value at %d,%d is %v, only finite values can be written as Go source", x, y, v)
+			}
+		}
+	}
+
+	elemType := "float32"
+	if opts.Quantize {
+		elemType = "uint8"
+	}
+
+	var buf bytes.Buffer
+	for _, line := range strings.Split(strings.TrimSpace(opts.Comment), "\n") {
+		if line == "" {
+			buf.WriteString("\n")
+		} else {
+			fmt.Fprintf(&buf, "// %s\n", line)
+		}
+	}
+	fmt.Fprintf(&buf, "package %s\n\n", opts.Package)
+	fmt.Fprintf(&buf, "// %sWidth and %sHeight are the dimensions of %s\n", opts.Name, opts.Name, opts.Name)
+	fmt.Fprintf(&buf, "const %sWidth, %sHeight = %d, %d\n\n", opts.Name, opts.Name, outW, outH)
+	fmt.Fprintf(&buf, "// %s is stored row by row\n", opts.Name)
+	fmt.Fprintf(&buf, "var %s = [%d]%s{\n", opts.Name, outW*outH, elemType)
+	n := 0
+	for y := 0; y < h; y += step {
+		for x := 0; x < w; x += step {
+			v := field[y*w+x]
+			if opts.Quantize {
+				buf.WriteString(strconv.Itoa(int(clampUnit(v)*255 + 0.5)))
+			} else {
+				buf.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+			}
+			n++
+			if n%valuesPerLine == 0 {
+				buf.WriteString(",\n")
+			} else {
+				buf.WriteString(", ")
+			}
+		}
+	}
+	buf.WriteString("}\n")
+
+	return format.Source(buf.Bytes())
+}
+
+// WriteGoSource writes the output of GoSource to path
+func WriteGoSource(path string, field []float32, w, h int, opts GoSourceOptions) error {
+	src, err := GoSource(field, w, h, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, src, 0644)
+}
+
+func clampUnit(v float32) float32 {
+	if v < 0 {
+		return 0
+	} else if v > 1 {
+		return 1
+	}
+	return v
+}
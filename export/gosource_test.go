@@ -0,0 +1,113 @@
+package export
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// parseField parses src and returns the length of the array literal of
+// variable name and the values in it
+func parseField(t *testing.T, src []byte, name string) (int, []string) {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "field.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, src)
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		spec := gen.Specs[0].(*ast.ValueSpec)
+		if spec.Names[0].Name != name {
+			continue
+		}
+		lit := spec.Values[0].(*ast.CompositeLit)
+		n, err := strconv.Atoi(lit.Type.(*ast.ArrayType).Len.(*ast.BasicLit).Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var values []string
+		for _, elt := range lit.Elts {
+			values = append(values, elt.(*ast.BasicLit).Value)
+		}
+		return n, values
+	}
+	t.Fatalf("no var %s in\n%s", name, src)
+	return 0, nil
+}
+
+func TestGoSourceParses(t *testing.T) {
+	const w, h = 7, 5
+	field := make([]float32, w*h)
+	for i := range field {
+		field[i] = float32(i) / float32(len(field)-1)
+	}
+	field[3] = 1e-20
+
+	tests := []struct {
+		opts   GoSourceOptions
+		length int
+	}{
+		{GoSourceOptions{Package: "maps", Name: "Field", Comment: "frequency=0.01\n\noctaves=3"}, w * h},
+		{GoSourceOptions{Package: "maps", Name: "Field", Quantize: true}, w * h},
+		{GoSourceOptions{Package: "maps", Name: "Small", Downsample: 3}, 3 * 2},
+	}
+	for _, tt := range tests {
+		src, err := GoSource(field, w, h, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, values := parseField(t, src, tt.opts.Name)
+		if n != tt.length || len(values) != tt.length {
+			t.Errorf("%+v: [%d] array with %d values, want %d", tt.opts, n, len(values), tt.length)
+		}
+		if tt.opts.Comment != "" && !strings.HasPrefix(string(src), "// frequency=0.01\n\n// octaves=3\n") {
+			t.Errorf("comment not written as given:\n%s", src)
+		}
+	}
+
+	// float32 values read back exactly
+	src, err := GoSource(field, w, h, GoSourceOptions{Package: "maps", Name: "Field"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, values := parseField(t, src, "Field")
+	for i, text := range values {
+		v, err := strconv.ParseFloat(text, 32)
+		if err != nil || float32(v) != field[i] {
+			t.Errorf("value %d is %s, want %v", i, text, field[i])
+		}
+	}
+}
+
+func TestGoSourceInvalid(t *testing.T) {
+	opts := GoSourceOptions{Package: "maps", Name: "Field"}
+	for _, bad := range []float32{float32(math.NaN()), float32(math.Inf(1)), float32(math.Inf(-1))} {
+		field := make([]float32, 16)
+		field[9] = bad
+		_, err := GoSource(field, 4, 4, opts)
+		if err == nil || !strings.Contains(err.Error(), "1,2") {
+			t.Errorf("%v at 1,2 gave %v, want an error naming the pixel", bad, err)
+		}
+	}
+	// a value downsampling skips does not matter
+	field := make([]float32, 16)
+	field[1] = float32(math.NaN())
+	if _, err := GoSource(field, 4, 4, GoSourceOptions{Package: "maps", Name: "Field", Downsample: 2}); err != nil {
+		t.Errorf("NaN left out by downsampling: %v", err)
+	}
+
+	if _, err := GoSource(make([]float32, 15), 4, 4, opts); err == nil {
+		t.Error("15 values were written as a 4x4 field")
+	}
+	opts.MaxSamples = 15
+	if _, err := GoSource(make([]float32, 16), 4, 4, opts); err == nil {
+		t.Error("16 samples were written with a limit of 15")
+	}
+}
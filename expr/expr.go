@@ -0,0 +1,177 @@
+// Package expr evaluates noise formulas such as
+//
+//	turbulence(x, y, 0.01, 2, 0.5, 4) * mix(0.5, 1, smoothstep(-1, 1, sin(x*0.02)))
+//
+// at every point of a plane. An expression is built from numbers, the
+// coordinates x and y, + - * / with the usual precedence, unary minus,
+// parentheses and calls to these functions:
+//
+//	snoise2(x, y)
+//	fbm2(x, y, frequency, lacunarity, gain, octaves)
+//	turbulence(x, y, frequency, lacunarity, gain, octaves)
+//	ridged(x, y, frequency, lacunarity, gain, octaves)
+//	mix(a, b, t)
+//	smoothstep(edge0, edge1, v)
+//	abs(v), pow(v, e), sin(v), cos(v)
+//
+// The noise functions are noise.Snoise2, noise.Fbm2, noise.Turbulence and
+// noise.Ridged2 with the same arguments, octaves is truncated to a whole
+// number up to 16. mix is a+(b-a)*t and smoothstep is the usual hermite step, 0
+// below edge0 and 1 above edge1.
+package expr
+
+import (
+	"math"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// Node is a parsed expression, or part of one, that can be evaluated at any
+// x, y. Nodes are never changed once parsed, so a Node can be evaluated
+// from any number of goroutines at once.
+type Node interface {
+	Eval(x, y float32) float32
+}
+
+type number float32
+
+func (n number) Eval(x, y float32) float32 {
+	return float32(n)
+}
+
+// variable is x or y
+type variable byte
+
+func (v variable) Eval(x, y float32) float32 {
+	if v == 'x' {
+		return x
+	}
+	return y
+}
+
+type negate struct {
+	arg Node
+}
+
+func (n negate) Eval(x, y float32) float32 {
+	return -n.arg.Eval(x, y)
+}
+
+// binary is one of + - * / applied to left and right
+type binary struct {
+	op          byte
+	left, right Node
+}
+
+func (b binary) Eval(x, y float32) float32 {
+	l, r := b.left.Eval(x, y), b.right.Eval(x, y)
+	switch b.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	default:
+		return l / r
+	}
+}
+
+// builtin is one of the functions expressions can call
+type builtin int
+
+const (
+	snoise2Fn builtin = iota
+	fbm2Fn
+	turbulenceFn
+	ridgedFn
+	mixFn
+	smoothstepFn
+	absFn
+	powFn
+	sinFn
+	cosFn
+)
+
+// functions maps the names expressions call the builtins by to the
+// builtins and how many arguments they take
+var functions = map[string]struct {
+	fn    builtin
+	arity int
+}{
+	"snoise2":    {snoise2Fn, 2},
+	"fbm2":       {fbm2Fn, 6},
+	"turbulence": {turbulenceFn, 6},
+	"ridged":     {ridgedFn, 6},
+	"mix":        {mixFn, 3},
+	"smoothstep": {smoothstepFn, 3},
+	"abs":        {absFn, 1},
+	"pow":        {powFn, 2},
+	"sin":        {sinFn, 1},
+	"cos":        {cosFn, 1},
+}
+
+const (
+	// maxArgs is the most arguments any builtin takes
+	maxArgs = 6
+	// maxOctaves caps the octaves of the fractal builtins, the count can
+	// come from anywhere and a pixel must not take forever
+	maxOctaves = 16
+)
+
+// octaves truncates v to a whole number of octaves within 0..maxOctaves
+func octaves(v float32) int {
+	if !(v > 0) {
+		return 0
+	}
+	if v > maxOctaves {
+		return maxOctaves
+	}
+	return int(v)
+}
+
+// call is fn applied to the values of args, which has as many elements as
+// fn takes arguments
+type call struct {
+	fn   builtin
+	args []Node
+}
+
+func (c call) Eval(x, y float32) float32 {
+	// the arguments stay on the stack, Eval runs for every pixel
+	var a [maxArgs]float32
+	for i, arg := range c.args {
+		a[i] = arg.Eval(x, y)
+	}
+	switch c.fn {
+	case snoise2Fn:
+		return noise.Snoise2(a[0], a[1])
+	case fbm2Fn:
+		return noise.Fbm2(a[0], a[1], a[2], a[3], a[4], octaves(a[5]))
+	case turbulenceFn:
+		return noise.Turbulence(a[0], a[1], a[2], a[3], a[4], octaves(a[5]))
+	case ridgedFn:
+		return noise.Ridged2(a[0], a[1], a[2], a[3], a[4], octaves(a[5]))
+	case mixFn:
+		return a[0] + (a[1]-a[0])*a[2]
+	case smoothstepFn:
+		t := (a[2] - a[0]) / (a[1] - a[0])
+		if t < 0 {
+			t = 0
+		} else if t > 1 {
+			t = 1
+		}
+		return t * t * (3 - 2*t)
+	case absFn:
+		if a[0] < 0 {
+			return -a[0]
+		}
+		return a[0]
+	case powFn:
+		return float32(math.Pow(float64(a[0]), float64(a[1])))
+	case sinFn:
+		return float32(math.Sin(float64(a[0])))
+	default:
+		return float32(math.Cos(float64(a[0])))
+	}
+}
@@ -0,0 +1,201 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parser is a recursive descent parser over src, one method per level of
+// precedence:
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | "x" | "y" | name "(" sum { "," sum } ")" | "(" sum ")"
+//
+// pos is the offset of the next byte not yet looked at
+type parser struct {
+	src string
+	pos int
+}
+
+// Parse parses src into a Node. Errors give the offset into src they were
+// found at.
+func Parse(src string) (Node, error) {
+	ps := &parser{src: src}
+	n, err := ps.sum()
+	if err != nil {
+		return nil, err
+	}
+	if ps.peek() != 0 {
+		return nil, ps.errorf("unexpected %q", ps.src[ps.pos])
+	}
+	return n, nil
+}
+
+func (ps *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("expr: %d: %s", ps.pos, fmt.Sprintf(format, args...))
+}
+
+// peek skips spaces and returns the next byte, or 0 at the end
+func (ps *parser) peek() byte {
+	for ps.pos < len(ps.src) && (ps.src[ps.pos] == ' ' || ps.src[ps.pos] == '\t' || ps.src[ps.pos] == '\n') {
+		ps.pos++
+	}
+	if ps.pos == len(ps.src) {
+		return 0
+	}
+	return ps.src[ps.pos]
+}
+
+// expect consumes c or fails
+func (ps *parser) expect(c byte) error {
+	if ps.peek() != c {
+		if ps.pos == len(ps.src) {
+			return ps.errorf("expected %q, got the end", c)
+		}
+		return ps.errorf("expected %q, got %q", c, ps.src[ps.pos])
+	}
+	ps.pos++
+	return nil
+}
+
+func (ps *parser) sum() (Node, error) {
+	left, err := ps.product()
+	if err != nil {
+		return nil, err
+	}
+	for op := ps.peek(); op == '+' || op == '-'; op = ps.peek() {
+		ps.pos++
+		right, err := ps.product()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op, left, right}
+	}
+	return left, nil
+}
+
+func (ps *parser) product() (Node, error) {
+	left, err := ps.unary()
+	if err != nil {
+		return nil, err
+	}
+	for op := ps.peek(); op == '*' || op == '/'; op = ps.peek() {
+		ps.pos++
+		right, err := ps.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op, left, right}
+	}
+	return left, nil
+}
+
+func (ps *parser) unary() (Node, error) {
+	if ps.peek() == '-' {
+		ps.pos++
+		arg, err := ps.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negate{arg}, nil
+	}
+	return ps.primary()
+}
+
+func (ps *parser) primary() (Node, error) {
+	c := ps.peek()
+	switch {
+	case c == 0:
+		return nil, ps.errorf("unexpected end")
+	case c == '(':
+		ps.pos++
+		n, err := ps.sum()
+		if err != nil {
+			return nil, err
+		}
+		return n, ps.expect(')')
+	case isDigit(c) || c == '.':
+		return ps.number()
+	case isLetter(c):
+		return ps.name()
+	}
+	return nil, ps.errorf("unexpected %q", c)
+}
+
+// number reads a decimal number with an optional exponent, like 2, 0.5, .5
+// or 1e-3
+func (ps *parser) number() (Node, error) {
+	start := ps.pos
+	for ps.pos < len(ps.src) && (isDigit(ps.src[ps.pos]) || ps.src[ps.pos] == '.') {
+		ps.pos++
+	}
+	if ps.pos < len(ps.src) && (ps.src[ps.pos] == 'e' || ps.src[ps.pos] == 'E') {
+		ps.pos++
+		if ps.pos < len(ps.src) && (ps.src[ps.pos] == '+' || ps.src[ps.pos] == '-') {
+			ps.pos++
+		}
+		for ps.pos < len(ps.src) && isDigit(ps.src[ps.pos]) {
+			ps.pos++
+		}
+	}
+	text := ps.src[start:ps.pos]
+	v, err := strconv.ParseFloat(text, 32)
+	if err != nil {
+		ps.pos = start
+		return nil, ps.errorf("bad number %q", text)
+	}
+	return number(v), nil
+}
+
+// name reads x, y or a call, the arguments are checked against the
+// function's arity here so Eval never has to
+func (ps *parser) name() (Node, error) {
+	start := ps.pos
+	for ps.pos < len(ps.src) && (isLetter(ps.src[ps.pos]) || isDigit(ps.src[ps.pos])) {
+		ps.pos++
+	}
+	name := ps.src[start:ps.pos]
+	if name == "x" || name == "y" {
+		return variable(name[0]), nil
+	}
+	f, ok := functions[name]
+	if !ok {
+		ps.pos = start
+		return nil, ps.errorf("unknown name %q", name)
+	}
+	err := ps.expect('(')
+	if err != nil {
+		return nil, err
+	}
+	var args []Node
+	for {
+		arg, err := ps.sum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if ps.peek() != ',' {
+			break
+		}
+		ps.pos++
+	}
+	err = ps.expect(')')
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != f.arity {
+		ps.pos = start
+		return nil, ps.errorf("%s takes %d arguments, got %d", name, f.arity, len(args))
+	}
+	return call{f.fn, args}, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
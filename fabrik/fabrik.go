@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sabith-th/games_with_go/ik"
+	"github.com/sabith-th/games_with_go/vector2"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	maxIterations = 10
+	armLinks      = 6
+	armLinkLength = 45
+
+	numLegs = 8
+	// legs reach out from the body this far and their two links are
+	// thigh and shin long
+	legSpread   = 110
+	thighLength = 60
+	shinLength  = 70
+)
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	groundColor = color{90, 110, 70}
+	linkColor   = color{230, 230, 230}
+	jointColor  = color{255, 80, 40}
+	bodyColor   = color{160, 60, 200}
+	targetColor = color{60, 200, 255}
+)
+
+// segment is one piece of walkable surface, a is left of b
+type segment struct {
+	a, b vector2.Vector2
+}
+
+// surfaces is the ground across the window plus two ledges above it
+var surfaces = []segment{
+	{vector2.Vector2{X: 0, Y: 520}, vector2.Vector2{X: 150, Y: 540}},
+	{vector2.Vector2{X: 150, Y: 540}, vector2.Vector2{X: 300, Y: 500}},
+	{vector2.Vector2{X: 300, Y: 500}, vector2.Vector2{X: 420, Y: 560}},
+	{vector2.Vector2{X: 420, Y: 560}, vector2.Vector2{X: 600, Y: 530}},
+	{vector2.Vector2{X: 600, Y: 530}, vector2.Vector2{X: 800, Y: 470}},
+	{vector2.Vector2{X: 80, Y: 360}, vector2.Vector2{X: 260, Y: 360}},
+	{vector2.Vector2{X: 480, Y: 320}, vector2.Vector2{X: 680, Y: 340}},
+}
+
+// surfaceBelow returns the height of the nearest surface at or below y at
+// column x, if there is one
+func surfaceBelow(x, y float32) (float32, bool) {
+	found := false
+	var best float32
+	for _, s := range surfaces {
+		if x < s.a.X || x > s.b.X {
+			continue
+		}
+		sy := s.a.Y + (x-s.a.X)/(s.b.X-s.a.X)*(s.b.Y-s.a.Y)
+		if sy >= y && (!found || sy < best) {
+			best = sy
+			found = true
+		}
+	}
+	return best, found
+}
+
+func clear(pixels []byte) {
+	for i := range pixels {
+		pixels[i] = 0
+	}
+}
+
+func setPixel(x, y int, c color, pixels []byte) {
+	if x < 0 || x >= winWidth || y < 0 || y >= winHeight {
+		return
+	}
+	index := (y*winWidth + x) * 4
+	pixels[index] = c.r
+	pixels[index+1] = c.g
+	pixels[index+2] = c.b
+}
+
+// drawLine draws a line between two points with Bresenham's algorithm
+func drawLine(a, b vector2.Vector2, c color, pixels []byte) {
+	x0, y0 := int(a.X), int(a.Y)
+	x1, y1 := int(b.X), int(b.Y)
+	dx := x1 - x0
+	if dx < 0 {
+		dx = -dx
+	}
+	dy := y1 - y0
+	if dy > 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		setPixel(x0, y0, c, pixels)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func drawPoint(p vector2.Vector2, size int, c color, pixels []byte) {
+	for y := -size; y <= size; y++ {
+		for x := -size; x <= size; x++ {
+			setPixel(int(p.X)+x, int(p.Y)+y, c, pixels)
+		}
+	}
+}
+
+func drawChain(chain *ik.Chain, pixels []byte) {
+	for i := 1; i < len(chain.Joints); i++ {
+		drawLine(chain.Joints[i-1], chain.Joints[i], linkColor, pixels)
+	}
+	for _, j := range chain.Joints {
+		drawPoint(j, 2, jointColor, pixels)
+	}
+}
+
+func drawSurfaces(pixels []byte) {
+	for _, s := range surfaces {
+		drawLine(s.a, s.b, groundColor, pixels)
+	}
+}
+
+// spider is a body with numLegs chains fixed to it. Every foot is planted
+// on the surface below the point its leg reaches out to.
+type spider struct {
+	body vector2.Vector2
+	legs []*ik.Chain
+}
+
+func newSpider(body vector2.Vector2) *spider {
+	s := &spider{body: body}
+	for i := 0; i < numLegs; i++ {
+		s.legs = append(s.legs, ik.NewChain(s.legRoot(i), []float32{thighLength, shinLength}, s.kneeDir(i)))
+	}
+	return s
+}
+
+// kneeDir points up and away from the body on leg i's side
+func (s *spider) kneeDir(i int) vector2.Vector2 {
+	if s.legOffset(i) > 0 {
+		return vector2.Vector2{X: 1, Y: -1}
+	}
+	return vector2.Vector2{X: -1, Y: -1}
+}
+
+// legOffset is how far along x leg i reaches from the body, spreading the
+// legs evenly from -legSpread to legSpread
+func (s *spider) legOffset(i int) float32 {
+	return -legSpread + 2*legSpread*float32(i)/float32(numLegs-1)
+}
+
+func (s *spider) legRoot(i int) vector2.Vector2 {
+	return vector2.Add(s.body, vector2.Vector2{X: s.legOffset(i) * 0.15})
+}
+
+// moveTo puts the body at pos and plants every foot. A leg with no surface
+// below its reach point hangs straight down.
+func (s *spider) moveTo(pos vector2.Vector2) {
+	s.body = pos
+	for i, leg := range s.legs {
+		// FABRIK keeps the bend it starts from, so the knee is put back up
+		// and out first or the legs would slowly fold the wrong way
+		leg.Joints[0] = s.legRoot(i)
+		leg.Joints[1] = vector2.Add(leg.Joints[0], vector2.Mult(vector2.Normalize(s.kneeDir(i)), thighLength))
+		x := pos.X + s.legOffset(i)
+		target := vector2.Vector2{X: x, Y: pos.Y + leg.Reach()}
+		if y, ok := surfaceBelow(x, pos.Y); ok {
+			target.Y = y
+		}
+		ik.FABRIK(leg, target, maxIterations)
+	}
+}
+
+func (s *spider) draw(pixels []byte) {
+	for _, leg := range s.legs {
+		drawChain(leg, pixels)
+	}
+	drawPoint(s.body, 8, bodyColor, pixels)
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("FABRIK", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	lengths := make([]float32, armLinks)
+	for i := range lengths {
+		lengths[i] = armLinkLength
+	}
+	armRoot := vector2.Vector2{X: float32(winWidth) / 2, Y: 530}
+	arm := ik.NewChain(armRoot, lengths, vector2.Vector2{X: 0, Y: -1})
+	s := newSpider(vector2.Vector2{X: float32(winWidth) / 2, Y: 400})
+	spiderMode := false
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == sdl.SCANCODE_SPACE {
+					spiderMode = !spiderMode
+				}
+			}
+		}
+
+		mouseX, mouseY, _ := sdl.GetMouseState()
+		target := vector2.Vector2{X: float32(mouseX), Y: float32(mouseY)}
+
+		clear(pixels)
+		drawSurfaces(pixels)
+		if spiderMode {
+			s.moveTo(target)
+			s.draw(pixels)
+		} else {
+			ik.FABRIK(arm, target, maxIterations)
+			drawChain(arm, pixels)
+			drawPoint(target, 3, targetColor, pixels)
+		}
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}
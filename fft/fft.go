@@ -0,0 +1,135 @@
+package fft
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// IsPowerOfTwo reports whether n can be transformed by FFT
+func IsPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+// FFT computes the discrete fourier transform of re + i*im in place using
+// the iterative radix-2 Cooley-Tukey algorithm. The length must be a power of two.
+func FFT(re, im []float64) error {
+	n := len(re)
+	if len(im) != n {
+		return fmt.Errorf("fft: real and imaginary parts differ in length, %d != %d", n, len(im))
+	}
+	if !IsPowerOfTwo(n) {
+		return fmt.Errorf("fft: length %d is not a power of two", n)
+	}
+
+	if n == 1 {
+		return nil
+	}
+
+	// reorder into bit reversed index order so the butterflies can run in place
+	shift := uint(bits.UintSize - bits.Len(uint(n-1)))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse(uint(i)) >> shift)
+		if j > i {
+			re[i], re[j] = re[j], re[i]
+			im[i], im[j] = im[j], im[i]
+		}
+	}
+
+	for size := 2; size <= n; size *= 2 {
+		half := size / 2
+		angle := -2 * math.Pi / float64(size)
+		wRe, wIm := math.Cos(angle), math.Sin(angle)
+		for start := 0; start < n; start += size {
+			tRe, tIm := 1.0, 0.0
+			for k := 0; k < half; k++ {
+				a := start + k
+				b := a + half
+				bRe := re[b]*tRe - im[b]*tIm
+				bIm := re[b]*tIm + im[b]*tRe
+				re[b] = re[a] - bRe
+				im[b] = im[a] - bIm
+				re[a] += bRe
+				im[a] += bIm
+				tRe, tIm = tRe*wRe-tIm*wIm, tRe*wIm+tIm*wRe
+			}
+		}
+	}
+	return nil
+}
+
+// FFT2D transforms a w*h grid stored row by row in place, rows first then columns
+func FFT2D(re, im []float64, w, h int) error {
+	if len(re) != w*h || len(im) != w*h {
+		return fmt.Errorf("fft: %dx%d grid needs %d values, got %d and %d", w, h, w*h, len(re), len(im))
+	}
+	for y := 0; y < h; y++ {
+		err := FFT(re[y*w:(y+1)*w], im[y*w:(y+1)*w])
+		if err != nil {
+			return err
+		}
+	}
+	colRe := make([]float64, h)
+	colIm := make([]float64, h)
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			colRe[y] = re[y*w+x]
+			colIm[y] = im[y*w+x]
+		}
+		err := FFT(colRe, colIm)
+		if err != nil {
+			return err
+		}
+		for y := 0; y < h; y++ {
+			re[y*w+x] = colRe[y]
+			im[y*w+x] = colIm[y]
+		}
+	}
+	return nil
+}
+
+// Magnitude2D returns the magnitude of the 2d transform of field with the
+// zero frequency moved to the centre of the grid. The mean is subtracted and
+// a hann window applied first so the DC term and the hard edges of the
+// sample don't drown out the rest of the spectrum.
+func Magnitude2D(field []float32, w, h int) ([]float64, error) {
+	if len(field) != w*h {
+		return nil, fmt.Errorf("fft: %dx%d grid needs %d values, got %d", w, h, w*h, len(field))
+	}
+	var mean float64
+	for _, v := range field {
+		mean += float64(v)
+	}
+	mean /= float64(len(field))
+
+	re := make([]float64, w*h)
+	im := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		wy := hann(y, h)
+		for x := 0; x < w; x++ {
+			re[y*w+x] = (float64(field[y*w+x]) - mean) * wy * hann(x, w)
+		}
+	}
+	err := FFT2D(re, im, w, h)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		sy := (y + h/2) % h
+		for x := 0; x < w; x++ {
+			sx := (x + w/2) % w
+			i := y*w + x
+			result[sy*w+sx] = math.Hypot(re[i], im[i])
+		}
+	}
+	return result, nil
+}
+
+func hann(i, n int) float64 {
+	if n < 2 {
+		return 1
+	}
+	return 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+}
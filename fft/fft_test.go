@@ -0,0 +1,100 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+// tolerance is how far from exact the transforms may be, rounding grows
+// with log n and stays well under it for these sizes
+const tolerance = 1e-9
+
+func TestFFTSinePeak(t *testing.T) {
+	for _, n := range []int{4, 16, 64, 512} {
+		for _, k := range []int{1, 3, n/2 - 1} {
+			re, im := make([]float64, n), make([]float64, n)
+			for i := range re {
+				re[i] = math.Sin(2 * math.Pi * float64(k*i) / float64(n))
+			}
+			err := FFT(re, im)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a real sine of amplitude 1 is n/2 at bins k and n-k, and
+			// nothing anywhere else
+			for bin := range re {
+				want := 0.0
+				if bin == k || bin == n-k {
+					want = float64(n) / 2
+				}
+				if got := math.Hypot(re[bin], im[bin]); math.Abs(got-want) > tolerance*float64(n) {
+					t.Errorf("n %d, sine at %d: bin %d has magnitude %v, want %v", n, k, bin, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestFFTMatchesDFT(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const n = 32
+	re, im := make([]float64, n), make([]float64, n)
+	for i := range re {
+		re[i], im[i] = rng.Float64()*2-1, rng.Float64()*2-1
+	}
+	want := make([]complex128, n)
+	for k := range want {
+		for i := range re {
+			want[k] += complex(re[i], im[i]) * cmplx.Exp(complex(0, -2*math.Pi*float64(k*i)/n))
+		}
+	}
+	err := FFT(re, im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := range want {
+		if d := cmplx.Abs(complex(re[k], im[k]) - want[k]); d > tolerance*n {
+			t.Errorf("bin %d is %v, the DFT gives %v", k, complex(re[k], im[k]), want[k])
+		}
+	}
+}
+
+func TestFFT2DPeak(t *testing.T) {
+	const w, h = 16, 8
+	const kx, ky = 3, 2
+	re, im := make([]float64, w*h), make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			re[y*w+x] = math.Cos(2 * math.Pi * (float64(kx*x)/w + float64(ky*y)/h))
+		}
+	}
+	err := FFT2D(re, im, w, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			want := 0.0
+			if (x == kx && y == ky) || (x == w-kx && y == h-ky) {
+				want = w * h / 2
+			}
+			if got := math.Hypot(re[y*w+x], im[y*w+x]); math.Abs(got-want) > tolerance*w*h {
+				t.Errorf("bin %d,%d has magnitude %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestFFTErrors(t *testing.T) {
+	if err := FFT(make([]float64, 6), make([]float64, 6)); err == nil {
+		t.Error("length 6 was transformed")
+	}
+	if err := FFT(make([]float64, 8), make([]float64, 4)); err == nil {
+		t.Error("parts of different lengths were transformed")
+	}
+	if err := FFT2D(make([]float64, 8), make([]float64, 8), 4, 4); err == nil {
+		t.Error("8 values were transformed as a 4x4 grid")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sabith-th/games_with_go/config"
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/gfx"
+	"github.com/sabith-th/games_with_go/puzzle15"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const tileSize = 120
+
+// the arrow keys slide the tile on the other side of the space towards it
+var slideKeys = []struct {
+	sc     gfx.Scancode
+	dr, dc int
+}{
+	{gfx.KeyLeft, 0, 1},
+	{gfx.KeyRight, 0, -1},
+	{gfx.KeyUp, 1, 0},
+	{gfx.KeyDown, -1, 0},
+}
+
+const help = "arrows/click: slide  r: shuffle  e: scramble  s: solve"
+
+var white = font.Color{R: 255, G: 255, B: 255}
+
+// easyMoves is how many random moves from solved E scrambles the board by,
+// few enough for Solve to search without the pattern database
+const easyMoves = 24
+
+// fifteen is a game of the 15-puzzle, moves counts the moves made since
+// the last shuffle
+type fifteen struct {
+	view  *puzzle15.View
+	seed  int64
+	moves int
+	// pdb is nil until the pattern database arrives on loaded, until then
+	// only boards close to solved can be solved
+	pdb    *puzzle15.PatternDatabase
+	loaded chan *puzzle15.PatternDatabase
+	// solving is set while a solution is searched for and played, the
+	// player's moves wait for it. searching is set until the search sends
+	// its moves on solution.
+	solving   bool
+	searching bool
+	solution  chan []puzzle15.Move
+	quit      bool
+}
+
+// solve searches for the moves that solve the board in the background,
+// with IDA* once the pattern database is there and breadth first before
+func (f *fifteen) solve() {
+	b, pdb := f.view.Board(), f.pdb
+	f.solving, f.searching = true, true
+	go func() {
+		start := time.Now()
+		var moves []puzzle15.Move
+		if pdb != nil {
+			moves, _ = puzzle15.IDAStar(b, pdb.Heuristic)
+		} else {
+			moves = puzzle15.Solve(b)
+		}
+		if moves != nil {
+			fmt.Println("solved in", len(moves), "moves, searching took", time.Since(start))
+		}
+		f.solution <- moves
+	}()
+}
+
+func (f *fifteen) shuffle() {
+	f.seed++
+	f.view.Set(puzzle15.Shuffle(f.seed))
+	f.moves = 0
+	f.solving = false
+}
+
+// scramble makes easyMoves random moves from solved, never straight back
+func (f *fifteen) scramble() {
+	f.seed++
+	r := rand.New(rand.NewSource(f.seed))
+	b := puzzle15.Solved
+	var last uint8
+	for i := 0; i < easyMoves; {
+		row, col := b.Find(0)
+		d := slideKeys[r.Intn(len(slideKeys))]
+		row, col = row+d.dr, col+d.dc
+		if row < 0 || row >= puzzle15.Size || col < 0 || col >= puzzle15.Size || b[row][col] == last {
+			continue
+		}
+		last = b[row][col]
+		b, _ = puzzle15.MoveTile(b, last)
+		i++
+	}
+	f.view.Set(b)
+	f.moves = 0
+	f.solving = false
+}
+
+func (f *fifteen) slide(tile uint8) {
+	if tile != 0 && f.view.Slide(tile) {
+		f.moves++
+	}
+}
+
+func (f *fifteen) Update(in gfx.Input, dt float64) {
+	f.quit = in.Pressed(gfx.KeyEscape)
+	f.view.Update(dt)
+	select {
+	case f.pdb = <-f.loaded:
+	default:
+	}
+	if f.solving {
+		select {
+		case moves := <-f.solution:
+			f.searching = false
+			if moves == nil {
+				fmt.Println("too far from solved to search without the pattern database, e scrambles a board it can solve")
+			}
+			for _, m := range moves {
+				f.slide(uint8(m))
+			}
+		default:
+		}
+		f.solving = f.searching || f.view.Busy()
+		return
+	}
+	if in.Pressed(gfx.KeyR) {
+		f.shuffle()
+	}
+	if in.Pressed(gfx.KeyE) {
+		f.scramble()
+	}
+	if in.Pressed(gfx.KeyS) {
+		f.solve()
+	}
+	b := f.view.Board()
+	row, col := b.Find(0)
+	for _, k := range slideKeys {
+		r, c := row+k.dr, col+k.dc
+		if in.Pressed(k.sc) && r >= 0 && r < puzzle15.Size && c >= 0 && c < puzzle15.Size {
+			f.slide(b[r][c])
+		}
+	}
+	if in.Clicked&gfx.MouseLeft != 0 {
+		f.slide(f.view.TileAt(in.MouseX, in.MouseY))
+	}
+}
+
+func (f *fifteen) Draw(pixels []byte, w, h int) {
+	for i := range pixels {
+		pixels[i] = 0
+	}
+	f.view.Draw(pixels, w, h)
+	status := fmt.Sprintf("moves %d", f.moves)
+	if f.view.Board() == puzzle15.Solved && !f.view.Busy() {
+		status += " solved"
+	}
+	font.Draw(status, f.view.X, 16, 3, white, pixels, w, h)
+	tw, _ := font.Size(help, 2)
+	font.Draw(help, (w-tw)/2, winHeight-32, 2, white, pixels, w, h)
+}
+
+func (f *fifteen) Done() bool {
+	return f.quit
+}
+
+// defaultPDBPath is where the pattern database is kept between runs, in the
+// user's cache directory if there is one
+func defaultPDBPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gameswithgo", "puzzle15-663.pdb")
+}
+
+func main() {
+	pdbPath := flag.String("pdb", defaultPDBPath(), "read the 6-6-3 pattern database from this file, it is built and saved there if it is missing")
+	configFile := flag.String("config", "", "read flags not given on the command line from this JSON file")
+	flag.Parse()
+	if *configFile != "" {
+		err := config.LoadConfig(flag.CommandLine, *configFile)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	x, y := (winWidth-puzzle15.Size*tileSize)/2, (winHeight-puzzle15.Size*tileSize)/2
+	f := &fifteen{
+		view:     puzzle15.NewView(puzzle15.Solved, x, y, tileSize),
+		seed:     time.Now().UnixNano(),
+		loaded:   make(chan *puzzle15.PatternDatabase, 1),
+		solution: make(chan []puzzle15.Move, 1),
+	}
+	f.shuffle()
+	// building the database takes a few seconds the first time, the game
+	// is playable meanwhile
+	go func() {
+		db, err := puzzle15.LoadPatternDatabase(*pdbPath, puzzle15.Groups663)
+		if err != nil {
+			fmt.Println(err)
+		}
+		if db != nil {
+			f.loaded <- db
+		}
+	}()
+	err := gfx.Run("Fifteen", winWidth, winHeight, f)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,151 @@
+package font
+
+// GlyphWidth and GlyphHeight are the size of one unscaled character cell,
+// characters are drawn with one column and one row of spacing
+const GlyphWidth, GlyphHeight = 5, 7
+
+// Color an rgb color
+type Color struct {
+	R, G, B byte
+}
+
+// glyphs maps each supported character to 7 rows of 5 columns, '#' is lit.
+// Lower case letters are drawn as upper case and anything else missing as '?'.
+// ☀ ☁ ☂ ❄ are icons for sun, cloud, rain and snow.
+var glyphs = map[rune][GlyphHeight]string{
+	' ':  {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+	'0':  {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1':  {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2':  {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3':  {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4':  {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5':  {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6':  {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7':  {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8':  {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9':  {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	'A':  {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'B':  {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'C':  {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'D':  {"###..", "#..#.", "#...#", "#...#", "#...#", "#..#.", "###.."},
+	'E':  {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'F':  {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'G':  {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+	'H':  {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'I':  {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'J':  {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'K':  {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'L':  {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'M':  {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'N':  {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+	'O':  {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'P':  {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'Q':  {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+	'R':  {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	'S':  {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+	'T':  {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'U':  {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'V':  {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'W':  {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+	'X':  {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+	'Y':  {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'Z':  {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+	'.':  {".....", ".....", ".....", ".....", ".....", ".##..", ".##.."},
+	',':  {".....", ".....", ".....", ".....", ".##..", "..#..", ".#..."},
+	':':  {".....", ".##..", ".##..", ".....", ".##..", ".##..", "....."},
+	';':  {".....", ".##..", ".##..", ".....", ".##..", "..#..", ".#..."},
+	'!':  {"..#..", "..#..", "..#..", "..#..", "..#..", ".....", "..#.."},
+	'?':  {".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#.."},
+	'%':  {"##...", "##..#", "...#.", "..#..", ".#...", "#..##", "...##"},
+	'-':  {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	'+':  {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	'=':  {".....", ".....", "#####", ".....", "#####", ".....", "....."},
+	'/':  {".....", "....#", "...#.", "..#..", ".#...", "#....", "....."},
+	'(':  {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#."},
+	')':  {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#..."},
+	'[':  {".###.", ".#...", ".#...", ".#...", ".#...", ".#...", ".###."},
+	']':  {".###.", "...#.", "...#.", "...#.", "...#.", "...#.", ".###."},
+	'<':  {"...#.", "..#..", ".#...", "#....", ".#...", "..#..", "...#."},
+	'>':  {".#...", "..#..", "...#.", "....#", "...#.", "..#..", ".#..."},
+	'_':  {".....", ".....", ".....", ".....", ".....", ".....", "#####"},
+	'\'': {"..#..", "..#..", ".#...", ".....", ".....", ".....", "....."},
+	'"':  {".#.#.", ".#.#.", ".#.#.", ".....", ".....", ".....", "....."},
+	'#':  {".#.#.", ".#.#.", "#####", ".#.#.", "#####", ".#.#.", ".#.#."},
+	'*':  {".....", "..#..", "#.#.#", ".###.", "#.#.#", "..#..", "....."},
+	'☀':  {"..#..", "#.#.#", ".###.", "#####", ".###.", "#.#.#", "..#.."},
+	'☁':  {".....", "..##.", ".####", "#####", "#####", ".....", "....."},
+	'☂':  {"..#..", ".###.", "#####", "..#..", "..#..", "#.#..", ".#..."},
+	'❄':  {"#...#", ".#.#.", "..#..", "#####", "..#..", ".#.#.", "#...#"},
+}
+
+func glyph(r rune) [GlyphHeight]string {
+	if r >= 'a' && r <= 'z' {
+		r -= 'a' - 'A'
+	}
+	g, ok := glyphs[r]
+	if !ok {
+		return glyphs['?']
+	}
+	return g
+}
+
+// setPixel writes c into a w*h ABGR8888 buffer, ignoring points outside it
+func setPixel(x, y int, c Color, pixels []byte, w, h int) {
+	if x < 0 || x >= w || y < 0 || y >= h {
+		return
+	}
+	index := (y*w + x) * 4
+	pixels[index] = c.R
+	pixels[index+1] = c.G
+	pixels[index+2] = c.B
+}
+
+// Draw writes text into the w*h pixel buffer with its top left corner at
+// x, y, each font pixel becoming a scale*scale block. A newline starts a new
+// line below x. Only the lit pixels are written, the background shows
+// through.
+func Draw(text string, x, y, scale int, c Color, pixels []byte, w, h int) {
+	startX := x
+	for _, r := range text {
+		if r == '\n' {
+			x = startX
+			y += (GlyphHeight + 1) * scale
+			continue
+		}
+		g := glyph(r)
+		for row, line := range g {
+			for col := 0; col < GlyphWidth; col++ {
+				if line[col] != '#' {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						setPixel(x+col*scale+dx, y+row*scale+dy, c, pixels, w, h)
+					}
+				}
+			}
+		}
+		x += (GlyphWidth + 1) * scale
+	}
+}
+
+// Size returns the width and height in pixels that Draw covers for text
+func Size(text string, scale int) (width, height int) {
+	lines, longest, current := 1, 0, 0
+	for _, r := range text {
+		if r == '\n' {
+			lines++
+			current = 0
+			continue
+		}
+		current++
+		if current > longest {
+			longest = current
+		}
+	}
+	height = (lines*(GlyphHeight+1) - 1) * scale
+	if longest == 0 {
+		return 0, height
+	}
+	return (longest*(GlyphWidth+1) - 1) * scale, height
+}
@@ -0,0 +1,56 @@
+package font
+
+import "strings"
+
+// Wrap breaks text into lines that Draw at scale fits in maxWidth pixels.
+// Lines break between words, the spaces there are dropped, and a word too
+// long for a line of its own is broken between characters. The newlines
+// already in text are kept, an empty line stays empty.
+func Wrap(text string, maxWidth, scale int) []string {
+	// every character but the last takes a column of spacing after it
+	perLine := (maxWidth + scale) / ((GlyphWidth + 1) * scale)
+	if perLine < 1 {
+		perLine = 1
+	}
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		var line []rune
+		for _, field := range strings.Fields(para) {
+			word := []rune(field)
+			if len(line) > 0 && len(line)+1+len(word) <= perLine {
+				line = append(append(line, ' '), word...)
+				continue
+			}
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+			}
+			for len(word) > perLine {
+				lines = append(lines, string(word[:perLine]))
+				word = word[perLine:]
+			}
+			line = word
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// DrawWrapped draws text wrapped by Wrap to maxWidth pixels, with its top
+// left corner at x, y and its lines lineHeight pixels apart. Every line is
+// drawn over a block of bg as wide as the line and lineHeight high. It
+// returns where the next character would go, just right of the last line.
+func DrawWrapped(text string, x, y, maxWidth, lineHeight, scale int, fg, bg Color, pixels []byte, w, h int) (endX, endY int) {
+	lines := Wrap(text, maxWidth, scale)
+	for i, line := range lines {
+		ly := y + i*lineHeight
+		lw := len([]rune(line)) * (GlyphWidth + 1) * scale
+		for py := ly; py < ly+lineHeight; py++ {
+			for px := x; px < x+lw; px++ {
+				setPixel(px, py, bg, pixels, w, h)
+			}
+		}
+		Draw(line, x, ly, scale, fg, pixels, w, h)
+		endX = x + lw
+	}
+	return endX, y + (len(lines)-1)*lineHeight
+}
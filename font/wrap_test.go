@@ -0,0 +1,89 @@
+package font
+
+import (
+	"reflect"
+	"testing"
+)
+
+// cells is the width Draw takes for n characters at scale 1
+func cells(n int) int {
+	return n*(GlyphWidth+1) - 1
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{"fits", "one two", cells(7), []string{"one two"}},
+		{"breaks between words", "one two three", cells(8), []string{"one two", "three"}},
+		{"exact fit", "one two", cells(7), []string{"one two"}},
+		{"one short", "one two", cells(6), []string{"one", "two"}},
+		{"spaces collapse", "  one   two  ", cells(20), []string{"one two"}},
+		{"newlines kept", "one\ntwo three", cells(20), []string{"one", "two three"}},
+		{"empty line kept", "one\n\ntwo", cells(20), []string{"one", "", "two"}},
+		{"long word broken", "abcdefghij", cells(4), []string{"abcd", "efgh", "ij"}},
+		{"long word after a short one", "ab cdefghij k", cells(4), []string{"ab", "cdef", "ghij", "k"}},
+		{"too narrow for a character", "abc", 1, []string{"a", "b", "c"}},
+		{"empty", "", cells(4), []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Wrap(tt.text, tt.width, 1)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+			for _, line := range got {
+				if w, _ := Size(line, 1); w > tt.width && len(line) > 1 {
+					t.Errorf("line %q is %d wide, more than %d", line, w, tt.width)
+				}
+			}
+		})
+	}
+}
+
+func TestWrapScale(t *testing.T) {
+	got := Wrap("one two", cells(7)*3, 3)
+	if want := []string{"one two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("at scale 3 got %q, want %q", got, want)
+	}
+	got = Wrap("one two", cells(7)*3-1, 3)
+	if want := []string{"one", "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("a pixel short at scale 3 got %q, want %q", got, want)
+	}
+}
+
+func TestDrawWrapped(t *testing.T) {
+	const w, h = 100, 60
+	pixels := make([]byte, w*h*4)
+	fg, bg := Color{R: 255, G: 255, B: 255}, Color{R: 1, G: 2, B: 3}
+	x, y := DrawWrapped("ab cde", 10, 5, cells(4), 10, 1, fg, bg, pixels, w, h)
+	// "ab" then "cde" on the next line, ending after its third character
+	if x != 10+3*(GlyphWidth+1) || y != 15 {
+		t.Errorf("ended at %d, %d, want %d, 15", x, y, 10+3*(GlyphWidth+1))
+	}
+	at := func(px, py int) Color {
+		i := (py*w + px) * 4
+		return Color{pixels[i], pixels[i+1], pixels[i+2]}
+	}
+	// the spacing column after a character is background, left of the
+	// text and past the end of the line is untouched
+	if c := at(10+GlyphWidth, 5); c != bg {
+		t.Errorf("spacing is %v, want the background", c)
+	}
+	if c := at(9, 5); c != (Color{}) {
+		t.Errorf("left of the text is %v", c)
+	}
+	if c := at(10+2*(GlyphWidth+1), 5); c != (Color{}) {
+		t.Errorf("past the first line is %v", c)
+	}
+	// the top left of A is unlit, the middle of its top row lit
+	if c := at(10, 5); c != bg {
+		t.Errorf("unlit pixel is %v", c)
+	}
+	if c := at(11, 5); c != fg {
+		t.Errorf("lit pixel is %v", c)
+	}
+}
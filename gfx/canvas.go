@@ -0,0 +1,434 @@
+//go:build js && wasm
+
+package gfx
+
+import (
+	"fmt"
+	"syscall/js"
+	"unicode/utf8"
+)
+
+// canvasID is the id of the canvas a page can provide, a canvas is added to
+// the body if it has none
+const canvasID = "gfx"
+
+// eventQueue is how many events are kept between two PollEvents, more are
+// dropped
+const eventQueue = 256
+
+// listener is one event listener the renderer added, kept to remove it
+// again
+type listener struct {
+	target js.Value
+	event  string
+	fn     js.Func
+}
+
+// canvasRenderer draws into an HTML canvas through syscall/js and turns
+// the page's keyboard, mouse and gamepad input into Events. The JavaScript
+// callbacks only run while the Go side is blocked, as it is in present,
+// so the state they share with it needs no locking.
+type canvasRenderer struct {
+	canvas, ctx js.Value
+	image, data js.Value
+	// rgba is the image as it is copied to data, with every alpha byte
+	// opaque
+	rgba []byte
+	w    int
+
+	events  chan Event
+	keys    []uint8
+	text    bool
+	mouseX  int
+	mouseY  int
+	buttons MouseButton
+	// pads are the gamepads as the last poll saw them, by their index
+	pads map[int]padState
+
+	frame     chan struct{}
+	onFrame   js.Func
+	listeners []listener
+}
+
+// newRenderer sizes the page's canvas to w*h and listens for its input.
+// Presents wait for the browser's next animation frame, which is always
+// synced to the display.
+func newRenderer(title string, w, h int) (renderer, bool, error) {
+	doc := js.Global().Get("document")
+	if !doc.Truthy() {
+		return nil, false, fmt.Errorf("gfx: no document to draw in")
+	}
+	doc.Set("title", title)
+	canvas := doc.Call("getElementById", canvasID)
+	if !canvas.Truthy() {
+		canvas = doc.Call("createElement", "canvas")
+		canvas.Set("id", canvasID)
+		doc.Get("body").Call("appendChild", canvas)
+	}
+	canvas.Set("width", w)
+	canvas.Set("height", h)
+	ctx := canvas.Call("getContext", "2d")
+	if !ctx.Truthy() {
+		return nil, false, fmt.Errorf("gfx: canvas has no 2d context")
+	}
+
+	c := &canvasRenderer{
+		canvas: canvas,
+		ctx:    ctx,
+		image:  ctx.Call("createImageData", w, h),
+		rgba:   make([]byte, w*h*4),
+		w:      w,
+		events: make(chan Event, eventQueue),
+		keys:   make([]uint8, NumScancodes),
+		pads:   map[int]padState{},
+		frame:  make(chan struct{}, 1),
+	}
+	c.data = c.image.Get("data")
+	c.onFrame = js.FuncOf(func(js.Value, []js.Value) interface{} {
+		select {
+		case c.frame <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	window := js.Global()
+	c.listen(doc, "keydown", c.keyDown)
+	c.listen(doc, "keyup", c.keyUp)
+	// keys and buttons let go of while the page is in the background never
+	// send a key or mouse up, so they are all let go of when it loses focus
+	c.listen(window, "blur", func(js.Value) {
+		for i := range c.keys {
+			c.keys[i] = 0
+		}
+		c.buttons = 0
+	})
+	c.listen(window, "pagehide", func(js.Value) {
+		c.send(QuitEvent{})
+	})
+	c.listen(canvas, "mousemove", c.mouseMove)
+	c.listen(canvas, "mousedown", func(e js.Value) {
+		c.buttons |= domButton(e)
+	})
+	c.listen(window, "mouseup", func(e js.Value) {
+		c.buttons &^= domButton(e)
+	})
+	// the right button is for the program, not the page's context menu
+	c.listen(canvas, "contextmenu", func(e js.Value) {
+		e.Call("preventDefault")
+	})
+	c.listen(canvas, "wheel", c.wheel)
+	return c, true, nil
+}
+
+func (c *canvasRenderer) listen(target js.Value, event string, handle func(e js.Value)) {
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		handle(args[0])
+		return nil
+	})
+	target.Call("addEventListener", event, fn)
+	c.listeners = append(c.listeners, listener{target, event, fn})
+}
+
+// send queues e for pollEvent, it is dropped if nobody has polled for a
+// while. A callback must never block.
+func (c *canvasRenderer) send(e Event) {
+	select {
+	case c.events <- e:
+	default:
+	}
+}
+
+func domMod(e js.Value) Mod {
+	var mod Mod
+	// the DOM does not tell which of the pair is held
+	if e.Get("shiftKey").Bool() {
+		mod |= ModLShift
+	}
+	if e.Get("ctrlKey").Bool() {
+		mod |= ModLCtrl
+	}
+	if e.Get("altKey").Bool() {
+		mod |= ModLAlt
+	}
+	return mod
+}
+
+// browserKey reports whether the browser acts on sc by itself, scrolling
+// the page or moving the focus
+func browserKey(sc Scancode) bool {
+	switch sc {
+	case KeySpace, KeyTab, KeyBackspace, KeyPageUp, KeyPageDown, KeyUp, KeyDown, KeyLeft, KeyRight:
+		return true
+	}
+	return false
+}
+
+// keyDown queues the key, and the text it types while text input is on.
+// Only the keys in domCodes are handled, anything else is left to the
+// browser.
+func (c *canvasRenderer) keyDown(e js.Value) {
+	sc, ok := domCodes[e.Get("code").String()]
+	if !ok {
+		return
+	}
+	mod := domMod(e)
+	// Ctrl+S and friends would otherwise open the browser's dialogs
+	if mod&ModCtrl != 0 || browserKey(sc) {
+		e.Call("preventDefault")
+	}
+	c.keys[sc] = 1
+	c.send(KeyEvent{Scancode: sc, Mod: mod, Down: true, Repeat: e.Get("repeat").Bool()})
+	// key is the name of keys that type nothing, Enter or Backspace, and
+	// the character itself for those that do
+	if key := e.Get("key").String(); c.text && mod&(ModCtrl|ModAlt) == 0 && utf8.RuneCountInString(key) == 1 {
+		c.send(TextEvent{key})
+	}
+}
+
+func (c *canvasRenderer) keyUp(e js.Value) {
+	sc, ok := domCodes[e.Get("code").String()]
+	if !ok {
+		return
+	}
+	c.keys[sc] = 0
+	c.send(KeyEvent{Scancode: sc, Mod: domMod(e), Down: false})
+}
+
+// mouseMove keeps the position in canvas pixels, the page may stretch the
+// canvas with CSS
+func (c *canvasRenderer) mouseMove(e js.Value) {
+	x, y := e.Get("offsetX").Int(), e.Get("offsetY").Int()
+	if cw := c.canvas.Get("clientWidth").Int(); cw > 0 {
+		x = x * c.canvas.Get("width").Int() / cw
+	}
+	if ch := c.canvas.Get("clientHeight").Int(); ch > 0 {
+		y = y * c.canvas.Get("height").Int() / ch
+	}
+	c.mouseX, c.mouseY = x, y
+}
+
+// domButton is the button of a mouse event, the DOM numbers them left,
+// middle, right
+func domButton(e js.Value) MouseButton {
+	switch e.Get("button").Int() {
+	case 0:
+		return MouseLeft
+	case 1:
+		return MouseMiddle
+	case 2:
+		return MouseRight
+	}
+	return 0
+}
+
+// wheel queues a step of the wheel each way it turned. The DOM measures
+// the turn in pixels or lines depending on the device, SDL counts notches,
+// so only the direction is kept. The page does not scroll while the
+// wheel is over the canvas.
+func (c *canvasRenderer) wheel(e js.Value) {
+	e.Call("preventDefault")
+	var we WheelEvent
+	if dx := e.Get("deltaX").Float(); dx != 0 {
+		we.X = sign(dx)
+	}
+	// the DOM's y grows down the page, a turn away from the user is negative
+	if dy := e.Get("deltaY").Float(); dy != 0 {
+		we.Y = -sign(dy)
+	}
+	if we != (WheelEvent{}) {
+		c.send(we)
+	}
+}
+
+func sign(v float64) int {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+// upload copies the rows to the image with their unused byte made opaque,
+// the canvas would otherwise blend them with the page, and puts just those
+// rows back on the canvas
+func (c *canvasRenderer) upload(pixels []byte, pitch, start, end int) error {
+	rowBytes := c.w * 4
+	for y := start; y < end; y++ {
+		dst := c.rgba[y*rowBytes : (y+1)*rowBytes]
+		copy(dst, pixels[y*pitch:y*pitch+rowBytes])
+		for i := 3; i < len(dst); i += 4 {
+			dst[i] = 255
+		}
+	}
+	js.CopyBytesToJS(c.data.Call("subarray", start*rowBytes, end*rowBytes), c.rgba[start*rowBytes:end*rowBytes])
+	c.ctx.Call("putImageData", c.image, 0, 0, 0, start, c.w, end-start)
+	return nil
+}
+
+// present waits for the next animation frame. The browser draws the canvas
+// by itself once the page runs again, waiting is what lets it. Gamepads
+// send no events for their buttons and axes, they are polled once a frame
+// instead.
+func (c *canvasRenderer) present() error {
+	js.Global().Call("requestAnimationFrame", c.onFrame)
+	<-c.frame
+	c.pollGamepads()
+	return nil
+}
+
+// padState is what a gamepad showed at the last poll
+type padState struct {
+	axes    [NumAxes]float64
+	buttons [NumButtons]bool
+}
+
+// standardButtons maps the buttons of the DOM's standard gamepad layout to
+// ControllerButtons. 6 and 7 are the triggers, which are axes here as they
+// are in SDL.
+var standardButtons = map[int]ControllerButton{
+	0: ButtonA, 1: ButtonB, 2: ButtonX, 3: ButtonY,
+	4: ButtonLeftShoulder, 5: ButtonRightShoulder,
+	8: ButtonBack, 9: ButtonStart, 10: ButtonLeftStick, 11: ButtonRightStick,
+	12: ButtonDPadUp, 13: ButtonDPadDown, 14: ButtonDPadLeft, 15: ButtonDPadRight,
+	16: ButtonGuide,
+}
+
+// pollGamepads sends events for whatever changed on the gamepads since the
+// last poll, gamepads turning up or going away included. Only gamepads the
+// browser maps to the standard layout are used, others number their
+// buttons however the device does. Browsers only show a page its gamepads
+// once one of their buttons has been pressed.
+func (c *canvasRenderer) pollGamepads() {
+	nav := js.Global().Get("navigator")
+	if !nav.Get("getGamepads").Truthy() {
+		return
+	}
+	pads := nav.Call("getGamepads")
+	seen := map[int]bool{}
+	for i := 0; i < pads.Length(); i++ {
+		pad := pads.Index(i)
+		if !pad.Truthy() || !pad.Get("connected").Bool() || pad.Get("mapping").String() != "standard" {
+			continue
+		}
+		id := pad.Get("index").Int()
+		seen[id] = true
+		old, ok := c.pads[id]
+		if !ok {
+			c.send(ControllerAddedEvent{id, pad.Get("id").String()})
+		}
+
+		var now padState
+		axes := pad.Get("axes")
+		for a := AxisLeftX; a <= AxisRightY && int(a) < axes.Length(); a++ {
+			now.axes[a] = axes.Index(int(a)).Float()
+		}
+		buttons := pad.Get("buttons")
+		for b := 0; b < buttons.Length(); b++ {
+			button := buttons.Index(b)
+			switch b {
+			case 6:
+				now.axes[AxisTriggerLeft] = button.Get("value").Float()
+			case 7:
+				now.axes[AxisTriggerRight] = button.Get("value").Float()
+			default:
+				if cb, ok := standardButtons[b]; ok {
+					now.buttons[cb] = button.Get("pressed").Bool()
+				}
+			}
+		}
+
+		for a, v := range now.axes {
+			if v != old.axes[a] {
+				c.send(ControllerAxisEvent{id, ControllerAxis(a), v})
+			}
+		}
+		for b, down := range now.buttons {
+			if down != old.buttons[b] {
+				c.send(ControllerButtonEvent{id, ControllerButton(b), down})
+			}
+		}
+		c.pads[id] = now
+	}
+	for id := range c.pads {
+		if !seen[id] {
+			delete(c.pads, id)
+			c.send(ControllerRemovedEvent{id})
+		}
+	}
+}
+
+func (c *canvasRenderer) pollEvent() Event {
+	select {
+	case e := <-c.events:
+		return e
+	default:
+		return nil
+	}
+}
+
+func (c *canvasRenderer) keyboardState() []uint8 {
+	return c.keys
+}
+
+func (c *canvasRenderer) mouseState() (x, y int, buttons MouseButton) {
+	return c.mouseX, c.mouseY, c.buttons
+}
+
+func (c *canvasRenderer) textInput(on bool) {
+	c.text = on
+}
+
+func (c *canvasRenderer) setTitle(title string) {
+	js.Global().Get("document").Set("title", title)
+}
+
+func (c *canvasRenderer) destroy() {
+	for _, l := range c.listeners {
+		l.target.Call("removeEventListener", l.event, l.fn)
+		l.fn.Release()
+	}
+	c.listeners = nil
+	c.onFrame.Release()
+}
+
+// domCodes maps KeyboardEvent.code, which like a scancode names the key
+// and not what it types, to Scancodes
+var domCodes = map[string]Scancode{
+	"Enter":          KeyReturn,
+	"Escape":         KeyEscape,
+	"Backspace":      KeyBackspace,
+	"Tab":            KeyTab,
+	"Space":          KeySpace,
+	"Minus":          KeyMinus,
+	"Equal":          KeyEquals,
+	"Backquote":      KeyGrave,
+	"PageUp":         KeyPageUp,
+	"PageDown":       KeyPageDown,
+	"ArrowRight":     KeyRight,
+	"ArrowLeft":      KeyLeft,
+	"ArrowDown":      KeyDown,
+	"ArrowUp":        KeyUp,
+	"NumpadSubtract": KeyKPMinus,
+	"NumpadAdd":      KeyKPPlus,
+	"NumpadEnter":    KeyKPEnter,
+	"ControlLeft":    KeyLCtrl,
+	"ShiftLeft":      KeyLShift,
+	"AltLeft":        KeyLAlt,
+	"ControlRight":   KeyRCtrl,
+	"ShiftRight":     KeyRShift,
+	"AltRight":       KeyRAlt,
+}
+
+func init() {
+	for i := 0; i < 26; i++ {
+		domCodes["Key"+string(rune('A'+i))] = KeyA + Scancode(i)
+	}
+	// the digit scancodes run 1 to 9 and then 0
+	for i := 1; i <= 9; i++ {
+		domCodes[fmt.Sprint("Digit", i)] = Key1 + Scancode(i-1)
+	}
+	domCodes["Digit0"] = Key0
+	for i := 1; i <= 12; i++ {
+		domCodes[fmt.Sprint("F", i)] = KeyF1 + Scancode(i-1)
+	}
+}
@@ -0,0 +1,127 @@
+package gfx
+
+// ControllerAxis is an axis of a game controller, numbered as SDL's game
+// controller API numbers them
+type ControllerAxis int
+
+const (
+	AxisLeftX ControllerAxis = iota
+	AxisLeftY
+	AxisRightX
+	AxisRightY
+	AxisTriggerLeft
+	AxisTriggerRight
+	NumAxes
+)
+
+// ControllerButton is a button of a game controller, named for where it is
+// on an Xbox controller and numbered as SDL numbers them
+type ControllerButton int
+
+const (
+	ButtonA ControllerButton = iota
+	ButtonB
+	ButtonX
+	ButtonY
+	ButtonBack
+	ButtonGuide
+	ButtonStart
+	ButtonLeftStick
+	ButtonRightStick
+	ButtonLeftShoulder
+	ButtonRightShoulder
+	ButtonDPadUp
+	ButtonDPadDown
+	ButtonDPadLeft
+	ButtonDPadRight
+	NumButtons
+)
+
+// ControllerAddedEvent is a controller being plugged in, controllers that
+// already are when the window opens send one too. ID tells its events
+// apart from another controller's.
+type ControllerAddedEvent struct {
+	ID   int
+	Name string
+}
+
+// ControllerRemovedEvent is a controller being unplugged
+type ControllerRemovedEvent struct {
+	ID int
+}
+
+// ControllerAxisEvent is an axis moving. The sticks go from -1 to 1, down
+// and right positive, the triggers from 0 to 1.
+type ControllerAxisEvent struct {
+	ID    int
+	Axis  ControllerAxis
+	Value float64
+}
+
+// ControllerButtonEvent is a controller button going down or up
+type ControllerButtonEvent struct {
+	ID     int
+	Button ControllerButton
+	Down   bool
+}
+
+// Pad is the state of the game controllers, every one plugged in read as
+// one. Axes keep the last value any controller sent.
+type Pad struct {
+	// Connected are the ids of the controllers plugged in
+	Connected     []int
+	Axes          [NumAxes]float64
+	held, pressed [NumButtons]bool
+}
+
+// Active reports whether a controller is plugged in
+func (p *Pad) Active() bool {
+	return len(p.Connected) > 0
+}
+
+// Held reports whether b is down
+func (p *Pad) Held(b ControllerButton) bool {
+	return b >= 0 && b < NumButtons && p.held[b]
+}
+
+// Pressed reports whether b went down during the frame
+func (p *Pad) Pressed(b ControllerButton) bool {
+	return b >= 0 && b < NumButtons && p.pressed[b]
+}
+
+// apply updates p for e, it reports whether e was a controller's event
+func (p *Pad) apply(event Event) bool {
+	switch e := event.(type) {
+	case ControllerAddedEvent:
+		// Connected may be shared with the frame before, it is copied
+		// rather than changed
+		p.Connected = append(append([]int(nil), p.Connected...), e.ID)
+	case ControllerRemovedEvent:
+		var left []int
+		for _, id := range p.Connected {
+			if id != e.ID {
+				left = append(left, id)
+			}
+		}
+		p.Connected = left
+		// nothing is held on a controller that is gone
+		if len(left) == 0 {
+			p.Axes = [NumAxes]float64{}
+			p.held = [NumButtons]bool{}
+		}
+	case ControllerAxisEvent:
+		if e.Axis >= 0 && e.Axis < NumAxes {
+			p.Axes[e.Axis] = e.Value
+		}
+	case ControllerButtonEvent:
+		if e.Button >= 0 && e.Button < NumButtons {
+			p.held[e.Button] = e.Down
+			if e.Down {
+				p.pressed[e.Button] = true
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
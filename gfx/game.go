@@ -0,0 +1,177 @@
+package gfx
+
+import "time"
+
+// MouseButton is a set of mouse buttons
+type MouseButton uint8
+
+const (
+	MouseLeft MouseButton = 1 << iota
+	MouseMiddle
+	MouseRight
+)
+
+// Input is the keyboard, mouse and game controllers as one frame sees them,
+// built by Run from the events since the frame before and the state of the
+// devices after them
+type Input struct {
+	held, pressed [NumScancodes]bool
+	// Keys are the key events of the frame in order, repeats and key ups
+	// included
+	Keys []KeyEvent
+	// Text is what was typed during the frame while text input was on
+	Text string
+
+	MouseX, MouseY int
+	// Buttons are the mouse buttons held, Clicked those of them that were
+	// not held the frame before
+	Buttons, Clicked MouseButton
+	// WheelX and WheelY are how far the wheel turned during the frame,
+	// positive y is away from the user
+	WheelX, WheelY int
+	// Pad is the game controllers
+	Pad Pad
+}
+
+// Held reports whether sc is down
+func (in *Input) Held(sc Scancode) bool {
+	return sc >= 0 && sc < NumScancodes && in.held[sc]
+}
+
+// Pressed reports whether sc went down during the frame. A key tapped
+// between two frames is pressed without being held.
+func (in *Input) Pressed(sc Scancode) bool {
+	return sc >= 0 && sc < NumScancodes && in.pressed[sc]
+}
+
+// newInput builds a frame's Input from the events polled for it and the
+// keyboard and mouse state after them, prev being the frame before's. It
+// reports whether one of the events asked to quit.
+func newInput(prev *Input, events []Event, keys []uint8, mouseX, mouseY int, buttons MouseButton) (in Input, quit bool) {
+	// controllers only have events, their state carries over from the frame
+	// before
+	in.Pad = prev.Pad
+	in.Pad.pressed = [NumButtons]bool{}
+	for sc, v := range keys {
+		if sc >= NumScancodes {
+			break
+		}
+		in.held[sc] = v != 0
+	}
+	for _, event := range events {
+		switch e := event.(type) {
+		case QuitEvent:
+			quit = true
+		case KeyEvent:
+			in.Keys = append(in.Keys, e)
+			if e.Down && !e.Repeat && e.Scancode >= 0 && e.Scancode < NumScancodes {
+				in.pressed[e.Scancode] = true
+			}
+		case TextEvent:
+			in.Text += e.Text
+		case WheelEvent:
+			in.WheelX += e.X
+			in.WheelY += e.Y
+		default:
+			in.Pad.apply(event)
+		}
+	}
+	in.MouseX, in.MouseY = mouseX, mouseY
+	in.Buttons = buttons
+	in.Clicked = buttons &^ prev.Buttons
+	return in, quit
+}
+
+// Game is what Run runs. Update is called once a frame with the input of
+// the frame and the seconds since the frame before, then Draw with the
+// window's pixel buffer, which keeps what was drawn into it last frame.
+type Game interface {
+	Update(input Input, dt float64)
+	Draw(pixels []byte, w, h int)
+}
+
+// RowDrawer is a Game that keeps track of what it changed. Run calls
+// DrawRows instead of Draw and uploads only rows start..end-1, nothing at
+// all when end is not past start.
+type RowDrawer interface {
+	Game
+	DrawRows(pixels []byte, w, h int) (start, end int)
+}
+
+// Quitter is a Game that can end Run itself, Run returns once Done reports
+// true after an Update
+type Quitter interface {
+	Game
+	Done() bool
+}
+
+// Run opens a w*h window titled title and runs g in it until the window is
+// closed
+func Run(title string, w, h int, g Game) error {
+	win, err := New(title, w, h)
+	if err != nil {
+		return err
+	}
+	defer win.Destroy()
+	return win.Run(g)
+}
+
+// SetFPSCap makes Run pace the window at fps frames per second, 0 leaves it
+// to vsync or DefaultFPSCap without it
+func (win *Window) SetFPSCap(fps int) {
+	win.fpsCap = fps
+}
+
+// UploadTime is how long Run took uploading the last frame, 0 if it
+// uploaded nothing
+func (win *Window) UploadTime() time.Duration {
+	return win.uploadTime
+}
+
+// Run runs g in the window until it is closed, or g is a Quitter that is
+// done. It stops at the first error uploading or showing a frame.
+func (win *Window) Run(g Game) error {
+	fps := win.fpsCap
+	if !win.vsync && fps == 0 {
+		fps = DefaultFPSCap
+	}
+	pacer := newFramePacer(fps, perfClock, time.Sleep)
+	quitter, _ := g.(Quitter)
+	rows, _ := g.(RowDrawer)
+	var in Input
+	var dt time.Duration
+	for {
+		events := win.PollEvents()
+		x, y, buttons := win.renderer.mouseState()
+		next, quit := newInput(&in, events, win.KeyboardState(), x, y, buttons)
+		if quit {
+			return nil
+		}
+		in = next
+		g.Update(in, dt.Seconds())
+		if quitter != nil && quitter.Done() {
+			return nil
+		}
+
+		start, end := 0, win.h
+		if rows != nil {
+			start, end = rows.DrawRows(win.pixels, win.w, win.h)
+		} else {
+			g.Draw(win.pixels, win.w, win.h)
+		}
+		win.uploadTime = 0
+		if start < end {
+			startTime := time.Now()
+			err := win.Update(start, end)
+			if err != nil {
+				return err
+			}
+			win.uploadTime = time.Since(startTime)
+		}
+		err := win.Show()
+		if err != nil {
+			return err
+		}
+		dt = pacer.frame()
+	}
+}
@@ -0,0 +1,238 @@
+package gfx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewInputKeys(t *testing.T) {
+	keys := make([]uint8, NumScancodes)
+	keys[KeyA] = 1
+	keys[KeyLShift] = 1
+	events := []Event{
+		// A went down this frame, B was tapped between frames, C is held
+		// from before and repeating
+		KeyEvent{Scancode: KeyA, Down: true},
+		KeyEvent{Scancode: KeyB, Down: true},
+		KeyEvent{Scancode: KeyB, Down: false},
+		KeyEvent{Scancode: KeyC, Down: true, Repeat: true},
+		KeyEvent{Scancode: Scancode(NumScancodes + 5), Down: true},
+	}
+	in, quit := newInput(&Input{}, events, keys, 0, 0, 0)
+	if quit {
+		t.Error("quit without a QuitEvent")
+	}
+	tests := []struct {
+		sc            Scancode
+		held, pressed bool
+	}{
+		{KeyA, true, true},
+		{KeyB, false, true},
+		{KeyC, false, false},
+		{KeyLShift, true, false},
+		{KeyD, false, false},
+		{-1, false, false},
+		{Scancode(NumScancodes + 5), false, false},
+	}
+	for _, tt := range tests {
+		if in.Held(tt.sc) != tt.held || in.Pressed(tt.sc) != tt.pressed {
+			t.Errorf("key %d is held %v pressed %v, want %v %v", tt.sc, in.Held(tt.sc), in.Pressed(tt.sc), tt.held, tt.pressed)
+		}
+	}
+	if len(in.Keys) != len(events) {
+		t.Errorf("Keys has %d events, want %d", len(in.Keys), len(events))
+	}
+	for i, e := range in.Keys {
+		if e != events[i] {
+			t.Errorf("Keys[%d] is %v, want %v", i, e, events[i])
+		}
+	}
+}
+
+func TestNewInputKeyboardStateIsCopied(t *testing.T) {
+	keys := make([]uint8, NumScancodes)
+	keys[KeyQ] = 1
+	in, _ := newInput(&Input{}, nil, keys, 0, 0, 0)
+	// the window updates its state array in place
+	keys[KeyQ] = 0
+	if !in.Held(KeyQ) {
+		t.Error("Input changed with the keyboard state after it was built")
+	}
+}
+
+func TestNewInputTextAndWheel(t *testing.T) {
+	events := []Event{
+		TextEvent{"ab"},
+		WheelEvent{0, 1},
+		TextEvent{"é"},
+		WheelEvent{1, 2},
+		WheelEvent{0, -1},
+	}
+	in, _ := newInput(&Input{}, events, nil, 0, 0, 0)
+	if in.Text != "abé" {
+		t.Errorf("Text is %q", in.Text)
+	}
+	if in.WheelX != 1 || in.WheelY != 2 {
+		t.Errorf("wheel is %d, %d, want 1, 2", in.WheelX, in.WheelY)
+	}
+}
+
+func TestNewInputMouse(t *testing.T) {
+	prev := &Input{Buttons: MouseLeft}
+	in, _ := newInput(prev, nil, nil, 12, 34, MouseLeft|MouseRight)
+	if in.MouseX != 12 || in.MouseY != 34 {
+		t.Errorf("mouse is at %d, %d", in.MouseX, in.MouseY)
+	}
+	if in.Buttons != MouseLeft|MouseRight || in.Clicked != MouseRight {
+		t.Errorf("buttons %b clicked %b, want %b %b", in.Buttons, in.Clicked, MouseLeft|MouseRight, MouseRight)
+	}
+	// letting go clicks nothing
+	in, _ = newInput(&in, nil, nil, 12, 34, 0)
+	if in.Buttons != 0 || in.Clicked != 0 {
+		t.Errorf("after letting go buttons %b clicked %b", in.Buttons, in.Clicked)
+	}
+}
+
+func TestNewInputPad(t *testing.T) {
+	events := []Event{
+		ControllerAddedEvent{ID: 3, Name: "pad"},
+		ControllerAxisEvent{3, AxisLeftX, -0.5},
+		ControllerButtonEvent{3, ButtonA, true},
+		ControllerButtonEvent{3, ButtonB, true},
+		ControllerButtonEvent{3, ButtonB, false},
+	}
+	in, _ := newInput(&Input{}, events, nil, 0, 0, 0)
+	if !in.Pad.Active() || in.Pad.Axes[AxisLeftX] != -0.5 {
+		t.Fatalf("pad is %+v", in.Pad)
+	}
+	if !in.Pad.Held(ButtonA) || !in.Pad.Pressed(ButtonA) {
+		t.Error("A is not held and pressed")
+	}
+	// a tap between two frames is pressed without being held
+	if in.Pad.Held(ButtonB) || !in.Pad.Pressed(ButtonB) {
+		t.Error("B tapped is held or not pressed")
+	}
+
+	// the state carries over, presses do not
+	prev := in
+	in, _ = newInput(&prev, nil, nil, 0, 0, 0)
+	if !in.Pad.Held(ButtonA) || in.Pad.Pressed(ButtonA) || in.Pad.Axes[AxisLeftX] != -0.5 {
+		t.Errorf("next frame pad is %+v", in.Pad)
+	}
+
+	// a second controller coming does not change the first frame's
+	in, _ = newInput(&prev, []Event{ControllerAddedEvent{ID: 4}}, nil, 0, 0, 0)
+	if len(prev.Pad.Connected) != 1 || len(in.Pad.Connected) != 2 {
+		t.Errorf("connected %v then %v", prev.Pad.Connected, in.Pad.Connected)
+	}
+
+	// unplugging the last lets go of everything
+	in, _ = newInput(&prev, []Event{ControllerRemovedEvent{3}}, nil, 0, 0, 0)
+	if in.Pad.Active() || in.Pad.Held(ButtonA) || in.Pad.Axes[AxisLeftX] != 0 {
+		t.Errorf("after unplugging pad is %+v", in.Pad)
+	}
+}
+
+func TestNewInputQuit(t *testing.T) {
+	events := []Event{KeyEvent{Scancode: KeyA, Down: true}, QuitEvent{}}
+	if _, quit := newInput(&Input{}, events, nil, 0, 0, 0); !quit {
+		t.Error("a QuitEvent did not quit")
+	}
+}
+
+// fakeGame records its frames and stops after frames of them
+type fakeGame struct {
+	frames  int
+	updates int
+	draws   int
+	pressed []bool
+	rows    [2]int
+}
+
+func (g *fakeGame) Update(in Input, dt float64) {
+	g.updates++
+	g.pressed = append(g.pressed, in.Pressed(KeyA))
+}
+
+func (g *fakeGame) Draw(pixels []byte, w, h int) {
+	g.draws++
+	pixels[0] = byte(g.draws)
+}
+
+func (g *fakeGame) Done() bool {
+	return g.updates == g.frames
+}
+
+// rowGame is a fakeGame that only ever changes g.rows
+type rowGame struct {
+	fakeGame
+}
+
+func (g *rowGame) DrawRows(pixels []byte, w, h int) (start, end int) {
+	g.draws++
+	return g.rows[0], g.rows[1]
+}
+
+func TestRunStopsOnQuit(t *testing.T) {
+	fake := &fakeRenderer{events: []Event{QuitEvent{}}}
+	g := &fakeGame{frames: -1}
+	err := newWindow(4, 3, fake, true).Run(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.updates != 0 || fake.presents != 0 {
+		t.Errorf("closed window ran %d updates and %d presents", g.updates, fake.presents)
+	}
+}
+
+func TestRunFrames(t *testing.T) {
+	fake := &fakeRenderer{events: []Event{KeyEvent{Scancode: KeyA, Down: true}}}
+	g := &fakeGame{frames: 3}
+	win := newWindow(4, 3, fake, true)
+	err := win.Run(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Done ends the run before the third frame is drawn
+	if g.draws != 2 || fake.presents != 2 {
+		t.Errorf("%d draws and %d presents, want 2 of each", g.draws, fake.presents)
+	}
+	if want := []bool{true, false, false}; !reflect.DeepEqual(g.pressed, want) {
+		t.Errorf("A was pressed %v, want %v", g.pressed, want)
+	}
+	if want := [][2]int{{0, 3}, {0, 3}}; !reflect.DeepEqual(fake.uploads, want) {
+		t.Errorf("uploaded %v, want %v", fake.uploads, want)
+	}
+	if fake.shown[0] != 2 {
+		t.Errorf("shown pixel is %d, want the second frame's", fake.shown[0])
+	}
+}
+
+func TestRunRowDrawer(t *testing.T) {
+	tests := []struct {
+		rows [2]int
+		want [][2]int
+	}{
+		{[2]int{1, 2}, [][2]int{{1, 2}}},
+		{[2]int{0, 0}, nil},
+		{[2]int{-4, 10}, [][2]int{{0, 3}}},
+	}
+	for _, tt := range tests {
+		fake := &fakeRenderer{}
+		g := &rowGame{fakeGame{frames: 2, rows: tt.rows}}
+		win := newWindow(4, 3, fake, true)
+		err := win.Run(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(fake.uploads, tt.want) {
+			t.Errorf("rows %v uploaded %v, want %v", tt.rows, fake.uploads, tt.want)
+		}
+		if fake.presents != 1 {
+			t.Errorf("rows %v presented %d times, want once", tt.rows, fake.presents)
+		}
+		if len(tt.want) == 0 && win.UploadTime() != 0 {
+			t.Errorf("rows %v uploaded nothing in %v", tt.rows, win.UploadTime())
+		}
+	}
+}
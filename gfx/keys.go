@@ -0,0 +1,172 @@
+package gfx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scancode names a physical key, independent of the keyboard layout. The
+// values are SDL's, which are the USB usage ids, so the SDL backend passes
+// its own through and KeyboardState can be indexed with them everywhere.
+type Scancode int
+
+// NumScancodes is the length of the KeyboardState array
+const NumScancodes = 512
+
+const (
+	KeyA Scancode = iota + 4
+	KeyB
+	KeyC
+	KeyD
+	KeyE
+	KeyF
+	KeyG
+	KeyH
+	KeyI
+	KeyJ
+	KeyK
+	KeyL
+	KeyM
+	KeyN
+	KeyO
+	KeyP
+	KeyQ
+	KeyR
+	KeyS
+	KeyT
+	KeyU
+	KeyV
+	KeyW
+	KeyX
+	KeyY
+	KeyZ
+	Key1
+	Key2
+	Key3
+	Key4
+	Key5
+	Key6
+	Key7
+	Key8
+	Key9
+	Key0
+	KeyReturn
+	KeyEscape
+	KeyBackspace
+	KeyTab
+	KeySpace
+	KeyMinus
+	KeyEquals
+)
+
+const (
+	KeyF1 Scancode = iota + 58
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+)
+
+const (
+	KeyGrave    Scancode = 53
+	KeyPageUp   Scancode = 75
+	KeyPageDown Scancode = 78
+	KeyRight    Scancode = 79
+	KeyLeft     Scancode = 80
+	KeyDown     Scancode = 81
+	KeyUp       Scancode = 82
+	KeyKPMinus  Scancode = 86
+	KeyKPPlus   Scancode = 87
+	KeyKPEnter  Scancode = 88
+	KeyLCtrl    Scancode = 224
+	KeyLShift   Scancode = 225
+	KeyLAlt     Scancode = 226
+	KeyRCtrl    Scancode = 228
+	KeyRShift   Scancode = 229
+	KeyRAlt     Scancode = 230
+)
+
+// scancodeNames are SDL's names for the scancodes above, as
+// SDL_GetScancodeName gives them
+var scancodeNames = map[Scancode]string{
+	KeyReturn:    "Return",
+	KeyEscape:    "Escape",
+	KeyBackspace: "Backspace",
+	KeyTab:       "Tab",
+	KeySpace:     "Space",
+	KeyMinus:     "-",
+	KeyEquals:    "=",
+	KeyGrave:     "`",
+	KeyPageUp:    "PageUp",
+	KeyPageDown:  "PageDown",
+	KeyRight:     "Right",
+	KeyLeft:      "Left",
+	KeyDown:      "Down",
+	KeyUp:        "Up",
+	KeyKPMinus:   "Keypad -",
+	KeyKPPlus:    "Keypad +",
+	KeyKPEnter:   "Keypad Enter",
+	KeyLCtrl:     "Left Ctrl",
+	KeyLShift:    "Left Shift",
+	KeyLAlt:      "Left Alt",
+	KeyRCtrl:     "Right Ctrl",
+	KeyRShift:    "Right Shift",
+	KeyRAlt:      "Right Alt",
+}
+
+func init() {
+	for i := 0; i < 26; i++ {
+		scancodeNames[KeyA+Scancode(i)] = string(rune('A' + i))
+	}
+	for i := 1; i <= 9; i++ {
+		scancodeNames[Key1+Scancode(i-1)] = fmt.Sprint(i)
+	}
+	scancodeNames[Key0] = "0"
+	for i := 1; i <= 12; i++ {
+		scancodeNames[KeyF1+Scancode(i-1)] = fmt.Sprint("F", i)
+	}
+}
+
+// String is SDL's name for sc, or its number for a key without a constant
+// here
+func (sc Scancode) String() string {
+	if name, ok := scancodeNames[sc]; ok {
+		return name
+	}
+	return fmt.Sprint("scancode ", int(sc))
+}
+
+// ParseScancode is the scancode SDL names name, ignoring case
+func ParseScancode(name string) (Scancode, error) {
+	for sc, n := range scancodeNames {
+		if strings.EqualFold(n, name) {
+			return sc, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key %q", name)
+}
+
+// Mod is the modifier keys held when a key event happened, as SDL's KMOD_
+// flags
+type Mod uint16
+
+const (
+	ModLShift Mod = 0x0001
+	ModRShift Mod = 0x0002
+	ModLCtrl  Mod = 0x0040
+	ModRCtrl  Mod = 0x0080
+	ModLAlt   Mod = 0x0100
+	ModRAlt   Mod = 0x0200
+
+	// either key of a pair
+	ModShift = ModLShift | ModRShift
+	ModCtrl  = ModLCtrl | ModRCtrl
+	ModAlt   = ModLAlt | ModRAlt
+)
@@ -0,0 +1,34 @@
+package gfx
+
+import "testing"
+
+func TestScancodeNames(t *testing.T) {
+	for sc, name := range scancodeNames {
+		got, err := ParseScancode(name)
+		if err != nil || got != sc {
+			t.Errorf("ParseScancode(%q) = %d, %v, want %d", name, got, err, sc)
+		}
+	}
+	tests := []struct {
+		name string
+		sc   Scancode
+	}{
+		{"a", KeyA},
+		{"left alt", KeyLAlt},
+		{"F12", KeyF12},
+		{"Keypad +", KeyKPPlus},
+		{"0", Key0},
+	}
+	for _, tt := range tests {
+		sc, err := ParseScancode(tt.name)
+		if err != nil || sc != tt.sc {
+			t.Errorf("ParseScancode(%q) = %d, %v, want %d", tt.name, sc, err, tt.sc)
+		}
+	}
+	if _, err := ParseScancode("Hyper"); err == nil {
+		t.Error("parsed a key that does not exist")
+	}
+	if s := KeyPageUp.String(); s != "PageUp" {
+		t.Errorf("page up is called %q", s)
+	}
+}
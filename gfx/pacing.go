@@ -0,0 +1,67 @@
+package gfx
+
+import "time"
+
+const (
+	// DefaultFPSCap paces a window when there is no vsync and no cap was
+	// set, without either Run would loop flat out
+	DefaultFPSCap = 60
+	// sleepSlack is how long before a deadline the pacer stops sleeping
+	// and spins instead, sleeps tend to overshoot by about this much
+	sleepSlack = 2 * time.Millisecond
+)
+
+// clockStart is the fixed point perfClock counts from
+var clockStart = time.Now()
+
+// perfClock is the monotonic time since the program started, the same on
+// the desktop and in the browser
+func perfClock() time.Duration {
+	return time.Since(clockStart)
+}
+
+// framePacer ends frames at a steady rate and measures how long they
+// really took. With a target of 0 it only measures, for a renderer that
+// waits for vsync itself.
+type framePacer struct {
+	target   time.Duration
+	clock    func() time.Duration
+	sleep    func(time.Duration)
+	last     time.Duration
+	deadline time.Duration
+}
+
+// newFramePacer returns a pacer for fps frames per second, 0 for no cap
+func newFramePacer(fps int, clock func() time.Duration, sleep func(time.Duration)) *framePacer {
+	fp := &framePacer{clock: clock, sleep: sleep}
+	if fps > 0 {
+		fp.target = time.Second / time.Duration(fps)
+	}
+	fp.last = clock()
+	fp.deadline = fp.last + fp.target
+	return fp
+}
+
+// frame waits until the current frame's deadline and returns the time since
+// the previous call. Most of the wait is slept and the last sleepSlack of
+// it spun, so the frame neither ends early nor overshoots by a whole sleep.
+// Deadlines stay on a fixed grid, so a late frame is followed by a shorter
+// one, but a frame more than a whole target late moves the grid on instead
+// of leaving a debt of frames to rush through.
+func (fp *framePacer) frame() time.Duration {
+	now := fp.clock()
+	if fp.target > 0 {
+		if remaining := fp.deadline - now; remaining > sleepSlack {
+			fp.sleep(remaining - sleepSlack)
+		}
+		for now = fp.clock(); now < fp.deadline; now = fp.clock() {
+		}
+		fp.deadline += fp.target
+		if fp.deadline <= now {
+			fp.deadline = now + fp.target
+		}
+	}
+	dt := now - fp.last
+	fp.last = now
+	return dt
+}
@@ -0,0 +1,104 @@
+package gfx
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock moves tick every time it is read, like time passing while the
+// pacer spins, and oversleep past every sleep
+type fakeClock struct {
+	now       time.Duration
+	tick      time.Duration
+	oversleep time.Duration
+	sleeps    []time.Duration
+}
+
+func (c *fakeClock) clock() time.Duration {
+	c.now += c.tick
+	return c.now
+}
+
+func (c *fakeClock) sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now += d + c.oversleep
+}
+
+const pacerTick = 10 * time.Microsecond
+
+// TestFramePacerSteady does 5ms of work a frame at 60fps. Every frame has
+// to end on its deadline, within a tick of the clock, and be slept for
+// all but sleepSlack of the wait.
+func TestFramePacerSteady(t *testing.T) {
+	for _, oversleep := range []time.Duration{0, sleepSlack / 2} {
+		c := &fakeClock{tick: pacerTick, oversleep: oversleep}
+		fp := newFramePacer(60, c.clock, c.sleep)
+		target := time.Second / 60
+		start := c.now
+		for i := 1; i <= 120; i++ {
+			c.now += 5 * time.Millisecond
+			slept := len(c.sleeps)
+			dt := fp.frame()
+			if late := c.now - (start + time.Duration(i)*target); late < 0 || late > 2*pacerTick {
+				t.Fatalf("oversleep %v: frame %d ended %v off its deadline", oversleep, i, late)
+			}
+			if dt < target-2*pacerTick || dt > target+2*pacerTick {
+				t.Errorf("oversleep %v: frame %d took %v, want %v", oversleep, i, dt, target)
+			}
+			if len(c.sleeps) != slept+1 {
+				t.Fatalf("oversleep %v: frame %d slept %d times", oversleep, i, len(c.sleeps)-slept)
+			}
+			if want := target - 5*time.Millisecond - sleepSlack; c.sleeps[slept] < want-4*pacerTick || c.sleeps[slept] > want {
+				t.Errorf("oversleep %v: frame %d slept %v, want about %v", oversleep, i, c.sleeps[slept], want)
+			}
+		}
+	}
+}
+
+// TestFramePacerLate has a frame run past its deadline. One less than a
+// frame late is made up by the next frame, one more than a frame late
+// moves the deadlines on.
+func TestFramePacerLate(t *testing.T) {
+	target := time.Second / 50
+	tests := []struct {
+		name string
+		work time.Duration
+		// next is how long the frame after the late one takes with no work
+		next time.Duration
+	}{
+		{"a little late", target + 5*time.Millisecond, target - 5*time.Millisecond},
+		{"more than a frame late", 2*target + 5*time.Millisecond, target},
+	}
+	for _, tt := range tests {
+		c := &fakeClock{tick: pacerTick}
+		fp := newFramePacer(50, c.clock, c.sleep)
+		c.now += tt.work
+		if dt := fp.frame(); dt < tt.work || dt > tt.work+2*pacerTick {
+			t.Errorf("%s: late frame took %v, want %v", tt.name, dt, tt.work)
+		}
+		if len(c.sleeps) != 0 {
+			t.Errorf("%s: slept %v in a late frame", tt.name, c.sleeps)
+		}
+		if dt := fp.frame(); dt < tt.next-2*pacerTick || dt > tt.next+2*pacerTick {
+			t.Errorf("%s: frame after the late one took %v, want %v", tt.name, dt, tt.next)
+		}
+		if dt := fp.frame(); dt < target-2*pacerTick || dt > target+2*pacerTick {
+			t.Errorf("%s: second frame after the late one took %v, want %v", tt.name, dt, target)
+		}
+	}
+}
+
+// TestFramePacerUncapped never waits, it only measures
+func TestFramePacerUncapped(t *testing.T) {
+	c := &fakeClock{tick: pacerTick}
+	fp := newFramePacer(0, c.clock, c.sleep)
+	for _, work := range []time.Duration{time.Millisecond, 30 * time.Millisecond, 0} {
+		c.now += work
+		if dt := fp.frame(); dt != work+pacerTick {
+			t.Errorf("frame with %v of work took %v", work, dt)
+		}
+	}
+	if len(c.sleeps) != 0 {
+		t.Errorf("uncapped pacer slept %v", c.sleeps)
+	}
+}
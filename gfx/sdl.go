@@ -0,0 +1,205 @@
+//go:build !js
+
+package gfx
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// sdlRenderer is a window, its renderer and a streaming texture the size of
+// the window
+type sdlRenderer struct {
+	window   *sdl.Window
+	renderer *sdl.Renderer
+	tex      *sdl.Texture
+	w        int
+	// controllers are the game controllers open, by joystick instance id
+	controllers map[sdl.JoystickID]*sdl.GameController
+}
+
+// newRenderer asks for a vsynced renderer and falls back to one without
+// vsync if the driver refuses, it reports whether vsync is on. On error
+// everything created so far is destroyed again, the error wraps SDL's and
+// says which step failed.
+func newRenderer(title string, w, h int) (renderer, bool, error) {
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		return nil, false, fmt.Errorf("gfx: initializing sdl: %w", err)
+	}
+	s := &sdlRenderer{w: w, controllers: map[sdl.JoystickID]*sdl.GameController{}}
+	vsync, err := s.open(title, w, h)
+	if err != nil {
+		s.destroy()
+		return nil, false, err
+	}
+	return s, vsync, nil
+}
+
+func (s *sdlRenderer) open(title string, w, h int) (vsync bool, err error) {
+	s.window, err = sdl.CreateWindow(title, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(w), int32(h), sdl.WINDOW_SHOWN)
+	if err != nil {
+		return false, fmt.Errorf("gfx: creating window: %w", err)
+	}
+
+	s.renderer, err = sdl.CreateRenderer(s.window, -1, sdl.RENDERER_ACCELERATED|sdl.RENDERER_PRESENTVSYNC)
+	if err != nil {
+		s.renderer, err = sdl.CreateRenderer(s.window, -1, sdl.RENDERER_ACCELERATED)
+		if err != nil {
+			return false, fmt.Errorf("gfx: creating renderer: %w", err)
+		}
+	}
+	if info, err := s.renderer.GetInfo(); err == nil {
+		vsync = info.Flags&sdl.RENDERER_PRESENTVSYNC != 0
+	}
+
+	s.tex, err = s.renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(w), int32(h))
+	if err != nil {
+		return false, fmt.Errorf("gfx: creating texture: %w", err)
+	}
+	return vsync, nil
+}
+
+// upload writes the rows straight into the texture's memory. Only the
+// locked rows are written, and all of them, since SDL does not promise a
+// locked area still holds the old pixels.
+func (s *sdlRenderer) upload(pixels []byte, pitch, start, end int) error {
+	rect := &sdl.Rect{X: 0, Y: int32(start), W: int32(s.w), H: int32(end - start)}
+	dst, texPitch, err := s.tex.Lock(rect)
+	if err != nil {
+		return err
+	}
+	defer s.tex.Unlock()
+	copyRows(dst, texPitch, pixels[start*pitch:], pitch, s.w*4, end-start)
+	return nil
+}
+
+func (s *sdlRenderer) present() error {
+	err := s.renderer.Copy(s.tex, nil, nil)
+	if err != nil {
+		return err
+	}
+	s.renderer.Present()
+	return nil
+}
+
+func (s *sdlRenderer) pollEvent() Event {
+	for {
+		event := sdl.PollEvent()
+		switch e := event.(type) {
+		case nil:
+			return nil
+		case *sdl.QuitEvent:
+			return QuitEvent{}
+		case *sdl.KeyboardEvent:
+			return KeyEvent{
+				Scancode: Scancode(e.Keysym.Scancode),
+				Mod:      Mod(e.Keysym.Mod),
+				Down:     e.Type == sdl.KEYDOWN,
+				Repeat:   e.Repeat != 0,
+			}
+		case *sdl.MouseWheelEvent:
+			x, y := int(e.X), int(e.Y)
+			if e.Direction == sdl.MOUSEWHEEL_FLIPPED {
+				x, y = -x, -y
+			}
+			return WheelEvent{x, y}
+		case *sdl.TextInputEvent:
+			return TextEvent{e.GetText()}
+		case *sdl.ControllerDeviceEvent:
+			if event := s.controllerDevice(e); event != nil {
+				return event
+			}
+		case *sdl.ControllerAxisEvent:
+			v := float64(e.Value) / 32767
+			if v < -1 {
+				v = -1
+			}
+			return ControllerAxisEvent{int(e.Which), ControllerAxis(e.Axis), v}
+		case *sdl.ControllerButtonEvent:
+			return ControllerButtonEvent{int(e.Which), ControllerButton(e.Button), e.State == sdl.PRESSED}
+		}
+		// anything else is skipped
+	}
+}
+
+// controllerDevice opens a controller plugged in and closes one unplugged,
+// nil if it is neither or the controller will not open. SDL sends an added
+// event for every controller plugged in at start up too.
+func (s *sdlRenderer) controllerDevice(e *sdl.ControllerDeviceEvent) Event {
+	switch e.Type {
+	case sdl.CONTROLLERDEVICEADDED:
+		// Which is a device index here, the other events use the instance id
+		ctrl := sdl.GameControllerOpen(int(e.Which))
+		if ctrl == nil {
+			fmt.Println("gfx: opening controller:", sdl.GetError())
+			return nil
+		}
+		id := ctrl.Joystick().InstanceID()
+		if _, ok := s.controllers[id]; ok {
+			// already open, opening it again only counted a reference
+			ctrl.Close()
+			return nil
+		}
+		s.controllers[id] = ctrl
+		return ControllerAddedEvent{int(id), ctrl.Name()}
+	case sdl.CONTROLLERDEVICEREMOVED:
+		ctrl, ok := s.controllers[e.Which]
+		if !ok {
+			return nil
+		}
+		ctrl.Close()
+		delete(s.controllers, e.Which)
+		return ControllerRemovedEvent{int(e.Which)}
+	}
+	return nil
+}
+
+func (s *sdlRenderer) keyboardState() []uint8 {
+	return sdl.GetKeyboardState()
+}
+
+func (s *sdlRenderer) mouseState() (x, y int, buttons MouseButton) {
+	mx, my, state := sdl.GetMouseState()
+	if state&sdl.ButtonLMask() != 0 {
+		buttons |= MouseLeft
+	}
+	if state&sdl.ButtonMMask() != 0 {
+		buttons |= MouseMiddle
+	}
+	if state&sdl.ButtonRMask() != 0 {
+		buttons |= MouseRight
+	}
+	return int(mx), int(my), buttons
+}
+
+func (s *sdlRenderer) textInput(on bool) {
+	if on {
+		sdl.StartTextInput()
+	} else {
+		sdl.StopTextInput()
+	}
+}
+
+func (s *sdlRenderer) setTitle(title string) {
+	s.window.SetTitle(title)
+}
+
+func (s *sdlRenderer) destroy() {
+	for _, ctrl := range s.controllers {
+		ctrl.Close()
+	}
+	if s.tex != nil {
+		s.tex.Destroy()
+	}
+	if s.renderer != nil {
+		s.renderer.Destroy()
+	}
+	if s.window != nil {
+		s.window.Destroy()
+	}
+	sdl.Quit()
+}
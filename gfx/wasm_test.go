@@ -0,0 +1,28 @@
+package gfx
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// webPackages are gfx and the programs on it, which all have to build for
+// the browser as well as the desktop
+var webPackages = []string{".", "../simplexnoise", "../torchlight", "../tileworld", "../fifteen", "../breakout", "../mines", "../rpg", "../battle"}
+
+func TestWebBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command: ", err)
+	}
+	// several packages are built and thrown away, nothing is written
+	cmd := exec.Command(goTool, append([]string{"build"}, webPackages...)...)
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v do not build for js/wasm: %v\n%s", webPackages, err, out)
+	}
+}
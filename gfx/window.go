@@ -0,0 +1,219 @@
+package gfx
+
+import (
+	"fmt"
+	"time"
+)
+
+// Color is an opaque pixel color
+type Color struct {
+	R, G, B byte
+}
+
+// Event is one of the event types below, as returned by PollEvents
+type Event interface{}
+
+// QuitEvent is sent when the window is closed
+type QuitEvent struct{}
+
+// KeyEvent is a key going down or up. Repeat is set on the key downs sent
+// while a key is held.
+type KeyEvent struct {
+	Scancode Scancode
+	Mod      Mod
+	Down     bool
+	Repeat   bool
+}
+
+// WheelEvent is the mouse wheel turning, Y is positive away from the user
+// and X to the right
+type WheelEvent struct {
+	X, Y int
+}
+
+// TextEvent is text typed while text input is on, already composed by the
+// keyboard layout and input method
+type TextEvent struct {
+	Text string
+}
+
+// renderer is everything a Window needs from the platform, there is one
+// for SDL and one for an HTML canvas when built for js. Window only reaches
+// the platform through it, so the tests can substitute a fake.
+type renderer interface {
+	// upload copies rows start..end-1 of pixels, pitch bytes apart, to what
+	// present shows
+	upload(pixels []byte, pitch, start, end int) error
+	present() error
+	// pollEvent returns the next pending event, or nil once there is none
+	pollEvent() Event
+	keyboardState() []uint8
+	mouseState() (x, y int, buttons MouseButton)
+	textInput(on bool)
+	setTitle(title string)
+	destroy()
+}
+
+// Window is a w*h window showing a pixel buffer. The buffer is 4 bytes per
+// pixel, r, g, b and an unused byte, row by row, and nothing changes on
+// screen until it is uploaded by Present or Update.
+type Window struct {
+	w, h     int
+	pixels   []byte
+	events   []Event
+	vsync    bool
+	renderer renderer
+	title    string
+	// fpsCap and uploadTime are Run's, see SetFPSCap and UploadTime
+	fpsCap     int
+	uploadTime time.Duration
+}
+
+func newWindow(w, h int, r renderer, vsync bool) *Window {
+	return &Window{w: w, h: h, pixels: make([]byte, w*h*4), vsync: vsync, renderer: r}
+}
+
+// New opens a w*h window titled title, synced to the display if the driver
+// allows it
+func New(title string, w, h int) (*Window, error) {
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("gfx: window size %dx%d", w, h)
+	}
+	r, vsync, err := newRenderer(title, w, h)
+	if err != nil {
+		return nil, err
+	}
+	win := newWindow(w, h, r, vsync)
+	win.title = title
+	return win, nil
+}
+
+func (win *Window) Width() int {
+	return win.w
+}
+
+func (win *Window) Height() int {
+	return win.h
+}
+
+// Pixels is the buffer the window shows, it can be written directly
+func (win *Window) Pixels() []byte {
+	return win.pixels
+}
+
+// VSync reports whether Present waits for the display, if it does not the
+// caller has to pace its frames itself
+func (win *Window) VSync() bool {
+	return win.vsync
+}
+
+// SetPixel sets pixel x, y to c, pixels outside the window are ignored
+func (win *Window) SetPixel(x, y int, c Color) {
+	if x < 0 || x >= win.w || y < 0 || y >= win.h {
+		return
+	}
+	i := (y*win.w + x) * 4
+	win.pixels[i] = c.R
+	win.pixels[i+1] = c.G
+	win.pixels[i+2] = c.B
+}
+
+// Clear sets every pixel to c
+func (win *Window) Clear(c Color) {
+	if len(win.pixels) == 0 {
+		return
+	}
+	win.pixels[0], win.pixels[1], win.pixels[2], win.pixels[3] = c.R, c.G, c.B, 0
+	// doubling the filled part copies whole runs of pixels at a time
+	for filled := 4; filled < len(win.pixels); filled *= 2 {
+		copy(win.pixels[filled:], win.pixels[:filled])
+	}
+}
+
+// Update uploads rows start..end-1 of the buffer without showing them, for
+// callers that keep track of which rows changed. Rows outside the window
+// are left out.
+func (win *Window) Update(start, end int) error {
+	if start < 0 {
+		start = 0
+	}
+	if end > win.h {
+		end = win.h
+	}
+	if end <= start {
+		return nil
+	}
+	return win.renderer.upload(win.pixels, win.w*4, start, end)
+}
+
+// copyRows copies rows rows of rowBytes each from src to dst. The pitches
+// are the distances between row starts, a locked texture's pitch can be
+// larger than the row itself.
+func copyRows(dst []byte, dstPitch int, src []byte, srcPitch, rowBytes, rows int) {
+	for y := 0; y < rows; y++ {
+		copy(dst[y*dstPitch:y*dstPitch+rowBytes], src[y*srcPitch:y*srcPitch+rowBytes])
+	}
+}
+
+// Show shows what has been uploaded so far
+func (win *Window) Show() error {
+	return win.renderer.present()
+}
+
+// Present uploads the whole buffer and shows it
+func (win *Window) Present() error {
+	err := win.Update(0, win.h)
+	if err != nil {
+		return err
+	}
+	return win.Show()
+}
+
+// PollEvents returns the events that arrived since the last call. The slice
+// is reused by the next call.
+func (win *Window) PollEvents() []Event {
+	win.events = win.events[:0]
+	for e := win.renderer.pollEvent(); e != nil; e = win.renderer.pollEvent() {
+		win.events = append(win.events, e)
+	}
+	return win.events
+}
+
+// KeyboardState is indexed by scancode, a key that is held is non-zero. It
+// is updated in place by PollEvents.
+func (win *Window) KeyboardState() []uint8 {
+	return win.renderer.keyboardState()
+}
+
+// Mouse returns where the mouse is and whether its left button is held
+func (win *Window) Mouse() (x, y int, left bool) {
+	x, y, buttons := win.renderer.mouseState()
+	return x, y, buttons&MouseLeft != 0
+}
+
+// SetTextInput turns text input on or off. While it is on typed text
+// arrives as TextEvents as well as the usual KeyEvents.
+func (win *Window) SetTextInput(on bool) {
+	win.renderer.textInput(on)
+}
+
+// SetTitle changes the window's title. Window managers can be slow to
+// take a new title, so a title the same as the current one is not passed
+// on at all.
+func (win *Window) SetTitle(title string) {
+	if title == win.title {
+		return
+	}
+	win.title = title
+	win.renderer.setTitle(title)
+}
+
+// Title is the window's title
+func (win *Window) Title() string {
+	return win.title
+}
+
+// Destroy closes the window
+func (win *Window) Destroy() {
+	win.renderer.destroy()
+}
@@ -0,0 +1,199 @@
+package gfx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// fakeRenderer records uploads into its own copy of the pixels and hands out
+// queued events
+type fakeRenderer struct {
+	shown     []byte
+	uploads   [][2]int
+	presents  int
+	events    []Event
+	keys      []uint8
+	titles    []string
+	destroyed bool
+}
+
+func (f *fakeRenderer) upload(pixels []byte, pitch, start, end int) error {
+	if f.shown == nil {
+		f.shown = make([]byte, len(pixels))
+	}
+	copy(f.shown[start*pitch:end*pitch], pixels[start*pitch:end*pitch])
+	f.uploads = append(f.uploads, [2]int{start, end})
+	return nil
+}
+
+func (f *fakeRenderer) present() error {
+	f.presents++
+	return nil
+}
+
+func (f *fakeRenderer) pollEvent() Event {
+	if len(f.events) == 0 {
+		return nil
+	}
+	e := f.events[0]
+	f.events = f.events[1:]
+	return e
+}
+
+func (f *fakeRenderer) keyboardState() []uint8 {
+	return f.keys
+}
+
+func (f *fakeRenderer) mouseState() (x, y int, buttons MouseButton) {
+	return 3, 4, MouseLeft
+}
+
+func (f *fakeRenderer) textInput(on bool) {}
+
+func (f *fakeRenderer) setTitle(title string) {
+	f.titles = append(f.titles, title)
+}
+
+func (f *fakeRenderer) destroy() {
+	f.destroyed = true
+}
+
+func TestSetPixel(t *testing.T) {
+	win := newWindow(4, 3, &fakeRenderer{}, false)
+	c := Color{10, 20, 30}
+	win.SetPixel(2, 1, c)
+	i := (1*4 + 2) * 4
+	if got := win.Pixels()[i : i+4]; !bytes.Equal(got, []byte{10, 20, 30, 0}) {
+		t.Errorf("pixel 2,1 is %v", got)
+	}
+
+	// every other byte is untouched, including by the pixels off the window
+	for _, p := range [][2]int{{-1, 0}, {4, 0}, {0, -1}, {0, 3}, {100, 100}} {
+		win.SetPixel(p[0], p[1], c)
+	}
+	for j, v := range win.Pixels() {
+		if (j < i || j >= i+3) && v != 0 {
+			t.Fatalf("byte %d is %d, only pixel 2,1 was set", j, v)
+		}
+	}
+}
+
+func TestClear(t *testing.T) {
+	// 7*5 pixels is not a power of two, so the last copy is a partial one
+	win := newWindow(7, 5, &fakeRenderer{}, false)
+	win.SetPixel(3, 3, Color{1, 2, 3})
+	win.Clear(Color{200, 100, 50})
+	for j := 0; j < len(win.Pixels()); j += 4 {
+		if got := win.Pixels()[j : j+4]; !bytes.Equal(got, []byte{200, 100, 50, 0}) {
+			t.Fatalf("pixel %d is %v after Clear", j/4, got)
+		}
+	}
+}
+
+func TestPresent(t *testing.T) {
+	fake := &fakeRenderer{}
+	win := newWindow(4, 3, fake, false)
+	win.Clear(Color{9, 9, 9})
+	if err := win.Present(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fake.shown, win.Pixels()) || fake.presents != 1 {
+		t.Errorf("Present showed %v after %d presents", fake.shown, fake.presents)
+	}
+}
+
+func TestUpdateClipsRows(t *testing.T) {
+	tests := []struct {
+		start, end int
+		want       [][2]int
+	}{
+		{0, 3, [][2]int{{0, 3}}},
+		{1, 2, [][2]int{{1, 2}}},
+		{-5, 10, [][2]int{{0, 3}}},
+		{2, 2, nil},
+		{3, 1, nil},
+	}
+	for _, tt := range tests {
+		fake := &fakeRenderer{}
+		win := newWindow(4, 3, fake, false)
+		if err := win.Update(tt.start, tt.end); err != nil {
+			t.Fatal(err)
+		}
+		if len(fake.uploads) != len(tt.want) || (len(tt.want) == 1 && fake.uploads[0] != tt.want[0]) {
+			t.Errorf("Update(%d, %d) uploaded %v, want %v", tt.start, tt.end, fake.uploads, tt.want)
+		}
+		if fake.presents != 0 {
+			t.Errorf("Update(%d, %d) presented", tt.start, tt.end)
+		}
+	}
+}
+
+// TestCopyRowsPitch copies into destinations whose pitch is wider than a
+// row, as a locked texture's can be. The padding after each row must be
+// left alone.
+func TestCopyRowsPitch(t *testing.T) {
+	const w, rows = 3, 4
+	src := make([]byte, w*4*rows)
+	for i := range src {
+		src[i] = byte(i + 1)
+	}
+	for _, dstPitch := range []int{w * 4, w*4 + 4, w*4 + 13} {
+		dst := bytes.Repeat([]byte{0xee}, dstPitch*(rows+1))
+		copyRows(dst, dstPitch, src, w*4, w*4, rows)
+		for y := 0; y < rows; y++ {
+			row := dst[y*dstPitch : (y+1)*dstPitch]
+			if !bytes.Equal(row[:w*4], src[y*w*4:(y+1)*w*4]) {
+				t.Errorf("pitch %d: row %d is %v, want %v", dstPitch, y, row[:w*4], src[y*w*4:(y+1)*w*4])
+			}
+			for x, b := range row[w*4:] {
+				if b != 0xee {
+					t.Errorf("pitch %d: padding byte %d of row %d overwritten with %d", dstPitch, x, y, b)
+				}
+			}
+		}
+		for _, b := range dst[rows*dstPitch:] {
+			if b != 0xee {
+				t.Fatalf("pitch %d: wrote past the last row", dstPitch)
+			}
+		}
+	}
+
+	// and the other way round, the last two pixels out of wider rows
+	dst := make([]byte, 2*4*rows)
+	copyRows(dst, 2*4, src[4:], w*4, 2*4, rows)
+	for y := 0; y < rows; y++ {
+		if want := src[y*w*4+4 : (y+1)*w*4]; !bytes.Equal(dst[y*8:(y+1)*8], want) {
+			t.Errorf("source pitch %d: row %d is %v, want %v", w*4, y, dst[y*8:(y+1)*8], want)
+		}
+	}
+}
+
+func TestPollEvents(t *testing.T) {
+	key := KeyEvent{Scancode: KeyA, Down: true}
+	text := TextEvent{"a"}
+	fake := &fakeRenderer{events: []Event{key, text, QuitEvent{}}}
+	win := newWindow(1, 1, fake, false)
+	events := win.PollEvents()
+	if len(events) != 3 || events[0] != key || events[1] != text || events[2] != (QuitEvent{}) {
+		t.Errorf("PollEvents returned %v", events)
+	}
+	if events := win.PollEvents(); len(events) != 0 {
+		t.Errorf("second PollEvents returned %v", events)
+	}
+}
+
+func TestSetTitle(t *testing.T) {
+	fake := &fakeRenderer{}
+	win := newWindow(1, 1, fake, false)
+	win.title = "a"
+	for _, title := range []string{"a", "b", "b", "c", "c", "a"} {
+		win.SetTitle(title)
+	}
+	if want := []string{"b", "c", "a"}; !reflect.DeepEqual(fake.titles, want) {
+		t.Errorf("titles set %q, want %q", fake.titles, want)
+	}
+	if win.Title() != "a" {
+		t.Errorf("title is %q", win.Title())
+	}
+}
@@ -0,0 +1,106 @@
+package hull
+
+import (
+	"sort"
+
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+// orientation is positive when a, b, c turn counter clockwise in a y up
+// frame, negative for clockwise and zero when they are collinear
+func orientation(a, b, c vector2.Vector2) float32 {
+	return vector2.Cross(vector2.Sub(b, a), vector2.Sub(c, a))
+}
+
+// lowest returns the index of the point with the smallest x, ties broken by
+// the smallest y. It is always a hull vertex.
+func lowest(points []vector2.Vector2) int {
+	result := 0
+	for i, p := range points {
+		q := points[result]
+		if p.X < q.X || (p.X == q.X && p.Y < q.Y) {
+			result = i
+		}
+	}
+	return result
+}
+
+// GiftWrapping returns the convex hull of points with the Jarvis march in
+// O(nh). Vertices run counter clockwise in a y up frame starting at the
+// leftmost point, collinear points along an edge are left out.
+func GiftWrapping(points []vector2.Vector2) []vector2.Vector2 {
+	if len(points) < 3 {
+		return append([]vector2.Vector2(nil), points...)
+	}
+	start := lowest(points)
+	var result []vector2.Vector2
+	current := start
+	for {
+		result = append(result, points[current])
+		next := -1
+		for i, p := range points {
+			if p == points[current] {
+				continue
+			}
+			if next < 0 {
+				next = i
+				continue
+			}
+			o := orientation(points[current], points[next], p)
+			// take p if it lies clockwise of the candidate, or further
+			// along the same line so the middle points are skipped
+			if o < 0 || (o == 0 && vector2.DistanceSquared(points[current], p) > vector2.DistanceSquared(points[current], points[next])) {
+				next = i
+			}
+		}
+		if next < 0 || points[next] == points[start] || len(result) > len(points) {
+			return result
+		}
+		current = next
+	}
+}
+
+// GrahamScan returns the same hull as GiftWrapping in O(n log n) by sorting
+// the points around the leftmost one and keeping only left turns
+func GrahamScan(points []vector2.Vector2) []vector2.Vector2 {
+	if len(points) < 3 {
+		return append([]vector2.Vector2(nil), points...)
+	}
+	pivot := points[lowest(points)]
+	sorted := make([]vector2.Vector2, 0, len(points))
+	for _, p := range points {
+		if p != pivot {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		o := orientation(pivot, sorted[i], sorted[j])
+		if o != 0 {
+			return o > 0
+		}
+		return vector2.DistanceSquared(pivot, sorted[i]) < vector2.DistanceSquared(pivot, sorted[j])
+	})
+
+	result := []vector2.Vector2{pivot}
+	for _, p := range sorted {
+		for len(result) > 1 && orientation(result[len(result)-2], result[len(result)-1], p) <= 0 {
+			result = result[:len(result)-1]
+		}
+		if p != result[len(result)-1] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Area returns the area enclosed by the polygon with the given vertices
+func Area(polygon []vector2.Vector2) float32 {
+	var sum float32
+	for i := range polygon {
+		sum += vector2.Cross(polygon[i], polygon[(i+1)%len(polygon)])
+	}
+	if sum < 0 {
+		sum = -sum
+	}
+	return sum / 2
+}
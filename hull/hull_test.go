@@ -0,0 +1,66 @@
+package hull
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+// randomPoints is n points on a side*side integer grid, small grids give
+// many duplicate and collinear points. The coordinates are whole numbers
+// so orientation is exact.
+func randomPoints(rng *rand.Rand, n, side int) []vector2.Vector2 {
+	points := make([]vector2.Vector2, n)
+	for i := range points {
+		points[i] = vector2.Vector2{X: float32(rng.Intn(side)), Y: float32(rng.Intn(side))}
+	}
+	return points
+}
+
+// checkHull fails t unless hull is convex, turns counter clockwise without
+// collinear vertices and has every point inside or on it
+func checkHull(t *testing.T, name string, hull, points []vector2.Vector2) {
+	t.Helper()
+	if len(hull) < 3 {
+		return
+	}
+	for i := range hull {
+		a, b, c := hull[i], hull[(i+1)%len(hull)], hull[(i+2)%len(hull)]
+		if orientation(a, b, c) <= 0 {
+			t.Fatalf("%s: %v, %v, %v is not a left turn in %v", name, a, b, c, hull)
+		}
+		for _, p := range points {
+			if orientation(a, b, p) < 0 {
+				t.Fatalf("%s: %v is outside edge %v %v of %v", name, p, a, b, hull)
+			}
+		}
+	}
+}
+
+func TestHullsAgree(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sets := [][]vector2.Vector2{
+		// a square with points along its edges and in the middle
+		{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 2}, {X: 4, Y: 4}, {X: 2, Y: 4}, {X: 0, Y: 4}, {X: 0, Y: 2}, {X: 2, Y: 2}},
+		// a triangle with every vertex twice
+		{{X: 0, Y: 0}, {X: 5, Y: 1}, {X: 2, Y: 6}, {X: 0, Y: 0}, {X: 5, Y: 1}, {X: 2, Y: 6}},
+		// all on one line, the hull is its two ends
+		{{X: 2, Y: 2}, {X: 0, Y: 0}, {X: 3, Y: 3}, {X: 1, Y: 1}, {X: 3, Y: 3}},
+	}
+	for len(sets) < 100 {
+		n := 3 + rng.Intn(60)
+		side := []int{4, 10, 1000}[len(sets)%3]
+		sets = append(sets, randomPoints(rng, n, side))
+	}
+
+	for i, points := range sets {
+		gift, graham := GiftWrapping(points), GrahamScan(points)
+		checkHull(t, "GiftWrapping", gift, points)
+		checkHull(t, "GrahamScan", graham, points)
+		if len(gift) != len(graham) || Area(gift) != Area(graham) {
+			t.Errorf("set %d %v: GiftWrapping has %d vertices and area %v, GrahamScan %d and %v",
+				i, points, len(gift), Area(gift), len(graham), Area(graham))
+		}
+	}
+}
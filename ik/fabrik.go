@@ -0,0 +1,89 @@
+package ik
+
+import "github.com/sabith-th/games_with_go/vector2"
+
+// Tolerance is how close the end effector has to get to the target for
+// FABRIK to stop early
+const Tolerance = 0.1
+
+// Chain is a series of rigid links. Joints[0] is the root, Joints[i+1] is
+// Lengths[i] away from Joints[i] and the last joint is the end effector.
+type Chain struct {
+	Joints  []vector2.Vector2
+	Lengths []float32
+}
+
+// NewChain returns a straight chain from root in direction dir
+func NewChain(root vector2.Vector2, lengths []float32, dir vector2.Vector2) *Chain {
+	dir = vector2.Normalize(dir)
+	joints := make([]vector2.Vector2, len(lengths)+1)
+	joints[0] = root
+	for i, l := range lengths {
+		joints[i+1] = vector2.Add(joints[i], vector2.Mult(dir, l))
+	}
+	return &Chain{joints, lengths}
+}
+
+// Root returns the first joint
+func (c *Chain) Root() vector2.Vector2 {
+	return c.Joints[0]
+}
+
+// End returns the end effector
+func (c *Chain) End() vector2.Vector2 {
+	return c.Joints[len(c.Joints)-1]
+}
+
+// Reach returns the total length of the links
+func (c *Chain) Reach() float32 {
+	var total float32
+	for _, l := range c.Lengths {
+		total += l
+	}
+	return total
+}
+
+// towards returns the point length away from from in the direction of to.
+// Coincident points give no direction, so it picks one along x.
+func towards(from, to vector2.Vector2, length float32) vector2.Vector2 {
+	d := vector2.Sub(to, from)
+	dist := d.Length()
+	if dist == 0 {
+		return vector2.Add(from, vector2.Vector2{X: length})
+	}
+	return vector2.Add(from, vector2.Mult(d, length/dist))
+}
+
+// FABRIK moves the joints of chain so the end effector reaches target,
+// keeping the root where it is and every link at its length. Each iteration
+// pulls the chain from the effector to the target, then back to the root.
+// It returns the number of iterations used and whether the effector ended
+// within Tolerance of the target. A target out of reach leaves the chain
+// stretched straight towards it.
+func FABRIK(chain *Chain, target vector2.Vector2, maxIterations int) (int, bool) {
+	joints, lengths := chain.Joints, chain.Lengths
+	n := len(lengths)
+	root := joints[0]
+
+	if vector2.Distance(root, target) > chain.Reach() {
+		for i := 0; i < n; i++ {
+			joints[i+1] = towards(joints[i], target, lengths[i])
+		}
+		return 0, false
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		if vector2.Distance(joints[n], target) <= Tolerance {
+			return i, true
+		}
+		joints[n] = target
+		for j := n - 1; j >= 0; j-- {
+			joints[j] = towards(joints[j+1], joints[j], lengths[j])
+		}
+		joints[0] = root
+		for j := 0; j < n; j++ {
+			joints[j+1] = towards(joints[j], joints[j+1], lengths[j])
+		}
+	}
+	return maxIterations, vector2.Distance(joints[n], target) <= Tolerance
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sabith-th/games_with_go/config"
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/gfx"
+	"github.com/sabith-th/games_with_go/minesweeper"
+)
+
+const winWidth, winHeight int = 800, 600
+
+// the board fits between the status line at the top and the help line at
+// the bottom, with cells at most maxCellSize pixels
+const (
+	top         = 50
+	bottom      = 40
+	maxCellSize = 32
+)
+
+const help = "left: reveal  right: flag  middle: chord  r: new game"
+
+var (
+	white      = font.Color{R: 255, G: 255, B: 255}
+	background = font.Color{R: 30, G: 30, B: 40}
+)
+
+// mines plays games of Minesweeper one after another
+type mines struct {
+	w, h, count int
+	seed        int64
+	game        *minesweeper.Game
+	view        minesweeper.View
+	quit        bool
+	// boards, tries and guesses add up the GenerateStats of every board
+	// generated, guesses counting those that needed one
+	boards, tries, guesses int
+}
+
+func (m *mines) newGame() {
+	m.seed++
+	// the size was checked when the first game was made
+	m.game, _ = minesweeper.NewGame(m.w, m.h, m.count, m.seed)
+}
+
+func (m *mines) Update(in gfx.Input, dt float64) {
+	m.quit = in.Pressed(gfx.KeyEscape)
+	if in.Pressed(gfx.KeyR) {
+		m.newGame()
+	}
+	m.game.Update(dt)
+	x, y, ok := m.view.CellAt(m.game.Board, in.MouseX, in.MouseY)
+	if !ok {
+		return
+	}
+	switch {
+	case in.Clicked&gfx.MouseLeft != 0:
+		started := m.game.Started()
+		m.game.Reveal(x, y)
+		if !started {
+			m.logStats(m.game.Stats)
+		}
+	case in.Clicked&gfx.MouseRight != 0:
+		m.game.ToggleFlag(x, y)
+	case in.Clicked&gfx.MouseMiddle != 0:
+		m.game.Chord(x, y)
+	}
+}
+
+// logStats prints how many layouts the new board took and the totals so
+// far
+func (m *mines) logStats(s minesweeper.GenerateStats) {
+	m.boards++
+	m.tries += s.Tries
+	found := "solvable"
+	if !s.Solvable {
+		m.guesses++
+		found = "gave up, needs a guess"
+	}
+	fmt.Printf("board %d: %s after %d tries, %.1f tries a board, %d of %d need a guess\n",
+		m.boards, found, s.Tries, float64(m.tries)/float64(m.boards), m.guesses, m.boards)
+}
+
+func (m *mines) Draw(pixels []byte, w, h int) {
+	for i := 0; i < len(pixels); i += 4 {
+		pixels[i], pixels[i+1], pixels[i+2] = background.R, background.G, background.B
+	}
+	m.view.Draw(m.game, pixels, w, h)
+
+	status := fmt.Sprintf("mines %d  time %d", m.game.FlagsLeft(), int(m.game.Elapsed/time.Second))
+	switch m.game.State {
+	case minesweeper.Won:
+		status += fmt.Sprintf("  cleared in %.1fs", m.game.Elapsed.Seconds())
+	case minesweeper.Lost:
+		status += "  boom"
+	}
+	font.Draw(status, m.view.X, 16, 3, white, pixels, w, h)
+	tw, _ := font.Size(help, 2)
+	font.Draw(help, (w-tw)/2, h-28, 2, white, pixels, w, h)
+}
+
+func (m *mines) Done() bool {
+	return m.quit
+}
+
+func main() {
+	width := flag.Int("width", 16, "columns of cells")
+	height := flag.Int("height", 16, "rows of cells")
+	count := flag.Int("mines", 40, "number of mines")
+	configFile := flag.String("config", "", "read flags not given on the command line from this JSON file")
+	flag.Parse()
+	if *configFile != "" {
+		err := config.LoadConfig(flag.CommandLine, *configFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+	}
+
+	m := &mines{w: *width, h: *height, count: *count, seed: time.Now().UnixNano()}
+	var err error
+	m.game, err = minesweeper.NewGame(m.w, m.h, m.count, m.seed)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	size := (winWidth - 40) / m.w
+	if s := (winHeight - top - bottom) / m.h; s < size {
+		size = s
+	}
+	if size > maxCellSize {
+		size = maxCellSize
+	}
+	if size < 8 {
+		fmt.Println("the board does not fit the window")
+		os.Exit(2)
+	}
+	m.view = minesweeper.View{
+		X:        (winWidth - m.w*size) / 2,
+		Y:        top + (winHeight-top-bottom-m.h*size)/2,
+		CellSize: size,
+	}
+	err = gfx.Run("Minesweeper", winWidth, winHeight, m)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,127 @@
+package minesweeper
+
+import "math/rand"
+
+// Cell is one square of a board
+type Cell struct {
+	Mine     bool
+	Flagged  bool
+	Revealed bool
+	// Count is how many of the eight cells around it are mines
+	Count uint8
+}
+
+// Board is a W*H grid of cells stored row by row. A copy of a board shares
+// its cells, what changes one changes every copy.
+type Board struct {
+	W, H  int
+	Cells []Cell
+}
+
+// NewBoard returns a w*h board with no mines and nothing revealed
+func NewBoard(w, h int) Board {
+	return Board{W: w, H: h, Cells: make([]Cell, w*h)}
+}
+
+// In reports whether x, y is on the board
+func (b Board) In(x, y int) bool {
+	return x >= 0 && x < b.W && y >= 0 && y < b.H
+}
+
+// At is the cell at x, y, which must be on the board
+func (b Board) At(x, y int) *Cell {
+	return &b.Cells[y*b.W+x]
+}
+
+// Clone is a copy of b with cells of its own
+func (b Board) Clone() Board {
+	c := Board{W: b.W, H: b.H, Cells: make([]Cell, len(b.Cells))}
+	copy(c.Cells, b.Cells)
+	return c
+}
+
+// neighbours calls visit with each cell around x, y that is on the board
+func (b Board) neighbours(x, y int, visit func(nx, ny int)) {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (dx != 0 || dy != 0) && b.In(x+dx, y+dy) {
+				visit(x+dx, y+dy)
+			}
+		}
+	}
+}
+
+// PlaceMines clears b and puts n mines on it at random, never on safeX,
+// safeY and, when there is room, not around it either so the first cell
+// revealed opens an area. n must leave at least that cell free.
+func PlaceMines(b Board, n, safeX, safeY int, rng *rand.Rand) {
+	for i := range b.Cells {
+		b.Cells[i] = Cell{}
+	}
+	around := 0
+	b.neighbours(safeX, safeY, func(int, int) { around++ })
+	wide := n <= len(b.Cells)-1-around
+	var free []int
+	for i := range b.Cells {
+		dx, dy := abs(i%b.W-safeX), abs(i/b.W-safeY)
+		if dx == 0 && dy == 0 || wide && dx <= 1 && dy <= 1 {
+			continue
+		}
+		free = append(free, i)
+	}
+	rng.Shuffle(len(free), func(i, j int) { free[i], free[j] = free[j], free[i] })
+	for _, i := range free[:n] {
+		b.Cells[i].Mine = true
+	}
+	for y := 0; y < b.H; y++ {
+		for x := 0; x < b.W; x++ {
+			c := b.At(x, y)
+			b.neighbours(x, y, func(nx, ny int) {
+				if b.At(nx, ny).Mine {
+					c.Count++
+				}
+			})
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// FloodFill reveals x, y and, if no mines are around it, breadth first
+// every cell connected to it through cells with no mines around, along
+// with the numbered cells at the edge. Flagged cells stay hidden. It
+// returns how many cells it revealed.
+func FloodFill(b Board, x, y int) int {
+	if !b.In(x, y) {
+		return 0
+	}
+	c := b.At(x, y)
+	if c.Revealed || c.Flagged {
+		return 0
+	}
+	c.Revealed = true
+	revealed := 1
+	queue := [][2]int{{x, y}}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if b.At(p[0], p[1]).Count != 0 || b.At(p[0], p[1]).Mine {
+			continue
+		}
+		b.neighbours(p[0], p[1], func(nx, ny int) {
+			n := b.At(nx, ny)
+			if n.Revealed || n.Flagged {
+				return
+			}
+			n.Revealed = true
+			revealed++
+			queue = append(queue, [2]int{nx, ny})
+		})
+	}
+	return revealed
+}
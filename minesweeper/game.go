@@ -0,0 +1,172 @@
+package minesweeper
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// State is whether a game is still being played
+type State int
+
+const (
+	Playing State = iota
+	Won
+	Lost
+)
+
+// Game is a game of Minesweeper. The mines are placed by the first
+// reveal with GenerateBoard, away from the cell revealed and so that the
+// rest of the board can be worked out without guessing.
+type Game struct {
+	Board Board
+	Mines int
+	State State
+	// Elapsed is the time from the first reveal until the game ended
+	Elapsed time.Duration
+	// ExplodedX, ExplodedY is the mine revealed in a lost game
+	ExplodedX, ExplodedY int
+	// Stats is how the board was generated, once it has been
+	Stats GenerateStats
+
+	rng     *rand.Rand
+	started bool
+	flags   int
+	// hidden counts the cells without a mine still to reveal
+	hidden int
+}
+
+// NewGame returns a game on a w*h board with mines mines. At least one
+// cell has to be free of mines.
+func NewGame(w, h, mines int, seed int64) (*Game, error) {
+	if w < 1 || h < 1 {
+		return nil, fmt.Errorf("minesweeper: board size %dx%d", w, h)
+	}
+	if mines < 1 || mines >= w*h {
+		return nil, fmt.Errorf("minesweeper: %d mines on %d cells", mines, w*h)
+	}
+	return &Game{
+		Board:  NewBoard(w, h),
+		Mines:  mines,
+		rng:    rand.New(rand.NewSource(seed)),
+		hidden: w*h - mines,
+	}, nil
+}
+
+// Started reports whether the mines are placed
+func (g *Game) Started() bool {
+	return g.started
+}
+
+// FlagsLeft is the number of mines less the flags placed, negative when
+// there are more flags than mines
+func (g *Game) FlagsLeft() int {
+	return g.Mines - g.flags
+}
+
+// Update counts dt seconds towards Elapsed while the game is on
+func (g *Game) Update(dt float64) {
+	if g.started && g.State == Playing {
+		g.Elapsed += time.Duration(dt * float64(time.Second))
+	}
+}
+
+// Reveal uncovers x, y, flood filling if it has no mines around. The first
+// reveal places the mines.
+func (g *Game) Reveal(x, y int) {
+	if g.State != Playing || !g.Board.In(x, y) {
+		return
+	}
+	if !g.started {
+		g.Board, g.Stats = GenerateBoard(g.Board.W, g.Board.H, g.Mines, x, y, g.rng)
+		g.started = true
+		for _, c := range g.Board.Cells {
+			if c.Revealed {
+				g.hidden--
+			}
+		}
+		if g.hidden == 0 {
+			g.win()
+		}
+		return
+	}
+	c := g.Board.At(x, y)
+	if c.Revealed || c.Flagged {
+		return
+	}
+	if c.Mine {
+		c.Revealed = true
+		g.lose(x, y)
+		return
+	}
+	g.hidden -= FloodFill(g.Board, x, y)
+	if g.hidden == 0 {
+		g.win()
+	}
+}
+
+// ToggleFlag marks or unmarks a hidden cell as a mine, there are none to
+// mark before the first reveal
+func (g *Game) ToggleFlag(x, y int) {
+	if g.State != Playing || !g.started || !g.Board.In(x, y) {
+		return
+	}
+	c := g.Board.At(x, y)
+	if c.Revealed {
+		return
+	}
+	c.Flagged = !c.Flagged
+	if c.Flagged {
+		g.flags++
+	} else {
+		g.flags--
+	}
+}
+
+// Chord reveals every hidden cell around a revealed number that has as
+// many flags around it as it counts mines
+func (g *Game) Chord(x, y int) {
+	if g.State != Playing || !g.Board.In(x, y) {
+		return
+	}
+	c := g.Board.At(x, y)
+	if !c.Revealed || c.Count == 0 {
+		return
+	}
+	flags := 0
+	g.Board.neighbours(x, y, func(nx, ny int) {
+		if g.Board.At(nx, ny).Flagged {
+			flags++
+		}
+	})
+	if flags != int(c.Count) {
+		return
+	}
+	g.Board.neighbours(x, y, func(nx, ny int) {
+		g.Reveal(nx, ny)
+	})
+}
+
+// lose shows every mine, the flags on cells without one stay
+func (g *Game) lose(x, y int) {
+	g.State = Lost
+	g.ExplodedX, g.ExplodedY = x, y
+	for i := range g.Board.Cells {
+		c := &g.Board.Cells[i]
+		if c.Mine && !c.Flagged {
+			c.Revealed = true
+		}
+	}
+}
+
+// win flags every mine left unflagged
+func (g *Game) win() {
+	g.State = Won
+	for i := range g.Board.Cells {
+		c := &g.Board.Cells[i]
+		if c.Mine && !c.Flagged {
+			c.Flagged = true
+			g.flags++
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package minesweeper
+
+import "math/rand"
+
+// MaxTries is how many layouts GenerateBoard places before it settles for
+// one that needs a guess
+const MaxTries = 1000
+
+// GenerateStats is how GenerateBoard came by its board
+type GenerateStats struct {
+	// Tries is how many layouts were placed, the board is the last
+	Tries int
+	// Solvable is false if none of MaxTries layouts could be solved
+	// without guessing
+	Solvable bool
+}
+
+// GenerateBoard places mines on a w*h board for a first reveal at x, y,
+// placing them again until IsSolvable says the rest of the board follows
+// from that reveal, at most MaxTries times. The board is returned with x,
+// y revealed.
+func GenerateBoard(w, h, mines, x, y int, rng *rand.Rand) (Board, GenerateStats) {
+	b := NewBoard(w, h)
+	var stats GenerateStats
+	for stats.Tries < MaxTries && !stats.Solvable {
+		stats.Tries++
+		PlaceMines(b, mines, x, y, rng)
+		FloodFill(b, x, y)
+		stats.Solvable = IsSolvable(b)
+	}
+	return b, stats
+}
+
+// IsSolvable reports whether every cell without a mine can be revealed,
+// starting from the cells revealed on b, by constraint propagation alone.
+// Over and over, until neither rule finds anything more, a number with as
+// many hidden cells around it as mines it still needs has mines in all of
+// them, and one with all its mines flagged has none in the rest. Flags on
+// b are not trusted, it works on a copy.
+func IsSolvable(b Board) bool {
+	s := b.Clone()
+	hidden := 0
+	for i := range s.Cells {
+		c := &s.Cells[i]
+		c.Flagged = false
+		if !c.Mine && !c.Revealed {
+			hidden++
+		}
+	}
+	for progress := true; progress && hidden > 0; {
+		progress = false
+		for cy := 0; cy < s.H; cy++ {
+			for cx := 0; cx < s.W; cx++ {
+				c := s.At(cx, cy)
+				if !c.Revealed || c.Count == 0 {
+					continue
+				}
+				unknown, flagged := 0, 0
+				s.neighbours(cx, cy, func(nx, ny int) {
+					n := s.At(nx, ny)
+					if n.Flagged {
+						flagged++
+					} else if !n.Revealed {
+						unknown++
+					}
+				})
+				if unknown == 0 {
+					continue
+				}
+				mines := int(c.Count) - flagged
+				if mines != unknown && mines != 0 {
+					continue
+				}
+				s.neighbours(cx, cy, func(nx, ny int) {
+					n := s.At(nx, ny)
+					if n.Flagged || n.Revealed {
+						return
+					}
+					if mines == 0 {
+						hidden -= FloodFill(s, nx, ny)
+					} else {
+						n.Flagged = true
+					}
+				})
+				progress = true
+			}
+		}
+	}
+	return hidden == 0
+}
@@ -0,0 +1,115 @@
+package minesweeper
+
+import (
+	"strconv"
+
+	"github.com/sabith-th/games_with_go/font"
+)
+
+var (
+	hiddenColor   = font.Color{R: 150, G: 155, B: 170}
+	revealedColor = font.Color{R: 215, G: 215, B: 210}
+	explodedColor = font.Color{R: 230, G: 60, B: 50}
+	mineColor     = font.Color{R: 20, G: 20, B: 20}
+	flagColor     = font.Color{R: 220, G: 40, B: 40}
+	// countColors are the classic colors of the numbers 1 to 8
+	countColors = [9]font.Color{
+		1: {R: 20, G: 60, B: 220},
+		2: {R: 20, G: 130, B: 30},
+		3: {R: 210, G: 30, B: 30},
+		4: {R: 20, G: 20, B: 130},
+		5: {R: 130, G: 20, B: 20},
+		6: {R: 20, G: 130, B: 130},
+		7: {R: 0, G: 0, B: 0},
+		8: {R: 110, G: 110, B: 110},
+	}
+)
+
+// View draws a board as cells CellSize pixels square with the top left
+// corner of the first at X, Y
+type View struct {
+	X, Y, CellSize int
+}
+
+// CellAt is the cell under pixel px, py of b, ok is false if there is none
+func (v View) CellAt(b Board, px, py int) (x, y int, ok bool) {
+	if px < v.X || py < v.Y {
+		return 0, 0, false
+	}
+	x, y = (px-v.X)/v.CellSize, (py-v.Y)/v.CellSize
+	return x, y, b.In(x, y)
+}
+
+func fillRect(x, y, w, h int, c font.Color, pixels []byte, pw, ph int) {
+	for py := y; py < y+h; py++ {
+		if py < 0 || py >= ph {
+			continue
+		}
+		for px := x; px < x+w; px++ {
+			if px < 0 || px >= pw {
+				continue
+			}
+			i := (py*pw + px) * 4
+			pixels[i], pixels[i+1], pixels[i+2] = c.R, c.G, c.B
+		}
+	}
+}
+
+// Draw draws g's board into a pixel buffer w pixels wide and h high. Hidden
+// cells are raised, revealed ones flat with their number, and the mine
+// that lost the game is on red.
+func (v View) Draw(g *Game, pixels []byte, w, h int) {
+	b := g.Board
+	s := v.CellSize
+	for y := 0; y < b.H; y++ {
+		for x := 0; x < b.W; x++ {
+			c := b.At(x, y)
+			px, py := v.X+x*s, v.Y+y*s
+			switch {
+			case c.Revealed:
+				bg := revealedColor
+				if c.Mine && g.State == Lost && x == g.ExplodedX && y == g.ExplodedY {
+					bg = explodedColor
+				}
+				fillRect(px, py, s-1, s-1, bg, pixels, w, h)
+			default:
+				fillRect(px, py, s-1, s-1, hiddenColor, pixels, w, h)
+				// a lighter top and left edge make it look raised
+				light := font.Color{R: 200, G: 205, B: 215}
+				fillRect(px, py, s-1, 2, light, pixels, w, h)
+				fillRect(px, py, 2, s-1, light, pixels, w, h)
+			}
+			switch {
+			case c.Flagged:
+				v.drawFlag(px, py, pixels, w, h)
+			case c.Revealed && c.Mine:
+				r := s / 4
+				fillRect(px+s/2-r, py+s/2-r, 2*r, 2*r, mineColor, pixels, w, h)
+				fillRect(px+s/2-r-2, py+s/2-1, 2*r+4, 2, mineColor, pixels, w, h)
+				fillRect(px+s/2-1, py+s/2-r-2, 2, 2*r+4, mineColor, pixels, w, h)
+			case c.Revealed && c.Count > 0:
+				text := strconv.Itoa(int(c.Count))
+				scale := s / 12
+				if scale < 1 {
+					scale = 1
+				}
+				tw, th := font.Size(text, scale)
+				font.Draw(text, px+(s-1-tw)/2, py+(s-1-th)/2, scale, countColors[c.Count], pixels, w, h)
+			}
+		}
+	}
+}
+
+// drawFlag draws a flag on a pole in the cell at px, py
+func (v View) drawFlag(px, py int, pixels []byte, w, h int) {
+	s := v.CellSize
+	pole, top, height := px+s/2, py+s/5, s*3/5
+	fillRect(pole, top, 2, height, mineColor, pixels, w, h)
+	// the pennant narrows to a point a third of the cell left of the pole
+	size := s / 3
+	for i := 0; i < size; i++ {
+		half := size / 2 * (size - i) / size
+		fillRect(pole-1-i, top+size/2-half, 1, 2*half, flagColor, pixels, w, h)
+	}
+	fillRect(px+s/3, top+height-2, s/3+2, 2, mineColor, pixels, w, h)
+}
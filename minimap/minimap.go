@@ -0,0 +1,193 @@
+package minimap
+
+import (
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/namegen"
+)
+
+// TileType is what is on one tile of a map
+type TileType rune
+
+const (
+	Blank      TileType = 0
+	StoneWall  TileType = '#'
+	DirtFloor  TileType = '.'
+	ClosedDoor TileType = '|'
+	OpenDoor   TileType = '/'
+	Water      TileType = '~'
+	Torch      TileType = '*'
+)
+
+// Point is a tile position, fullMap[Y][X]
+type Point struct {
+	X, Y int
+}
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	fogColor    = color{0, 0, 0}
+	playerColor = color{255, 255, 255}
+	labelColor  = font.Color{R: 240, G: 220, B: 140}
+
+	tileColors = map[TileType]color{
+		StoneWall:  {110, 110, 120},
+		DirtFloor:  {70, 50, 35},
+		ClosedDoor: {160, 100, 40},
+		OpenDoor:   {200, 150, 80},
+		Water:      {40, 80, 180},
+		Torch:      {230, 160, 50},
+	}
+)
+
+// blinkFrames is how many renders the player dot stays on, then off
+const blinkFrames = 15
+
+// Room is a rectangle of tiles with a name, W*H tiles from X, Y
+type Room struct {
+	Name       string
+	X, Y, W, H int
+}
+
+// center is the tile in the middle of the room
+func (r Room) center() Point {
+	return Point{r.X + r.W/2, r.Y + r.H/2}
+}
+
+// NameRooms gives every room a name from names, room i the one for seed+i,
+// so a dungeon made from the same seed gets the same names
+func NameRooms(rooms []Room, names *namegen.NameGenerator, seed int64) {
+	for i := range rooms {
+		rooms[i].Name = names.Generate(seed + int64(i))
+	}
+}
+
+// MiniMap draws a map one pixel per tile into a corner of the frame. Tiles
+// the player has not seen yet stay dark.
+type MiniMap struct {
+	// Visible is flipped by Toggle, Render draws nothing while it is false
+	Visible bool
+	// Rooms are labeled with their names once the player has seen the
+	// middle of them
+	Rooms []Room
+
+	width, height int
+	visited       map[Point]bool
+	frame         int
+}
+
+// NewMiniMap returns a visible minimap for a width*height ABGR8888 frame
+func NewMiniMap(width, height int) *MiniMap {
+	return &MiniMap{Visible: true, width: width, height: height, visited: make(map[Point]bool)}
+}
+
+// Toggle shows or hides the minimap, games bind it to M
+func (m *MiniMap) Toggle() {
+	m.Visible = !m.Visible
+}
+
+// Visit marks every tile within radius of p as seen
+func (m *MiniMap) Visit(p Point, radius int) {
+	for y := p.Y - radius; y <= p.Y+radius; y++ {
+		for x := p.X - radius; x <= p.X+radius; x++ {
+			dx, dy := x-p.X, y-p.Y
+			if dx*dx+dy*dy <= radius*radius {
+				m.visited[Point{x, y}] = true
+			}
+		}
+	}
+}
+
+// Visited reports whether the tile at p has been seen
+func (m *MiniMap) Visited(p Point) bool {
+	return m.visited[p]
+}
+
+func (m *MiniMap) setPixel(x, y int, c color, pixels []byte) {
+	if x < 0 || x >= m.width || y < 0 || y >= m.height {
+		return
+	}
+	index := (y*m.width + x) * 4
+	pixels[index] = c.r
+	pixels[index+1] = c.g
+	pixels[index+2] = c.b
+}
+
+// origin is the tile shown in the top left pixel. A map larger than the
+// minimap scrolls to keep the player in the middle, stopping at its edges.
+func origin(player, size, mapSize int) int {
+	if mapSize <= size {
+		return 0
+	}
+	o := player - size/2
+	if o < 0 {
+		return 0
+	}
+	if o > mapSize-size {
+		return mapSize - size
+	}
+	return o
+}
+
+// Render draws fullMap into the mmW*mmH rectangle at mmX, mmY of pixels,
+// one pixel per tile, with the player as a blinking 2x2 dot. Pixels are
+// overwritten, not blended, and the whole rectangle is drawn so unseen
+// tiles and the area past the map edge cover what was there.
+func (m *MiniMap) Render(fullMap [][]TileType, playerPos Point, pixels []byte, mmX, mmY, mmW, mmH int) {
+	if !m.Visible {
+		return
+	}
+	m.frame++
+
+	mapH := len(fullMap)
+	mapW := 0
+	if mapH > 0 {
+		mapW = len(fullMap[0])
+	}
+	ox, oy := origin(playerPos.X, mmW, mapW), origin(playerPos.Y, mmH, mapH)
+
+	for y := 0; y < mmH; y++ {
+		ty := oy + y
+		for x := 0; x < mmW; x++ {
+			tx := ox + x
+			c := fogColor
+			if ty < mapH && tx < len(fullMap[ty]) && m.visited[Point{tx, ty}] {
+				c = tileColors[fullMap[ty][tx]]
+			}
+			m.setPixel(mmX+x, mmY+y, c, pixels)
+		}
+	}
+
+	m.drawLabels(ox, oy, mmX, mmY, mmW, mmH, pixels)
+
+	if (m.frame/blinkFrames)%2 == 1 {
+		return
+	}
+	px, py := playerPos.X-ox, playerPos.Y-oy
+	for y := py; y < py+2; y++ {
+		for x := px; x < px+2; x++ {
+			if x >= 0 && x < mmW && y >= 0 && y < mmH {
+				m.setPixel(mmX+x, mmY+y, playerColor, pixels)
+			}
+		}
+	}
+}
+
+// drawLabels writes the names of the rooms seen so far over their middle.
+// A name that would stick out of the minimap is left out rather than cut.
+func (m *MiniMap) drawLabels(ox, oy, mmX, mmY, mmW, mmH int, pixels []byte) {
+	for _, r := range m.Rooms {
+		c := r.center()
+		if r.Name == "" || !m.visited[c] {
+			continue
+		}
+		w, h := font.Size(r.Name, 1)
+		x, y := c.X-ox-w/2, c.Y-oy-h/2
+		if x < 0 || y < 0 || x+w > mmW || y+h > mmH {
+			continue
+		}
+		font.Draw(r.Name, mmX+x, mmY+y, 1, labelColor, pixels, m.width, m.height)
+	}
+}
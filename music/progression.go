@@ -0,0 +1,284 @@
+package music
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// NoteNames are the pitch classes 0 to 11 from C, sharps and flats as they
+// are usually spelled in chord names
+var NoteNames = [12]string{"C", "C#", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+
+// Scale is a diatonic mode, each a rotation of the major scale
+type Scale int
+
+const (
+	Major Scale = iota
+	Minor
+	Dorian
+	Phrygian
+	Lydian
+	Mixolydian
+	Locrian
+	NumScales
+)
+
+var scaleNames = [NumScales]string{"major", "minor", "dorian", "phrygian", "lydian", "mixolydian", "locrian"}
+
+// majorSteps are the semitones between the degrees of the major scale,
+// modeStart the degree of it each mode starts on
+var (
+	majorSteps = [7]int{2, 2, 1, 2, 2, 2, 1}
+	modeStart  = [NumScales]int{Major: 0, Minor: 5, Dorian: 1, Phrygian: 2, Lydian: 3, Mixolydian: 4, Locrian: 6}
+)
+
+func (s Scale) String() string {
+	if s < 0 || s >= NumScales {
+		return fmt.Sprintf("Scale(%d)", int(s))
+	}
+	return scaleNames[s]
+}
+
+// ParseScale is the scale named name, as String spells it
+func ParseScale(name string) (Scale, error) {
+	for i, n := range scaleNames {
+		if n == name {
+			return Scale(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown scale %q, expected one of %v", name, scaleNames)
+}
+
+// Intervals are the semitones above the tonic of each degree of s
+func (s Scale) Intervals() [7]int {
+	var iv [7]int
+	for d := 1; d < 7; d++ {
+		iv[d] = iv[d-1] + majorSteps[(modeStart[s]+d-1)%7]
+	}
+	return iv
+}
+
+// Quality is the kind of chord built on a root
+type Quality int
+
+const (
+	MajorTriad Quality = iota
+	MinorTriad
+	Diminished
+	Major7
+	Minor7
+	Dominant7
+	HalfDiminished7
+	numQualities
+)
+
+var (
+	qualitySuffixes = [numQualities]string{"maj", "m", "dim", "maj7", "m7", "7", "m7b5"}
+	// qualityIntervals are the semitones above the root of each note
+	qualityIntervals = [numQualities][]int{
+		{0, 4, 7},
+		{0, 3, 7},
+		{0, 3, 6},
+		{0, 4, 7, 11},
+		{0, 3, 7, 10},
+		{0, 4, 7, 10},
+		{0, 3, 6, 10},
+	}
+)
+
+// Intervals are the semitones above the root of the notes of q
+func (q Quality) Intervals() []int {
+	return qualityIntervals[q]
+}
+
+// ChordVoicing is a chord and the notes it is played with. Root is a pitch
+// class, 0 to 11 from C, and Degree is the degree of the scale it is
+// built on, 0 for the tonic. Notes are MIDI note numbers from the bass up.
+type ChordVoicing struct {
+	Root    int
+	Quality Quality
+	Degree  int
+	Notes   []int
+}
+
+// Name is how the chord is written, "Dm7" or "Gmaj"
+func (v ChordVoicing) Name() string {
+	return NoteNames[v.Root] + qualitySuffixes[v.Quality]
+}
+
+// diatonicQuality is the chord stacked in thirds from degree d of s, with
+// its seventh if seventh is set
+func diatonicQuality(s Scale, d int, seventh bool) Quality {
+	iv := s.Intervals()
+	above := func(n int) int {
+		return ((iv[(d+n)%7]-iv[d])%12 + 12) % 12
+	}
+	third, fifth, sev := above(2), above(4), above(6)
+	switch {
+	case third == 4 && !seventh:
+		return MajorTriad
+	case third == 4 && sev == 11:
+		return Major7
+	case third == 4:
+		return Dominant7
+	case fifth == 6 && !seventh:
+		return Diminished
+	case fifth == 6:
+		return HalfDiminished7
+	case !seventh:
+		return MinorTriad
+	}
+	return Minor7
+}
+
+// transitions weights which degree follows which, rows from and columns to,
+// I ii iii IV V vi vii in major and the same degrees of the other modes.
+// They are rounded from root motion counts in corpora of common practice
+// and popular music: a fall of a fifth round the circle of fifths (ii-V,
+// V-I, vi-ii, iii-vi) is by far the likeliest move, then steps to IV and V
+// and the deceptive V-vi.
+var transitions = [7][7]float64{
+	{0, 10, 5, 30, 30, 20, 5},
+	{5, 0, 2, 8, 70, 5, 10},
+	{5, 5, 0, 20, 5, 60, 5},
+	{30, 10, 2, 0, 45, 8, 5},
+	{65, 3, 2, 8, 0, 20, 2},
+	{8, 40, 5, 30, 15, 0, 2},
+	{80, 0, 10, 0, 5, 5, 0},
+}
+
+const (
+	// bassNote is the lowest the bass goes, the root is put in the octave
+	// from it
+	bassNote = 36
+	// the upper voices stay between voiceLow and voiceHigh
+	voiceLow, voiceHigh = 52, 76
+	// startNote is where the first chord's upper voices start from
+	startNote = 60
+)
+
+// ChordProgressionGenerator makes an endless progression in Root's Scale.
+// It starts on the tonic and picks every next degree with the weights of
+// transitions. The upper voices move to the nearest notes of the next
+// chord, the bass plays its root.
+type ChordProgressionGenerator struct {
+	Root  int
+	Scale Scale
+	// SeventhChance is the chance of a chord getting its diatonic seventh
+	SeventhChance float64
+
+	rng     *rand.Rand
+	degree  int
+	started bool
+	upper   []int
+}
+
+// NewChordProgressionGenerator starts a progression in root's scale, the
+// same seed always makes the same one
+func NewChordProgressionGenerator(root int, scale Scale, seed int64) *ChordProgressionGenerator {
+	return &ChordProgressionGenerator{
+		Root:          ((root % 12) + 12) % 12,
+		Scale:         scale,
+		SeventhChance: 0.35,
+		rng:           rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Next is the next chord of the progression
+func (g *ChordProgressionGenerator) Next() ChordVoicing {
+	if g.started {
+		g.degree = g.nextDegree()
+	}
+	g.started = true
+
+	q := diatonicQuality(g.Scale, g.degree, g.rng.Float64() < g.SeventhChance)
+	root := (g.Root + g.Scale.Intervals()[g.degree]) % 12
+	var classes []int
+	for _, i := range q.Intervals() {
+		classes = append(classes, (root+i)%12)
+	}
+	g.upper = voiceLead(g.upper, classes)
+	notes := append([]int{bassNote + root}, g.upper...)
+	return ChordVoicing{Root: root, Quality: q, Degree: g.degree, Notes: notes}
+}
+
+func (g *ChordProgressionGenerator) nextDegree() int {
+	row := transitions[g.degree]
+	total := 0.0
+	for _, w := range row {
+		total += w
+	}
+	r := g.rng.Float64() * total
+	for d, w := range row {
+		r -= w
+		if r < 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// voiceLead places the pitch classes as close to prev as it can, every
+// inversion of them in every octave that keeps them between voiceLow and
+// voiceHigh is tried and the one moving least from prev kept. Without prev
+// they are placed from startNote up.
+func voiceLead(prev, classes []int) []int {
+	if len(prev) == 0 {
+		prev = []int{startNote}
+	}
+	var best []int
+	bestMove := -1
+	for inv := range classes {
+		for base := voiceLow; base <= voiceHigh; base++ {
+			if base%12 != classes[inv] {
+				continue
+			}
+			notes := []int{base}
+			for k := 1; k < len(classes); k++ {
+				c := classes[(inv+k)%len(classes)]
+				n := notes[k-1] + 1
+				for n%12 != c {
+					n++
+				}
+				notes = append(notes, n)
+			}
+			if notes[len(notes)-1] > voiceHigh {
+				continue
+			}
+			if m := movement(prev, notes); bestMove < 0 || m < bestMove {
+				best, bestMove = notes, m
+			}
+		}
+	}
+	return best
+}
+
+// movement is how far voices have to move from prev to notes, each note
+// counted from the nearest note of the other chord so chords of three and
+// four notes compare
+func movement(prev, notes []int) int {
+	nearest := func(n int, in []int) int {
+		d := -1
+		for _, m := range in {
+			if a := abs(n - m); d < 0 || a < d {
+				d = a
+			}
+		}
+		return d
+	}
+	total := 0
+	for _, n := range notes {
+		total += nearest(n, prev)
+	}
+	for _, n := range prev {
+		total += nearest(n, notes)
+	}
+	return total
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,148 @@
+package music
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScaleIntervals(t *testing.T) {
+	tests := []struct {
+		s    Scale
+		want [7]int
+	}{
+		{Major, [7]int{0, 2, 4, 5, 7, 9, 11}},
+		{Minor, [7]int{0, 2, 3, 5, 7, 8, 10}},
+		{Dorian, [7]int{0, 2, 3, 5, 7, 9, 10}},
+		{Mixolydian, [7]int{0, 2, 4, 5, 7, 9, 10}},
+		{Locrian, [7]int{0, 1, 3, 5, 6, 8, 10}},
+	}
+	for _, tt := range tests {
+		if got := tt.s.Intervals(); got != tt.want {
+			t.Errorf("%v intervals %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDiatonicChords(t *testing.T) {
+	names := func(s Scale, root int, seventh bool) []string {
+		var out []string
+		iv := s.Intervals()
+		for d := 0; d < 7; d++ {
+			v := ChordVoicing{Root: (root + iv[d]) % 12, Quality: diatonicQuality(s, d, seventh)}
+			out = append(out, v.Name())
+		}
+		return out
+	}
+	if got, want := names(Major, 0, false), []string{"Cmaj", "Dm", "Em", "Fmaj", "Gmaj", "Am", "Bdim"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("C major triads %v, want %v", got, want)
+	}
+	if got, want := names(Major, 0, true), []string{"Cmaj7", "Dm7", "Em7", "Fmaj7", "G7", "Am7", "Bm7b5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("C major sevenths %v, want %v", got, want)
+	}
+	if got, want := names(Minor, 9, false), []string{"Am", "Bdim", "Cmaj", "Dm", "Em", "Fmaj", "Gmaj"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("A minor triads %v, want %v", got, want)
+	}
+	if got, want := names(Dorian, 2, true), []string{"Dm7", "Em7", "Fmaj7", "G7", "Am7", "Bm7b5", "Cmaj7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("D dorian sevenths %v, want %v", got, want)
+	}
+}
+
+func TestParseScale(t *testing.T) {
+	for s := Scale(0); s < NumScales; s++ {
+		got, err := ParseScale(s.String())
+		if err != nil || got != s {
+			t.Errorf("ParseScale(%q) = %v, %v", s.String(), got, err)
+		}
+	}
+	if _, err := ParseScale("blues"); err == nil {
+		t.Error("an unknown scale parsed")
+	}
+}
+
+func TestProgression(t *testing.T) {
+	g := NewChordProgressionGenerator(7, Major, 1)
+	first := g.Next()
+	if first.Degree != 0 || first.Root != 7 {
+		t.Errorf("started on %s, degree %d", first.Name(), first.Degree)
+	}
+	iv := Major.Intervals()
+	prev := first
+	moves := map[[2]int]int{}
+	for i := 0; i < 5000; i++ {
+		v := g.Next()
+		if v.Root != (7+iv[v.Degree])%12 {
+			t.Fatalf("%s is not degree %d of G major", v.Name(), v.Degree)
+		}
+		if transitions[prev.Degree][v.Degree] == 0 {
+			t.Fatalf("moved from degree %d to %d, which has no weight", prev.Degree, v.Degree)
+		}
+		// the bass plays the root, the voices are the chord's notes
+		if v.Notes[0]%12 != v.Root {
+			t.Fatalf("%s has %d in the bass", v.Name(), v.Notes[0])
+		}
+		want := map[int]bool{}
+		for _, i := range v.Quality.Intervals() {
+			want[(v.Root+i)%12] = true
+		}
+		for _, n := range v.Notes[1:] {
+			if !want[n%12] || n < voiceLow || n > voiceHigh {
+				t.Fatalf("%s has voice %d", v.Name(), n)
+			}
+		}
+		moves[[2]int{prev.Degree, v.Degree}]++
+		prev = v
+	}
+	// V goes home to I far more often than anywhere else
+	if moves[[2]int{4, 0}] < 3*moves[[2]int{4, 5}] {
+		t.Errorf("V-I %d times against V-vi %d", moves[[2]int{4, 0}], moves[[2]int{4, 5}])
+	}
+
+	// the same seed makes the same progression
+	a, b := NewChordProgressionGenerator(2, Dorian, 9), NewChordProgressionGenerator(2, Dorian, 9)
+	for i := 0; i < 50; i++ {
+		if va, vb := a.Next(), b.Next(); !reflect.DeepEqual(va, vb) {
+			t.Fatalf("chord %d is %v and %v", i, va, vb)
+		}
+	}
+}
+
+func TestVoiceLeading(t *testing.T) {
+	// C E G to G B D: the nearest voicing keeps G and moves the others by
+	// a step or two, B D G
+	got := voiceLead([]int{60, 64, 67}, []int{7, 11, 2})
+	if want := []int{59, 62, 67}; !reflect.DeepEqual(got, want) {
+		t.Errorf("C to G voiced %v, want %v", got, want)
+	}
+	// no voice jumps far between chords of the generator
+	g := NewChordProgressionGenerator(0, Major, 3)
+	prev := g.Next().Notes[1:]
+	for i := 0; i < 500; i++ {
+		notes := g.Next().Notes[1:]
+		if m := movement(prev, notes); m > 12 {
+			t.Fatalf("%v to %v moves %d semitones", prev, notes, m)
+		}
+		prev = notes
+	}
+}
+
+func TestRender(t *testing.T) {
+	v := NewChordProgressionGenerator(0, Major, 1).Next()
+	samples := Render(v, 8000, 1)
+	if len(samples) != 8000 {
+		t.Fatalf("%d samples for a second at 8000", len(samples))
+	}
+	loudest := float32(0)
+	for _, s := range samples {
+		if s > loudest {
+			loudest = s
+		} else if -s > loudest {
+			loudest = -s
+		}
+	}
+	if loudest > 1 || loudest < 0.1 {
+		t.Errorf("peak %v", loudest)
+	}
+	if end := samples[len(samples)-1]; end > 0.01 || end < -0.01 {
+		t.Errorf("does not fade out, ends at %v", end)
+	}
+}
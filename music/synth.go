@@ -0,0 +1,64 @@
+package music
+
+import "math"
+
+const (
+	// every note is its fundamental and two overtones, weaker the higher
+	// they are, for something warmer than a bare sine
+	overtone2, overtone3 = 0.4, 0.15
+	// the envelope rises over attack seconds, falls to sustain of the peak
+	// over decay and fades out over the last release seconds
+	attack, decay, release = 0.01, 0.3, 0.4
+	sustain                = 0.6
+	// bassGain keeps the bass from drowning the voices above it
+	bassGain = 0.7
+)
+
+// Frequency is the frequency of MIDI note n in Hz, A4 (69) being 440
+func Frequency(n int) float64 {
+	return 440 * math.Pow(2, float64(n-69)/12)
+}
+
+// envelope is the loudness at t seconds into a sound lasting length
+func envelope(t, length float64) float64 {
+	var e float64
+	switch {
+	case t < attack:
+		e = t / attack
+	case t < attack+decay:
+		e = 1 - (1-sustain)*(t-attack)/decay
+	default:
+		e = sustain
+	}
+	if left := length - t; left < release {
+		e *= left / release
+	}
+	return e
+}
+
+// Render synthesizes v for seconds at sampleRate samples a second, one
+// channel between -1 and 1. The first note is the bass.
+func Render(v ChordVoicing, sampleRate int, seconds float64) []float32 {
+	samples := make([]float32, int(seconds*float64(sampleRate)))
+	if len(v.Notes) == 0 {
+		return samples
+	}
+	// the peak of every note together stays below 1
+	peak := (1 + overtone2 + overtone3) * (bassGain + float64(len(v.Notes)-1))
+	for i, n := range v.Notes {
+		gain := 1 / peak
+		if i == 0 {
+			gain *= bassGain
+		}
+		w := 2 * math.Pi * Frequency(n) / float64(sampleRate)
+		for s := range samples {
+			x := w * float64(s)
+			tone := math.Sin(x) + overtone2*math.Sin(2*x) + overtone3*math.Sin(3*x)
+			samples[s] += float32(gain * tone)
+		}
+	}
+	for s := range samples {
+		samples[s] *= float32(envelope(float64(s)/float64(sampleRate), seconds))
+	}
+	return samples
+}
@@ -0,0 +1,112 @@
+package namegen
+
+import (
+	_ "embed"
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MinLength and MaxLength bound the length of a generated name in
+	// letters
+	MinLength, MaxLength = 5, 12
+	// maxAttempts is how many names Generate draws before it settles for
+	// one that is also in the corpus
+	maxAttempts = 100
+	// start pads the beginning of every name, so the first two letters
+	// have a state to be drawn from, and end follows the last letter
+	start, end = '^', '$'
+)
+
+// places is the training corpus, one fantasy place name per line
+//
+//go:embed places.txt
+var places string
+
+// NameGenerator makes up place names with an order two Markov chain: every
+// letter is drawn from the letters that followed the two before it
+// somewhere in the corpus, as often as they did. The zero value uses the
+// chain built from places.txt.
+type NameGenerator struct {
+	table  map[string][]rune
+	corpus map[string]bool
+}
+
+var defaultChain NameGenerator
+
+func init() {
+	defaultChain = train(strings.Fields(places))
+}
+
+// train builds the chain of names. A letter is listed once for every time
+// it follows a pair, so drawing uniformly from the list draws it by its
+// frequency.
+func train(names []string) NameGenerator {
+	g := NameGenerator{table: make(map[string][]rune), corpus: make(map[string]bool)}
+	for _, name := range names {
+		name = strings.ToLower(name)
+		g.corpus[name] = true
+		prev := []rune{start, start}
+		for _, r := range name + string(end) {
+			key := string(prev)
+			g.table[key] = append(g.table[key], r)
+			prev[0], prev[1] = prev[1], r
+		}
+	}
+	return g
+}
+
+// Generate returns the name for seed, MinLength to MaxLength letters with
+// the first one capitalized. The same seed always gives the same name.
+// Names that are in the corpus are passed over while there are others to
+// be had.
+func (g *NameGenerator) Generate(seed int64) string {
+	chain := g
+	if chain.table == nil {
+		chain = &defaultChain
+	}
+	rng := rand.New(rand.NewSource(seed))
+	fallback := ""
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		name, ok := chain.draw(rng)
+		if !ok {
+			continue
+		}
+		if !chain.corpus[name] {
+			return capitalize(name)
+		}
+		if fallback == "" {
+			fallback = name
+		}
+	}
+	return capitalize(fallback)
+}
+
+// draw walks the chain once. It fails if the word ends before MinLength
+// letters or has not ended by MaxLength.
+func (g *NameGenerator) draw(rng *rand.Rand) (string, bool) {
+	var name []rune
+	prev := []rune{start, start}
+	for len(name) <= MaxLength {
+		next := g.table[string(prev)]
+		if len(next) == 0 {
+			return "", false
+		}
+		r := next[rng.Intn(len(next))]
+		if r == end {
+			return string(name), len(name) >= MinLength
+		}
+		name = append(name, r)
+		prev[0], prev[1] = prev[1], r
+	}
+	return "", false
+}
+
+func capitalize(name string) string {
+	r := []rune(name)
+	if len(r) > 0 {
+		r[0] = unicode.ToUpper(r[0])
+	}
+	return string(r)
+}
@@ -0,0 +1,268 @@
+package noise
+
+import (
+	"fmt"
+	"math"
+)
+
+// FractalConfig is a fractal sum over a base Source, made by NewFractal.
+// Its octaves are worked out once when it is made, so the sums only loop
+// over them.
+type FractalConfig struct {
+	base       Source
+	frequency  float32
+	lacunarity float32
+	gain       float32
+	octaves    int
+	rotation   float32
+	weights    []float32
+
+	// octavesSet and gainSet tell the options that conflict with
+	// WithWeights whether they were given
+	octavesSet, gainSet bool
+
+	layers []octave
+	total  float32
+}
+
+// octave is where one octave samples the base and how much it weighs. Its
+// coordinates are turned by cos, sin before they are scaled by frequency.
+type octave struct {
+	frequency, amplitude float32
+	cos, sin             float32
+	rotated              bool
+}
+
+// Option changes one setting of a FractalConfig, it fails on a value that
+// makes no sense
+type Option func(*FractalConfig) error
+
+// finite reports whether v is neither infinite nor NaN
+func finite(v float32) bool {
+	return !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
+}
+
+// WithFrequency sets the frequency of the first octave, 1 by default
+func WithFrequency(frequency float32) Option {
+	return func(c *FractalConfig) error {
+		if !(frequency > 0) || !finite(frequency) {
+			return fmt.Errorf("frequency must be positive, got %v", frequency)
+		}
+		c.frequency = frequency
+		return nil
+	}
+}
+
+// WithLacunarity sets how many times finer every octave is than the one
+// before, 2 by default
+func WithLacunarity(lacunarity float32) Option {
+	return func(c *FractalConfig) error {
+		if !(lacunarity > 0) || !finite(lacunarity) {
+			return fmt.Errorf("lacunarity must be positive, got %v", lacunarity)
+		}
+		c.lacunarity = lacunarity
+		return nil
+	}
+}
+
+// WithGain sets how many times less every octave weighs than the one
+// before, 0.5 by default
+func WithGain(gain float32) Option {
+	return func(c *FractalConfig) error {
+		if !(gain >= 0) || !finite(gain) {
+			return fmt.Errorf("gain must not be negative, got %v", gain)
+		}
+		c.gain = gain
+		c.gainSet = true
+		return nil
+	}
+}
+
+// WithOctaves sets how many octaves are summed, 4 by default
+func WithOctaves(octaves int) Option {
+	return func(c *FractalConfig) error {
+		if octaves < 1 {
+			return fmt.Errorf("octaves must be at least 1, got %d", octaves)
+		}
+		c.octaves = octaves
+		c.octavesSet = true
+		return nil
+	}
+}
+
+// WithRotation turns every octave by angle radians more than the one
+// before, so the lattices of the octaves do not line up. 0 by default.
+func WithRotation(angle float32) Option {
+	return func(c *FractalConfig) error {
+		if !finite(angle) {
+			return fmt.Errorf("rotation must be finite, got %v", angle)
+		}
+		c.rotation = angle
+		return nil
+	}
+}
+
+// WithWeights gives every octave its own weight instead of stepping it by
+// the gain, there are as many octaves as weights. It cannot be combined
+// with WithGain, or with WithOctaves for a different number of octaves.
+func WithWeights(weights ...float32) Option {
+	return func(c *FractalConfig) error {
+		if len(weights) == 0 {
+			return fmt.Errorf("weights needs at least one weight")
+		}
+		for i, w := range weights {
+			if !(w >= 0) || !finite(w) {
+				return fmt.Errorf("weight %d must not be negative, got %v", i, w)
+			}
+		}
+		c.weights = append([]float32(nil), weights...)
+		return nil
+	}
+}
+
+// NewFractal configures a fractal over base. Options are applied in order
+// and the first one that fails is returned as the error.
+func NewFractal(base Source, opts ...Option) (*FractalConfig, error) {
+	if base == nil {
+		return nil, fmt.Errorf("fractal needs a base source")
+	}
+	c := &FractalConfig{base: base, frequency: 1, lacunarity: 2, gain: 0.5, octaves: 4}
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.weights != nil {
+		if c.gainSet {
+			return nil, fmt.Errorf("weights and gain can not both be given")
+		}
+		if c.octavesSet && c.octaves != len(c.weights) {
+			return nil, fmt.Errorf("%d octaves given with %d weights", c.octaves, len(c.weights))
+		}
+		c.octaves = len(c.weights)
+	}
+	c.layout()
+	return c, nil
+}
+
+// layout works out the octaves. Frequencies and amplitudes are stepped by
+// repeated multiplication, exactly as the functions taking a frequency,
+// lacunarity and gain do, so both give the same sums.
+func (c *FractalConfig) layout() {
+	c.layers = make([]octave, c.octaves)
+	c.total = 0
+	frequency, amplitude := c.frequency, float32(1.0)
+	for i := range c.layers {
+		o := octave{frequency: frequency, amplitude: amplitude, cos: 1}
+		if c.weights != nil {
+			o.amplitude = c.weights[i]
+		}
+		if angle := float64(c.rotation) * float64(i); angle != 0 {
+			o.cos, o.sin = float32(math.Cos(angle)), float32(math.Sin(angle))
+			o.rotated = true
+		}
+		c.layers[i] = o
+		c.total += o.amplitude
+		frequency *= c.lacunarity
+		amplitude *= c.gain
+	}
+}
+
+// sample is the base at x, y as octave o sees it
+func (c *FractalConfig) sample(o *octave, x, y float32) float32 {
+	if o.rotated {
+		x, y = x*o.cos-y*o.sin, x*o.sin+y*o.cos
+	}
+	return c.base.At(x*o.frequency, y*o.frequency)
+}
+
+// Amplitude is the sum of the octaves' weights, the most Fbm can reach
+// with a base within -1..1
+func (c *FractalConfig) Amplitude() float32 {
+	return c.total
+}
+
+// Fbm sums the octaves as they are, fractal brownian motion
+func (c *FractalConfig) Fbm() Source {
+	return fbm{c}
+}
+
+// Turbulence sums the absolute value of the octaves, which folds every one
+// at zero into sharp creases
+func (c *FractalConfig) Turbulence() Source {
+	return turbulence{c}
+}
+
+// Ridged sums (1-|octave|)^2, sharp crests where the base crosses zero
+func (c *FractalConfig) Ridged() Source {
+	return ridged{c}
+}
+
+// Billow is |Fbm| divided by the total weight, stretched back to -1..1
+func (c *FractalConfig) Billow() Source {
+	return billow{c}
+}
+
+type fbm struct {
+	*FractalConfig
+}
+
+func (s fbm) At(x, y float32) float32 {
+	var sum float32
+	for i := range s.layers {
+		o := &s.layers[i]
+		sum += s.sample(o, x, y) * o.amplitude
+	}
+	return sum
+}
+
+type turbulence struct {
+	*FractalConfig
+}
+
+func (s turbulence) At(x, y float32) float32 {
+	var sum float32
+	for i := range s.layers {
+		o := &s.layers[i]
+		f := s.sample(o, x, y)
+		if f < 0 {
+			f = -f
+		}
+		sum += f * o.amplitude
+	}
+	return sum
+}
+
+type ridged struct {
+	*FractalConfig
+}
+
+func (s ridged) At(x, y float32) float32 {
+	var sum float32
+	for i := range s.layers {
+		o := &s.layers[i]
+		f := s.sample(o, x, y)
+		if f < 0 {
+			f = -f
+		}
+		f = 1 - f
+		sum += f * f * o.amplitude
+	}
+	return sum
+}
+
+type billow struct {
+	*FractalConfig
+}
+
+func (s billow) At(x, y float32) float32 {
+	if s.total == 0 {
+		return -1
+	}
+	f := fbm{s.FractalConfig}.At(x, y) / s.total
+	if f < 0 {
+		f = -f
+	}
+	return f*2 - 1
+}
@@ -0,0 +1,163 @@
+package noise
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNewFractalDefaults(t *testing.T) {
+	c, err := NewFractal(Simplex{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.frequency != 1 || c.lacunarity != 2 || c.gain != 0.5 || c.octaves != 4 || c.rotation != 0 || c.weights != nil {
+		t.Errorf("defaults are %+v", c)
+	}
+	if c.Amplitude() != 1.875 {
+		t.Errorf("Amplitude is %v, want 1.875", c.Amplitude())
+	}
+	old := Fractal{Frequency: 1, Lacunarity: 2, Gain: 0.5, Octaves: 4}.Fbm(Simplex{})
+	fbm := c.Fbm()
+	sampleGrid(func(x, y float32) {
+		if got, want := fbm.At(x, y), old.At(x, y); got != want {
+			t.Fatalf("default Fbm at %v, %v is %v, Fractal.Fbm gives %v", x, y, got, want)
+		}
+	})
+}
+
+func TestNewFractalOptions(t *testing.T) {
+	nan, inf := float32(math.NaN()), float32(math.Inf(1))
+	tests := []struct {
+		name string
+		opts []Option
+		// err is a piece of the expected error, empty if there should be
+		// none
+		err string
+		// octaves and total are checked when there is no error
+		octaves int
+		total   float32
+	}{
+		{"no options", nil, "", 4, 1.875},
+		{"one octave", []Option{WithOctaves(1)}, "", 1, 1},
+		{"zero gain", []Option{WithGain(0)}, "", 4, 1},
+		{"last one wins", []Option{WithOctaves(2), WithOctaves(3)}, "", 3, 1.75},
+		{"frequency", []Option{WithFrequency(0.01)}, "", 4, 1.875},
+		{"lacunarity", []Option{WithLacunarity(3)}, "", 4, 1.875},
+		{"negative rotation", []Option{WithRotation(-0.5)}, "", 4, 1.875},
+		{"weights", []Option{WithWeights(1, 0.25)}, "", 2, 1.25},
+		{"weights and matching octaves", []Option{WithOctaves(2), WithWeights(0.5, 0.5)}, "", 2, 1},
+		{"zero weight", []Option{WithWeights(0, 1)}, "", 2, 1},
+
+		{"zero octaves", []Option{WithOctaves(0)}, "octaves", 0, 0},
+		{"negative octaves", []Option{WithOctaves(-3)}, "octaves", 0, 0},
+		{"zero lacunarity", []Option{WithLacunarity(0)}, "lacunarity", 0, 0},
+		{"negative lacunarity", []Option{WithLacunarity(-2)}, "lacunarity", 0, 0},
+		{"nan lacunarity", []Option{WithLacunarity(nan)}, "lacunarity", 0, 0},
+		{"zero frequency", []Option{WithFrequency(0)}, "frequency", 0, 0},
+		{"infinite frequency", []Option{WithFrequency(inf)}, "frequency", 0, 0},
+		{"negative gain", []Option{WithGain(-0.1)}, "gain", 0, 0},
+		{"nan gain", []Option{WithGain(nan)}, "gain", 0, 0},
+		{"infinite rotation", []Option{WithRotation(inf)}, "rotation", 0, 0},
+		{"no weights", []Option{WithWeights()}, "weight", 0, 0},
+		{"negative weight", []Option{WithWeights(1, -1)}, "weight 1", 0, 0},
+		{"weights and gain", []Option{WithGain(0.5), WithWeights(1, 1)}, "gain", 0, 0},
+		{"weights and other octaves", []Option{WithWeights(1, 1), WithOctaves(3)}, "3 octaves given with 2 weights", 0, 0},
+		{"first failure is reported", []Option{WithOctaves(0), WithGain(-1)}, "octaves", 0, 0},
+	}
+	for _, tt := range tests {
+		c, err := NewFractal(Simplex{}, tt.opts...)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error is %v, want one about %q", tt.name, err, tt.err)
+			}
+			if c != nil {
+				t.Errorf("%s: a config came back with the error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(c.layers) != tt.octaves || c.Amplitude() != tt.total {
+			t.Errorf("%s: %d octaves weighing %v, want %d weighing %v", tt.name, len(c.layers), c.Amplitude(), tt.octaves, tt.total)
+		}
+	}
+}
+
+func TestNewFractalNeedsBase(t *testing.T) {
+	if _, err := NewFractal(nil); err == nil {
+		t.Error("a nil base is accepted")
+	}
+}
+
+func TestWeightsMatchGain(t *testing.T) {
+	// powers of a half are exact, so the weights are the same numbers the
+	// gain steps through
+	byGain, err := NewFractal(Perlin{}, WithGain(0.5), WithOctaves(3), WithFrequency(0.2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byWeights, err := NewFractal(Perlin{}, WithWeights(1, 0.5, 0.25), WithFrequency(0.2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := byGain.Turbulence(), byWeights.Turbulence()
+	sampleGrid(func(x, y float32) {
+		if a.At(x, y) != b.At(x, y) {
+			t.Fatalf("at %v, %v gain gives %v and weights %v", x, y, a.At(x, y), b.At(x, y))
+		}
+	})
+}
+
+func TestRotation(t *testing.T) {
+	plain, _ := NewFractal(Simplex{}, WithFrequency(0.2))
+	turned, _ := NewFractal(Simplex{}, WithFrequency(0.2), WithRotation(1))
+	// the first octave is never turned, so one octave ignores the rotation
+	single, _ := NewFractal(Simplex{}, WithFrequency(0.2), WithOctaves(1), WithRotation(1))
+	first, _ := NewFractal(Simplex{}, WithFrequency(0.2), WithOctaves(1))
+
+	differ := 0
+	sampleGrid(func(x, y float32) {
+		v := turned.Fbm().At(x, y)
+		if v < -turned.Amplitude() || v > turned.Amplitude() {
+			t.Fatalf("rotated Fbm at %v, %v is %v, outside the amplitude %v", x, y, v, turned.Amplitude())
+		}
+		if v != plain.Fbm().At(x, y) {
+			differ++
+		}
+		if single.Fbm().At(x, y) != first.Fbm().At(x, y) {
+			t.Fatalf("a single octave changes with the rotation at %v, %v", x, y)
+		}
+	})
+	if differ < 400*400/2 {
+		t.Errorf("rotating only changes %d of %d points", differ, 400*400)
+	}
+}
+
+func TestFractalConfigSums(t *testing.T) {
+	c, err := NewFractal(Value{}, WithFrequency(0.3), WithRotation(0.7), WithWeights(1, 0.6, 0.3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := c.Amplitude()
+	tests := []struct {
+		name     string
+		src      Source
+		min, max float32
+	}{
+		{"fbm", c.Fbm(), -total, total},
+		{"turbulence", c.Turbulence(), 0, total},
+		{"ridged", c.Ridged(), 0, total},
+		{"billow", c.Billow(), -1, 1},
+	}
+	for _, tt := range tests {
+		sampleGrid(func(x, y float32) {
+			v := tt.src.At(x, y)
+			if v < tt.min || v > tt.max || math.IsNaN(float64(v)) {
+				t.Fatalf("%s at %v, %v is %v, outside %v..%v", tt.name, x, y, v, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package noise
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// headlessPackages are the packages that must build without SDL or cgo, so
+// they cross compile and build where there are no SDL headers. The
+// windowing code lives in gfx and the programs, none of these may import
+// it.
+var headlessPackages = []string{".", "../export"}
+
+// goTool is the go command, the test is skipped without one
+func goTool(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+	path, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command: ", err)
+	}
+	return path
+}
+
+func TestHeadlessDependencies(t *testing.T) {
+	args := append([]string{"list", "-deps", "-f", "{{.ImportPath}}{{if .CgoFiles}} uses cgo{{end}}"}, headlessPackages...)
+	out, err := exec.Command(goTool(t), args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go list: %v\n%s", err, out)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if strings.Contains(line, "go-sdl2") || strings.HasSuffix(line, " uses cgo") || line == "runtime/cgo" {
+			t.Errorf("%v depend on %s", headlessPackages, line)
+		}
+	}
+}
+
+func TestHeadlessBuildWithoutCgo(t *testing.T) {
+	cmd := exec.Command(goTool(t), append([]string{"build"}, headlessPackages...)...)
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v do not build with CGO_ENABLED=0: %v\n%s", headlessPackages, err, out)
+	}
+}
@@ -0,0 +1,40 @@
+package noise_test
+
+import (
+	"fmt"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+func Example_fbm2() {
+	f := noise.Fractal{Frequency: 0.01, Lacunarity: 2, Gain: 0.5, Octaves: 4}
+	for x := 0; x < 3; x++ {
+		v := noise.Fbm2(float32(x*40), 25, f.Frequency, f.Lacunarity, f.Gain, f.Octaves)
+		fmt.Printf("%.4f\n", v*40)
+	}
+
+	// a seeded permutation gives its own noise, the same for every run
+	perm := noise.NewPerm(42)
+	fmt.Printf("%.4f\n", perm.Fbm2(40, 25, f.Frequency, f.Lacunarity, f.Gain, f.Octaves)*40)
+	// Output:
+	// 0.4833
+	// -0.4905
+	// 0.1528
+	// -0.1523
+}
+
+func ExampleNewFractal() {
+	f, err := noise.NewFractal(noise.Perlin{}, noise.WithFrequency(0.02), noise.WithOctaves(5), noise.WithRotation(0.5))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ridged := f.Ridged()
+	fmt.Printf("%.4f of %.4f\n", ridged.At(10, 20), f.Amplitude())
+
+	_, err = noise.NewFractal(noise.Perlin{}, noise.WithLacunarity(0))
+	fmt.Println(err)
+	// Output:
+	// 1.1666 of 1.9375
+	// lacunarity must be positive, got 0
+}
@@ -0,0 +1,161 @@
+package noise
+
+import (
+	"fmt"
+	"math"
+)
+
+// Fractal is the set of options every fractal sum here takes. The first
+// octave samples the noise at Frequency and weighs 1, every next one is
+// sampled Lacunarity times finer and weighs Gain times less.
+type Fractal struct {
+	Frequency  float32 `json:"frequency"`
+	Lacunarity float32 `json:"lacunarity"`
+	Gain       float32 `json:"gain"`
+	Octaves    int     `json:"octaves"`
+}
+
+// Validate rejects options that give an empty or degenerate sum
+func (f Fractal) Validate() error {
+	if f.Frequency <= 0 {
+		return fmt.Errorf("frequency must be positive, got %v", f.Frequency)
+	}
+	if f.Lacunarity <= 0 {
+		return fmt.Errorf("lacunarity must be positive, got %v", f.Lacunarity)
+	}
+	if f.Gain < 0 {
+		return fmt.Errorf("gain must not be negative, got %v", f.Gain)
+	}
+	if f.Octaves < 1 {
+		return fmt.Errorf("octaves must be at least 1, got %d", f.Octaves)
+	}
+	return nil
+}
+
+// Turbulence sums the absolute value of octaves of Snoise2, which folds
+// every octave at zero into the sharp creases turbulence is known for
+func (s *Perm) Turbulence(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	var sum float32
+	amplitude := float32(1.0)
+	for i := 0; i < octaves; i++ {
+		f := s.Snoise2(x*frequency, y*frequency) * amplitude
+		if f < 0 {
+			f = -1.0 * f
+		}
+		sum += f
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return sum
+}
+
+// TurbulenceWide is Turbulence for float64 coordinates, the octaves are
+// stepped and weighted the same way
+func (s *Perm) TurbulenceWide(x, y float64, frequency, lacunarity, gain float32, octaves int) float32 {
+	var sum float32
+	amplitude := float32(1.0)
+	for i := 0; i < octaves; i++ {
+		f := s.Snoise2Wide(x*float64(frequency), y*float64(frequency)) * amplitude
+		if f < 0 {
+			f = -1.0 * f
+		}
+		sum += f
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return sum
+}
+
+// Fbm2 sums octaves of Snoise2 as they are, fractal brownian motion
+func (s *Perm) Fbm2(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	sum := float32(0.0)
+	amplitude := float32(1.0)
+	for i := 0; i < octaves; i++ {
+		sum += s.Snoise2(x*frequency, y*frequency) * amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return sum
+}
+
+// Fbm1 sums octaves of Noise1 the way Fbm2 sums Snoise2, for a value that
+// wanders over time, like a flame's brightness
+func (s *Perm) Fbm1(x, frequency, lacunarity, gain float32, octaves int) float32 {
+	sum := float32(0.0)
+	amplitude := float32(1.0)
+	for i := 0; i < octaves; i++ {
+		sum += s.Noise1(x*frequency) * amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return sum
+}
+
+// Ridged2 sums octaves folded into sharp crests where Snoise2 crosses zero,
+// each one squared so the crests stand out from the valleys between them
+func (s *Perm) Ridged2(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	sum := float32(0.0)
+	amplitude := float32(1.0)
+	for i := 0; i < octaves; i++ {
+		f := s.Snoise2(x*frequency, y*frequency) * SnoiseScale
+		if f < 0 {
+			f = -f
+		}
+		f = 1 - f
+		sum += f * f * amplitude
+		frequency *= lacunarity
+		amplitude *= gain
+	}
+	return sum
+}
+
+// BillowNoise folds Fbm2 at zero into puffy, rounded shapes with creases
+// between them. Fbm2 is scaled to roughly -1..1 by its total amplitude
+// first, so the result is roughly -1..1 too.
+func (s *Perm) BillowNoise(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
+	var total float32
+	amplitude := float32(1.0)
+	for i := 0; i < octaves; i++ {
+		total += amplitude
+		amplitude *= gain
+	}
+	if total == 0 {
+		return -1
+	}
+	f := s.Fbm2(x, y, frequency, lacunarity, gain, octaves) * SnoiseScale / total
+	return float32(math.Abs(float64(f)))*2 - 1
+}
+
+// config is f over src as a FractalConfig, without NewFractal's checks, so
+// the methods below keep accepting whatever they were given
+func (f Fractal) config(src Source) *FractalConfig {
+	c := &FractalConfig{base: src, frequency: f.Frequency, lacunarity: f.Lacunarity, gain: f.Gain, octaves: f.Octaves}
+	if c.octaves < 0 {
+		c.octaves = 0
+	}
+	c.layout()
+	return c
+}
+
+// Fbm returns src summed over f's octaves, see FractalConfig.Fbm
+func (f Fractal) Fbm(src Source) Source {
+	return f.config(src).Fbm()
+}
+
+// Turbulence returns the sum of |src| over f's octaves, see
+// FractalConfig.Turbulence
+func (f Fractal) Turbulence(src Source) Source {
+	return f.config(src).Turbulence()
+}
+
+// Ridged returns the sum over f's octaves of (1-|src|)^2, see
+// FractalConfig.Ridged
+func (f Fractal) Ridged(src Source) Source {
+	return f.config(src).Ridged()
+}
+
+// Billow returns |Fbm(src)| divided by the total amplitude, stretched back
+// to -1..1
+func (f Fractal) Billow(src Source) Source {
+	return f.config(src).Billow()
+}
@@ -0,0 +1,75 @@
+package noise
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// generateRows is how many rows Generate fills between checks of its
+// context, and between reports of its progress
+const generateRows = 8
+
+// Config is a Width*Height block of Source. Point x, y of the block is
+// sampled at X+x*Step, Y+y*Step, worked out in float64 and rounded to
+// float32 once. As in Snoise2 the product is rounded explicitly, so no
+// platform fuses it into the sum.
+type Config struct {
+	Source        Source
+	Width, Height int
+	X, Y, Step    float64
+	// Perm is the permutation registered sources are made with, nil for
+	// the reference one. Generate does not use it.
+	Perm *Perm
+}
+
+func (c Config) validate() error {
+	if c.Source == nil {
+		return fmt.Errorf("config needs a source")
+	}
+	if c.Width < 1 || c.Height < 1 {
+		return fmt.Errorf("size must be positive, got %dx%d", c.Width, c.Height)
+	}
+	if !(c.Step > 0) || math.IsInf(c.Step, 0) {
+		return fmt.Errorf("step must be positive, got %v", c.Step)
+	}
+	if math.IsNaN(c.X) || math.IsInf(c.X, 0) || math.IsNaN(c.Y) || math.IsInf(c.Y, 0) {
+		return fmt.Errorf("origin must be finite, got %v, %v", c.X, c.Y)
+	}
+	return nil
+}
+
+// Generate samples cfg into a new slice, row by row on the calling
+// goroutine. Between every few rows it gives up if ctx is done, returning
+// ctx's error, and calls progress, if it is not nil, with the number of
+// rows done so far out of cfg.Height. The counts only ever go up, and a
+// call that returns without an error has reported cfg.Height of cfg.Height
+// last.
+func Generate(ctx context.Context, cfg Config, progress func(done, total int)) ([]float32, error) {
+	err := cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+	field := make([]float32, cfg.Width*cfg.Height)
+	for startY := 0; startY < cfg.Height; startY += generateRows {
+		err := ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+		endY := startY + generateRows
+		if endY > cfg.Height {
+			endY = cfg.Height
+		}
+		for y := startY; y < endY; y++ {
+			row := field[y*cfg.Width : (y+1)*cfg.Width]
+			wy := float32(cfg.Y + float64(float64(y)*cfg.Step))
+			for x := range row {
+				row[x] = cfg.Source.At(float32(cfg.X+float64(float64(x)*cfg.Step)), wy)
+			}
+		}
+		if progress != nil {
+			progress(endY, cfg.Height)
+		}
+	}
+	return field, nil
+}
@@ -0,0 +1,131 @@
+package noise
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// sourceFunc makes a Source of a function
+type sourceFunc func(x, y float32) float32
+
+func (f sourceFunc) At(x, y float32) float32 {
+	return f(x, y)
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := Config{Source: Simplex{}, Width: 37, Height: 21, X: -12.5, Y: 300, Step: 0.75}
+	field, err := Generate(context.Background(), cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(field) != cfg.Width*cfg.Height {
+		t.Fatalf("%d values for %dx%d", len(field), cfg.Width, cfg.Height)
+	}
+	for y := 0; y < cfg.Height; y++ {
+		for x := 0; x < cfg.Width; x++ {
+			want := cfg.Source.At(float32(cfg.X+float64(float64(x)*cfg.Step)), float32(cfg.Y+float64(float64(y)*cfg.Step)))
+			if got := field[y*cfg.Width+x]; got != want {
+				t.Fatalf("%d, %d is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestGenerateProgress(t *testing.T) {
+	for _, h := range []int{1, generateRows - 1, generateRows, generateRows + 1, 100} {
+		var reports []int
+		_, err := Generate(context.Background(), Config{Source: Value{}, Width: 5, Height: h, Step: 1}, func(done, total int) {
+			if total != h {
+				t.Errorf("height %d: total reported as %d", h, total)
+			}
+			reports = append(reports, done)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, done := range reports {
+			if i > 0 && done <= reports[i-1] {
+				t.Errorf("height %d: progress goes from %d to %d", h, reports[i-1], done)
+			}
+		}
+		if len(reports) == 0 || reports[len(reports)-1] != h {
+			t.Errorf("height %d: finished after reporting %v", h, reports)
+		}
+	}
+}
+
+func TestGenerateCancel(t *testing.T) {
+	rows := 0
+	src := sourceFunc(func(x, y float32) float32 {
+		if x == 0 {
+			rows++
+		}
+		return 0
+	})
+	cfg := Config{Source: src, Width: 4, Height: 10 * generateRows, Step: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	field, err := Generate(ctx, cfg, nil)
+	if !errors.Is(err, context.Canceled) || field != nil || rows != 0 {
+		t.Errorf("canceled up front: %d rows, %d values and error %v", rows, len(field), err)
+	}
+
+	// canceling from the first report stops before the next band
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	last := 0
+	field, err = Generate(ctx, cfg, func(done, total int) {
+		last = done
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) || field != nil {
+		t.Errorf("canceled midway: %d values and error %v", len(field), err)
+	}
+	if rows != generateRows || last != generateRows {
+		t.Errorf("canceled after the first band, yet %d rows were sampled and %d reported", rows, last)
+	}
+}
+
+func TestGenerateDeadline(t *testing.T) {
+	// every row takes a millisecond, so the whole field would take a second
+	src := sourceFunc(func(x, y float32) float32 {
+		if x == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		return 0
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Generate(ctx, Config{Source: src, Width: 2, Height: 1000, Step: 1}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error is %v, want the deadline", err)
+	}
+	// one band past the deadline at most, with plenty of room for a slow
+	// machine
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("gave up %v after starting, 20ms was the deadline", elapsed)
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	nan := math.NaN()
+	for _, cfg := range []Config{
+		{Width: 4, Height: 4, Step: 1},
+		{Source: Simplex{}, Width: 0, Height: 4, Step: 1},
+		{Source: Simplex{}, Width: 4, Height: -1, Step: 1},
+		{Source: Simplex{}, Width: 4, Height: 4},
+		{Source: Simplex{}, Width: 4, Height: 4, Step: math.Inf(1)},
+		{Source: Simplex{}, Width: 4, Height: 4, Step: 1, X: nan},
+	} {
+		called := false
+		field, err := Generate(context.Background(), cfg, func(done, total int) { called = true })
+		if err == nil || field != nil || called {
+			t.Errorf("%+v: error %v, %d values, progress reported %v", cfg, err, len(field), called)
+		}
+	}
+}
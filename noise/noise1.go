@@ -0,0 +1,14 @@
+package noise
+
+// grad1 is x times one of 16 gradients spread evenly over -1..1
+func grad1(hash int, x float32) float32 {
+	return (float32(hash&15)/7.5 - 1) * x
+}
+
+// Noise1 is 1d gradient noise, Perlin's noise along a line. It is 0 on
+// every integer and stays within -1..1.
+func (s *Perm) Noise1(x float32) float32 {
+	i, f := lattice(x)
+	// the two ramps can meet at most at 1/2, halfway between the integers
+	return 2 * lerp32(grad1(s.perm[i], f), grad1(s.perm[i+1], f-1), fade(f))
+}
@@ -0,0 +1,212 @@
+package noise
+
+import (
+	"math"
+	"runtime"
+	"testing"
+)
+
+// sampleGrid calls f on a grid of points spread over a few hundred cells,
+// off the lattice so every part of a cell is covered
+func sampleGrid(f func(x, y float32)) {
+	for y := -200; y < 200; y++ {
+		for x := -200; x < 200; x++ {
+			f(float32(x)*0.37+0.11, float32(y)*0.41+0.07)
+		}
+	}
+}
+
+func TestSnoise2Deterministic(t *testing.T) {
+	a, b := NewPerm(7), NewPerm(7)
+	sampleGrid(func(x, y float32) {
+		if a.Snoise2(x, y) != b.Snoise2(x, y) {
+			t.Fatalf("two perms with seed 7 differ at %v, %v", x, y)
+		}
+		if Snoise2(x, y) != classic.Snoise2(x, y) {
+			t.Fatalf("Snoise2 differs from the reference permutation at %v, %v", x, y)
+		}
+	})
+}
+
+func TestNewPermSeeds(t *testing.T) {
+	a, b := NewPerm(1), NewPerm(2)
+	same := 0
+	sampleGrid(func(x, y float32) {
+		if a.Snoise2(x, y) == b.Snoise2(x, y) {
+			same++
+		}
+	})
+	// a point only matches if the gradients that reach it do, which with 8
+	// gradients happens about one time in a hundred
+	if same > 400*400/20 {
+		t.Errorf("seeds 1 and 2 agree on %d of %d points", same, 400*400)
+	}
+}
+
+func TestSnoise2Bounds(t *testing.T) {
+	seen := float32(0)
+	sampleGrid(func(x, y float32) {
+		v := Snoise2(x, y) * SnoiseScale
+		if v < -1 || v > 1 || math.IsNaN(float64(v)) {
+			t.Fatalf("Snoise2(%v, %v)*%d = %v, outside -1..1", x, y, SnoiseScale, v)
+		}
+		if v > seen {
+			seen = v
+		} else if -v > seen {
+			seen = -v
+		}
+	})
+	// a field stuck near zero would pass the check above too
+	if seen < 0.5 {
+		t.Errorf("largest |Snoise2|*%d seen is %v, expected the noise to use most of -1..1", SnoiseScale, seen)
+	}
+}
+
+func TestTurbulenceBounds(t *testing.T) {
+	f := Fractal{Frequency: 0.05, Lacunarity: 2, Gain: 0.5, Octaves: 5}
+	// each octave is at most 1/SnoiseScale times its amplitude
+	limit := float32(1+0.5+0.25+0.125+0.0625) / SnoiseScale
+	sampleGrid(func(x, y float32) {
+		v := Turbulence(x, y, f.Frequency, f.Lacunarity, f.Gain, f.Octaves)
+		if v < 0 || v > limit {
+			t.Fatalf("Turbulence(%v, %v) = %v, outside 0..%v", x, y, v, limit)
+		}
+	})
+}
+
+func TestSnoise2WideMatchesSnoise2(t *testing.T) {
+	sampleGrid(func(x, y float32) {
+		narrow := Snoise2(x, y)
+		wide := Snoise2Wide(float64(x), float64(y))
+		// Snoise2's rounded skew factors move it a little away from the
+		// exact ones as the cell index grows, by about 1e-6 here
+		if d := math.Abs(float64(narrow - wide)); d > 1e-5 {
+			t.Fatalf("at %v, %v Snoise2 is %v and Snoise2Wide %v", x, y, narrow, wide)
+		}
+	})
+}
+
+func TestSnoise2WideFarFromOrigin(t *testing.T) {
+	// float32 can't tell these points apart, the float64 path has to
+	const origin, step = 1e9, 1e-3
+	same := 0
+	prev := Snoise2Wide(origin, origin)
+	for i := 1; i <= 1000; i++ {
+		v := Snoise2Wide(origin+float64(i)*step, origin)
+		if v == prev {
+			same++
+		}
+		prev = v
+	}
+	if same > 10 {
+		t.Errorf("%d of 1000 neighbouring samples at %v are equal", same, origin)
+	}
+}
+
+func TestFractalValidate(t *testing.T) {
+	good := Fractal{Frequency: 0.01, Lacunarity: 2, Gain: 0.5, Octaves: 3}
+	if err := good.Validate(); err != nil {
+		t.Errorf("%+v: %v", good, err)
+	}
+	for _, bad := range []Fractal{
+		{Frequency: 0, Lacunarity: 2, Gain: 0.5, Octaves: 3},
+		{Frequency: 0.01, Lacunarity: -1, Gain: 0.5, Octaves: 3},
+		{Frequency: 0.01, Lacunarity: 2, Gain: -0.5, Octaves: 3},
+		{Frequency: 0.01, Lacunarity: 2, Gain: 0.5, Octaves: 0},
+	} {
+		if bad.Validate() == nil {
+			t.Errorf("%+v is accepted", bad)
+		}
+	}
+}
+
+func TestSnoise3(t *testing.T) {
+	seen := float32(0)
+	moved := 0
+	sampleGrid(func(x, y float32) {
+		// z runs along with x so the grid crosses cells in all three
+		// directions
+		z := x*0.3 - 2
+		v := Snoise3(x, y, z) * snoise3Scale
+		if v < -1 || v > 1 || math.IsNaN(float64(v)) {
+			t.Fatalf("Snoise3(%v, %v, %v)*%d = %v, outside -1..1", x, y, z, snoise3Scale, v)
+		}
+		if v > seen {
+			seen = v
+		} else if -v > seen {
+			seen = -v
+		}
+		// a small step in z changes the noise, but only a little
+		next := Snoise3(x, y, z+0.01) * snoise3Scale
+		if d := next - v; d > 0.1 || d < -0.1 {
+			t.Fatalf("Snoise3 at %v, %v jumps from %v to %v stepping z from %v", x, y, v, next, z)
+		} else if d != 0 {
+			moved++
+		}
+	})
+	if seen < 0.5 {
+		t.Errorf("largest |Snoise3|*%d seen is %v, expected the noise to use most of -1..1", snoise3Scale, seen)
+	}
+	if moved < 400*400/2 {
+		t.Errorf("stepping z only changes %d of %d points", moved, 400*400)
+	}
+}
+
+func TestNoise1(t *testing.T) {
+	seen := float32(0)
+	for i := -2000; i < 2000; i++ {
+		x := float32(i)*0.037 + 0.011
+		v := Noise1(x)
+		if v < -1 || v > 1 || math.IsNaN(float64(v)) {
+			t.Fatalf("Noise1(%v) = %v, outside -1..1", x, v)
+		}
+		if v > seen {
+			seen = v
+		} else if -v > seen {
+			seen = -v
+		}
+		if next := Noise1(x + 0.001); next-v > 0.01 || v-next > 0.01 {
+			t.Fatalf("Noise1 jumps from %v to %v stepping from %v", v, next, x)
+		}
+		if n := float32(i); Noise1(n) != 0 {
+			t.Fatalf("Noise1(%v) = %v, want 0 on the integers", n, Noise1(n))
+		}
+	}
+	if seen < 0.5 {
+		t.Errorf("largest |Noise1| seen is %v, expected the noise to use most of -1..1", seen)
+	}
+	if a, b := NewPerm(3).Fbm1(1.3, 1, 2, 0.5, 4), NewPerm(3).Fbm1(1.3, 1, 2, 0.5, 4); a != b {
+		t.Errorf("Fbm1 gives %v, then %v for the same seed", a, b)
+	}
+}
+
+// TestMakeNoiseCoversEveryPixel fills the buffer with NaN first, for sizes
+// that do not split evenly into bands, so any pixel no band writes is left
+// NaN
+func TestMakeNoiseCoversEveryPixel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	sentinel := float32(math.NaN())
+	for _, procs := range []int{1, 2, 3, 7, 16} {
+		runtime.GOMAXPROCS(procs)
+		for _, size := range [][2]int{{1, 1}, {7, 13}, {641, 479}} {
+			w, h := size[0], size[1]
+			for _, typ := range []Type{FBM, TURBULENCE} {
+				field := make([]float32, w*h)
+				for i := range field {
+					field[i] = sentinel
+				}
+				min, max := fillNoise(field, typ, 0.01, 2, 0.5, 2, w, h)
+				r := [2]float32{float32(math.MaxFloat32), -float32(math.MaxFloat32)}
+				for i, v := range field {
+					if math.IsNaN(float64(v)) {
+						t.Fatalf("GOMAXPROCS %d, %dx%d, type %d: pixel %d,%d never written", procs, w, h, typ, i%w, i/w)
+					}
+					r[0], r[1] = float32(math.Min(float64(r[0]), float64(v))), float32(math.Max(float64(r[1]), float64(v)))
+				}
+				if min != r[0] || max != r[1] {
+					t.Errorf("GOMAXPROCS %d, %dx%d, type %d: range %v..%v, the field has %v..%v", procs, w, h, typ, min, max, r[0], r[1])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package noise
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Perm is a permutation of 0-255 that decides the gradient or value at
+// every lattice point, two Perms with the same permutation give the same
+// noise everywhere. A Perm is never changed after it is made, so it can be
+// used from any number of goroutines at once.
+type Perm struct {
+	// perm is the permutation repeated twice, every lookup is an index
+	// wrapped at 256 plus an offset of at most 256, so none has to wrap
+	// again
+	perm [512]int
+}
+
+// NewPerm returns a Perm with a permutation shuffled by seed
+func NewPerm(seed int64) *Perm {
+	var p [256]uint8
+	for i, v := range rand.New(rand.NewSource(seed)).Perm(256) {
+		p[i] = uint8(v)
+	}
+	return newPerm(p)
+}
+
+func newPerm(p [256]uint8) *Perm {
+	s := &Perm{}
+	for i := range s.perm {
+		s.perm[i] = int(p[i&255])
+	}
+	return s
+}
+
+// classic is the Perm of the package level functions, with Ken Perlin's
+// reference permutation
+var classic = newPerm(classicPerm)
+
+func fastFloor(x float32) int {
+	if float32(int(x)) <= x {
+		return int(x)
+	}
+	return int(x) - 1
+}
+
+// Static data
+
+/*
+ * Permutation table. This is just a random jumble of all numbers 0-255
+ * This needs to be exactly the same for all instances on all platforms,
+ * so it's easiest to just keep it as static explicit data.
+ * This also removes the need for any initialisation of this class.
+ *
+ */
+var classicPerm = [256]uint8{151, 160, 137, 91, 90, 15,
+	131, 13, 201, 95, 96, 53, 194, 233, 7, 225, 140, 36, 103, 30, 69, 142, 8, 99, 37, 240, 21, 10, 23,
+	190, 6, 148, 247, 120, 234, 75, 0, 26, 197, 62, 94, 252, 219, 203, 117, 35, 11, 32, 57, 177, 33,
+	88, 237, 149, 56, 87, 174, 20, 125, 136, 171, 168, 68, 175, 74, 165, 71, 134, 139, 48, 27, 166,
+	77, 146, 158, 231, 83, 111, 229, 122, 60, 211, 133, 230, 220, 105, 92, 41, 55, 46, 245, 40, 244,
+	102, 143, 54, 65, 25, 63, 161, 1, 216, 80, 73, 209, 76, 132, 187, 208, 89, 18, 169, 200, 196,
+	135, 130, 116, 188, 159, 86, 164, 100, 109, 198, 173, 186, 3, 64, 52, 217, 226, 250, 124, 123,
+	5, 202, 38, 147, 118, 126, 255, 82, 85, 212, 207, 206, 59, 227, 47, 16, 58, 17, 182, 189, 28, 42,
+	223, 183, 170, 213, 119, 248, 152, 2, 44, 154, 163, 70, 221, 153, 101, 155, 167, 43, 172, 9,
+	129, 22, 39, 253, 19, 98, 108, 110, 79, 113, 224, 232, 178, 185, 112, 104, 218, 246, 97, 228,
+	251, 34, 242, 193, 238, 210, 144, 12, 191, 179, 162, 241, 81, 51, 145, 235, 249, 14, 239, 107,
+	49, 192, 214, 31, 181, 199, 106, 157, 184, 84, 204, 176, 115, 121, 50, 45, 127, 4, 150, 254,
+	138, 236, 205, 93, 222, 114, 67, 29, 24, 72, 243, 141, 128, 195, 78, 66, 215, 61, 156, 180}
+
+//---------------------------------------------------------------------
+
+func grad2(hash int, x, y float32) float32 {
+	h := hash & 7 // Convert low 3 bits of hash code
+	u := y
+	v := 2 * x
+	if h < 4 {
+		u = x
+		v = 2 * y
+	} // into 8 simple gradient directions,
+	// and compute the dot product with (x,y).
+
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+// Snoise2 is 2D simplex noise, without the usual final *40, so it stays
+// within about -1/40..1/40.
+//
+// The products that are added to something are converted to float32
+// explicitly. Go may otherwise fuse a multiply and add into one FMA
+// instruction, as it does on arm64, which rounds once instead of twice and
+// gives slightly different noise than amd64.
+func (s *Perm) Snoise2(x, y float32) float32 {
+
+	const F2 float32 = 0.366025403 // F2 = 0.5*(sqrt(3.0)-1.0)
+	const G2 float32 = 0.211324865 // G2 = (3.0-Math.sqrt(3.0))/6.0
+
+	// Skew the input space to determine which simplex cell we're in
+	sk := float32((x + y) * F2) // Hairy factor for 2D
+	xs := x + sk
+	ys := y + sk
+	i := fastFloor(xs)
+	j := fastFloor(ys)
+
+	t := float32(float32(i+j) * G2)
+	X0 := float32(i) - t // Unskew the cell origin back to (x,y) space
+	Y0 := float32(j) - t
+	x0 := x - X0 // The x,y distances from the cell origin
+	y0 := y - Y0
+
+	var n0, n1, n2 float32 // Noise contributions from the three corners
+
+	// For the 2D case, the simplex shape is an equilateral triangle.
+	// Determine which simplex we are in.
+	var i1, j1 int // Offsets for second (middle) corner of simplex in (i,j) coords
+	if x0 > y0 {
+		i1 = 1
+		j1 = 0
+	} else { // lower triangle, XY order: (0,0)->(1,0)->(1,1)
+		i1 = 0
+		j1 = 1
+	} // upper triangle, YX order: (0,0)->(0,1)->(1,1)
+
+	// A step of (1,0) in (i,j) means a step of (1-c,-c) in (x,y), and
+	// a step of (0,1) in (i,j) means a step of (-c,1-c) in (x,y), where
+	// c = (3-sqrt(3))/6
+
+	x1 := x0 - float32(i1) + G2 // Offsets for middle corner in (x,y) unskewed coords
+	y1 := y0 - float32(j1) + G2
+	x2 := x0 - 1.0 + 2.0*G2 // Offsets for last corner in (x,y) unskewed coords
+	y2 := y0 - 1.0 + 2.0*G2
+
+	// Wrap the integer indices at 256, the doubled perm covers the offsets
+	// added to them below
+	ii := i & 255
+	jj := j & 255
+	perm := &s.perm
+
+	// Calculate the contribution from the three corners
+	t0 := 0.5 - float32(x0*x0) - float32(y0*y0)
+	if t0 < 0.0 {
+		n0 = 0.0
+	} else {
+		t0 *= t0
+		n0 = t0 * t0 * grad2(perm[ii+perm[jj]], x0, y0)
+	}
+
+	t1 := 0.5 - float32(x1*x1) - float32(y1*y1)
+	if t1 < 0.0 {
+		n1 = 0.0
+	} else {
+		t1 *= t1
+		n1 = t1 * t1 * grad2(perm[ii+i1+perm[jj+j1]], x1, y1)
+	}
+
+	t2 := 0.5 - float32(x2*x2) - float32(y2*y2)
+	if t2 < 0.0 {
+		n2 = 0.0
+	} else {
+		t2 *= t2
+		n2 = t2 * t2 * grad2(perm[ii+1+perm[jj+1]], x2, y2)
+	}
+
+	// Add contributions from each corner to get the final noise value.
+	return (n0 + n1 + n2)
+}
+
+// Snoise2Wide is Snoise2 for float64 coordinates, for points far enough
+// from the origin that float32 can no longer tell neighbouring samples
+// apart. Only finding the cell and the offset into it needs the precision,
+// the offset is small so the corners are summed in float32 like Snoise2
+// does. The skew factors are exact here: Snoise2's are rounded to 9 places,
+// which is harmless near the origin, but unskewing a cell index in the
+// billions with them lands whole units away from the cell.
+func (s *Perm) Snoise2Wide(x, y float64) float32 {
+	const sqrt3 = 1.73205080756887729352744634150587236
+	const F2 = (sqrt3 - 1) / 2
+	const G2 = (3 - sqrt3) / 6
+
+	// rounded explicitly against fused multiply adds, as in Snoise2
+	sk := float64((x + y) * F2)
+	i := math.Floor(x + sk)
+	j := math.Floor(y + sk)
+	t := float64((i + j) * G2)
+	x0 := x - (i - t)
+	y0 := y - (j - t)
+	// the cell index only matters modulo 256, wrapping it first keeps it in
+	// range of an int wherever the coordinates are
+	return s.cell(int(math.Mod(i, 256)+256)&255, int(math.Mod(j, 256)+256)&255, float32(x0), float32(y0))
+}
+
+// cell sums the contributions of the corners of the simplex cell i, j to a
+// point x0, y0 away from the cell's origin. It is the second half of
+// Snoise2, which keeps its own copy inline: the call costs it about 15%.
+func (s *Perm) cell(i, j int, x0, y0 float32) float32 {
+	const G2 float32 = 0.211324865 // G2 = (3.0-Math.sqrt(3.0))/6.0
+
+	var n0, n1, n2 float32 // Noise contributions from the three corners
+
+	// For the 2D case, the simplex shape is an equilateral triangle.
+	// Determine which simplex we are in.
+	var i1, j1 int // Offsets for second (middle) corner of simplex in (i,j) coords
+	if x0 > y0 {
+		i1 = 1
+		j1 = 0
+	} else { // lower triangle, XY order: (0,0)->(1,0)->(1,1)
+		i1 = 0
+		j1 = 1
+	} // upper triangle, YX order: (0,0)->(0,1)->(1,1)
+
+	// A step of (1,0) in (i,j) means a step of (1-c,-c) in (x,y), and
+	// a step of (0,1) in (i,j) means a step of (-c,1-c) in (x,y), where
+	// c = (3-sqrt(3))/6
+
+	x1 := x0 - float32(i1) + G2 // Offsets for middle corner in (x,y) unskewed coords
+	y1 := y0 - float32(j1) + G2
+	x2 := x0 - 1.0 + 2.0*G2 // Offsets for last corner in (x,y) unskewed coords
+	y2 := y0 - 1.0 + 2.0*G2
+
+	// Wrap the integer indices at 256, the doubled perm covers the offsets
+	// added to them below
+	ii := i & 255
+	jj := j & 255
+	perm := &s.perm
+
+	// Calculate the contribution from the three corners
+	t0 := 0.5 - float32(x0*x0) - float32(y0*y0)
+	if t0 < 0.0 {
+		n0 = 0.0
+	} else {
+		t0 *= t0
+		n0 = t0 * t0 * grad2(perm[ii+perm[jj]], x0, y0)
+	}
+
+	t1 := 0.5 - float32(x1*x1) - float32(y1*y1)
+	if t1 < 0.0 {
+		n1 = 0.0
+	} else {
+		t1 *= t1
+		n1 = t1 * t1 * grad2(perm[ii+i1+perm[jj+j1]], x1, y1)
+	}
+
+	t2 := 0.5 - float32(x2*x2) - float32(y2*y2)
+	if t2 < 0.0 {
+		n2 = 0.0
+	} else {
+		t2 *= t2
+		n2 = t2 * t2 * grad2(perm[ii+1+perm[jj+1]], x2, y2)
+	}
+
+	// Add contributions from each corner to get the final noise value.
+	return (n0 + n1 + n2)
+}
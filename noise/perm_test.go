@@ -0,0 +1,50 @@
+package noise
+
+import "testing"
+
+// TestPermDoubled checks every lookup Snoise2 makes in the doubled table
+// against the uint8 arithmetic it used to wrap with,
+// perm[uint8(ii+i1+perm[uint8(jj+j1)])], for every wrapped cell and corner
+// offset
+func TestPermDoubled(t *testing.T) {
+	perm := &classic.perm
+	for ii := 0; ii < 256; ii++ {
+		for jj := 0; jj < 256; jj++ {
+			for _, d := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				i1, j1 := d[0], d[1]
+				got := perm[ii+i1+perm[jj+j1]]
+				want := classicPerm[uint8(uint8(ii)+uint8(i1)+classicPerm[uint8(jj)+uint8(j1)])]
+				if got != int(want) {
+					t.Fatalf("ii %d jj %d offset %v: doubled table gives %d, uint8 wrap %d", ii, jj, d, got, want)
+				}
+			}
+		}
+	}
+}
+
+// snoise2Grid is Snoise2 at x -297.3 + 86.1*i, y -301.7 + 83.9*j, taken from
+// the uint8 wrapping version before the doubled table. The grid crosses
+// zero and the 256 wrap in both directions. The products are converted
+// explicitly so they are not fused into the adds, as Snoise2 does.
+var snoise2Grid = [8][8]float32{
+	{-0.014423055, -0.010488377, -0.014368258, -0.00822695, -0.015612122, 0.0099787675, 0.004531116, -0.0011327844},
+	{0.019088503, -0.003927827, 0.0009509679, 0.0040677506, -0.016989272, -0.0062978757, 0.0015984167, 0.011025694},
+	{-0.016069528, -0.011354565, -0.019017035, -0.00043673246, -0.0057462784, 0.013212478, -0.00024324376, 0.008036277},
+	{0.0077418066, -0.0038236259, 0.012463587, 0.00933727, 0.0018083742, 0.0042967238, -0.014778285, 0.0057733245},
+	{0.016355107, -0.0073138373, 0.0055506644, 0.0058945525, -0.012564602, 0.0073586563, -8.975761e-05, 0.004411055},
+	{-0.01054787, -0.007098689, -0.0023886622, -0.019147445, -0.009429621, -0.0012537732, -0.018010769, -0.013461275},
+	{0.0074297125, -0.00017182805, 0.017317433, -0.007477432, 0.004833686, 0.010284154, 0.0046880217, 0.018654417},
+	{0.004476308, -0.0009028814, 0.011484876, 0.0035174894, 0.0048215976, -0.011613758, -0.017177312, -0.017992958},
+}
+
+func TestSnoise2Grid(t *testing.T) {
+	for j, row := range snoise2Grid {
+		y := float32(-301.7) + float32(float32(j)*83.9)
+		for i, want := range row {
+			x := float32(-297.3) + float32(float32(i)*86.1)
+			if got := Snoise2(x, y); got != want {
+				t.Errorf("Snoise2(%v, %v) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
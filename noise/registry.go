@@ -0,0 +1,90 @@
+package noise
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory makes the Source registered under a name for the block cfg
+// describes. cfg.Source is not set, it is what the factory is asked for.
+type Factory func(cfg Config) Source
+
+// registry maps names to the factories of their noise. The sources this
+// package has register themselves from init, a program can add its own
+// the same way.
+type registry struct {
+	mutex     sync.RWMutex
+	factories map[string]Factory
+}
+
+func newRegistry() *registry {
+	return &registry{factories: make(map[string]Factory)}
+}
+
+var defaultRegistry = newRegistry()
+
+func (r *registry) register(name string, factory Factory) {
+	if name == "" {
+		panic("noise: Register with an empty name")
+	}
+	if factory == nil {
+		panic("noise: Register of " + name + " with a nil factory")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.factories[name]; ok {
+		panic("noise: Register called twice for " + name)
+	}
+	r.factories[name] = factory
+}
+
+func (r *registry) names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *registry) registered(name string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	_, ok := r.factories[name]
+	return ok
+}
+
+func (r *registry) create(name string, cfg Config) (Source, error) {
+	r.mutex.RLock()
+	factory, ok := r.factories[name]
+	r.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown noise type %q, expected one of %v", name, r.names())
+	}
+	return factory(cfg), nil
+}
+
+// Register makes factory available as name to New and Names. It is meant
+// to be called from init, and panics if the name is empty or already
+// taken, or if factory is nil.
+func Register(name string, factory Factory) {
+	defaultRegistry.register(name, factory)
+}
+
+// Names are the registered names in sorted order
+func Names() []string {
+	return defaultRegistry.names()
+}
+
+// Registered reports whether name has been registered
+func Registered(name string) bool {
+	return defaultRegistry.registered(name)
+}
+
+// New makes the Source registered as name for cfg
+func New(name string, cfg Config) (Source, error) {
+	return defaultRegistry.create(name, cfg)
+}
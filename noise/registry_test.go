@@ -0,0 +1,118 @@
+package noise
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// constant is a fake source that is v everywhere
+type constant float32
+
+func (c constant) At(x, y float32) float32 {
+	return float32(c)
+}
+
+// panics returns what f panicked with, nil if it returned
+func panics(f func()) (v interface{}) {
+	defer func() { v = recover() }()
+	f()
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	r := newRegistry()
+	var got Config
+	r.register("zeta", func(cfg Config) Source { got = cfg; return constant(3) })
+	r.register("alpha", func(Config) Source { return constant(1) })
+	r.register("mid", func(Config) Source { return constant(2) })
+
+	if names := r.names(); !reflect.DeepEqual(names, []string{"alpha", "mid", "zeta"}) {
+		t.Errorf("names are %v, want them sorted", names)
+	}
+	cfg := Config{Width: 3, Height: 2, X: 1, Step: 0.5}
+	src, err := r.create("zeta", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.At(0, 0) != 3 || got != cfg {
+		t.Errorf("zeta made %v from %+v, want 3 from %+v", src.At(0, 0), got, cfg)
+	}
+	if !r.registered("mid") || r.registered("missing") {
+		t.Error("registered disagrees with what was registered")
+	}
+	_, err = r.create("missing", cfg)
+	if err == nil || !strings.Contains(err.Error(), `"missing"`) || !strings.Contains(err.Error(), "[alpha mid zeta]") {
+		t.Errorf("unknown name gives error %v, want one naming it and the known names", err)
+	}
+}
+
+func TestRegistryPanics(t *testing.T) {
+	r := newRegistry()
+	r.register("taken", func(Config) Source { return constant(1) })
+	tests := []struct {
+		name    string
+		factory Factory
+		want    string
+	}{
+		{"taken", func(Config) Source { return constant(2) }, "noise: Register called twice for taken"},
+		{"", func(Config) Source { return constant(2) }, "noise: Register with an empty name"},
+		{"nil", nil, "noise: Register of nil with a nil factory"},
+	}
+	for _, tt := range tests {
+		v := panics(func() { r.register(tt.name, tt.factory) })
+		if fmt.Sprint(v) != tt.want {
+			t.Errorf("registering %q panicked with %v, want %q", tt.name, v, tt.want)
+		}
+	}
+	// the first registration survives the duplicate
+	src, err := r.create("taken", Config{})
+	if err != nil || src.At(0, 0) != 1 {
+		t.Errorf("taken is %v, %v after the duplicate", src, err)
+	}
+	if names := r.names(); !reflect.DeepEqual(names, []string{"taken"}) {
+		t.Errorf("names are %v after the failed registrations", names)
+	}
+}
+
+func TestBuiltinSources(t *testing.T) {
+	want := map[string]Source{"cellular": Cellular{}, "perlin": Perlin{}, "simplex": Simplex{}, "value": Value{}}
+	for name, src := range want {
+		got, err := New(name, Config{})
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got != src {
+			t.Errorf("%s makes %T, want %T", name, got, src)
+		}
+	}
+	// and they are all seeded through the config
+	perm := NewPerm(7)
+	for name := range want {
+		seeded, err := New(name, Config{Perm: perm})
+		if err != nil {
+			t.Fatal(err)
+		}
+		classic, _ := New(name, Config{})
+		differ := false
+		for i := 0; i < 64 && !differ; i++ {
+			x, y := float32(i)*0.37+0.1, float32(i)*0.61+0.2
+			differ = seeded.At(x, y) != classic.At(x, y)
+		}
+		if !differ {
+			t.Errorf("%s is the same with a seeded permutation", name)
+		}
+	}
+
+	names := Names()
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Errorf("Names are not sorted: %v", names)
+		}
+	}
+	if panics(func() { Register("simplex", func(Config) Source { return Simplex{} }) }) == nil {
+		t.Error("simplex can be registered again")
+	}
+}
@@ -0,0 +1,132 @@
+package noise
+
+// snoise3Scale is the usual final *32 of 3d simplex noise that Snoise3
+// leaves out, it brings the noise to roughly -1..1
+const snoise3Scale = 32
+
+// Snoise3 is Perm.Snoise3 on the reference permutation
+func Snoise3(x, y, z float32) float32 {
+	return classic.Snoise3(x, y, z)
+}
+
+func grad3(hash int, x, y, z float32) float32 {
+	h := hash & 15 // Convert low 4 bits of hash code into 12 simple
+	u := y         // gradient directions, and compute dot product.
+	if h < 8 {
+		u = x
+	}
+	v := z // Fix repeats at h = 12 to 15
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+// Snoise3 is 3D simplex noise, without the usual final *32, so it stays
+// within about -1/32..1/32. Like Snoise2 it rounds every product
+// explicitly, so it is the same on every platform.
+func (s *Perm) Snoise3(x, y, z float32) float32 {
+
+	// Simple skewing factors for the 3D case
+	const F3 float32 = 0.333333333
+	const G3 float32 = 0.166666667
+
+	// Skew the input space to determine which simplex cell we're in
+	sk := float32((x + y + z) * F3) // Very nice and simple skew factor for 3D
+	i := fastFloor(x + sk)
+	j := fastFloor(y + sk)
+	k := fastFloor(z + sk)
+
+	t := float32(float32(i+j+k) * G3)
+	x0 := x - (float32(i) - t) // The x,y,z distances from the cell origin
+	y0 := y - (float32(j) - t)
+	z0 := z - (float32(k) - t)
+
+	// For the 3D case, the simplex shape is a slightly irregular tetrahedron.
+	// Determine which simplex we are in.
+	var i1, j1, k1 int // Offsets for second corner of simplex in (i,j,k) coords
+	var i2, j2, k2 int // Offsets for third corner of simplex in (i,j,k) coords
+	if x0 >= y0 {
+		if y0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0 // X Y Z order
+		} else if x0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1 // X Z Y order
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1 // Z X Y order
+		}
+	} else {
+		if y0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1 // Z Y X order
+		} else if x0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1 // Y Z X order
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0 // Y X Z order
+		}
+	}
+
+	// A step of (1,0,0) in (i,j,k) means a step of (1-c,-c,-c) in (x,y,z),
+	// a step of (0,1,0) in (i,j,k) means a step of (-c,1-c,-c) in (x,y,z), and
+	// a step of (0,0,1) in (i,j,k) means a step of (-c,-c,1-c) in (x,y,z), where
+	// c = 1/6.
+
+	x1 := x0 - float32(i1) + G3 // Offsets for second corner in (x,y,z) coords
+	y1 := y0 - float32(j1) + G3
+	z1 := z0 - float32(k1) + G3
+	x2 := x0 - float32(i2) + 2.0*G3 // Offsets for third corner in (x,y,z) coords
+	y2 := y0 - float32(j2) + 2.0*G3
+	z2 := z0 - float32(k2) + 2.0*G3
+	x3 := x0 - 1.0 + 3.0*G3 // Offsets for last corner in (x,y,z) coords
+	y3 := y0 - 1.0 + 3.0*G3
+	z3 := z0 - 1.0 + 3.0*G3
+
+	// Wrap the integer indices at 256, the doubled perm covers the offsets
+	// added to them below
+	ii := i & 255
+	jj := j & 255
+	kk := k & 255
+	perm := &s.perm
+
+	// Calculate the contribution from the four corners
+	var n float32
+	t0 := 0.6 - float32(x0*x0) - float32(y0*y0) - float32(z0*z0)
+	if t0 >= 0 {
+		t0 *= t0
+		n += float32(t0 * t0 * grad3(perm[ii+perm[jj+perm[kk]]], x0, y0, z0))
+	}
+	t1 := 0.6 - float32(x1*x1) - float32(y1*y1) - float32(z1*z1)
+	if t1 >= 0 {
+		t1 *= t1
+		n += float32(t1 * t1 * grad3(perm[ii+i1+perm[jj+j1+perm[kk+k1]]], x1, y1, z1))
+	}
+	t2 := 0.6 - float32(x2*x2) - float32(y2*y2) - float32(z2*z2)
+	if t2 >= 0 {
+		t2 *= t2
+		n += float32(t2 * t2 * grad3(perm[ii+i2+perm[jj+j2+perm[kk+k2]]], x2, y2, z2))
+	}
+	t3 := 0.6 - float32(x3*x3) - float32(y3*y3) - float32(z3*z3)
+	if t3 >= 0 {
+		t3 *= t3
+		n += float32(t3 * t3 * grad3(perm[ii+1+perm[jj+1+perm[kk+1]]], x3, y3, z3))
+	}
+	return n
+}
+
+// SimplexSlice is the plane at height Z through Perm.Snoise3, scaled to
+// -1..1. Stepping Z a little at a time moves smoothly through the noise,
+// which animates it.
+type SimplexSlice struct {
+	Perm *Perm
+	Z    float32
+}
+
+func (s SimplexSlice) At(x, y float32) float32 {
+	return orClassic(s.Perm).Snoise3(x, y, s.Z) * snoise3Scale
+}
@@ -0,0 +1,145 @@
+package noise
+
+import "math"
+
+// the basic sources are registered under the names the demos know them by
+func init() {
+	Register("simplex", func(cfg Config) Source { return Simplex{cfg.Perm} })
+	Register("perlin", func(cfg Config) Source { return Perlin{cfg.Perm} })
+	Register("value", func(cfg Config) Source { return Value{cfg.Perm} })
+	Register("cellular", func(cfg Config) Source { return Cellular{cfg.Perm} })
+}
+
+// Source is 2d noise that can be sampled anywhere. The basic sources here
+// stay within -1..1, the fractals made by Fractal's methods sum any Source
+// into another one.
+type Source interface {
+	At(x, y float32) float32
+}
+
+// orClassic is p, or the reference permutation if p is nil, so the zero
+// value of every source below is the noise the package level functions give
+func orClassic(p *Perm) *Perm {
+	if p == nil {
+		return classic
+	}
+	return p
+}
+
+// Simplex is Perm.Snoise2 scaled to -1..1
+type Simplex struct {
+	Perm *Perm
+}
+
+func (s Simplex) At(x, y float32) float32 {
+	return orClassic(s.Perm).Snoise2(x, y) * SnoiseScale
+}
+
+// fade is Perlin's 6t^5-15t^4+10t^3, it eases the blend between lattice
+// points so the noise has no creases along the lattice lines
+func fade(t float32) float32 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp32(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
+
+// lattice splits x into the lattice cell it is in, wrapped at 256 for the
+// permutation, and the offset into that cell
+func lattice(x float32) (int, float32) {
+	i := fastFloor(x)
+	return i & 255, x - float32(i)
+}
+
+// Perlin is classic gradient noise on the square lattice, it is 0 on every
+// lattice point and stays within -1..1
+type Perlin struct {
+	Perm *Perm
+}
+
+// perlinGrad is the dot product of x, y with one of 8 gradients, the
+// diagonals and the axes
+func perlinGrad(hash int, x, y float32) float32 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+func (pn Perlin) At(x, y float32) float32 {
+	perm := &orClassic(pn.Perm).perm
+	i, fx := lattice(x)
+	j, fy := lattice(y)
+	u, v := fade(fx), fade(fy)
+	a := lerp32(perlinGrad(perm[i+perm[j]], fx, fy), perlinGrad(perm[i+1+perm[j]], fx-1, fy), u)
+	b := lerp32(perlinGrad(perm[i+perm[j+1]], fx, fy-1), perlinGrad(perm[i+1+perm[j+1]], fx-1, fy-1), u)
+	return lerp32(a, b, v)
+}
+
+// Value is value noise: a random value in -1..1 on every lattice point,
+// blended smoothly in between
+type Value struct {
+	Perm *Perm
+}
+
+// latticeValue spreads a permutation entry over -1..1
+func latticeValue(h int) float32 {
+	return float32(h)/127.5 - 1
+}
+
+func (vn Value) At(x, y float32) float32 {
+	perm := &orClassic(vn.Perm).perm
+	i, fx := lattice(x)
+	j, fy := lattice(y)
+	u, v := fade(fx), fade(fy)
+	a := lerp32(latticeValue(perm[i+perm[j]]), latticeValue(perm[i+1+perm[j]]), u)
+	b := lerp32(latticeValue(perm[i+perm[j+1]]), latticeValue(perm[i+1+perm[j+1]]), u)
+	return lerp32(a, b, v)
+}
+
+// Cellular is Worley noise: every lattice cell holds one random point and
+// the noise is the distance to the nearest of them, -1 on a point and 1
+// once it is a whole cell or further away
+type Cellular struct {
+	Perm *Perm
+}
+
+func (c Cellular) At(x, y float32) float32 {
+	perm := &orClassic(c.Perm).perm
+	ci, cj := fastFloor(x), fastFloor(y)
+	fx, fy := x-float32(ci), y-float32(cj)
+	nearest := float32(math.MaxFloat32)
+	// points past the neighbouring cells are at least a cell away, where
+	// the distance is cut off anyway
+	for dj := -1; dj <= 1; dj++ {
+		for di := -1; di <= 1; di++ {
+			h := perm[(ci+di)&255+perm[(cj+dj)&255]]
+			px := float32(di) + (float32(h)+0.5)/256
+			py := float32(dj) + (float32(perm[h+1])+0.5)/256
+			dx, dy := px-fx, py-fy
+			if d := dx*dx + dy*dy; d < nearest {
+				nearest = d
+			}
+		}
+	}
+	d := float32(math.Sqrt(float64(nearest)))
+	if d > 1 {
+		d = 1
+	}
+	return d*2 - 1
+}
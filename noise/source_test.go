@@ -0,0 +1,109 @@
+package noise
+
+import (
+	"math"
+	"testing"
+)
+
+// testFractal is the fractal the decorators are checked with, its total
+// amplitude is 1.875
+var testFractal = Fractal{Frequency: 0.3, Lacunarity: 2, Gain: 0.5, Octaves: 4}
+
+// sources is every Source with the range it has to stay in and how much
+// of that range the sample grid should at least cover
+var sources = []struct {
+	name     string
+	src      Source
+	min, max float32
+	spread   float32
+}{
+	{"simplex", Simplex{}, -1, 1, 1.5},
+	{"perlin", Perlin{}, -1, 1, 1.5},
+	{"value", Value{}, -1, 1, 1.8},
+	{"cellular", Cellular{}, -1, 1, 1.5},
+	{"simplex slice", SimplexSlice{Z: 0.3}, -1, 1, 1.5},
+	{"fbm", testFractal.Fbm(Simplex{}), -1.875, 1.875, 2},
+	{"turbulence", testFractal.Turbulence(Simplex{}), 0, 1.875, 1},
+	{"ridged", testFractal.Ridged(Simplex{}), 0, 1.875, 1},
+	{"billow", testFractal.Billow(Simplex{}), -1, 1, 1},
+	{"ridged perlin", testFractal.Ridged(Perlin{}), 0, 1.875, 1},
+	{"turbulence of fbm", testFractal.Turbulence(testFractal.Fbm(Value{})), 0, 1.875 * 1.875, 1.5},
+}
+
+func TestSources(t *testing.T) {
+	for _, tt := range sources {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			lo, hi := float32(math.MaxFloat32), float32(-math.MaxFloat32)
+			sampleGrid(func(x, y float32) {
+				v := tt.src.At(x, y)
+				if v < tt.min || v > tt.max || math.IsNaN(float64(v)) {
+					t.Fatalf("At(%v, %v) = %v, outside %v..%v", x, y, v, tt.min, tt.max)
+				}
+				if again := tt.src.At(x, y); again != v {
+					t.Fatalf("At(%v, %v) is %v and then %v", x, y, v, again)
+				}
+				if v < lo {
+					lo = v
+				}
+				if v > hi {
+					hi = v
+				}
+			})
+			// a source stuck on one value would pass the checks above too
+			if hi-lo < tt.spread {
+				t.Errorf("values only cover %v..%v", lo, hi)
+			}
+		})
+	}
+}
+
+func TestSourcesDeterministic(t *testing.T) {
+	bases := []struct {
+		name string
+		make func(p *Perm) Source
+	}{
+		{"simplex", func(p *Perm) Source { return Simplex{p} }},
+		{"perlin", func(p *Perm) Source { return Perlin{p} }},
+		{"value", func(p *Perm) Source { return Value{p} }},
+		{"cellular", func(p *Perm) Source { return Cellular{p} }},
+		{"simplex slice", func(p *Perm) Source { return SimplexSlice{p, 0.3} }},
+	}
+	for _, tt := range bases {
+		a, b, other := tt.make(NewPerm(7)), tt.make(NewPerm(7)), tt.make(NewPerm(8))
+		differ := 0
+		sampleGrid(func(x, y float32) {
+			if a.At(x, y) != b.At(x, y) {
+				t.Fatalf("%s: two perms with seed 7 differ at %v, %v", tt.name, x, y)
+			}
+			if a.At(x, y) != other.At(x, y) {
+				differ++
+			}
+		})
+		if differ < 400*400/2 {
+			t.Errorf("%s: seeds 7 and 8 only differ on %d of %d points", tt.name, differ, 400*400)
+		}
+	}
+}
+
+func TestFractalsMatchFunctions(t *testing.T) {
+	f := testFractal
+	sampleGrid(func(x, y float32) {
+		tests := []struct {
+			name      string
+			got, want float32
+		}{
+			{"Fbm", f.Fbm(Simplex{}).At(x, y), Fbm2(x, y, f.Frequency, f.Lacunarity, f.Gain, f.Octaves) * SnoiseScale},
+			{"Turbulence", f.Turbulence(Simplex{}).At(x, y), Turbulence(x, y, f.Frequency, f.Lacunarity, f.Gain, f.Octaves) * SnoiseScale},
+			{"Ridged", f.Ridged(Simplex{}).At(x, y), Ridged2(x, y, f.Frequency, f.Lacunarity, f.Gain, f.Octaves)},
+			{"Billow", f.Billow(Simplex{}).At(x, y), BillowNoise(x, y, f.Frequency, f.Lacunarity, f.Gain, f.Octaves)},
+		}
+		for _, tt := range tests {
+			// the functions scale by SnoiseScale at a different point, so
+			// they round differently
+			if d := math.Abs(float64(tt.got - tt.want)); d > 1e-5 {
+				t.Fatalf("%s at %v, %v is %v, the function gives %v", tt.name, x, y, tt.got, tt.want)
+			}
+		}
+	})
+}
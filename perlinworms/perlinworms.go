@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/sabith-th/games_with_go/caves"
+	"github.com/sabith-th/games_with_go/vector2"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	cellSize   = 4
+	gridWidth  = winWidth / cellSize
+	gridHeight = winHeight / cellSize
+	numWorms   = 6
+	wormSteps  = 400
+	radius     = 2
+)
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	rockColor   = color{60, 45, 40}
+	tunnelColor = color{15, 10, 10}
+)
+
+// generate carves numWorms worms that all start near the middle of the
+// grid, like a cave system branching out from one chamber
+func generate(rng *rand.Rand) [][]bool {
+	grid := caves.NewGrid(gridWidth, gridHeight)
+	centre := vector2.Vector2{X: float32(gridWidth) / 2, Y: float32(gridHeight) / 2}
+	for i := 0; i < numWorms; i++ {
+		start := vector2.Add(centre, vector2.Vector2{X: rng.Float32()*20 - 10, Y: rng.Float32()*20 - 10})
+		worm := caves.NewWormGenerator(start, rng.Int63())
+		caves.CarveTunnel(grid, worm.Walk(wormSteps), radius)
+	}
+	return grid
+}
+
+func draw(grid [][]bool, pixels []byte) {
+	for y := 0; y < winHeight; y++ {
+		for x := 0; x < winWidth; x++ {
+			c := rockColor
+			if grid[y/cellSize][x/cellSize] {
+				c = tunnelColor
+			}
+			index := (y*winWidth + x) * 4
+			pixels[index] = c.r
+			pixels[index+1] = c.g
+			pixels[index+2] = c.b
+		}
+	}
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Perlin Worms", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	draw(generate(rng), pixels)
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == sdl.SCANCODE_SPACE {
+					draw(generate(rng), pixels)
+				}
+			}
+		}
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}
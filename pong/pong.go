@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/sabith-th/games_with_go/postfx"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
@@ -233,14 +234,20 @@ func main() {
 
 	var frameStart time.Time
 	var elapsedTime float32
+	bloom := false
+	var bloomPass postfx.Bloom
 
 	for {
 		frameStart = time.Now()
 
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch event.(type) {
+			switch e := event.(type) {
 			case *sdl.QuitEvent:
 				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == sdl.SCANCODE_B {
+					bloom = !bloom
+				}
 			}
 		}
 
@@ -266,6 +273,10 @@ func main() {
 		player2.draw(pixels)
 		ball.draw(pixels)
 
+		if bloom {
+			bloomPass.Apply(pixels, winWidth, winHeight, 20, 1.5)
+		}
+
 		tex.Update(nil, pixels, winWidth*4)
 		renderer.Copy(tex, nil, nil)
 		renderer.Present()
@@ -0,0 +1,207 @@
+package postfx
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Pixel buffers are expected to be 4 bytes per pixel in r, g, b, a order,
+// tightly packed with a pitch of w*4 - the same layout the demos upload with
+// PIXELFORMAT_ABGR8888.
+
+// 5 tap binomial approximation of a gaussian, applied once per axis
+var kernel = [5]int{1, 4, 6, 4, 1}
+
+const kernelSum = 16
+
+func luminance(r, g, b byte) byte {
+	return byte((299*int(r) + 587*int(g) + 114*int(b)) / 1000)
+}
+
+func clampByte(v int) byte {
+	if v < 0 {
+		return 0
+	} else if v > 255 {
+		return 255
+	}
+	return byte(v)
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		return 0
+	} else if i >= n {
+		return n - 1
+	}
+	return i
+}
+
+// ExtractBright copies every pixel of src whose luminance is above threshold
+// into dst and writes black everywhere else
+func ExtractBright(src, dst []byte, threshold byte) {
+	for p := 0; p+3 < len(src) && p+3 < len(dst); p += 4 {
+		if luminance(src[p], src[p+1], src[p+2]) > threshold {
+			dst[p] = src[p]
+			dst[p+1] = src[p+1]
+			dst[p+2] = src[p+2]
+		} else {
+			dst[p] = 0
+			dst[p+1] = 0
+			dst[p+2] = 0
+		}
+		dst[p+3] = src[p+3]
+	}
+}
+
+// parallelRows splits the rows 0..h-1 into one band per cpu and calls fn for
+// each band on its own goroutine
+func parallelRows(h int, fn func(startY, endY int)) {
+	if h <= 0 {
+		return
+	}
+	numRoutines := runtime.NumCPU()
+	if numRoutines > h {
+		numRoutines = h
+	}
+	var wg sync.WaitGroup
+	wg.Add(numRoutines)
+	batchSize := h / numRoutines
+	remainder := h % numRoutines
+
+	start := 0
+	for i := 0; i < numRoutines; i++ {
+		end := start + batchSize
+		if i < remainder {
+			end++
+		}
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, end)
+		start = end
+	}
+	wg.Wait()
+}
+
+// GaussianBlur5x5 returns a blurred copy of src using a 5x5 gaussian kernel.
+// The kernel is separable so it runs as a horizontal and a vertical pass,
+// each split across all cpus. Edges are clamped.
+func GaussianBlur5x5(src []byte, w, h int) []byte {
+	result := make([]byte, w*h*4)
+	blur5x5(src, make([]byte, w*h*4), result, w, h)
+	return result
+}
+
+// blur5x5 is GaussianBlur5x5 into result, with tmp holding the horizontal
+// pass. All three must be distinct.
+func blur5x5(src, tmp, result []byte, w, h int) {
+	pitch := w * 4
+
+	parallelRows(h, func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			row := y * pitch
+			for x := 0; x < w; x++ {
+				var r, g, b int
+				for k, weight := range kernel {
+					p := row + clampIndex(x+k-2, w)*4
+					r += int(src[p]) * weight
+					g += int(src[p+1]) * weight
+					b += int(src[p+2]) * weight
+				}
+				p := row + x*4
+				tmp[p] = byte(r / kernelSum)
+				tmp[p+1] = byte(g / kernelSum)
+				tmp[p+2] = byte(b / kernelSum)
+				tmp[p+3] = src[p+3]
+			}
+		}
+	})
+
+	parallelRows(h, func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			for x := 0; x < w; x++ {
+				var r, g, b int
+				for k, weight := range kernel {
+					p := clampIndex(y+k-2, h)*pitch + x*4
+					r += int(tmp[p]) * weight
+					g += int(tmp[p+1]) * weight
+					b += int(tmp[p+2]) * weight
+				}
+				p := y*pitch + x*4
+				result[p] = byte(r / kernelSum)
+				result[p+1] = byte(g / kernelSum)
+				result[p+2] = byte(b / kernelSum)
+				result[p+3] = tmp[p+3]
+			}
+		}
+	})
+}
+
+// AdditiveBlend adds bloom, scaled by strength, onto base in place
+func AdditiveBlend(base, bloom []byte, strength float32) {
+	for p := 0; p+3 < len(base) && p+3 < len(bloom); p += 4 {
+		base[p] = clampByte(int(base[p]) + int(float32(bloom[p])*strength))
+		base[p+1] = clampByte(int(base[p+1]) + int(float32(bloom[p+1])*strength))
+		base[p+2] = clampByte(int(base[p+2]) + int(float32(bloom[p+2])*strength))
+	}
+}
+
+// Bloom runs the full bright pass, blur and composite. It keeps its scratch
+// buffers between calls so a demo can bloom every frame without
+// allocating, the zero value is ready to use.
+type Bloom struct {
+	bright, tmp, blurred []byte
+}
+
+// Apply blooms pixels in place
+func (b *Bloom) Apply(pixels []byte, w, h int, threshold byte, strength float32) {
+	if len(b.bright) != len(pixels) {
+		b.bright = make([]byte, len(pixels))
+		b.tmp = make([]byte, len(pixels))
+		b.blurred = make([]byte, len(pixels))
+	}
+	ExtractBright(pixels, b.bright, threshold)
+	// a single 5x5 pass barely spreads the glow, so blur the bright pass twice
+	blur5x5(b.bright, b.tmp, b.blurred, w, h)
+	blur5x5(b.blurred, b.tmp, b.bright, w, h)
+	AdditiveBlend(pixels, b.bright, strength)
+}
+
+// ChromaticAberration returns a copy of pixels where each colour channel is
+// read from a horizontally shifted position, offsetR/G/B pixels to the right
+// for positive values. Reads past the left or right edge are clamped.
+func ChromaticAberration(pixels []byte, w, h int, offsetR, offsetG, offsetB int) []byte {
+	result := make([]byte, len(pixels))
+	aberrate(pixels, result, w, h, offsetR, offsetG, offsetB)
+	return result
+}
+
+// Aberration is ChromaticAberration in place. It keeps a copy of the
+// frame it reads from between calls, the zero value is ready to use.
+type Aberration struct {
+	src []byte
+}
+
+// Apply shifts the channels of pixels in place
+func (a *Aberration) Apply(pixels []byte, w, h int, offsetR, offsetG, offsetB int) {
+	if len(a.src) != len(pixels) {
+		a.src = make([]byte, len(pixels))
+	}
+	copy(a.src, pixels)
+	aberrate(a.src, pixels, w, h, offsetR, offsetG, offsetB)
+}
+
+// aberrate is ChromaticAberration into result, which must not be pixels
+func aberrate(pixels, result []byte, w, h int, offsetR, offsetG, offsetB int) {
+	pitch := w * 4
+	for y := 0; y < h; y++ {
+		row := y * pitch
+		for x := 0; x < w; x++ {
+			p := row + x*4
+			result[p] = pixels[row+clampIndex(x-offsetR, w)*4]
+			result[p+1] = pixels[row+clampIndex(x-offsetG, w)*4+1]
+			result[p+2] = pixels[row+clampIndex(x-offsetB, w)*4+2]
+			result[p+3] = pixels[p+3]
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package postfx
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// TestBloomApply blooms random frames and compares them with the passes
+// run one by one. The second frame must reuse the first one's buffers.
+func TestBloomApply(t *testing.T) {
+	const w, h = 37, 23
+	rng := rand.New(rand.NewSource(1))
+	var b Bloom
+	var bright0 *byte
+	for frame := 0; frame < 2; frame++ {
+		pixels := make([]byte, w*h*4)
+		rng.Read(pixels)
+
+		want := append([]byte(nil), pixels...)
+		bright := make([]byte, len(want))
+		ExtractBright(want, bright, 160)
+		AdditiveBlend(want, GaussianBlur5x5(GaussianBlur5x5(bright, w, h), w, h), 1.0)
+
+		b.Apply(pixels, w, h, 160, 1.0)
+		if !bytes.Equal(pixels, want) {
+			t.Fatalf("frame %d differs from the passes run one by one", frame)
+		}
+		if frame == 0 {
+			bright0 = &b.bright[0]
+		} else if &b.bright[0] != bright0 {
+			t.Error("the second frame allocated new buffers")
+		}
+	}
+}
+
+// TestAberrationApply shifts random frames in place and compares them with
+// ChromaticAberration's copies. The second frame must reuse the first
+// one's buffer.
+func TestAberrationApply(t *testing.T) {
+	const w, h = 37, 23
+	rng := rand.New(rand.NewSource(1))
+	var a Aberration
+	var src0 *byte
+	for frame := 0; frame < 2; frame++ {
+		pixels := make([]byte, w*h*4)
+		rng.Read(pixels)
+
+		want := ChromaticAberration(pixels, w, h, -5, 0, 5)
+		a.Apply(pixels, w, h, -5, 0, 5)
+		if !bytes.Equal(pixels, want) {
+			t.Fatalf("frame %d differs from ChromaticAberration", frame)
+		}
+		if frame == 0 {
+			src0 = &a.src[0]
+		} else if &a.src[0] != src0 {
+			t.Error("the second frame allocated a new buffer")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package puzzle15
+
+import "math/rand"
+
+// Size is the number of rows and columns of tiles
+const Size = 4
+
+// Board is the tiles by row and column, Board[row][col]. Tiles are numbered
+// 1 to 15 and 0 is the empty space.
+type Board [Size][Size]uint8
+
+// Solved is the goal, the tiles in order with the space last
+var Solved = Board{
+	{1, 2, 3, 4},
+	{5, 6, 7, 8},
+	{9, 10, 11, 12},
+	{13, 14, 15, 0},
+}
+
+// Find returns where tile is, or -1, -1 if it is not on the board
+func (b Board) Find(tile uint8) (row, col int) {
+	for row := range b {
+		for col, t := range b[row] {
+			if t == tile {
+				return row, col
+			}
+		}
+	}
+	return -1, -1
+}
+
+// valid reports whether every tile and the space are on the board once
+func (b Board) valid() bool {
+	var seen [Size * Size]bool
+	for row := range b {
+		for _, t := range b[row] {
+			if int(t) >= len(seen) || seen[t] {
+				return false
+			}
+			seen[t] = true
+		}
+	}
+	return true
+}
+
+// IsSolvable reports whether b can be slid into Solved. Every move either
+// keeps the space in its row and the order of the tiles read row by row,
+// or moves it a row and past three other tiles, which changes the number
+// of inversions, pairs of tiles out of order, by an odd amount. So the
+// inversions plus the space's row counted from the bottom keep their
+// parity, and Solved has none and the space on row 1.
+func IsSolvable(b Board) bool {
+	if !b.valid() {
+		return false
+	}
+	var tiles []uint8
+	for row := range b {
+		for _, t := range b[row] {
+			if t != 0 {
+				tiles = append(tiles, t)
+			}
+		}
+	}
+	inversions := 0
+	for i := range tiles {
+		for j := i + 1; j < len(tiles); j++ {
+			if tiles[i] > tiles[j] {
+				inversions++
+			}
+		}
+	}
+	row, _ := b.Find(0)
+	return (inversions+Size-row)%2 == 1
+}
+
+// Shuffle returns a random solvable board, the same one for the same seed.
+// Half of all orders are unsolvable, swapping two tiles of one of those
+// makes it solvable.
+func Shuffle(seed int64) Board {
+	r := rand.New(rand.NewSource(seed))
+	var b Board
+	for i, t := range r.Perm(Size * Size) {
+		b[i/Size][i%Size] = uint8(t)
+	}
+	if !IsSolvable(b) {
+		// the first two tiles that are not the space
+		var cells [][2]int
+		for i := 0; len(cells) < 2; i++ {
+			if b[i/Size][i%Size] != 0 {
+				cells = append(cells, [2]int{i / Size, i % Size})
+			}
+		}
+		a, c := cells[0], cells[1]
+		b[a[0]][a[1]], b[c[0]][c[1]] = b[c[0]][c[1]], b[a[0]][a[1]]
+	}
+	return b
+}
+
+// MoveTile slides tile into the space if it is next to it. It reports
+// whether it was, the board is returned unchanged if not.
+func MoveTile(b Board, tile uint8) (Board, bool) {
+	if tile == 0 {
+		return b, false
+	}
+	row, col := b.Find(tile)
+	if row < 0 {
+		return b, false
+	}
+	spaceRow, spaceCol := b.Find(0)
+	if abs(row-spaceRow)+abs(col-spaceCol) != 1 {
+		return b, false
+	}
+	b[spaceRow][spaceCol], b[row][col] = tile, 0
+	return b, true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
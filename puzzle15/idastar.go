@@ -0,0 +1,60 @@
+package puzzle15
+
+import "math"
+
+// found is what IDAStar's search returns once it reaches Solved
+const found = -1
+
+// IDAStar returns the fewest moves that solve b, if heuristic never
+// overestimates the moves a board needs, such as a PatternDatabase's. It
+// searches depth first for a solution within a bound, starting from the
+// heuristic of b and raising the bound to the smallest estimate that went
+// past it each time, so it needs no more memory than the path. It reports
+// false if b is unsolvable.
+func IDAStar(b Board, heuristic func(Board) int) ([]Move, bool) {
+	if !IsSolvable(b) {
+		return nil, false
+	}
+	var path []Move
+	// search looks for a solution of b within bound moves, having made
+	// moves already, and returns found or the smallest estimate past the
+	// bound. It never slides back the tile that last moved.
+	var search func(b Board, moves, bound int, last uint8) int
+	search = func(b Board, moves, bound int, last uint8) int {
+		estimate := moves + heuristic(b)
+		if estimate > bound {
+			return estimate
+		}
+		if b == Solved {
+			return found
+		}
+		next := math.MaxInt32
+		row, col := b.Find(0)
+		for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			r, c := row+d[0], col+d[1]
+			if r < 0 || r >= Size || c < 0 || c >= Size || b[r][c] == last {
+				continue
+			}
+			tile := b[r][c]
+			moved := b
+			moved[row][col], moved[r][c] = tile, 0
+			path = append(path, Move(tile))
+			t := search(moved, moves+1, bound, tile)
+			if t == found {
+				return found
+			}
+			path = path[:len(path)-1]
+			if t < next {
+				next = t
+			}
+		}
+		return next
+	}
+	for bound := heuristic(b); ; {
+		t := search(b, 0, bound, 0)
+		if t == found {
+			return path, true
+		}
+		bound = t
+	}
+}
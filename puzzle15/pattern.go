@@ -0,0 +1,359 @@
+package puzzle15
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Groups663 splits the tiles into the groups of the 6-6-3 pattern
+// database: the left two columns bar the top row, the right two bar the
+// top row, and the top row bar tile 1
+var Groups663 = [][]uint8{
+	{1, 5, 6, 9, 10, 13},
+	{7, 8, 11, 12, 14, 15},
+	{2, 3, 4},
+}
+
+// maxGroup is the most tiles a group can have, the search for a group of k
+// marks the states it visited in a bitset of 2^(4k+4) bits, 32MB for 6
+const maxGroup = 6
+
+// unvisited marks a place in a pattern table no search reached
+const unvisited = 0xff
+
+// pattern is the fewest moves of its tiles that put them in place, from
+// every placement of them, indexed by rank. Only moves of the group's own
+// tiles are counted, so the distances of disjoint groups add up to a bound
+// on the moves a board needs.
+type pattern struct {
+	tiles []uint8
+	table []uint8
+}
+
+// permutations is the number of ways to place k tiles in n cells
+func permutations(n, k int) int {
+	p := 1
+	for i := 0; i < k; i++ {
+		p *= n - i
+	}
+	return p
+}
+
+// rank numbers the placements of len(cells) tiles from 0 to one less than
+// permutations(16, len(cells)): each cell counts among the cells its
+// predecessors left free
+func rank(cells []int) int {
+	r := 0
+	for i, c := range cells {
+		free := c
+		for _, p := range cells[:i] {
+			if p < c {
+				free--
+			}
+		}
+		r = r*(Size*Size-i) + free
+	}
+	return r
+}
+
+// sets of cells are the bits of a uint32, cell row*Size+col is bit
+// row*Size+col
+const (
+	leftColumn  = 0x1111
+	rightColumn = 0x8888
+	allCells    = 0xffff
+)
+
+// neighbourCells are the cells next to any of mask
+func neighbourCells(mask uint32) uint32 {
+	return ((mask&^rightColumn)<<1 | (mask&^leftColumn)>>1 | mask<<Size | mask>>Size) & allCells
+}
+
+// region is every cell the space at cell space reaches without moving one
+// of occupied
+func region(space int, occupied uint32) uint32 {
+	free := ^occupied & allCells
+	r := uint32(1) << uint(space)
+	for {
+		next := (r | neighbourCells(r)) & free
+		if next == r {
+			return r
+		}
+		r = next
+	}
+}
+
+func lowestCell(mask uint32) int {
+	for i := 0; i < Size*Size; i++ {
+		if mask&(1<<uint(i)) != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// buildPattern searches breadth first from the solved placement of tiles.
+// A search state is where the tiles are and which region of the other
+// cells the space is in, it moves freely inside that. States are packed
+// as 4 bits a tile and 4 for the lowest cell of the space's region.
+func buildPattern(tiles []uint8) pattern {
+	k := len(tiles)
+	table := make([]uint8, permutations(Size*Size, k))
+	for i := range table {
+		table[i] = unvisited
+	}
+	visited := make([]uint64, (1<<uint(4*k+4)+63)/64)
+	pack := func(cells []int, occupied uint32, space int) uint32 {
+		s := uint32(lowestCell(region(space, occupied)))
+		for i := k - 1; i >= 0; i-- {
+			s = s<<4 | uint32(cells[i])
+		}
+		return s
+	}
+	visit := func(s uint32) bool {
+		if visited[s/64]&(1<<(s%64)) != 0 {
+			return false
+		}
+		visited[s/64] |= 1 << (s % 64)
+		return true
+	}
+
+	cells := make([]int, k)
+	var occupied uint32
+	for i, t := range tiles {
+		cells[i] = int(t) - 1
+		occupied |= 1 << uint(cells[i])
+	}
+	start := pack(cells, occupied, Size*Size-1)
+	visit(start)
+	frontier := []uint32{start}
+	var tileAt [Size * Size]int
+	for depth := 0; len(frontier) > 0; depth++ {
+		var next []uint32
+		for _, s := range frontier {
+			occupied = 0
+			for i := range tileAt {
+				tileAt[i] = -1
+			}
+			for i := range cells {
+				cells[i] = int(s >> uint(4*i) & 0xf)
+				occupied |= 1 << uint(cells[i])
+				tileAt[cells[i]] = i
+			}
+			if r := rank(cells); table[r] == unvisited {
+				table[r] = uint8(depth)
+			}
+			space := region(int(s>>uint(4*k)), occupied)
+			// a tile next to the space's region can move into any cell of
+			// it next to the tile, leaving the space where the tile was
+			for _, from := range cells {
+				to := neighbourCells(1<<uint(from)) & space
+				for c := 0; to != 0; c++ {
+					if to&(1<<uint(c)) == 0 {
+						continue
+					}
+					to &^= 1 << uint(c)
+					i := tileAt[from]
+					cells[i] = c
+					n := pack(cells, occupied&^(1<<uint(from))|1<<uint(c), from)
+					cells[i] = from
+					if visit(n) {
+						next = append(next, n)
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+	return pattern{tiles, table}
+}
+
+// distance is the fewest moves of p's tiles that put them in place on b,
+// cell[t] being where tile t is
+func (p pattern) distance(cell *[Size * Size]int) int {
+	var cells [Size * Size]int
+	for i, t := range p.tiles {
+		cells[i] = cell[t]
+	}
+	return int(p.table[rank(cells[:len(p.tiles)])])
+}
+
+// PatternDatabase is an admissible heuristic for IDAStar: the sum of the
+// moves each group of tiles needs, counting only the group's own moves
+type PatternDatabase struct {
+	patterns []pattern
+}
+
+// NewPatternDatabase builds the tables for groups, which must not share a
+// tile. Each group's search takes time in proportion to the placements of
+// its tiles, a group of 6 takes seconds. Groups have at most 6 tiles.
+func NewPatternDatabase(groups [][]uint8) (*PatternDatabase, error) {
+	err := checkGroups(groups)
+	if err != nil {
+		return nil, err
+	}
+	db := &PatternDatabase{}
+	for _, g := range groups {
+		db.patterns = append(db.patterns, buildPattern(g))
+	}
+	return db, nil
+}
+
+func checkGroups(groups [][]uint8) error {
+	var seen [Size * Size]bool
+	for _, g := range groups {
+		if len(g) == 0 || len(g) > maxGroup {
+			return fmt.Errorf("puzzle15: group %v must have 1 to %d tiles", g, maxGroup)
+		}
+		for _, t := range g {
+			if t == 0 || int(t) >= len(seen) {
+				return fmt.Errorf("puzzle15: group %v has tile %d", g, t)
+			}
+			if seen[t] {
+				return fmt.Errorf("puzzle15: tile %d is in more than one group", t)
+			}
+			seen[t] = true
+		}
+	}
+	return nil
+}
+
+// Heuristic is at most the number of moves b needs, b must be valid
+func (db *PatternDatabase) Heuristic(b Board) int {
+	var cell [Size * Size]int
+	for row := range b {
+		for col, t := range b[row] {
+			cell[t] = row*Size + col
+		}
+	}
+	h := 0
+	for _, p := range db.patterns {
+		h += p.distance(&cell)
+	}
+	return h
+}
+
+// databaseMagic starts a saved pattern database, the digit is the version
+// of the format
+var databaseMagic = []byte("puzzle15 pdb 1\n")
+
+// WriteTo saves the tables in a binary format: the magic line, the number
+// of groups, then for each group the number of tiles, the tiles and the
+// table
+func (db *PatternDatabase) WriteTo(w io.Writer) (int64, error) {
+	header := append([]byte{}, databaseMagic...)
+	header = append(header, byte(len(db.patterns)))
+	parts := [][]byte{header}
+	for _, p := range db.patterns {
+		parts = append(parts, append([]byte{byte(len(p.tiles))}, p.tiles...), p.table)
+	}
+	var written int64
+	for _, part := range parts {
+		n, err := w.Write(part)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// ReadPatternDatabase reads tables saved by WriteTo
+func ReadPatternDatabase(r io.Reader) (*PatternDatabase, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(databaseMagic))
+	_, err := io.ReadFull(br, magic)
+	if err != nil || !bytes.Equal(magic, databaseMagic) {
+		return nil, errors.New("puzzle15: not a pattern database")
+	}
+	n, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	groups := make([][]uint8, n)
+	db := &PatternDatabase{}
+	for i := range groups {
+		k, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		groups[i] = make([]uint8, k)
+		_, err = io.ReadFull(br, groups[i])
+		if err != nil {
+			return nil, err
+		}
+		// the groups are checked before their sizes are trusted
+		err = checkGroups(groups[:i+1])
+		if err != nil {
+			return nil, err
+		}
+		p := pattern{tiles: groups[i], table: make([]uint8, permutations(Size*Size, int(k)))}
+		_, err = io.ReadFull(br, p.table)
+		if err != nil {
+			return nil, fmt.Errorf("puzzle15: pattern database is cut short: %v", err)
+		}
+		db.patterns = append(db.patterns, p)
+	}
+	return db, nil
+}
+
+// sameGroups reports whether db has the tables for groups
+func (db *PatternDatabase) sameGroups(groups [][]uint8) bool {
+	if len(db.patterns) != len(groups) {
+		return false
+	}
+	for i, p := range db.patterns {
+		if !bytes.Equal(p.tiles, groups[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadPatternDatabase reads the tables for groups from path, or builds
+// them and saves them there if the file is missing, unreadable or for
+// other groups. A database that cannot be saved is still returned, with
+// the error.
+func LoadPatternDatabase(path string, groups [][]uint8) (*PatternDatabase, error) {
+	if f, err := os.Open(path); err == nil {
+		db, err := ReadPatternDatabase(f)
+		f.Close()
+		if err == nil && db.sameGroups(groups) {
+			return db, nil
+		}
+	}
+	db, err := NewPatternDatabase(groups)
+	if err != nil {
+		return nil, err
+	}
+	return db, save(path, db)
+}
+
+// save writes db to a temporary file beside path and moves it into place,
+// so a reader never sees half a database
+func save(path string, db *PatternDatabase) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = db.WriteTo(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
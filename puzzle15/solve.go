@@ -0,0 +1,144 @@
+package puzzle15
+
+// Move is the tile slid into the space, MoveTile plays it
+type Move uint8
+
+// maxStates is how many boards Solve may visit before giving up, about
+// 100MB of maps. Searching both ways reaches solutions of about 30 moves
+// within it, most shuffled boards need 50 or more.
+const maxStates = 1 << 21
+
+// state is a board packed 4 bits a cell, cell 0 in the lowest bits
+type state uint64
+
+func pack(b Board) state {
+	var s state
+	for i := Size*Size - 1; i >= 0; i-- {
+		s = s<<4 | state(b[i/Size][i%Size])
+	}
+	return s
+}
+
+func (s state) cell(i int) uint8 {
+	return uint8(s>>(4*uint(i))) & 0xf
+}
+
+func (s state) space() int {
+	for i := 0; i < Size*Size; i++ {
+		if s.cell(i) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// neighbours calls visit with every board one move from s and the tile
+// that moved
+func (s state) neighbours(visit func(n state, tile uint8)) {
+	space := s.space()
+	row, col := space/Size, space%Size
+	from := func(i int) {
+		t := s.cell(i)
+		visit(s&^(0xf<<(4*uint(i)))|state(t)<<(4*uint(space)), t)
+	}
+	if row > 0 {
+		from(space - Size)
+	}
+	if row < Size-1 {
+		from(space + Size)
+	}
+	if col > 0 {
+		from(space - 1)
+	}
+	if col < Size-1 {
+		from(space + 1)
+	}
+}
+
+// node is how a search reached a board: from parent by sliding tile,
+// depth moves from where it started
+type node struct {
+	parent state
+	tile   uint8
+	depth  int
+}
+
+// search is one direction of Solve's breadth first search
+type search struct {
+	seen     map[state]node
+	frontier []state
+}
+
+func newSearch(start state) *search {
+	return &search{seen: map[state]node{start: {}}, frontier: []state{start}}
+}
+
+// expand visits the boards one move past the frontier and makes them the
+// new frontier. It returns the board both searches have seen with the
+// fewest moves through it, and that number, or -1 if they did not meet.
+func (s *search) expand(other *search) (meet state, moves int) {
+	moves = -1
+	var next []state
+	for _, b := range s.frontier {
+		depth := s.seen[b].depth + 1
+		b.neighbours(func(n state, tile uint8) {
+			if _, ok := s.seen[n]; ok {
+				return
+			}
+			s.seen[n] = node{b, tile, depth}
+			next = append(next, n)
+			if o, ok := other.seen[n]; ok && (moves < 0 || depth+o.depth < moves) {
+				meet, moves = n, depth+o.depth
+			}
+		})
+	}
+	s.frontier = next
+	return meet, moves
+}
+
+// Solve returns the fewest moves that solve b, found by searching breadth
+// first from b and from Solved at once until the searches meet. It returns
+// no moves for a solved board and nil for an unsolvable one, or one too
+// far from solved to search within maxStates. IDAStar with a
+// PatternDatabase solves those.
+func Solve(b Board) []Move {
+	if !IsSolvable(b) {
+		return nil
+	}
+	start, goal := pack(b), pack(Solved)
+	if start == goal {
+		return []Move{}
+	}
+	forward, backward := newSearch(start), newSearch(goal)
+	for len(forward.seen)+len(backward.seen) < maxStates {
+		// the smaller frontier is cheaper to take a level further
+		s, other := forward, backward
+		if len(backward.frontier) < len(forward.frontier) {
+			s, other = backward, forward
+		}
+		// a whole level is expanded before stopping, a board met later in
+		// it can have a shorter way on through the other search
+		meet, moves := s.expand(other)
+		if moves >= 0 {
+			return path(forward, backward, meet)
+		}
+	}
+	return nil
+}
+
+// path is the moves from forward's start to meet and then on to
+// backward's. A move backward made from a board to the next is undone
+// by sliding the same tile back.
+func path(forward, backward *search, meet state) []Move {
+	var moves []Move
+	for b := meet; forward.seen[b].depth > 0; b = forward.seen[b].parent {
+		moves = append(moves, Move(forward.seen[b].tile))
+	}
+	for i, j := 0, len(moves)-1; i < j; i, j = i+1, j-1 {
+		moves[i], moves[j] = moves[j], moves[i]
+	}
+	for b := meet; backward.seen[b].depth > 0; b = backward.seen[b].parent {
+		moves = append(moves, Move(backward.seen[b].tile))
+	}
+	return moves
+}
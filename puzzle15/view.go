@@ -0,0 +1,166 @@
+package puzzle15
+
+import (
+	"strconv"
+
+	"github.com/sabith-th/games_with_go/font"
+)
+
+const (
+	// slideTime is how many seconds a tile takes to slide into the space
+	slideTime = 0.12
+	// gap is the space between tiles in pixels
+	gap = 4
+)
+
+var (
+	background = font.Color{R: 30, G: 30, B: 40}
+	numberText = font.Color{R: 255, G: 255, B: 255}
+	// rowColors are the colors of the tiles whose place is in each row, so
+	// a solved row is a stripe of one color
+	rowColors = [Size]font.Color{
+		{R: 200, G: 70, B: 70},
+		{R: 220, G: 160, B: 50},
+		{R: 70, G: 170, B: 90},
+		{R: 70, G: 120, B: 200},
+	}
+)
+
+// View draws a board as numbered tiles TileSize pixels square with their
+// top left corner at X, Y, sliding each tile moved from its old place to
+// its new one. Moves made while a tile is sliding wait their turn.
+type View struct {
+	X, Y, TileSize int
+
+	// shown is the board once the sliding tile is in place, target the
+	// board once every move waiting is made
+	shown, target Board
+	queue         []uint8
+	// sliding is the tile moving, from where it was and progress of the
+	// way to its place in shown, 0 if no tile is moving
+	sliding  uint8
+	fromRow  int
+	fromCol  int
+	progress float64
+}
+
+// NewView returns a view of b with no tile moving
+func NewView(b Board, x, y, tileSize int) *View {
+	return &View{X: x, Y: y, TileSize: tileSize, shown: b, target: b}
+}
+
+// Board is the board once every move made is shown
+func (v *View) Board() Board {
+	return v.target
+}
+
+// Set shows b at once, dropping the moves that were waiting
+func (v *View) Set(b Board) {
+	v.shown, v.target = b, b
+	v.queue = v.queue[:0]
+	v.sliding = 0
+}
+
+// Slide moves tile into the space, as it will be once the moves waiting
+// are made. It reports whether tile could move.
+func (v *View) Slide(tile uint8) bool {
+	b, ok := MoveTile(v.target, tile)
+	if !ok {
+		return false
+	}
+	v.target = b
+	v.queue = append(v.queue, tile)
+	return true
+}
+
+// Busy reports whether a tile is moving or waiting to
+func (v *View) Busy() bool {
+	return v.sliding != 0 || len(v.queue) > 0
+}
+
+// Update moves the sliding tile on by dt seconds, and starts the next one
+// once it is in place
+func (v *View) Update(dt float64) {
+	if v.sliding != 0 {
+		v.progress += dt / slideTime
+		if v.progress < 1 {
+			return
+		}
+		v.sliding = 0
+	}
+	if len(v.queue) == 0 {
+		return
+	}
+	tile := v.queue[0]
+	v.queue = v.queue[1:]
+	v.fromRow, v.fromCol = v.shown.Find(tile)
+	v.shown, _ = MoveTile(v.shown, tile)
+	v.sliding, v.progress = tile, 0
+}
+
+// TileAt returns the tile under pixel x, y as it is shown, 0 for the space
+// or a point off the board
+func (v *View) TileAt(x, y int) uint8 {
+	if x < v.X || y < v.Y {
+		return 0
+	}
+	col, row := (x-v.X)/v.TileSize, (y-v.Y)/v.TileSize
+	if row >= Size || col >= Size {
+		return 0
+	}
+	return v.shown[row][col]
+}
+
+// Draw draws the board over a background the size of the board into a
+// w*h pixel buffer
+func (v *View) Draw(pixels []byte, w, h int) {
+	fillRect(v.X, v.Y, Size*v.TileSize, Size*v.TileSize, background, pixels, w, h)
+	for row := range v.shown {
+		for col, t := range v.shown[row] {
+			if t == 0 {
+				continue
+			}
+			x, y := float64(col), float64(row)
+			if t == v.sliding {
+				// eased so the tile slows down as it settles
+				p := 1 - (1-v.progress)*(1-v.progress)
+				x = float64(v.fromCol) + (x-float64(v.fromCol))*p
+				y = float64(v.fromRow) + (y-float64(v.fromRow))*p
+			}
+			v.drawTile(t, v.X+int(x*float64(v.TileSize)), v.Y+int(y*float64(v.TileSize)), pixels, w, h)
+		}
+	}
+}
+
+// drawTile draws tile with its top left corner at x, y, with its number as
+// large as fits in the middle
+func (v *View) drawTile(tile uint8, x, y int, pixels []byte, w, h int) {
+	size := v.TileSize - gap
+	fillRect(x+gap/2, y+gap/2, size, size, rowColors[(tile-1)/Size], pixels, w, h)
+	text := strconv.Itoa(int(tile))
+	scale := 1
+	for tw, th := font.Size(text, scale+1); tw <= size/2 && th <= size/2; tw, th = font.Size(text, scale+1) {
+		scale++
+	}
+	tw, th := font.Size(text, scale)
+	font.Draw(text, x+(v.TileSize-tw)/2, y+(v.TileSize-th)/2, scale, numberText, pixels, w, h)
+}
+
+// fillRect fills a rectangle of a w*h pixel buffer, the part of it off the
+// buffer is left out
+func fillRect(x, y, rw, rh int, c font.Color, pixels []byte, w, h int) {
+	for py := y; py < y+rh; py++ {
+		if py < 0 || py >= h {
+			continue
+		}
+		for px := x; px < x+rw; px++ {
+			if px < 0 || px >= w {
+				continue
+			}
+			i := (py*w + px) * 4
+			pixels[i] = c.R
+			pixels[i+1] = c.G
+			pixels[i+2] = c.B
+		}
+	}
+}
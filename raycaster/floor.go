@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+const (
+	// the noise floor scrolls along the noise field by this many texels a
+	// second
+	noiseScrollSpeed = 12
+	noiseFrequency   = 0.04
+	// ceilingShade darkens the ceiling, which shares the floor's texture
+	ceilingShade = 0.5
+)
+
+type floorMode int
+
+const (
+	flatFloor floorMode = iota
+	stoneFloor
+	noiseFloor
+	numFloorModes
+)
+
+var floorModeNames = [numFloorModes]string{"flat", "stone", "noise"}
+
+// FloorRaycast draws a textured floor and ceiling one row at a time, the
+// way mode 7 does. Every row below the horizon sees the floor at a single
+// distance along the view, the one at which a wall's bottom edge would
+// land on that row, and every column looks along its own ray, so a floor
+// point is the player's position plus the column's ray scaled by the row's
+// distance. The ceiling mirrors the floor above the horizon. floorTex is
+// textureSize*textureSize RGBA texels, row by row, and repeats once per
+// tile. It is O(w*h): the rays are worked out once per column.
+func FloorRaycast(pixels []byte, w, h int, player Player, floorTex []byte) {
+	// rayX, rayY is each column's ray scaled so that one unit along it is
+	// one unit along the view direction
+	rayX, rayY := make([]float32, w), make([]float32, w)
+	for x := 0; x < w; x++ {
+		offset := float64(fov * ((float32(x)+0.5)/float32(w) - 0.5))
+		angle := float64(player.Angle) + offset
+		rayX[x] = float32(math.Cos(angle) / math.Cos(offset))
+		rayY[x] = float32(math.Sin(angle) / math.Cos(offset))
+	}
+
+	for y := h / 2; y < h; y++ {
+		dist := float32(h) / (2 * (float32(y) + 0.5 - float32(h)/2))
+		shade := 1 / (1 + fogDensity*dist*dist)
+		ceiling := h - 1 - y
+		for x := 0; x < w; x++ {
+			fx := player.X + dist*rayX[x]
+			fy := player.Y + dist*rayY[x]
+			tx := int((fx-float32(math.Floor(float64(fx))))*textureSize) & (textureSize - 1)
+			ty := int((fy-float32(math.Floor(float64(fy))))*textureSize) & (textureSize - 1)
+			t := (ty*textureSize + tx) * 4
+			c := color{floorTex[t], floorTex[t+1], floorTex[t+2]}
+
+			floor := scale(c, shade)
+			index := (y*w + x) * 4
+			pixels[index] = floor.r
+			pixels[index+1] = floor.g
+			pixels[index+2] = floor.b
+
+			top := scale(c, shade*ceilingShade)
+			index = (ceiling*w + x) * 4
+			pixels[index] = top.r
+			pixels[index+1] = top.g
+			pixels[index+2] = top.b
+		}
+	}
+}
+
+// textureRGBA converts a wall texture to the RGBA layout FloorRaycast reads
+func textureRGBA(t *texture) []byte {
+	rgba := make([]byte, textureSize*textureSize*4)
+	for i, c := range t {
+		rgba[i*4], rgba[i*4+1], rgba[i*4+2], rgba[i*4+3] = c.r, c.g, c.b, 255
+	}
+	return rgba
+}
+
+// drawNoiseTexture fills tex with turbulence at offset along the noise
+// field, colored by running it through three out of phase sine waves.
+// Every texel blends the field at its own position with the field a whole
+// texture away in x, y and both, weighted by how close it is to each edge,
+// so the texture tiles without seams.
+func drawNoiseTexture(tex []byte, offset float32) {
+	const size = float32(textureSize)
+	field := func(x, y float32) float32 {
+		return noise.Turbulence(x+offset, y+offset*0.7, noiseFrequency, 2, 0.5, 4) * noise.SnoiseScale
+	}
+	for y := 0; y < textureSize; y++ {
+		for x := 0; x < textureSize; x++ {
+			fx, fy := float32(x), float32(y)
+			u, v := fx/size, fy/size
+			n := field(fx, fy)*(1-u)*(1-v) +
+				field(fx-size, fy)*u*(1-v) +
+				field(fx, fy-size)*(1-u)*v +
+				field(fx-size, fy-size)*u*v
+			phase := float64(n)*4 + float64(offset)*0.05
+			i := (y*textureSize + x) * 4
+			tex[i] = byte(127 + 127*math.Sin(phase))
+			tex[i+1] = byte(127 + 127*math.Sin(phase+2*math.Pi/3))
+			tex[i+2] = byte(127 + 127*math.Sin(phase+4*math.Pi/3))
+			tex[i+3] = 255
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	fov = 66 * math.Pi / 180
+	// moveSpeed is in tiles and turnSpeed in radians per second
+	moveSpeed = 3
+	turnSpeed = 2
+	frameTime = 1.0 / 60
+	// the player keeps this far from walls so the view never clips into one
+	playerRadius = 0.2
+	// fogDensity sets how quickly walls fade, a wall d tiles away keeps
+	// 1/(1+fogDensity*d*d) of its color
+	fogDensity = 0.04
+	// maxDistance stops rays in open maps, no wall is drawn past it
+	maxDistance = 64
+
+	// the overlay map draws every tile overlayTile pixels wide, at
+	// overlayMargin from the top left, with one ray of the fan for every
+	// overlayRayStep screen columns
+	overlayTile    = 10
+	overlayMargin  = 10
+	overlayRayStep = 16
+)
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	ceilingColor = color{40, 40, 55}
+	floorColor   = color{70, 60, 50}
+
+	overlayWall   = color{200, 200, 200}
+	overlayFloor  = color{20, 20, 20}
+	overlayRay    = color{230, 200, 40}
+	overlayPlayer = color{255, 60, 60}
+	overlaySprite = color{60, 200, 255}
+)
+
+// level is the map, . is floor and the digits are walls of that tile type
+var level = []string{
+	"1111111111111111",
+	"1......2.......1",
+	"1......2.......1",
+	"1..33..2...44..1",
+	"1..33......44..1",
+	"1..............1",
+	"11111..222222..1",
+	"5..............5",
+	"5..3..4..3..4..5",
+	"5..............5",
+	"5.......2222...5",
+	"5.......2......5",
+	"5...1...2......5",
+	"5555555555555555",
+}
+
+// parseLevel turns rows of the level into a map, mapData[y][x] is the tile
+// type there and 0 is floor
+func parseLevel(rows []string) [][]int {
+	mapData := make([][]int, len(rows))
+	for y, row := range rows {
+		mapData[y] = make([]int, len(row))
+		for x, c := range row {
+			if c >= '1' && c <= '9' {
+				mapData[y][x] = int(c - '0')
+			}
+		}
+	}
+	return mapData
+}
+
+// tileAt treats everything off the map as wall of type 1
+func tileAt(mapData [][]int, x, y int) int {
+	if y < 0 || y >= len(mapData) || x < 0 || x >= len(mapData[y]) {
+		return 1
+	}
+	return mapData[y][x]
+}
+
+func isWall(mapData [][]int, x, y int) bool {
+	return tileAt(mapData, x, y) != 0
+}
+
+// hit is where a ray met a wall. eastWest is whether the wall faces east or
+// west, u is how far along the wall's face the ray hit, from 0 to 1.
+type hit struct {
+	dist     float32
+	eastWest bool
+	tile     int
+	u        float32
+}
+
+// castRay walks a ray from x, y through the grid with DDA, stepping from one
+// tile edge to the next, and returns the first wall it hits. A ray that
+// leaves the map hits its edge, one that finds nothing within maxDistance
+// returns a hit at maxDistance with no tile.
+func castRay(mapData [][]int, x, y, angle float32) hit {
+	dirX, dirY := math.Cos(float64(angle)), math.Sin(float64(angle))
+	tileX, tileY := int(math.Floor(float64(x))), int(math.Floor(float64(y)))
+
+	// deltaX is how far along the ray it is from one vertical tile edge to
+	// the next, sideX how far it is to the first one, likewise for y
+	deltaX, deltaY := math.Inf(1), math.Inf(1)
+	if dirX != 0 {
+		deltaX = math.Abs(1 / dirX)
+	}
+	if dirY != 0 {
+		deltaY = math.Abs(1 / dirY)
+	}
+	stepX, stepY := 1, 1
+	sideX := (float64(tileX) + 1 - float64(x)) * deltaX
+	if dirX < 0 {
+		stepX = -1
+		sideX = (float64(x) - float64(tileX)) * deltaX
+	}
+	sideY := (float64(tileY) + 1 - float64(y)) * deltaY
+	if dirY < 0 {
+		stepY = -1
+		sideY = (float64(y) - float64(tileY)) * deltaY
+	}
+
+	for {
+		var dist float64
+		eastWest := sideX < sideY
+		if eastWest {
+			dist = sideX
+			sideX += deltaX
+			tileX += stepX
+		} else {
+			dist = sideY
+			sideY += deltaY
+			tileY += stepY
+		}
+		if dist > maxDistance {
+			return hit{dist: maxDistance, eastWest: eastWest}
+		}
+		if tile := tileAt(mapData, tileX, tileY); tile != 0 {
+			// the coordinate along the face, flipped on the faces seen
+			// from the other side so textures are never mirrored
+			var along float64
+			if eastWest {
+				along = float64(y) + dist*dirY
+			} else {
+				along = float64(x) + dist*dirX
+			}
+			u := along - math.Floor(along)
+			if (eastWest && dirX < 0) || (!eastWest && dirY > 0) {
+				u = 1 - u
+			}
+			return hit{float32(dist), eastWest, tile, float32(u)}
+		}
+	}
+}
+
+func scale(c color, k float32) color {
+	return color{byte(float32(c.r) * k), byte(float32(c.g) * k), byte(float32(c.b) * k)}
+}
+
+// Raycast draws the walls seen from playerX, playerY looking along
+// playerAngle into a width*height pixel buffer, one ray per column, with
+// walls textured by their tile type. The floor and ceiling are left to
+// drawFlat or FloorRaycast beforehand. Distances are taken along the view
+// direction rather than along each ray, which keeps straight walls
+// straight instead of bulging towards the middle of the screen. That
+// distance is written to zBuffer for every column, so sprites drawn after
+// can be hidden behind the walls.
+func Raycast(mapData [][]int, playerX, playerY, playerAngle, fov float32, width, height int, pixels []byte, zBuffer []float32) {
+	for x := 0; x < width; x++ {
+		offset := fov * ((float32(x)+0.5)/float32(width) - 0.5)
+		h := castRay(mapData, playerX, playerY, playerAngle+offset)
+		dist := h.dist * float32(math.Cos(float64(offset)))
+		zBuffer[x] = dist
+
+		top, wallHeight := height/2, 0
+		var tex *texture
+		var shade float32
+		if h.tile != 0 {
+			wallHeight = int(float32(height) / dist)
+			top = (height - wallHeight) / 2
+			tex = textureFor(h.tile)
+			// walls facing east and west are darker than the ones facing
+			// north and south, which is enough to tell corners apart
+			shade = 1 / (1 + fogDensity*dist*dist)
+			if h.eastWest {
+				shade *= sideShade
+			}
+		}
+		texX := clampInt(int(h.u*textureSize), 0, textureSize-1)
+
+		for y := clampInt(top, 0, height); y < clampInt(top+wallHeight, 0, height); y++ {
+			texY := clampInt((y-top)*textureSize/wallHeight, 0, textureSize-1)
+			pc := scale(tex[texY*textureSize+texX], shade)
+			index := (y*width + x) * 4
+			pixels[index] = pc.r
+			pixels[index+1] = pc.g
+			pixels[index+2] = pc.b
+		}
+	}
+}
+
+// drawFlat fills the top half of the view with the ceiling color and the
+// bottom half with the floor color
+func drawFlat(pixels []byte, width, height int) {
+	for y := 0; y < height; y++ {
+		c := ceilingColor
+		if y >= height/2 {
+			c = floorColor
+		}
+		for x := 0; x < width; x++ {
+			index := (y*width + x) * 4
+			pixels[index] = c.r
+			pixels[index+1] = c.g
+			pixels[index+2] = c.b
+		}
+	}
+}
+
+func setPixel(x, y int, c color, pixels []byte) {
+	if x < 0 || x >= winWidth || y < 0 || y >= winHeight {
+		return
+	}
+	index := (y*winWidth + x) * 4
+	pixels[index] = c.r
+	pixels[index+1] = c.g
+	pixels[index+2] = c.b
+}
+
+// drawLine draws a line between two points with Bresenham's algorithm
+func drawLine(x0, y0, x1, y1 int, c color, pixels []byte) {
+	dx := x1 - x0
+	if dx < 0 {
+		dx = -dx
+	}
+	dy := y1 - y0
+	if dy > 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		setPixel(x0, y0, c, pixels)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawOverlay draws the map from above in the top left corner with the
+// sprites, the player and a fan of the rays Raycast casts
+func drawOverlay(mapData [][]int, sprites []Sprite, player Player, pixels []byte) {
+	playerX, playerY, playerAngle := player.X, player.Y, player.Angle
+	for ty, row := range mapData {
+		for tx, tile := range row {
+			c := overlayFloor
+			if tile != 0 {
+				c = overlayWall
+			}
+			for y := 0; y < overlayTile; y++ {
+				for x := 0; x < overlayTile; x++ {
+					setPixel(overlayMargin+tx*overlayTile+x, overlayMargin+ty*overlayTile+y, c, pixels)
+				}
+			}
+		}
+	}
+
+	toOverlay := func(x, y float32) (int, int) {
+		return overlayMargin + int(x*overlayTile), overlayMargin + int(y*overlayTile)
+	}
+	for _, sp := range sprites {
+		sx, sy := toOverlay(sp.X, sp.Y)
+		for y := -1; y <= 1; y++ {
+			for x := -1; x <= 1; x++ {
+				setPixel(sx+x, sy+y, overlaySprite, pixels)
+			}
+		}
+	}
+	px, py := toOverlay(playerX, playerY)
+	for x := 0; x <= winWidth; x += overlayRayStep {
+		angle := playerAngle + fov*(float32(x)/float32(winWidth)-0.5)
+		dist := castRay(mapData, playerX, playerY, angle).dist
+		hx, hy := toOverlay(playerX+dist*float32(math.Cos(float64(angle))), playerY+dist*float32(math.Sin(float64(angle))))
+		drawLine(px, py, hx, hy, overlayRay, pixels)
+	}
+	for y := -2; y <= 2; y++ {
+		for x := -2; x <= 2; x++ {
+			setPixel(px+x, py+y, overlayPlayer, pixels)
+		}
+	}
+}
+
+// move steps the player by dx, dy unless that brings them within
+// playerRadius of a wall. Each axis is tried on its own, so walking into a
+// wall at an angle slides along it.
+func move(mapData [][]int, x, y *float32, dx, dy float32) {
+	blocked := func(x, y float32) bool {
+		for _, o := range [][2]float32{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+			if isWall(mapData, int(math.Floor(float64(x+o[0]*playerRadius))), int(math.Floor(float64(y+o[1]*playerRadius)))) {
+				return true
+			}
+		}
+		return false
+	}
+	if !blocked(*x+dx, *y) {
+		*x += dx
+	}
+	if !blocked(*x, *y+dy) {
+		*y += dy
+	}
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Raycaster", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	zBuffer := make([]float32, winWidth)
+	mapData := parseLevel(level)
+	player := Player{2.5, 2.5, 0}
+	sprites := levelSprites()
+	spriteRenderer := NewSpriteRenderer(fov, winWidth, winHeight)
+	showOverlay := false
+	mode := flatFloor
+	stoneTex := textureRGBA(textures[0])
+	noiseTex := make([]byte, textureSize*textureSize*4)
+	noiseOffset := float32(0)
+	keyState := sdl.GetKeyboardState()
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				if e.Type != sdl.KEYDOWN || e.Repeat != 0 {
+					break
+				}
+				switch e.Keysym.Scancode {
+				case sdl.SCANCODE_M:
+					showOverlay = !showOverlay
+				case sdl.SCANCODE_F:
+					mode = (mode + 1) % numFloorModes
+					fmt.Println("floor", floorModeNames[mode])
+				}
+			}
+		}
+
+		if keyState[sdl.SCANCODE_A] != 0 {
+			player.Angle -= turnSpeed * frameTime
+		}
+		if keyState[sdl.SCANCODE_D] != 0 {
+			player.Angle += turnSpeed * frameTime
+		}
+		step := float32(0)
+		if keyState[sdl.SCANCODE_W] != 0 {
+			step += moveSpeed * frameTime
+		}
+		if keyState[sdl.SCANCODE_S] != 0 {
+			step -= moveSpeed * frameTime
+		}
+		if step != 0 {
+			dx := step * float32(math.Cos(float64(player.Angle)))
+			dy := step * float32(math.Sin(float64(player.Angle)))
+			move(mapData, &player.X, &player.Y, dx, dy)
+		}
+
+		switch mode {
+		case flatFloor:
+			drawFlat(pixels, winWidth, winHeight)
+		case stoneFloor:
+			FloorRaycast(pixels, winWidth, winHeight, player, stoneTex)
+		case noiseFloor:
+			noiseOffset += noiseScrollSpeed * frameTime
+			drawNoiseTexture(noiseTex, noiseOffset)
+			FloorRaycast(pixels, winWidth, winHeight, player, noiseTex)
+		}
+		Raycast(mapData, player.X, player.Y, player.Angle, fov, winWidth, winHeight, pixels, zBuffer)
+		spriteRenderer.Draw(sprites, player, zBuffer, pixels)
+		if showOverlay {
+			drawOverlay(mapData, sprites, player, pixels)
+		}
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}
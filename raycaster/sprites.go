@@ -0,0 +1,167 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	spriteSize = 64
+	// sprites closer than nearPlane along the view are not drawn, they
+	// would cover the whole screen
+	nearPlane = 0.1
+)
+
+// Player is where the view is rendered from, Angle is the view direction in
+// radians
+type Player struct {
+	X, Y, Angle float32
+}
+
+// Sprite is a billboard standing on the floor at X, Y, one tile tall.
+// Texture is spriteSize*spriteSize RGBA texels, row by row, and texels with
+// alpha below half are not drawn.
+type Sprite struct {
+	X, Y    float32
+	Texture []byte
+}
+
+// SpriteRenderer draws sprites into a view rendered by Raycast with the same
+// field of view and size
+type SpriteRenderer struct {
+	FOV           float32
+	Width, Height int
+	order         []int
+}
+
+func NewSpriteRenderer(fov float32, width, height int) *SpriteRenderer {
+	return &SpriteRenderer{FOV: fov, Width: width, Height: height}
+}
+
+// Draw draws sprites from the farthest to the nearest, so nearer ones cover
+// farther ones. A sprite is placed and sized exactly like a wall at the same
+// distance: its column follows from the angle it is seen at and its height
+// is the screen height over its distance along the view. Columns where
+// zBuffer has a nearer wall are skipped.
+func (sr *SpriteRenderer) Draw(sprites []Sprite, player Player, zBuffer []float32, pixels []byte) {
+	sr.order = sr.order[:0]
+	for i := range sprites {
+		sr.order = append(sr.order, i)
+	}
+	dist2 := func(i int) float32 {
+		dx, dy := sprites[i].X-player.X, sprites[i].Y-player.Y
+		return dx*dx + dy*dy
+	}
+	sort.Slice(sr.order, func(a, b int) bool {
+		return dist2(sr.order[a]) > dist2(sr.order[b])
+	})
+
+	for _, i := range sr.order {
+		sp := &sprites[i]
+		dx, dy := float64(sp.X-player.X), float64(sp.Y-player.Y)
+		angle := math.Atan2(dy, dx) - float64(player.Angle)
+		angle = math.Remainder(angle, 2*math.Pi)
+		perp := float32(math.Hypot(dx, dy) * math.Cos(angle))
+		if perp < nearPlane {
+			continue
+		}
+
+		size := float32(sr.Height) / perp
+		centre := (float32(angle)/sr.FOV + 0.5) * float32(sr.Width)
+		left := centre - size/2
+		top := (float32(sr.Height) - size) / 2
+		shade := 1 / (1 + fogDensity*perp*perp)
+
+		startX := clampInt(int(math.Floor(float64(left))), 0, sr.Width)
+		endX := clampInt(int(math.Ceil(float64(left+size))), 0, sr.Width)
+		startY := clampInt(int(math.Floor(float64(top))), 0, sr.Height)
+		endY := clampInt(int(math.Ceil(float64(top+size))), 0, sr.Height)
+		for x := startX; x < endX; x++ {
+			if perp >= zBuffer[x] {
+				continue
+			}
+			texX := clampInt(int((float32(x)+0.5-left)/size*spriteSize), 0, spriteSize-1)
+			for y := startY; y < endY; y++ {
+				texY := clampInt(int((float32(y)+0.5-top)/size*spriteSize), 0, spriteSize-1)
+				t := (texY*spriteSize + texX) * 4
+				if sp.Texture[t+3] < 128 {
+					continue
+				}
+				c := scale(color{sp.Texture[t], sp.Texture[t+1], sp.Texture[t+2]}, shade)
+				index := (y*sr.Width + x) * 4
+				pixels[index] = c.r
+				pixels[index+1] = c.g
+				pixels[index+2] = c.b
+			}
+		}
+	}
+}
+
+// makeSpriteTexture builds a texture from a function giving each texel's
+// color and whether it is opaque
+func makeSpriteTexture(texel func(x, y int) (color, bool)) []byte {
+	tex := make([]byte, spriteSize*spriteSize*4)
+	for y := 0; y < spriteSize; y++ {
+		for x := 0; x < spriteSize; x++ {
+			c, opaque := texel(x, y)
+			if !opaque {
+				continue
+			}
+			i := (y*spriteSize + x) * 4
+			tex[i], tex[i+1], tex[i+2], tex[i+3] = c.r, c.g, c.b, 255
+		}
+	}
+	return tex
+}
+
+var (
+	// barrelTexture is a wooden barrel with two iron bands
+	barrelTexture = makeSpriteTexture(func(x, y int) (color, bool) {
+		if x < 18 || x >= 46 || y < 26 {
+			return color{}, false
+		}
+		// darker towards the edges so it looks round
+		edge := float32(math.Abs(float64(x)-31.5)) / 14
+		if y < 29 || (y >= 36 && y < 39) || (y >= 53 && y < 56) {
+			return scale(color{90, 90, 95}, 1-0.5*edge), true
+		}
+		return scale(color{140, 90, 45}, 1-0.5*edge), true
+	})
+	// pillarTexture is a stone column with a wider base and top
+	pillarTexture = makeSpriteTexture(func(x, y int) (color, bool) {
+		half := 7
+		if y < 6 || y >= 58 {
+			half = 12
+		}
+		if x < 32-half || x >= 32+half {
+			return color{}, false
+		}
+		edge := float32(math.Abs(float64(x)-31.5)) / float32(half)
+		return scale(color{185, 180, 170}, 1-0.45*edge), true
+	})
+	// orbTexture is a glowing green orb hovering above the floor
+	orbTexture = makeSpriteTexture(func(x, y int) (color, bool) {
+		dx, dy := float64(x)-31.5, float64(y)-44.5
+		d := math.Hypot(dx, dy) / 9
+		if d > 1 {
+			return color{}, false
+		}
+		// lit from the top left
+		light := float32(1 - 0.6*math.Hypot(dx+3, dy+3)/12)
+		return scale(color{90, 255, 120}, light), true
+	})
+)
+
+// levelSprites places the sprites of the level
+func levelSprites() []Sprite {
+	return []Sprite{
+		{5.5, 1.5, barrelTexture},
+		{6.5, 1.5, barrelTexture},
+		{3.5, 5.5, pillarTexture},
+		{6.5, 5.5, pillarTexture},
+		{10.5, 2.5, orbTexture},
+		{13.5, 7.5, barrelTexture},
+		{4.5, 11.5, orbTexture},
+		{11.5, 12.5, pillarTexture},
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+const (
+	textureSize = 64
+	// sideShade darkens walls facing east and west
+	sideShade = 0.7
+	// every tile type reads the noise noiseSpread further along, so no two
+	// types share any of the field
+	noiseSpread = 1000
+	// fbmScale brings the fbm below to roughly -1..1, it sums four octaves
+	// at gain 0.5 so it divides by their total of 1.875
+	fbmScale = noise.SnoiseScale / 1.875
+)
+
+// texture is textureSize*textureSize texels, row by row
+type texture [textureSize * textureSize]color
+
+// wall patterns, tile type 1 is stone, 2 brick, 3 wood, 4 marble and 5
+// mossy stone. Higher types reuse them in turn.
+var textures = func() []*texture {
+	var t []*texture
+	for tile := 1; tile <= 5; tile++ {
+		t = append(t, makeTexture(tile))
+	}
+	return t
+}()
+
+func textureFor(tile int) *texture {
+	return textures[(tile-1)%len(textures)]
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func clampByte(v float32) byte {
+	return byte(clampInt(int(v), 0, 255))
+}
+
+func mix(c1, c2 color, t float32) color {
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	return color{
+		clampByte(float32(c1.r) + t*(float32(c2.r)-float32(c1.r))),
+		clampByte(float32(c1.g) + t*(float32(c2.g)-float32(c1.g))),
+		clampByte(float32(c1.b) + t*(float32(c2.b)-float32(c1.b))),
+	}
+}
+
+// makeTexture draws tile's pattern. Its noise is offset by the tile type so
+// every type gets a field of its own.
+func makeTexture(tile int) *texture {
+	offset := float32(tile * noiseSpread)
+	fbm := func(x, y, frequency float32) float32 {
+		return noise.Fbm2(x+offset, y+offset, frequency, 2, 0.5, 4) * fbmScale
+	}
+
+	t := &texture{}
+	for y := 0; y < textureSize; y++ {
+		for x := 0; x < textureSize; x++ {
+			fx, fy := float32(x), float32(y)
+			n := fbm(fx, fy, 0.08)
+			var c color
+			switch (tile - 1) % 5 {
+			case 0:
+				c = mix(color{95, 95, 100}, color{170, 170, 165}, 0.5+n)
+				if float32(math.Abs(float64(fbm(fx, fy, 0.03)))) < 0.03 {
+					c = color{50, 50, 55}
+				}
+			case 1:
+				// 32x16 bricks, every other row shifted by half a brick
+				// and 2 texels of mortar around each
+				bx := x
+				if (y/16)%2 == 1 {
+					bx += 16
+				}
+				if y%16 < 2 || bx%32 < 2 {
+					c = mix(color{150, 145, 135}, color{185, 180, 170}, 0.5+n)
+				} else {
+					brick := fbm(float32(bx/32)*7, float32(y/16)*7, 1)
+					c = mix(color{120, 45, 30}, color{175, 75, 50}, 0.5+0.6*brick+0.3*n)
+				}
+			case 2:
+				// vertical planks with grain bent by the noise
+				grain := math.Sin(float64(fx*0.9 + 6*fbm(fx, fy, 0.02)))
+				c = mix(color{95, 60, 30}, color{150, 100, 55}, float32(0.5+0.35*grain)+0.2*n)
+				if x%16 == 0 {
+					c = color{60, 38, 20}
+				}
+			case 3:
+				vein := math.Abs(math.Sin(float64(fx*0.1+fy*0.05) + float64(8*fbm(fx, fy, 0.03))))
+				c = mix(color{120, 120, 130}, color{235, 232, 225}, float32(math.Pow(vein, 0.3)))
+			case 4:
+				c = mix(color{90, 90, 95}, color{150, 150, 150}, 0.5+n)
+				if moss := fbm(fx, fy, 0.05); moss > 0.1 {
+					c = mix(c, color{50, 110, 40}, (moss-0.1)*4)
+				}
+			}
+			t[y*textureSize+x] = c
+		}
+	}
+	return t
+}
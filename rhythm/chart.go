@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const numLanes = 4
+
+// timing windows in milliseconds either side of a note
+const (
+	perfectWindow = 30
+	goodWindow    = 80
+	badWindow     = 160
+)
+
+type judgement int
+
+const (
+	noJudgement judgement = iota
+	perfect
+	good
+	bad
+	miss
+	numJudgements
+)
+
+var judgementNames = [numJudgements]string{"", "PERFECT", "GOOD", "BAD", "MISS"}
+
+var judgementScores = [numJudgements]int{0, 300, 100, 50, 0}
+
+func judge(offset float64) judgement {
+	offset = math.Abs(offset)
+	switch {
+	case offset < perfectWindow:
+		return perfect
+	case offset < goodWindow:
+		return good
+	case offset < badWindow:
+		return bad
+	}
+	return miss
+}
+
+// chartNote is a note as written in the chart file. It is placed with either
+// beat, counted from the start of the song at the chart's bpm, or ms.
+type chartNote struct {
+	Lane int      `json:"lane"`
+	Beat *float64 `json:"beat,omitempty"`
+	Ms   *float64 `json:"ms,omitempty"`
+}
+
+// chartFile is the json format of a chart. Offset is added to every note
+// and shifts the whole chart against the song.
+type chartFile struct {
+	Title  string      `json:"title"`
+	Song   string      `json:"song"`
+	BPM    float64     `json:"bpm"`
+	Offset float64     `json:"offset"`
+	Notes  []chartNote `json:"notes"`
+}
+
+type note struct {
+	lane   int
+	time   float64
+	result judgement
+}
+
+type chart struct {
+	title string
+	song  string
+	notes []note
+}
+
+// loadChart reads a chart file and converts its notes to milliseconds,
+// sorted by time. The song path is taken relative to the chart.
+func loadChart(filename string) (*chart, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var f chartFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	c := &chart{title: f.Title}
+	if f.Song != "" {
+		c.song = filepath.Join(filepath.Dir(filename), f.Song)
+	}
+	for i, n := range f.Notes {
+		if n.Lane < 0 || n.Lane >= numLanes {
+			return nil, fmt.Errorf("%s: note %d: lane must be between 0 and %d", filename, i, numLanes-1)
+		}
+		var t float64
+		switch {
+		case n.Beat != nil && n.Ms != nil:
+			return nil, fmt.Errorf("%s: note %d: has both beat and ms", filename, i)
+		case n.Beat != nil:
+			if f.BPM <= 0 {
+				return nil, fmt.Errorf("%s: note %d: placed by beat but the chart has no bpm", filename, i)
+			}
+			t = *n.Beat * 60000 / f.BPM
+		case n.Ms != nil:
+			t = *n.Ms
+		default:
+			return nil, fmt.Errorf("%s: note %d: needs a beat or ms", filename, i)
+		}
+		c.notes = append(c.notes, note{lane: n.Lane, time: t + f.Offset})
+	}
+	sort.SliceStable(c.notes, func(i, j int) bool { return c.notes[i].time < c.notes[j].time })
+	return c, nil
+}
+
+func (c *chart) reset() {
+	for i := range c.notes {
+		c.notes[i].result = noJudgement
+	}
+}
+
+// length is the time of the last note, or 0 for an empty chart
+func (c *chart) length() float64 {
+	if len(c.notes) == 0 {
+		return 0
+	}
+	return c.notes[len(c.notes)-1].time
+}
+
+// hit judges the earliest open note in lane that is within the bad window
+// of now. A press with no note close enough is ignored.
+func (c *chart) hit(lane int, now float64) judgement {
+	for i := range c.notes {
+		n := &c.notes[i]
+		if n.lane != lane || n.result != noJudgement {
+			continue
+		}
+		if n.time-now >= badWindow {
+			return noJudgement
+		}
+		n.result = judge(now - n.time)
+		return n.result
+	}
+	return noJudgement
+}
+
+// expire marks every open note that can no longer be hit as a miss and
+// returns how many there were
+func (c *chart) expire(now float64) int {
+	count := 0
+	for i := range c.notes {
+		n := &c.notes[i]
+		if n.result == noJudgement && now-n.time >= badWindow {
+			n.result = miss
+			count++
+		}
+	}
+	return count
+}
+
+type score struct {
+	points   int
+	combo    int
+	maxCombo int
+	counts   [numJudgements]int
+}
+
+func (s *score) record(j judgement) {
+	s.counts[j]++
+	s.points += judgementScores[j]
+	if j == miss {
+		s.combo = 0
+		return
+	}
+	s.combo++
+	if s.combo > s.maxCombo {
+		s.maxCombo = s.combo
+	}
+}
+
+// accuracy is the percentage of the best possible score for the notes
+// judged so far, 100 before any note is judged
+func (s *score) accuracy() float64 {
+	judged := 0
+	for j := perfect; j < numJudgements; j++ {
+		judged += s.counts[j]
+	}
+	if judged == 0 {
+		return 100
+	}
+	return float64(s.points) * 100 / float64(judged*judgementScores[perfect])
+}
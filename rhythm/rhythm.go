@@ -0,0 +1,302 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/sabith-th/games_with_go/config"
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	laneWidth   = 80
+	noteHeight  = 16
+	hitY        = winHeight - 80
+	scrollSpeed = 0.4 // pixels per millisecond
+	leadIn      = 2000
+	endDelay    = 1500
+	// judgementTime is how long the last judgement stays on screen
+	judgementTime = 500
+)
+
+var laneKeys = [numLanes]sdl.Scancode{sdl.SCANCODE_D, sdl.SCANCODE_F, sdl.SCANCODE_J, sdl.SCANCODE_K}
+
+var laneColors = [numLanes]font.Color{{R: 240, G: 80, B: 80}, {R: 80, G: 160, B: 240}, {R: 80, G: 160, B: 240}, {R: 240, G: 80, B: 80}}
+
+var judgementColors = [numJudgements]font.Color{
+	{},
+	{R: 255, G: 220, B: 60},
+	{R: 90, G: 230, B: 110},
+	{R: 200, G: 140, B: 80},
+	{R: 200, G: 40, B: 40},
+}
+
+var (
+	white     = font.Color{R: 255, G: 255, B: 255}
+	laneColor = font.Color{R: 25, G: 25, B: 35}
+	lineColor = font.Color{R: 70, G: 70, B: 90}
+)
+
+func lanesX() int {
+	return (winWidth - numLanes*laneWidth) / 2
+}
+
+func clear(pixels []byte) {
+	for i := range pixels {
+		pixels[i] = 0
+	}
+}
+
+func fillRect(x, y, w, h int, c font.Color, pixels []byte) {
+	for py := y; py < y+h; py++ {
+		if py < 0 || py >= winHeight {
+			continue
+		}
+		for px := x; px < x+w; px++ {
+			if px < 0 || px >= winWidth {
+				continue
+			}
+			index := (py*winWidth + px) * 4
+			pixels[index] = c.R
+			pixels[index+1] = c.G
+			pixels[index+2] = c.B
+		}
+	}
+}
+
+func dim(c font.Color) font.Color {
+	return font.Color{R: c.R / 3, G: c.G / 3, B: c.B / 3}
+}
+
+func drawText(text string, x, y, scale int, c font.Color, pixels []byte) {
+	font.Draw(text, x, y, scale, c, pixels, winWidth, winHeight)
+}
+
+func drawCentered(text string, y, scale int, c font.Color, pixels []byte) {
+	w, _ := font.Size(text, scale)
+	drawText(text, (winWidth-w)/2, y, scale, c, pixels)
+}
+
+// song plays the chart's wav once the chart reaches time 0
+type song struct {
+	wavBytes []byte
+	deviceID sdl.AudioDeviceID
+	playing  bool
+}
+
+func loadSong(filename string) (*song, error) {
+	wavBytes, audioSpec := sdl.LoadWAV(filename)
+	if wavBytes == nil {
+		return nil, fmt.Errorf("could not load %s: %v", filename, sdl.GetError())
+	}
+	deviceID, err := sdl.OpenAudioDevice("", false, audioSpec, nil, 0)
+	if err != nil {
+		sdl.FreeWAV(wavBytes)
+		return nil, err
+	}
+	return &song{wavBytes: wavBytes, deviceID: deviceID}, nil
+}
+
+func (s *song) play() {
+	if s == nil || s.playing {
+		return
+	}
+	sdl.QueueAudio(s.deviceID, s.wavBytes)
+	sdl.PauseAudioDevice(s.deviceID, false)
+	s.playing = true
+}
+
+func (s *song) stop() {
+	if s == nil {
+		return
+	}
+	sdl.PauseAudioDevice(s.deviceID, true)
+	sdl.ClearQueuedAudio(s.deviceID)
+	s.playing = false
+}
+
+func (s *song) free() {
+	if s == nil {
+		return
+	}
+	sdl.CloseAudioDevice(s.deviceID)
+	sdl.FreeWAV(s.wavBytes)
+}
+
+func draw(c *chart, sc *score, now float64, last judgement, lastTime float64, pressed [numLanes]bool, pixels []byte) {
+	clear(pixels)
+	x0 := lanesX()
+	for lane := 0; lane < numLanes; lane++ {
+		x := x0 + lane*laneWidth
+		bg := laneColor
+		if pressed[lane] {
+			bg = dim(laneColors[lane])
+		}
+		fillRect(x, 0, laneWidth, winHeight, bg, pixels)
+		fillRect(x, 0, 1, winHeight, lineColor, pixels)
+	}
+	fillRect(x0+numLanes*laneWidth, 0, 1, winHeight, lineColor, pixels)
+	fillRect(x0, hitY, numLanes*laneWidth, 3, white, pixels)
+
+	for _, n := range c.notes {
+		if n.result != noJudgement {
+			continue
+		}
+		y := hitY - int((n.time-now)*scrollSpeed) - noteHeight/2
+		if y > winHeight || y+noteHeight < 0 {
+			continue
+		}
+		fillRect(x0+n.lane*laneWidth+4, y, laneWidth-8, noteHeight, laneColors[n.lane], pixels)
+	}
+	for lane, key := range []string{"D", "F", "J", "K"} {
+		drawText(key, x0+lane*laneWidth+laneWidth/2-5, hitY+30, 2, white, pixels)
+	}
+
+	if last != noJudgement && now-lastTime < judgementTime {
+		drawCentered(judgementNames[last], hitY-80, 4, judgementColors[last], pixels)
+	}
+	drawText(c.title, 20, 20, 2, white, pixels)
+	drawText(fmt.Sprintf("SCORE %d", sc.points), 20, 60, 2, white, pixels)
+	drawText(fmt.Sprintf("COMBO %d", sc.combo), 20, 90, 2, white, pixels)
+	drawText(fmt.Sprintf("ACC %.2f%%", sc.accuracy()), 20, 120, 2, white, pixels)
+}
+
+func drawResults(sc *score, pixels []byte) {
+	fillRect(winWidth/2-200, 140, 400, 300, font.Color{R: 10, G: 10, B: 15}, pixels)
+	drawCentered("FINISHED", 170, 4, white, pixels)
+	y := 230
+	for j := perfect; j < numJudgements; j++ {
+		drawCentered(fmt.Sprintf("%-8s %4d", judgementNames[j], sc.counts[j]), y, 2, judgementColors[j], pixels)
+		y += 24
+	}
+	drawCentered(fmt.Sprintf("MAX COMBO %d  ACC %.2f%%", sc.maxCombo, sc.accuracy()), y+10, 2, white, pixels)
+	drawCentered("R TO RESTART", y+50, 2, white, pixels)
+}
+
+func main() {
+	chartFile := flag.String("chart", "charts/demo.json", "note chart to play")
+	configFile := flag.String("config", "", "read flags not given on the command line from this JSON file")
+	flag.Parse()
+	if *configFile != "" {
+		err := config.LoadConfig(flag.CommandLine, *configFile)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	c, err := loadChart(*chartFile)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Rhythm", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	var music *song
+	if c.song != "" {
+		music, err = loadSong(c.song)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer music.free()
+	}
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	var sc score
+	last, lastTime := noJudgement, 0.0
+	var pressed [numLanes]bool
+
+	// chart time runs from -leadIn so the first notes scroll in before the
+	// song starts. The song is started on the same clock the notes are
+	// judged against, that is what keeps them in time.
+	startTime := time.Now()
+
+	for {
+		now := float64(time.Since(startTime).Milliseconds()) - leadIn
+		if now >= 0 {
+			music.play()
+		}
+
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				for lane, key := range laneKeys {
+					if e.Keysym.Scancode == key {
+						pressed[lane] = e.Type == sdl.KEYDOWN
+					}
+				}
+				if e.Type != sdl.KEYDOWN || e.Repeat != 0 {
+					continue
+				}
+				for lane, key := range laneKeys {
+					if e.Keysym.Scancode != key {
+						continue
+					}
+					j := c.hit(lane, now)
+					if j != noJudgement {
+						sc.record(j)
+						last, lastTime = j, now
+					}
+				}
+				if e.Keysym.Scancode == sdl.SCANCODE_R {
+					music.stop()
+					c.reset()
+					sc = score{}
+					last = noJudgement
+					startTime = time.Now()
+				}
+			}
+		}
+
+		for i := c.expire(now); i > 0; i-- {
+			sc.record(miss)
+			last, lastTime = miss, now
+		}
+
+		draw(c, &sc, now, last, lastTime, pressed, pixels)
+		if now > c.length()+endDelay {
+			drawResults(&sc, pixels)
+		}
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(1)
+	}
+}
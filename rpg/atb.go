@@ -0,0 +1,69 @@
+package rpg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+const winWidth, winHeight = 800, 600
+
+// actionKeys are the player's choices, A attacks, I uses an item and R
+// runs
+var actionKeys = []struct {
+	sc     gfx.Scancode
+	action Action
+}{
+	{gfx.KeyA, Attack},
+	{gfx.KeyI, Item},
+	{gfx.KeyR, Run},
+}
+
+// battleScreen runs a Battle in a window. Once it is decided the result,
+// after any level up has flashed, stays up until a key is pressed; escape
+// gives up before that.
+type battleScreen struct {
+	battle *Battle
+	done   bool
+}
+
+func (s *battleScreen) Update(in gfx.Input, dt float64) {
+	b := s.battle
+	if in.Pressed(gfx.KeyEscape) {
+		s.done = true
+		return
+	}
+	b.Update(dt)
+	if b.Result != Undecided {
+		for _, e := range in.Keys {
+			s.done = s.done || e.Down && !e.Repeat && b.Since >= flashTime
+		}
+		return
+	}
+	for _, k := range actionKeys {
+		if in.Pressed(k.sc) && b.Choose(k.action) {
+			break
+		}
+	}
+}
+
+func (s *battleScreen) Draw(pixels []byte, w, h int) {
+	s.battle.Draw(pixels, w, h)
+}
+
+func (s *battleScreen) Done() bool {
+	return s.done
+}
+
+// ATBBattle fights player against enemy in a window of its own and returns
+// how it ended, Undecided if the window was closed or escape pressed
+// first. Both keep the hit points they end with.
+func ATBBattle(player, enemy *Entity) BattleResult {
+	s := &battleScreen{battle: NewBattle(player, enemy, time.Now().UnixNano())}
+	err := gfx.Run(player.Name+" vs "+enemy.Name, winWidth, winHeight, s)
+	if err != nil {
+		fmt.Println(err)
+	}
+	return s.battle.Result
+}
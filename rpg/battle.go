@@ -0,0 +1,226 @@
+package rpg
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Action is what an entity does when its gauge is full
+type Action int
+
+const (
+	// Attack hits for the attack less half the target's defense
+	Attack Action = iota
+	// Pierce goes through the target's defense for two thirds of the
+	// attack, it is only the enemy's
+	Pierce
+	// Item drinks a potion, healing a third of the max hit points
+	Item
+	// Run tries to get away, the faster the player is than the enemy
+	// the likelier it works
+	Run
+	// Fireball burns for the whole attack whatever the defense, it is
+	// only the enemy's
+	Fireball
+)
+
+var actionNames = [...]string{"attack", "pierce", "item", "run", "fireball"}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+// BattleResult is how a battle ended
+type BattleResult int
+
+const (
+	// Undecided is a battle still going, or given up on
+	Undecided BattleResult = iota
+	Won
+	Lost
+	Fled
+)
+
+const (
+	// GaugeFull is how far a gauge fills before its entity acts, it fills
+	// by Speed*GaugeRate every second
+	GaugeFull = 100
+	GaugeRate = 10
+	// Potions is how many items the player has in each battle
+	Potions = 3
+)
+
+// Damage is how many hit points a does to target when from does it, at
+// least 1 for an attack and 0 for anything else
+func Damage(a Action, from, target *Entity) int {
+	var d int
+	switch a {
+	case Attack:
+		d = from.Attack - target.Defense/2
+	case Pierce:
+		d = from.Attack * 2 / 3
+	case Fireball:
+		d = from.Attack
+	default:
+		return 0
+	}
+	if d < 1 {
+		d = 1
+	}
+	return d
+}
+
+// Battle is an Active Time Battle between the player and one enemy. Both
+// have a gauge filling at a rate proportional to their speed and act when
+// it is full, emptying it. Time stands still while the player chooses
+// what to do; the enemy does whatever does the most damage.
+type Battle struct {
+	Player, Enemy *Entity
+	// PlayerGauge and EnemyGauge fill from 0 to GaugeFull
+	PlayerGauge, EnemyGauge float64
+	// Potions are what is left of the player's items
+	Potions int
+	Result  BattleResult
+	// Log says what happened, oldest first
+	Log []string
+	// a won battle gives the player XP, which can take them up Levels
+	// levels from the stats they had Before
+	XP     int
+	Levels int
+	Before Entity
+	// Since is how many seconds ago the battle was decided
+	Since float64
+
+	rng *rand.Rand
+}
+
+// NewBattle starts a battle between player and enemy with both gauges
+// empty, seed decides whether running works
+func NewBattle(player, enemy *Entity, seed int64) *Battle {
+	b := &Battle{
+		Player:  player,
+		Enemy:   enemy,
+		Potions: Potions,
+		rng:     rand.New(rand.NewSource(seed)),
+	}
+	b.logf("%s appears!", enemy.Name)
+	return b
+}
+
+func (b *Battle) logf(format string, a ...interface{}) {
+	b.Log = append(b.Log, fmt.Sprintf(format, a...))
+}
+
+// Ready reports whether the battle is waiting for the player to Choose
+func (b *Battle) Ready() bool {
+	return b.Result == Undecided && b.PlayerGauge >= GaugeFull
+}
+
+// Update fills the gauges for dt seconds, the enemy acting if its gauge
+// fills. Nothing moves while the player is Ready, and once the battle is
+// decided only Since counts on.
+func (b *Battle) Update(dt float64) {
+	if b.Result != Undecided {
+		b.Since += dt
+		return
+	}
+	if b.Ready() {
+		return
+	}
+	b.PlayerGauge = fill(b.PlayerGauge, b.Player.Speed, dt)
+	b.EnemyGauge = fill(b.EnemyGauge, b.Enemy.Speed, dt)
+	if b.EnemyGauge >= GaugeFull {
+		b.EnemyGauge = 0
+		b.act(b.Enemy, b.Player, b.enemyAction())
+	}
+}
+
+// fill is gauge after dt seconds at speed, no further than GaugeFull
+func fill(gauge float64, speed int, dt float64) float64 {
+	gauge += float64(speed) * GaugeRate * dt
+	if gauge > GaugeFull {
+		gauge = GaugeFull
+	}
+	return gauge
+}
+
+// enemyAction is whichever of Attack and the enemy's abilities does the
+// most damage to the player, the first of them on a tie
+func (b *Battle) enemyAction() Action {
+	best, most := Attack, Damage(Attack, b.Enemy, b.Player)
+	for _, a := range b.Enemy.Abilities {
+		if d := Damage(a, b.Enemy, b.Player); d > most {
+			best, most = a, d
+		}
+	}
+	return best
+}
+
+// Choose makes the player do a. It reports whether the player acted, not
+// if they are not Ready or a is an item when there are none left.
+func (b *Battle) Choose(a Action) bool {
+	if !b.Ready() {
+		return false
+	}
+	switch a {
+	case Item:
+		if b.Potions == 0 {
+			b.logf("no potions left")
+			return false
+		}
+	case Attack, Run:
+	default:
+		return false
+	}
+	b.PlayerGauge = 0
+	b.act(b.Player, b.Enemy, a)
+	return true
+}
+
+// act makes from do a to target
+func (b *Battle) act(from, target *Entity, a Action) {
+	switch a {
+	case Attack, Pierce, Fireball:
+		d := Damage(a, from, target)
+		target.hurt(d)
+		verb := "attacks"
+		switch a {
+		case Pierce:
+			verb = "pierces"
+		case Fireball:
+			verb = "burns"
+		}
+		b.logf("%s %s %s for %d", from.Name, verb, target.Name, d)
+		if target.Alive() {
+			return
+		}
+		b.logf("%s is defeated", target.Name)
+		b.Result = Lost
+		if target == b.Enemy {
+			b.win()
+		}
+	case Item:
+		b.Potions--
+		b.logf("%s drinks a potion, +%d hp", from.Name, from.heal(from.MaxHP/3))
+	case Run:
+		chance := float64(b.Player.Speed) / float64(b.Player.Speed+b.Enemy.Speed)
+		if b.rng.Float64() < chance {
+			b.logf("%s got away", from.Name)
+			b.Result = Fled
+			return
+		}
+		b.logf("%s could not get away", from.Name)
+	}
+}
+
+// win ends the battle won, giving the player XP for the enemy
+func (b *Battle) win() {
+	b.Result = Won
+	b.Before = *b.Player
+	b.XP = XPReward(b.Enemy)
+	b.logf("%s gains %d xp", b.Player.Name, b.XP)
+	b.Levels = GainXP(b.Player, b.XP, b.rng)
+	if b.Levels > 0 {
+		b.logf("%s reaches level %d!", b.Player.Name, b.Player.Level)
+	}
+}
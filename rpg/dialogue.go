@@ -0,0 +1,279 @@
+package rpg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+// MaxResponses is how many responses a node can have, one for each of the
+// keys 1 to 4
+const MaxResponses = 4
+
+// DialogueResponse is one thing the player can say back, leading to the
+// node NextNodeID, or out of the conversation if it is empty
+type DialogueResponse struct {
+	Text       string `json:"text"`
+	NextNodeID string `json:"next,omitempty"`
+}
+
+// DialogueNode is one thing an NPC says. OnEnter, if there is one, is run
+// every time the conversation comes to the node; in a dialogue file it is
+// named by Effect and looked up when the file is loaded.
+type DialogueNode struct {
+	Speaker   string             `json:"speaker,omitempty"`
+	Text      string             `json:"text"`
+	Responses []DialogueResponse `json:"responses,omitempty"`
+	Effect    string             `json:"effect,omitempty"`
+	OnEnter   func()             `json:"-"`
+}
+
+// LoadDialogue reads a dialogue file, a JSON object of nodes by id, and
+// hooks each node's effect up to the function of that name in effects.
+// Every response has to lead to a node in the file or nowhere.
+func LoadDialogue(path string, effects map[string]func()) (map[string]*DialogueNode, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var nodes map[string]*DialogueNode
+	err = json.Unmarshal(data, &nodes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for id, n := range nodes {
+		if n == nil {
+			return nil, fmt.Errorf("%s: node %q is empty", path, id)
+		}
+		if len(n.Responses) > MaxResponses {
+			return nil, fmt.Errorf("%s: node %q has %d responses, at most %d fit", path, id, len(n.Responses), MaxResponses)
+		}
+		for i, r := range n.Responses {
+			if r.NextNodeID != "" && nodes[r.NextNodeID] == nil {
+				return nil, fmt.Errorf("%s: node %q response %d leads to unknown node %q", path, id, i+1, r.NextNodeID)
+			}
+		}
+		if n.Effect != "" {
+			n.OnEnter = effects[n.Effect]
+			if n.OnEnter == nil {
+				return nil, fmt.Errorf("%s: node %q has unknown effect %q", path, id, n.Effect)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+var (
+	boxColor      = font.Color{R: 230, G: 230, B: 240}
+	responseColor = font.Color{R: 160, G: 200, B: 255}
+	npcColor      = font.Color{R: 120, G: 180, B: 110}
+)
+
+const (
+	// the text box fills the bottom boxHeight rows bar a margin, with the
+	// node's text in the top and its responses below
+	boxHeight   = 260
+	boxMargin   = 20
+	boxPadding  = 12
+	textScale   = 2
+	lineSpacing = 4
+	npcSize     = 120
+	arrowSize   = 12
+)
+
+// textLine is how far apart lines of dialogue are
+const textLine = font.GlyphHeight*textScale + lineSpacing
+
+// DialogueSystem runs conversations through a tree of nodes. Keys 1 to 4
+// pick a response and the arrow keys scroll text too long for the box.
+type DialogueSystem struct {
+	Nodes map[string]*DialogueNode
+	// Done is set once a conversation has run out of nodes
+	Done bool
+
+	current *DialogueNode
+	lines   []string
+	scroll  int
+}
+
+// StartConversation goes to the node rootNodeID, running its OnEnter
+func (d *DialogueSystem) StartConversation(rootNodeID string) error {
+	n := d.Nodes[rootNodeID]
+	if n == nil {
+		return fmt.Errorf("no dialogue node %q", rootNodeID)
+	}
+	d.Done = false
+	d.enter(n)
+	return nil
+}
+
+func (d *DialogueSystem) enter(n *DialogueNode) {
+	d.current = n
+	d.lines = font.Wrap(n.Text, winWidth-2*(boxMargin+boxPadding), textScale)
+	d.scroll = 0
+	if n.OnEnter != nil {
+		n.OnEnter()
+	}
+}
+
+// Respond picks response i, counting from 0, and reports whether there was
+// one. A node without responses takes response 0 as leaving.
+func (d *DialogueSystem) Respond(i int) bool {
+	if d.current == nil || d.Done {
+		return false
+	}
+	rs := d.current.Responses
+	if len(rs) == 0 && i == 0 {
+		d.Done = true
+		return true
+	}
+	if i < 0 || i >= len(rs) {
+		return false
+	}
+	next := d.Nodes[rs[i].NextNodeID]
+	if next == nil {
+		d.Done = true
+		return true
+	}
+	d.enter(next)
+	return true
+}
+
+// responseKeys pick responses 1 to 4
+var responseKeys = [MaxResponses]gfx.Scancode{gfx.Key1, gfx.Key2, gfx.Key3, gfx.Key4}
+
+// Update responds to the keys pressed this frame
+func (d *DialogueSystem) Update(in gfx.Input) {
+	for i, sc := range responseKeys {
+		if in.Pressed(sc) {
+			d.Respond(i)
+			return
+		}
+	}
+	switch {
+	case in.Pressed(gfx.KeyUp):
+		d.scroll--
+	case in.Pressed(gfx.KeyDown):
+		d.scroll++
+	}
+	d.scroll = clampInt(d.scroll, 0, d.maxScroll())
+}
+
+// textRows is how many lines of text fit above the responses
+func (d *DialogueSystem) textRows() int {
+	n := len(d.current.Responses)
+	if n == 0 {
+		n = 1
+	}
+	return (boxHeight - boxMargin - 3*boxPadding - n*textLine) / textLine
+}
+
+func (d *DialogueSystem) maxScroll() int {
+	if d.current == nil {
+		return 0
+	}
+	return clampInt(len(d.lines)-d.textRows(), 0, len(d.lines))
+}
+
+// Present draws the conversation into a winWidth by winHeight pixel
+// buffer: the NPC above a text box along the bottom, holding as much of
+// the text as fits and the numbered responses
+func (d *DialogueSystem) Present(pixels []byte) {
+	w, h := winWidth, winHeight
+	fillRect(0, 0, w, h, background, pixels, w, h)
+	if d.current == nil {
+		return
+	}
+	n := d.current
+	top := h - boxHeight
+
+	nx, ny := boxMargin, top-npcSize-boxMargin
+	fillRect(nx, ny, npcSize, npcSize, npcColor, pixels, w, h)
+	if n.Speaker != "" {
+		font.Draw(n.Speaker, nx+npcSize+20, ny+npcSize-font.GlyphHeight*3, 3, white, pixels, w, h)
+	}
+
+	fillRect(boxMargin-2, top-2, w-2*boxMargin+4, boxHeight-boxMargin+4, boxColor, pixels, w, h)
+	fillRect(boxMargin, top, w-2*boxMargin, boxHeight-boxMargin, logColor, pixels, w, h)
+	x, y := boxMargin+boxPadding, top+boxPadding
+	rows := d.textRows()
+	end := clampInt(d.scroll+rows, 0, len(d.lines))
+	for i, line := range d.lines[d.scroll:end] {
+		font.Draw(line, x, y+i*textLine, textScale, white, pixels, w, h)
+	}
+	// arrows at the right edge show there is more text above or below
+	ax := w - boxMargin - boxPadding - arrowSize
+	if d.scroll > 0 {
+		arrow(ax, y, true, pixels, w, h)
+	}
+	if end < len(d.lines) {
+		arrow(ax, y+(rows-1)*textLine, false, pixels, w, h)
+	}
+
+	y += rows*textLine + boxPadding
+	if len(n.Responses) == 0 {
+		font.Draw("1. (leave)", x, y, textScale, responseColor, pixels, w, h)
+	}
+	for i, r := range n.Responses {
+		font.Draw(fmt.Sprintf("%d. %s", i+1, r.Text), x, y+i*textLine, textScale, responseColor, pixels, w, h)
+	}
+}
+
+// arrow draws a triangle arrowSize wide pointing up or down with its top
+// left corner at x, y
+func arrow(x, y int, up bool, pixels []byte, w, h int) {
+	rows := arrowSize / 2
+	for i := 0; i < rows; i++ {
+		half := i
+		if !up {
+			half = rows - 1 - i
+		}
+		fillRect(x+rows-1-half, y+i, 2*half+2, 1, readyColor, pixels, w, h)
+	}
+}
+
+// dialogueScreen runs a conversation in a window until it ends or escape
+// is pressed
+type dialogueScreen struct {
+	d    *DialogueSystem
+	quit bool
+}
+
+func (s *dialogueScreen) Update(in gfx.Input, dt float64) {
+	if in.Pressed(gfx.KeyEscape) {
+		s.quit = true
+		return
+	}
+	s.d.Update(in)
+}
+
+func (s *dialogueScreen) Draw(pixels []byte, w, h int) {
+	s.d.Present(pixels)
+}
+
+func (s *dialogueScreen) Done() bool {
+	return s.quit || s.d.Done
+}
+
+// Converse runs d from the node rootNodeID in a window of its own titled
+// title, returning once the conversation is over or the window closed
+func Converse(title string, d *DialogueSystem, rootNodeID string) error {
+	err := d.StartConversation(rootNodeID)
+	if err != nil {
+		return err
+	}
+	return gfx.Run(title, winWidth, winHeight, &dialogueScreen{d: d})
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
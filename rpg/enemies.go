@@ -0,0 +1,110 @@
+package rpg
+
+import (
+	"fmt"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// Archetype is a kind of enemy, each with stats of its own shape
+type Archetype int
+
+const (
+	Warrior Archetype = iota
+	Mage
+	Rogue
+	NumArchetypes
+)
+
+var archetypeNames = [NumArchetypes]string{"Warrior", "Mage", "Rogue"}
+
+func (a Archetype) String() string {
+	return archetypeNames[a]
+}
+
+// statRange is what a stat can be at depth 0, noise picks where in it an
+// enemy is
+type statRange struct {
+	min, max float32
+}
+
+// profile is an archetype's stat ranges, the abilities it can have and
+// where in the noise its enemies are
+type profile struct {
+	hp, attack, defense, speed statRange
+	abilities                  []Action
+	offset                     float32
+}
+
+// profiles keep warriors tough, mages hard hitting but frail and rogues
+// fast. The offsets are far enough apart that no two archetypes sample
+// the same noise.
+var profiles = [NumArchetypes]profile{
+	Warrior: {statRange{30, 50}, statRange{8, 12}, statRange{6, 10}, statRange{5, 9}, []Action{Pierce}, 0},
+	Mage:    {statRange{16, 28}, statRange{10, 16}, statRange{1, 4}, statRange{7, 11}, []Action{Fireball}, 1000},
+	Rogue:   {statRange{20, 34}, statRange{7, 11}, statRange{3, 6}, statRange{11, 16}, []Action{Pierce, Fireball}, 2000},
+}
+
+const (
+	// variantSpacing is how far apart in x the variants of an archetype
+	// are, enough for their stats to have little to do with each other.
+	// depthSpacing is how far in y a level of the dungeon is, close
+	// enough that a variant changes smoothly on the way down.
+	variantSpacing = 3.7
+	depthSpacing   = 0.15
+	// statSpacing keeps the stats of an enemy on noise of their own
+	statSpacing = 97.3
+	// depthGrowth is how much stronger every level down makes the stats,
+	// as a fraction of what they are at depth 0. It is what makes enemies
+	// tougher the deeper they are, on average, the noise only varies them.
+	depthGrowth = 0.15
+	// abilityChance is the chance of an enemy at depth 0 having each
+	// ability of its archetype, abilityGrowth how much more it is every
+	// level down
+	abilityChance = 0.3
+	abilityGrowth = 0.1
+)
+
+// unit is snoise2 at x, y mapped from -1..1 to 0..1
+func unit(x, y float32) float32 {
+	n := noise.Simplex{}.At(x, y)
+	if n < -1 {
+		n = -1
+	} else if n > 1 {
+		n = 1
+	}
+	return (n + 1) / 2
+}
+
+// GenerateEnemy is variant variant of archetype a at depth depth of the
+// dungeon. Every stat is snoise2 at the enemy's variant and depth, scaled
+// from -1..1 into the archetype's range and grown by depthGrowth a level.
+// The same arguments always make the same enemy, and one variant changes
+// smoothly with depth.
+func GenerateEnemy(a Archetype, variant, depth int) Entity {
+	p := &profiles[a]
+	x := p.offset + float32(variant)*variantSpacing
+	y := float32(depth) * depthSpacing
+	grow := 1 + depthGrowth*float32(depth)
+	stat := func(i int, r statRange) int {
+		u := unit(x+float32(i)*statSpacing, y)
+		v := (r.min + u*(r.max-r.min)) * grow
+		return int(v + 0.5)
+	}
+	e := Entity{
+		Name:    fmt.Sprint(a),
+		MaxHP:   stat(0, p.hp),
+		Attack:  stat(1, p.attack),
+		Defense: stat(2, p.defense),
+		Speed:   stat(3, p.speed),
+		Level:   1 + depth,
+	}
+	e.HP = e.MaxHP
+	chance := abilityChance + abilityGrowth*float32(depth)
+	for i, ability := range p.abilities {
+		if unit(x+float32(4+i)*statSpacing, y) < chance {
+			e.Abilities = append(e.Abilities, ability)
+		}
+	}
+	return e
+}
@@ -0,0 +1,95 @@
+package rpg
+
+import "testing"
+
+// variants is how many enemies of each archetype the simulation averages
+// at every depth
+const variants = 400
+
+func TestGenerateEnemyDeterministic(t *testing.T) {
+	a, b := GenerateEnemy(Rogue, 17, 5), GenerateEnemy(Rogue, 17, 5)
+	if a.MaxHP != b.MaxHP || a.Attack != b.Attack || a.Defense != b.Defense || a.Speed != b.Speed || len(a.Abilities) != len(b.Abilities) {
+		t.Errorf("the same enemy came out as %+v and %+v", a, b)
+	}
+	if a.HP != a.MaxHP || a.Level != 6 || a.Name != "Rogue" {
+		t.Errorf("enemy %+v is not a fresh level 6 rogue", a)
+	}
+}
+
+func TestGenerateEnemyInRange(t *testing.T) {
+	for a := Archetype(0); a < NumArchetypes; a++ {
+		p := profiles[a]
+		for v := 0; v < variants; v++ {
+			e := GenerateEnemy(a, v, 0)
+			for _, s := range []struct {
+				name string
+				v    int
+				r    statRange
+			}{
+				{"hp", e.MaxHP, p.hp},
+				{"attack", e.Attack, p.attack},
+				{"defense", e.Defense, p.defense},
+				{"speed", e.Speed, p.speed},
+			} {
+				if float32(s.v) < s.r.min-0.5 || float32(s.v) > s.r.max+0.5 {
+					t.Fatalf("%v variant %d has %s %d outside %v", a, v, s.name, s.v, s.r)
+				}
+			}
+		}
+	}
+}
+
+// TestStatsGrowWithDepth simulates many enemies of every archetype at each
+// depth and checks every stat goes up on average from one depth to the next
+func TestStatsGrowWithDepth(t *testing.T) {
+	const depths = 20
+	for a := Archetype(0); a < NumArchetypes; a++ {
+		var last [4]float64
+		var lastAbilities float64
+		for d := 0; d < depths; d++ {
+			var sum [4]float64
+			abilities := 0.0
+			for v := 0; v < variants; v++ {
+				e := GenerateEnemy(a, v, d)
+				for i, s := range []int{e.MaxHP, e.Attack, e.Defense, e.Speed} {
+					sum[i] += float64(s)
+				}
+				abilities += float64(len(e.Abilities))
+			}
+			for i := range sum {
+				mean := sum[i] / variants
+				if d > 0 && mean <= last[i] {
+					t.Errorf("%v stat %d averages %.2f at depth %d, down from %.2f", a, i, mean, d, last[i])
+				}
+				last[i] = mean
+			}
+			abilities /= variants
+			if d > 0 && abilities < lastAbilities {
+				t.Errorf("%v has %.2f abilities on average at depth %d, down from %.2f", a, abilities, d, lastAbilities)
+			}
+			lastAbilities = abilities
+		}
+	}
+}
+
+func TestArchetypesDiffer(t *testing.T) {
+	// warriors are the toughest, mages hit hardest and rogues are fastest
+	var hp, attack, speed [NumArchetypes]int
+	for a := Archetype(0); a < NumArchetypes; a++ {
+		for v := 0; v < variants; v++ {
+			e := GenerateEnemy(a, v, 3)
+			hp[a] += e.MaxHP
+			attack[a] += e.Attack
+			speed[a] += e.Speed
+		}
+	}
+	if hp[Warrior] <= hp[Mage] || hp[Warrior] <= hp[Rogue] {
+		t.Errorf("warriors have %d hp in all, mages %d and rogues %d", hp[Warrior], hp[Mage], hp[Rogue])
+	}
+	if attack[Mage] <= attack[Warrior] || attack[Mage] <= attack[Rogue] {
+		t.Errorf("mages attack for %d in all, warriors %d and rogues %d", attack[Mage], attack[Warrior], attack[Rogue])
+	}
+	if speed[Rogue] <= speed[Warrior] || speed[Rogue] <= speed[Mage] {
+		t.Errorf("rogues have %d speed in all, warriors %d and mages %d", speed[Rogue], speed[Warrior], speed[Mage])
+	}
+}
@@ -0,0 +1,40 @@
+package rpg
+
+// Entity is anything that fights, the player or an enemy
+type Entity struct {
+	Name    string `json:"name"`
+	HP      int    `json:"hp"`
+	MaxHP   int    `json:"max_hp"`
+	Attack  int    `json:"attack"`
+	Defense int    `json:"defense"`
+	// Speed is how fast the entity's gauge fills in a battle
+	Speed int `json:"speed"`
+	// Level starts at 1, XP is what has been earned towards the next
+	Level int `json:"level"`
+	XP    int `json:"xp"`
+	// Abilities are what an enemy can do besides Attack
+	Abilities []Action `json:"abilities,omitempty"`
+}
+
+// Alive reports whether e has hit points left
+func (e *Entity) Alive() bool {
+	return e.HP > 0
+}
+
+// heal adds up to hp hit points, no further than MaxHP, and returns how
+// many it added
+func (e *Entity) heal(hp int) int {
+	if e.HP+hp > e.MaxHP {
+		hp = e.MaxHP - e.HP
+	}
+	e.HP += hp
+	return hp
+}
+
+// hurt takes hp hit points, no further than 0
+func (e *Entity) hurt(hp int) {
+	e.HP -= hp
+	if e.HP < 0 {
+		e.HP = 0
+	}
+}
@@ -0,0 +1,44 @@
+package rpg
+
+import "math/rand"
+
+// growth is what every stat gains on a level up, and variance how much
+// more at most it can gain by chance
+var (
+	growth   = Entity{MaxHP: 10, Attack: 2, Defense: 2, Speed: 1}
+	variance = Entity{MaxHP: 4, Attack: 1, Defense: 1, Speed: 1}
+)
+
+// XPReward is the XP for beating enemy, more for tougher ones
+func XPReward(enemy *Entity) int {
+	return enemy.Level*20 + enemy.MaxHP/2
+}
+
+// XPToLevel is how much XP takes an entity at level to the next
+func XPToLevel(level int) int {
+	return level * 100
+}
+
+// GainXP gives e xp, levelling it up for as many thresholds as that
+// crosses, and returns how many levels it gained. Each level adds growth
+// to the stats and up to variance more, drawn from rng. The hit points go
+// up as much as the max does.
+func GainXP(e *Entity, xp int, rng *rand.Rand) int {
+	if e.Level < 1 {
+		e.Level = 1
+	}
+	e.XP += xp
+	levels := 0
+	for e.XP >= XPToLevel(e.Level) {
+		e.XP -= XPToLevel(e.Level)
+		e.Level++
+		levels++
+		hp := growth.MaxHP + rng.Intn(variance.MaxHP+1)
+		e.MaxHP += hp
+		e.HP += hp
+		e.Attack += growth.Attack + rng.Intn(variance.Attack+1)
+		e.Defense += growth.Defense + rng.Intn(variance.Defense+1)
+		e.Speed += growth.Speed + rng.Intn(variance.Speed+1)
+	}
+	return levels
+}
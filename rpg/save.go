@@ -0,0 +1,39 @@
+package rpg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SavePlayer writes e to path as JSON, so its stats carry over to the next
+// run
+func SavePlayer(path string, e *Entity) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadPlayer reads an entity written by SavePlayer
+func LoadPlayer(path string) (*Entity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var e Entity
+	err = json.Unmarshal(data, &e)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if e.MaxHP < 1 || e.Level < 1 {
+		return nil, fmt.Errorf("%s: not a player", path)
+	}
+	return &e, nil
+}
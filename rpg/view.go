@@ -0,0 +1,153 @@
+package rpg
+
+import (
+	"fmt"
+
+	"github.com/sabith-th/games_with_go/font"
+)
+
+var (
+	background  = font.Color{R: 20, G: 20, B: 35}
+	white       = font.Color{R: 255, G: 255, B: 255}
+	barEmpty    = font.Color{R: 60, G: 60, B: 70}
+	gaugeColor  = font.Color{R: 80, G: 160, B: 244}
+	readyColor  = font.Color{R: 250, G: 220, B: 90}
+	logColor    = font.Color{R: 10, G: 10, B: 15}
+	playerColor = font.Color{R: 70, G: 110, B: 220}
+	enemyColor  = font.Color{R: 200, G: 60, B: 60}
+	// hpColors are the hit point bar over half, over a quarter and below
+	hpColors = [3]font.Color{{R: 70, G: 200, B: 90}, {R: 230, G: 200, B: 60}, {R: 220, G: 60, B: 50}}
+)
+
+const (
+	barWidth, barHeight = 300, 20
+	gaugeHeight         = 8
+	spriteSize          = 96
+	// the log fills the bottom logHeight rows, showing as many of the
+	// newest lines as fit
+	logHeight = 180
+	logScale  = 2
+	logMargin = 20
+	// a level up flashes the player's sprite white for flashTime seconds,
+	// every other flashPeriod, then compares the stats
+	flashTime   = 1.2
+	flashPeriod = 0.1
+	statsWidth  = 420
+	statsHeight = 200
+)
+
+func fillRect(x, y, w, h int, c font.Color, pixels []byte, pw, ph int) {
+	for py := y; py < y+h; py++ {
+		if py < 0 || py >= ph {
+			continue
+		}
+		for px := x; px < x+w; px++ {
+			if px < 0 || px >= pw {
+				continue
+			}
+			i := (py*pw + px) * 4
+			pixels[i], pixels[i+1], pixels[i+2] = c.R, c.G, c.B
+		}
+	}
+}
+
+// bar draws a bar width pixels wide filled value/max of the way
+func bar(x, y, width, height, value, max int, c font.Color, pixels []byte, pw, ph int) {
+	fillRect(x, y, width, height, barEmpty, pixels, pw, ph)
+	if max > 0 && value > 0 {
+		fillRect(x, y, width*value/max, height, c, pixels, pw, ph)
+	}
+}
+
+// drawEntity draws e's sprite, a block of c, at x, y with its name, hit
+// points and gauge beside it
+func drawEntity(e *Entity, gauge float64, x, y int, c font.Color, pixels []byte, w, h int) {
+	fillRect(x, y, spriteSize, spriteSize, c, pixels, w, h)
+	tx := x + spriteSize + 20
+	font.Draw(e.Name, tx, y, 3, white, pixels, w, h)
+	hpColor := hpColors[2]
+	switch {
+	case e.HP*2 > e.MaxHP:
+		hpColor = hpColors[0]
+	case e.HP*4 > e.MaxHP:
+		hpColor = hpColors[1]
+	}
+	bar(tx, y+32, barWidth, barHeight, e.HP, e.MaxHP, hpColor, pixels, w, h)
+	font.Draw(fmt.Sprintf("HP %d/%d", e.HP, e.MaxHP), tx+barWidth+10, y+35, 2, white, pixels, w, h)
+	gc := gaugeColor
+	if gauge >= GaugeFull {
+		gc = readyColor
+	}
+	bar(tx, y+32+barHeight+6, barWidth, gaugeHeight, int(gauge), GaugeFull, gc, pixels, w, h)
+}
+
+// Draw draws the battle screen into a pixel buffer w pixels wide and h
+// high: the enemy at the top, the player below with the actions when they
+// are Ready, and the log along the bottom scrolled to its newest lines
+func (b *Battle) Draw(pixels []byte, w, h int) {
+	fillRect(0, 0, w, h, background, pixels, w, h)
+	drawEntity(b.Enemy, b.EnemyGauge, 40, 30, enemyColor, pixels, w, h)
+	pc := playerColor
+	if b.Levels > 0 && b.Since < flashTime && int(b.Since/flashPeriod)%2 == 0 {
+		pc = white
+	}
+	drawEntity(b.Player, b.PlayerGauge, 40, 170, pc, pixels, w, h)
+
+	var prompt string
+	switch b.Result {
+	case Won:
+		prompt = "victory!"
+	case Lost:
+		prompt = "defeat"
+	case Fled:
+		prompt = "escaped"
+	default:
+		if b.Ready() {
+			prompt = fmt.Sprintf("A: attack  I: item (%d)  R: run", b.Potions)
+		}
+	}
+	font.Draw(prompt, 40, 300, 3, readyColor, pixels, w, h)
+
+	top := h - logHeight
+	fillRect(logMargin, top, w-2*logMargin, logHeight-logMargin, logColor, pixels, w, h)
+	// entries too long for the box go on over as many lines as they need
+	var lines []string
+	for _, entry := range b.Log {
+		lines = append(lines, font.Wrap(entry, w-2*logMargin-2*8, logScale)...)
+	}
+	lineHeight := (font.GlyphHeight + 1) * logScale
+	rows := (logHeight - logMargin - 2*8) / lineHeight
+	first := len(lines) - rows
+	if first < 0 {
+		first = 0
+	}
+	for i, line := range lines[first:] {
+		font.Draw(line, logMargin+8, top+8+i*lineHeight, logScale, white, pixels, w, h)
+	}
+
+	if b.Levels > 0 && b.Since >= flashTime {
+		drawLevelUp(&b.Before, b.Player, pixels, w, h)
+	}
+}
+
+// drawLevelUp puts the stats before and after a level up side by side in
+// the middle of the screen
+func drawLevelUp(before, after *Entity, pixels []byte, w, h int) {
+	x, y := (w-statsWidth)/2, (h-statsHeight)/2
+	fillRect(x-4, y-4, statsWidth+8, statsHeight+8, readyColor, pixels, w, h)
+	fillRect(x, y, statsWidth, statsHeight, logColor, pixels, w, h)
+	font.Draw(fmt.Sprintf("level %d -> %d", before.Level, after.Level), x+20, y+16, 3, readyColor, pixels, w, h)
+	stats := []struct {
+		name          string
+		before, after int
+	}{
+		{"max hp", before.MaxHP, after.MaxHP},
+		{"attack", before.Attack, after.Attack},
+		{"defense", before.Defense, after.Defense},
+		{"speed", before.Speed, after.Speed},
+	}
+	for i, s := range stats {
+		line := fmt.Sprintf("%-8s %4d -> %4d", s.name, s.before, s.after)
+		font.Draw(line, x+20, y+60+i*30, 3, white, pixels, w, h)
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/sabith-th/games_with_go/postfx"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
@@ -53,9 +55,32 @@ func main() {
 		}
 	}
 
-	tex.Update(nil, pixels, winWidth*4)
-	renderer.Copy(tex, nil, nil)
-	renderer.Present()
+	// the picture never changes, so bloom a copy once and let B switch
+	// between the two
+	bloomPixels := append([]byte(nil), pixels...)
+	var bloomPass postfx.Bloom
+	bloomPass.Apply(bloomPixels, winWidth, winHeight, 160, 1.0)
+	bloom := false
 
-	sdl.Delay(10000)
+	for end := time.Now().Add(10 * time.Second); time.Now().Before(end); {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == sdl.SCANCODE_B {
+					bloom = !bloom
+				}
+			}
+		}
+
+		if bloom {
+			tex.Update(nil, bloomPixels, winWidth*4)
+		} else {
+			tex.Update(nil, pixels, winWidth*4)
+		}
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"time"
+
+	"github.com/sabith-th/games_with_go/expr"
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// rowScheduler hands out the rows of a pass a few at a time, as many per
+// step as fit in budget. The cost of a row is measured as it goes, so the
+// number of rows follows the parameters without being told about them.
+type rowScheduler struct {
+	budget time.Duration
+	rows   int
+	next   int
+	// rowCost is a running average of the last rows' cost, zero until the
+	// first row is timed
+	rowCost time.Duration
+	clock   func() time.Time
+}
+
+func newRowScheduler(budget time.Duration, rows int) *rowScheduler {
+	return &rowScheduler{budget: budget, rows: rows, next: rows, clock: time.Now}
+}
+
+// restart begins a new pass from the first row, dropping what is left of
+// the current one
+func (s *rowScheduler) restart() {
+	s.next = 0
+}
+
+func (s *rowScheduler) done() bool {
+	return s.next >= s.rows
+}
+
+// step calls row for the next rows of the pass until another one would
+// take it past budget, always at least one, and returns the rows it did.
+// The next step carries on from there.
+func (s *rowScheduler) step(row func(y int)) rowRange {
+	start := s.next
+	begin := s.clock()
+	rowStart := begin
+	for s.next < s.rows {
+		row(s.next)
+		s.next++
+		rowEnd := s.clock()
+		cost := rowEnd.Sub(rowStart)
+		if s.rowCost == 0 {
+			s.rowCost = cost
+		} else {
+			s.rowCost = (3*s.rowCost + cost) / 4
+		}
+		if rowEnd.Sub(begin)+s.rowCost > s.budget {
+			break
+		}
+		rowStart = rowEnd
+	}
+	return rowRange{start, s.next}
+}
+
+// amortizedField generates a field on the calling goroutine over several
+// frames. Rows are evaluated into raw and shown as they are done, colored
+// with the range of the last finished pass since the range of this one is
+// not known until its last row. Once the pass is complete the whole field
+// is rescaled with its own range.
+type amortizedField struct {
+	sched    *rowScheduler
+	w, h     int
+	p        preset
+	wide     bool
+	formula  expr.Node
+	src      noise.Source
+	raw      []float32
+	pass     bandRange
+	last     bandRange
+	haveLast bool
+	generate time.Duration
+}
+
+// newAmortizedField fills w*h fields budget at a time each frame, with
+// formula instead of the preset's noise if it is not nil
+func newAmortizedField(budget time.Duration, w, h int, formula expr.Node) *amortizedField {
+	return &amortizedField{sched: newRowScheduler(budget, h), w: w, h: h, formula: formula, raw: make([]float32, w*h)}
+}
+
+// start begins a pass for p, abandoning any pass still running
+func (a *amortizedField) start(p preset) {
+	a.p = p
+	a.wide = p.View.wide(a.w, a.h)
+	a.src = p.rowSource(a.w, a.h)
+	if a.formula != nil {
+		a.src = formulaSource{a.formula}
+	}
+	a.pass = emptyRange()
+	a.generate = 0
+	a.sched.restart()
+}
+
+// step evaluates this frame's rows and shows them in buf. It returns the
+// rows of buf that changed, and once the pass is complete its timing and
+// true.
+func (a *amortizedField) step(buf *fieldBuffer, gradient []color) (rowRange, timing, bool) {
+	var t timing
+	if a.sched.done() {
+		return rowRange{}, t, false
+	}
+	startTime := time.Now()
+	rows := a.sched.step(func(y int) {
+		row := a.raw[y*a.w : (y+1)*a.w]
+		if a.src != nil {
+			a.p.View.sampleSourceRow(row, y, a.src)
+		} else {
+			a.p.View.sampleRow(row, y, a.wide, a.p)
+		}
+		for _, v := range row {
+			a.pass.add(v)
+		}
+	})
+	a.generate += time.Since(startTime)
+
+	if !a.sched.done() {
+		r := a.pass
+		if a.haveLast {
+			r = a.last
+		}
+		a.show(buf, gradient, rows, r)
+		return rows, t, false
+	}
+
+	t.generate = a.generate
+	startTime = time.Now()
+	a.last, a.haveLast = a.pass, true
+	all := rowRange{0, a.h}
+	a.show(buf, gradient, all, a.pass)
+	t.draw = time.Since(startTime)
+	return all, t, true
+}
+
+// progress is how much of the pass is done, from 0 to 1, or -1 with no
+// pass running
+func (a *amortizedField) progress() float64 {
+	if a.sched.done() {
+		return -1
+	}
+	return float64(a.sched.next) / float64(a.sched.rows)
+}
+
+// show rescales rows of raw with r into buf.noise and colors them
+func (a *amortizedField) show(buf *fieldBuffer, gradient []color, rows rowRange, r bandRange) {
+	start, end := rows.start*a.w, rows.end*a.w
+	copy(buf.noise[start:end], a.raw[start:end])
+	rescale(buf.noise[start:end], r.min, r.max)
+	drawField(buf.noise[start:end], gradient, buf.pixels[start*4:end*4])
+}
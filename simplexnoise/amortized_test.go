@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeRows is a clock that only moves when a row is evaluated, by that
+// row's cost
+type fakeRows struct {
+	now  time.Time
+	cost func(y int) time.Duration
+	// did is every row evaluated, in order
+	did []int
+}
+
+func (f *fakeRows) clock() time.Time {
+	return f.now
+}
+
+func (f *fakeRows) row(y int) {
+	f.did = append(f.did, y)
+	f.now = f.now.Add(f.cost(y))
+}
+
+// TestRowSchedulerBudget runs passes with rows of steady, varying and over
+// budget cost. A step may only start a row while the time taken so far and
+// the expected cost of a row fit in the budget, must always make progress
+// and has to carry on where the last one stopped.
+func TestRowSchedulerBudget(t *testing.T) {
+	const budget = 6 * time.Millisecond
+	const rows = 100
+	tests := []struct {
+		name string
+		cost func(y int) time.Duration
+		// perStep is the rows every step but the last must do, or 0 if
+		// it varies
+		perStep int
+	}{
+		{"steady", func(int) time.Duration { return time.Millisecond }, 6},
+		{"cheap", func(int) time.Duration { return 100 * time.Microsecond }, 60},
+		{"varying", func(y int) time.Duration { return time.Duration(y%7+1) * 300 * time.Microsecond }, 0},
+		{"getting dearer", func(y int) time.Duration { return time.Duration(y+1) * 20 * time.Microsecond }, 0},
+		{"over budget", func(int) time.Duration { return 2 * budget }, 1},
+	}
+	for _, tt := range tests {
+		f := &fakeRows{now: time.Unix(1000, 0), cost: tt.cost}
+		s := newRowScheduler(budget, rows)
+		s.clock = f.clock
+		if !s.done() {
+			t.Fatalf("%s: a new scheduler has a pass running", tt.name)
+		}
+		s.restart()
+
+		next := 0
+		for steps := 0; !s.done(); steps++ {
+			if steps > rows {
+				t.Fatalf("%s: no end after %d steps", tt.name, steps)
+			}
+			begin := f.now
+			did := len(f.did)
+			r := s.step(f.row)
+			if r.start != next || r.end <= r.start {
+				t.Fatalf("%s: step did rows %d..%d, want to start at %d", tt.name, r.start, r.end, next)
+			}
+			next = r.end
+			if tt.perStep != 0 && !s.done() && r.end-r.start != tt.perStep {
+				t.Errorf("%s: step did %d rows, want %d", tt.name, r.end-r.start, tt.perStep)
+			}
+			// every row but the first was started with time left for it
+			last := f.did[len(f.did)-1]
+			if len(f.did)-did > 1 && f.now.Sub(begin)-tt.cost(last) > budget {
+				t.Errorf("%s: step started row %d %v into a %v budget", tt.name, last, f.now.Sub(begin)-tt.cost(last), budget)
+			}
+		}
+		if next != rows {
+			t.Errorf("%s: pass ended at row %d, want %d", tt.name, next, rows)
+		}
+		for i, y := range f.did {
+			if y != i {
+				t.Fatalf("%s: row %d evaluated as the %dth", tt.name, y, i)
+			}
+		}
+		if r := s.step(f.row); !r.empty() {
+			t.Errorf("%s: step after the pass did rows %v", tt.name, r)
+		}
+	}
+}
+
+func TestRowSchedulerRestart(t *testing.T) {
+	f := &fakeRows{now: time.Unix(1000, 0), cost: func(int) time.Duration { return time.Millisecond }}
+	s := newRowScheduler(4*time.Millisecond, 20)
+	s.clock = f.clock
+	s.restart()
+	s.step(f.row)
+	s.step(f.row)
+
+	s.restart()
+	f.did = nil
+	if r := s.step(f.row); r.start != 0 {
+		t.Fatalf("step after a restart did rows %v, want them from 0", r)
+	}
+	for !s.done() {
+		s.step(f.row)
+	}
+	if len(f.did) != 20 {
+		t.Errorf("restarted pass evaluated %d rows, want 20", len(f.did))
+	}
+}
+
+// TestAmortizedFieldCompletes spreads a field over many frames and restarts
+// it once midway. The finished field must be the one makeNoise draws.
+func TestAmortizedFieldCompletes(t *testing.T) {
+	const w, h = 40, 30
+	p := defaultPreset()
+	other := p
+	other.Gain = 0.5
+
+	pool := newWorkerPool(2)
+	defer pool.close()
+	want := newFieldBuffer(w, h)
+	_, err := makeNoise(context.Background(), pool, want, w, h, p, defaultGradient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every reading of the clock is a millisecond after the last, so a
+	// row costs about a millisecond
+	now := time.Unix(1000, 0)
+	a := newAmortizedField(6*time.Millisecond, w, h, nil)
+	a.sched.clock = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+
+	buf := newFieldBuffer(w, h)
+	a.start(other)
+	a.step(buf, defaultGradient)
+	a.step(buf, defaultGradient)
+	a.start(p)
+	if a.progress() != 0 {
+		t.Errorf("progress %v after a restart", a.progress())
+	}
+	frames := 0
+	for {
+		frames++
+		if frames > h {
+			t.Fatal("field not done after a frame a row")
+		}
+		rows, _, done := a.step(buf, defaultGradient)
+		if done {
+			if rows != (rowRange{0, h}) {
+				t.Errorf("finished pass showed rows %v", rows)
+			}
+			break
+		}
+		if p := a.progress(); p <= 0 || p >= 1 {
+			t.Errorf("progress %v midway through the pass", p)
+		}
+	}
+	if frames < 3 {
+		t.Errorf("field done in %d frames, the budget should spread it over more", frames)
+	}
+	if a.progress() != -1 {
+		t.Errorf("progress %v after the pass", a.progress())
+	}
+	if !bytes.Equal(buf.pixels, want.pixels) {
+		t.Error("amortized field differs from makeNoise's")
+	}
+}
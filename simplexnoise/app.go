@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sabith-th/games_with_go/expr"
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+// windowOptions are the flags that only matter when there is a window
+type windowOptions struct {
+	fpsCap         int
+	serveAddr      string
+	verbose        bool
+	repeatDelay    time.Duration
+	repeatInterval time.Duration
+	amortize       time.Duration
+	paramsFile     string
+	palette        int
+	overlays       overlays
+	slots          *slotStore
+	bindings       []binding
+	sweep          *sweep
+	// sessionFile is where the window's session is saved on close
+	sessionFile string
+	// macroFile is the macro played before one is recorded
+	macroFile  string
+	macroSpeed float64
+}
+
+// app is the window and everything started for the game in it. newApp
+// adds a step to stop for everything it starts, the pool it is given
+// included, and close runs them.
+type app struct {
+	win    *gfx.Window
+	log    *statsLog
+	server *previewServer
+	gen    *generator
+	game   *noiseGame
+	stop   shutdown
+}
+
+// newApp opens the window and makes the first field for p, on the pool
+// or through formula if there is one. Every step's error says which step
+// failed, and whatever was started before it is closed again. Closing the
+// window or pressing escape while the first field is made returns
+// context.Canceled.
+func newApp(o windowOptions, p preset, pool *workerPool, formula expr.Node, workers int) (a *app, err error) {
+	a = &app{}
+	defer func() {
+		if err != nil {
+			a.close()
+			a = nil
+		}
+	}()
+	a.stop.add(drainPhase, pool.close)
+
+	a.win, err = gfx.New(formatTitle(p, -1), winWidth, winHeight)
+	if err != nil {
+		return nil, fmt.Errorf("opening window: %w", err)
+	}
+	a.stop.add(destroyPhase, a.win.Destroy)
+	a.win.SetFPSCap(o.fpsCap)
+	if !a.win.VSync() && o.fpsCap == 0 {
+		fmt.Println("no vsync, capping at", gfx.DefaultFPSCap, "fps")
+	}
+
+	frame := make([]byte, winWidth*winHeight*4)
+
+	// only the window's regenerations are logged, the exports stay quiet
+	// so their output can be scripted
+	a.log = newStatsLog(os.Stdout, workers, o.verbose)
+	a.stop.add(flushPhase, a.log.dump)
+
+	var filler fieldFiller = pool
+	if formula != nil {
+		filler = formulaFiller{pool, formula}
+	}
+	var updates <-chan paramsRequest
+	var commands <-chan commandRequest
+	if o.serveAddr != "" {
+		a.server = newPreviewServer(o.serveAddr, winWidth, winHeight, filler, a.log)
+		// its handlers render on the pool, so it is shut down first
+		a.stop.add(drainPhase, a.server.shutdown)
+		a.server.publish(frame, p)
+		// the window is still worth having without its preview
+		err := a.server.start()
+		if err != nil {
+			fmt.Println(err)
+		}
+		updates, commands = a.server.updates, a.server.commands
+	}
+
+	editor := newGradientEditor(palettePresets[o.palette])
+	gradient := editor.gradient()
+
+	// the first field is made up front so there is always one to show,
+	// every later one is made in the background by gen
+	// the octave cache only knows turbulence, a formula goes to the pool
+	cache := filler
+	if formula == nil {
+		cache = newOctaveCache(pool, winWidth, winHeight)
+	}
+	a.gen = newGenerator(cache, winWidth, winHeight, a.log)
+	a.stop.add(cancelPhase, a.gen.stop)
+	a.stop.add(drainPhase, a.gen.wait)
+	shown := newFieldBuffer(winWidth, winHeight)
+	err = a.firstField(filler, shown, p, gradient)
+	if err != nil {
+		return nil, fmt.Errorf("generating first field: %w", err)
+	}
+
+	a.game = &noiseGame{
+		win:             a.win,
+		p:               p,
+		frame:           frame,
+		spectrum:        o.overlays.Spectrum,
+		bloom:           o.overlays.Bloom,
+		chromatic:       o.overlays.Chromatic,
+		chromaticOffset: o.overlays.ChromaticOffset,
+		showEditor:      o.overlays.Editor,
+		showHUD:         o.overlays.HUD,
+		dirty:           true,
+		log:             a.log,
+		server:          a.server,
+		updates:         updates,
+		commands:        commands,
+		editor:          editor,
+		gradient:        gradient,
+		paletteIndex:    o.palette,
+		gen:             a.gen,
+		shown:           shown,
+		pixels:          shown.pixels,
+		field:           shown.noise,
+		quality:         newQualityGovernor(previewIdle),
+		keys:            newKeyRepeater(o.repeatDelay, o.repeatInterval),
+		changed:         allRows,
+		hud:             newFrameHUD(time.Now()),
+		history:         newUndoStack(undoDepth, coalesceWindow),
+		slots:           o.slots,
+		bindings:        o.bindings,
+		sweep:           o.sweep,
+		titles:          titleThrottle{interval: titleInterval},
+		macroFile:       o.macroFile,
+		macroSpeed:      o.macroSpeed,
+	}
+	if o.amortize > 0 {
+		a.game.amortized = newAmortizedField(o.amortize, winWidth, winHeight, formula)
+	}
+	if o.paramsFile != "" {
+		a.game.watcher = watchFile(o.paramsFile)
+	}
+	a.stop.add(cancelPhase, func() { a.game.watcher.stop() })
+	// the session is kept however the window ended
+	if o.sessionFile != "" {
+		a.stop.add(flushPhase, func() {
+			err := saveSession(o.sessionFile, a.game.session())
+			if err != nil {
+				fmt.Println("saving the session:", err)
+			}
+		})
+	}
+	return a, nil
+}
+
+// quitRequested reports whether events close the window or press escape
+func quitRequested(events []gfx.Event) bool {
+	for _, event := range events {
+		switch e := event.(type) {
+		case gfx.QuitEvent:
+			return true
+		case gfx.KeyEvent:
+			if e.Down && e.Scancode == gfx.KeyEscape {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// firstField generates p into shown on the pool, closing the window or
+// pressing escape cancels it. The pool reports every band it finishes on
+// this goroutine, which keeps the window polled and a progress bar on
+// screen.
+func (a *app) firstField(filler fieldFiller, shown *fieldBuffer, p preset, gradient []color) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var bar progressBar
+	titles := titleThrottle{interval: titleInterval}
+	shown.progress = func(done, total int) {
+		if quitRequested(a.win.PollEvents()) {
+			cancel()
+		}
+		if titles.ready(time.Now()) {
+			a.win.SetTitle(formatTitle(p, float64(done)/float64(total)))
+		}
+		if bar.draw(time.Now(), done, total, a.win.Pixels()) {
+			err := a.win.Present()
+			if err != nil {
+				fmt.Println(err)
+			}
+		}
+	}
+	defer func() { shown.progress = nil }()
+	t, err := makeNoise(ctx, filler, shown, winWidth, winHeight, p, gradient)
+	if err != nil {
+		return err
+	}
+	a.log.record(t)
+	return nil
+}
+
+// close stops whatever newApp got as far as starting: everything in flight
+// is canceled and waited for before the summary and the session are
+// written, and the window goes last
+func (a *app) close() {
+	a.stop.run()
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+// binding is a key, or a group of keys doing much the same thing, and what
+// it does. key and Update act on the keyboard by going through the game's
+// bindings and the help overlay lists them, so a key added here is
+// documented too.
+type binding struct {
+	// actions name the keys one for one, a keymap file moves them by name
+	actions []string
+	keys    []gfx.Scancode
+	// name is how the help shows a group of keys, a single key or a
+	// remapped group is shown by the keys' names. help says what they do.
+	name, help string
+	// ctrl bindings only act with ctrl held, the rest only without it
+	ctrl bool
+	// step changes the preset while keys[i] is held, once when it goes
+	// down and then repeating, with mult -1 while shift is held and scale
+	// from stepScale. It returns the new value to show, "" for none.
+	// press acts once per press of keys[i]. A binding with neither is
+	// read by Update itself.
+	step  func(p *preset, i, mult int, scale float32) string
+	press func(g *noiseGame, i int, e gfx.KeyEvent) (keyChange bool)
+	// value shows the current setting in the help, nil for none
+	value func(g *noiseGame) string
+}
+
+// index is where sc is in b's keys, -1 if it is not one of them
+func (b *binding) index(sc gfx.Scancode) int {
+	for i, k := range b.keys {
+		if k == sc {
+			return i
+		}
+	}
+	return -1
+}
+
+// label is how the help shows b's keys
+func (b *binding) label() string {
+	name := b.name
+	if name == "" {
+		names := make([]string, len(b.keys))
+		for i, k := range b.keys {
+			names[i] = k.String()
+		}
+		name = strings.Join(names, " ")
+	}
+	if b.ctrl {
+		name = "ctrl+" + name
+	}
+	return name
+}
+
+// defaultBindings are the keys without a keymap file
+var defaultBindings = []binding{
+	{
+		actions: []string{"octaves"}, keys: []gfx.Scancode{gfx.KeyO}, help: "more octaves",
+		step: func(p *preset, _, mult int, _ float32) string {
+			p.Octaves = stepOctaves(p.Octaves, mult)
+			return fmt.Sprint("octaves ", p.Octaves)
+		},
+		value: func(g *noiseGame) string { return fmt.Sprint(g.p.Octaves) },
+	},
+	{
+		actions: []string{"frequency"}, keys: []gfx.Scancode{gfx.KeyF}, help: "higher frequency",
+		step: func(p *preset, _, mult int, scale float32) string {
+			p.Frequency = stepValue(p.Frequency, mult, scale, frequencyRange)
+			return fmt.Sprintf("frequency %.4g", p.Frequency)
+		},
+		value: func(g *noiseGame) string { return fmt.Sprintf("%.4g", g.p.Frequency) },
+	},
+	{
+		actions: []string{"gain"}, keys: []gfx.Scancode{gfx.KeyG}, help: "more gain",
+		step: func(p *preset, _, mult int, scale float32) string {
+			p.Gain = stepValue(p.Gain, mult, scale, gainRange)
+			return fmt.Sprintf("gain %.4g", p.Gain)
+		},
+		value: func(g *noiseGame) string { return fmt.Sprintf("%.4g", g.p.Gain) },
+	},
+	{
+		actions: []string{"lacunarity"}, keys: []gfx.Scancode{gfx.KeyL}, help: "more lacunarity",
+		step: func(p *preset, _, mult int, scale float32) string {
+			p.Lacunarity = stepValue(p.Lacunarity, mult, scale, lacunarityRange)
+			return fmt.Sprintf("lacunarity %.4g", p.Lacunarity)
+		},
+		value: func(g *noiseGame) string { return fmt.Sprintf("%.4g", g.p.Lacunarity) },
+	},
+	{
+		actions: []string{"pan-left", "pan-right", "pan-up", "pan-down"},
+		keys:    []gfx.Scancode{gfx.KeyLeft, gfx.KeyRight, gfx.KeyUp, gfx.KeyDown},
+		name:    "arrows", help: "pan",
+		step: func(p *preset, i, _ int, _ float32) string {
+			p.View = p.View.pan(panDirections[i].dx*panPixels, panDirections[i].dy*panPixels)
+			return ""
+		},
+		value: func(g *noiseGame) string { return fmt.Sprintf("%.4g, %.4g", g.p.View.X, g.p.View.Y) },
+	},
+	{
+		actions: []string{"zoom-in"}, keys: []gfx.Scancode{gfx.KeyPageUp}, help: "zoom in",
+		step: func(p *preset, _, _ int, _ float32) string {
+			p.View = p.View.zoom(winWidth/2, winHeight/2, zoomFactor)
+			return ""
+		},
+		value: func(g *noiseGame) string { return fmt.Sprintf("step %.4g", g.p.View.Step) },
+	},
+	{
+		actions: []string{"zoom-out"}, keys: []gfx.Scancode{gfx.KeyPageDown}, help: "zoom out",
+		step: func(p *preset, _, _ int, _ float32) string {
+			p.View = p.View.zoom(winWidth/2, winHeight/2, 1/zoomFactor)
+			return ""
+		},
+	},
+	{
+		actions: []string{"mode"}, keys: []gfx.Scancode{gfx.KeyM}, help: "next mode",
+		press: func(g *noiseGame, _ int, e gfx.KeyEvent) bool {
+			step := 1
+			if e.Mod&gfx.ModShift != 0 {
+				step = int(numNoiseModes) - 1
+			}
+			g.p.Mode = (g.p.Mode + noiseMode(step)) % numNoiseModes
+			fmt.Println("mode:", g.p.Mode)
+			return true
+		},
+		value: func(g *noiseGame) string { return g.p.Mode.String() },
+	},
+	{
+		actions: []string{"basis"}, keys: []gfx.Scancode{gfx.KeyN}, help: "next basis",
+		press: func(g *noiseGame, _ int, e gfx.KeyEvent) bool {
+			step := 1
+			if e.Mod&gfx.ModShift != 0 {
+				step = -1
+			}
+			g.p.Basis = g.p.Basis.next(step)
+			fmt.Println("basis:", g.p.Basis)
+			return true
+		},
+		value: func(g *noiseGame) string { return g.p.Basis.String() },
+	},
+	{
+		actions: []string{"palette"}, keys: []gfx.Scancode{gfx.KeyP}, help: "next palette",
+		press: func(g *noiseGame, _ int, e gfx.KeyEvent) bool {
+			step := 1
+			if e.Mod&gfx.ModShift != 0 {
+				step = len(palettePresets) - 1
+			}
+			g.setPalette((g.paletteIndex + step) % len(palettePresets))
+			return false
+		},
+		value: func(g *noiseGame) string { return palettePresets[g.paletteIndex].name },
+	},
+	{
+		actions: []string{"editor"}, keys: []gfx.Scancode{gfx.KeyE}, help: "gradient editor",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.showEditor = !g.showEditor
+			g.changed = g.changed.union(g.editor.rows())
+			return false
+		},
+		value: func(g *noiseGame) string { return onOff(g.showEditor) },
+	},
+	{
+		actions: []string{"spectrum"}, keys: []gfx.Scancode{gfx.KeyX}, help: "spectrum",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.spectrum = !g.spectrum
+			g.dirty = true
+			return false
+		},
+		value: func(g *noiseGame) string { return onOff(g.spectrum) },
+	},
+	{
+		actions: []string{"bloom"}, keys: []gfx.Scancode{gfx.KeyB}, help: "bloom",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.bloom = !g.bloom
+			g.dirty = true
+			return false
+		},
+		value: func(g *noiseGame) string { return onOff(g.bloom) },
+	},
+	{
+		actions: []string{"chromatic"}, keys: []gfx.Scancode{gfx.KeyC}, help: "chromatic aberration",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.chromatic = !g.chromatic
+			g.dirty = true
+			return false
+		},
+		value: func(g *noiseGame) string { return onOff(g.chromatic) },
+	},
+	{
+		actions: []string{"offset-up", "offset-up-keypad", "offset-down", "offset-down-keypad"},
+		keys:    []gfx.Scancode{gfx.KeyEquals, gfx.KeyKPPlus, gfx.KeyMinus, gfx.KeyKPMinus},
+		name:    "+ -", help: "chromatic offset",
+		press: func(g *noiseGame, i int, _ gfx.KeyEvent) bool {
+			step := 1
+			if i >= 2 {
+				step = -1
+			}
+			g.chromaticOffset = clamp(0, maxChromaticOffset, g.chromaticOffset+step)
+			g.dirty = true
+			return false
+		},
+		value: func(g *noiseGame) string { return fmt.Sprint(g.chromaticOffset) },
+	},
+	{
+		actions: []string{"sweep"}, keys: []gfx.Scancode{gfx.KeyA}, help: "sweep, keys pause it",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			if g.sweep.state == sweepOn {
+				g.sweep.state = sweepOff
+			} else {
+				g.sweep.begin(g.p, false)
+				g.sweepWaiting = false
+			}
+			g.showSweep(time.Now())
+			return false
+		},
+		value: func(g *noiseGame) string { return g.sweep.status() },
+	},
+	{
+		actions: []string{"hud"}, keys: []gfx.Scancode{gfx.KeyH}, help: "frame times",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.showHUD = !g.showHUD
+			g.changed = g.changed.union(g.hud.rows())
+			return false
+		},
+		value: func(g *noiseGame) string { return onOff(g.showHUD) },
+	},
+	{
+		actions: []string{"times"}, keys: []gfx.Scancode{gfx.KeyT}, help: "print generation times",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.log.dump()
+			return false
+		},
+	},
+	{
+		actions: []string{"screenshot"}, keys: []gfx.Scancode{gfx.KeyF12}, help: "screenshot",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			filename, err := saveScreenshot(g.frame)
+			if err != nil {
+				g.notify(fmt.Sprint("screenshot failed: ", err))
+				return false
+			}
+			g.notify("saved " + filename)
+			return false
+		},
+	},
+	{
+		actions: []string{"slot-1", "slot-2", "slot-3", "slot-4", "slot-5", "slot-6", "slot-7", "slot-8", "slot-9"},
+		keys:    []gfx.Scancode{gfx.Key1, gfx.Key2, gfx.Key3, gfx.Key4, gfx.Key5, gfx.Key6, gfx.Key7, gfx.Key8, gfx.Key9},
+		name:    "1-9", help: "recall slot, shift saves",
+		press: func(g *noiseGame, i int, e gfx.KeyEvent) bool {
+			return g.slotKey(i+1, e.Mod&gfx.ModShift != 0)
+		},
+		value: func(g *noiseGame) string {
+			var full []string
+			for n := 1; n <= numSlots; n++ {
+				if _, ok := g.slots.slots[n]; ok {
+					full = append(full, fmt.Sprint(n))
+				}
+			}
+			if full == nil {
+				return "all empty"
+			}
+			return strings.Join(full, " ")
+		},
+	},
+	{
+		actions: []string{"help"}, keys: []gfx.Scancode{gfx.KeyF1}, help: "this help",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.showHelp = !g.showHelp
+			g.changed = allRows
+			return false
+		},
+	},
+	{
+		actions: []string{"console"}, keys: []gfx.Scancode{gfx.KeyGrave}, help: "command console",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.toggleConsole()
+			return false
+		},
+	},
+	{actions: []string{"loupe"}, keys: []gfx.Scancode{gfx.KeyLAlt}, help: "hold for the loupe"},
+	{
+		actions: []string{"quit"}, keys: []gfx.Scancode{gfx.KeyEscape}, help: "quit",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.quit = true
+			return false
+		},
+	},
+	{
+		actions: []string{"save"}, keys: []gfx.Scancode{gfx.KeyS}, help: "save parameters", ctrl: true,
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			filename, err := saveParams(g.p, palettePresets[g.paletteIndex].name)
+			if err != nil {
+				g.notify(fmt.Sprint("save failed: ", err))
+				return false
+			}
+			g.notify("saved " + filename)
+			g.lastSaved = filename
+			return false
+		},
+		value: func(g *noiseGame) string { return g.lastSaved },
+	},
+	{
+		actions: []string{"load"}, keys: []gfx.Scancode{gfx.KeyL}, help: "load parameters", ctrl: true,
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.prompt = &textPrompt{"load: ", g.lastSaved}
+			g.changed = g.changed.union(g.prompt.rows())
+			g.win.SetTextInput(true)
+			return false
+		},
+	},
+	{
+		actions: []string{"record-macro"}, keys: []gfx.Scancode{gfx.KeyR}, help: "record a macro",
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.toggleRecording()
+			return false
+		},
+		value: func(g *noiseGame) string { return onOff(g.recorder.on) },
+	},
+	{
+		actions: []string{"play-macro"}, keys: []gfx.Scancode{gfx.KeyR}, help: "play the macro", ctrl: true,
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool {
+			g.playMacro()
+			return false
+		},
+		value: func(g *noiseGame) string { return g.macroFile },
+	},
+	{
+		actions: []string{"undo"}, keys: []gfx.Scancode{gfx.KeyZ}, help: "undo", ctrl: true,
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool { return g.walkHistory(false) },
+		value: func(g *noiseGame) string { return fmt.Sprint(len(g.history.done), " left") },
+	},
+	{
+		actions: []string{"redo"}, keys: []gfx.Scancode{gfx.KeyY}, help: "redo", ctrl: true,
+		press: func(g *noiseGame, _ int, _ gfx.KeyEvent) bool { return g.walkHistory(true) },
+		value: func(g *noiseGame) string { return fmt.Sprint(len(g.history.undone), " left") },
+	},
+}
+
+// boundKey is the key bound to action
+func (g *noiseGame) boundKey(action string) gfx.Scancode {
+	for _, b := range g.bindings {
+		for i, a := range b.actions {
+			if a == action {
+				return b.keys[i]
+			}
+		}
+	}
+	return -1
+}
+
+// ctrlBound reports whether a ctrl binding has sc, which then does not
+// step anything with ctrl held
+func (g *noiseGame) ctrlBound(sc gfx.Scancode) bool {
+	for i := range g.bindings {
+		if g.bindings[i].ctrl && g.bindings[i].index(sc) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// stepHeld reports whether any key of a step binding is held and would
+// step with ctrl as it is
+func (g *noiseGame) stepHeld(in *gfx.Input, ctrl bool) bool {
+	for _, b := range g.bindings {
+		if b.step == nil {
+			continue
+		}
+		for _, sc := range b.keys {
+			if in.Held(sc) && !(ctrl && g.ctrlBound(sc)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+const (
+	helpScale = 2
+	helpX     = 40
+	helpY     = 40
+	// helpHelp is how many characters the help column is given
+	helpHelp = 26
+)
+
+// helpText lists the bindings a line each, with their current values. The
+// font is fixed width, so padding lines the columns up.
+func (g *noiseGame) helpText() string {
+	width := 0
+	for i := range g.bindings {
+		if n := len(g.bindings[i].label()); n > width {
+			width = n
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("keys, shift steps back, ctrl finer, alt coarser\n\n")
+	for i := range g.bindings {
+		b := &g.bindings[i]
+		value := ""
+		if b.value != nil {
+			value = b.value(g)
+		}
+		fmt.Fprintf(&sb, "%-*s %-*s%s\n", width, b.label(), helpHelp, b.help, value)
+	}
+	return sb.String()
+}
+
+// dim darkens the rows r of pixels under the help
+func dim(r rowRange, pixels []byte) {
+	for i := r.start * winWidth * 4; i < r.end*winWidth*4; i += 4 {
+		pixels[i] /= 3
+		pixels[i+1] /= 3
+		pixels[i+2] /= 3
+	}
+}
+
+func drawHelp(text string, pixels []byte) {
+	font.Draw(text, helpX, helpY, helpScale, font.Color{R: 255, G: 255, B: 255}, pixels, winWidth, winHeight)
+}
@@ -0,0 +1,136 @@
+package main
+
+import "math"
+
+// ColorHSV is a color as hue in degrees 0..360 and saturation and value in 0..1
+type ColorHSV struct {
+	H, S, V float32
+}
+
+// ColorHSL is a color as hue in degrees 0..360 and saturation and lightness in 0..1
+type ColorHSL struct {
+	H, S, L float32
+}
+
+func toByte(f float32) byte {
+	return byte(clamp(0, 255, int(f*255+0.5)))
+}
+
+func max3(a, b, c float32) float32 {
+	return float32(math.Max(float64(a), math.Max(float64(b), float64(c))))
+}
+
+func min3(a, b, c float32) float32 {
+	return float32(math.Min(float64(a), math.Min(float64(b), float64(c))))
+}
+
+// wrapHue brings any angle into 0..360
+func wrapHue(h float32) float32 {
+	h = float32(math.Mod(float64(h), 360))
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// hue returns the hue of r, g, b in 0..1 with the given largest component
+// and spread, 0 for grays
+func hue(r, g, b, max, delta float32) float32 {
+	if delta == 0 {
+		return 0
+	}
+	var h float32
+	switch max {
+	case r:
+		h = 60 * float32(math.Mod(float64((g-b)/delta), 6))
+	case g:
+		h = 60 * ((b-r)/delta + 2)
+	default:
+		h = 60 * ((r-g)/delta + 4)
+	}
+	return wrapHue(h)
+}
+
+// chromaToRGB places chroma c and the second largest component x by hue
+// sector and adds m to all three
+func chromaToRGB(h, c, m float32) color {
+	h = wrapHue(h)
+	x := c * (1 - float32(math.Abs(math.Mod(float64(h/60), 2)-1)))
+	var r, g, b float32
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color{toByte(r + m), toByte(g + m), toByte(b + m)}
+}
+
+// RGBtoHSV converts c to hue, saturation and value. Grays get a hue of 0.
+func RGBtoHSV(c color) ColorHSV {
+	r, g, b := float32(c.r)/255, float32(c.g)/255, float32(c.b)/255
+	max, min := max3(r, g, b), min3(r, g, b)
+	delta := max - min
+	var s float32
+	if max > 0 {
+		s = delta / max
+	}
+	return ColorHSV{hue(r, g, b, max, delta), s, max}
+}
+
+// HSVtoRGB converts h back to rgb, any hue is accepted and wrapped
+func HSVtoRGB(h ColorHSV) color {
+	c := h.V * h.S
+	return chromaToRGB(h.H, c, h.V-c)
+}
+
+// RGBtoHSL converts c to hue, saturation and lightness. Grays get a hue of 0.
+func RGBtoHSL(c color) ColorHSL {
+	r, g, b := float32(c.r)/255, float32(c.g)/255, float32(c.b)/255
+	max, min := max3(r, g, b), min3(r, g, b)
+	delta := max - min
+	l := (max + min) / 2
+	var s float32
+	if delta > 0 {
+		s = delta / (1 - float32(math.Abs(float64(2*l-1))))
+	}
+	return ColorHSL{hue(r, g, b, max, delta), s, l}
+}
+
+// HSLtoRGB converts h back to rgb, any hue is accepted and wrapped
+func HSLtoRGB(h ColorHSL) color {
+	c := (1 - float32(math.Abs(float64(2*h.L-1)))) * h.S
+	return chromaToRGB(h.H, c, h.L-c/2)
+}
+
+// HueLerp interpolates between two hues in degrees through the shorter arc,
+// so 350 to 10 passes through 0 rather than 180. The result is in 0..360.
+func HueLerp(h1, h2, t float32) float32 {
+	d := wrapHue(h2 - h1)
+	if d > 180 {
+		d -= 360
+	}
+	return wrapHue(h1 + d*t)
+}
+
+// hsvLerp interpolates two colors in hsv space, taking the shorter way
+// around the hue circle. A gray end takes the hue of the other end so fading
+// to white or black does not sweep through unrelated hues.
+func hsvLerp(c1, c2 color, pct float32) color {
+	a, b := RGBtoHSV(c1), RGBtoHSV(c2)
+	if a.S == 0 {
+		a.H = b.H
+	}
+	if b.S == 0 {
+		b.H = a.H
+	}
+	return HSVtoRGB(ColorHSV{HueLerp(a.H, b.H, pct), a.S + pct*(b.S-a.S), a.V + pct*(b.V-a.V)})
+}
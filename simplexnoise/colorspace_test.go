@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+// nearColor reports whether every channel of a and b is within 1
+func nearColor(a, b color) bool {
+	d := func(x, y byte) bool { return int(x)-int(y) <= 1 && int(y)-int(x) <= 1 }
+	return d(a.r, b.r) && d(a.g, b.g) && d(a.b, b.b)
+}
+
+func TestHSVBoundaries(t *testing.T) {
+	tests := []struct {
+		name string
+		c    color
+		hsv  ColorHSV
+	}{
+		{"red", color{255, 0, 0}, ColorHSV{0, 1, 1}},
+		{"yellow", color{255, 255, 0}, ColorHSV{60, 1, 1}},
+		{"green", color{0, 255, 0}, ColorHSV{120, 1, 1}},
+		{"cyan", color{0, 255, 255}, ColorHSV{180, 1, 1}},
+		{"blue", color{0, 0, 255}, ColorHSV{240, 1, 1}},
+		{"magenta", color{255, 0, 255}, ColorHSV{300, 1, 1}},
+		{"white", color{255, 255, 255}, ColorHSV{0, 0, 1}},
+		{"black", color{0, 0, 0}, ColorHSV{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		if got := RGBtoHSV(tt.c); got != tt.hsv {
+			t.Errorf("%s: RGBtoHSV(%v) = %v, want %v", tt.name, tt.c, got, tt.hsv)
+		}
+		if got := HSVtoRGB(tt.hsv); !nearColor(got, tt.c) {
+			t.Errorf("%s: HSVtoRGB(%v) = %v, want %v", tt.name, tt.hsv, got, tt.c)
+		}
+	}
+	// hues past either end wrap round to red
+	for _, h := range []float32{360, -360, 720} {
+		if got := HSVtoRGB(ColorHSV{h, 1, 1}); !nearColor(got, color{255, 0, 0}) {
+			t.Errorf("hue %v is %v, want red", h, got)
+		}
+	}
+}
+
+// TestHSVRoundTrip converts a grid of colors through hsv and hsl and back,
+// every channel has to come back within 1
+func TestHSVRoundTrip(t *testing.T) {
+	levels := []byte{0, 1, 2, 17, 63, 127, 128, 200, 253, 254, 255}
+	for _, r := range levels {
+		for _, g := range levels {
+			for _, b := range levels {
+				c := color{r, g, b}
+				if got := HSVtoRGB(RGBtoHSV(c)); !nearColor(got, c) {
+					t.Errorf("%v through hsv %v comes back as %v", c, RGBtoHSV(c), got)
+				}
+				if got := HSLtoRGB(RGBtoHSL(c)); !nearColor(got, c) {
+					t.Errorf("%v through hsl %v comes back as %v", c, RGBtoHSL(c), got)
+				}
+			}
+		}
+	}
+}
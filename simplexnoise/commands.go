@@ -0,0 +1,298 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// commandTarget is what commands act on: the window's game, or the
+// server's state without a window. Commands only go through it, so the
+// console and the server run the same ones.
+type commandTarget interface {
+	params() preset
+	// setParams replaces the parameters with p, which has been validated
+	setParams(p preset)
+	usePalette(name string) error
+	exportPNG(path string) error
+}
+
+// command is one thing the console can be told to do
+type command struct {
+	name  string
+	usage string
+	// nargs is how many arguments it takes
+	nargs int
+	// local commands write files, they only run from the console and never
+	// for the server
+	local bool
+	// check rejects arguments that cannot work whatever the target, it
+	// can be nil
+	check func(args []string) error
+	// complete lists what argument i can be given the ones before it, nil
+	// where it can be anything
+	complete func(i int, before []string) []string
+	run      func(t commandTarget, args []string) (string, error)
+}
+
+// consoleParam is a parameter set can change, through a flag.Value onto
+// its field of a preset
+type consoleParam struct {
+	name    string
+	value   func(p *preset) flag.Value
+	options func() []string
+}
+
+// intValue is an int as a flag.Value
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a whole number", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+// viewValue is a viewport as a flag.Value, its x, y and step separated by
+// commas
+type viewValue viewport
+
+func (v *viewValue) Set(s string) error {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return fmt.Errorf("%q is not x,y,step", s)
+	}
+	var xyz [3]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%q is not x,y,step", s)
+		}
+		xyz[i] = f
+	}
+	*v = viewValue{X: xyz[0], Y: xyz[1], Step: xyz[2]}
+	return nil
+}
+
+func (v *viewValue) String() string {
+	format := func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+	return format(v.X) + "," + format(v.Y) + "," + format(v.Step)
+}
+
+var consoleParams = []consoleParam{
+	{"frequency", func(p *preset) flag.Value { return (*float32Value)(&p.Frequency) }, nil},
+	{"lacunarity", func(p *preset) flag.Value { return (*float32Value)(&p.Lacunarity) }, nil},
+	{"gain", func(p *preset) flag.Value { return (*float32Value)(&p.Gain) }, nil},
+	{"octaves", func(p *preset) flag.Value { return (*intValue)(&p.Octaves) }, nil},
+	{"mode", func(p *preset) flag.Value { return &p.Mode }, func() []string { return noiseModeNames[:] }},
+	{"basis", func(p *preset) flag.Value { return &p.Basis }, noise.Names},
+	{"view", func(p *preset) flag.Value { return (*viewValue)(&p.View) }, nil},
+}
+
+func findParam(name string) (consoleParam, bool) {
+	for _, cp := range consoleParams {
+		if cp.name == name {
+			return cp, true
+		}
+	}
+	return consoleParam{}, false
+}
+
+func paramNames() []string {
+	var names []string
+	for _, cp := range consoleParams {
+		names = append(names, cp.name)
+	}
+	return names
+}
+
+func paletteNames() []string {
+	var names []string
+	for _, pp := range palettePresets {
+		names = append(names, pp.name)
+	}
+	return names
+}
+
+// exportFormats are what export can write
+var exportFormats = []string{"png"}
+
+// commands are sorted by name, help is added in init since it lists them
+var commands = []command{
+	{
+		name: "export", usage: "export png <file>", nargs: 2, local: true,
+		check: func(args []string) error {
+			if args[0] != "png" {
+				return fmt.Errorf("cannot export %q, expected one of %v", args[0], exportFormats)
+			}
+			return nil
+		},
+		complete: func(i int, _ []string) []string {
+			if i == 0 {
+				return exportFormats
+			}
+			return nil
+		},
+		run: func(t commandTarget, args []string) (string, error) {
+			err := t.exportPNG(args[1])
+			if err != nil {
+				return "", err
+			}
+			return "saved " + args[1], nil
+		},
+	},
+	{
+		name: "palette", usage: "palette <name>", nargs: 1,
+		complete: func(int, []string) []string { return paletteNames() },
+		run: func(t commandTarget, args []string) (string, error) {
+			err := t.usePalette(args[0])
+			if err != nil {
+				return "", err
+			}
+			return "palette " + args[0], nil
+		},
+	},
+	{
+		name: "set", usage: "set <param> <value>", nargs: 2,
+		check: func(args []string) error {
+			if _, ok := findParam(args[0]); !ok {
+				return fmt.Errorf("unknown parameter %q, expected one of %v", args[0], paramNames())
+			}
+			return nil
+		},
+		complete: func(i int, before []string) []string {
+			if i == 0 {
+				return paramNames()
+			}
+			if cp, ok := findParam(before[0]); ok && cp.options != nil {
+				return cp.options()
+			}
+			return nil
+		},
+		run: func(t commandTarget, args []string) (string, error) {
+			cp, _ := findParam(args[0])
+			p := t.params()
+			v := cp.value(&p)
+			err := v.Set(args[1])
+			if err != nil {
+				return "", err
+			}
+			err = p.validate()
+			if err != nil {
+				return "", err
+			}
+			t.setParams(p)
+			return cp.name + " " + v.String(), nil
+		},
+	},
+}
+
+func init() {
+	commands = append(commands, command{
+		name: "help", usage: "help",
+		run: func(commandTarget, []string) (string, error) {
+			var usages []string
+			for _, c := range commands {
+				usages = append(usages, c.usage)
+			}
+			return strings.Join(usages, ", "), nil
+		},
+	})
+	sort.Slice(commands, func(i, j int) bool { return commands[i].name < commands[j].name })
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// commandCall is a parsed line, ready to run
+type commandCall struct {
+	cmd  *command
+	args []string
+}
+
+// parseCommand splits line into words and looks its command up, checking
+// what can be checked without a target
+func parseCommand(line string) (commandCall, error) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return commandCall{}, fmt.Errorf("no command")
+	}
+	cmd := findCommand(strings.ToLower(words[0]))
+	if cmd == nil {
+		return commandCall{}, fmt.Errorf("unknown command %q, try help", words[0])
+	}
+	args := words[1:]
+	if len(args) != cmd.nargs {
+		return commandCall{}, fmt.Errorf("usage: %s", cmd.usage)
+	}
+	if cmd.check != nil {
+		err := cmd.check(args)
+		if err != nil {
+			return commandCall{}, err
+		}
+	}
+	return commandCall{cmd, args}, nil
+}
+
+// run runs the call on t, returning what to show for it
+func (c commandCall) run(t commandTarget) (string, error) {
+	return c.cmd.run(t, c.args)
+}
+
+// completeLine completes the last word of line, or a new word after a
+// trailing space. A single match is filled in with a space after it, several
+// are filled in as far as they agree. It returns the line and every match.
+func completeLine(line string) (string, []string) {
+	words := strings.Fields(line)
+	if len(words) == 0 || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	i := len(words) - 1
+	var options []string
+	if i == 0 {
+		for _, c := range commands {
+			options = append(options, c.name)
+		}
+	} else if cmd := findCommand(words[0]); cmd != nil && cmd.complete != nil && i-1 < cmd.nargs {
+		options = cmd.complete(i-1, words[1:i])
+	}
+
+	var matches []string
+	for _, o := range options {
+		if strings.HasPrefix(o, words[i]) {
+			matches = append(matches, o)
+		}
+	}
+	if len(matches) == 0 {
+		return line, nil
+	}
+	prefix := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	words[i] = prefix
+	completed := strings.Join(words, " ")
+	if len(matches) == 1 {
+		completed += " "
+	}
+	return completed, matches
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeTarget records what commands did to it
+type fakeTarget struct {
+	p       preset
+	palette string
+	saved   string
+}
+
+func (t *fakeTarget) params() preset     { return t.p }
+func (t *fakeTarget) setParams(p preset) { t.p = p }
+func (t *fakeTarget) exportPNG(path string) error {
+	t.saved = path
+	return nil
+}
+
+func (t *fakeTarget) usePalette(name string) error {
+	if name != "fire" {
+		return errors.New("unknown palette")
+	}
+	t.palette = name
+	return nil
+}
+
+func runLine(t *testing.T, target commandTarget, line string) (string, error) {
+	t.Helper()
+	call, err := parseCommand(line)
+	if err != nil {
+		return "", err
+	}
+	return call.run(target)
+}
+
+func TestCommands(t *testing.T) {
+	target := &fakeTarget{p: defaultPreset()}
+	tests := []struct {
+		line, out string
+	}{
+		{"set frequency 0.02", "frequency 0.02"},
+		{"set octaves 5", "octaves 5"},
+		{"SET mode ridged", "mode ridged"},
+		{"  set   gain   0.5 ", "gain 0.5"},
+		{"palette fire", "palette fire"},
+		{"export png out.png", "saved out.png"},
+	}
+	for _, tt := range tests {
+		out, err := runLine(t, target, tt.line)
+		if err != nil || out != tt.out {
+			t.Errorf("%q gave %q, %v, want %q", tt.line, out, err, tt.out)
+		}
+	}
+	want := defaultPreset()
+	want.Frequency, want.Octaves, want.Mode, want.Gain = 0.02, 5, ridgedMode, 0.5
+	if target.p != want {
+		t.Errorf("parameters %+v, want %+v", target.p, want)
+	}
+	if target.palette != "fire" || target.saved != "out.png" {
+		t.Errorf("palette %q, saved %q", target.palette, target.saved)
+	}
+	if out, err := runLine(t, target, "help"); err != nil || !strings.Contains(out, "set <param> <value>") {
+		t.Errorf("help gave %q, %v", out, err)
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	target := &fakeTarget{p: defaultPreset()}
+	for _, line := range []string{
+		"",
+		"seed 1337",
+		"set frequency",
+		"set colour 3",
+		"set octaves three",
+		"set octaves 0",
+		"set mode spiky",
+		"set frequency nan",
+		"set gain inf",
+		"export jpeg out.jpg",
+		"palette mud",
+	} {
+		if out, err := runLine(t, target, line); err == nil {
+			t.Errorf("%q was accepted, gave %q", line, out)
+		}
+	}
+	if target.p != defaultPreset() {
+		t.Errorf("failed commands changed the parameters to %+v", target.p)
+	}
+}
+
+func TestCompleteLine(t *testing.T) {
+	tests := []struct {
+		line, want string
+		matches    []string
+	}{
+		{"", "", []string{"export", "help", "palette", "set"}},
+		{"s", "set ", []string{"set"}},
+		{"set ", "set ", paramNames()},
+		{"set f", "set frequency ", []string{"frequency"}},
+		{"set mode r", "set mode ridged ", []string{"ridged"}},
+		{"set mode ", "set mode ", noiseModeNames[:]},
+		{"set frequency 0", "set frequency 0", nil},
+		{"export ", "export png ", []string{"png"}},
+		{"export png o", "export png o", nil},
+		{"bogus x", "bogus x", nil},
+	}
+	for _, tt := range tests {
+		got, matches := completeLine(tt.line)
+		if got != tt.want || !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("completeLine(%q) = %q, %v, want %q, %v", tt.line, got, matches, tt.want, tt.matches)
+		}
+	}
+	// several matches fill in as far as they agree
+	commands = append(commands, command{name: "settle"}, command{name: "setting"})
+	defer func() { commands = commands[:len(commands)-2] }()
+	if got, matches := completeLine("se"); got != "set" || len(matches) != 3 {
+		t.Errorf("completeLine(%q) = %q, %v", "se", got, matches)
+	}
+}
+
+func TestServerCommand(t *testing.T) {
+	s := newPreviewServer("", winWidth, winHeight, nil, nil)
+	target := &fakeTarget{p: defaultPreset()}
+	// the render loop's side
+	go func() {
+		for req := range s.commands {
+			out, err := req.call.run(target)
+			req.reply <- commandReply{out, err}
+		}
+	}()
+	defer close(s.commands)
+
+	post := func(line string) (int, string) {
+		rec := httptest.NewRecorder()
+		s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/command", strings.NewReader(line)))
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+	if code, body := post("set octaves 4"); code != http.StatusOK || body != "octaves 4" {
+		t.Errorf("set gave %d %q", code, body)
+	}
+	if target.p.Octaves != 4 {
+		t.Errorf("octaves %d after set", target.p.Octaves)
+	}
+	if code, _ := post("set octaves -1"); code != http.StatusBadRequest {
+		t.Errorf("invalid value gave %d", code)
+	}
+	if code, _ := post("seed 1"); code != http.StatusBadRequest {
+		t.Errorf("unknown command gave %d", code)
+	}
+	if code, _ := post("export png /tmp/x.png"); code != http.StatusForbidden || target.saved != "" {
+		t.Errorf("export gave %d, saved %q", code, target.saved)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sabith-th/games_with_go/font"
+)
+
+const (
+	consoleScale      = 2
+	consoleLineHeight = (font.GlyphHeight + 1) * consoleScale
+	// consoleLines is how many lines of output show above the input line,
+	// the error line is below it
+	consoleLines  = 8
+	consoleHeight = (consoleLines+2)*consoleLineHeight + 2*hudPadding
+	consolePrompt = "] "
+	// maxOutput is how many lines of output are kept
+	maxOutput = 100
+)
+
+var (
+	consoleBackground = color{16, 16, 24}
+	consoleText       = font.Color{R: 220, G: 220, B: 220}
+	consoleInput      = font.Color{R: 255, G: 255, B: 255}
+	consoleError      = font.Color{R: 255, G: 90, B: 90}
+)
+
+// console is a command line over the top of the window. Its history lasts
+// as long as the window, up and down walk it.
+type console struct {
+	open   bool
+	line   string
+	output []string
+	// errText is why the last line failed, until the next one runs
+	errText string
+
+	history []string
+	// walk is where up and down have got to in history, len(history) on
+	// the line being typed, which draft keeps while walking
+	walk  int
+	draft string
+}
+
+// typeText adds typed text to the line. The backquote opens and closes
+// the console, it never ends up in the line.
+func (c *console) typeText(text string) {
+	c.line += strings.ReplaceAll(text, "`", "")
+}
+
+func (c *console) backspace() {
+	_, size := utf8.DecodeLastRuneInString(c.line)
+	c.line = c.line[:len(c.line)-size]
+}
+
+// submit takes the line for running and keeps it in the history, once if
+// it is the same as the last
+func (c *console) submit() string {
+	line := strings.TrimSpace(c.line)
+	c.line, c.draft = "", ""
+	if line != "" && (len(c.history) == 0 || c.history[len(c.history)-1] != line) {
+		c.history = append(c.history, line)
+	}
+	c.walk = len(c.history)
+	return line
+}
+
+// previous shows the line before the one shown in the history
+func (c *console) previous() {
+	if c.walk == 0 {
+		return
+	}
+	if c.walk == len(c.history) {
+		c.draft = c.line
+	}
+	c.walk--
+	c.line = c.history[c.walk]
+}
+
+// next shows the line after the one shown, back to the draft after the
+// last
+func (c *console) next() {
+	if c.walk >= len(c.history) {
+		return
+	}
+	c.walk++
+	if c.walk == len(c.history) {
+		c.line = c.draft
+		return
+	}
+	c.line = c.history[c.walk]
+}
+
+// print adds text to the output, wrapped to the window
+func (c *console) print(text string) {
+	c.output = append(c.output, font.Wrap(text, winWidth-2*hudX, consoleScale)...)
+	if len(c.output) > maxOutput {
+		c.output = c.output[len(c.output)-maxOutput:]
+	}
+}
+
+// fail shows err on the error line
+func (c *console) fail(err error) {
+	c.errText = err.Error()
+}
+
+// rows are the rows the console covers
+func (c *console) rows() rowRange {
+	return rowRange{0, consoleHeight}
+}
+
+func (c *console) draw(pixels []byte) {
+	fillRect(0, 0, winWidth, consoleHeight, consoleBackground, pixels)
+	fillRect(0, consoleHeight-2, winWidth, 2, color{90, 90, 110}, pixels)
+	out := c.output
+	if len(out) > consoleLines {
+		out = out[len(out)-consoleLines:]
+	}
+	y := hudPadding + (consoleLines-len(out))*consoleLineHeight
+	for _, line := range out {
+		font.Draw(line, hudX, y, consoleScale, consoleText, pixels, winWidth, winHeight)
+		y += consoleLineHeight
+	}
+	font.Draw(consolePrompt+c.line+"_", hudX, y, consoleScale, consoleInput, pixels, winWidth, winHeight)
+	if c.errText != "" {
+		font.Draw(c.errText, hudX, y+consoleLineHeight, consoleScale, consoleError, pixels, winWidth, winHeight)
+	}
+}
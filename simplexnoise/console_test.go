@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestConsoleHistory(t *testing.T) {
+	var c console
+	for _, line := range []string{"set octaves 4", "help", "help", "  "} {
+		c.typeText(line)
+		c.submit()
+	}
+	if len(c.history) != 2 {
+		t.Fatalf("history %q", c.history)
+	}
+
+	c.typeText("pal")
+	c.previous()
+	if c.line != "help" {
+		t.Errorf("up shows %q", c.line)
+	}
+	c.previous()
+	c.previous()
+	if c.line != "set octaves 4" {
+		t.Errorf("up past the start shows %q", c.line)
+	}
+	c.next()
+	if c.line != "help" {
+		t.Errorf("down shows %q", c.line)
+	}
+	// the line being typed comes back after the newest
+	c.next()
+	c.next()
+	if c.line != "pal" {
+		t.Errorf("down past the end shows %q", c.line)
+	}
+
+	// a line from the history runs again and is not kept twice
+	c.previous()
+	if got := c.submit(); got != "help" || len(c.history) != 2 {
+		t.Errorf("submitted %q, history %q", got, c.history)
+	}
+	if c.line != "" || c.walk != 2 {
+		t.Errorf("line %q and walk %d after submitting", c.line, c.walk)
+	}
+}
+
+func TestConsoleTyping(t *testing.T) {
+	var c console
+	c.typeText("`set gain 0.5`")
+	if c.line != "set gain 0.5" {
+		t.Errorf("line %q", c.line)
+	}
+	c.typeText("é")
+	c.backspace()
+	c.backspace()
+	if c.line != "set gain 0." {
+		t.Errorf("line %q after backspaces", c.line)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sabith-th/games_with_go/font"
+)
+
+// rowRange is the rows start..end-1 of the window, it is empty if end is
+// not past start
+type rowRange struct {
+	start, end int
+}
+
+var allRows = rowRange{0, winHeight}
+
+func (r rowRange) empty() bool {
+	return r.end <= r.start
+}
+
+// union is the smallest range covering both
+func (r rowRange) union(o rowRange) rowRange {
+	if r.empty() {
+		return o
+	}
+	if o.empty() {
+		return r
+	}
+	if o.start < r.start {
+		r.start = o.start
+	}
+	if o.end > r.end {
+		r.end = o.end
+	}
+	return r
+}
+
+const (
+	hudX, hudY  = 8, 8
+	hudScale    = 2
+	hudPadding  = 4
+	hudInterval = 500 * time.Millisecond
+	hudBottom   = hudY + font.GlyphHeight*hudScale + hudPadding
+)
+
+// frameHUD shows the average frame time, the average upload time and how
+// many frames per second were uploaded at all. The text only changes every
+// hudInterval, so showing it does not force an upload every frame.
+type frameHUD struct {
+	text string
+	// preview marks the shown field as a scaled up preview
+	preview bool
+
+	last        time.Time
+	frames      int
+	frameTime   time.Duration
+	uploads     int
+	uploadTime  time.Duration
+	windowStart time.Time
+}
+
+func newFrameHUD(now time.Time) *frameHUD {
+	return &frameHUD{text: "measuring", last: now, windowStart: now}
+}
+
+// frame records one frame ending at now, uploaded or not, and reports
+// whether the text changed
+func (h *frameHUD) frame(now time.Time, uploaded bool, upload time.Duration) bool {
+	h.frames++
+	h.frameTime += now.Sub(h.last)
+	h.last = now
+	if uploaded {
+		h.uploads++
+		h.uploadTime += upload
+	}
+
+	elapsed := now.Sub(h.windowStart)
+	if elapsed < hudInterval {
+		return false
+	}
+	var uploadMs float64
+	if h.uploads > 0 {
+		uploadMs = ms(h.uploadTime / time.Duration(h.uploads))
+	}
+	h.text = fmt.Sprintf("frame %.1fms upload %.2fms %.0f/s",
+		ms(h.frameTime/time.Duration(h.frames)), uploadMs, float64(h.uploads)/elapsed.Seconds())
+	h.frames, h.frameTime, h.uploads, h.uploadTime = 0, 0, 0, 0
+	h.windowStart = now
+	return true
+}
+
+// setPreview sets whether the shown field is a preview and reports whether
+// that changed the text
+func (h *frameHUD) setPreview(preview bool) bool {
+	changed := preview != h.preview
+	h.preview = preview
+	return changed
+}
+
+// rows are the rows the hud covers
+func (h *frameHUD) rows() rowRange {
+	return rowRange{0, hudBottom}
+}
+
+func (h *frameHUD) draw(pixels []byte) {
+	text := h.text
+	if h.preview {
+		text += " preview"
+	}
+	w, _ := font.Size(text, hudScale)
+	fillRect(hudX-hudPadding, hudY-hudPadding, w+2*hudPadding, hudBottom-(hudY-hudPadding), color{0, 0, 0}, pixels)
+	font.Draw(text, hudX, hudY, hudScale, font.Color{R: 255, G: 255, B: 255}, pixels, winWidth, winHeight)
+}
+
+const (
+	flashScale    = 4
+	flashHeight   = font.GlyphHeight*flashScale + 2*hudPadding
+	flashY        = (winHeight - flashHeight) / 2
+	flashDuration = 1500 * time.Millisecond
+)
+
+// flash is a message across the middle of the window that goes away by
+// itself, saying what a key stepped to or what the sweep is doing
+type flash struct {
+	text  string
+	until time.Time
+}
+
+// show shows text from now for flashDuration, replacing any message
+func (f *flash) show(text string, now time.Time) {
+	f.text, f.until = text, now.Add(flashDuration)
+}
+
+// expire takes the message down once its time is up, it reports whether
+// there was one to take down
+func (f *flash) expire(now time.Time) bool {
+	if f.text == "" || now.Before(f.until) {
+		return false
+	}
+	f.text = ""
+	return true
+}
+
+// rows are the rows a message covers
+func (f *flash) rows() rowRange {
+	return rowRange{flashY, flashY + flashHeight}
+}
+
+func (f *flash) draw(pixels []byte) {
+	if f.text == "" {
+		return
+	}
+	w, _ := font.Size(f.text, flashScale)
+	x := (winWidth - w) / 2
+	fillRect(x-hudPadding, flashY, w+2*hudPadding, flashHeight, color{0, 0, 0}, pixels)
+	font.Draw(f.text, x, flashY+hudPadding, flashScale, font.Color{R: 255, G: 255, B: 255}, pixels, winWidth, winHeight)
+}
+
+const (
+	progressWidth, progressHeight = 400, 16
+	progressBorder                = 2
+	// progressInterval is how often the progress bar is shown while a
+	// field is generated, showing it waits for vsync
+	progressInterval = 50 * time.Millisecond
+)
+
+// progressBar shows how far the first field is, in the middle of an
+// otherwise black window
+type progressBar struct {
+	last time.Time
+}
+
+// draw draws the bar done/total of the way full. It reports whether the
+// bar is worth showing: it is at most every progressInterval, except when
+// it is full.
+func (pb *progressBar) draw(now time.Time, done, total int, pixels []byte) bool {
+	if done < total && now.Sub(pb.last) < progressInterval {
+		return false
+	}
+	pb.last = now
+	x, y := (winWidth-progressWidth)/2, (winHeight-progressHeight)/2
+	fillRect(x-progressBorder, y-progressBorder, progressWidth+2*progressBorder, progressHeight+2*progressBorder, color{255, 255, 255}, pixels)
+	fillRect(x, y, progressWidth, progressHeight, color{0, 0, 0}, pixels)
+	fillRect(x, y, progressWidth*done/total, progressHeight, color{80, 160, 244}, pixels)
+	return true
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+
+	"github.com/sabith-th/games_with_go/expr"
+)
+
+// formulaFiller fills fields with an -expr formula evaluated at the world
+// coordinates of each pixel. Only the view of the preset it is given
+// matters, the formula calls the noise with its own parameters.
+type formulaFiller struct {
+	pool    *workerPool
+	formula expr.Node
+}
+
+func (f formulaFiller) fill(ctx context.Context, buf *fieldBuffer, w, h int, p preset) (min, max float32, err error) {
+	return f.pool.fillSource(ctx, buf, w, h, p, formulaSource{f.formula})
+}
+
+// formulaSource samples a formula as a noise.Source
+type formulaSource struct {
+	formula expr.Node
+}
+
+func (f formulaSource) At(x, y float32) float32 {
+	return f.formula.Eval(x, y)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func FuzzClamp(f *testing.F) {
+	f.Add(0, 255, -1)
+	f.Add(0, 255, 256)
+	f.Add(0, 255, 100)
+	f.Add(5, 5, 5)
+	f.Add(math.MinInt, math.MaxInt, 0)
+	f.Fuzz(func(t *testing.T, min, max, v int) {
+		if min > max {
+			min, max = max, min
+		}
+		got := clamp(min, max, v)
+		if got < min || got > max {
+			t.Fatalf("clamp(%d, %d, %d) = %d, outside the range", min, max, v, got)
+		}
+		if v >= min && v <= max && got != v {
+			t.Fatalf("clamp(%d, %d, %d) = %d, v is already in range", min, max, v, got)
+		}
+	})
+}
+
+func FuzzLerp(f *testing.F) {
+	f.Add(byte(0), byte(255), float32(0.5))
+	f.Add(byte(255), byte(0), float32(1))
+	f.Add(byte(10), byte(10), float32(0.3))
+	f.Add(byte(200), byte(3), float32(0.999999))
+	f.Fuzz(func(t *testing.T, b1, b2 byte, pct float32) {
+		// the gradients only ever blend within 0..1
+		if !(pct >= 0 && pct <= 1) {
+			t.Skip()
+		}
+		got := lerp(b1, b2, pct)
+		lo, hi := b1, b2
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if got < lo || got > hi {
+			t.Fatalf("lerp(%d, %d, %v) = %d, outside %d..%d", b1, b2, pct, got, lo, hi)
+		}
+		if pct == 0 && got != b1 || pct == 1 && got != b2 {
+			t.Fatalf("lerp(%d, %d, %v) = %d, want the end itself", b1, b2, pct, got)
+		}
+	})
+}
+
+func FuzzSetPixel(f *testing.F) {
+	row := winWidth * 4
+	// the last pixel of a buffer of whole rows, which used to be refused
+	f.Add(winWidth-1, 1, 2*row)
+	f.Add(0, 0, row)
+	f.Add(-1, 1, 2*row)
+	f.Add(winWidth, 0, 2*row)
+	f.Add(3, 2, 2*row+17)
+	f.Add(0, 0, 3)
+	f.Add(math.MaxInt/winWidth, math.MaxInt/winWidth, row)
+	f.Fuzz(func(t *testing.T, x, y, size int) {
+		if size < 0 || size > 4*row {
+			t.Skip()
+		}
+		pixels := make([]byte, size)
+		setPixel(x, y, color{1, 2, 3}, pixels)
+		inside := x >= 0 && x < winWidth && y >= 0 && y < size/row
+		for i, v := range pixels {
+			want := byte(0)
+			if inside && i/4 == y*winWidth+x && i%4 < 3 {
+				want = byte(i%4 + 1)
+			}
+			if v != want {
+				t.Fatalf("setPixel(%d, %d) on %d bytes left byte %d at %d, want %d", x, y, size, i, v, want)
+			}
+		}
+	})
+}
+
+// FuzzRescaleAndDraw runs rescale and drawField on fields of any floats,
+// NaN and the infinities included, and on ranges the field given to it
+// may not even have
+func FuzzRescaleAndDraw(f *testing.F) {
+	floats := func(vs ...float32) []byte {
+		b := make([]byte, 4*len(vs))
+		for i, v := range vs {
+			binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(v))
+		}
+		return b
+	}
+	nan, inf := float32(math.NaN()), float32(math.Inf(1))
+	f.Add(floats(0, 0.5, 1), true, float32(0), float32(0))
+	f.Add(floats(0.25, 0.25, 0.25), true, float32(0), float32(0))
+	f.Add(floats(1, nan, -1), true, float32(0), float32(0))
+	f.Add(floats(1, inf, -1), true, float32(0), float32(0))
+	f.Add(floats(-inf, 3), true, float32(0), float32(0))
+	f.Add(floats(1, 2, 3), false, float32(2), float32(2))
+	f.Add(floats(1, 2, 3), false, float32(3), float32(1))
+	f.Add(floats(1, 2, 3), false, float32(0), float32(math.SmallestNonzeroFloat32))
+	f.Add(floats(1, 2, 3), false, -float32(math.MaxFloat32), float32(math.MaxFloat32))
+	f.Add(floats(), true, float32(0), float32(0))
+
+	// every index has its own color, so a pixel tells which index drew it
+	gradient := make([]color, 256)
+	for i := range gradient {
+		gradient[i] = color{byte(i), byte(255 - i), 7}
+	}
+	f.Fuzz(func(t *testing.T, data []byte, ownRange bool, min, max float32) {
+		// the order check below compares every pair
+		if len(data) > 4*1024 {
+			t.Skip()
+		}
+		field := make([]float32, len(data)/4)
+		for i := range field {
+			field[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+		values := append([]float32(nil), field...)
+		if ownRange {
+			// the range the pipeline would have measured
+			r := emptyRange()
+			for _, v := range field {
+				r.add(v)
+			}
+			min, max = r.min, r.max
+		}
+		pixels := make([]byte, len(field)*4)
+		rescale(field, min, max)
+		drawField(field, gradient, pixels)
+
+		flat := !(max > min) || math.IsInf(float64(max-min), 0) || math.IsInf(float64(255/(max-min)), 0)
+		index := make([]int, len(values))
+		for i := range values {
+			p := pixels[i*4 : i*4+4]
+			index[i] = int(p[0])
+			if p[1] != 255-p[0] || p[2] != 7 || p[3] != 0 {
+				t.Fatalf("pixel %d is %v, not a gradient color", i, p)
+			}
+			if flat && index[i] != gradientIndex(flatLevel) {
+				t.Fatalf("range %v..%v has no scale, yet pixel %d drew index %d", min, max, i, index[i])
+			}
+		}
+		// stretching keeps the order of the values, whatever clamping does
+		// at the ends
+		for i, a := range values {
+			for j, b := range values {
+				if a < b && index[i] > index[j] {
+					t.Fatalf("%v drew index %d and %v index %d, over the range %v..%v", a, index[i], b, index[j], min, max)
+				}
+			}
+		}
+	})
+}
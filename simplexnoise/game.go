@@ -0,0 +1,677 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sabith-th/games_with_go/export"
+	"github.com/sabith-th/games_with_go/gfx"
+	"github.com/sabith-th/games_with_go/noise"
+	"github.com/sabith-th/games_with_go/postfx"
+)
+
+// noiseGame is the window's state between frames. Update reacts to the
+// input and rebuilds frame when the field or the effects change, DrawRows
+// copies the changed rows of it to the window with the overlays on top.
+type noiseGame struct {
+	win *gfx.Window
+	p   preset
+
+	// frame holds what is actually shown: the noise plus any post effects.
+	// It is only rebuilt when the noise or the enabled effects change.
+	frame           []byte
+	spectrum        bool
+	bloom           bool
+	bloomPass       postfx.Bloom
+	chromatic       bool
+	chromaticOffset int
+	chromaticPass   postfx.Aberration
+	dirty           bool
+
+	log *statsLog
+	// updates stays nil without a server, so receiving from it never succeeds
+	server  *previewServer
+	updates <-chan paramsRequest
+	// commands are the server's console commands, run by the render loop
+	commands <-chan commandRequest
+
+	// palette counts gradient edits, so a field that was colored with an
+	// older gradient while it was generated can be recolored
+	editor       *gradientEditor
+	showEditor   bool
+	gradient     []color
+	palette      int
+	paletteIndex int
+
+	// pixels and field always point into shown, which goes back to gen for
+	// reuse once a newer field replaces it
+	gen    *generator
+	shown  *fieldBuffer
+	pixels []byte
+	field  []float32
+	// with -amortize the shown buffer is filled in place a few rows per
+	// frame and gen is never started
+	amortized *amortizedField
+	quality   *qualityGovernor
+	keys      *keyRepeater
+
+	// changed are the rows of the window that have not seen frame and the
+	// overlays yet, uploaded whether the last DrawRows had any
+	changed   rowRange
+	uploaded  bool
+	showHUD   bool
+	hud       *frameHUD
+	showLoupe bool
+	loupeX    int
+	loupeY    int
+	prevLeft  bool
+
+	// while prompt or the console is open typing goes to it instead of
+	// the key bindings
+	console   console
+	prompt    *textPrompt
+	lastSaved string
+	// watcher follows the last parameters file loaded, with -params or
+	// Ctrl+L, so it can be edited from outside the window
+	watcher *fileWatcher
+
+	// history holds the parameters and palettes before each change, walked
+	// is set when this frame's change was an undo or redo
+	history *undoStack
+	walked  bool
+
+	// slots are the presets on the number keys
+	slots *slotStore
+	flash flash
+	// toasts say what saving, loading and screenshots did
+	toasts toastQueue
+	// pads is how many game controllers are plugged in, the corner shows
+	// one is
+	pads int
+
+	// recorder records a macro while it is on. player plays one, macroFile
+	// being the last recorded or the -macro flag's, macroSpeed times as
+	// fast as it was recorded.
+	recorder   macroRecorder
+	player     *macroPlayer
+	macroFile  string
+	macroSpeed float64
+
+	// bindings are the keys, the defaults moved by any keymap file.
+	// showHelp dims the field under a list of them, helpShown is the list
+	// as last drawn.
+	bindings  []binding
+	showHelp  bool
+	helpShown string
+
+	// sweep animates the parameters while it is on. sweepWaiting is set
+	// while a field it started is being made, it only starts the next once
+	// that one is shown.
+	sweep        *sweep
+	sweepWaiting bool
+
+	// titles keeps the window's title from changing more often than
+	// titleInterval, see updateTitle
+	titles titleThrottle
+
+	// quit ends the window after this frame, see Done
+	quit bool
+}
+
+func (g *noiseGame) setPalette(i int) {
+	g.paletteIndex = i
+	g.editor.load(palettePresets[g.paletteIndex])
+	fmt.Println("palette:", palettePresets[g.paletteIndex].name)
+	g.gradient = g.editor.gradient()
+	g.palette++
+	drawField(g.field, g.gradient, g.pixels)
+	g.dirty = true
+}
+
+// loadParams replaces the preset with the one in path, it reports whether
+// that worked
+func (g *noiseGame) loadParams(path string) bool {
+	np, i, err := loadParams(path, g.p, g.paletteIndex)
+	if err != nil {
+		g.notify(fmt.Sprint("load failed: ", err))
+		return false
+	}
+	g.p = np
+	if i != g.paletteIndex {
+		g.setPalette(i)
+	}
+	return true
+}
+
+// promptKey edits the open prompt, Return loads the file it names
+func (g *noiseGame) promptKey(e gfx.KeyEvent) (keyChange bool) {
+	switch e.Scancode {
+	case gfx.KeyBackspace:
+		g.prompt.backspace()
+	case gfx.KeyReturn, gfx.KeyKPEnter:
+		if !g.loadParams(g.prompt.text) {
+			break
+		}
+		g.notify("loaded " + g.prompt.text)
+		keyChange = true
+		g.watcher.stop()
+		g.watcher = watchFile(g.prompt.text)
+		fallthrough
+	case gfx.KeyEscape:
+		g.changed = g.changed.union(g.prompt.rows())
+		g.prompt = nil
+		g.win.SetTextInput(false)
+	}
+	if g.prompt != nil {
+		g.changed = g.changed.union(g.prompt.rows())
+	}
+	return keyChange
+}
+
+// toggleConsole opens the console, or closes it
+func (g *noiseGame) toggleConsole() {
+	g.console.open = !g.console.open
+	g.win.SetTextInput(g.console.open)
+	g.changed = g.changed.union(g.console.rows())
+}
+
+// consoleKey edits the open console's line, Return runs it. It reports
+// whether a command changed the parameters.
+func (g *noiseGame) consoleKey(e gfx.KeyEvent) (keyChange bool) {
+	switch e.Scancode {
+	case gfx.KeyBackspace:
+		g.console.backspace()
+	case gfx.KeyTab:
+		line, matches := completeLine(g.console.line)
+		g.console.line = line
+		if len(matches) > 1 {
+			g.console.print(strings.Join(matches, " "))
+		}
+	case gfx.KeyUp:
+		g.console.previous()
+	case gfx.KeyDown:
+		g.console.next()
+	case gfx.KeyReturn, gfx.KeyKPEnter:
+		keyChange = g.runLine(g.console.submit())
+	case gfx.KeyEscape, g.boundKey("console"):
+		if !e.Repeat {
+			g.toggleConsole()
+		}
+	}
+	g.changed = g.changed.union(g.console.rows())
+	return keyChange
+}
+
+// runLine runs a line typed into the console, showing what it did or why
+// it failed
+func (g *noiseGame) runLine(line string) (keyChange bool) {
+	if line == "" {
+		return false
+	}
+	g.console.errText = ""
+	g.console.print(consolePrompt + line)
+	call, err := parseCommand(line)
+	if err != nil {
+		g.console.fail(err)
+		return false
+	}
+	before := g.p
+	out, err := call.run(g)
+	if err != nil {
+		g.console.fail(err)
+		return false
+	}
+	g.console.print(out)
+	return g.p != before
+}
+
+// toggleRecording starts recording a macro, or stops and saves it to a
+// timestamped file that playMacro then plays
+func (g *noiseGame) toggleRecording() {
+	if !g.recorder.on {
+		g.recorder.begin(g.snapshot(), time.Now())
+		g.notify("recording macro")
+		return
+	}
+	steps := g.recorder.end()
+	filename := fmt.Sprintf("noise_macro_%d.txt", time.Now().Unix())
+	err := saveMacro(filename, steps)
+	if err != nil {
+		g.notify(fmt.Sprint("saving macro failed: ", err))
+		return
+	}
+	g.macroFile = filename
+	g.notify(fmt.Sprint("saved ", len(steps), " steps to ", filename))
+}
+
+// playMacro plays macroFile from its start, or stops the one playing
+func (g *noiseGame) playMacro() {
+	if g.player != nil {
+		g.player = nil
+		g.notify("macro stopped")
+		return
+	}
+	if g.macroFile == "" {
+		g.notify("no macro, record one with " + g.boundKey("record-macro").String())
+		return
+	}
+	steps, err := loadMacro(g.macroFile)
+	if err != nil {
+		g.notify(fmt.Sprint("macro failed: ", err))
+		return
+	}
+	g.player = &macroPlayer{steps: steps, speed: g.macroSpeed}
+	g.notify("playing " + g.macroFile)
+}
+
+// the game is the console's commandTarget
+
+func (g *noiseGame) params() preset {
+	return g.p
+}
+
+func (g *noiseGame) setParams(p preset) {
+	g.p = p
+}
+
+func (g *noiseGame) usePalette(name string) error {
+	for i, pp := range palettePresets {
+		if pp.name == name {
+			g.setPalette(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown palette %q, expected one of %v", name, paletteNames())
+}
+
+// exportPNG writes the frame as shown, without the overlays
+func (g *noiseGame) exportPNG(path string) error {
+	err := export.WritePNG(path, g.frame, winWidth, winHeight)
+	if err != nil {
+		return err
+	}
+	g.notify("saved " + path)
+	return nil
+}
+
+// slotKey saves the parameters into slot n, or recalls them from it
+func (g *noiseGame) slotKey(n int, save bool) (keyChange bool) {
+	var msg string
+	if save {
+		msg = fmt.Sprint("slot ", n, " saved")
+		err := g.slots.assign(n, savedParams{g.p, palettePresets[g.paletteIndex].name})
+		if err != nil {
+			msg = fmt.Sprint("slot ", n, " not saved: ", err)
+		}
+	} else if p, palette, ok := g.slots.recall(n); ok {
+		g.restore(snapshot{p, palette})
+		msg = fmt.Sprint("slot ", n, " recalled")
+		keyChange = true
+	} else {
+		msg = fmt.Sprint("slot ", n, " is empty")
+	}
+	g.notify(msg)
+	return keyChange
+}
+
+// notify prints msg and shows it as a toast
+func (g *noiseGame) notify(msg string) {
+	fmt.Println(msg)
+	g.toasts.push(msg, time.Now())
+	g.changed = g.changed.union(g.toasts.rows())
+}
+
+// showSweep flashes what the sweep is doing
+func (g *noiseGame) showSweep(now time.Time) {
+	msg := g.sweep.status()
+	fmt.Println(msg)
+	g.flash.show(msg, now)
+	g.changed = g.changed.union(g.flash.rows())
+}
+
+// walkHistory undoes the last change, or redoes the last undo
+func (g *noiseGame) walkHistory(redo bool) (keyChange bool) {
+	walk, name := g.history.undo, "undo"
+	if redo {
+		walk, name = g.history.redo, "redo"
+	}
+	s, ok := walk(g.snapshot())
+	if !ok {
+		fmt.Println("nothing to", name)
+		return false
+	}
+	fmt.Println(name, changes(g.snapshot(), s))
+	g.restore(s)
+	g.walked = true
+	return true
+}
+
+// key runs the binding pressed, if any, with ctrl held only the ctrl
+// bindings act
+func (g *noiseGame) key(e gfx.KeyEvent) (keyChange bool) {
+	ctrl := e.Mod&gfx.ModCtrl != 0
+	for i := range g.bindings {
+		b := &g.bindings[i]
+		if k := b.index(e.Scancode); k >= 0 && b.press != nil && b.ctrl == ctrl {
+			return b.press(g, k, e)
+		}
+	}
+	return false
+}
+
+// noKeys stands in for the input while the prompt or the console is open,
+// so nothing held steps the preset
+var noKeys gfx.Input
+
+func (g *noiseGame) Update(in gfx.Input, dt float64) {
+	now := time.Now()
+	// the hud counts the frame that the last DrawRows ended
+	if g.hud.frame(now, g.uploaded, g.win.UploadTime()) && g.showHUD {
+		g.changed = g.changed.union(g.hud.rows())
+	}
+	if g.flash.expire(now) {
+		g.changed = g.changed.union(g.flash.rows())
+	}
+	if g.toasts.update(now) {
+		g.changed = g.changed.union(g.toasts.rows())
+	}
+
+	// every change to the parameters this frame goes on the undo stack as
+	// one, unless it was an undo or redo
+	before := g.snapshot()
+
+	// keyChange is set by keys that replace parts of p outright, and by
+	// the watched parameters file changing
+	keyChange := false
+	select {
+	case path := <-g.watcher.reloads():
+		if g.loadParams(path) {
+			g.notify("reloaded " + path)
+			keyChange = true
+		}
+	default:
+	}
+	if g.prompt != nil && in.Text != "" {
+		g.prompt.text += in.Text
+		g.changed = g.changed.union(g.prompt.rows())
+	}
+	if g.console.open && in.Text != "" {
+		g.console.typeText(in.Text)
+		g.changed = g.changed.union(g.console.rows())
+	}
+	for _, e := range in.Keys {
+		if !e.Down {
+			continue
+		}
+		if g.console.open {
+			keyChange = g.consoleKey(e) || keyChange
+		} else if g.prompt != nil {
+			keyChange = g.promptKey(e) || keyChange
+		} else if !e.Repeat {
+			keyChange = g.key(e) || keyChange
+		}
+	}
+
+	// apply everything posted since the last frame, then render once
+	remoteChange := false
+drainUpdates:
+	for {
+		select {
+		case req := <-g.updates:
+			before := g.p
+			g.p = req.apply(g.p)
+			remoteChange = remoteChange || g.p != before
+		case req := <-g.commands:
+			before := g.p
+			out, err := req.call.run(g)
+			req.reply <- commandReply{out, err}
+			remoteChange = remoteChange || g.p != before
+		default:
+			break drainUpdates
+		}
+	}
+	// a macro's steps run as though typed into the console
+	if g.player != nil {
+		for _, line := range g.player.advance(time.Duration(dt * float64(time.Second))) {
+			keyChange = g.runLine(line) || keyChange
+		}
+		if g.player.done() {
+			g.player = nil
+			g.notify("macro done")
+		}
+	}
+	regenerate := remoteChange || keyChange
+
+	// typing a filename or a command steps nothing, and with ctrl held
+	// the keys of shortcuts are left to them
+	stepKeys := &in
+	if g.prompt != nil || g.console.open {
+		stepKeys = &noKeys
+	}
+	ctrl := in.Held(gfx.KeyLCtrl) || in.Held(gfx.KeyRCtrl)
+	alt := in.Held(gfx.KeyLAlt) || in.Held(gfx.KeyRAlt)
+	scale := stepScale(ctrl, alt)
+
+	mult := 1
+	if in.Held(gfx.KeyLShift) || in.Held(gfx.KeyRShift) {
+		mult = -1
+	}
+	for i := range g.bindings {
+		b := &g.bindings[i]
+		if b.step == nil {
+			continue
+		}
+		for k, sc := range b.keys {
+			if ctrl && g.ctrlBound(sc) || !g.keys.pressed(stepKeys, sc, now) {
+				continue
+			}
+			if shown := b.step(&g.p, k, mult, scale); shown != "" {
+				g.flash.show(shown, now)
+				g.changed = g.changed.union(g.flash.rows())
+			}
+			regenerate = true
+		}
+	}
+
+	// the controller works alongside the keys, its sticks moving count as
+	// a step key held
+	padMoving := false
+	if in.Pad.Active() && stepKeys == &in {
+		regenerate = g.padButtons(&in.Pad, now) || regenerate
+		if d := padDeltas(in.Pad.Axes, dt); !d.zero() {
+			g.p = d.apply(g.p)
+			padMoving, regenerate = true, true
+		}
+	}
+	if n := len(in.Pad.Connected); n != g.pads {
+		if n > g.pads {
+			g.notify("controller connected")
+		} else {
+			g.notify("controller disconnected")
+		}
+		if n == 0 || g.pads == 0 {
+			g.changed = g.changed.union(padRows())
+		}
+		g.pads = n
+	}
+
+	if key := changes(before, g.snapshot()); key != "" && !g.walked {
+		g.history.push(before, key, now)
+	}
+	g.walked = false
+
+	// the sweep's changes stay off the undo stack. Any other change to
+	// the parameters pauses it, so the keys are never fought over.
+	sweepStart := false
+	if g.sweep.state == sweepOn {
+		if regenerate {
+			g.sweep.state = sweepPaused
+			g.showSweep(now)
+		} else {
+			if !g.sweepWaiting {
+				g.p = g.sweep.preset()
+				sweepStart, g.sweepWaiting = true, true
+			}
+			g.sweep.advance(dt, sweepStart)
+		}
+	}
+
+	if g.amortized != nil {
+		if regenerate || sweepStart {
+			g.amortized.start(g.p)
+		}
+		if rows, t, done := g.amortized.step(g.shown, g.gradient); !rows.empty() {
+			if done {
+				g.log.record(t)
+				g.sweepWaiting = false
+			}
+			g.dirty = true
+		}
+	} else if sweepStart {
+		// the sweep is always shown at full resolution, it only moves
+		// on as fast as fields can be made
+		g.gen.start(g.p, g.gradient, g.palette, false)
+	} else {
+		if start, preview := g.quality.update(now, g.stepHeld(stepKeys, ctrl) || padMoving, regenerate); start {
+			g.gen.start(g.p, g.gradient, g.palette, preview)
+		}
+	}
+	if r, ok := g.gen.poll(); ok {
+		g.sweepWaiting = false
+		g.gen.release(g.shown)
+		g.shown = r.buf
+		g.pixels, g.field = g.shown.pixels, g.shown.noise
+		if r.palette != g.palette {
+			drawField(g.field, g.gradient, g.pixels)
+		}
+		if g.hud.setPreview(r.preview) && g.showHUD {
+			g.changed = g.changed.union(g.hud.rows())
+		}
+		g.dirty = true
+	}
+
+	g.recorder.record(g.snapshot(), now)
+	g.updateTitle(now)
+
+	// clicks and drags can open, close or move the picker even when
+	// they do not change the gradient
+	left := in.Buttons&gfx.MouseLeft != 0
+	if g.showEditor && (left || g.prevLeft) {
+		g.changed = g.changed.union(g.editor.rows())
+	}
+	if g.showEditor && g.editor.handleMouse(in.MouseX, in.MouseY, left, g.prevLeft) {
+		g.gradient = g.editor.gradient()
+		g.palette++
+		drawField(g.field, g.gradient, g.pixels)
+		g.dirty = true
+	}
+	g.prevLeft = left
+
+	// the loupe shows while left alt is held and prints what is under
+	// the mouse whenever it moves, but not while alt makes steps coarser
+	loupe := in.Held(g.boundKey("loupe")) && !g.stepHeld(stepKeys, ctrl)
+	mx, my := in.MouseX, in.MouseY
+	if loupe != g.showLoupe || (loupe && (mx != g.loupeX || my != g.loupeY)) {
+		g.changed = g.changed.union(loupeRows(g.loupeY)).union(loupeRows(my))
+		if loupe && mx >= 0 && mx < winWidth && my >= 0 && my < winHeight {
+			wx, wy := g.p.View.at(mx, my)
+			fmt.Println("x", wx, "y", wy, "snoise2", noise.Snoise2Wide(wx*float64(g.p.Frequency), wy*float64(g.p.Frequency)))
+		}
+		g.showLoupe, g.loupeX, g.loupeY = loupe, mx, my
+	}
+
+	// the values in the help follow the settings
+	if g.showHelp {
+		if text := g.helpText(); text != g.helpShown {
+			g.helpShown = text
+			g.changed = allRows
+		}
+	}
+
+	if g.dirty {
+		if g.spectrum {
+			drawSpectrum(g.field, winWidth, winHeight, g.frame)
+		} else {
+			copy(g.frame, g.pixels)
+		}
+		if g.bloom {
+			g.bloomPass.Apply(g.frame, winWidth, winHeight, 160, 1.0)
+		}
+		if g.chromatic {
+			// red and blue move apart in opposite directions, green stays put
+			g.chromaticPass.Apply(g.frame, winWidth, winHeight, -g.chromaticOffset, 0, g.chromaticOffset)
+		}
+		if g.server != nil {
+			g.server.publish(g.frame, g.p)
+		}
+		g.dirty = false
+		g.changed = allRows
+	}
+}
+
+// updateTitle shows the parameters in the window's title, with how far an
+// amortized pass has got. A change held back by the throttle is shown on a
+// later frame.
+func (g *noiseGame) updateTitle(now time.Time) {
+	progress := -1.0
+	if g.amortized != nil {
+		progress = g.amortized.progress()
+	}
+	title := formatTitle(g.p, progress)
+	if title != g.win.Title() && g.titles.ready(now) {
+		g.win.SetTitle(title)
+	}
+}
+
+// DrawRows copies the changed rows of frame to display and draws the
+// overlays over them, so they never end up in the screenshots or the
+// preview stream. Frames where nothing changed are not uploaded at all.
+func (g *noiseGame) DrawRows(display []byte, w, h int) (start, end int) {
+	g.uploaded = !g.changed.empty()
+	if !g.uploaded {
+		return 0, 0
+	}
+	r := g.changed
+	copy(display[r.start*w*4:r.end*w*4], g.frame[r.start*w*4:r.end*w*4])
+	if g.showHelp {
+		dim(r, display)
+	}
+	if g.showEditor {
+		g.editor.draw(g.gradient, display)
+	}
+	if g.showHUD {
+		g.hud.draw(display)
+	}
+	if g.showLoupe {
+		drawLoupe(g.loupeX, g.loupeY, g.frame, display)
+	}
+	if g.prompt != nil {
+		g.prompt.draw(display)
+	}
+	if g.console.open {
+		g.console.draw(display)
+	}
+	if g.showHelp {
+		drawHelp(g.helpShown, display)
+	}
+	if g.pads > 0 {
+		drawPadIndicator(display)
+	}
+	g.flash.draw(display)
+	g.toasts.draw(r, display, time.Now())
+	g.changed = rowRange{}
+	return r.start, r.end
+}
+
+// Done ends the window's Run once quit is pressed, the app's close then
+// shuts down what is still running
+func (g *noiseGame) Done() bool {
+	return g.quit
+}
+
+// Draw redraws the whole window
+func (g *noiseGame) Draw(display []byte, w, h int) {
+	g.changed = allRows
+	g.DrawRows(display, w, h)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// maxFreeBuffers is how many idle field buffers the generator keeps. One is
+// shown, one is being generated and one may belong to a canceled field that
+// has not noticed yet, so this is enough to stop allocating after warm up.
+const maxFreeBuffers = 3
+
+// generation is a finished field and the palette its pixels were colored
+// with. A preview was generated at lower resolution and scaled up.
+type generation struct {
+	seq     int
+	palette int
+	preview bool
+	buf     *fieldBuffer
+}
+
+// generator makes fields on a background goroutine so the render loop keeps
+// running while they are computed. Starting a new field cancels the one in
+// flight, so only the latest parameters are ever finished. Buffers are
+// recycled through free instead of allocated for every field. filler is
+// usually an octaveCache, so the octave layers they are summed from are
+// kept between fields. Every finished full
+// resolution field's timings go to log, previews would only skew them. It
+// is only used from the main goroutine.
+type generator struct {
+	filler fieldFiller
+	log    *statsLog
+	w, h   int
+	seq    int
+	cancel context.CancelFunc
+	// running counts the fields still being made, canceled or not
+	running sync.WaitGroup
+	results chan generation
+	free    chan *fieldBuffer
+	// small holds the buffers previews are generated into before they are
+	// scaled up
+	small chan *fieldBuffer
+}
+
+func newGenerator(filler fieldFiller, w, h int, log *statsLog) *generator {
+	return &generator{
+		filler:  filler,
+		log:     log,
+		w:       w,
+		h:       h,
+		results: make(chan generation, 1),
+		free:    make(chan *fieldBuffer, maxFreeBuffers),
+		small:   make(chan *fieldBuffer, maxFreeBuffers),
+	}
+}
+
+func (g *generator) buffer() *fieldBuffer {
+	select {
+	case buf := <-g.free:
+		return buf
+	default:
+		return newFieldBuffer(g.w, g.h)
+	}
+}
+
+// release hands a buffer that is no longer shown back for reuse
+func (g *generator) release(buf *fieldBuffer) {
+	select {
+	case g.free <- buf:
+	default:
+	}
+}
+
+func (g *generator) smallBuffer() *fieldBuffer {
+	select {
+	case buf := <-g.small:
+		return buf
+	default:
+		return newFieldBuffer(previewSize(g.w, g.h))
+	}
+}
+
+func (g *generator) releaseSmall(buf *fieldBuffer) {
+	select {
+	case g.small <- buf:
+	default:
+	}
+}
+
+// start cancels any field in flight and begins one for p, colored with
+// gradient, at full resolution or as a preview. palette is handed back with
+// the result.
+func (g *generator) start(p preset, gradient []color, palette int, preview bool) {
+	g.stop()
+	g.seq++
+	ctx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+	buf := g.buffer()
+	g.running.Add(1)
+	go func(seq int) {
+		defer g.running.Done()
+		var t timing
+		var err error
+		if preview {
+			small := g.smallBuffer()
+			t, err = makePreview(ctx, g.filler, small, buf, g.w, g.h, p, gradient)
+			g.releaseSmall(small)
+		} else {
+			t, err = makeNoise(ctx, g.filler, buf, g.w, g.h, p, gradient)
+		}
+		if err != nil {
+			g.release(buf)
+			return
+		}
+		if !preview {
+			g.log.record(t)
+		}
+		// an older result nobody polled can still be in the way, a field
+		// canceled meanwhile will never be polled either
+		select {
+		case g.results <- generation{seq, palette, preview, buf}:
+		case <-ctx.Done():
+			g.release(buf)
+		}
+	}(g.seq)
+}
+
+// poll returns the latest finished field if there is a new one. Fields that
+// finished just before being canceled are dropped.
+func (g *generator) poll() (generation, bool) {
+	for {
+		select {
+		case r := <-g.results:
+			if r.seq == g.seq {
+				return r, true
+			}
+			g.release(r.buf)
+		default:
+			return generation{}, false
+		}
+	}
+}
+
+// stop cancels the field in flight, if any
+func (g *generator) stop() {
+	if g.cancel != nil {
+		g.cancel()
+		g.cancel = nil
+	}
+}
+
+// wait returns once every field started has finished or given up, after
+// stop nothing is left using the filler or the log
+func (g *generator) wait() {
+	g.running.Wait()
+}
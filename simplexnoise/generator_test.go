@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+// cancelBound is how long a canceled field may keep the workers busy. They
+// check between rows and a row of slowPreset at slowSize takes well under
+// a millisecond, the rest is slack for a loaded machine.
+const cancelBound = 250 * time.Millisecond
+
+// slowSize and slowPreset make a field that takes seconds to fill, so it is
+// nowhere near done when it is canceled
+const slowSize = 2048
+
+func slowPreset() preset {
+	p := defaultPreset()
+	p.Octaves = maxOctaves
+	return p
+}
+
+func TestGeneratorCancelIsPrompt(t *testing.T) {
+	pool := newWorkerPool(4)
+	defer pool.close()
+	gen := newGenerator(pool, slowSize, slowSize, newStatsLog(io.Discard, 1, false))
+	gen.start(slowPreset(), defaultGradient, 0, false)
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	gen.stop()
+	within(t, "wait", gen.wait)
+	if elapsed := time.Since(start); elapsed > cancelBound {
+		t.Errorf("the workers took %v to stop after a cancel, want under %v", elapsed, cancelBound)
+	}
+	if _, ok := gen.poll(); ok {
+		t.Error("a canceled field was handed back")
+	}
+}
+
+func TestPoolFillCancel(t *testing.T) {
+	pool := newWorkerPool(4)
+	defer pool.close()
+	buf := newFieldBuffer(slowSize, slowSize)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := pool.fill(ctx, buf, slowSize, slowSize, slowPreset())
+	if err != context.DeadlineExceeded {
+		t.Fatalf("fill returned %v, want the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond+cancelBound {
+		t.Errorf("fill took %v to return with a 20ms deadline, want under %v", elapsed, 20*time.Millisecond+cancelBound)
+	}
+	// the workers are free again straight away
+	small := newFieldBuffer(8, 8)
+	within(t, "fill after a cancel", func() {
+		_, _, err := pool.fill(context.Background(), small, 8, 8, defaultPreset())
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sabith-th/games_with_go/export"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite the golden images in testdata instead of comparing against them")
+
+const (
+	goldenSize = 256
+	// goldenTolerance is how far any channel may be from the golden image,
+	// enough for rounding differences between platforms but not for a change
+	// to the noise
+	goldenTolerance = 2
+)
+
+// TestGolden renders every noise mode at the default parameters and compares
+// it to testdata/golden_<mode>.png. Run with -update-golden to accept a
+// deliberate change to the output.
+func TestGolden(t *testing.T) {
+	for mode := noiseMode(0); mode < numNoiseModes; mode++ {
+		mode := mode
+		name := noiseModeNames[mode]
+		t.Run(name, func(t *testing.T) {
+			p := defaultPreset()
+			p.Mode = mode
+			pixels := GenerateNoise(p, goldenSize, goldenSize)
+			path := filepath.Join("testdata", "golden_"+name+".png")
+			if *updateGolden {
+				err := os.MkdirAll("testdata", 0755)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = export.WritePNG(path, pixels, goldenSize, goldenSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			compareGolden(t, path, pixels, goldenSize, goldenSize)
+		})
+	}
+}
+
+// compareGolden fails t if any channel of a w*h pixel buffer is more than
+// goldenTolerance away from the png at path. Alpha is not compared, the
+// demos leave it at zero and the pngs are opaque.
+func compareGolden(t *testing.T, path string, pixels []byte, w, h int) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v, run go test -update-golden to create it", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := img.Bounds()
+	if b.Dx() != w || b.Dy() != h {
+		t.Fatalf("%s is %dx%d, the render is %dx%d", path, b.Dx(), b.Dy(), w, h)
+	}
+
+	bad := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			want := [3]int{int(r >> 8), int(g >> 8), int(bl >> 8)}
+			i := (y*w + x) * 4
+			for c := 0; c < 3; c++ {
+				d := int(pixels[i+c]) - want[c]
+				if d < -goldenTolerance || d > goldenTolerance {
+					if bad == 0 {
+						t.Errorf("pixel %d,%d is %v, golden has %v", x, y, pixels[i:i+3], want)
+					}
+					bad++
+					break
+				}
+			}
+		}
+	}
+	if bad > 0 {
+		t.Errorf("%d of %d pixels differ from %s by more than %d", bad, w*h, path, goldenTolerance)
+	}
+}
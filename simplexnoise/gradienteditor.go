@@ -0,0 +1,183 @@
+package main
+
+// gradientStop is a color at a position in 0..1 along a gradient. Two stops
+// at the same position make a hard edge.
+type gradientStop struct {
+	pos   float32
+	color color
+}
+
+// defaultStops reproduces getDualGradient for the original colors, the
+// second half starts a quarter of the way from its first color
+func defaultStops() []gradientStop {
+	c1, c2, c3, c4 := color{0, 0, 175}, color{80, 160, 244}, color{12, 192, 75}, color{255, 255, 255}
+	return []gradientStop{{0, c1}, {0.5, c2}, {0.5, colorlerp(c3, c4, 0.25)}, {1, c4}}
+}
+
+// palettePreset is a named set of stops. With hue set the stops are blended
+// in hsv space instead of rgb.
+type palettePreset struct {
+	name  string
+	stops []gradientStop
+	hue   bool
+}
+
+var palettePresets = []palettePreset{
+	{"ocean", defaultStops(), false},
+	{"fire", []gradientStop{
+		{0, color{0, 0, 0}},
+		{0.35, HSLtoRGB(ColorHSL{0, 1, 0.3})},
+		{0.65, HSLtoRGB(ColorHSL{30, 1, 0.5})},
+		{0.85, HSLtoRGB(ColorHSL{55, 1, 0.6})},
+		{1, color{255, 255, 255}},
+	}, false},
+	// 330 to 30 crosses 0, which only the hue blend gets right
+	{"sunset", []gradientStop{
+		{0, HSLtoRGB(ColorHSL{260, 0.6, 0.2})},
+		{0.5, HSLtoRGB(ColorHSL{330, 0.7, 0.5})},
+		{1, HSLtoRGB(ColorHSL{30, 0.9, 0.65})},
+	}, true},
+	{"rainbow", []gradientStop{
+		{0, HSVtoRGB(ColorHSV{240, 1, 1})},
+		{0.5, HSVtoRGB(ColorHSV{120, 1, 1})},
+		{1, HSVtoRGB(ColorHSV{0, 1, 1})},
+	}, true},
+	{"moss", []gradientStop{
+		{0, HSLtoRGB(ColorHSL{90, 0.3, 0.15})},
+		{0.6, HSLtoRGB(ColorHSL{110, 0.5, 0.45})},
+		{1, HSLtoRGB(ColorHSL{60, 0.4, 0.8})},
+	}, false},
+}
+
+// getStopGradient builds a 256 entry gradient from stops sorted by position,
+// blending neighbouring stops in hsv when hue is set
+func getStopGradient(stops []gradientStop, hue bool) []color {
+	result := make([]color, 256)
+	for i := range result {
+		pct := float32(i) / float32(255)
+		k := 0
+		for k+2 < len(stops) && stops[k+1].pos <= pct {
+			k++
+		}
+		a, b := stops[k], stops[k+1]
+		t := float32(1)
+		if b.pos > a.pos {
+			t = (pct - a.pos) / (b.pos - a.pos)
+		}
+		if t < 0 {
+			t = 0
+		} else if t > 1 {
+			t = 1
+		}
+		if hue {
+			result[i] = hsvLerp(a.color, b.color, t)
+		} else {
+			result[i] = colorlerp(a.color, b.color, t)
+		}
+	}
+	return result
+}
+
+const (
+	barX         = 20
+	barHeight    = 20
+	barWidth     = winWidth - 2*barX
+	barY         = winHeight - 50
+	markerSize   = 10
+	markerY      = barY + barHeight + 4
+	panelPadding = 8
+)
+
+// gradientEditor draws the gradient as a bar along the bottom of the window
+// with a marker under each stop. Clicking a marker opens a color picker for
+// that stop, clicking the same place again moves on to the next stop there.
+type gradientEditor struct {
+	stops    []gradientStop
+	hue      bool
+	selected int
+	picker   colorPicker
+}
+
+func newGradientEditor(p palettePreset) *gradientEditor {
+	ge := &gradientEditor{picker: colorPicker{x: barX + panelPadding, y: barY - panelPadding - pickerSize - 2*pickerBorder}}
+	ge.load(p)
+	return ge
+}
+
+// load replaces the stops with a copy of the preset's, closing the picker
+func (ge *gradientEditor) load(p palettePreset) {
+	ge.stops = append([]gradientStop(nil), p.stops...)
+	ge.hue = p.hue
+	ge.selected = -1
+}
+
+func (ge *gradientEditor) gradient() []color {
+	return getStopGradient(ge.stops, ge.hue)
+}
+
+func (ge *gradientEditor) markerX(i int) int {
+	return barX + int(ge.stops[i].pos*float32(barWidth-1)) - markerSize/2
+}
+
+// stopAt returns the stop whose marker is under the mouse, preferring the
+// first one after the current selection so coincident stops can be reached
+func (ge *gradientEditor) stopAt(mx, my int) int {
+	var hits []int
+	for i := range ge.stops {
+		if inRect(mx, my, ge.markerX(i), markerY, markerSize, markerSize) {
+			hits = append(hits, i)
+		}
+	}
+	if len(hits) == 0 {
+		return -1
+	}
+	for _, i := range hits {
+		if i > ge.selected {
+			return i
+		}
+	}
+	return hits[0]
+}
+
+// handleMouse returns true when a stop changed color
+func (ge *gradientEditor) handleMouse(mx, my int, down, wasDown bool) bool {
+	if down && !wasDown {
+		if i := ge.stopAt(mx, my); i >= 0 {
+			ge.selected = i
+			ge.picker.setColor(ge.stops[i].color)
+			return false
+		}
+		if ge.selected >= 0 && !ge.picker.contains(mx, my) {
+			ge.selected = -1
+		}
+	}
+	if ge.selected < 0 || !ge.picker.handleMouse(mx, my, down, wasDown) {
+		return false
+	}
+	ge.stops[ge.selected].color = ge.picker.color()
+	return true
+}
+
+// rows are the rows the editor can cover, with the picker open
+func (ge *gradientEditor) rows() rowRange {
+	return rowRange{ge.picker.y - pickerBorder, winHeight}
+}
+
+func (ge *gradientEditor) draw(gradient []color, pixels []byte) {
+	fillRect(barX-panelPadding, barY-panelPadding, barWidth+2*panelPadding,
+		markerY+markerSize+panelPadding-(barY-panelPadding), color{30, 30, 30}, pixels)
+	for x := 0; x < barWidth; x++ {
+		fillRect(barX+x, barY, 1, barHeight, gradient[x*255/(barWidth-1)], pixels)
+	}
+	for i, s := range ge.stops {
+		border := color{200, 200, 200}
+		if i == ge.selected {
+			border = color{255, 255, 0}
+		}
+		fillRect(ge.markerX(i), markerY, markerSize, markerSize, border, pixels)
+		fillRect(ge.markerX(i)+2, markerY+2, markerSize-4, markerSize-4, s.color, pixels)
+	}
+	if ge.selected >= 0 {
+		ge.picker.draw(pixels)
+	}
+}
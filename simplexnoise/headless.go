@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// GenerateNoise renders a w*h field for p and colors it with
+// defaultGradient, without a window, a pool or any cache. It evaluates
+// every pixel on the calling goroutine in a fixed order and the noise has
+// no seed, so the same arguments always give the same pixels, which is
+// what the golden tests compare against.
+func GenerateNoise(p preset, w, h int) []byte {
+	// a background context is never canceled and the buffer is made for
+	// w*h, only an invalid p can fail and that renders nothing
+	pixels, _ := generateNoise(context.Background(), p, w, h, nil)
+	return pixels
+}
+
+// generateNoise is GenerateNoise through noise.Generate, so it can be
+// canceled and report its progress, which it passes on. Its pixels are the
+// pool's for every mode and basis, except where a view is far enough out
+// for the pool to sample simplex turbulence in float64.
+func generateNoise(ctx context.Context, p preset, w, h int, progress func(done, total int)) ([]byte, error) {
+	return generateSource(ctx, p.source(w, h), p, w, h, progress)
+}
+
+// generateSource is generateNoise with src sampled over p's view instead of
+// p's own noise, as fillSource is to fill
+func generateSource(ctx context.Context, src noise.Source, p preset, w, h int, progress func(done, total int)) ([]byte, error) {
+	buf := newFieldBuffer(w, h)
+	err := generateField(ctx, buf, src, p, w, h, defaultGradient, progress)
+	if err != nil {
+		return nil, err
+	}
+	return buf.pixels, nil
+}
+
+// fillerSource is the noise filler fills p's w*h fields with: the formula
+// of a formulaFiller, p's own noise otherwise
+func fillerSource(filler fieldFiller, p preset, w, h int) noise.Source {
+	if f, ok := filler.(formulaFiller); ok {
+		return formulaSource{f.formula}
+	}
+	return p.source(w, h)
+}
+
+// generateField fills and draws buf like makeNoise, but with noise.Generate
+// sampling src on the calling goroutine
+func generateField(ctx context.Context, buf *fieldBuffer, src noise.Source, p preset, w, h int, gradient []color, progress func(done, total int)) error {
+	err := checkField(buf, w, h, p, true)
+	if err != nil {
+		return err
+	}
+	cfg := p.block(w, h)
+	cfg.Source = src
+	field, err := noise.Generate(ctx, cfg, progress)
+	if err != nil {
+		return err
+	}
+	r := emptyRange()
+	for _, v := range field {
+		r.add(v)
+	}
+	copy(buf.noise, field)
+	rescale(buf.noise, r.min, r.max)
+	drawField(buf.noise, gradient, buf.pixels)
+	return nil
+}
+
+// percentPrinter is a progress callback for the headless modes. It prints
+// what is being done and how far it is to w, rewriting the line whenever
+// the whole percentage changes and ending it once everything is done.
+func percentPrinter(w io.Writer, what string) func(done, total int) {
+	last := -1
+	return func(done, total int) {
+		percent := done * 100 / total
+		if percent == last {
+			return
+		}
+		last = percent
+		fmt.Fprintf(w, "\r%s %d%%", what, percent)
+		if done == total {
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+// A keymap file moves actions onto other keys. It is one JSON object from
+// action names to SDL's names for the keys, as -print-keymap writes it:
+//
+//	{
+//	  "octaves": "K",
+//	  "loupe": "Right Alt"
+//	}
+//
+// Actions left out keep their keys. An entry naming an action or a key
+// that does not exist is an error and changes nothing, and so is one
+// putting an action on a key another already has, ctrl bindings apart
+// from the rest.
+
+// loadKeymap reads the keymap file at path over defaults. The bindings
+// returned always work: every entry that cannot be used is left at its
+// default and reported in the error.
+func loadKeymap(path string, defaults []binding) ([]binding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return copyBindings(defaults), err
+	}
+	return applyKeymap(defaults, data, path)
+}
+
+// copyBindings copies bs deep enough that moving keys leaves bs alone
+func copyBindings(bs []binding) []binding {
+	c := make([]binding, len(bs))
+	for i, b := range bs {
+		b.keys = append([]gfx.Scancode(nil), b.keys...)
+		c[i] = b
+	}
+	return c
+}
+
+// findAction is the binding with action among bs and the index of the
+// action in it, nil if there is none
+func findAction(bs []binding, action string) (*binding, int) {
+	for i := range bs {
+		for k, a := range bs[i].actions {
+			if a == action {
+				return &bs[i], k
+			}
+		}
+	}
+	return nil, -1
+}
+
+// applyKeymap applies the keymap in data, read from source, to a copy of
+// defaults
+func applyKeymap(defaults []binding, data []byte, source string) ([]binding, error) {
+	bs := copyBindings(defaults)
+	var entries map[string]string
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return bs, fmt.Errorf("%s: %v", source, err)
+	}
+	actions := make([]string, 0, len(entries))
+	for a := range entries {
+		actions = append(actions, a)
+	}
+	sort.Strings(actions)
+
+	var errs []error
+	moved := make(map[string]bool)
+	for _, a := range actions {
+		b, i := findAction(bs, a)
+		if b == nil {
+			errs = append(errs, fmt.Errorf("%s: unknown action %q", source, a))
+			continue
+		}
+		sc, err := gfx.ParseScancode(entries[a])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %v", source, a, err))
+			continue
+		}
+		if sc != b.keys[i] {
+			b.keys[i] = sc
+			moved[a] = true
+		}
+	}
+
+	// an action moved onto a key that is taken goes back, which can free
+	// a key another was moved off; the defaults never clash, so it ends
+	for {
+		clashes := keyConflicts(bs)
+		back := false
+		for _, c := range clashes {
+			for _, a := range []string{c.a, c.b} {
+				if !moved[a] {
+					continue
+				}
+				moved[a], back = false, true
+				b, i := findAction(bs, a)
+				d, _ := findAction(defaults, a)
+				b.keys[i] = d.keys[i]
+				errs = append(errs, fmt.Errorf("%s: %s and %s are both on %s, %s stays on %s", source, c.a, c.b, c.key, a, b.keys[i]))
+			}
+		}
+		if !back {
+			break
+		}
+	}
+
+	// groups of keys keep their short name until one of them moves
+	for i := range bs {
+		for k := range bs[i].keys {
+			if bs[i].keys[k] != defaults[i].keys[k] {
+				bs[i].name = ""
+			}
+		}
+	}
+	return bs, errors.Join(errs...)
+}
+
+// keyConflict is two actions on one key
+type keyConflict struct {
+	key  gfx.Scancode
+	a, b string
+}
+
+// keyConflicts finds every pair of actions on the same key. Ctrl bindings
+// only clash with each other, as do the rest.
+func keyConflicts(bs []binding) []keyConflict {
+	type owner struct {
+		key  gfx.Scancode
+		ctrl bool
+	}
+	taken := make(map[owner]string)
+	var clashes []keyConflict
+	for _, b := range bs {
+		for i, k := range b.keys {
+			o := owner{k, b.ctrl}
+			if a, ok := taken[o]; ok {
+				clashes = append(clashes, keyConflict{k, a, b.actions[i]})
+				continue
+			}
+			taken[o] = b.actions[i]
+		}
+	}
+	return clashes
+}
+
+// printKeymap writes bs as a keymap file, every action in the order of the
+// help
+func printKeymap(w io.Writer, bs []binding) error {
+	var lines []string
+	for _, b := range bs {
+		for i, a := range b.actions {
+			action, _ := json.Marshal(a)
+			key, _ := json.Marshal(b.keys[i].String())
+			lines = append(lines, fmt.Sprintf("  %s: %s", action, key))
+		}
+	}
+	_, err := fmt.Fprintf(w, "{\n%s\n}\n", strings.Join(lines, ",\n"))
+	return err
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+// keyOf is the key bound to action among bs
+func keyOf(t *testing.T, bs []binding, action string) gfx.Scancode {
+	t.Helper()
+	b, i := findAction(bs, action)
+	if b == nil {
+		t.Fatalf("no action %q", action)
+	}
+	return b.keys[i]
+}
+
+func TestDefaultBindingsDoNotConflict(t *testing.T) {
+	if c := keyConflicts(defaultBindings); len(c) > 0 {
+		t.Errorf("default bindings conflict: %v", c)
+	}
+	seen := make(map[string]bool)
+	for _, b := range defaultBindings {
+		if len(b.actions) != len(b.keys) {
+			t.Errorf("%v has %d actions for %d keys", b.actions, len(b.actions), len(b.keys))
+		}
+		for _, a := range b.actions {
+			if seen[a] {
+				t.Errorf("action %q is named twice", a)
+			}
+			seen[a] = true
+		}
+	}
+}
+
+func TestKeyConflicts(t *testing.T) {
+	bs := copyBindings(defaultBindings)
+	b, i := findAction(bs, "bloom")
+	b.keys[i] = gfx.KeyO
+	c := keyConflicts(bs)
+	if len(c) != 1 || c[0].key != gfx.KeyO || c[0].a != "octaves" || c[0].b != "bloom" {
+		t.Errorf("conflicts %v, want octaves and bloom on O", c)
+	}
+
+	// ctrl+L loads and L steps the lacunarity, ctrl bindings only clash
+	// with each other
+	bs = copyBindings(defaultBindings)
+	b, i = findAction(bs, "undo")
+	b.keys[i] = gfx.KeyL
+	c = keyConflicts(bs)
+	if len(c) != 1 || c[0].a != "load" || c[0].b != "undo" {
+		t.Errorf("conflicts %v, want load and undo on ctrl+L", c)
+	}
+}
+
+func TestApplyKeymap(t *testing.T) {
+	bs, err := applyKeymap(defaultBindings, []byte(`{"octaves": "k", "loupe": "Right Alt", "pan-left": "Q"}`), "keys.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for action, want := range map[string]gfx.Scancode{
+		"octaves":   gfx.KeyK,
+		"loupe":     gfx.KeyRAlt,
+		"pan-left":  gfx.KeyQ,
+		"pan-right": gfx.KeyRight,
+		"gain":      gfx.KeyG,
+	} {
+		if got := keyOf(t, bs, action); got != want {
+			t.Errorf("%s is on %v, want %v", action, got, want)
+		}
+	}
+	// the defaults are left alone
+	if got := keyOf(t, defaultBindings, "octaves"); got != gfx.KeyO {
+		t.Errorf("the defaults moved octaves to %v", got)
+	}
+	// a moved group is shown by its keys, the rest keep their names
+	b, _ := findAction(bs, "pan-left")
+	if l := b.label(); l != "Q Right Up Down" {
+		t.Errorf("moved arrows are labelled %q", l)
+	}
+	b, _ = findAction(bs, "slot-1")
+	if l := b.label(); l != "1-9" {
+		t.Errorf("slots are labelled %q", l)
+	}
+}
+
+func TestApplyKeymapErrors(t *testing.T) {
+	tests := []struct {
+		name, keymap string
+		// want are the keys the actions end up on
+		want map[string]gfx.Scancode
+		// errs are what the error has to mention
+		errs []string
+	}{
+		{
+			"unknown action",
+			`{"warp": "W", "gain": "J"}`,
+			map[string]gfx.Scancode{"gain": gfx.KeyJ},
+			[]string{`unknown action "warp"`},
+		},
+		{
+			"unknown key",
+			`{"gain": "Hyper", "bloom": "V"}`,
+			map[string]gfx.Scancode{"gain": gfx.KeyG, "bloom": gfx.KeyV},
+			[]string{`gain: unknown key "Hyper"`},
+		},
+		{
+			"onto a default",
+			`{"bloom": "O"}`,
+			map[string]gfx.Scancode{"bloom": gfx.KeyB, "octaves": gfx.KeyO},
+			[]string{"octaves and bloom are both on O, bloom stays on B"},
+		},
+		{
+			"two onto one",
+			`{"bloom": "K", "spectrum": "K"}`,
+			map[string]gfx.Scancode{"bloom": gfx.KeyB, "spectrum": gfx.KeyX},
+			[]string{"bloom stays on B", "spectrum stays on X"},
+		},
+		{
+			"swap",
+			`{"bloom": "C", "chromatic": "B"}`,
+			map[string]gfx.Scancode{"bloom": gfx.KeyC, "chromatic": gfx.KeyB},
+			nil,
+		},
+		{
+			"chain",
+			// gain and bloom clash on O and both go back, then gain
+			// clashes with octaves on G
+			`{"octaves": "G", "gain": "O", "bloom": "O"}`,
+			map[string]gfx.Scancode{"octaves": gfx.KeyO, "gain": gfx.KeyG, "bloom": gfx.KeyB},
+			[]string{"bloom stays on B", "gain stays on G", "octaves stays on O"},
+		},
+		{
+			"not json",
+			`{"gain": `,
+			map[string]gfx.Scancode{"gain": gfx.KeyG},
+			[]string{"keys.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, err := applyKeymap(defaultBindings, []byte(tt.keymap), "keys.json")
+			for action, want := range tt.want {
+				if got := keyOf(t, bs, action); got != want {
+					t.Errorf("%s is on %v, want %v", action, got, want)
+				}
+			}
+			if c := keyConflicts(bs); len(c) > 0 {
+				t.Errorf("left conflicts %v", c)
+			}
+			if tt.errs == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			for _, e := range tt.errs {
+				if err == nil || !strings.Contains(err.Error(), e) {
+					t.Errorf("error %v does not mention %q", err, e)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintKeymapRoundTrip(t *testing.T) {
+	moved, err := applyKeymap(defaultBindings, []byte(`{"zoom-in": "Keypad +", "offset-up-keypad": "Keypad Enter"}`), "keys.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err = printKeymap(&out, moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := applyKeymap(defaultBindings, out.Bytes(), "printed")
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	for i := range moved {
+		for k := range moved[i].keys {
+			if back[i].keys[k] != moved[i].keys[k] {
+				t.Errorf("%s came back on %v, want %v", moved[i].actions[k], back[i].keys[k], moved[i].keys[k])
+			}
+		}
+	}
+}
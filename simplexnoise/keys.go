@@ -0,0 +1,56 @@
+package main
+
+import (
+	"time"
+
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+// keyRepeater turns held keys into single steps: a key acts once when it
+// goes down and, if delay is positive, again every interval once it has
+// been held for delay
+type keyRepeater struct {
+	delay, interval time.Duration
+	next            map[gfx.Scancode]time.Time
+}
+
+func newKeyRepeater(delay, interval time.Duration) *keyRepeater {
+	return &keyRepeater{delay: delay, interval: interval, next: make(map[gfx.Scancode]time.Time)}
+}
+
+// keyState is the keyboard of a frame, a *gfx.Input
+type keyState interface {
+	Pressed(sc gfx.Scancode) bool
+	Held(sc gfx.Scancode) bool
+}
+
+// pressed reports whether the key held binding sc should act this frame
+func (kr *keyRepeater) pressed(in keyState, sc gfx.Scancode, now time.Time) bool {
+	if in.Pressed(sc) {
+		kr.next[sc] = now.Add(kr.delay)
+		return true
+	}
+	if !in.Held(sc) || kr.delay <= 0 || now.Before(kr.next[sc]) {
+		return false
+	}
+	kr.next[sc] = now.Add(kr.interval)
+	return true
+}
+
+const (
+	// the arrow keys pan the view by panPixels, page up and down zoom in
+	// and out by zoomFactor about the middle of the window
+	panPixels  = 50
+	zoomFactor = 2
+)
+
+// panDirections are where the pan keys move the view, in the order of
+// their actions: left, right, up and down
+var panDirections = []struct {
+	dx, dy int
+}{
+	{-1, 0},
+	{1, 0},
+	{0, -1},
+	{0, 1},
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+// fakeKeys is a keyboard state made up for a frame
+type fakeKeys struct {
+	pressed, held [gfx.NumScancodes]bool
+}
+
+func (k *fakeKeys) Pressed(sc gfx.Scancode) bool { return k.pressed[sc] }
+func (k *fakeKeys) Held(sc gfx.Scancode) bool    { return k.held[sc] }
+
+// frameKeys builds the state of a frame from the keys held during it and
+// the keys held the frame before, tapped keys went down and up in between
+func frameKeys(prev, held, tapped []gfx.Scancode) *fakeKeys {
+	k := &fakeKeys{}
+	for _, sc := range held {
+		k.held[sc] = true
+		k.pressed[sc] = true
+	}
+	for _, sc := range prev {
+		k.pressed[sc] = false
+	}
+	for _, sc := range tapped {
+		k.pressed[sc] = true
+	}
+	return k
+}
+
+func TestKeyRepeater(t *testing.T) {
+	const delay, interval = 300 * time.Millisecond, 50 * time.Millisecond
+	const frame = 10 * time.Millisecond
+	o, f := gfx.KeyO, gfx.KeyF
+	type step struct {
+		at     time.Duration
+		held   []gfx.Scancode
+		tapped []gfx.Scancode
+		// acted are the keys that should act this frame
+		acted []gfx.Scancode
+	}
+	tests := []struct {
+		name  string
+		delay time.Duration
+		steps []step
+	}{
+		{"held once", delay, []step{
+			{0, nil, nil, nil},
+			{frame, []gfx.Scancode{o}, nil, []gfx.Scancode{o}},
+			{2 * frame, []gfx.Scancode{o}, nil, nil},
+			{10 * frame, []gfx.Scancode{o}, nil, nil},
+			{11 * frame, nil, nil, nil},
+			{12 * frame, []gfx.Scancode{o}, nil, []gfx.Scancode{o}},
+		}},
+		{"held past the delay", delay, []step{
+			{0, []gfx.Scancode{o}, nil, []gfx.Scancode{o}},
+			{delay - frame, []gfx.Scancode{o}, nil, nil},
+			{delay, []gfx.Scancode{o}, nil, []gfx.Scancode{o}},
+			{delay + frame, []gfx.Scancode{o}, nil, nil},
+			{delay + interval - frame, []gfx.Scancode{o}, nil, nil},
+			{delay + interval, []gfx.Scancode{o}, nil, []gfx.Scancode{o}},
+			{delay + 2*interval, []gfx.Scancode{o}, nil, []gfx.Scancode{o}},
+			{delay + 2*interval + frame, nil, nil, nil},
+			{2 * delay, nil, nil, nil},
+		}},
+		{"tapped between frames", delay, []step{
+			{0, nil, []gfx.Scancode{o}, []gfx.Scancode{o}},
+			{frame, nil, nil, nil},
+			{2 * frame, nil, []gfx.Scancode{o}, []gfx.Scancode{o}},
+		}},
+		{"keys repeat on their own", delay, []step{
+			{0, []gfx.Scancode{o}, nil, []gfx.Scancode{o}},
+			{delay / 2, []gfx.Scancode{o, f}, nil, []gfx.Scancode{f}},
+			{delay, []gfx.Scancode{o, f}, nil, []gfx.Scancode{o}},
+			{delay + frame, []gfx.Scancode{o, f}, nil, nil},
+			{delay * 3 / 2, []gfx.Scancode{o, f}, nil, []gfx.Scancode{o, f}},
+		}},
+		{"no repeat", 0, []step{
+			{0, []gfx.Scancode{o}, nil, []gfx.Scancode{o}},
+			{delay, []gfx.Scancode{o}, nil, nil},
+			{10 * delay, []gfx.Scancode{o}, nil, nil},
+		}},
+	}
+	start := time.Unix(1000, 0)
+	for _, tt := range tests {
+		kr := newKeyRepeater(tt.delay, interval)
+		var prev []gfx.Scancode
+		for i, s := range tt.steps {
+			keys := frameKeys(prev, s.held, s.tapped)
+			prev = s.held
+			for _, sc := range []gfx.Scancode{o, f} {
+				want := false
+				for _, a := range s.acted {
+					want = want || a == sc
+				}
+				if got := kr.pressed(keys, sc, start.Add(s.at)); got != want {
+					t.Errorf("%s: step %d at %v: %v acted %v, want %v", tt.name, i, s.at, sc, got, want)
+				}
+			}
+		}
+	}
+}
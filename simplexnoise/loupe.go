@@ -0,0 +1,57 @@
+package main
+
+const (
+	// the loupe shows a loupeSource square around the mouse loupeZoom times
+	// larger, cut to a circle that just fits the enlarged square
+	loupeSource = 40
+	loupeZoom   = 4
+	loupeRadius = loupeSource * loupeZoom / 2
+	loupeRim    = 2
+)
+
+var loupeRimColor = color{255, 255, 255}
+
+// loupeRows are the rows a loupe centred on row y covers
+func loupeRows(y int) rowRange {
+	return rowRange{clamp(0, winHeight, y-loupeRadius), clamp(0, winHeight, y+loupeRadius)}
+}
+
+// drawLoupe draws the loupe centred on mx, my into dst, enlarging src with
+// nearest neighbour sampling. The area of src past the window is black.
+func drawLoupe(mx, my int, src, dst []byte) {
+	srcWords, dstWords := pixelWords(src), pixelWords(dst)
+	rim := packColor(loupeRimColor, nativeBigEndian)
+	inner := (loupeRadius - loupeRim) * (loupeRadius - loupeRim)
+
+	for dy := -loupeRadius; dy < loupeRadius; dy++ {
+		y := my + dy
+		if y < 0 || y >= winHeight {
+			continue
+		}
+		// dx and dy are offsets of pixel corners, the circle is tested
+		// against pixel centres so it is symmetric around the mouse
+		cy := 2*dy + 1
+		sy := my - loupeSource/2 + (dy+loupeRadius)/loupeZoom
+		for dx := -loupeRadius; dx < loupeRadius; dx++ {
+			x := mx + dx
+			if x < 0 || x >= winWidth {
+				continue
+			}
+			cx := 2*dx + 1
+			d2 := cx*cx + cy*cy
+			if d2 > 4*loupeRadius*loupeRadius {
+				continue
+			}
+			if d2 > 4*inner {
+				dstWords[y*winWidth+x] = rim
+				continue
+			}
+			sx := mx - loupeSource/2 + (dx+loupeRadius)/loupeZoom
+			var w uint32
+			if sx >= 0 && sx < winWidth && sy >= 0 && sy < winHeight {
+				w = srcWords[sy*winWidth+sx]
+			}
+			dstWords[y*winWidth+x] = w
+		}
+	}
+}
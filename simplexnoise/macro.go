@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sabith-th/games_with_go/export"
+)
+
+// macroHeader starts every macro file, readMacro skips it like any comment
+const macroHeader = "# simplexnoise macro: seconds, then a console command"
+
+// macroStep is a console command and when it ran, counted from the start
+// of the recording
+type macroStep struct {
+	at   time.Duration
+	line string
+}
+
+// writeMacro writes m a step a line, the seconds to the millisecond and
+// then the command, e.g. "1.250 set gain 0.3"
+func writeMacro(w io.Writer, m []macroStep) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, macroHeader)
+	for _, s := range m {
+		fmt.Fprintf(bw, "%.3f %s\n", s.at.Seconds(), s.line)
+	}
+	return bw.Flush()
+}
+
+// readMacro reads what writeMacro wrote, skipping blank lines and lines
+// starting with #. Every command is parsed as it is read, so a macro with
+// a misspelt command is rejected before any of it runs. So are steps out
+// of order, and commands that write files: a macro is something to pass
+// around, and playing one should not leave files behind.
+func readMacro(r io.Reader) ([]macroStep, error) {
+	var m []macroStep
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		secs, line := text, ""
+		if i := strings.IndexAny(text, " \t"); i >= 0 {
+			secs, line = text[:i], strings.TrimSpace(text[i:])
+		}
+		v, err := strconv.ParseFloat(secs, 64)
+		if err != nil || !(v >= 0) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("line %d: %q is not a time in seconds", n, secs)
+		}
+		at := time.Duration(math.Round(v*1000)) * time.Millisecond
+		if len(m) > 0 && at < m[len(m)-1].at {
+			return nil, fmt.Errorf("line %d: %v is before the step above it", n, at)
+		}
+		call, err := parseCommand(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if call.cmd.local {
+			return nil, fmt.Errorf("line %d: %s cannot be in a macro", n, call.cmd.name)
+		}
+		m = append(m, macroStep{at, line})
+	}
+	return m, sc.Err()
+}
+
+func saveMacro(path string, m []macroStep) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = writeMacro(f, m)
+	closeErr := f.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func loadMacro(path string) ([]macroStep, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := readMacro(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return m, nil
+}
+
+// stateLines are the commands that set every parameter the console can
+// set, and the palette, to what s has
+func stateLines(s snapshot) []string {
+	var lines []string
+	for _, cp := range consoleParams {
+		lines = append(lines, "set "+cp.name+" "+cp.value(&s.p).String())
+	}
+	return append(lines, "palette "+palettePresets[s.palette].name)
+}
+
+// changedLines are the commands of stateLines that take a to b
+func changedLines(a, b snapshot) []string {
+	before, after := stateLines(a), stateLines(b)
+	var lines []string
+	for i := range after {
+		if after[i] != before[i] {
+			lines = append(lines, after[i])
+		}
+	}
+	return lines
+}
+
+// macroRecorder writes down every change to the parameters and palette as
+// the console command that makes it, whatever made it: keys, the
+// controller, the console, the server or the sweep
+type macroRecorder struct {
+	on    bool
+	start time.Time
+	last  snapshot
+	steps []macroStep
+}
+
+// begin starts a recording with the commands that set everything to s, so
+// playing it gives the same result whatever the parameters were before
+func (r *macroRecorder) begin(s snapshot, now time.Time) {
+	r.on, r.start, r.last, r.steps = true, now, s, nil
+	for _, line := range stateLines(s) {
+		r.steps = append(r.steps, macroStep{0, line})
+	}
+}
+
+// record adds what changed since the last call, s being how things are
+// now
+func (r *macroRecorder) record(s snapshot, now time.Time) {
+	if !r.on {
+		return
+	}
+	for _, line := range changedLines(r.last, s) {
+		r.steps = append(r.steps, macroStep{now.Sub(r.start), line})
+	}
+	r.last = s
+}
+
+// end stops recording and returns the steps
+func (r *macroRecorder) end() []macroStep {
+	r.on = false
+	return r.steps
+}
+
+// macroPlayer hands out the steps of a macro as they come due, speed times
+// as fast as they were recorded
+type macroPlayer struct {
+	steps   []macroStep
+	speed   float64
+	elapsed time.Duration
+}
+
+// advance moves playback on by dt and returns the commands that came due,
+// in order
+func (p *macroPlayer) advance(dt time.Duration) []string {
+	p.elapsed += time.Duration(float64(dt) * p.speed)
+	var due []string
+	for len(p.steps) > 0 && p.steps[0].at <= p.elapsed {
+		due = append(due, p.steps[0].line)
+		p.steps = p.steps[1:]
+	}
+	return due
+}
+
+func (p *macroPlayer) done() bool {
+	return len(p.steps) == 0
+}
+
+// replayTarget runs a macro's commands without a window, it has the
+// palettes the window has
+type replayTarget struct {
+	headlessTarget
+	palette int
+}
+
+func (t *replayTarget) usePalette(name string) error {
+	for i, pp := range palettePresets {
+		if pp.name == name {
+			t.palette = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown palette %q, expected one of %v", name, paletteNames())
+}
+
+// replayMacro plays the macro in path on p without a window, all at once
+// since nothing is shown on the way, and writes the last frame to out as
+// the window would have shown it
+func replayMacro(filler fieldFiller, path, out string, p preset) error {
+	m, err := loadMacro(path)
+	if err != nil {
+		return err
+	}
+	t := &replayTarget{headlessTarget: headlessTarget{p: p}}
+	for _, s := range m {
+		// readMacro has parsed every line already
+		call, _ := parseCommand(s.line)
+		_, err := call.run(t)
+		if err != nil {
+			return fmt.Errorf("%s at %v: %v", s.line, s.at, err)
+		}
+	}
+	pp := palettePresets[t.palette]
+	buf := newFieldBuffer(winWidth, winHeight)
+	_, err = makeNoise(context.Background(), filler, buf, winWidth, winHeight, t.p, getStopGradient(pp.stops, pp.hue))
+	if err != nil {
+		return err
+	}
+	err = export.WritePNG(out, buf.pixels, winWidth, winHeight)
+	if err != nil {
+		return err
+	}
+	fmt.Println("played", len(m), "steps of", path, "and wrote", out)
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMacroRoundTrip(t *testing.T) {
+	m := []macroStep{
+		{0, "set octaves 4"},
+		{1250 * time.Millisecond, "set view 0.5,-12,0.25"},
+		{1250 * time.Millisecond, "palette fire"},
+		{3 * time.Second, "set mode ridged"},
+	}
+	var buf bytes.Buffer
+	if err := writeMacro(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "1.250 set view 0.5,-12,0.25\n") {
+		t.Errorf("unexpected macro text:\n%s", buf.String())
+	}
+	got, err := readMacro(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("read back %v, want %v", got, m)
+	}
+}
+
+func TestReadMacroSkipsComments(t *testing.T) {
+	got, err := readMacro(strings.NewReader("# a comment\n\n  0.5   set gain 0.3  \n\t# another\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []macroStep{{500 * time.Millisecond, "set gain 0.3"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("read %v, want %v", got, want)
+	}
+}
+
+func TestReadMacroErrors(t *testing.T) {
+	tests := []struct {
+		text, err string
+	}{
+		{"soon set gain 0.3", "line 1:"},
+		{"-1 set gain 0.3", "line 1:"},
+		{"NaN set gain 0.3", "line 1:"},
+		{"2 set gain 0.3\n1 set gain 0.4", "line 2:"},
+		{"0 jump", "line 1:"},
+		{"0 set gain", "line 1:"},
+		{"# header\n0 export png out.png", "line 2: export cannot be in a macro"},
+	}
+	for _, tt := range tests {
+		_, err := readMacro(strings.NewReader(tt.text))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("%q gave %v, want an error starting %q", tt.text, err, tt.err)
+		}
+	}
+}
+
+func TestMacroPlayerSpeed(t *testing.T) {
+	p := macroPlayer{
+		steps: []macroStep{
+			{0, "a"},
+			{time.Second, "b"},
+			{time.Second, "c"},
+			{3 * time.Second, "d"},
+		},
+		speed: 2,
+	}
+	tests := []struct {
+		dt   time.Duration
+		want []string
+	}{
+		{0, []string{"a"}},
+		{400 * time.Millisecond, nil},
+		// twice as fast, so the steps at 1s are due after half a second
+		{100 * time.Millisecond, []string{"b", "c"}},
+		{900 * time.Millisecond, nil},
+		{100 * time.Millisecond, []string{"d"}},
+	}
+	for i, tt := range tests {
+		if p.done() {
+			t.Fatalf("done before advance %d", i)
+		}
+		if got := p.advance(tt.dt); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("advance %d by %v gave %v, want %v", i, tt.dt, got, tt.want)
+		}
+	}
+	if !p.done() {
+		t.Error("not done after the last step")
+	}
+}
+
+func TestMacroRecordReplay(t *testing.T) {
+	start := snapshot{p: defaultPreset()}
+	now := time.Now()
+	var r macroRecorder
+	r.begin(start, now)
+
+	s := start
+	s.p.Gain = 0.3
+	s.p.View = s.p.View.zoom(winWidth/2, winHeight/2, 1.7)
+	r.record(s, now.Add(time.Second))
+	r.record(s, now.Add(2*time.Second))
+	s.palette = len(palettePresets) - 1
+	s.p.Octaves++
+	r.record(s, now.Add(3*time.Second))
+	m := r.end()
+	r.record(octaves(1), now.Add(4*time.Second))
+	if len(r.steps) != len(m) {
+		t.Error("recorded after end")
+	}
+
+	// the start sets everything, then only what changed
+	n := len(stateLines(start))
+	if len(m) != n+4 {
+		t.Fatalf("recorded %d steps, want %d: %v", len(m), n+4, m)
+	}
+	for _, s := range m[n:] {
+		if s.at == 0 || s.at == 2*time.Second {
+			t.Errorf("%q recorded at %v", s.line, s.at)
+		}
+	}
+
+	// playing it from somewhere else ends where the recording did
+	var buf bytes.Buffer
+	if err := writeMacro(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readMacro(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := defaultPreset()
+	other.Octaves, other.Frequency = 1, 0.5
+	target := &replayTarget{headlessTarget: headlessTarget{p: other}, palette: 1}
+	for _, step := range read {
+		if _, err := runLine(t, target, step.line); err != nil {
+			t.Fatalf("%q: %v", step.line, err)
+		}
+	}
+	got := snapshot{target.p, target.palette}
+	if got != s {
+		t.Errorf("replayed to %+v, want %+v", got, s)
+	}
+}
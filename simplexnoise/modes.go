@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// noiseMode picks the fractal the octaves of the basis are summed with
+type noiseMode int
+
+const (
+	turbulenceMode noiseMode = iota
+	fbmMode
+	ridgedMode
+	numNoiseModes
+)
+
+var noiseModeNames = [numNoiseModes]string{"turbulence", "fbm", "ridged"}
+
+// basis picks the noise every octave samples, by the name it is
+// registered under in the noise package
+type basis string
+
+const simplexBasis basis = "simplex"
+
+// next is the basis step places after b in the sorted names, wrapping
+// around at either end
+func (b basis) next(step int) basis {
+	names := noise.Names()
+	i, _ := lookupName("basis", names, string(b))
+	n := len(names)
+	return basis(names[((i+step)%n+n)%n])
+}
+
+// lookupName returns the index of name in names
+func lookupName(kind string, names []string, name string) (int, error) {
+	for i, n := range names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s %q, expected one of %v", kind, name, names)
+}
+
+// noiseMode and basis are flags and json strings by name
+
+func (m noiseMode) String() string {
+	if m < 0 || m >= numNoiseModes {
+		return fmt.Sprintf("noiseMode(%d)", int(m))
+	}
+	return noiseModeNames[m]
+}
+
+func (m *noiseMode) Set(s string) error {
+	i, err := lookupName("mode", noiseModeNames[:], s)
+	*m = noiseMode(i)
+	return err
+}
+
+func (m noiseMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *noiseMode) UnmarshalText(text []byte) error {
+	return m.Set(string(text))
+}
+
+func (b basis) String() string {
+	return string(b)
+}
+
+func (b *basis) Set(s string) error {
+	_, err := lookupName("basis", noise.Names(), s)
+	if err != nil {
+		return err
+	}
+	*b = basis(s)
+	return nil
+}
+
+func (b basis) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *basis) UnmarshalText(text []byte) error {
+	return b.Set(string(text))
+}
+
+// block is the block of noise a w*h field of p covers, without its source
+func (p preset) block(w, h int) noise.Config {
+	return noise.Config{Width: w, Height: h, X: p.View.X, Y: p.View.Y, Step: p.View.Step}
+}
+
+// source is the noise p describes for a w*h field. validate has checked
+// the basis is registered, one that is not falls back to simplex.
+func (p preset) source(w, h int) noise.Source {
+	if p.classic() {
+		return turbulenceSource{p.Fractal}
+	}
+	src, err := noise.New(string(p.Basis), p.block(w, h))
+	if err != nil {
+		src = noise.Simplex{}
+	}
+	switch p.Mode {
+	case fbmMode:
+		return p.Fractal.Fbm(src)
+	case ridgedMode:
+		return p.Fractal.Ridged(src)
+	default:
+		return p.Fractal.Turbulence(src)
+	}
+}
+
+// turbulenceSource is the classic path as the pool samples it when a view
+// does not need float64. noise.Fractal's Turbulence over noise.Simplex
+// scales every octave by 40 rather than the sum and rounds differently.
+type turbulenceSource struct {
+	f noise.Fractal
+}
+
+func (s turbulenceSource) At(x, y float32) float32 {
+	return noise.Turbulence(x, y, s.f.Frequency, s.f.Lacunarity, s.f.Gain, s.f.Octaves)
+}
+
+// classic reports whether p is simplex turbulence, which the worker pool
+// and the octave cache evaluate without going through a noise.Source
+func (p preset) classic() bool {
+	return p.Mode == turbulenceMode && p.Basis == simplexBasis
+}
+
+// rowSource is what the rows of p's w*h fields are filled from, nil for
+// the classic path
+func (p preset) rowSource(w, h int) noise.Source {
+	if p.classic() {
+		return nil
+	}
+	return p.source(w, h)
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/png"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sabith-th/games_with_go/export"
+)
+
+const (
+	// noiseCacheTTL is how long a png rendered for /noise is handed out
+	// again to requests for the same image
+	noiseCacheTTL = 500 * time.Millisecond
+	// defaultStreamSpeed is how many pixels a second /noise/stream pans
+	// the view by without a speed parameter
+	defaultStreamSpeed = 40
+)
+
+// noiseKey is everything a /noise image depends on
+type noiseKey struct {
+	p    preset
+	w, h int
+}
+
+// pngCache keeps the last png it rendered for ttl. Renders are made one at
+// a time, so however many requests arrive at once only one core renders,
+// and the requests that queued behind a render of the same image are
+// answered from the cache once it is done.
+type pngCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	render sync.Mutex
+
+	mutex    sync.Mutex
+	key      noiseKey
+	png      []byte
+	rendered time.Time
+	valid    bool
+}
+
+func newPNGCache(ttl time.Duration) *pngCache {
+	return &pngCache{ttl: ttl, now: time.Now}
+}
+
+func (c *pngCache) lookup(key noiseKey) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.valid || c.key != key || c.now().Sub(c.rendered) >= c.ttl {
+		return nil, false
+	}
+	return c.png, true
+}
+
+// get returns the cached png for key, or renders and caches a new one. The
+// returned slice is shared and must not be changed.
+func (c *pngCache) get(key noiseKey, render func() ([]byte, error)) ([]byte, error) {
+	if png, ok := c.lookup(key); ok {
+		return png, nil
+	}
+	c.render.Lock()
+	defer c.render.Unlock()
+	if png, ok := c.lookup(key); ok {
+		return png, nil
+	}
+	png, err := render()
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	c.key, c.png, c.rendered, c.valid = key, png, c.now(), true
+	c.mutex.Unlock()
+	return png, nil
+}
+
+// parseFinite parses a float64 query parameter that has to be a real number
+func parseFinite(v string) (float64, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("%v is not a finite number", v)
+	}
+	return f, nil
+}
+
+// noiseQuery reads the preset and size of a /noise request from its query,
+// starting from p and w*h. Every parameter is optional, unknown ones are
+// an error.
+func noiseQuery(q url.Values, p preset, w, h int) (noiseKey, error) {
+	names := make([]string, 0, len(q))
+	for name := range q {
+		names = append(names, name)
+	}
+	// sorted so the same bad query always gives the same error
+	sort.Strings(names)
+	for _, name := range names {
+		v := q.Get(name)
+		var err error
+		switch name {
+		case "frequency":
+			err = (*float32Value)(&p.Frequency).Set(v)
+		case "lacunarity":
+			err = (*float32Value)(&p.Lacunarity).Set(v)
+		case "gain":
+			err = (*float32Value)(&p.Gain).Set(v)
+		case "octaves":
+			p.Octaves, err = strconv.Atoi(v)
+		case "x":
+			p.View.X, err = parseFinite(v)
+		case "y":
+			p.View.Y, err = parseFinite(v)
+		case "step":
+			p.View.Step, err = parseFinite(v)
+		case "mode":
+			err = p.Mode.Set(v)
+		case "basis":
+			err = p.Basis.Set(v)
+		case "w", "h":
+			// parseSize reads them below
+		default:
+			return noiseKey{}, fmt.Errorf("unknown parameter %q", name)
+		}
+		if err != nil {
+			return noiseKey{}, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	err := p.validate()
+	if err != nil {
+		return noiseKey{}, err
+	}
+	if w, err = parseSize(q, "w", w); err != nil {
+		return noiseKey{}, err
+	}
+	if h, err = parseSize(q, "h", h); err != nil {
+		return noiseKey{}, err
+	}
+	return noiseKey{p, w, h}, nil
+}
+
+// renderNoise returns the png for key, from the cache if it was rendered
+// in the last noiseCacheTTL. A render is given up once ctx is done, which
+// for a request is when its client goes away, and nothing is cached then.
+func (s *previewServer) renderNoise(ctx context.Context, key noiseKey) ([]byte, error) {
+	return s.noise.get(key, func() ([]byte, error) {
+		pixels, err := generateNoise(ctx, key.p, key.w, key.h, nil)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		err = png.Encode(&buf, export.ToImage(pixels, key.w, key.h))
+		return buf.Bytes(), err
+	})
+}
+
+// handleNoise renders the preset given by the query as a png, parameters
+// left out are taken from the current preset. It goes through the
+// deterministic generateNoise, so it ignores -expr and always uses the
+// default gradient.
+func (s *previewServer) handleNoise(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	key, err := noiseQuery(r.URL.Query(), s.currentPreset(), s.w, s.h)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := s.renderNoise(r.Context(), key)
+	if r.Context().Err() != nil {
+		// the client is gone, there is no one to answer
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+// handleNoiseStream streams pngs of the query's preset as
+// multipart/x-mixed-replace, panning the view right by speed pixels a
+// second. The pan follows the server's clock rather than the stream's, so
+// streams of the same query show the same frames and share the cache.
+func (s *previewServer) handleNoiseStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	q := r.URL.Query()
+	speed := float64(defaultStreamSpeed)
+	if v := q.Get("speed"); v != "" {
+		var err error
+		speed, err = parseFinite(v)
+		if err != nil {
+			http.Error(w, "speed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.Del("speed")
+	}
+	key, err := noiseQuery(q, s.currentPreset(), s.w, s.h)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+streamBoundary)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	x := key.p.View.X
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+	for {
+		frame := time.Since(s.started) / streamInterval
+		key.p.View.X = x + float64(frame)*streamInterval.Seconds()*speed*key.p.View.Step
+		data, err := s.renderNoise(r.Context(), key)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "--%s\r\nContent-Type: image/png\r\nContent-Length: %d\r\n\r\n", streamBoundary, len(data))
+		_, err = w.Write(data)
+		if err != nil {
+			return
+		}
+		fmt.Fprint(w, "\r\n")
+		flusher.Flush()
+
+		select {
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// maxCachedLayers caps how many octave layers an octaveCache keeps. A layer
+// is one float32 per pixel, about 1.9MB for the 800x600 window, so the
+// layers take at most about 11.5MB on top of the 1.9MB running sum.
+// Octaves past the cap are evaluated again whenever the sum is rebuilt.
+const maxCachedLayers = 6
+
+// fieldFiller evaluates the turbulence field for p into buf.noise and
+// returns its range
+type fieldFiller interface {
+	fill(ctx context.Context, buf *fieldBuffer, w, h int, p preset) (min, max float32, err error)
+}
+
+// octaveCache fills the window's fields from per-octave layers. A layer
+// holds the unweighted |noise.Snoise2| of one octave and only depends on the
+// frequency schedule, that is frequency and lacunarity, and on the view, so
+// adding an octave evaluates just the new layer and changing the gain
+// re-weights the layers already there without calling noise.Snoise2. The
+// weighted sum is kept too, so going from 3 to 4 octaves is one layer plus
+// one pass over the sum.
+// Layers are summed in the same order and with the same amplitudes as
+// turbulence, which makes the result identical to the workerPool's
+// bit-for-bit. It is safe to use from several goroutines, fills run one at
+// a time.
+type octaveCache struct {
+	mutex sync.Mutex
+	pool  *workerPool
+	w, h  int
+
+	frequency, lacunarity float32
+	view                  viewport
+	layers                [][]float32
+
+	// sum is the weighted sum of the first sumOctaves octaves for sumGain
+	sum        []float32
+	sumGain    float32
+	sumOctaves int
+
+	// extra holds octaves past maxCachedLayers, target points the pool at a
+	// layer
+	extra  []float32
+	target fieldBuffer
+}
+
+func newOctaveCache(pool *workerPool, w, h int) *octaveCache {
+	return &octaveCache{
+		pool:   pool,
+		w:      w,
+		h:      h,
+		layers: make([][]float32, 0, maxCachedLayers),
+		sum:    make([]float32, w*h),
+	}
+}
+
+// fill implements fieldFiller. Sizes other than the cache's are passed
+// straight to the pool. If ctx is canceled every layer finished so far is
+// kept, so the next fill picks up where this one stopped.
+func (c *octaveCache) fill(ctx context.Context, buf *fieldBuffer, w, h int, p preset) (min, max float32, err error) {
+	if w != c.w || h != c.h || !p.classic() {
+		return c.pool.fill(ctx, buf, w, h, p)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if p.Frequency != c.frequency || p.Lacunarity != c.lacunarity || p.View != c.view {
+		c.frequency, c.lacunarity, c.view = p.Frequency, p.Lacunarity, p.View
+		c.layers = c.layers[:0]
+		c.sumOctaves = 0
+	}
+	if p.Gain != c.sumGain || p.Octaves < c.sumOctaves {
+		c.sumGain = p.Gain
+		c.sumOctaves = 0
+	}
+	if c.sumOctaves == 0 {
+		for i := range c.sum {
+			c.sum[i] = 0
+		}
+	}
+
+	// frequency and amplitude are stepped exactly like turbulence steps them
+	frequency := p.Frequency
+	amplitude := float32(1.0)
+	for i := 0; i < c.sumOctaves; i++ {
+		frequency *= p.Lacunarity
+		amplitude *= p.Gain
+	}
+	for i := c.sumOctaves; i < p.Octaves; i++ {
+		layer, err := c.layer(ctx, i, frequency, p.View)
+		if err != nil {
+			return 0, 0, err
+		}
+		for j, v := range layer {
+			// the conversion keeps the product from being fused into the
+			// add, turbulence rounds it separately as well
+			c.sum[j] += float32(v * amplitude)
+		}
+		c.sumOctaves = i + 1
+		frequency *= p.Lacunarity
+		amplitude *= p.Gain
+	}
+
+	r := emptyRange()
+	for i, v := range c.sum {
+		buf.noise[i] = v
+		r.add(v)
+	}
+	return r.min, r.max, nil
+}
+
+// layer returns octave i, evaluating it if it is not cached. Octave i is
+// never asked for before the ones below it, so a new layer always goes on
+// the end.
+func (c *octaveCache) layer(ctx context.Context, i int, frequency float32, view viewport) ([]float32, error) {
+	if i < len(c.layers) {
+		return c.layers[i], nil
+	}
+
+	var dst []float32
+	switch {
+	case i >= maxCachedLayers:
+		if c.extra == nil {
+			c.extra = make([]float32, c.w*c.h)
+		}
+		dst = c.extra
+	case i < cap(c.layers) && c.layers[:i+1][i] != nil:
+		// reuse the memory of a layer dropped by a schedule change
+		dst = c.layers[:i+1][i]
+	default:
+		dst = make([]float32, c.w*c.h)
+	}
+
+	// a single octave of turbulence at amplitude 1 is exactly |noise.Snoise2|
+	c.target.noise = dst
+	_, _, err := c.pool.fill(ctx, &c.target, c.w, c.h, preset{Fractal: noise.Fractal{Frequency: frequency, Lacunarity: 1, Gain: 1, Octaves: 1}, View: view, Basis: simplexBasis})
+	if err != nil {
+		return nil, err
+	}
+	if i < maxCachedLayers {
+		c.layers = append(c.layers, dst)
+	}
+	return dst, nil
+}
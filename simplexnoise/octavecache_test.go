@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// TestOctaveCacheMatchesPool walks the cache through the changes it keeps
+// layers across, and those it has to drop them for. Every field must be
+// bit for bit the one the pool evaluates from scratch.
+func TestOctaveCacheMatchesPool(t *testing.T) {
+	const w, h = 48, 40
+	pool := newWorkerPool(3)
+	defer pool.close()
+	cache := newOctaveCache(pool, w, h)
+
+	p := defaultPreset()
+	var steps []preset
+	add := func(change func(p *preset)) {
+		change(&p)
+		steps = append(steps, p)
+	}
+	add(func(p *preset) {})
+	add(func(p *preset) { p.Octaves = 4 })
+	add(func(p *preset) { p.Octaves = maxCachedLayers })
+	add(func(p *preset) { p.Octaves = maxCachedLayers + 3 })
+	add(func(p *preset) { p.Gain = 0.45 })
+	add(func(p *preset) { p.Octaves = 2 })
+	add(func(p *preset) { p.Octaves = 5 })
+	add(func(p *preset) { p.Gain = 0.2 })
+	add(func(p *preset) { p.Frequency = 0.03 })
+	add(func(p *preset) { p.Lacunarity = 2.1 })
+	add(func(p *preset) { p.View = p.View.pan(17, -5) })
+	add(func(p *preset) { p.View = p.View.zoom(w/2, h/2, 2) })
+	add(func(p *preset) { p.Octaves = maxOctaves })
+	add(func(p *preset) { p.Octaves = 1 })
+
+	want := newFieldBuffer(w, h)
+	got := newFieldBuffer(w, h)
+	for i, p := range steps {
+		wantMin, wantMax, err := pool.fill(context.Background(), want, w, h, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		min, max, err := cache.fill(context.Background(), got, w, h, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if min != wantMin || max != wantMax {
+			t.Errorf("step %d: range %v..%v, want %v..%v", i, min, max, wantMin, wantMax)
+		}
+		for j := range want.noise {
+			if math.Float32bits(got.noise[j]) != math.Float32bits(want.noise[j]) {
+				t.Fatalf("step %d, %+v: pixel %d,%d is %v, want %v", i, p, j%w, j/w, got.noise[j], want.noise[j])
+			}
+		}
+	}
+}
+
+// TestOctaveCacheCanceled cancels a fill and finishes it with the next,
+// the layers kept from the canceled one must not show
+func TestOctaveCacheCanceled(t *testing.T) {
+	const w, h = 48, 40
+	pool := newWorkerPool(3)
+	defer pool.close()
+	cache := newOctaveCache(pool, w, h)
+	p := defaultPreset()
+	p.Octaves = 5
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got := newFieldBuffer(w, h)
+	if _, _, err := cache.fill(ctx, got, w, h, p); err != context.Canceled {
+		t.Fatalf("canceled fill returned %v", err)
+	}
+	if _, _, err := cache.fill(context.Background(), got, w, h, p); err != nil {
+		t.Fatal(err)
+	}
+	want := newFieldBuffer(w, h)
+	if _, _, err := pool.fill(context.Background(), want, w, h, p); err != nil {
+		t.Fatal(err)
+	}
+	for j := range want.noise {
+		if math.Float32bits(got.noise[j]) != math.Float32bits(want.noise[j]) {
+			t.Fatalf("pixel %d,%d is %v after a canceled fill, want %v", j%w, j/w, got.noise[j], want.noise[j])
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "unsafe"
+
+// nativeBigEndian is whether this machine stores the high byte of a word
+// first
+var nativeBigEndian = func() bool {
+	x := uint32(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 0
+}()
+
+// packColor packs c into a word whose bytes lie in memory as r, g, b, a on
+// a machine of the given byte order, the layout of the pixel buffers. Alpha
+// stays zero like the byte stores always left it.
+func packColor(c color, bigEndian bool) uint32 {
+	if bigEndian {
+		return uint32(c.r)<<24 | uint32(c.g)<<16 | uint32(c.b)<<8
+	}
+	return uint32(c.r) | uint32(c.g)<<8 | uint32(c.b)<<16
+}
+
+// packGradient packs the 256 colors of a gradient. It is an array rather
+// than a slice so drawField keeps it on its stack, drawing allocates
+// nothing.
+func packGradient(gradient []color, bigEndian bool) (packed [256]uint32) {
+	for i := range packed {
+		packed[i] = packColor(gradient[i], bigEndian)
+	}
+	return packed
+}
+
+// pixelWords views a pixel buffer as one word per pixel, sharing its
+// memory. Buffers come from make and frame offsets are whole pixels, so the
+// words are always aligned.
+func pixelWords(pixels []byte) []uint32 {
+	if len(pixels) < 4 {
+		return nil
+	}
+	return unsafe.Slice((*uint32)(unsafe.Pointer(&pixels[0])), len(pixels)/4)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+// drawFieldBytes is drawField as it was before packed words, four byte
+// stores a pixel. The tests compare against it and the benchmarks time it.
+func drawFieldBytes(field []float32, gradient []color, pixels []byte) {
+	for i, v := range field {
+		c := gradient[gradientIndex(v)]
+		p := i * 4
+		pixels[p] = c.r
+		pixels[p+1] = c.g
+		pixels[p+2] = c.b
+		pixels[p+3] = 0
+	}
+}
+
+// TestPackColorByteOrder stores packed words the way each byte order lays
+// them out in memory, the bytes must be r, g, b, 0 either way. The big
+// endian case runs on any machine through encoding/binary.
+func TestPackColorByteOrder(t *testing.T) {
+	colors := []color{{0, 0, 0}, {255, 255, 255}, {1, 2, 3}, {0xde, 0xad, 0xbe}, {12, 192, 75}}
+	for _, c := range colors {
+		want := [4]byte{c.r, c.g, c.b, 0}
+		var got [4]byte
+		binary.LittleEndian.PutUint32(got[:], packColor(c, false))
+		if got != want {
+			t.Errorf("little endian %v packs to %v, want %v", c, got, want)
+		}
+		binary.BigEndian.PutUint32(got[:], packColor(c, true))
+		if got != want {
+			t.Errorf("big endian %v packs to %v, want %v", c, got, want)
+		}
+
+		// and through the word view on this machine
+		pixels := make([]byte, 4)
+		pixelWords(pixels)[0] = packColor(c, nativeBigEndian)
+		if [4]byte(pixels) != want {
+			t.Errorf("native %v stores as %v, want %v", c, pixels, want)
+		}
+	}
+}
+
+func TestDrawFieldMatchesBytes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	field := make([]float32, 1000)
+	for i := range field {
+		// past both ends of the gradient as well
+		field[i] = rng.Float32()*300 - 20
+	}
+	gradient := getDualGradient(color{0, 0, 175}, color{80, 160, 244}, color{12, 192, 75}, color{255, 255, 255})
+	want := make([]byte, len(field)*4)
+	drawFieldBytes(field, gradient, want)
+	got := make([]byte, len(field)*4)
+	for i := range got {
+		got[i] = 0xff
+	}
+	drawField(field, gradient, got)
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("pixel %d is %v, byte stores give %v", i/4, got[i/4*4:i/4*4+4], want[i/4*4:i/4*4+4])
+		}
+	}
+}
+
+// BenchmarkDraw colors a window sized field with packed words and with the
+// byte stores they replaced
+func BenchmarkDraw(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	field := make([]float32, winWidth*winHeight)
+	for i := range field {
+		field[i] = rng.Float32() * 255
+	}
+	pixels := make([]byte, len(field)*4)
+	b.Run("packed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			drawField(field, defaultGradient, pixels)
+		}
+	})
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			drawFieldBytes(field, defaultGradient, pixels)
+		}
+	})
+}
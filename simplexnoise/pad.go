@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+const (
+	// padDeadZone is how far from rest an axis reads as at rest, sticks
+	// never quite center and triggers never quite let go
+	padDeadZone = 0.15
+	// padCurve is the power the travel past the dead zone is raised to, so
+	// a stick pushed a little changes things finely and pushed all the
+	// way quickly
+	padCurve = 2
+
+	// how much an axis pushed all the way changes things in a second: the
+	// left stick pans padPanSpeed pixels, the right stick multiplies the
+	// frequency by e to the padFrequencyRate and adds padGainRate to the
+	// gain, a trigger zooms by padZoomRate
+	padPanSpeed      = 500
+	padFrequencyRate = 1
+	padGainRate      = 0.5
+	padZoomRate      = 4
+)
+
+// axisResponse is how much a stick or trigger at v counts for: nothing
+// inside the dead zone, and from there the rest of the travel rescaled to
+// 0..1 and curved, keeping the sign
+func axisResponse(v float64) float64 {
+	a := math.Abs(v)
+	if a <= padDeadZone {
+		return 0
+	}
+	if a > 1 {
+		a = 1
+	}
+	r := math.Pow((a-padDeadZone)/(1-padDeadZone), padCurve)
+	if v < 0 {
+		return -r
+	}
+	return r
+}
+
+// padDelta is what the sticks and triggers do to the parameters over a
+// frame
+type padDelta struct {
+	// panX and panY are in pixels
+	panX, panY float64
+	// frequency and zoom multiply, zoom above 1 zooms in
+	frequency, zoom float64
+	// gain adds
+	gain float64
+}
+
+// padDeltas is what axes do over dt seconds. The left stick pans the way
+// it is pushed, the right stick raises the frequency to the right and the
+// gain upwards, the right trigger zooms in and the left out.
+func padDeltas(axes [gfx.NumAxes]float64, dt float64) padDelta {
+	trigger := axisResponse(axes[gfx.AxisTriggerRight]) - axisResponse(axes[gfx.AxisTriggerLeft])
+	return padDelta{
+		panX:      axisResponse(axes[gfx.AxisLeftX]) * padPanSpeed * dt,
+		panY:      axisResponse(axes[gfx.AxisLeftY]) * padPanSpeed * dt,
+		frequency: math.Exp(axisResponse(axes[gfx.AxisRightX]) * padFrequencyRate * dt),
+		// the sticks' y grows downwards
+		gain: -axisResponse(axes[gfx.AxisRightY]) * padGainRate * dt,
+		zoom: math.Pow(padZoomRate, trigger*dt),
+	}
+}
+
+// zero reports whether d changes nothing
+func (d padDelta) zero() bool {
+	return d == padDelta{frequency: 1, zoom: 1}
+}
+
+// apply changes p by d, keeping frequency and gain to their ranges. The
+// view zooms about the middle of the window as the zoom keys do, and pans
+// by fractions of a pixel so slow pans move at all.
+func (d padDelta) apply(p preset) preset {
+	p.View.X += d.panX * p.View.Step
+	p.View.Y += d.panY * p.View.Step
+	p.View = p.View.zoom(winWidth/2, winHeight/2, d.zoom)
+	p.Frequency = frequencyRange.clamp(float32(float64(p.Frequency) * d.frequency))
+	p.Gain = gainRange.clamp(p.Gain + float32(d.gain))
+	return p
+}
+
+// padButtons acts on the buttons pressed this frame: the d-pad steps the
+// octaves up and down and the lacunarity right and left, A and B go to the
+// next and previous palette, X and Y to the next and previous mode. It
+// reports whether the parameters changed.
+func (g *noiseGame) padButtons(pad *gfx.Pad, now time.Time) (keyChange bool) {
+	show := func(text string) {
+		g.flash.show(text, now)
+		g.changed = g.changed.union(g.flash.rows())
+		keyChange = true
+	}
+	// pair is 1 if up is pressed, -1 if down is and 0 for neither or both
+	pair := func(up, down gfx.ControllerButton) int {
+		mult := 0
+		if pad.Pressed(up) {
+			mult++
+		}
+		if pad.Pressed(down) {
+			mult--
+		}
+		return mult
+	}
+	if mult := pair(gfx.ButtonDPadUp, gfx.ButtonDPadDown); mult != 0 {
+		g.p.Octaves = stepOctaves(g.p.Octaves, mult)
+		show(fmt.Sprint("octaves ", g.p.Octaves))
+	}
+	if mult := pair(gfx.ButtonDPadRight, gfx.ButtonDPadLeft); mult != 0 {
+		g.p.Lacunarity = stepValue(g.p.Lacunarity, mult, 1, lacunarityRange)
+		show(fmt.Sprintf("lacunarity %.4g", g.p.Lacunarity))
+	}
+	if pad.Pressed(gfx.ButtonA) {
+		g.setPalette((g.paletteIndex + 1) % len(palettePresets))
+	}
+	if pad.Pressed(gfx.ButtonB) {
+		g.setPalette((g.paletteIndex + len(palettePresets) - 1) % len(palettePresets))
+	}
+	if pad.Pressed(gfx.ButtonX) {
+		g.p.Mode = (g.p.Mode + 1) % numNoiseModes
+		show("mode " + g.p.Mode.String())
+	}
+	if pad.Pressed(gfx.ButtonY) {
+		g.p.Mode = (g.p.Mode + numNoiseModes - 1) % numNoiseModes
+		show("mode " + g.p.Mode.String())
+	}
+	return keyChange
+}
+
+const padLabel = "pad"
+
+// padRows are the rows the controller indicator covers, in the bottom left
+// corner
+func padRows() rowRange {
+	return rowRange{winHeight - hudBottom, winHeight}
+}
+
+// drawPadIndicator shows that a controller is plugged in
+func drawPadIndicator(pixels []byte) {
+	w, _ := font.Size(padLabel, hudScale)
+	top := winHeight - hudBottom
+	fillRect(hudX-hudPadding, top, w+2*hudPadding, hudBottom-(hudY-hudPadding), color{0, 0, 0}, pixels)
+	font.Draw(padLabel, hudX, top+hudPadding, hudScale, font.Color{R: 120, G: 255, B: 120}, pixels, winWidth, winHeight)
+}
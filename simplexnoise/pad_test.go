@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sabith-th/games_with_go/gfx"
+)
+
+func TestAxisResponse(t *testing.T) {
+	// halfway is halfway through the travel past the dead zone
+	halfway := padDeadZone + (1-padDeadZone)/2
+	tests := []struct {
+		name string
+		v    float64
+		want float64
+	}{
+		{"rest", 0, 0},
+		{"inside dead zone", 0.1, 0},
+		{"inside dead zone negative", -0.1, 0},
+		{"edge of dead zone", padDeadZone, 0},
+		{"halfway is curved", halfway, 0.25},
+		{"halfway negative", -halfway, -0.25},
+		{"full", 1, 1},
+		{"full negative", -1, -1},
+		{"past full", 1.2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := axisResponse(tt.v); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("axisResponse(%v) = %v, want %v", tt.v, got, tt.want)
+			}
+		})
+	}
+
+	// no jump coming out of the dead zone, and more travel is always more
+	prev := 0.0
+	for v := padDeadZone; v <= 1; v += 0.01 {
+		got := axisResponse(v)
+		if got < prev || got-prev > 0.05 {
+			t.Fatalf("axisResponse(%v) = %v after %v", v, got, prev)
+		}
+		prev = got
+	}
+}
+
+func TestPadDeltas(t *testing.T) {
+	var axes [gfx.NumAxes]float64
+	// sticks and triggers resting a little off still change nothing
+	axes[gfx.AxisLeftX], axes[gfx.AxisRightY], axes[gfx.AxisTriggerLeft] = 0.1, -0.05, 0.12
+	if d := padDeltas(axes, 0.5); !d.zero() {
+		t.Errorf("resting axes give %+v", d)
+	}
+
+	axes = [gfx.NumAxes]float64{}
+	axes[gfx.AxisLeftX], axes[gfx.AxisLeftY] = 1, -1
+	axes[gfx.AxisRightX], axes[gfx.AxisRightY] = 1, -1
+	axes[gfx.AxisTriggerRight] = 1
+	d := padDeltas(axes, 0.5)
+	if d.panX != padPanSpeed/2 || d.panY != -padPanSpeed/2 {
+		t.Errorf("pan is %v, %v, want %v, %v", d.panX, d.panY, padPanSpeed/2, -padPanSpeed/2)
+	}
+	if d.frequency <= 1 || d.gain != padGainRate/2 {
+		t.Errorf("stick right and up gives frequency *%v gain +%v", d.frequency, d.gain)
+	}
+	if d.zoom != 2 {
+		t.Errorf("right trigger for half a second zooms %v, want 2", d.zoom)
+	}
+
+	// both triggers cancel out
+	axes = [gfx.NumAxes]float64{}
+	axes[gfx.AxisTriggerLeft], axes[gfx.AxisTriggerRight] = 1, 1
+	if d := padDeltas(axes, 1); !d.zero() {
+		t.Errorf("both triggers give %+v", d)
+	}
+}
+
+func TestPadDeltaApply(t *testing.T) {
+	p := defaultPreset()
+	d := padDelta{panX: 10, panY: -5, frequency: 1e9, zoom: 1, gain: -100}
+	got := d.apply(p)
+	if got.View.X != p.View.X+10*p.View.Step || got.View.Y != p.View.Y-5*p.View.Step {
+		t.Errorf("view moved from %+v to %+v", p.View, got.View)
+	}
+	if got.Frequency != frequencyRange.max || got.Gain != gainRange.min {
+		t.Errorf("frequency %v gain %v, want them clamped to %v and %v", got.Frequency, got.Gain, frequencyRange.max, gainRange.min)
+	}
+
+	// zooming keeps the middle of the window where it is
+	d = padDelta{frequency: 1, zoom: 2}
+	got = d.apply(p)
+	wx, wy := p.View.at(winWidth/2, winHeight/2)
+	gx, gy := got.View.at(winWidth/2, winHeight/2)
+	if got.View.Step != p.View.Step/2 || gx != wx || gy != wy {
+		t.Errorf("zooming in took %+v to %+v", p.View, got.View)
+	}
+}
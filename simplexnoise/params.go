@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sabith-th/games_with_go/font"
+)
+
+// savedParams is what Ctrl+S writes and Ctrl+L and -params read back, one JSON
+// object:
+//
+//	{
+//	  "frequency": 0.01,     frequency of the first octave
+//	  "lacunarity": 3,       frequency multiplier from one octave to the next
+//	  "gain": 0.2,           amplitude multiplier from one octave to the next
+//	  "octaves": 3,          1 to maxOctaves
+//	  "view": {
+//	    "x": 0,              world position of the top left pixel, the pan
+//	    "y": 0,
+//	    "step": 1            world units per pixel, the zoom, smaller is closer
+//	  },
+//	  "mode": "turbulence",  turbulence, fbm or ridged
+//	  "basis": "simplex",    one of noise.Names(): cellular, perlin, simplex, value
+//	  "palette": "ocean"     name of one of palettePresets
+//	}
+//
+// The palette is saved by name, changes made in the gradient editor are
+// not saved.
+// Fields missing from a loaded file keep their current value, unknown
+// fields are an error.
+type savedParams struct {
+	preset
+	Palette string `json:"palette"`
+}
+
+// saveParams writes p and the named palette to a timestamped json file and
+// returns its name
+func saveParams(p preset, palette string) (string, error) {
+	data, err := json.MarshalIndent(savedParams{p, palette}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	filename := fmt.Sprintf("noise_params_%d.json", time.Now().Unix())
+	return filename, os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// loadParams reads a file written by saveParams over p and palette, the
+// index of the current palette preset, and returns the result
+func loadParams(path string, p preset, palette int) (preset, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p, palette, err
+	}
+	s := savedParams{p, palettePresets[palette].name}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&s)
+	if err != nil {
+		return p, palette, fmt.Errorf("%s: %v", path, err)
+	}
+	np, i, err := s.resolve()
+	if err != nil {
+		return p, palette, fmt.Errorf("%s: %v", path, err)
+	}
+	return np, i, nil
+}
+
+// resolve checks s's preset and returns it with the index of its palette
+func (s savedParams) resolve() (preset, int, error) {
+	err := s.preset.validate()
+	if err != nil {
+		return preset{}, 0, err
+	}
+	for i, pp := range palettePresets {
+		if pp.name == s.Palette {
+			return s.preset, i, nil
+		}
+	}
+	return preset{}, 0, fmt.Errorf("unknown palette %q", s.Palette)
+}
+
+const (
+	promptScale  = 2
+	promptHeight = font.GlyphHeight*promptScale + 2*hudPadding
+)
+
+// textPrompt is a one line text field along the bottom of the window
+type textPrompt struct {
+	label, text string
+}
+
+// backspace removes the last character
+func (tp *textPrompt) backspace() {
+	_, size := utf8.DecodeLastRuneInString(tp.text)
+	tp.text = tp.text[:len(tp.text)-size]
+}
+
+// rows are the rows the prompt covers
+func (tp *textPrompt) rows() rowRange {
+	return rowRange{winHeight - promptHeight, winHeight}
+}
+
+func (tp *textPrompt) draw(pixels []byte) {
+	top := winHeight - promptHeight
+	fillRect(0, top, winWidth, promptHeight, color{0, 0, 0}, pixels)
+	font.Draw(tp.label+tp.text+"_", hudX, top+hudPadding, promptScale, font.Color{R: 255, G: 255, B: 255}, pixels, winWidth, winHeight)
+}
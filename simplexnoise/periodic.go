@@ -0,0 +1,63 @@
+package main
+
+import "context"
+
+// periodicFiller fills fields that repeat every w*h pixels, so a tileset
+// cut from a w*h field wraps: its right edge runs on into its left and its
+// bottom into its top. Each pixel blends filler's field with the same field
+// a period to the left, a period up and both, weighted by how far across
+// the period the pixel is. A whole period across, the blend is all the
+// field a period back, which is the field where the period started.
+// It costs four fills and three fields of memory, buf.progress hears of
+// them as one field four times as high.
+type periodicFiller struct {
+	filler fieldFiller
+	w, h   int
+}
+
+func (f periodicFiller) fill(ctx context.Context, buf *fieldBuffer, w, h int, p preset) (min, max float32, err error) {
+	// left, up and both, one period back
+	var shifted [3]*fieldBuffer
+	for i := range shifted {
+		shifted[i] = &fieldBuffer{noise: make([]float32, w*h)}
+	}
+	// each fill reports as its quarter of the whole
+	if progress := buf.progress; progress != nil {
+		defer func() { buf.progress = progress }()
+		for i, b := range append([]*fieldBuffer{buf}, shifted[:]...) {
+			before := i * h
+			b.progress = func(done, total int) { progress(before+done, 4*total) }
+		}
+	}
+
+	_, _, err = f.filler.fill(ctx, buf, w, h, p)
+	if err != nil {
+		return 0, 0, err
+	}
+	for i, d := range [3][2]int{{1, 0}, {0, 1}, {1, 1}} {
+		q := p
+		q.View = p.View.pan(-d[0]*f.w, -d[1]*f.h)
+		_, _, err = f.filler.fill(ctx, shifted[i], w, h, q)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	r := emptyRange()
+	for y := 0; y < h; y++ {
+		ty := float32(y) / float32(f.h)
+		for x := 0; x < w; x++ {
+			tx := float32(x) / float32(f.w)
+			i := y*w + x
+			// the products are converted so none is fused into an add,
+			// the weights that are zero at the edges must stay exactly zero
+			v := float32(buf.noise[i]*float32((1-tx)*(1-ty))) +
+				float32(shifted[0].noise[i]*float32(tx*(1-ty))) +
+				float32(shifted[1].noise[i]*float32((1-tx)*ty)) +
+				float32(shifted[2].noise[i]*float32(tx*ty))
+			buf.noise[i] = v
+			r.add(v)
+		}
+	}
+	return r.min, r.max, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// TestPeriodicFillerWraps fills one pixel past the period each way, that
+// column and row must be the first column and row again exactly
+func TestPeriodicFillerWraps(t *testing.T) {
+	pool := newWorkerPool(2)
+	defer pool.close()
+	const pw, ph = 48, 32
+	const w, h = pw + 1, ph + 1
+
+	fbm := defaultPreset()
+	fbm.Mode = fbmMode
+	for _, p := range []preset{defaultPreset(), fbm} {
+		buf := newFieldBuffer(w, h)
+		min, max, err := makeField(context.Background(), periodicFiller{pool, pw, ph}, buf, w, h, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := func(x, y int) float32 { return buf.noise[y*w+x] }
+		for y := 0; y < h; y++ {
+			if at(pw, y) != at(0, y) {
+				t.Fatalf("%v: right edge is %v at row %d, left edge %v", p.Mode, at(pw, y), y, at(0, y))
+			}
+		}
+		for x := 0; x < w; x++ {
+			if at(x, ph) != at(x, 0) {
+				t.Fatalf("%v: bottom edge is %v at column %d, top edge %v", p.Mode, at(x, ph), x, at(x, 0))
+			}
+		}
+		r := emptyRange()
+		for _, v := range buf.noise {
+			r.add(v)
+		}
+		if min != r.min || max != r.max || min == max {
+			t.Errorf("%v: range %v..%v, the field has %v..%v", p.Mode, min, max, r.min, r.max)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+// fillRect fills a rectangle of a winWidth wide buffer, clipped to the window
+func fillRect(x, y, w, h int, c color, pixels []byte) {
+	packed := packColor(c, nativeBigEndian)
+	words := pixelWords(pixels)
+	for py := y; py < y+h; py++ {
+		if py < 0 || py >= winHeight {
+			continue
+		}
+		for px := x; px < x+w; px++ {
+			if px < 0 || px >= winWidth {
+				continue
+			}
+			words[py*winWidth+px] = packed
+		}
+	}
+}
+
+func inRect(mx, my, x, y, w, h int) bool {
+	return mx >= x && mx < x+w && my >= y && my < y+h
+}
+
+type pickerDrag int
+
+const (
+	dragNone pickerDrag = iota
+	dragSquare
+	dragSlider
+)
+
+const (
+	pickerSize   = 150
+	sliderWidth  = 20
+	sliderGap    = 10
+	pickerBorder = 4
+)
+
+// colorPicker edits a color as hue and saturation, picked on a square with
+// hue across and saturation down, and value, picked on a slider beside it
+type colorPicker struct {
+	x, y    int
+	h, s, v float32
+	drag    pickerDrag
+}
+
+func (cp *colorPicker) setColor(c color) {
+	hsv := RGBtoHSV(c)
+	cp.h, cp.s, cp.v = hsv.H, hsv.S, hsv.V
+}
+
+func (cp *colorPicker) color() color {
+	return HSVtoRGB(ColorHSV{cp.h, cp.s, cp.v})
+}
+
+func (cp *colorPicker) sliderX() int {
+	return cp.x + pickerSize + sliderGap
+}
+
+// contains reports whether the point is on the picker or its background
+func (cp *colorPicker) contains(mx, my int) bool {
+	return inRect(mx, my, cp.x-pickerBorder, cp.y-pickerBorder,
+		pickerSize+sliderGap+sliderWidth+2*pickerBorder, pickerSize+2*pickerBorder)
+}
+
+func unit(v, size int) float32 {
+	return float32(clamp(0, size-1, v)) / float32(size-1)
+}
+
+// handleMouse starts a drag when the left button goes down on the square or
+// the slider and follows the mouse until it is released. It returns true
+// when the color changed.
+func (cp *colorPicker) handleMouse(mx, my int, down, wasDown bool) bool {
+	if !down {
+		cp.drag = dragNone
+		return false
+	}
+	if !wasDown {
+		switch {
+		case inRect(mx, my, cp.x, cp.y, pickerSize, pickerSize):
+			cp.drag = dragSquare
+		case inRect(mx, my, cp.sliderX(), cp.y, sliderWidth, pickerSize):
+			cp.drag = dragSlider
+		}
+	}
+	switch cp.drag {
+	case dragSquare:
+		cp.h = unit(mx-cp.x, pickerSize) * 359
+		cp.s = 1 - unit(my-cp.y, pickerSize)
+		return true
+	case dragSlider:
+		cp.v = 1 - unit(my-cp.y, pickerSize)
+		return true
+	}
+	return false
+}
+
+func (cp *colorPicker) draw(pixels []byte) {
+	fillRect(cp.x-pickerBorder, cp.y-pickerBorder, pickerSize+sliderGap+sliderWidth+2*pickerBorder,
+		pickerSize+2*pickerBorder, color{30, 30, 30}, pixels)
+
+	// the square is drawn at full value so every hue stays visible
+	for y := 0; y < pickerSize; y++ {
+		for x := 0; x < pickerSize; x++ {
+			fillRect(cp.x+x, cp.y+y, 1, 1, HSVtoRGB(ColorHSV{unit(x, pickerSize) * 359, 1 - unit(y, pickerSize), 1}), pixels)
+		}
+	}
+	for y := 0; y < pickerSize; y++ {
+		fillRect(cp.sliderX(), cp.y+y, sliderWidth, 1, HSVtoRGB(ColorHSV{cp.h, cp.s, 1 - unit(y, pickerSize)}), pixels)
+	}
+
+	white, black := color{255, 255, 255}, color{0, 0, 0}
+	mx := cp.x + int(cp.h/359*(pickerSize-1))
+	my := cp.y + int((1-cp.s)*(pickerSize-1))
+	fillRect(mx-4, my, 9, 1, black, pixels)
+	fillRect(mx, my-4, 1, 9, black, pixels)
+	vy := cp.y + int((1-cp.v)*(pickerSize-1))
+	fillRect(cp.sliderX()-2, vy-1, sliderWidth+4, 3, white, pixels)
+	fillRect(cp.sliderX(), vy, sliderWidth, 1, black, pixels)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/sabith-th/games_with_go/export"
+	"github.com/sabith-th/games_with_go/expr"
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+const pipelineWidth, pipelineHeight = 128, 96
+
+// thresholdGradient is black below the middle of the field's range and
+// white from it on
+func thresholdGradient() []color {
+	gradient := make([]color, 256)
+	for i := 128; i < len(gradient); i++ {
+		gradient[i] = color{255, 255, 255}
+	}
+	return gradient
+}
+
+// TestPipelineGolden renders through the same workers, cache and fieldBuffer
+// the window uses, then compares against testdata/pipeline_<name>.png.
+// Every case is drawn into one shared buffer, twice over, so a field or
+// pixel left over from the case before shows up as a difference. The noise
+// has no seed, the fixed parameters are all it depends on. Run with
+// -update-golden to accept a deliberate change.
+func TestPipelineGolden(t *testing.T) {
+	pool := newWorkerPool(4)
+	defer pool.close()
+	cache := newOctaveCache(pool, pipelineWidth, pipelineHeight)
+
+	fbm := defaultPreset()
+	fbm.Mode = fbmMode
+	ridged := defaultPreset()
+	ridged.Mode = ridgedMode
+	ridged.View = viewport{X: -40, Y: 25, Step: 3}
+	tests := []struct {
+		name     string
+		filler   fieldFiller
+		p        preset
+		gradient []color
+		// golden is the png the case is compared against, cases taking
+		// different routes to the same pixels share one
+		golden string
+	}{
+		{"turbulence", pool, defaultPreset(), defaultGradient, "turbulence"},
+		{"turbulence through the octave cache", cache, defaultPreset(), defaultGradient, "turbulence"},
+		{"grayscale", pool, fbm, getGradient(color{0, 0, 0}, color{255, 255, 255}), "grayscale"},
+		{"threshold", pool, ridged, thresholdGradient(), "threshold"},
+	}
+
+	buf := newFieldBuffer(pipelineWidth, pipelineHeight)
+	written := map[string]bool{}
+	for pass := 0; pass < 2; pass++ {
+		for _, tt := range tests {
+			_, err := makeNoise(context.Background(), tt.filler, buf, pipelineWidth, pipelineHeight, tt.p, tt.gradient)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			path := filepath.Join("testdata", "pipeline_"+tt.golden+".png")
+			if *updateGolden {
+				if !written[path] {
+					err = export.WritePNG(path, buf.pixels, pipelineWidth, pipelineHeight)
+					if err != nil {
+						t.Fatal(err)
+					}
+					written[path] = true
+				}
+				continue
+			}
+			name := tt.name
+			if pass > 0 {
+				name += " again"
+			}
+			t.Run(name, func(t *testing.T) {
+				compareGolden(t, path, buf.pixels, pipelineWidth, pipelineHeight)
+			})
+		}
+	}
+}
+
+// TestGenerateMatchesPool renders every mode and basis, and a formula,
+// through noise.Generate as /render and /noise do and on the pool as the
+// window does. The two must give the same pixels.
+func TestGenerateMatchesPool(t *testing.T) {
+	const w, h = pipelineWidth, pipelineHeight
+	pool := newWorkerPool(4)
+	defer pool.close()
+	formula, err := expr.Parse("turbulence(x, y, 0.01, 3, 0.2, 3) * (1 + sin(x*0.05))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fillers := []fieldFiller{pool, formulaFiller{pool, formula}}
+	for _, filler := range fillers {
+		for mode := noiseMode(0); mode < numNoiseModes; mode++ {
+			for _, b := range noise.Names() {
+				for _, view := range []viewport{defaultPreset().View, {X: -40, Y: 25, Step: 3}} {
+					p := defaultPreset()
+					p.Mode, p.Basis, p.View = mode, basis(b), view
+					want, err := renderPreset(filler, p, w, h, nil)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got, err := generateSource(context.Background(), fillerSource(filler, p, w, h), p, w, h, nil)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.Equal(got, want) {
+						t.Errorf("%T, %v %v at %v: noise.Generate differs from the pool", filler, mode, b, view)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestPercentPrinter reports a fill of 8 bands of 8 rows, every whole
+// percentage once
+func TestPercentPrinter(t *testing.T) {
+	var out bytes.Buffer
+	progress := percentPrinter(&out, "generating")
+	for done := 8; done <= 64; done += 8 {
+		progress(done, 64)
+		progress(done, 64)
+	}
+	want := "\rgenerating 12%\rgenerating 25%\rgenerating 37%\rgenerating 50%" +
+		"\rgenerating 62%\rgenerating 75%\rgenerating 87%\rgenerating 100%\n"
+	if out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+}
+
+// TestGenerationRejects checks every way into a generation refuses a buffer
+// of the wrong size or an invalid preset before writing to it
+func TestGenerationRejects(t *testing.T) {
+	pool := newWorkerPool(2)
+	defer pool.close()
+	w, h := pipelineWidth, pipelineHeight
+	noOctaves := defaultPreset()
+	noOctaves.Octaves = 0
+
+	shortPixels := newFieldBuffer(w, h)
+	shortPixels.pixels = shortPixels.pixels[:w*h*4-1]
+	shortNoise := newFieldBuffer(w, h)
+	shortNoise.noise = shortNoise.noise[:w*h-1]
+
+	generate := func(buf *fieldBuffer, p preset) error {
+		_, err := makeNoise(context.Background(), pool, buf, w, h, p, defaultGradient)
+		return err
+	}
+	tests := []struct {
+		name string
+		run  func() error
+		// buffer is the sizeError's buffer, or empty for errInvalidPreset
+		buffer string
+	}{
+		{"makeNoise short pixels", func() error { return generate(shortPixels, defaultPreset()) }, "pixel"},
+		{"makeNoise short noise", func() error { return generate(shortNoise, defaultPreset()) }, "noise"},
+		{"makeNoise no octaves", func() error { return generate(newFieldBuffer(w, h), noOctaves) }, ""},
+		{"makeField short noise", func() error {
+			_, _, err := makeField(context.Background(), pool, shortNoise, w, h, defaultPreset())
+			return err
+		}, "noise"},
+		{"makePreview short pixels", func() error {
+			sw, sh := previewSize(w, h)
+			_, err := makePreview(context.Background(), pool, newFieldBuffer(sw, sh), shortPixels, w, h, defaultPreset(), defaultGradient)
+			return err
+		}, "pixel"},
+		{"makePreview no octaves", func() error {
+			sw, sh := previewSize(w, h)
+			_, err := makePreview(context.Background(), pool, newFieldBuffer(sw, sh), newFieldBuffer(w, h), w, h, noOctaves, defaultGradient)
+			return err
+		}, ""},
+		{"generateField short pixels", func() error {
+			return generateField(context.Background(), shortPixels, defaultPreset().source(w, h), defaultPreset(), w, h, defaultGradient, nil)
+		}, "pixel"},
+		{"generateField no octaves", func() error {
+			return generateField(context.Background(), newFieldBuffer(w, h), noOctaves.source(w, h), noOctaves, w, h, defaultGradient, nil)
+		}, ""},
+		{"renderPreset no octaves", func() error {
+			_, err := renderPreset(pool, noOctaves, w, h, nil)
+			return err
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if tt.buffer == "" {
+				if !errors.Is(err, errInvalidPreset) {
+					t.Fatalf("got %v, want an invalid preset", err)
+				}
+				return
+			}
+			var size *sizeError
+			if !errors.As(err, &size) || size.buffer != tt.buffer {
+				t.Fatalf("got %v, want a %s buffer size error", err, tt.buffer)
+			}
+		})
+	}
+
+	// nothing is drawn into a buffer that is refused
+	buf := newFieldBuffer(w, h)
+	for i := range buf.pixels {
+		buf.pixels[i] = 7
+	}
+	buf.noise = buf.noise[:1]
+	if generate(buf, defaultPreset()) == nil {
+		t.Fatal("no error for a short noise buffer")
+	}
+	for i, v := range buf.pixels {
+		if v != 7 {
+			t.Fatalf("pixel byte %d was written", i)
+		}
+	}
+}
+
+// TestExportGoSourceFlat exports a field with no range, every sample must
+// be the middle of 0..1 rather than the NaN of dividing by the range
+func TestExportGoSourceFlat(t *testing.T) {
+	const w, h = 16, 8
+	pool := newWorkerPool(2)
+	defer pool.close()
+	flat, err := expr.Parse("3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "field.go")
+	err = exportGoSource(formulaFiller{pool, flat}, path, "field", defaultPreset(), w, h, 1, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := 0
+	ast.Inspect(f, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.FLOAT {
+			samples++
+			if lit.Value != "0.5" {
+				t.Errorf("sample %d is %s, want 0.5", samples, lit.Value)
+			}
+		}
+		return true
+	})
+	if samples != w*h {
+		t.Errorf("%d samples, want %d", samples, w*h)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// rowsPerJob is the height of the bands a field is split into. Bands much
+// smaller than the field keep every worker busy until the end.
+const rowsPerJob = 8
+
+// fieldJob asks a worker to fill rows startY..endY-1 of noise, with wide
+// telling whether the whole field needs float64 coordinates. If src is set
+// the rows are sampled from it instead of p's simplex turbulence. The worker
+// gives up between rows once ctx is done, merges the range of what it wrote
+// into its own slot of ranges, sends its number of rows on finished if that
+// is not nil and marks the job done on wg.
+type fieldJob struct {
+	ctx          context.Context
+	noise        []float32
+	w            int
+	startY, endY int
+	p            preset
+	wide         bool
+	src          noise.Source
+	ranges       []bandRange
+	wg           *sync.WaitGroup
+	finished     chan<- int
+}
+
+// bandRange is the range of some values, empty until the first add
+type bandRange struct {
+	min, max float32
+}
+
+func emptyRange() bandRange {
+	return bandRange{float32(math.MaxFloat32), float32(-math.MaxFloat32)}
+}
+
+func (r *bandRange) add(v float32) {
+	if v < r.min {
+		r.min = v
+	}
+	if v > r.max {
+		r.max = v
+	}
+}
+
+func (r *bandRange) merge(o bandRange) {
+	if o.min < r.min {
+		r.min = o.min
+	}
+	if o.max > r.max {
+		r.max = o.max
+	}
+}
+
+// workerPool is a fixed set of goroutines that live for the whole program
+// and evaluate noise bands sent to them, so regenerating a field only costs
+// sending jobs instead of starting goroutines
+type workerPool struct {
+	size   int
+	jobs   chan fieldJob
+	done   chan struct{}
+	wg     sync.WaitGroup
+	closed sync.Once
+}
+
+// workerCount checks the -workers flag. A field is shared out by rows, so
+// workers past the number of rows would never get any and are dropped.
+func workerCount(n, rows int) (int, error) {
+	if n < 1 {
+		return 0, fmt.Errorf("workers must be at least 1, got %d", n)
+	}
+	if n > rows {
+		return rows, nil
+	}
+	return n, nil
+}
+
+func newWorkerPool(size int) *workerPool {
+	wp := &workerPool{size: size, jobs: make(chan fieldJob), done: make(chan struct{})}
+	wp.wg.Add(size)
+	for i := 0; i < size; i++ {
+		go wp.work(i)
+	}
+	return wp
+}
+
+// work runs jobs as worker id. Only worker id writes slot id of a job's
+// ranges, so the slots need no lock.
+func (wp *workerPool) work(id int) {
+	defer wp.wg.Done()
+	for {
+		select {
+		case job := <-wp.jobs:
+			job.ranges[id].merge(job.run())
+			if job.finished != nil {
+				job.finished <- job.endY - job.startY
+			}
+			job.wg.Done()
+		case <-wp.done:
+			return
+		}
+	}
+}
+
+func (job fieldJob) run() bandRange {
+	r := emptyRange()
+	for y := job.startY; y < job.endY; y++ {
+		if job.ctx.Err() != nil {
+			return r
+		}
+		row := job.noise[y*job.w : (y+1)*job.w]
+		if job.src != nil {
+			job.p.View.sampleSourceRow(row, y, job.src)
+		} else {
+			job.p.View.sampleRow(row, y, job.wide, job.p)
+		}
+		for _, v := range row {
+			r.add(v)
+		}
+	}
+	return r
+}
+
+// fieldBuffer holds everything one field is generated into, so the same
+// memory can be used again for the next field
+type fieldBuffer struct {
+	noise  []float32
+	pixels []byte
+	// ranges has a slot per worker of the pool that last filled the
+	// buffer, wg counts the jobs of the fill still running
+	ranges []bandRange
+	wg     sync.WaitGroup
+	// progress, if set, is called by the pool on the goroutine filling the
+	// buffer as bands of the field finish, with the rows done so far out
+	// of the field's height. The octave cache does not report it.
+	progress func(done, total int)
+}
+
+func newFieldBuffer(w, h int) *fieldBuffer {
+	return &fieldBuffer{
+		noise:  make([]float32, w*h),
+		pixels: make([]byte, w*h*4),
+	}
+}
+
+// sizeError is a buffer the wrong size for the field generated into it,
+// writing the field anyway would leave part of it stale or run off its end
+type sizeError struct {
+	buffer    string
+	got, want int
+	w, h      int
+}
+
+func (e *sizeError) Error() string {
+	return fmt.Sprintf("%s buffer has length %d, a %dx%d field needs %d", e.buffer, e.got, e.w, e.h, e.want)
+}
+
+// errInvalidPreset wraps why a preset was refused on the way into a
+// generation, it is checked there as well as where presets are read since
+// a bad one reaching the workers would draw nonsense without failing
+var errInvalidPreset = errors.New("invalid preset")
+
+// errPoolClosed is a fill the pool was closed in the middle of, the rows it
+// had not handed out yet are left as they were
+var errPoolClosed = errors.New("worker pool closed")
+
+// checkField reports whether a w*h field for p can be generated into buf.
+// Only the noise is checked when pixels is false, previews draw their
+// pixels into a larger buffer.
+func checkField(buf *fieldBuffer, w, h int, p preset, pixels bool) error {
+	if w <= 0 || h <= 0 {
+		return fmt.Errorf("field size %dx%d", w, h)
+	}
+	if len(buf.noise) < w*h {
+		return &sizeError{"noise", len(buf.noise), w * h, w, h}
+	}
+	if pixels && len(buf.pixels) != w*h*4 {
+		return &sizeError{"pixel", len(buf.pixels), w * h * 4, w, h}
+	}
+	err := p.validate()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidPreset, err)
+	}
+	return nil
+}
+
+// fill evaluates a w*h field for p into buf.noise using the pool and returns
+// its range. Past the first fill into a buffer the classic path does not
+// allocate, and it is safe to call from several
+// goroutines at once with different buffers. If ctx is canceled the field
+// is incomplete and ctx's error is returned, after close it is incomplete
+// too and errPoolClosed is returned. buf.progress hears of every band
+// finished before then.
+func (wp *workerPool) fill(ctx context.Context, buf *fieldBuffer, w, h int, p preset) (min, max float32, err error) {
+	return wp.fillSource(ctx, buf, w, h, p, p.rowSource(w, h))
+}
+
+// fillSource is fill with src sampled over p's view instead of p's simplex
+// turbulence, or fill for the classic path if src is nil
+func (wp *workerPool) fillSource(ctx context.Context, buf *fieldBuffer, w, h int, p preset, src noise.Source) (min, max float32, err error) {
+	if len(buf.ranges) != wp.size {
+		buf.ranges = make([]bandRange, wp.size)
+	}
+	for i := range buf.ranges {
+		buf.ranges[i] = emptyRange()
+	}
+	wide := p.View.wide(w, h)
+	closed := false
+	// bands only report back when there is someone to tell, finished has
+	// room for all of them so no worker waits on it
+	var finished chan int
+	if buf.progress != nil {
+		finished = make(chan int, (h+rowsPerJob-1)/rowsPerJob)
+	}
+	submitted, reported, rows := 0, 0, 0
+
+submit:
+	for startY := 0; startY < h; startY += rowsPerJob {
+		endY := startY + rowsPerJob
+		if endY > h {
+			endY = h
+		}
+		// the job is counted before it is sent, a worker can finish it
+		// before the send returns
+		buf.wg.Add(1)
+		job := fieldJob{ctx, buf.noise, w, startY, endY, p, wide, src, buf.ranges, &buf.wg, finished}
+	send:
+		for {
+			select {
+			case wp.jobs <- job:
+				submitted++
+				break send
+			case n := <-finished:
+				reported++
+				rows += n
+				if ctx.Err() == nil {
+					buf.progress(rows, h)
+				}
+			case <-ctx.Done():
+				buf.wg.Done()
+				break submit
+			case <-wp.done:
+				buf.wg.Done()
+				closed = true
+				break submit
+			}
+		}
+	}
+	for finished != nil && reported < submitted {
+		reported++
+		rows += <-finished
+		if ctx.Err() == nil {
+			buf.progress(rows, h)
+		}
+	}
+
+	// every submitted job is waited for before the slots are read, the
+	// reduction happens here rather than in the workers
+	buf.wg.Wait()
+	r := emptyRange()
+	for _, slot := range buf.ranges {
+		r.merge(slot)
+	}
+	if ctx.Err() == nil && closed {
+		return r.min, r.max, errPoolClosed
+	}
+	return r.min, r.max, ctx.Err()
+}
+
+// close stops the workers once they finish the job they are on, closing
+// it again only waits for that
+func (wp *workerPool) close() {
+	wp.closed.Do(func() { close(wp.done) })
+	wp.wg.Wait()
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// TestMakeFieldCoversEveryPixel fills the buffer with NaN first, for sizes
+// that do not split evenly into bands or workers, so any pixel no job
+// writes is left NaN. The pool has as many workers as GOMAXPROCS, like the
+// window's.
+func TestMakeFieldCoversEveryPixel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	fbm := defaultPreset()
+	fbm.Mode = fbmMode
+	far := defaultPreset()
+	far.View = viewport{X: 3e9, Y: -3e9, Step: 1}
+	presets := []struct {
+		name string
+		p    preset
+	}{
+		{"turbulence", defaultPreset()},
+		{"fbm", fbm},
+		{"far", far},
+	}
+
+	sentinel := float32(math.NaN())
+	for _, procs := range []int{1, 2, 3, 7, 16} {
+		runtime.GOMAXPROCS(procs)
+		pool := newWorkerPool(procs)
+		for _, size := range [][2]int{{1, 1}, {7, 13}, {641, 479}} {
+			w, h := size[0], size[1]
+			cache := newOctaveCache(pool, w, h)
+			for _, filler := range []fieldFiller{pool, cache} {
+				for _, pp := range presets {
+					buf := newFieldBuffer(w, h)
+					for i := range buf.noise {
+						buf.noise[i] = sentinel
+					}
+					min, max, err := makeField(context.Background(), filler, buf, w, h, pp.p)
+					if err != nil {
+						t.Fatal(err)
+					}
+					r := emptyRange()
+					for i, v := range buf.noise {
+						if math.IsNaN(float64(v)) {
+							t.Fatalf("GOMAXPROCS %d, %dx%d, %s through %T: pixel %d,%d never written", procs, w, h, pp.name, filler, i%w, i/w)
+						}
+						r.add(v)
+					}
+					if min != r.min || max != r.max {
+						t.Errorf("GOMAXPROCS %d, %dx%d, %s through %T: range %v..%v, the field has %v..%v", procs, w, h, pp.name, filler, min, max, r.min, r.max)
+					}
+				}
+			}
+		}
+		pool.close()
+	}
+}
+
+// craftedSource returns values[y*w+x] at pixel x, y of the default view
+type craftedSource struct {
+	w      int
+	values []float32
+}
+
+func (s craftedSource) At(x, y float32) float32 {
+	return s.values[int(y)*s.w+int(x)]
+}
+
+func rangeOf(values []float32) bandRange {
+	r := emptyRange()
+	for _, v := range values {
+		r.add(v)
+	}
+	return r
+}
+
+// TestRangeMaxAtChunkStart puts the field's max, and its min, on the first
+// pixel of a band, where an else-if between the checks missed it. Each band
+// of a field falling from the first pixel to the last starts with its own
+// max too.
+func TestRangeMaxAtChunkStart(t *testing.T) {
+	const w, h = 5, 3*rowsPerJob + 2
+	pool := newWorkerPool(3)
+	defer pool.close()
+	p := defaultPreset()
+
+	var fields [][]float32
+	for start := 0; start < h; start += rowsPerJob {
+		values := make([]float32, w*h)
+		values[start*w] = 1
+		values[(h-1-start)/rowsPerJob*rowsPerJob*w] = -1
+		fields = append(fields, values)
+	}
+	falling := make([]float32, w*h)
+	for i := range falling {
+		falling[i] = float32(len(falling) - i)
+	}
+	fields = append(fields, falling)
+
+	for _, values := range fields {
+		want := rangeOf(values)
+		buf := newFieldBuffer(w, h)
+		min, max, err := pool.fillSource(context.Background(), buf, w, h, p, craftedSource{w, values})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if min != want.min || max != want.max {
+			t.Errorf("pool range %v..%v, want %v..%v", min, max, want.min, want.max)
+		}
+	}
+
+	// the cache takes the range of its sum in one pass, the first pixel
+	// starts that pass
+	cache := newOctaveCache(pool, w, h)
+	buf := newFieldBuffer(w, h)
+	if _, _, err := cache.fill(context.Background(), buf, w, h, p); err != nil {
+		t.Fatal(err)
+	}
+	for _, values := range fields {
+		copy(cache.sum, values)
+		min, max, err := cache.fill(context.Background(), buf, w, h, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := rangeOf(values)
+		if min != want.min || max != want.max {
+			t.Errorf("cache range %v..%v, want %v..%v", min, max, want.min, want.max)
+		}
+	}
+}
+
+// spawnFill is how fields were made before the pool, for BenchmarkPool to
+// compare against: a goroutine per cpu started for every field, each taking
+// an equal band of rows, with the range merged under a mutex
+func spawnFill(buf *fieldBuffer, w, h int, p preset) (min, max float32) {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	r := emptyRange()
+	n := runtime.NumCPU()
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(startY, endY int) {
+			defer wg.Done()
+			band := emptyRange()
+			for y := startY; y < endY; y++ {
+				row := buf.noise[y*w : (y+1)*w]
+				p.View.sampleRow(row, y, false, p)
+				for _, v := range row {
+					band.add(v)
+				}
+			}
+			mutex.Lock()
+			r.merge(band)
+			mutex.Unlock()
+		}(i*h/n, (i+1)*h/n)
+	}
+	wg.Wait()
+	return r.min, r.max
+}
+
+// BenchmarkPool is the latency of one regeneration on the long-lived pool
+// and with goroutines started for each field. One octave keeps the noise
+// cheap, so the cost of handing out the work shows.
+func BenchmarkPool(b *testing.B) {
+	p := defaultPreset()
+	p.Octaves = 1
+	for _, size := range [][2]int{{200, 150}, {winWidth, winHeight}} {
+		w, h := size[0], size[1]
+		buf := newFieldBuffer(w, h)
+		b.Run(fmt.Sprintf("pool/%dx%d", w, h), func(b *testing.B) {
+			pool := newWorkerPool(runtime.NumCPU())
+			defer pool.close()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				pool.fill(context.Background(), buf, w, h, p)
+			}
+		})
+		b.Run(fmt.Sprintf("goroutines/%dx%d", w, h), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				spawnFill(buf, w, h, p)
+			}
+		})
+	}
+}
+
+// TestRegenerateAllocs regenerates into the same buffer the way the
+// generator reuses its free ones, after the first field nothing is
+// allocated. The cache alternates the gain so every field re-weights the
+// cached layers.
+func TestRegenerateAllocs(t *testing.T) {
+	const w, h = 64, 48
+	pool := newWorkerPool(4)
+	defer pool.close()
+	cache := newOctaveCache(pool, w, h)
+	for _, tt := range []struct {
+		name   string
+		filler fieldFiller
+	}{
+		{"pool", pool},
+		{"cache", cache},
+	} {
+		buf := newFieldBuffer(w, h)
+		p := defaultPreset()
+		gains := []float32{p.Gain, p.Gain / 2}
+		i := 0
+		regenerate := func() {
+			p.Gain = gains[i%len(gains)]
+			i++
+			_, err := makeNoise(context.Background(), tt.filler, buf, w, h, p, defaultGradient)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		regenerate()
+		if allocs := testing.AllocsPerRun(20, regenerate); allocs != 0 {
+			t.Errorf("%s: %v allocations a regeneration, want 0", tt.name, allocs)
+		}
+	}
+}
+
+// BenchmarkRegenerate is one window sized makeNoise into a reused buffer,
+// on the pool and re-weighting the octave cache
+func BenchmarkRegenerate(b *testing.B) {
+	p := defaultPreset()
+	gains := []float32{p.Gain, p.Gain / 2}
+	pool := newWorkerPool(runtime.NumCPU())
+	defer pool.close()
+	for _, tt := range []struct {
+		name   string
+		filler fieldFiller
+	}{
+		{"pool", pool},
+		{"cache", newOctaveCache(pool, winWidth, winHeight)},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			buf := newFieldBuffer(winWidth, winHeight)
+			makeNoise(context.Background(), tt.filler, buf, winWidth, winHeight, p, defaultGradient)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p.Gain = gains[i%len(gains)]
+				makeNoise(context.Background(), tt.filler, buf, winWidth, winHeight, p, defaultGradient)
+			}
+		})
+	}
+}
+
+// BenchmarkWorkers regenerates a window sized field on pools of 1, 2, 4 and
+// 8 workers, ns/op should fall with the count up to the number of cpus
+func BenchmarkWorkers(b *testing.B) {
+	p := defaultPreset()
+	buf := newFieldBuffer(winWidth, winHeight)
+	for _, n := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			pool := newWorkerPool(n)
+			defer pool.close()
+			// the first fill sizes the buffer's range slots for the pool
+			makeNoise(context.Background(), pool, buf, winWidth, winHeight, p, defaultGradient)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				makeNoise(context.Background(), pool, buf, winWidth, winHeight, p, defaultGradient)
+			}
+		})
+	}
+}
+
+// TestFillProgress checks the rows the pool reports go up band by band to
+// the height of the field, for a periodic fill as well as it is four
+// fields tall, and that canceling from the callback stops the reports
+func TestFillProgress(t *testing.T) {
+	const w, h = 24, 5*rowsPerJob + 3
+	pool := newWorkerPool(3)
+	defer pool.close()
+	for _, tt := range []struct {
+		filler fieldFiller
+		total  int
+	}{
+		{pool, h},
+		{periodicFiller{pool, w, h}, 4 * h},
+	} {
+		buf := newFieldBuffer(w, h)
+		last := 0
+		buf.progress = func(done, total int) {
+			if total != tt.total || done <= last || done > total {
+				t.Errorf("%T: %d of %d rows after %d", tt.filler, done, total, last)
+			}
+			last = done
+		}
+		_, _, err := tt.filler.fill(context.Background(), buf, w, h, defaultPreset())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last != tt.total {
+			t.Errorf("%T: last reported %d rows, want %d", tt.filler, last, tt.total)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buf := newFieldBuffer(w, h)
+	reports := 0
+	buf.progress = func(done, total int) {
+		reports++
+		cancel()
+	}
+	_, _, err := pool.fill(ctx, buf, w, h, defaultPreset())
+	if err != context.Canceled || reports != 1 {
+		t.Errorf("a fill canceled at the first report returned %v after %d reports", err, reports)
+	}
+}
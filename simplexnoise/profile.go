@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"time"
+)
+
+// profileOptions are the files the profiling flags asked for, empty ones
+// are not written
+type profileOptions struct {
+	cpu, mem, trace string
+}
+
+func (o profileOptions) enabled() bool {
+	return o.cpu != "" || o.mem != "" || o.trace != ""
+}
+
+// start begins the cpu profile and execution trace. The returned stop ends
+// them and writes the heap profile, so everything between the two calls is
+// covered and nothing else.
+func (o profileOptions) start() (stop func() error, err error) {
+	var cpuFile, traceFile *os.File
+	closeAll := func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+		if traceFile != nil {
+			trace.Stop()
+			traceFile.Close()
+		}
+	}
+
+	if o.cpu != "" {
+		cpuFile, err = os.Create(o.cpu)
+		if err != nil {
+			return nil, err
+		}
+		err = pprof.StartCPUProfile(cpuFile)
+		if err != nil {
+			cpuFile.Close()
+			return nil, err
+		}
+	}
+	if o.trace != "" {
+		traceFile, err = os.Create(o.trace)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		err = trace.Start(traceFile)
+		if err != nil {
+			traceFile.Close()
+			traceFile = nil
+			closeAll()
+			return nil, err
+		}
+	}
+
+	return func() error {
+		closeAll()
+		if o.mem == "" {
+			return nil
+		}
+		f, err := os.Create(o.mem)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		// collect first so the profile shows what is still live
+		runtime.GC()
+		return pprof.WriteHeapProfile(f)
+	}, nil
+}
+
+// runHeadless runs one of the windowless modes under the requested profiles
+// and returns the exit code, 1 if it fails
+func runHeadless(o profileOptions, run func() error) int {
+	stop, err := o.start()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	err = run()
+	stopErr := stop()
+	if err == nil {
+		err = stopErr
+	}
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, pct float64) time.Duration {
+	rank := int(math.Ceil(pct/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// benchTimes regenerates a w*h field for p n times on the pool, after one
+// warm up run that is not counted, and returns the generation times sorted
+// from the fastest. It goes straight to the pool, so every run evaluates
+// every octave.
+func benchTimes(pool *workerPool, p preset, w, h, n int) ([]time.Duration, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("bench needs at least one run, got %d", n)
+	}
+	buf := newFieldBuffer(w, h)
+	_, err := makeNoise(context.Background(), pool, buf, w, h, p, defaultGradient)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Duration, n)
+	for i := range times {
+		t, err := makeNoise(context.Background(), pool, buf, w, h, p, defaultGradient)
+		if err != nil {
+			return nil, err
+		}
+		times[i] = t.generate
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times, nil
+}
+
+func mean(times []time.Duration) time.Duration {
+	var total time.Duration
+	for _, t := range times {
+		total += t
+	}
+	return total / time.Duration(len(times))
+}
+
+// runBench benchmarks n regenerations on the pool and writes the
+// generation times to out
+func runBench(out io.Writer, pool *workerPool, p preset, w, h, n int) error {
+	times, err := benchTimes(pool, p, w, h, n)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d regenerations of %dx%d with %d workers\n", n, w, h, pool.size)
+	fmt.Fprintf(out, "generation mean %.2fms median %.2fms p95 %.2fms\n",
+		ms(mean(times)), ms(percentile(times, 50)), ms(percentile(times, 95)))
+	return nil
+}
+
+// scalingWorkers are the pool sizes runScaling compares
+var scalingWorkers = []int{1, 2, 4, 8}
+
+// defaultScalingRuns is how many regenerations -bench-scaling times per
+// pool size without -bench
+const defaultScalingRuns = 10
+
+// runScaling benchmarks n regenerations on a pool of each of
+// scalingWorkers in turn and writes each median with its speed up over one
+// worker and its efficiency, the speed up per worker. Sizes past the
+// number of rows are skipped, the rows are all there is to share out.
+func runScaling(out io.Writer, p preset, w, h, n int) error {
+	fmt.Fprintf(out, "%d regenerations of %dx%d per pool size\n", n, w, h)
+	var single time.Duration
+	for _, workers := range scalingWorkers {
+		if workers > h {
+			break
+		}
+		pool := newWorkerPool(workers)
+		times, err := benchTimes(pool, p, w, h, n)
+		pool.close()
+		if err != nil {
+			return err
+		}
+		median := percentile(times, 50)
+		if single == 0 {
+			single = median
+		}
+		speedup := float64(single) / float64(median)
+		fmt.Fprintf(out, "%d workers median %.2fms speed up %.2fx efficiency %.0f%%\n",
+			workers, ms(median), speedup, 100*speedup/float64(workers))
+	}
+	return nil
+}
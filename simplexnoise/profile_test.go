@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// TestBenchSmoke runs -bench for a few regenerations under a cpu and a
+// heap profile
+func TestBenchSmoke(t *testing.T) {
+	pool := newWorkerPool(2)
+	defer pool.close()
+	dir := t.TempDir()
+	profiles := profileOptions{cpu: filepath.Join(dir, "cpu.pprof"), mem: filepath.Join(dir, "mem.pprof")}
+
+	var out bytes.Buffer
+	code := runHeadless(profiles, func() error {
+		return runBench(&out, pool, defaultPreset(), winWidth, winHeight, 3)
+	})
+	if code != 0 {
+		t.Fatalf("bench exited with %d:\n%s", code, out.String())
+	}
+	want := regexp.MustCompile(`^3 regenerations of 800x600 with 2 workers\n` +
+		`generation mean \d+\.\d\dms median \d+\.\d\dms p95 \d+\.\d\dms\n$`)
+	if !want.Match(out.Bytes()) {
+		t.Errorf("bench printed\n%s", out.String())
+	}
+
+	// pprof profiles are gzipped protocol buffers
+	for _, path := range []string{profiles.cpu, profiles.mem} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		z, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s is not gzipped: %v", filepath.Base(path), err)
+		}
+		n, err := io.Copy(io.Discard, z)
+		if err != nil || n == 0 {
+			t.Errorf("%s has %d bytes, %v", filepath.Base(path), n, err)
+		}
+	}
+
+	if err := runBench(io.Discard, pool, defaultPreset(), winWidth, winHeight, 0); err == nil {
+		t.Error("bench ran with no runs")
+	}
+}
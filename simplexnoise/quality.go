@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// previews are generated previewDivisor times smaller along each axis
+	previewDivisor = 4
+	// previewIdle is how long after the last change, with no parameter key
+	// held, the full resolution field is started
+	previewIdle = 150 * time.Millisecond
+)
+
+// qualityGovernor decides which resolution to generate at. Changes get a
+// quick preview while the parameters are being adjusted, and once input
+// has been idle for idle a single full resolution field follows.
+type qualityGovernor struct {
+	idle      time.Duration
+	last      time.Time
+	adjusting bool
+}
+
+func newQualityGovernor(idle time.Duration) *qualityGovernor {
+	return &qualityGovernor{idle: idle}
+}
+
+// update is called once a frame with whether a parameter key is held and
+// whether the parameters changed. It reports whether a field should be
+// started, and if so whether it should be a preview.
+func (q *qualityGovernor) update(now time.Time, held, changed bool) (start, preview bool) {
+	if changed || held {
+		q.last = now
+	}
+	if changed {
+		q.adjusting = true
+		return true, true
+	}
+	if q.adjusting && !held && now.Sub(q.last) >= q.idle {
+		q.adjusting = false
+		return true, false
+	}
+	return false, false
+}
+
+// upscaleNearest fills a dw*dh field from a sw*sh one, every destination
+// cell taking the source cell factor times closer to the origin
+func upscaleNearest(dst []float32, dw, dh int, src []float32, sw, sh, factor int) {
+	for y := 0; y < dh; y++ {
+		sy := y / factor
+		if sy >= sh {
+			sy = sh - 1
+		}
+		row := src[sy*sw : (sy+1)*sw]
+		for x := 0; x < dw; x++ {
+			sx := x / factor
+			if sx >= sw {
+				sx = sw - 1
+			}
+			dst[y*dw+x] = row[sx]
+		}
+	}
+}
+
+// previewSize is the size of the field previewed for a w*h one
+func previewSize(w, h int) (int, int) {
+	return (w + previewDivisor - 1) / previewDivisor, (h + previewDivisor - 1) / previewDivisor
+}
+
+// makePreview is makeNoise for a field previewDivisor times smaller,
+// generated into small and scaled back up into buf with nearest neighbour.
+// The view's step is scaled with it so the preview shows the same pattern.
+func makePreview(ctx context.Context, filler fieldFiller, small, buf *fieldBuffer, w, h int, p preset, gradient []color) (timing, error) {
+	var t timing
+	err := checkField(buf, w, h, p, true)
+	if err != nil {
+		return t, err
+	}
+	sw, sh := previewSize(w, h)
+	p.View.Step *= previewDivisor
+	startTime := time.Now()
+	min, max, err := makeField(ctx, filler, small, sw, sh, p)
+	if err != nil {
+		return t, err
+	}
+	t.generate = time.Since(startTime)
+
+	startTime = time.Now()
+	rescale(small.noise[:sw*sh], min, max)
+	upscaleNearest(buf.noise, w, h, small.noise, sw, sh, previewDivisor)
+	t.normalize = time.Since(startTime)
+
+	startTime = time.Now()
+	drawField(buf.noise, gradient, buf.pixels)
+	t.draw = time.Since(startTime)
+	return t, nil
+}
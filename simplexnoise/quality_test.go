@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpscaleNearest(t *testing.T) {
+	// 3x2 scaled by 4 into 10x7, the last column and row of the source
+	// cover less than a full cell
+	src := []float32{
+		1, 2, 3,
+		4, 5, 6,
+	}
+	const dw, dh = 10, 7
+	dst := make([]float32, dw*dh)
+	upscaleNearest(dst, dw, dh, src, 3, 2, 4)
+	want := []float32{
+		1, 1, 1, 1, 2, 2, 2, 2, 3, 3,
+		1, 1, 1, 1, 2, 2, 2, 2, 3, 3,
+		1, 1, 1, 1, 2, 2, 2, 2, 3, 3,
+		1, 1, 1, 1, 2, 2, 2, 2, 3, 3,
+		4, 4, 4, 4, 5, 5, 5, 5, 6, 6,
+		4, 4, 4, 4, 5, 5, 5, 5, 6, 6,
+		4, 4, 4, 4, 5, 5, 5, 5, 6, 6,
+	}
+	for i := range want {
+		if dst[i] != want[i] {
+			t.Fatalf("pixel %d,%d is %v, want %v", i%dw, i/dw, dst[i], want[i])
+		}
+	}
+
+	// a source too small for the factor repeats its last cell
+	dst = make([]float32, 10)
+	upscaleNearest(dst, 10, 1, []float32{7, 8}, 2, 1, 4)
+	for i, v := range []float32{7, 7, 7, 7, 8, 8, 8, 8, 8, 8} {
+		if dst[i] != v {
+			t.Errorf("short source: pixel %d is %v, want %v", i, dst[i], v)
+		}
+	}
+}
+
+// TestQualityGovernor walks the governor from idle through previews to the
+// full field on a fake clock, one step a frame
+func TestQualityGovernor(t *testing.T) {
+	const idle = 150 * time.Millisecond
+	const frame = 16 * time.Millisecond
+	type step struct {
+		at             time.Duration
+		held, changed  bool
+		start, preview bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"idle starts nothing", []step{
+			{0, false, false, false, false},
+			{time.Second, false, false, false, false},
+		}},
+		{"single change", []step{
+			{0, false, true, true, true},
+			{frame, false, false, false, false},
+			{idle - frame, false, false, false, false},
+			{idle, false, false, true, false},
+			{idle + frame, false, false, false, false},
+			{time.Second, false, false, false, false},
+		}},
+		{"held key waits for release", []step{
+			{0, true, true, true, true},
+			{frame, true, false, false, false},
+			{idle * 3, true, false, false, false},
+			{idle*3 + frame, false, false, false, false},
+			{idle*4 - frame, false, false, false, false},
+			{idle*4 + frame, false, false, true, false},
+		}},
+		{"changes in a row", []step{
+			{0, false, true, true, true},
+			{frame, false, true, true, true},
+			{2 * frame, false, true, true, true},
+			{idle + frame, false, false, false, false},
+			{idle + 2*frame, false, false, true, false},
+		}},
+		{"change after the full field", []step{
+			{0, false, true, true, true},
+			{idle, false, false, true, false},
+			{idle + frame, false, true, true, true},
+			{2 * idle, false, false, false, false},
+			{2*idle + frame, false, false, true, false},
+		}},
+		{"held without a change", []step{
+			{0, true, false, false, false},
+			{idle * 2, false, false, false, false},
+		}},
+	}
+	start := time.Unix(1000, 0)
+	for _, tt := range tests {
+		q := newQualityGovernor(idle)
+		for i, s := range tt.steps {
+			gotStart, gotPreview := q.update(start.Add(s.at), s.held, s.changed)
+			if gotStart != s.start || gotPreview != s.preview {
+				t.Errorf("%s: step %d at %v gave start %v preview %v, want %v %v",
+					tt.name, i, s.at, gotStart, gotPreview, s.start, s.preview)
+			}
+		}
+	}
+}
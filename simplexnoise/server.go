@@ -0,0 +1,456 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sabith-th/games_with_go/export"
+)
+
+const (
+	streamInterval = 200 * time.Millisecond
+	streamBoundary = "frame"
+	jpegQuality    = 80
+	maxRenderSize  = 4096
+	maxParamsBody  = 1 << 16
+	// maxRenders is how many /render requests render at once, the rest
+	// wait their turn. Each renders on its own goroutine and at the
+	// largest size holds about 128MB of field and pixels.
+	maxRenders = 2
+)
+
+const indexPage = `<!DOCTYPE html>
+<html><head><title>Simplex Noise</title></head>
+<body style="margin:0;background:#000"><img src="/stream"></body></html>
+`
+
+// previewServer serves the displayed frame as an mjpeg stream, the
+// current preset and the regeneration timings as json, and renders pngs of
+// any preset at /noise. The render loop
+// publishes copies of its state, handlers only ever read those copies
+// under the mutex. Parameter changes and console commands are sent to the
+// render loop over updates and commands rather than applied here, so the
+// preset is only ever mutated on the main goroutine.
+type previewServer struct {
+	mutex  sync.Mutex
+	frame  []byte
+	w, h   int
+	preset preset
+
+	updates  chan paramsRequest
+	commands chan commandRequest
+	filler   fieldFiller
+	log      *statsLog
+	noise    *pngCache
+	renders  chan struct{}
+	started  time.Time
+
+	srv  *http.Server
+	done chan struct{}
+}
+
+func newPreviewServer(addr string, w, h int, filler fieldFiller, log *statsLog) *previewServer {
+	s := &previewServer{
+		frame:    make([]byte, w*h*4),
+		w:        w,
+		h:        h,
+		updates:  make(chan paramsRequest, 8),
+		commands: make(chan commandRequest, 8),
+		filler:   filler,
+		log:      log,
+		noise:    newPNGCache(noiseCacheTTL),
+		renders:  make(chan struct{}, maxRenders),
+		started:  time.Now(),
+		done:     make(chan struct{}),
+	}
+	s.srv = &http.Server{Addr: addr, Handler: s.handler()}
+	return s
+}
+
+func (s *previewServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/stream", s.handleStream)
+	mux.HandleFunc("/params", s.handleParams)
+	mux.HandleFunc("/command", s.handleCommand)
+	mux.HandleFunc("/render", s.handleRender)
+	mux.HandleFunc("/stats", s.handleStats)
+	mux.HandleFunc("/noise", s.handleNoise)
+	mux.HandleFunc("/noise/stream", s.handleNoiseStream)
+	return mux
+}
+
+// publish copies the latest frame and parameters for the handlers
+func (s *previewServer) publish(frame []byte, p preset) {
+	s.mutex.Lock()
+	copy(s.frame, frame)
+	s.preset = p
+	s.mutex.Unlock()
+}
+
+func (s *previewServer) snapshot() []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	frame := make([]byte, len(s.frame))
+	copy(frame, s.frame)
+	return frame
+}
+
+func (s *previewServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, indexPage)
+}
+
+func (s *previewServer) currentPreset() preset {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.preset
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *previewServer) handleParams(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, s.currentPreset())
+	case http.MethodPost:
+		s.postParams(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// postParams hands a partial preset to the render loop, which merges it
+// into the preset it has at that point. The response is the merged preset,
+// or why it was refused if it is invalid.
+func (s *previewServer) postParams(w http.ResponseWriter, r *http.Request) {
+	var u presetUpdate
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxParamsBody))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&u)
+	if err != nil {
+		http.Error(w, "invalid preset: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := paramsRequest{u, make(chan paramsReply, 1)}
+	select {
+	case s.updates <- req:
+	case <-r.Context().Done():
+		return
+	case <-s.done:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	var reply paramsReply
+	select {
+	case reply = <-req.reply:
+	case <-r.Context().Done():
+		return
+	case <-s.done:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if reply.err != nil {
+		http.Error(w, reply.err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, reply.p)
+}
+
+// paramsRequest is a partial preset posted to the server, merged by the
+// render loop like a command is run. reply gets the result, it has room
+// for it so the render loop never waits.
+type paramsRequest struct {
+	update presetUpdate
+	reply  chan paramsReply
+}
+
+type paramsReply struct {
+	p   preset
+	err error
+}
+
+// apply merges the update into p and replies with the result. An invalid
+// result is refused and p is returned as it was.
+func (req paramsRequest) apply(p preset) preset {
+	next := req.update.apply(p)
+	err := next.validate()
+	req.reply <- paramsReply{next, err}
+	if err != nil {
+		return p
+	}
+	return next
+}
+
+// commandRequest is a console command posted to the server, run by the
+// render loop like a parameter update. reply gets the result, it has room
+// for it so the render loop never waits.
+type commandRequest struct {
+	call  commandCall
+	reply chan commandReply
+}
+
+type commandReply struct {
+	out string
+	err error
+}
+
+// handleCommand runs a console command posted as plain text, e.g.
+// "set frequency 0.02", and returns its output. Commands that write files
+// are refused, they are only for the console.
+func (s *previewServer) handleCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	line, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxParamsBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	call, err := parseCommand(string(line))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if call.cmd.local {
+		http.Error(w, call.cmd.name+" only runs from the console", http.StatusForbidden)
+		return
+	}
+
+	req := commandRequest{call, make(chan commandReply, 1)}
+	select {
+	case s.commands <- req:
+	case <-r.Context().Done():
+		return
+	case <-s.done:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	var reply commandReply
+	select {
+	case reply = <-req.reply:
+	case <-r.Context().Done():
+		return
+	case <-s.done:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if reply.err != nil {
+		http.Error(w, reply.err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, reply.out)
+}
+
+// handleStats returns the window's regeneration timings as json
+func (s *previewServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, s.log.stats())
+}
+
+func parseSize(q url.Values, name string, fallback int) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxRenderSize {
+		return 0, fmt.Errorf("%s must be an integer between 1 and %d", name, maxRenderSize)
+	}
+	return n, nil
+}
+
+// handleRender renders the current preset at the requested size on the
+// request goroutine and returns it as a png, the window is not affected.
+// At most maxRenders run at once. A request waiting for its turn or
+// rendering gives up if the client goes away.
+func (s *previewServer) handleRender(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	width, err := parseSize(r.URL.Query(), "w", s.w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	height, err := parseSize(r.URL.Query(), "h", s.h)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case s.renders <- struct{}{}:
+		defer func() { <-s.renders }()
+	case <-r.Context().Done():
+		return
+	case <-s.done:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	p := s.currentPreset()
+	pixels, err := generateSource(r.Context(), fillerSource(s.filler, p, width, height), p, width, height, nil)
+	if r.Context().Err() != nil {
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, export.ToImage(pixels, width, height))
+}
+
+func (s *previewServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+streamBoundary)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+	for {
+		frame := s.snapshot()
+		fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\n\r\n", streamBoundary)
+		err := jpeg.Encode(w, export.ToImage(frame, s.w, s.h), &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return
+		}
+		fmt.Fprint(w, "\r\n")
+		flusher.Flush()
+
+		select {
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// start listens on the address and serves in the background. Errors after
+// it started, other than a normal shutdown, are printed.
+func (s *previewServer) start() error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		err := s.srv.Serve(ln)
+		if err != nil && err != http.ErrServerClosed {
+			fmt.Println(err)
+		}
+	}()
+	return nil
+}
+
+// shutdown ends any open streams and waits briefly for handlers to return
+func (s *previewServer) shutdown() {
+	close(s.done)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	s.srv.Shutdown(ctx)
+}
+
+// serveHeadless runs a server for p without a window until the process is
+// interrupted. Posted parameters are applied here instead of by the render
+// loop, and every change renders a new frame for /stream.
+func serveHeadless(addr string, filler fieldFiller, log *statsLog, p preset) error {
+	s := newPreviewServer(addr, winWidth, winHeight, filler, log)
+	pixels, err := renderPreset(filler, p, winWidth, winHeight, percentPrinter(os.Stderr, "rendering"))
+	if err != nil {
+		return err
+	}
+	s.publish(pixels, p)
+	err = s.start()
+	if err != nil {
+		return err
+	}
+	defer s.shutdown()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	fmt.Println("serving on", addr, "without a window, interrupt to stop")
+	for {
+		var next preset
+		select {
+		case req := <-s.updates:
+			next = req.apply(p)
+		case req := <-s.commands:
+			t := &headlessTarget{p: p}
+			out, err := req.call.run(t)
+			req.reply <- commandReply{out, err}
+			next = t.p
+		case <-interrupt:
+			return nil
+		}
+		if next == p {
+			continue
+		}
+		// a render that fails anyway keeps the last frame
+		p = next
+		pixels, err := renderPreset(filler, p, winWidth, winHeight, percentPrinter(os.Stderr, "rendering"))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		s.publish(pixels, p)
+	}
+}
+
+// headlessTarget runs the server's commands without a window, which only
+// has parameters to change
+type headlessTarget struct {
+	p preset
+}
+
+func (t *headlessTarget) params() preset {
+	return t.p
+}
+
+func (t *headlessTarget) setParams(p preset) {
+	t.p = p
+}
+
+func (t *headlessTarget) usePalette(string) error {
+	return fmt.Errorf("there are no palettes without a window")
+}
+
+func (t *headlessTarget) exportPNG(string) error {
+	return fmt.Errorf("there is nothing to export without a window")
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"image/jpeg"
+	"image/png"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const serverWidth, serverHeight = 32, 24
+
+// get serves a GET of target on s
+func get(s *previewServer, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestServerStream(t *testing.T) {
+	s := newPreviewServer("", serverWidth, serverHeight, nil, nil)
+	s.publish(make([]byte, serverWidth*serverHeight*4), defaultPreset())
+
+	// a request already canceled gets the first frame and nothing more
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx))
+
+	mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/x-mixed-replace" || params["boundary"] != streamBoundary {
+		t.Fatalf("Content-Type %q, %v", rec.Header().Get("Content-Type"), err)
+	}
+	part, err := multipart.NewReader(rec.Body, params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := part.Header.Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("frame has Content-Type %q", ct)
+	}
+	img, err := jpeg.Decode(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != serverWidth || b.Dy() != serverHeight {
+		t.Errorf("frame is %dx%d, want %dx%d", b.Dx(), b.Dy(), serverWidth, serverHeight)
+	}
+}
+
+func TestServerGetParams(t *testing.T) {
+	s := newPreviewServer("", serverWidth, serverHeight, nil, nil)
+	p := defaultPreset()
+	p.Octaves, p.Gain, p.Mode = 6, 0.35, ridgedMode
+	p.View = viewport{X: -12.5, Y: 40, Step: 0.25}
+	s.publish(make([]byte, serverWidth*serverHeight*4), p)
+
+	rec := get(s, "/params")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /params gave %d, %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var got preset
+	err := json.NewDecoder(rec.Body).Decode(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != p {
+		t.Errorf("GET /params gave %+v, want %+v", got, p)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/params", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, POST" {
+		t.Errorf("DELETE /params gave %d, Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestServerPostParams(t *testing.T) {
+	s := newPreviewServer("", serverWidth, serverHeight, nil, nil)
+	s.publish(make([]byte, serverWidth*serverHeight*4), defaultPreset())
+	// the render loop's side, a key press has changed its preset since the
+	// last one it published
+	go func() {
+		loop := defaultPreset()
+		loop.Frequency = 0.02
+		for req := range s.updates {
+			loop = req.apply(loop)
+		}
+	}()
+	defer close(s.updates)
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/params", strings.NewReader(body)))
+		return rec
+	}
+
+	for _, body := range []string{
+		"",
+		"octaves=4",
+		`{"octaves": 4`,
+		`{"octaves": "four"}`,
+		`{"octaves": 4, "colour": "red"}`,
+		`{"octaves": 0}`,
+		`{"lacunarity": -2}`,
+		`{"gain": 0.3, "padding": "` + strings.Repeat("x", maxParamsBody) + `"}`,
+	} {
+		if rec := post(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%.40q gave %d, want 400", body, rec.Code)
+		}
+	}
+	rec := post(`{"octaves": 5, "gain": 0.3}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("valid update gave %d: %s", rec.Code, rec.Body)
+	}
+	// merged into the loop's preset, which the refused updates left alone
+	want := defaultPreset()
+	want.Frequency, want.Octaves, want.Gain = 0.02, 5, 0.3
+	var got preset
+	err := json.NewDecoder(rec.Body).Decode(&got)
+	if err != nil || got != want {
+		t.Errorf("valid update answered %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestServerRender(t *testing.T) {
+	pool := newWorkerPool(2)
+	defer pool.close()
+	s := newPreviewServer("", serverWidth, serverHeight, pool, nil)
+	s.publish(make([]byte, serverWidth*serverHeight*4), defaultPreset())
+
+	for _, query := range []string{"w=0", "w=-3", "w=4097", "h=4097", "w=16&h=abc", "h=1.5"} {
+		if rec := get(s, "/render?"+query); rec.Code != http.StatusBadRequest {
+			t.Errorf("/render?%s gave %d, want 400", query, rec.Code)
+		}
+	}
+
+	for _, tt := range []struct {
+		query string
+		w, h  int
+	}{
+		{"", serverWidth, serverHeight},
+		{"w=16&h=8", 16, 8},
+		{"h=1", serverWidth, 1},
+	} {
+		rec := get(s, "/render?"+tt.query)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("/render?%s gave %d, %q: %s", tt.query, rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+		}
+		img, err := png.Decode(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("/render?%s is %dx%d, want %dx%d", tt.query, b.Dx(), b.Dy(), tt.w, tt.h)
+		}
+	}
+}
+
+// TestServerRenderLimit holds every render slot, a render has to wait for
+// one and a client that leaves while waiting gets nothing
+func TestServerRenderLimit(t *testing.T) {
+	pool := newWorkerPool(2)
+	defer pool.close()
+	s := newPreviewServer("", serverWidth, serverHeight, pool, nil)
+	s.publish(make([]byte, serverWidth*serverHeight*4), defaultPreset())
+	for i := 0; i < maxRenders; i++ {
+		s.renders <- struct{}{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/render", nil).WithContext(ctx))
+	if rec.Body.Len() != 0 {
+		t.Errorf("a canceled request waiting for a slot got %d bytes", rec.Body.Len())
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- get(s, "/render?w=8&h=8") }()
+	select {
+	case <-done:
+		t.Fatal("rendered with every slot taken")
+	case <-time.After(50 * time.Millisecond):
+	}
+	<-s.renders
+	select {
+	case rec := <-done:
+		if rec.Code != http.StatusOK {
+			t.Errorf("render after a slot freed gave %d", rec.Code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("render still waiting after a slot freed")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// session is the window's state, written to -session on quit and restored
+// from it on the next launch unless -fresh is given:
+//
+//	{
+//	  "params": {...},           as savedParams, the preset and palette
+//	  "overlays": {
+//	    "spectrum": false,       X
+//	    "bloom": false,          B
+//	    "chromatic": false,      C
+//	    "chromatic_offset": 4,   + and -, 0 to maxChromaticOffset
+//	    "hud": false,            H
+//	    "editor": false          E
+//	  }
+//	}
+//
+// The noise has no seed, the preset is all a field depends on. Like a
+// saved preset the palette is kept by name, without the editor's changes.
+type session struct {
+	Params   savedParams `json:"params"`
+	Overlays overlays    `json:"overlays"`
+}
+
+// overlays are the views and effects toggled over the field
+type overlays struct {
+	Spectrum        bool `json:"spectrum"`
+	Bloom           bool `json:"bloom"`
+	Chromatic       bool `json:"chromatic"`
+	ChromaticOffset int  `json:"chromatic_offset"`
+	HUD             bool `json:"hud"`
+	Editor          bool `json:"editor"`
+}
+
+func defaultOverlays() overlays {
+	return overlays{ChromaticOffset: 4}
+}
+
+func (g *noiseGame) session() session {
+	return session{
+		Params: savedParams{g.p, palettePresets[g.paletteIndex].name},
+		Overlays: overlays{
+			Spectrum:        g.spectrum,
+			Bloom:           g.bloom,
+			Chromatic:       g.chromatic,
+			ChromaticOffset: g.chromaticOffset,
+			HUD:             g.showHUD,
+			Editor:          g.showEditor,
+		},
+	}
+}
+
+// defaultSessionPath is where the session is kept, in the user's config
+// directory if there is one
+func defaultSessionPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gameswithgo", "simplexnoise-session.json")
+}
+
+// saveSession writes s to path, quitting part way through never leaves
+// half a session
+func saveSession(path string, s session) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// writeFileAtomic writes data to a temporary file beside path and moves it
+// into place, making path's directory if need be
+func writeFileAtomic(path string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// loadSession reads a session written by saveSession and returns it with
+// the index of its palette. Fields missing from the file keep p and the
+// default overlays. A file that is not a whole valid session is an error,
+// nothing of it is used.
+func loadSession(path string, p preset) (session, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return session{}, 0, err
+	}
+	s := session{Params: savedParams{p, palettePresets[0].name}, Overlays: defaultOverlays()}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&s)
+	if err != nil {
+		return session{}, 0, fmt.Errorf("%s: %v", path, err)
+	}
+	np, palette, err := s.Params.resolve()
+	if err != nil {
+		return session{}, 0, fmt.Errorf("%s: %v", path, err)
+	}
+	s.Params.preset = np
+	if off := s.Overlays.ChromaticOffset; off < 0 || off > maxChromaticOffset {
+		return session{}, 0, fmt.Errorf("%s: chromatic offset must be between 0 and %d, got %d", path, maxChromaticOffset, off)
+	}
+	return s, palette, nil
+}
+
+// restoreSession loads the session at path over p. The flags set on the
+// command line or by -config point into p, they are set again afterwards
+// so they still win over the session.
+func restoreSession(path string, fs *flag.FlagSet, p *preset) (session, int, error) {
+	s, palette, err := loadSession(path, *p)
+	if err != nil {
+		return session{}, 0, err
+	}
+	var set []*flag.Flag
+	fs.Visit(func(f *flag.Flag) {
+		set = append(set, f)
+	})
+	values := make([]string, len(set))
+	for i, f := range set {
+		values[i] = f.Value.String()
+	}
+	*p = s.Params.preset
+	for i, f := range set {
+		err = fs.Set(f.Name, values[i])
+		if err != nil {
+			return session{}, 0, err
+		}
+	}
+	s.Params.preset = *p
+	return s, palette, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// changedSession differs from the defaults in every field it persists
+func changedSession() session {
+	p := preset{
+		Fractal: noise.Fractal{Frequency: 0.02, Lacunarity: 2.5, Gain: 0.4, Octaves: 6},
+		View:    viewport{X: -120.5, Y: 33.25, Step: 0.5},
+		Mode:    ridgedMode,
+		Basis:   basis("perlin"),
+	}
+	return session{
+		Params: savedParams{p, palettePresets[len(palettePresets)-1].name},
+		Overlays: overlays{
+			Spectrum:        true,
+			Bloom:           true,
+			Chromatic:       true,
+			ChromaticOffset: maxChromaticOffset,
+			HUD:             true,
+			Editor:          true,
+		},
+	}
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	want := changedSession()
+	if reflect.DeepEqual(want.Params.preset, defaultPreset()) || want.Overlays == defaultOverlays() {
+		t.Fatal("the test session has to differ from the defaults")
+	}
+	path := filepath.Join(t.TempDir(), "dir", "session.json")
+	err := saveSession(path, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, palette, err := loadSession(path, defaultPreset())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if palette != len(palettePresets)-1 {
+		t.Errorf("palette index %d, want %d", palette, len(palettePresets)-1)
+	}
+
+	// saving again replaces the file and leaves no temporary files behind
+	err = saveSession(path, session{Params: savedParams{defaultPreset(), palettePresets[0].name}, Overlays: defaultOverlays()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("session directory has %d files, want 1", len(entries))
+	}
+}
+
+func TestSessionMissingFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	err := os.WriteFile(path, []byte(`{"params": {"octaves": 7}, "overlays": {"bloom": true}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := loadSession(path, defaultPreset())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPreset := defaultPreset()
+	wantPreset.Octaves = 7
+	wantOverlays := defaultOverlays()
+	wantOverlays.Bloom = true
+	if !reflect.DeepEqual(got.Params.preset, wantPreset) || got.Overlays != wantOverlays {
+		t.Errorf("got %+v, want %+v and %+v", got, wantPreset, wantOverlays)
+	}
+}
+
+func TestSessionCorrupt(t *testing.T) {
+	tests := []struct {
+		name, contents string
+	}{
+		{"empty", ""},
+		{"truncated", `{"params": {"frequency": 0.`},
+		{"not json", "frequency=0.01"},
+		{"unknown field", `{"seed": 1}`},
+		{"invalid preset", `{"params": {"octaves": 0}}`},
+		{"unknown palette", `{"params": {"palette": "plaid"}}`},
+		{"unknown mode", `{"params": {"mode": "sideways"}}`},
+		{"chromatic offset", `{"overlays": {"chromatic_offset": 99}}`},
+		{"wrong type", `{"overlays": {"hud": "yes"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "session.json")
+			err := os.WriteFile(path, []byte(tt.contents), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _, err = loadSession(path, defaultPreset())
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("error %q does not name the file", err)
+			}
+			// a corrupt session leaves the flags' preset alone
+			p := defaultPreset()
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			_, _, err = restoreSession(path, fs, &p)
+			if err == nil || !reflect.DeepEqual(p, defaultPreset()) {
+				t.Errorf("restoring gave %v and changed the preset to %+v", err, p)
+			}
+		})
+	}
+}
+
+func TestSessionMissingFile(t *testing.T) {
+	_, _, err := loadSession(filepath.Join(t.TempDir(), "none.json"), defaultPreset())
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v, want a missing file", err)
+	}
+}
+
+// TestRestoreSessionFlagsWin checks flags given on the command line keep
+// their values over the restored session and every other field comes
+// from the session
+func TestRestoreSessionFlagsWin(t *testing.T) {
+	saved := changedSession()
+	path := filepath.Join(t.TempDir(), "session.json")
+	err := saveSession(path, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := defaultPreset()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.IntVar(&p.Octaves, "octaves", p.Octaves, "")
+	fs.Float64Var(&p.View.Step, "step", p.View.Step, "")
+	err = fs.Parse([]string{"-octaves", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := restoreSession(path, fs, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := saved.Params.preset
+	want.Octaves = 2
+	if !reflect.DeepEqual(p, want) || !reflect.DeepEqual(got.Params.preset, want) {
+		t.Errorf("restored %+v and returned %+v, want %+v", p, got.Params.preset, want)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+// phase is when a shutdown step runs
+type phase int
+
+const (
+	// cancelPhase tells everything in flight to give up, without waiting
+	cancelPhase phase = iota
+	// drainPhase waits for what was canceled to finish, after it nothing
+	// generates or writes anything any more
+	drainPhase
+	// flushPhase writes out whatever was left pending, the summary and
+	// the session
+	flushPhase
+	// destroyPhase tears down the window last, so it is there for as long
+	// as anything could still draw into it
+	destroyPhase
+	numPhases
+)
+
+// shutdown closes whatever was started, in phases: cancel, drain, flush,
+// destroy. Steps are added as things are started and within a phase run
+// in the reverse of the order they were added, like defers, so something
+// is stopped before what it was started on top of.
+type shutdown struct {
+	steps [numPhases][]func()
+}
+
+func (s *shutdown) add(ph phase, step func()) {
+	s.steps[ph] = append(s.steps[ph], step)
+}
+
+// run runs every step once, running it again does nothing
+func (s *shutdown) run() {
+	for ph := range s.steps {
+		steps := s.steps[ph]
+		s.steps[ph] = nil
+		for i := len(steps) - 1; i >= 0; i-- {
+			steps[i]()
+		}
+	}
+}
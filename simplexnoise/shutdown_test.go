@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownOrder(t *testing.T) {
+	var s shutdown
+	var ran []string
+	step := func(name string) func() {
+		return func() { ran = append(ran, name) }
+	}
+	// added in the order an app starts things, not the order they stop
+	s.add(drainPhase, step("pool"))
+	s.add(destroyPhase, step("window"))
+	s.add(flushPhase, step("log"))
+	s.add(drainPhase, step("server"))
+	s.add(cancelPhase, step("generator"))
+	s.add(drainPhase, step("generator done"))
+	s.add(cancelPhase, step("watcher"))
+	s.add(flushPhase, step("session"))
+	s.run()
+	want := []string{"watcher", "generator", "generator done", "server", "pool", "session", "log", "window"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+	ran = nil
+	s.run()
+	if ran != nil {
+		t.Errorf("running again ran %v", ran)
+	}
+}
+
+// slowFiller stands in for a long generation: it holds on to the field
+// until it is canceled and then takes a while longer to notice
+type slowFiller struct {
+	started  chan struct{}
+	finished atomic.Bool
+}
+
+func (f *slowFiller) fill(ctx context.Context, buf *fieldBuffer, w, h int, p preset) (min, max float32, err error) {
+	f.started <- struct{}{}
+	<-ctx.Done()
+	time.Sleep(20 * time.Millisecond)
+	f.finished.Store(true)
+	return 0, 0, ctx.Err()
+}
+
+// quickFiller finishes every field straight away
+type quickFiller struct{}
+
+func (quickFiller) fill(ctx context.Context, buf *fieldBuffer, w, h int, p preset) (min, max float32, err error) {
+	return 0, 1, nil
+}
+
+// within fails t if f does not return within a second
+func within(t *testing.T, what string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+func TestShutdownDrainsGeneration(t *testing.T) {
+	const w, h = 8, 8
+	filler := &slowFiller{started: make(chan struct{}, 1)}
+	gen := newGenerator(filler, w, h, newStatsLog(io.Discard, 1, false))
+	gen.start(defaultPreset(), defaultGradient, 0, false)
+	<-filler.started
+
+	var s shutdown
+	var ran []string
+	s.add(destroyPhase, func() {
+		ran = append(ran, "destroy")
+	})
+	s.add(flushPhase, func() {
+		// nothing may still be generating once the flush starts
+		if !filler.finished.Load() {
+			t.Error("flushed while a field was still being made")
+		}
+		ran = append(ran, "flush")
+	})
+	s.add(cancelPhase, gen.stop)
+	s.add(drainPhase, gen.wait)
+	within(t, "shutdown", s.run)
+	if want := []string{"flush", "destroy"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+	if _, ok := gen.poll(); ok {
+		t.Error("a canceled field was handed back")
+	}
+}
+
+func TestGeneratorWaitUnpolled(t *testing.T) {
+	// the second field finishes with the first one's result still waiting
+	// to be polled, it must not hold up the shutdown
+	const w, h = 8, 8
+	gen := newGenerator(quickFiller{}, w, h, newStatsLog(io.Discard, 1, false))
+	gen.start(defaultPreset(), defaultGradient, 0, false)
+	gen.start(defaultPreset(), defaultGradient, 0, false)
+	time.Sleep(10 * time.Millisecond)
+	gen.stop()
+	within(t, "wait", gen.wait)
+}
+
+// TestFillClosedPool fills on a pool that is already closed, the field
+// cannot be complete so the fill must fail
+func TestFillClosedPool(t *testing.T) {
+	const w, h = 8, 3 * rowsPerJob
+	pool := newWorkerPool(2)
+	pool.close()
+	_, _, err := pool.fill(context.Background(), newFieldBuffer(w, h), w, h, defaultPreset())
+	if err != errPoolClosed {
+		t.Errorf("fill on a closed pool returned %v, want %v", err, errPoolClosed)
+	}
+}
+
+func TestPoolCloseTwice(t *testing.T) {
+	pool := newWorkerPool(2)
+	pool.close()
+	within(t, "second close", pool.close)
+}
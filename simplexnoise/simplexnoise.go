@@ -1,17 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"math"
+	"os"
 	"runtime"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/veandco/go-sdl2/sdl"
+	"github.com/sabith-th/games_with_go/config"
+	"github.com/sabith-th/games_with_go/export"
+	"github.com/sabith-th/games_with_go/expr"
+	"github.com/sabith-th/games_with_go/fft"
+	"github.com/sabith-th/games_with_go/noise"
 )
 
 const winWidth, winHeight int = 800, 600
 
+const maxChromaticOffset = 8
+
+const maxOctaves = 16
+
+// spectrumSize is the side of the centre crop shown in the spectrum view,
+// it has to be a power of two that fits inside the window
+const spectrumSize = 512
+
 type color struct {
 	r, g, b byte
 }
@@ -55,303 +72,522 @@ func clamp(min, max, v int) int {
 	return v
 }
 
-func rescaleAndDraw(noise []float32, min, max float32, gradient []color, pixels []byte) {
+// flatLevel is what rescale maps a field without a usable range to, the
+// middle of the palette
+const flatLevel = 127.5
+
+// rescale maps a field with the given range onto 0-255 in place. A flat
+// field, min == max, has no scale to stretch it by, nor has one whose range
+// is not finite, so those become flatLevel throughout.
+func rescale(noise []float32, min, max float32) {
 	scale := 255.0 / (max - min)
+	if !(max > min) || math.IsInf(float64(max-min), 0) || math.IsInf(float64(scale), 0) {
+		for i := range noise {
+			noise[i] = flatLevel
+		}
+		return
+	}
 	offset := min * scale
 
 	for i := range noise {
 		noise[i] = noise[i]*scale - offset
-		c := gradient[clamp(0, 255, int(noise[i]))]
-		p := i * 4
-		pixels[p] = c.r
-		pixels[p+1] = c.g
-		pixels[p+2] = c.b
 	}
 }
 
-func turbulence(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
-	var sum float32
-	amplitude := float32(1.0)
-	for i := 0; i < octaves; i++ {
-		f := snoise2(x*frequency, y*frequency) * amplitude
-		if f < 0 {
-			f = -1.0 * f
-		}
-		sum += f
-		frequency *= lacunarity
-		amplitude *= gain
+// drawField colors a field that is already scaled to 0-255, a whole pixel
+// at a time
+func drawField(field []float32, gradient []color, pixels []byte) {
+	packed := packGradient(gradient, nativeBigEndian)
+	words := pixelWords(pixels)
+	for i, v := range field {
+		words[i] = packed[gradientIndex(v)]
 	}
-	return sum
 }
 
-func fbm2(x, y, frequency, lacunarity, gain float32, octaves int) float32 {
-	sum := float32(0.0)
-	amplitude := float32(1.0)
-	for i := 0; i < octaves; i++ {
-		sum += snoise2(x*frequency, y*frequency) * amplitude
-		frequency *= lacunarity
-		amplitude *= gain
+// gradientIndex clamps v to 0-255 before converting it, a float outside the
+// range of int converts to a different value on every platform. NaN, which
+// fails every comparison, ends up at 0.
+func gradientIndex(v float32) int {
+	if !(v >= 0) {
+		return 0
 	}
-	return sum
+	if v >= 255 {
+		return 255
+	}
+	return int(v)
 }
 
-func makeNoise(pixels []byte, frequency, lacunarity, gain float32, octaves int) {
-	var mutex = &sync.Mutex{}
-	startTime := time.Now()
-	noise := make([]float32, winWidth*winHeight)
-
-	min := float32(math.MaxFloat32)
-	max := float32(-math.MaxFloat32)
-
-	numRoutines := runtime.NumCPU()
-	var wg sync.WaitGroup
-	wg.Add(numRoutines)
-	batchSize := len(noise) / numRoutines
-
-	for i := 0; i < numRoutines; i++ {
-		go func(i int) {
-			defer wg.Done()
-			innerMin := float32(math.MaxFloat32)
-			innerMax := float32(-math.MaxFloat32)
-			start := i * batchSize
-			end := start + batchSize - 1
-			for j := start; j < end; j++ {
-				x := j % winWidth
-				y := (j - x) / winHeight
-				noise[j] = turbulence(float32(x), float32(y), frequency, lacunarity, gain, octaves)
-
-				if noise[j] < innerMin {
-					innerMin = noise[j]
-				} else if noise[j] > innerMax {
-					innerMax = noise[j]
-				}
-			}
+// preset is the full set of parameters that determine the generated field
+type preset struct {
+	noise.Fractal
+	// View is where in the world the field is taken from
+	View viewport `json:"view"`
+	// Mode is how the octaves of Basis are summed
+	Mode  noiseMode `json:"mode"`
+	Basis basis     `json:"basis"`
+}
 
-			mutex.Lock()
-			if innerMin < min {
-				min = innerMin
-			}
-			if innerMax > max {
-				max = innerMax
-			}
-			mutex.Unlock()
-		}(i)
+// float32Value is a flag holding a float32, printed as the shortest text
+// that reads back as the same float32 instead of as its float64 expansion.
+// NaN and the infinities are rejected, no parameter can be either.
+type float32Value float32
+
+func (f *float32Value) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return err
 	}
-	wg.Wait()
-	elapsedTime := time.Since(startTime).Seconds() * 1000.0
-	fmt.Println(elapsedTime)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("%q is not a finite number", s)
+	}
+	*f = float32Value(v)
+	return nil
+}
 
-	gradient := getDualGradient(color{0, 0, 175}, color{80, 160, 244}, color{12, 192, 75}, color{255, 255, 255})
-	rescaleAndDraw(noise, min, max, gradient, pixels)
+func (f *float32Value) String() string {
+	return strconv.FormatFloat(float64(*f), 'g', -1, 32)
 }
 
-func setPixel(x, y int, c color, pixels []byte) {
-	index := (y*winWidth + x) * 4
-	if index < len(pixels)-4 && index >= 0 {
-		pixels[index] = c.r
-		pixels[index+1] = c.g
-		pixels[index+2] = c.b
+// Get gives the value as the float64 its text reads as, so 0.2 is saved as
+// 0.2 and not 0.20000000298023224
+func (f *float32Value) Get() interface{} {
+	v, _ := strconv.ParseFloat(f.String(), 64)
+	return v
+}
+
+func defaultPreset() preset {
+	return preset{Fractal: noise.Fractal{Frequency: 0.01, Lacunarity: 3.0, Gain: 0.2, Octaves: 3}, View: defaultViewport(), Basis: simplexBasis}
+}
+
+// validate rejects parameters that would render an empty or degenerate field
+func (p preset) validate() error {
+	err := p.Fractal.Validate()
+	if err != nil {
+		return err
+	}
+	if p.Octaves > maxOctaves {
+		return fmt.Errorf("octaves must be between 1 and %d, got %d", maxOctaves, p.Octaves)
+	}
+	if !(p.View.Step > 0) || math.IsInf(p.View.Step, 0) {
+		return fmt.Errorf("view step must be positive, got %v", p.View.Step)
+	}
+	if p.Mode < 0 || p.Mode >= numNoiseModes {
+		return fmt.Errorf("unknown mode %v", p.Mode)
 	}
+	if !noise.Registered(string(p.Basis)) {
+		return fmt.Errorf("unknown basis %q, expected one of %v", p.Basis, noise.Names())
+	}
+	return nil
 }
 
-func main() {
+// presetUpdate is a partial preset, nil fields keep their current value
+type presetUpdate struct {
+	Frequency  *float32 `json:"frequency"`
+	Lacunarity *float32 `json:"lacunarity"`
+	Gain       *float32 `json:"gain"`
+	Octaves    *int     `json:"octaves"`
+}
 
-	err := sdl.Init(sdl.INIT_EVERYTHING)
+func (u presetUpdate) apply(p preset) preset {
+	if u.Frequency != nil {
+		p.Frequency = *u.Frequency
+	}
+	if u.Lacunarity != nil {
+		p.Lacunarity = *u.Lacunarity
+	}
+	if u.Gain != nil {
+		p.Gain = *u.Gain
+	}
+	if u.Octaves != nil {
+		p.Octaves = *u.Octaves
+	}
+	return p
+}
+
+// makeField evaluates the noise for every pixel of a w*h field into
+// buf.noise with filler and returns its range, or ctx's error if it was
+// canceled. A buffer too small for the field or an invalid p is an error
+// before anything is written.
+func makeField(ctx context.Context, filler fieldFiller, buf *fieldBuffer, w, h int, p preset) (min, max float32, err error) {
+	err = checkField(buf, w, h, p, false)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return 0, 0, err
 	}
-	defer sdl.Quit()
+	return filler.fill(ctx, buf, w, h, p)
+}
 
-	window, err := sdl.CreateWindow("Simplex Noise", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
-		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+// defaultGradient is the palette used by the headless renders, it never changes
+var defaultGradient = getDualGradient(color{0, 0, 175}, color{80, 160, 244}, color{12, 192, 75}, color{255, 255, 255})
+
+// makeNoise generates the field for p into buf, rescaling buf.noise to 0-255
+// and drawing it into buf.pixels, and returns how long each step took. On
+// error buf.pixels is left untouched.
+func makeNoise(ctx context.Context, filler fieldFiller, buf *fieldBuffer, w, h int, p preset, gradient []color) (timing, error) {
+	var t timing
+	err := checkField(buf, w, h, p, true)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return t, err
+	}
+	startTime := time.Now()
+	min, max, err := makeField(ctx, filler, buf, w, h, p)
+	if err != nil {
+		return t, err
 	}
-	defer window.Destroy()
+	t.generate = time.Since(startTime)
 
-	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	startTime = time.Now()
+	rescale(buf.noise, min, max)
+	t.normalize = time.Since(startTime)
+
+	startTime = time.Now()
+	drawField(buf.noise, gradient, buf.pixels)
+	t.draw = time.Since(startTime)
+	return t, nil
+}
+
+func drawSpectrum(field []float32, w, h int, pixels []byte) {
+	crop := make([]float32, spectrumSize*spectrumSize)
+	cropX, cropY := (w-spectrumSize)/2, (h-spectrumSize)/2
+	for y := 0; y < spectrumSize; y++ {
+		copy(crop[y*spectrumSize:(y+1)*spectrumSize], field[(cropY+y)*w+cropX:])
+	}
+	magnitude, err := fft.Magnitude2D(crop, spectrumSize, spectrumSize)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	defer renderer.Destroy()
 
-	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
-		int32(winWidth), int32(winHeight))
+	maxLog := 0.0
+	for i, m := range magnitude {
+		magnitude[i] = math.Log1p(m)
+		if magnitude[i] > maxLog {
+			maxLog = magnitude[i]
+		}
+	}
+
+	for i := range pixels {
+		pixels[i] = 0
+	}
+	for y := 0; y < spectrumSize; y++ {
+		for x := 0; x < spectrumSize; x++ {
+			v := byte(magnitude[y*spectrumSize+x] / maxLog * 255)
+			setPixel(cropX+x, cropY+y, color{v, v, v}, pixels)
+		}
+	}
+}
+
+// saveScreenshot writes the currently displayed frame to a timestamped png
+// and returns its name
+func saveScreenshot(frame []byte) (string, error) {
+	filename := fmt.Sprintf("screenshot_%d.png", time.Now().Unix())
+	return filename, export.WritePNG(filename, frame, winWidth, winHeight)
+}
+
+// exportGoSource writes the normalized field for p as a Go source file,
+// telling progress how far the field is
+func exportGoSource(filler fieldFiller, path, pkg string, p preset, w, h, downsample int, quantize bool, progress func(done, total int)) error {
+	buf := newFieldBuffer(w, h)
+	buf.progress = progress
+	min, max, err := makeField(context.Background(), filler, buf, w, h, p)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
-	defer tex.Destroy()
+	// rescale leaves a flat field at flatLevel, half way, instead of
+	// dividing by its zero range
+	noise := buf.noise
+	rescale(noise, min, max)
+	for i := range noise {
+		noise[i] /= 255
+	}
+	comment := fmt.Sprintf("Code generated by simplexnoise; DO NOT EDIT.\n\n"+
+		"frequency=%v lacunarity=%v gain=%v octaves=%d", p.Frequency, p.Lacunarity, p.Gain, p.Octaves)
+	return export.WriteGoSource(path, noise, w, h, export.GoSourceOptions{
+		Package:    pkg,
+		Name:       "Field",
+		Comment:    comment,
+		Quantize:   quantize,
+		Downsample: downsample,
+	})
+}
 
-	pixels := make([]byte, winWidth*winHeight*4)
-	frequency := float32(0.01)
-	gain := float32(0.2)
-	lacunarity := float32(3.0)
-	octaves := 3
+// renderPreset generates a w*h field headlessly and returns its pixels,
+// telling progress how far the field is
+func renderPreset(filler fieldFiller, p preset, w, h int, progress func(done, total int)) ([]byte, error) {
+	buf := newFieldBuffer(w, h)
+	buf.progress = progress
+	_, err := makeNoise(context.Background(), filler, buf, w, h, p, defaultGradient)
+	if err != nil {
+		return nil, err
+	}
+	return buf.pixels, nil
+}
 
-	makeNoise(pixels, frequency, lacunarity, gain, octaves)
-	keyState := sdl.GetKeyboardState()
+// setPixel sets x, y of a buffer winWidth pixels wide, pixels off it are
+// ignored rather than wrapping onto the next or previous row
+func setPixel(x, y int, c color, pixels []byte) {
+	if x < 0 || x >= winWidth || y < 0 || y >= len(pixels)/(winWidth*4) {
+		return
+	}
+	pixelWords(pixels)[y*winWidth+x] = packColor(c, nativeBigEndian)
+}
 
-	for {
-		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch event.(type) {
-			case *sdl.QuitEvent:
-				return
-			}
-		}
+func main() {
+	os.Exit(run())
+}
 
-		mult := 1
-		if keyState[sdl.SCANCODE_LSHIFT] != 0 || keyState[sdl.SCANCODE_RSHIFT] != 0 {
-			mult = -1
+// run is the program, returning its exit code: 2 for bad flags and 1 for
+// anything that fails later. It returns instead of exiting so the deferred
+// cleanup runs first.
+func run() int {
+	tilesDir := flag.String("tiles-out", "", "render a tileset into this directory instead of opening a window")
+	tileSize := flag.Int("tile-size", 64, "width and height of each exported tile in pixels")
+	tilesX := flag.Int("tiles-x", 8, "number of tile columns to export")
+	tilesY := flag.Int("tiles-y", 8, "number of tile rows to export")
+	tilesPeriodic := flag.Bool("tiles-periodic", false, "make the tileset wrap, so the tiles on opposite outer edges match up")
+	goSrc := flag.String("gosrc", "", "write the field as a Go source file instead of opening a window")
+	goSrcPkg := flag.String("gosrc-pkg", "field", "package name of the generated Go source")
+	goSrcQuantize := flag.Bool("gosrc-quantize", false, "store the generated field as uint8 instead of float32")
+	goSrcDownsample := flag.Int("gosrc-downsample", 4, "keep every nth sample of the generated field")
+	serveAddr := flag.String("serve", "", "serve an mjpeg preview, the current parameters and pngs of any parameters at /noise on this address, e.g. :8080")
+	serveOnly := flag.Bool("serve-only", false, "with -serve, only serve and do not open a window")
+	repeatDelay := flag.Duration("key-repeat-delay", 400*time.Millisecond, "how long a parameter key is held before it repeats, 0 disables repeating")
+	repeatInterval := flag.Duration("key-repeat-interval", 100*time.Millisecond, "time between repeats of a held parameter key")
+	verbose := flag.Bool("verbose", false, "print the timings of every regeneration instead of only a summary on exit")
+	fpsCap := flag.Int("fps-cap", 0, "limit the window to this many frames per second, 0 leaves it to vsync or 60 without it")
+	amortize := flag.Duration("amortize", 0, "generate fields on the main goroutine over several frames, spending about this long on each, instead of in the background")
+	benchRuns := flag.Int("bench", 0, "regenerate the field this many times without a window and report the generation times")
+	scaling := flag.Bool("bench-scaling", false, "run -bench with 1, 2, 4 and 8 workers and compare them")
+	workerFlag := flag.Int("workers", runtime.NumCPU(), "number of goroutines generating fields, at most one per row")
+	var profiles profileOptions
+	flag.StringVar(&profiles.cpu, "cpuprofile", "", "write a cpu profile of -bench, -gosrc, -tiles-out or -replay to this file")
+	flag.StringVar(&profiles.mem, "memprofile", "", "write a heap profile taken after -bench, -gosrc, -tiles-out or -replay to this file")
+	flag.StringVar(&profiles.trace, "trace", "", "write an execution trace of -bench, -gosrc, -tiles-out or -replay to this file")
+	p := defaultPreset()
+	flag.Var((*float32Value)(&p.Frequency), "frequency", "frequency of the first octave")
+	flag.Var((*float32Value)(&p.Lacunarity), "lacunarity", "frequency multiplier between octaves")
+	flag.Var((*float32Value)(&p.Gain), "gain", "amplitude multiplier between octaves")
+	flag.IntVar(&p.Octaves, "octaves", p.Octaves, "number of octaves summed")
+	flag.Float64Var(&p.View.X, "x", p.View.X, "world x of the left edge")
+	flag.Float64Var(&p.View.Y, "y", p.View.Y, "world y of the top edge")
+	flag.Float64Var(&p.View.Step, "step", p.View.Step, "world units per pixel, smaller zooms in")
+	flag.Var(&p.Mode, "mode", "fractal the octaves are summed with: turbulence, fbm or ridged")
+	flag.Var(&p.Basis, "basis", "noise every octave samples, one of "+strings.Join(noise.Names(), ", "))
+	formulaSrc := flag.String("expr", "", "color the field by this formula of x and y instead of turbulence, "+
+		"e.g. \"turbulence(x, y, 0.01, 3, 0.2, 3) * (1 + sin(x*0.05))\", -bench ignores it")
+	configFile := flag.String("config", "", "read flags not given on the command line from this JSON file")
+	saveConfig := flag.String("save-config", "", "write the flags, after -config, to this JSON file")
+	paramsFile := flag.String("params", "", "start from the parameters in this file saved with Ctrl+S, the window reloads it whenever it changes")
+	sessionFile := flag.String("session", defaultSessionPath(), "save the window's parameters, palette and overlays to this file on quit and restore them from it on launch")
+	slotsFile := flag.String("presets", defaultSlotsPath(), "keep the presets saved with Shift+1 to Shift+9 in this file")
+	keymapFile := flag.String("keymap", "", "move the window's key bindings as this JSON file of action names and key names says")
+	printMap := flag.Bool("print-keymap", false, "print the key bindings, after -keymap, as a keymap file and exit")
+	sw := &sweep{params: sweepSet{sweepFrequency: true, sweepDrift: true}}
+	flag.Var(&sw.params, "sweep", "parameters the sweep on A animates, any of "+strings.Join(sweepParamNames[:], ", ")+" separated by commas")
+	flag.Float64Var(&sw.speed, "sweep-speed", 1, "how many times faster than normal the sweep animates")
+	attract := flag.Bool("attract", false, "start the window with the sweep on, e.g. as a screensaver")
+	sweepStart := flag.Float64("sweep-start", -1, "start the sweep at this many seconds in and move it on a fixed 1/60 s per field shown, so recordings come out the same every time")
+	fresh := flag.Bool("fresh", false, "start the window from the flags instead of restoring the last session")
+	replayFile := flag.String("replay", "", "play this macro, recorded with R, without a window and write its last frame to -replay-out")
+	replayOut := flag.String("replay-out", "replay.png", "png file -replay writes")
+	macroFile := flag.String("macro", "", "macro Ctrl+R plays until one is recorded with R")
+	macroSpeed := flag.Float64("macro-speed", 1, "how many times faster than it was recorded Ctrl+R plays a macro")
+	flag.Parse()
+	if *configFile != "" {
+		err := config.LoadConfig(flag.CommandLine, *configFile)
+		if err != nil {
+			fmt.Println(err)
+			return 2
 		}
-		if keyState[sdl.SCANCODE_O] != 0 {
-			octaves = octaves + 1*mult
-			makeNoise(pixels, frequency, lacunarity, gain, octaves)
+	}
+	if *saveConfig != "" {
+		err := config.Write(flag.CommandLine, *saveConfig, "config", "save-config")
+		if err != nil {
+			fmt.Println(err)
+			return 1
 		}
-		if keyState[sdl.SCANCODE_F] != 0 {
-			frequency = frequency + 0.001*float32(mult)
-			makeNoise(pixels, frequency, lacunarity, gain, octaves)
+		fmt.Println("saved flags to", *saveConfig)
+	}
+	// a keymap that is partly wrong still moves the keys it can
+	bindings := defaultBindings
+	if *keymapFile != "" {
+		var err error
+		bindings, err = loadKeymap(*keymapFile, defaultBindings)
+		if err != nil {
+			fmt.Println(err)
 		}
-		if keyState[sdl.SCANCODE_G] != 0 {
-			gain = gain + 0.1*float32(mult)
-			makeNoise(pixels, frequency, lacunarity, gain, octaves)
+	}
+	if *printMap {
+		err := printKeymap(os.Stdout, bindings)
+		if err != nil {
+			fmt.Println(err)
+			return 1
 		}
-		if keyState[sdl.SCANCODE_L] != 0 {
-			lacunarity = lacunarity + 0.001*float32(mult)
-			makeNoise(pixels, frequency, lacunarity, gain, octaves)
+		return 0
+	}
+	// the last session only applies to the window, the headless modes
+	// always start from the flags. Flags that were given still win over it.
+	windowed := !*scaling && *benchRuns == 0 && *goSrc == "" && *tilesDir == "" && !*serveOnly && *replayFile == ""
+	startPalette, startOverlays := 0, defaultOverlays()
+	if windowed && !*fresh {
+		s, palette, err := restoreSession(*sessionFile, flag.CommandLine, &p)
+		switch {
+		case err == nil:
+			startPalette, startOverlays = palette, s.Overlays
+		case !errors.Is(err, os.ErrNotExist):
+			fmt.Println("ignoring the last session:", err)
+		}
+	}
+	err := p.validate()
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	if !(*macroSpeed > 0) || math.IsInf(*macroSpeed, 0) {
+		fmt.Println("-macro-speed must be positive, got", *macroSpeed)
+		return 2
+	}
+	if *paramsFile != "" {
+		p, startPalette, err = loadParams(*paramsFile, p, startPalette)
+		if err != nil {
+			fmt.Println(err)
+			return 2
 		}
+	}
 
-		tex.Update(nil, pixels, winWidth*4)
-		renderer.Copy(tex, nil, nil)
-		renderer.Present()
-		sdl.Delay(16)
+	var formula expr.Node
+	if *formulaSrc != "" {
+		formula, err = expr.Parse(*formulaSrc)
+		if err != nil {
+			fmt.Println(err)
+			return 2
+		}
 	}
-}
 
-func fastFloor(x float32) int {
-	if float32(int(x)) <= x {
-		return int(x)
+	workers, err := workerCount(*workerFlag, winHeight)
+	if err != nil {
+		fmt.Println(err)
+		return 2
 	}
-	return int(x) - 1
-}
 
-// Static data
-
-/*
- * Permutation table. This is just a random jumble of all numbers 0-255
- * This needs to be exactly the same for all instances on all platforms,
- * so it's easiest to just keep it as static explicit data.
- * This also removes the need for any initialisation of this class.
- *
- */
-var perm = [256]uint8{151, 160, 137, 91, 90, 15,
-	131, 13, 201, 95, 96, 53, 194, 233, 7, 225, 140, 36, 103, 30, 69, 142, 8, 99, 37, 240, 21, 10, 23,
-	190, 6, 148, 247, 120, 234, 75, 0, 26, 197, 62, 94, 252, 219, 203, 117, 35, 11, 32, 57, 177, 33,
-	88, 237, 149, 56, 87, 174, 20, 125, 136, 171, 168, 68, 175, 74, 165, 71, 134, 139, 48, 27, 166,
-	77, 146, 158, 231, 83, 111, 229, 122, 60, 211, 133, 230, 220, 105, 92, 41, 55, 46, 245, 40, 244,
-	102, 143, 54, 65, 25, 63, 161, 1, 216, 80, 73, 209, 76, 132, 187, 208, 89, 18, 169, 200, 196,
-	135, 130, 116, 188, 159, 86, 164, 100, 109, 198, 173, 186, 3, 64, 52, 217, 226, 250, 124, 123,
-	5, 202, 38, 147, 118, 126, 255, 82, 85, 212, 207, 206, 59, 227, 47, 16, 58, 17, 182, 189, 28, 42,
-	223, 183, 170, 213, 119, 248, 152, 2, 44, 154, 163, 70, 221, 153, 101, 155, 167, 43, 172, 9,
-	129, 22, 39, 253, 19, 98, 108, 110, 79, 113, 224, 232, 178, 185, 112, 104, 218, 246, 97, 228,
-	251, 34, 242, 193, 238, 210, 144, 12, 191, 179, 162, 241, 81, 51, 145, 235, 249, 14, 239, 107,
-	49, 192, 214, 31, 181, 199, 106, 157, 184, 84, 204, 176, 115, 121, 50, 45, 127, 4, 150, 254,
-	138, 236, 205, 93, 222, 114, 67, 29, 24, 72, 243, 141, 128, 195, 78, 66, 215, 61, 156, 180}
-
-//---------------------------------------------------------------------
-
-func grad2(hash uint8, x, y float32) float32 {
-	h := hash & 7 // Convert low 3 bits of hash code
-	u := y
-	v := 2 * x
-	if h < 4 {
-		u = x
-		v = 2 * y
-	} // into 8 simple gradient directions,
-	// and compute the dot product with (x,y).
-
-	if h&1 != 0 {
-		u = -u
-	}
-	if h&2 != 0 {
-		v = -v
-	}
-	return u + v
-}
+	// the server is shut down before the pool by the order of the defers,
+	// so no render request can still be waiting on it
+	pool := newWorkerPool(workers)
+	defer pool.close()
+	var filler fieldFiller = pool
+	if formula != nil {
+		filler = formulaFiller{pool, formula}
+	}
 
-// 2D simplex noise
-func snoise2(x, y float32) float32 {
-
-	const F2 float32 = 0.366025403 // F2 = 0.5*(sqrt(3.0)-1.0)
-	const G2 float32 = 0.211324865 // G2 = (3.0-Math.sqrt(3.0))/6.0
-
-	var n0, n1, n2 float32 // Noise contributions from the three corners
-
-	// Skew the input space to determine which simplex cell we're in
-	s := (x + y) * F2 // Hairy factor for 2D
-	xs := x + s
-	ys := y + s
-	i := fastFloor(xs)
-	j := fastFloor(ys)
-
-	t := float32(i+j) * G2
-	X0 := float32(i) - t // Unskew the cell origin back to (x,y) space
-	Y0 := float32(j) - t
-	x0 := x - X0 // The x,y distances from the cell origin
-	y0 := y - Y0
-
-	// For the 2D case, the simplex shape is an equilateral triangle.
-	// Determine which simplex we are in.
-	var i1, j1 uint8 // Offsets for second (middle) corner of simplex in (i,j) coords
-	if x0 > y0 {
-		i1 = 1
-		j1 = 0
-	} else { // lower triangle, XY order: (0,0)->(1,0)->(1,1)
-		i1 = 0
-		j1 = 1
-	} // upper triangle, YX order: (0,0)->(0,1)->(1,1)
-
-	// A step of (1,0) in (i,j) means a step of (1-c,-c) in (x,y), and
-	// a step of (0,1) in (i,j) means a step of (-c,1-c) in (x,y), where
-	// c = (3-sqrt(3))/6
-
-	x1 := x0 - float32(i1) + G2 // Offsets for middle corner in (x,y) unskewed coords
-	y1 := y0 - float32(j1) + G2
-	x2 := x0 - 1.0 + 2.0*G2 // Offsets for last corner in (x,y) unskewed coords
-	y2 := y0 - 1.0 + 2.0*G2
-
-	// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
-	ii := uint8(i)
-	jj := uint8(j)
-
-	// Calculate the contribution from the three corners
-	t0 := 0.5 - x0*x0 - y0*y0
-	if t0 < 0.0 {
-		n0 = 0.0
-	} else {
-		t0 *= t0
-		n0 = t0 * t0 * grad2(perm[ii+perm[jj]], x0, y0)
-	}
-
-	t1 := 0.5 - x1*x1 - y1*y1
-	if t1 < 0.0 {
-		n1 = 0.0
-	} else {
-		t1 *= t1
-		n1 = t1 * t1 * grad2(perm[ii+i1+perm[jj+j1]], x1, y1)
-	}
-
-	t2 := 0.5 - x2*x2 - y2*y2
-	if t2 < 0.0 {
-		n2 = 0.0
-	} else {
-		t2 *= t2
-		n2 = t2 * t2 * grad2(perm[ii+1+perm[jj+1]], x2, y2)
-	}
-
-	// Add contributions from each corner to get the final noise value.
-	return (n0 + n1 + n2)
+	// the headless modes are profiled from just before they start to just
+	// after they finish, so the profiles show generation and not start up
+	if *scaling {
+		runs := *benchRuns
+		if runs == 0 {
+			runs = defaultScalingRuns
+		}
+		return runHeadless(profiles, func() error {
+			return runScaling(os.Stdout, p, winWidth, winHeight, runs)
+		})
+	}
+
+	if *benchRuns != 0 {
+		return runHeadless(profiles, func() error {
+			return runBench(os.Stdout, pool, p, winWidth, winHeight, *benchRuns)
+		})
+	}
+
+	if *goSrc != "" {
+		return runHeadless(profiles, func() error {
+			return exportGoSource(filler, *goSrc, *goSrcPkg, p, winWidth, winHeight, *goSrcDownsample, *goSrcQuantize, percentPrinter(os.Stderr, "generating"))
+		})
+	}
+
+	if *tilesDir != "" {
+		return runHeadless(profiles, func() error {
+			w, h := *tilesX**tileSize, *tilesY**tileSize
+			tiles := filler
+			if *tilesPeriodic {
+				tiles = periodicFiller{filler, w, h}
+			}
+			pixels, err := renderPreset(tiles, p, w, h, percentPrinter(os.Stderr, "generating"))
+			if err != nil {
+				return err
+			}
+			atlas, err := export.Tiles(pixels, w, h, *tileSize, *tileSize, *tilesDir)
+			if err != nil {
+				return err
+			}
+			fmt.Println("wrote", len(atlas.Tiles), "tiles to", *tilesDir)
+			return nil
+		})
+	}
+
+	if *replayFile != "" {
+		return runHeadless(profiles, func() error {
+			return replayMacro(filler, *replayFile, *replayOut, p)
+		})
+	}
+
+	if *serveOnly {
+		if *serveAddr == "" {
+			fmt.Println("-serve-only needs -serve")
+			return 2
+		}
+		err := serveHeadless(*serveAddr, filler, newStatsLog(os.Stdout, workers, *verbose), p)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		return 0
+	}
+
+	// profiling the window would mostly measure the event loop and waiting
+	// for vsync
+	if profiles.enabled() {
+		fmt.Println("profiles are only written for -bench, -gosrc, -tiles-out and -replay")
+	}
+
+	// slots that cannot be read stay empty, the rest still work
+	slots, err := loadSlots(*slotsFile)
+	if err != nil {
+		fmt.Println("preset slots:", err)
+	}
+	a, err := newApp(windowOptions{
+		fpsCap:         *fpsCap,
+		serveAddr:      *serveAddr,
+		verbose:        *verbose,
+		repeatDelay:    *repeatDelay,
+		repeatInterval: *repeatInterval,
+		amortize:       *amortize,
+		paramsFile:     *paramsFile,
+		palette:        startPalette,
+		overlays:       startOverlays,
+		slots:          slots,
+		bindings:       bindings,
+		sweep:          sw,
+		sessionFile:    *sessionFile,
+		macroFile:      *macroFile,
+		macroSpeed:     *macroSpeed,
+	}, p, pool, formula, workers)
+	if errors.Is(err, context.Canceled) {
+		// the window was closed before the first field was ready
+		return 0
+	}
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer a.close()
+	if *sweepStart >= 0 {
+		sw.fixed, sw.start = true, *sweepStart
+	}
+	if *attract || sw.fixed {
+		sw.begin(a.game.p, true)
+	}
+	err = a.win.Run(a.game)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// numSlots is how many preset slots there are, one for each of the keys 1
+// to 9
+const numSlots = 9
+
+// slotStore holds the presets Shift+1 to Shift+9 save and 1 to 9 recall.
+// It is kept in one JSON object keyed by slot number, empty slots left
+// out:
+//
+//	{
+//	  "1": {...},   as savedParams, the preset and palette
+//	  "4": {...}
+//	}
+//
+// and every assign writes the whole of it back.
+type slotStore struct {
+	path  string
+	slots map[int]savedParams
+}
+
+// defaultSlotsPath is presets.json next to the binary, or in the working
+// directory if where the binary is cannot be told
+func defaultSlotsPath() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "presets.json"
+	}
+	return filepath.Join(filepath.Dir(exe), "presets.json")
+}
+
+// loadSlots reads the slots kept at path. A missing file is an empty
+// store. Slots that cannot be used are left empty and reported in the
+// error, the store returned always holds the rest and works; the next
+// assign drops what was left out.
+func loadSlots(path string) (*slotStore, error) {
+	st := &slotStore{path: path, slots: map[int]savedParams{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	var raw map[string]json.RawMessage
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return st, fmt.Errorf("%s: %v", path, err)
+	}
+	var errs []error
+	for key, msg := range raw {
+		n, err := strconv.Atoi(key)
+		if err != nil || n < 1 || n > numSlots {
+			errs = append(errs, fmt.Errorf("%s: no slot %q", path, key))
+			continue
+		}
+		s := savedParams{defaultPreset(), palettePresets[0].name}
+		dec := json.NewDecoder(bytes.NewReader(msg))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&s)
+		if err == nil {
+			_, _, err = s.resolve()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: slot %d: %v", path, n, err))
+			continue
+		}
+		st.slots[n] = s
+	}
+	return st, errors.Join(errs...)
+}
+
+// assign puts s in slot n and saves the store. The slot holds s even if
+// saving fails.
+func (st *slotStore) assign(n int, s savedParams) error {
+	if n < 1 || n > numSlots {
+		return fmt.Errorf("no slot %d", n)
+	}
+	st.slots[n] = s
+	data, err := json.MarshalIndent(st.slots, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(st.path, append(data, '\n'))
+}
+
+// recall returns the preset in slot n and the index of its palette, false
+// if the slot is empty
+func (st *slotStore) recall(n int) (preset, int, bool) {
+	s, ok := st.slots[n]
+	if !ok {
+		return preset{}, 0, false
+	}
+	// loadSlots only keeps slots that resolve
+	p, palette, err := s.resolve()
+	return p, palette, err == nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSlotsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	st, err := loadSlots(path)
+	if err != nil {
+		t.Fatal("missing file:", err)
+	}
+	if _, _, ok := st.recall(3); ok {
+		t.Fatal("recalled an empty slot")
+	}
+
+	p := defaultPreset()
+	p.Octaves = 5
+	p.View = p.View.pan(40, -20)
+	err = st.assign(3, savedParams{p, palettePresets[1].name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.assign(9, savedParams{defaultPreset(), palettePresets[0].name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err = loadSlots(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, palette, ok := st.recall(3)
+	if !ok || got != p || palette != 1 {
+		t.Errorf("slot 3 recalled %+v palette %d, %v, want %+v palette 1", got, palette, ok, p)
+	}
+	if _, _, ok := st.recall(9); !ok {
+		t.Error("slot 9 was not kept")
+	}
+	if _, _, ok := st.recall(1); ok {
+		t.Error("slot 1 was filled")
+	}
+}
+
+func TestSlotsOutOfRange(t *testing.T) {
+	st, _ := loadSlots(filepath.Join(t.TempDir(), "presets.json"))
+	for _, n := range []int{0, numSlots + 1} {
+		if err := st.assign(n, savedParams{defaultPreset(), palettePresets[0].name}); err == nil {
+			t.Errorf("assigned slot %d", n)
+		}
+	}
+}
+
+func TestSlotsCorrupt(t *testing.T) {
+	good := `{"octaves": 4, "palette": "` + palettePresets[0].name + `"}`
+	tests := []struct {
+		name, data string
+		// kept are the slots that survive loading
+		kept []int
+		// dropped names what the error has to mention, "" for no error
+		dropped string
+	}{
+		{"not json", `{"1": {"octaves":`, nil, "presets.json"},
+		{"bad slot", `{"1": ` + good + `, "2": {"octaves": 0}}`, []int{1}, "slot 2"},
+		{"unknown field", `{"1": {"seed": 3}, "5": ` + good + `}`, []int{5}, "slot 1"},
+		{"unknown palette", `{"2": {"palette": "nope"}, "3": ` + good + `}`, []int{3}, "slot 2"},
+		{"no such slot", `{"0": ` + good + `, "x": ` + good + `, "4": ` + good + `}`, []int{4}, `"x"`},
+		{"empty", `{}`, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "presets.json")
+			err := os.WriteFile(path, []byte(tt.data), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st, err := loadSlots(path)
+			switch {
+			case tt.dropped == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.dropped != "" && (err == nil || !strings.Contains(err.Error(), tt.dropped)):
+				t.Errorf("error %v does not mention %s", err, tt.dropped)
+			}
+			for n := 1; n <= numSlots; n++ {
+				_, _, ok := st.recall(n)
+				want := false
+				for _, k := range tt.kept {
+					want = want || k == n
+				}
+				if ok != want {
+					t.Errorf("slot %d kept %v, want %v", n, ok, want)
+				}
+			}
+			// the store still saves over a corrupt file
+			err = st.assign(6, savedParams{defaultPreset(), palettePresets[0].name})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadSlots(path); err != nil {
+				t.Errorf("reloading after assign: %v", err)
+			}
+		})
+	}
+}
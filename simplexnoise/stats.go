@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// statsWindow is how many of the latest regenerations the summary covers
+const statsWindow = 64
+
+// timing is what one regeneration spent in each phase
+type timing struct {
+	generate, normalize, draw time.Duration
+}
+
+// phaseStats summarizes one phase over the recent regenerations, in ms
+type phaseStats struct {
+	Last float64 `json:"last_ms"`
+	Mean float64 `json:"mean_ms"`
+	Min  float64 `json:"min_ms"`
+	Max  float64 `json:"max_ms"`
+}
+
+// Stats is a snapshot of the regeneration timings. Count is every
+// regeneration so far, the phases only cover the latest Window of them.
+type Stats struct {
+	Workers   int        `json:"workers"`
+	Count     int        `json:"count"`
+	Window    int        `json:"window"`
+	Generate  phaseStats `json:"generate"`
+	Normalize phaseStats `json:"normalize"`
+	Draw      phaseStats `json:"draw"`
+}
+
+func (s Stats) String() string {
+	if s.Count == 0 {
+		return "no regenerations"
+	}
+	line := func(name string, p phaseStats) string {
+		return fmt.Sprintf("%-13s last %.2fms mean %.2fms min %.2fms max %.2fms\n", name, p.Last, p.Mean, p.Min, p.Max)
+	}
+	return fmt.Sprintf("%d regenerations, last %d with %d workers:\n", s.Count, s.Window, s.Workers) +
+		line("generation", s.Generate) + line("normalization", s.Normalize) + line("draw", s.Draw)
+}
+
+// statsLog collects the timings of the window's regenerations. When verbose
+// every one is printed to out as it is recorded, otherwise they are only
+// kept for the summary. It is safe to use from several goroutines.
+type statsLog struct {
+	mutex   sync.Mutex
+	out     io.Writer
+	verbose bool
+	workers int
+
+	count  int
+	recent [statsWindow]timing
+}
+
+func newStatsLog(out io.Writer, workers int, verbose bool) *statsLog {
+	return &statsLog{out: out, workers: workers, verbose: verbose}
+}
+
+func ms(d time.Duration) float64 {
+	return d.Seconds() * 1000.0
+}
+
+func (l *statsLog) record(t timing) {
+	l.mutex.Lock()
+	l.recent[l.count%statsWindow] = t
+	l.count++
+	l.mutex.Unlock()
+	if l.verbose {
+		fmt.Fprintf(l.out, "generation %.2fms normalization %.2fms draw %.2fms workers %d\n",
+			ms(t.generate), ms(t.normalize), ms(t.draw), l.workers)
+	}
+}
+
+func (l *statsLog) stats() Stats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	s := Stats{Workers: l.workers, Count: l.count, Window: l.count}
+	if s.Window > statsWindow {
+		s.Window = statsWindow
+	}
+	if s.Window == 0 {
+		return s
+	}
+	last := l.recent[(l.count-1)%statsWindow]
+	s.Generate = summarize(l.recent[:s.Window], last.generate, func(t timing) time.Duration { return t.generate })
+	s.Normalize = summarize(l.recent[:s.Window], last.normalize, func(t timing) time.Duration { return t.normalize })
+	s.Draw = summarize(l.recent[:s.Window], last.draw, func(t timing) time.Duration { return t.draw })
+	return s
+}
+
+func summarize(timings []timing, last time.Duration, phase func(timing) time.Duration) phaseStats {
+	p := phaseStats{Last: ms(last), Min: math.MaxFloat64, Max: -math.MaxFloat64}
+	var sum float64
+	for _, t := range timings {
+		v := ms(phase(t))
+		sum += v
+		p.Min = math.Min(p.Min, v)
+		p.Max = math.Max(p.Max, v)
+	}
+	p.Mean = sum / float64(len(timings))
+	return p
+}
+
+// dump prints the summary to out
+func (l *statsLog) dump() {
+	fmt.Fprint(l.out, l.stats())
+}
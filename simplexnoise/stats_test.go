@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsLog(t *testing.T) {
+	var out bytes.Buffer
+	l := newStatsLog(&out, 3, false)
+	if s := l.stats(); s.Count != 0 || s.Window != 0 || s.String() != "no regenerations" {
+		t.Errorf("empty log gave %+v, %q", s, s)
+	}
+
+	for _, g := range []time.Duration{4, 1, 7, 2} {
+		l.record(timing{g * time.Millisecond, time.Millisecond, 3 * time.Millisecond})
+	}
+	s := l.stats()
+	if s.Workers != 3 || s.Count != 4 || s.Window != 4 {
+		t.Errorf("stats %+v, want 3 workers and 4 regenerations", s)
+	}
+	if want := (phaseStats{Last: 2, Mean: 3.5, Min: 1, Max: 7}); s.Generate != want {
+		t.Errorf("generation %+v, want %+v", s.Generate, want)
+	}
+	if want := (phaseStats{Last: 1, Mean: 1, Min: 1, Max: 1}); s.Normalize != want {
+		t.Errorf("normalization %+v, want %+v", s.Normalize, want)
+	}
+	if want := (phaseStats{Last: 3, Mean: 3, Min: 3, Max: 3}); s.Draw != want {
+		t.Errorf("draw %+v, want %+v", s.Draw, want)
+	}
+	if out.Len() != 0 {
+		t.Errorf("quiet log printed %q", out.String())
+	}
+
+	// past the window only the latest statsWindow count, 100..163ms
+	for i := 0; i < 2*statsWindow; i++ {
+		l.record(timing{generate: time.Duration(100+i-statsWindow) * time.Millisecond})
+	}
+	s = l.stats()
+	if s.Count != 4+2*statsWindow || s.Window != statsWindow {
+		t.Errorf("%d regenerations over a window of %d, want %d over %d", s.Count, s.Window, 4+2*statsWindow, statsWindow)
+	}
+	if want := (phaseStats{Last: 163, Mean: 131.5, Min: 100, Max: 163}); s.Generate != want {
+		t.Errorf("generation over the window %+v, want %+v", s.Generate, want)
+	}
+	l.dump()
+	if !strings.HasPrefix(out.String(), "132 regenerations, last 64 with 3 workers:\ngeneration    last 163.00ms mean 131.50ms min 100.00ms max 163.00ms\n") {
+		t.Errorf("summary is\n%s", out.String())
+	}
+}
+
+func TestStatsLogVerbose(t *testing.T) {
+	var out bytes.Buffer
+	l := newStatsLog(&out, 2, true)
+	l.record(timing{12500 * time.Microsecond, 250 * time.Microsecond, 2 * time.Millisecond})
+	if want := "generation 12.50ms normalization 0.25ms draw 2.00ms workers 2\n"; out.String() != want {
+		t.Errorf("verbose log printed %q, want %q", out.String(), want)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 20; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		times []time.Duration
+		pct   float64
+		want  time.Duration
+	}{
+		{sorted, 50, 10 * time.Millisecond},
+		{sorted, 95, 19 * time.Millisecond},
+		{sorted, 100, 20 * time.Millisecond},
+		{sorted, 0, time.Millisecond},
+		{sorted[:3], 50, 2 * time.Millisecond},
+		{sorted[:1], 95, time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.times, tt.pct); got != tt.want {
+			t.Errorf("p%v of %d samples is %v, want %v", tt.pct, len(tt.times), got, tt.want)
+		}
+	}
+	if got := mean(sorted); got != 10500*time.Microsecond {
+		t.Errorf("mean is %v, want 10.5ms", got)
+	}
+}
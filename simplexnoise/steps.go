@@ -0,0 +1,62 @@
+package main
+
+const (
+	// stepFraction is how much of itself a parameter changes by in a
+	// step. Ctrl makes steps fineStep times that and alt coarseStep.
+	stepFraction = 0.02
+	fineStep     = 0.1
+	coarseStep   = 10
+)
+
+// paramRange is the values a parameter steps between, both ends included.
+// Ranges open at an end are closed a little inside it.
+type paramRange struct {
+	min, max float32
+}
+
+var (
+	frequencyRange  = paramRange{1e-5, 10}
+	gainRange       = paramRange{0.01, 2}
+	lacunarityRange = paramRange{1.01, 8}
+)
+
+func (r paramRange) clamp(v float32) float32 {
+	if v < r.min {
+		return r.min
+	}
+	if v > r.max {
+		return r.max
+	}
+	return v
+}
+
+// stepScale is how big a step is with ctrl or alt held, relative to one
+// without, ctrl winning if both are
+func stepScale(ctrl, alt bool) float32 {
+	switch {
+	case ctrl:
+		return fineStep
+	case alt:
+		return coarseStep
+	}
+	return 1
+}
+
+// stepValue steps v up, or down if mult is negative, by scale steps of
+// stepFraction of itself and clamps it to r. Down divides by what up
+// multiplies by, so a step up and one back down come back to v.
+func stepValue(v float32, mult int, scale float32, r paramRange) float32 {
+	f := 1 + stepFraction*scale
+	if mult < 0 {
+		v /= f
+	} else {
+		v *= f
+	}
+	return r.clamp(v)
+}
+
+// stepOctaves adds mult octaves to n, keeping to 1 to maxOctaves. Octaves
+// only come whole, the step is the same with ctrl or alt.
+func stepOctaves(n, mult int) int {
+	return clamp(1, maxOctaves, n+mult)
+}
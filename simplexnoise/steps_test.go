@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) <= 1e-6*math.Max(1, math.Abs(float64(b)))
+}
+
+func TestStepValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     float32
+		mult  int
+		scale float32
+		r     paramRange
+		want  float32
+	}{
+		{"up", 0.01, 1, 1, frequencyRange, 0.0102},
+		{"down", 0.0102, -1, 1, frequencyRange, 0.01},
+		{"fine", 0.5, 1, fineStep, gainRange, 0.501},
+		{"coarse", 0.5, 1, coarseStep, gainRange, 0.6},
+		{"coarse down", 0.6, -1, coarseStep, gainRange, 0.5},
+		{"proportional near zero", 1e-4, 1, 1, frequencyRange, 1.02e-4},
+		{"gain capped", 1.99, 1, 1, gainRange, 2},
+		{"gain floor", 0.0101, -1, coarseStep, gainRange, 0.01},
+		{"frequency floor", 1e-5, -1, 1, frequencyRange, 1e-5},
+		{"lacunarity floor", 1.02, -1, coarseStep, lacunarityRange, 1.01},
+		{"lacunarity cap", 7.9, 1, coarseStep, lacunarityRange, 8},
+		{"out of range comes back in", 5, -1, fineStep, gainRange, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stepValue(tt.v, tt.mult, tt.scale, tt.r)
+			if !near(got, tt.want) {
+				t.Errorf("stepValue(%v, %d, %v) = %v, want %v", tt.v, tt.mult, tt.scale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepValueRoundTrip(t *testing.T) {
+	for _, scale := range []float32{fineStep, 1, coarseStep} {
+		v := float32(0.25)
+		up := stepValue(v, 1, scale, gainRange)
+		if back := stepValue(up, -1, scale, gainRange); !near(back, v) {
+			t.Errorf("scale %v: %v up and down is %v", scale, v, back)
+		}
+	}
+}
+
+func TestStepScale(t *testing.T) {
+	tests := []struct {
+		ctrl, alt bool
+		want      float32
+	}{
+		{false, false, 1},
+		{true, false, fineStep},
+		{false, true, coarseStep},
+		{true, true, fineStep},
+	}
+	for _, tt := range tests {
+		if got := stepScale(tt.ctrl, tt.alt); got != tt.want {
+			t.Errorf("stepScale(%v, %v) = %v, want %v", tt.ctrl, tt.alt, got, tt.want)
+		}
+	}
+}
+
+func TestStepOctaves(t *testing.T) {
+	tests := []struct {
+		n, mult, want int
+	}{
+		{3, 1, 4},
+		{3, -1, 2},
+		{1, -1, 1},
+		{maxOctaves, 1, maxOctaves},
+		{maxOctaves + 3, -1, maxOctaves},
+	}
+	for _, tt := range tests {
+		if got := stepOctaves(tt.n, tt.mult); got != tt.want {
+			t.Errorf("stepOctaves(%d, %d) = %d, want %d", tt.n, tt.mult, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// sweepParam is a parameter the sweep can animate
+type sweepParam int
+
+const (
+	sweepFrequency sweepParam = iota
+	sweepGain
+	sweepLacunarity
+	sweepDrift
+	numSweepParams
+)
+
+var sweepParamNames = [numSweepParams]string{"frequency", "gain", "lacunarity", "drift"}
+
+// sweepSet is the parameters that take part in the sweep, a flag of their
+// names separated by commas
+type sweepSet [numSweepParams]bool
+
+func (s *sweepSet) String() string {
+	var names []string
+	for i, on := range s {
+		if on {
+			names = append(names, sweepParamNames[i])
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func (s *sweepSet) Set(v string) error {
+	var set sweepSet
+	for _, name := range strings.Split(v, ",") {
+		i, err := lookupName("sweep parameter", sweepParamNames[:], strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		set[i] = true
+	}
+	*s = set
+	return nil
+}
+
+const (
+	// the swinging parameters go through a whole cycle every so many
+	// seconds at speed 1, periods that share no small multiple so the
+	// display does not repeat for a long time
+	frequencyPeriod  = 23
+	gainPeriod       = 13
+	lacunarityPeriod = 17
+	// frequency swings frequencySwing octaves either side of where it
+	// started, gain and lacunarity by a fraction of their own value
+	frequencySwing  = 1
+	gainSwing       = 0.4
+	lacunaritySwing = 0.25
+	// drift pans the view this many pixels a second at speed 1
+	driftX, driftY = 24, 9
+	// sweepFrameTime is how far a deterministic sweep moves on for each
+	// field it shows
+	sweepFrameTime = 1.0 / 60
+)
+
+type sweepState int
+
+const (
+	sweepOff sweepState = iota
+	sweepOn
+	// a paused sweep has been stopped by a parameter key and goes on from
+	// where the keys left the parameters when it is turned back on
+	sweepPaused
+)
+
+// sweep slowly animates the parameters in params around base, the preset
+// they had when it started. t is how far into it it is in seconds of the
+// sweep's own time, which runs speed times the window's. A fixed sweep is
+// deterministic: it starts at start and moves on exactly sweepFrameTime
+// for every field shown, however long they take to make, so the same flags
+// always show the same fields.
+type sweep struct {
+	params sweepSet
+	speed  float64
+	fixed  bool
+	start  float64
+
+	state sweepState
+	base  preset
+	t     float64
+}
+
+// begin starts the sweep around p. Only a fixed sweep's first start is at
+// its start time, every other start is at 0 where the swings are all at
+// their middle, so the parameters carry on from where they are.
+func (s *sweep) begin(p preset, first bool) {
+	s.state = sweepOn
+	s.base = p
+	s.t = 0
+	if first && s.fixed {
+		s.t = s.start
+	}
+}
+
+// advance moves the sweep on by dt seconds of the window's time, or by
+// sweepFrameTime if it is fixed and a field was started for it
+func (s *sweep) advance(dt float64, started bool) {
+	if s.fixed {
+		if started {
+			s.t += sweepFrameTime * s.speed
+		}
+		return
+	}
+	s.t += dt * s.speed
+}
+
+// preset is base with the swept parameters at time t
+func (s *sweep) preset() preset {
+	p := s.base
+	swing := func(period float64) float32 {
+		return float32(math.Sin(2 * math.Pi * s.t / period))
+	}
+	if s.params[sweepFrequency] {
+		p.Frequency = frequencyRange.clamp(p.Frequency * float32(math.Exp2(float64(frequencySwing*swing(frequencyPeriod)))))
+	}
+	if s.params[sweepGain] {
+		p.Gain = gainRange.clamp(p.Gain * (1 + gainSwing*swing(gainPeriod)))
+	}
+	if s.params[sweepLacunarity] {
+		p.Lacunarity = lacunarityRange.clamp(p.Lacunarity * (1 + lacunaritySwing*swing(lacunarityPeriod)))
+	}
+	if s.params[sweepDrift] {
+		// by fractions of a pixel, pan only moves by whole ones
+		p.View.X += s.t * driftX * p.View.Step
+		p.View.Y += s.t * driftY * p.View.Step
+	}
+	return p
+}
+
+// status is how the help and the flash show the sweep
+func (s *sweep) status() string {
+	switch s.state {
+	case sweepOn:
+		return fmt.Sprint("sweeping ", s.params.String())
+	case sweepPaused:
+		return "sweep paused"
+	}
+	return "sweep off"
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestSweepSetFlag(t *testing.T) {
+	var s sweepSet
+	err := s.Set("gain, drift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (sweepSet{sweepGain: true, sweepDrift: true}); s != want {
+		t.Errorf("parsed %v, want %v", s, want)
+	}
+	if got := s.String(); got != "gain,drift" {
+		t.Errorf("String() = %q", got)
+	}
+	if err := s.Set("gain,seed"); err == nil {
+		t.Error("an unknown parameter was accepted")
+	}
+	var _ flag.Value = &s
+}
+
+func TestSweepStartsWhereItIs(t *testing.T) {
+	p := defaultPreset()
+	s := &sweep{params: sweepSet{true, true, true, true}, speed: 1}
+	s.begin(p, true)
+	if got := s.preset(); got != p {
+		t.Errorf("a sweep at 0 shows %+v, want %+v", got, p)
+	}
+}
+
+func TestSweepDeltaTime(t *testing.T) {
+	// the same time in big or small frames ends up in the same place
+	p := defaultPreset()
+	a := &sweep{params: sweepSet{true, true, true, true}, speed: 2}
+	b := *a
+	a.begin(p, false)
+	b.begin(p, false)
+	for i := 0; i < 4; i++ {
+		a.advance(0.25, true)
+	}
+	for i := 0; i < 100; i++ {
+		b.advance(0.01, i%2 == 0)
+	}
+	if !near(float32(a.t), 2) || !near(float32(b.t), 2) {
+		t.Errorf("sweeps at %v and %v after a second at speed 2", a.t, b.t)
+	}
+	if pa, pb := a.preset(), b.preset(); !near(pa.Frequency, pb.Frequency) || !near(float32(pa.View.X), float32(pb.View.X)) {
+		t.Errorf("sweeps a second in differ: %+v and %+v", pa, pb)
+	}
+}
+
+func TestSweepFixed(t *testing.T) {
+	// a fixed sweep ignores the frame times and only moves on for the
+	// fields shown, so two runs agree field for field
+	p := defaultPreset()
+	run := func(dts []float64) []preset {
+		s := &sweep{params: sweepSet{true, true, true, true}, speed: 1, fixed: true, start: 30}
+		s.begin(p, true)
+		var shown []preset
+		for i, dt := range dts {
+			started := i%3 == 0
+			if started {
+				shown = append(shown, s.preset())
+			}
+			s.advance(dt, started)
+		}
+		return shown
+	}
+	a := run([]float64{0.016, 0.016, 0.017, 0.05, 0.016, 0.016, 0.2})
+	b := run([]float64{0.1, 0.001, 0.03, 0.016, 0.5, 0.016, 0.016})
+	if len(a) != len(b) {
+		t.Fatalf("%d and %d fields", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("field %d is %+v in one run and %+v in the other", i, a[i], b[i])
+		}
+	}
+	if a[0] == p {
+		t.Error("a fixed sweep did not start at its start time")
+	}
+
+	// turned off and on again it carries on from where it is
+	s := &sweep{params: sweepSet{true, true, true, true}, speed: 1, fixed: true, start: 30}
+	s.begin(a[1], false)
+	if got := s.preset(); got != a[1] {
+		t.Errorf("restarted sweep shows %+v, want %+v", got, a[1])
+	}
+}
+
+func TestSweepStaysInRange(t *testing.T) {
+	p := defaultPreset()
+	p.Gain, p.Frequency, p.Lacunarity = gainRange.max, frequencyRange.max, lacunarityRange.min
+	s := &sweep{params: sweepSet{sweepFrequency: true, sweepGain: true, sweepLacunarity: true}, speed: 1}
+	s.begin(p, false)
+	for i := 0; i < 1000; i++ {
+		q := s.preset()
+		if q.Gain < gainRange.min || q.Gain > gainRange.max ||
+			q.Frequency < frequencyRange.min || q.Frequency > frequencyRange.max ||
+			q.Lacunarity < lacunarityRange.min || q.Lacunarity > lacunarityRange.max {
+			t.Fatalf("at %v the sweep left the ranges: %+v", s.t, q)
+		}
+		if q.View != p.View || q.Octaves != p.Octaves {
+			t.Fatalf("at %v the sweep moved what it does not animate: %+v", s.t, q)
+		}
+		s.advance(0.1, true)
+	}
+}
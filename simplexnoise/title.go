@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	windowTitle = "Simplex Noise"
+	// titleInterval is the least time between two titles, window managers
+	// redraw the taskbar for every one
+	titleInterval = 500 * time.Millisecond
+)
+
+// formatTitle is the window's title for p, e.g.
+//
+//	Simplex Noise — ridged simplex, f=0.012 o=5 g=0.2 l=3
+//
+// with the percentage done appended while a field is being made a piece at
+// a time, progress from 0 to 1, and nothing for a negative progress
+func formatTitle(p preset, progress float64) string {
+	title := fmt.Sprintf("%s — %s %s, f=%.4g o=%d g=%.3g l=%.3g", windowTitle, p.Mode, p.Basis, p.Frequency, p.Octaves, p.Gain, p.Lacunarity)
+	if progress >= 0 {
+		if progress > 1 {
+			progress = 1
+		}
+		title += fmt.Sprintf(" [%d%%]", int(progress*100))
+	}
+	return title
+}
+
+// titleThrottle lets a new title through at most once every interval. A
+// title held back is let through once the interval is over, so the last
+// one always shows.
+type titleThrottle struct {
+	interval time.Duration
+	last     time.Time
+}
+
+// ready reports whether a title can be set now, and if so counts it as set
+func (t *titleThrottle) ready(now time.Time) bool {
+	if now.Sub(t.last) < t.interval {
+		return false
+	}
+	t.last = now
+	return true
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTitle(t *testing.T) {
+	p := defaultPreset()
+	tests := []struct {
+		progress float64
+		want     string
+	}{
+		{-1, "Simplex Noise — turbulence simplex, f=0.01 o=3 g=0.2 l=3"},
+		{0, "Simplex Noise — turbulence simplex, f=0.01 o=3 g=0.2 l=3 [0%]"},
+		{0.427, "Simplex Noise — turbulence simplex, f=0.01 o=3 g=0.2 l=3 [42%]"},
+		{1.5, "Simplex Noise — turbulence simplex, f=0.01 o=3 g=0.2 l=3 [100%]"},
+	}
+	for _, tt := range tests {
+		if got := formatTitle(p, tt.progress); got != tt.want {
+			t.Errorf("formatTitle(p, %v) = %q, want %q", tt.progress, got, tt.want)
+		}
+	}
+
+	p.Mode, p.Octaves, p.Frequency = ridgedMode, 5, 0.0125
+	if got, want := formatTitle(p, -1), "Simplex Noise — ridged simplex, f=0.0125 o=5 g=0.2 l=3"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTitleThrottle(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	th := titleThrottle{interval: titleInterval}
+	if !th.ready(start) {
+		t.Fatal("the first title was held back")
+	}
+	// at 60 frames a second at most two titles a second get through
+	set := 0
+	for f := 1; f <= 120; f++ {
+		if th.ready(start.Add(time.Duration(f) * time.Second / 60)) {
+			set++
+		}
+	}
+	if set != 4 {
+		t.Errorf("%d titles set in two seconds, want 4", set)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"time"
+
+	"github.com/sabith-th/games_with_go/font"
+)
+
+const (
+	// maxToasts is how many toasts show at once, more wait their turn
+	maxToasts = 3
+	// maxWaiting is how many toasts can wait, past it the oldest waiting
+	// one is dropped
+	maxWaiting = 8
+	// a toast shows for toastDuration, the last toastFade of it fading out
+	toastDuration = 2 * time.Second
+	toastFade     = 500 * time.Millisecond
+	// toastOpacity is how much a toast covers the field before it fades
+	toastOpacity = 0.85
+
+	toastScale  = 2
+	toastHeight = font.GlyphHeight*toastScale + 2*hudPadding
+	toastGap    = 4
+	toastMargin = 8
+)
+
+// toast is a message shown in a corner of the window for a while
+type toast struct {
+	text  string
+	until time.Time
+}
+
+// toastQueue holds the messages actions leave, saved files and failed
+// exports, as toasts in the top right corner. Every toast is shown for
+// toastDuration from when there is room for it, newest at the bottom.
+type toastQueue struct {
+	shown   []toast
+	waiting []string
+}
+
+// push queues text, it is shown once fewer than maxToasts are
+func (q *toastQueue) push(text string, now time.Time) {
+	q.waiting = append(q.waiting, text)
+	if len(q.waiting) > maxWaiting {
+		q.waiting = q.waiting[1:]
+	}
+	q.fill(now)
+}
+
+// fill shows waiting toasts while there is room
+func (q *toastQueue) fill(now time.Time) {
+	for len(q.shown) < maxToasts && len(q.waiting) > 0 {
+		q.shown = append(q.shown, toast{q.waiting[0], now.Add(toastDuration)})
+		q.waiting = q.waiting[1:]
+	}
+}
+
+// update takes down the toasts whose time is up and shows waiting ones in
+// their place. It reports whether the toasts look different from the last
+// frame: one went, came or is fading.
+func (q *toastQueue) update(now time.Time) bool {
+	changed := false
+	kept := q.shown[:0]
+	for _, t := range q.shown {
+		if now.Before(t.until) {
+			kept = append(kept, t)
+		} else {
+			changed = true
+		}
+	}
+	q.shown = kept
+	before := len(q.shown)
+	q.fill(now)
+	changed = changed || len(q.shown) != before
+	for _, t := range q.shown {
+		if t.until.Sub(now) < toastFade {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// opacity is how much of t shows at now, toastOpacity until it starts
+// fading and 0 once it is gone
+func (t toast) opacity(now time.Time) float64 {
+	left := t.until.Sub(now)
+	if left <= 0 {
+		return 0
+	}
+	if left >= toastFade {
+		return toastOpacity
+	}
+	return toastOpacity * float64(left) / float64(toastFade)
+}
+
+// rows are the rows the toasts can cover
+func (q *toastQueue) rows() rowRange {
+	return rowRange{toastMargin, toastMargin + maxToasts*(toastHeight+toastGap)}
+}
+
+// draw blends every toast over rows r of pixels, white text on black, as
+// opaque as its opacity. Rows outside r already have the toasts over them,
+// blending them again would darken them.
+func (q *toastQueue) draw(r rowRange, pixels []byte, now time.Time) {
+	for i, t := range q.shown {
+		a := t.opacity(now)
+		if a == 0 {
+			continue
+		}
+		tw, _ := font.Size(t.text, toastScale)
+		w := tw + 2*hudPadding
+		x, y := winWidth-toastMargin-w, toastMargin+i*(toastHeight+toastGap)
+		// the toast is drawn on its own and then blended, the text fades
+		// with its box
+		box := make([]byte, w*toastHeight*4)
+		font.Draw(t.text, hudPadding, hudPadding, toastScale, font.Color{R: 255, G: 255, B: 255}, box, w, toastHeight)
+		for by := 0; by < toastHeight; by++ {
+			py := y + by
+			if py < r.start || py >= r.end {
+				continue
+			}
+			for bx := 0; bx < w; bx++ {
+				px := x + bx
+				if px < 0 || px >= winWidth {
+					continue
+				}
+				src, dst := (by*w+bx)*4, (py*winWidth+px)*4
+				for c := 0; c < 3; c++ {
+					pixels[dst+c] = byte(float64(pixels[dst+c])*(1-a) + float64(box[src+c])*a + 0.5)
+				}
+			}
+		}
+	}
+}
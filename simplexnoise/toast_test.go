@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func toastTexts(q *toastQueue) []string {
+	var texts []string
+	for _, t := range q.shown {
+		texts = append(texts, t.text)
+	}
+	return texts
+}
+
+func TestToastQueue(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var q toastQueue
+	for i := 1; i <= 5; i++ {
+		q.push(fmt.Sprint("toast ", i), start)
+	}
+	if got := fmt.Sprint(toastTexts(&q)); got != "[toast 1 toast 2 toast 3]" {
+		t.Fatalf("shown %s", got)
+	}
+	if q.update(start.Add(time.Second)) {
+		t.Error("changed a second in")
+	}
+
+	// the first three go together and the two waiting take their place
+	if !q.update(start.Add(toastDuration)) {
+		t.Error("no change when the first toasts went")
+	}
+	if got := fmt.Sprint(toastTexts(&q)); got != "[toast 4 toast 5]" {
+		t.Fatalf("shown %s after the first went", got)
+	}
+	if q.shown[0].until != start.Add(2*toastDuration) {
+		t.Errorf("toast 4 shows until %v", q.shown[0].until)
+	}
+
+	// fading toasts change every frame
+	if !q.update(start.Add(2*toastDuration - toastFade/2)) {
+		t.Error("no change while fading")
+	}
+	if q.update(start.Add(2 * toastDuration)); len(q.shown) != 0 {
+		t.Errorf("%d toasts left", len(q.shown))
+	}
+	if q.update(start.Add(3 * toastDuration)) {
+		t.Error("changed with nothing to show")
+	}
+}
+
+func TestToastWaitingLimit(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var q toastQueue
+	for i := 0; i < maxToasts+maxWaiting+2; i++ {
+		q.push(fmt.Sprint(i), now)
+	}
+	if len(q.shown) != maxToasts || len(q.waiting) != maxWaiting {
+		t.Fatalf("%d shown and %d waiting", len(q.shown), len(q.waiting))
+	}
+	// the oldest waiting were dropped, not the newest
+	if q.waiting[0] != fmt.Sprint(maxToasts+2) {
+		t.Errorf("first waiting is %s", q.waiting[0])
+	}
+}
+
+func TestToastOpacity(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tt := toast{"x", now.Add(toastDuration)}
+	if a := tt.opacity(now); a != toastOpacity {
+		t.Errorf("opacity %v when new", a)
+	}
+	if a := tt.opacity(now.Add(toastDuration - toastFade/2)); a != toastOpacity/2 {
+		t.Errorf("opacity %v halfway through fading", a)
+	}
+	if a := tt.opacity(now.Add(toastDuration)); a != 0 {
+		t.Errorf("opacity %v when gone", a)
+	}
+}
+
+func TestToastDrawOnlyInRows(t *testing.T) {
+	var q toastQueue
+	now := time.Now()
+	q.push("saved", now)
+	pixels := make([]byte, winWidth*winHeight*4)
+	for i := range pixels {
+		pixels[i] = 200
+	}
+	r := q.rows()
+	q.draw(rowRange{r.start, r.start + 2}, pixels, now)
+	for y := r.start + 2; y < r.end; y++ {
+		for x := 0; x < winWidth; x++ {
+			if pixels[(y*winWidth+x)*4] != 200 {
+				t.Fatalf("drew outside the rows at %d, %d", x, y)
+			}
+		}
+	}
+	// the box's top rows are darkened in the top right corner
+	if i := (r.start*winWidth + winWidth - toastMargin - 1) * 4; pixels[i] >= 200 {
+		t.Errorf("toast not drawn, pixel is %d", pixels[i])
+	}
+}
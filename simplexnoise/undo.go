@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// undoDepth is how many changes Ctrl+Z can go back
+	undoDepth = 100
+	// coalesceWindow is how soon a change to the same parameter has to
+	// follow the last one to be undone together with it
+	coalesceWindow = time.Second
+)
+
+// snapshot is the state an undo goes back to, the preset and the palette
+type snapshot struct {
+	p       preset
+	palette int
+}
+
+func (g *noiseGame) snapshot() snapshot {
+	return snapshot{g.p, g.paletteIndex}
+}
+
+// restore goes back to s, the caller regenerates the field
+func (g *noiseGame) restore(s snapshot) {
+	g.p = s.p
+	if s.palette != g.paletteIndex {
+		g.setPalette(s.palette)
+	}
+}
+
+// changes names the parts of a and b that differ, "" if none do
+func changes(a, b snapshot) string {
+	var names []string
+	add := func(differ bool, name string) {
+		if differ {
+			names = append(names, name)
+		}
+	}
+	add(a.p.Frequency != b.p.Frequency, "frequency")
+	add(a.p.Lacunarity != b.p.Lacunarity, "lacunarity")
+	add(a.p.Gain != b.p.Gain, "gain")
+	add(a.p.Octaves != b.p.Octaves, "octaves")
+	add(a.p.View != b.p.View, "view")
+	add(a.p.Mode != b.p.Mode, "mode")
+	add(a.p.Basis != b.p.Basis, "basis")
+	add(a.palette != b.palette, "palette")
+	return strings.Join(names, ",")
+}
+
+// undoStack is the history of snapshots Ctrl+Z and Ctrl+Y walk. Changes
+// are pushed with a key naming what changed, and a change with the same
+// key as the last one within window of it extends that entry instead of
+// adding another, so holding a key is one step back. It keeps at most
+// depth entries, dropping the oldest.
+type undoStack struct {
+	done, undone []snapshot
+	depth        int
+	window       time.Duration
+	// lastKey is what the newest entry was pushed for, lastAt when it was
+	// last extended. Undo and redo clear lastKey so nothing joins it.
+	lastKey string
+	lastAt  time.Time
+}
+
+func newUndoStack(depth int, window time.Duration) *undoStack {
+	return &undoStack{depth: depth, window: window}
+}
+
+// push records prev, the state before a change to key made at now, and
+// drops what could be redone
+func (s *undoStack) push(prev snapshot, key string, now time.Time) {
+	s.undone = s.undone[:0]
+	if key != "" && key == s.lastKey && len(s.done) > 0 && now.Sub(s.lastAt) <= s.window {
+		s.lastAt = now
+		return
+	}
+	s.done = s.add(s.done, prev)
+	s.lastKey, s.lastAt = key, now
+}
+
+// add appends v to entries, dropping the oldest past depth
+func (s *undoStack) add(entries []snapshot, v snapshot) []snapshot {
+	entries = append(entries, v)
+	if len(entries) > s.depth {
+		entries = append(entries[:0], entries[len(entries)-s.depth:]...)
+	}
+	return entries
+}
+
+// undo returns the state before the newest change, keeping cur to redo,
+// or cur and false if there is nothing to undo
+func (s *undoStack) undo(cur snapshot) (snapshot, bool) {
+	if len(s.done) == 0 {
+		return cur, false
+	}
+	prev := s.done[len(s.done)-1]
+	s.done = s.done[:len(s.done)-1]
+	s.undone = s.add(s.undone, cur)
+	s.lastKey = ""
+	return prev, true
+}
+
+// redo returns the state the newest undo left, keeping cur to undo again,
+// or cur and false if there is nothing to redo
+func (s *undoStack) redo(cur snapshot) (snapshot, bool) {
+	if len(s.undone) == 0 {
+		return cur, false
+	}
+	next := s.undone[len(s.undone)-1]
+	s.undone = s.undone[:len(s.undone)-1]
+	s.done = s.add(s.done, cur)
+	s.lastKey = ""
+	return next, true
+}
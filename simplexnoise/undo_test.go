@@ -0,0 +1,163 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// octaves is the default preset with n octaves
+func octaves(n int) snapshot {
+	p := defaultPreset()
+	p.Octaves = n
+	return snapshot{p: p}
+}
+
+func TestUndoRedo(t *testing.T) {
+	s := newUndoStack(10, time.Second)
+	now := time.Now()
+	if _, ok := s.undo(octaves(1)); ok {
+		t.Fatal("undid with nothing pushed")
+	}
+	// 1 -> 2 -> 3, far enough apart not to coalesce
+	s.push(octaves(1), "octaves", now)
+	s.push(octaves(2), "octaves", now.Add(2*time.Second))
+	cur := octaves(3)
+
+	var ok bool
+	for _, want := range []int{2, 1} {
+		cur, ok = s.undo(cur)
+		if !ok || cur.p.Octaves != want {
+			t.Fatalf("undo gave %d octaves, %v, want %d", cur.p.Octaves, ok, want)
+		}
+	}
+	if _, ok := s.undo(cur); ok {
+		t.Fatal("undid past the first change")
+	}
+	for _, want := range []int{2, 3} {
+		cur, ok = s.redo(cur)
+		if !ok || cur.p.Octaves != want {
+			t.Fatalf("redo gave %d octaves, %v, want %d", cur.p.Octaves, ok, want)
+		}
+	}
+	if _, ok := s.redo(cur); ok {
+		t.Fatal("redid past the last change")
+	}
+
+	// a new change after an undo drops what could be redone
+	cur, _ = s.undo(cur)
+	s.push(cur, "gain", now.Add(4*time.Second))
+	if _, ok := s.redo(octaves(9)); ok {
+		t.Fatal("redo survived a new change")
+	}
+}
+
+func TestUndoCoalesce(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		// the second push's key and how long after the first it comes
+		key   string
+		after time.Duration
+		// entries is how many undos there are afterwards
+		entries int
+	}{
+		{"same key soon", "octaves", 500 * time.Millisecond, 1},
+		{"same key at the window", "octaves", time.Second, 1},
+		{"same key later", "octaves", 1500 * time.Millisecond, 2},
+		{"other key soon", "gain", 100 * time.Millisecond, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newUndoStack(10, time.Second)
+			s.push(octaves(1), "octaves", now)
+			s.push(octaves(2), tt.key, now.Add(tt.after))
+			cur := octaves(3)
+			n := 0
+			for {
+				var ok bool
+				cur, ok = s.undo(cur)
+				if !ok {
+					break
+				}
+				n++
+			}
+			if n != tt.entries {
+				t.Errorf("%d undos, want %d", n, tt.entries)
+			}
+			// however the pushes were joined the oldest state comes back
+			if cur.p.Octaves != 1 {
+				t.Errorf("undoing everything gave %d octaves, want 1", cur.p.Octaves)
+			}
+		})
+	}
+}
+
+func TestUndoCoalesceChain(t *testing.T) {
+	// a held key repeating keeps extending the same entry, each repeat
+	// counting from the one before and not from the first
+	s := newUndoStack(10, time.Second)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		s.push(octaves(1+i), "octaves", now.Add(time.Duration(i)*800*time.Millisecond))
+	}
+	cur, ok := s.undo(octaves(6))
+	if !ok || cur.p.Octaves != 1 {
+		t.Fatalf("undo gave %d octaves, want 1", cur.p.Octaves)
+	}
+	if _, ok := s.undo(cur); ok {
+		t.Error("repeats made more than one entry")
+	}
+}
+
+func TestUndoAfterWalkDoesNotCoalesce(t *testing.T) {
+	s := newUndoStack(10, time.Second)
+	now := time.Now()
+	s.push(octaves(1), "octaves", now)
+	cur, _ := s.undo(octaves(2))
+	cur, _ = s.redo(cur)
+	// straight after a redo the same key starts an entry of its own
+	s.push(cur, "octaves", now.Add(10*time.Millisecond))
+	cur, _ = s.undo(octaves(3))
+	if cur.p.Octaves != 2 {
+		t.Errorf("undo gave %d octaves, want 2", cur.p.Octaves)
+	}
+}
+
+func TestUndoDepth(t *testing.T) {
+	s := newUndoStack(3, time.Second)
+	now := time.Now()
+	for i := 1; i <= 5; i++ {
+		s.push(octaves(i), "octaves", now.Add(time.Duration(i)*time.Hour))
+	}
+	cur := octaves(6)
+	var got []int
+	for {
+		var ok bool
+		cur, ok = s.undo(cur)
+		if !ok {
+			break
+		}
+		got = append(got, cur.p.Octaves)
+	}
+	if len(got) != 3 || got[0] != 5 || got[2] != 3 {
+		t.Errorf("undid to %v, want [5 4 3]", got)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	a := octaves(3)
+	if k := changes(a, a); k != "" {
+		t.Errorf("no change named %q", k)
+	}
+	b := a
+	b.p.Gain = 0.5
+	b.palette = 1
+	if k := changes(a, b); k != "gain,palette" {
+		t.Errorf("changes named %q, want gain,palette", k)
+	}
+	c := a
+	c.p.View.X += 10
+	if k := changes(a, c); k != "view" {
+		t.Errorf("pan named %q, want view", k)
+	}
+}
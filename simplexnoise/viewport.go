@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math"
+
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+// minStepULPs is how many float32 steps a pixel has to span at the far
+// edge of the view for the float32 noise path to be used. Below it nearby
+// pixels round to the same coordinate and the field turns into blocks.
+const minStepULPs = 256
+
+// viewport places the field in the world: pixel x, y is at X+x*Step,
+// Y+y*Step. It is kept in float64, float32 runs out of precision for
+// neighbouring pixels long before a deep zoom gets anywhere near as far
+// from the origin as it can pan.
+type viewport struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Step float64 `json:"step"`
+}
+
+func defaultViewport() viewport {
+	return viewport{Step: 1}
+}
+
+// at returns the world coordinates of pixel x, y
+func (v viewport) at(x, y int) (float64, float64) {
+	return v.X + float64(x)*v.Step, v.Y + float64(y)*v.Step
+}
+
+// wide reports whether a w*h field of this view needs the float64 noise
+// path, that is whether float32 coordinates would be too coarse for Step
+// somewhere in it. The default view never needs it, so it renders exactly
+// as it did before there was a view.
+func (v viewport) wide(w, h int) bool {
+	x0, y0 := v.at(0, 0)
+	x1, y1 := v.at(w-1, h-1)
+	far := math.Max(math.Max(math.Abs(x0), math.Abs(x1)), math.Max(math.Abs(y0), math.Abs(y1)))
+	f := float32(far)
+	ulp := float64(math.Nextafter32(f, float32(math.Inf(1))) - f)
+	return v.Step < minStepULPs*ulp
+}
+
+// pan moves the view by dx, dy pixels
+func (v viewport) pan(dx, dy int) viewport {
+	v.X, v.Y = v.at(dx, dy)
+	return v
+}
+
+// zoom scales the view by factor about pixel x, y, which stays where it is.
+// A factor above 1 zooms in.
+func (v viewport) zoom(x, y int, factor float64) viewport {
+	cx, cy := v.at(x, y)
+	v.Step /= factor
+	v.X = cx - float64(x)*v.Step
+	v.Y = cy - float64(y)*v.Step
+	return v
+}
+
+// sampleSourceRow fills row with src along pixel row y. A noise.Source
+// takes float32 coordinates, so deep zooms far from the origin turn blocky.
+func (v viewport) sampleSourceRow(row []float32, y int, src noise.Source) {
+	_, wy := v.at(0, y)
+	for x := range row {
+		wx, _ := v.at(x, y)
+		row[x] = src.At(float32(wx), float32(wy))
+	}
+}
+
+// sampleRow fills row with the turbulence of p along pixel row y, in
+// float64 if wide says the view needs it. It works a row at a time so the
+// float32 path costs no more than calling turbulence directly.
+func (v viewport) sampleRow(row []float32, y int, wide bool, p preset) {
+	_, wy := v.at(0, y)
+	if wide {
+		for x := range row {
+			wx, _ := v.at(x, y)
+			row[x] = noise.TurbulenceWide(wx, wy, p.Frequency, p.Lacunarity, p.Gain, p.Octaves)
+		}
+		return
+	}
+	for x := range row {
+		wx, _ := v.at(x, y)
+		row[x] = noise.Turbulence(float32(wx), float32(wy), p.Frequency, p.Lacunarity, p.Gain, p.Octaves)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+// TestViewportExtremeOffsets samples views far from the origin, where
+// float32 coordinates gave neighbouring pixels the same position and the
+// field turned into blocks. The coordinates the noise is evaluated at
+// must differ for every pair of neighbouring pixels, and so must nearly
+// all the values.
+func TestViewportExtremeOffsets(t *testing.T) {
+	const w, h = 64, 4
+	p := defaultPreset()
+	for _, v := range []viewport{
+		{X: 1e6, Y: 1e6, Step: 1e-2},
+		{X: -3e7, Y: 5e7, Step: 1e-3},
+		{X: 1e9, Y: -1e9, Step: 1e-4},
+		{X: 2e11, Y: 2e11, Step: 0.5},
+		{X: -7.5e12, Y: 3e12, Step: 64},
+	} {
+		if !v.wide(w, h) {
+			t.Errorf("%+v: float32 coordinates used this far out", v)
+		}
+		// after a pan and a zoom about the middle, as the window gets there
+		zoomed := v.pan(-w/2, 7).zoom(w/2, 0, 2).pan(w/2, -7)
+		for _, v := range []viewport{v, zoomed} {
+			for y := 0; y < h; y++ {
+				for x := 1; x < w; x++ {
+					x0, y0 := v.at(x-1, y)
+					x1, y1 := v.at(x, y)
+					if !(x1 > x0) || y1 != y0 {
+						t.Fatalf("%+v: pixels %d and %d of row %d are at %v,%v and %v,%v", v, x-1, x, y, x0, y0, x1, y1)
+					}
+				}
+			}
+			for y := 1; y < h; y++ {
+				_, y0 := v.at(0, y-1)
+				_, y1 := v.at(0, y)
+				if !(y1 > y0) {
+					t.Fatalf("%+v: rows %d and %d are at %v and %v", v, y-1, y, y0, y1)
+				}
+			}
+
+			row := make([]float32, w)
+			v.sampleRow(row, 1, v.wide(w, h), p)
+			same := 0
+			for x := 1; x < w; x++ {
+				if row[x] == row[x-1] {
+					same++
+				}
+			}
+			if same > w/10 {
+				t.Errorf("%+v: %d of %d neighbouring pixels have the same value", v, same, w-1)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	// watchInterval is how often a watched file is checked
+	watchInterval = 50 * time.Millisecond
+	// watchDebounce is how long a watched file has to stay unchanged before
+	// it is reloaded. Editors often write a file in several steps, or save
+	// it by writing a new file and renaming it over the old one, and only
+	// the last of those is worth reading.
+	watchDebounce = 100 * time.Millisecond
+)
+
+// fileStamp is what a change to a file is told by. A missing file has the
+// zero stamp.
+type fileStamp struct {
+	exists  bool
+	modTime int64
+	size    int64
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{true, info.ModTime().UnixNano(), info.Size()}
+}
+
+// fileWatcher polls a file on a background goroutine and sends its path on
+// changes once it has settled for watchDebounce, so a parameters file being
+// edited elsewhere can be reloaded. Polling rather than file system
+// notifications keeps it to the standard library and works the same
+// everywhere. A change is not sent while the file is missing, and changes
+// the receiver has not picked up yet are merged into one.
+type fileWatcher struct {
+	path    string
+	changes chan string
+	done    chan struct{}
+}
+
+func watchFile(path string) *fileWatcher {
+	fw := &fileWatcher{
+		path:    path,
+		changes: make(chan string, 1),
+		done:    make(chan struct{}),
+	}
+	go fw.run(statFile(path))
+	return fw
+}
+
+func (fw *fileWatcher) run(last fileStamp) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	pending := false
+	var changedAt time.Time
+	for {
+		select {
+		case <-fw.done:
+			return
+		case now := <-ticker.C:
+			stamp := statFile(fw.path)
+			if stamp != last {
+				last = stamp
+				pending = true
+				changedAt = now
+				break
+			}
+			if !pending || !stamp.exists || now.Sub(changedAt) < watchDebounce {
+				break
+			}
+			pending = false
+			select {
+			case fw.changes <- fw.path:
+			default:
+			}
+		}
+	}
+}
+
+// stop ends the polling, a change not yet received is dropped. A nil
+// watcher has nothing to stop.
+func (fw *fileWatcher) stop() {
+	if fw != nil {
+		close(fw.done)
+	}
+}
+
+// reloads is the channel changes are sent on, nil for a nil watcher so
+// receiving from it never succeeds
+func (fw *fileWatcher) reloads() <-chan string {
+	if fw == nil {
+		return nil
+	}
+	return fw.changes
+}
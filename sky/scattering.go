@@ -0,0 +1,172 @@
+package main
+
+import "math"
+
+// vec3 is used for both directions and linear rgb radiance
+type vec3 struct {
+	x, y, z float64
+}
+
+func (a vec3) dot(b vec3) float64 {
+	return a.x*b.x + a.y*b.y + a.z*b.z
+}
+
+// direction returns the unit vector for an azimuth and an elevation in
+// radians, y is up and azimuth 0 looks along z
+func direction(azimuth, elevation float64) vec3 {
+	return vec3{math.Cos(elevation) * math.Sin(azimuth), math.Sin(elevation), math.Cos(elevation) * math.Cos(azimuth)}
+}
+
+// Rayleigh and Mie scattering coefficients at sea level in 1/km, for red,
+// green and blue at 680, 550 and 440nm, with the scale heights of their
+// particles in km
+var (
+	betaRayleigh = vec3{5.8e-3, 13.5e-3, 33.1e-3}
+	betaMie      = 21e-3
+)
+
+const (
+	rayleighHeight = 8.0
+	mieHeight      = 1.2
+	// mieG is the Henyey-Greenstein asymmetry, how strongly haze scatters forward
+	mieG           = 0.76
+	sunIntensity   = 20.0
+	sunAngularSize = 0.6 * math.Pi / 180
+)
+
+// airMass is the relative length of the path through the atmosphere towards
+// an elevation, using Kasten and Young's fit which stays finite at the horizon
+func airMass(elevation float64) float64 {
+	deg := elevation * 180 / math.Pi
+	if deg < 0 {
+		deg = 0
+	}
+	return 1 / (math.Sin(deg*math.Pi/180) + 0.50572*math.Pow(deg+6.07995, -1.6364))
+}
+
+func rayleighPhase(cosGamma float64) float64 {
+	return 3 / (16 * math.Pi) * (1 + cosGamma*cosGamma)
+}
+
+func miePhase(cosGamma float64) float64 {
+	g2 := mieG * mieG
+	return (1 - g2) / (4 * math.Pi * math.Pow(1+g2-2*mieG*cosGamma, 1.5))
+}
+
+// sunTransmittance is the fraction of sunlight of each channel that is left
+// after crossing the atmosphere at the given altitude, it is what turns the
+// sun orange and red near the horizon
+func sunTransmittance(altitude float64) vec3 {
+	m := airMass(altitude)
+	t := func(br float64) float64 {
+		return math.Exp(-(br*rayleighHeight + betaMie*mieHeight) * m)
+	}
+	return vec3{t(betaRayleigh.x), t(betaRayleigh.y), t(betaRayleigh.z)}
+}
+
+// scatteringSky is a single scattering approximation: sunlight dimmed along
+// its way in is scattered towards the viewer by air (Rayleigh, strongly
+// wavelength dependent and so blue) and haze (Mie, gray and forward), in
+// proportion to how much atmosphere the view ray crosses
+func scatteringSky(view vec3, elevation float64, sun vec3, altitude float64) vec3 {
+	cosGamma := view.dot(sun)
+	pr, pm := rayleighPhase(cosGamma), miePhase(cosGamma)
+	mv := airMass(elevation)
+	sunT := sunTransmittance(altitude)
+	channel := func(br, st float64) float64 {
+		r, m := br*rayleighHeight, betaMie*mieHeight
+		scatter := (r*pr + m*pm) / (r + m)
+		return sunIntensity * st * scatter * (1 - math.Exp(-(r+m)*mv))
+	}
+	return vec3{channel(betaRayleigh.x, sunT.x), channel(betaRayleigh.y, sunT.y), channel(betaRayleigh.z, sunT.z)}
+}
+
+// turbidity is how hazy the sky is for the Preetham model, 2 is very clear
+const turbidity = 2.5
+
+// Preetham sky model coefficients for the Perez distribution of luminance Y
+// and chromaticity x and y, each as a*turbidity + b
+var (
+	perezY  = [5][2]float64{{0.1787, -1.4630}, {-0.3554, 0.4275}, {-0.0227, 5.3251}, {0.1206, -2.5771}, {-0.0670, 0.3703}}
+	perezX  = [5][2]float64{{-0.0193, -0.2592}, {-0.0665, 0.0008}, {-0.0004, 0.2125}, {-0.0641, -0.8989}, {-0.0033, 0.0452}}
+	perezYc = [5][2]float64{{-0.0167, -0.2608}, {-0.0950, 0.0092}, {-0.0079, 0.2102}, {-0.0441, -1.6537}, {-0.0109, 0.0529}}
+
+	// zenith chromaticity polynomials, rows are turbidity^2, turbidity and
+	// 1, columns are the sun's zenith angle^3, ^2, ^1 and ^0
+	zenithX = [3][4]float64{
+		{0.00166, -0.00375, 0.00209, 0},
+		{-0.02903, 0.06377, -0.03202, 0.00394},
+		{0.11693, -0.21196, 0.06052, 0.25886},
+	}
+	zenithY = [3][4]float64{
+		{0.00275, -0.00610, 0.00317, 0},
+		{-0.04214, 0.08970, -0.04153, 0.00516},
+		{0.15346, -0.26756, 0.06670, 0.26688},
+	}
+)
+
+// preethamExposure scales the model's luminance, in kcd/m2, before tone mapping
+const preethamExposure = 0.06
+
+func perezCoefficients(table [5][2]float64) [5]float64 {
+	var result [5]float64
+	for i, c := range table {
+		result[i] = c[0]*turbidity + c[1]
+	}
+	return result
+}
+
+// perez is the Perez sky distribution for a view at zenith angle theta and
+// angle gamma from the sun
+func perez(c [5]float64, theta, gamma float64) float64 {
+	cosTheta := math.Max(math.Cos(theta), 0.01)
+	cosGamma := math.Cos(gamma)
+	return (1 + c[0]*math.Exp(c[1]/cosTheta)) * (1 + c[2]*math.Exp(c[3]*gamma) + c[4]*cosGamma*cosGamma)
+}
+
+func zenithChromaticity(table [3][4]float64, thetaS float64) float64 {
+	t := [3]float64{turbidity * turbidity, turbidity, 1}
+	s := [4]float64{thetaS * thetaS * thetaS, thetaS * thetaS, thetaS, 1}
+	var sum float64
+	for i := range table {
+		for j := range table[i] {
+			sum += t[i] * table[i][j] * s[j]
+		}
+	}
+	return sum
+}
+
+// preethamSky evaluates the Preetham analytic daylight model and converts
+// its Yxy result to linear rgb
+func preethamSky(view vec3, elevation float64, sun vec3, altitude float64) vec3 {
+	theta := math.Pi/2 - math.Max(elevation, 0)
+	thetaS := math.Pi/2 - altitude
+	gamma := math.Acos(math.Max(-1, math.Min(1, view.dot(sun))))
+
+	chi := (4.0/9.0 - turbidity/120) * (math.Pi - 2*thetaS)
+	zenithLum := (4.0453*turbidity-4.9710)*math.Tan(chi) - 0.2155*turbidity + 2.4192
+	cY, cx, cy := perezCoefficients(perezY), perezCoefficients(perezX), perezCoefficients(perezYc)
+
+	Y := zenithLum * perez(cY, theta, gamma) / perez(cY, 0, thetaS)
+	x := zenithChromaticity(zenithX, thetaS) * perez(cx, theta, gamma) / perez(cx, 0, thetaS)
+	y := zenithChromaticity(zenithY, thetaS) * perez(cy, theta, gamma) / perez(cy, 0, thetaS)
+	Y = math.Max(Y, 0) * preethamExposure
+
+	X := x * Y / y
+	Z := (1 - x - y) * Y / y
+	return vec3{
+		3.2406*X - 1.5372*Y - 0.4986*Z,
+		-0.9689*X + 1.8758*Y + 0.0415*Z,
+		0.0557*X - 0.2040*Y + 1.0570*Z,
+	}
+}
+
+// toneMap compresses linear radiance into 0-255 with an exponential curve
+// and srgb gamma
+func toneMap(v float64) byte {
+	if v < 0 {
+		v = 0
+	}
+	v = 1 - math.Exp(-v)
+	return byte(math.Pow(v, 1/2.2)*255 + 0.5)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sabith-th/games_with_go/noise"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 400
+
+const (
+	skyHeight     = 200
+	terrainHeight = winHeight - skyHeight
+	// the sky strip spans this much azimuth across and elevation up from
+	// the horizon, with the sun centred horizontally. Both axes share one
+	// scale so the sun disk stays round.
+	horizontalFOV = 120 * math.Pi / 180
+	verticalFOV   = horizontalFOV * skyHeight / float64(winWidth)
+)
+
+type color struct {
+	r, g, b byte
+}
+
+type skyModel int
+
+const (
+	scattering skyModel = iota
+	preetham
+	numModels
+)
+
+var modelNames = [numModels]string{"rayleigh/mie scattering", "preetham"}
+
+// drawSky fills the top skyHeight rows for a sun at altitude degrees
+func drawSky(model skyModel, altitude float64, pixels []byte) {
+	alt := altitude * math.Pi / 180
+	sun := direction(0, alt)
+	sunT := sunTransmittance(alt)
+	cosSun := math.Cos(sunAngularSize)
+	for y := 0; y < skyHeight; y++ {
+		elevation := (1 - float64(y)/float64(skyHeight-1)) * verticalFOV
+		for x := 0; x < winWidth; x++ {
+			azimuth := (float64(x)/float64(winWidth-1) - 0.5) * horizontalFOV
+			view := direction(azimuth, elevation)
+			var c vec3
+			if model == preetham {
+				c = preethamSky(view, elevation, sun, alt)
+			} else {
+				c = scatteringSky(view, elevation, sun, alt)
+			}
+			if view.dot(sun) > cosSun {
+				c = vec3{c.x + sunIntensity*sunT.x, c.y + sunIntensity*sunT.y, c.z + sunIntensity*sunT.z}
+			}
+			index := (y*winWidth + x) * 4
+			pixels[index] = toneMap(c.x)
+			pixels[index+1] = toneMap(c.y)
+			pixels[index+2] = toneMap(c.z)
+		}
+	}
+}
+
+func lerp(b1, b2 byte, pct float32) byte {
+	return byte(float32(b1) + pct*(float32(b2)-float32(b1)))
+}
+
+func colorlerp(c1, c2 color, pct float32) color {
+	return color{lerp(c1.r, c2.r, pct), lerp(c1.g, c2.g, pct), lerp(c1.b, c2.b, pct)}
+}
+
+func getGradient(c1, c2 color) []color {
+	result := make([]color, 256)
+	for i := range result {
+		pct := float32(i) / float32(255)
+		result[i] = colorlerp(c1, c2, pct)
+	}
+	return result
+}
+
+// makeTerrain returns the unlit terrain colors below the horizon. Rows
+// further down are nearer, so the noise is stretched less there.
+func makeTerrain() []color {
+	field, min, max := noise.MakeNoise(noise.FBM, 0.01, 2.0, 0.5, 5, winWidth, terrainHeight)
+	gradient := getGradient(color{30, 60, 25}, color{120, 110, 70})
+	terrain := make([]color, len(field))
+	scale := 255 / (max - min)
+	for i, v := range field {
+		terrain[i] = gradient[int((v-min)*scale)&255]
+	}
+	return terrain
+}
+
+// drawTerrain lights the terrain with the sun's color after its way through
+// the atmosphere plus a little sky light, so it darkens and reddens at dusk
+func drawTerrain(terrain []color, altitude float64, pixels []byte) {
+	alt := altitude * math.Pi / 180
+	sunT := sunTransmittance(alt)
+	direct := math.Max(math.Sin(alt), 0)
+	const ambient = 0.25
+	light := vec3{ambient + direct*sunT.x, ambient + direct*sunT.y, ambient + direct*sunT.z}
+	shade := func(v byte, l float64) byte {
+		return byte(math.Min(255, float64(v)*l*1.3))
+	}
+	for i, c := range terrain {
+		index := (skyHeight*winWidth + i) * 4
+		pixels[index] = shade(c.r, light.x)
+		pixels[index+1] = shade(c.g, light.y)
+		pixels[index+2] = shade(c.b, light.z)
+	}
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Sky", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	terrain := makeTerrain()
+	altitude := 20.0
+	model := scattering
+	dirty := true
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				// key repeat is welcome here, holding U or D sweeps the sun
+				if e.Type != sdl.KEYDOWN {
+					continue
+				}
+				switch e.Keysym.Scancode {
+				case sdl.SCANCODE_U:
+					altitude = math.Min(90, altitude+1)
+				case sdl.SCANCODE_D:
+					altitude = math.Max(0, altitude-1)
+				case sdl.SCANCODE_M:
+					if e.Repeat == 0 {
+						model = (model + 1) % numModels
+						fmt.Println(modelNames[model])
+					}
+				}
+				dirty = true
+			}
+		}
+
+		if dirty {
+			drawSky(model, altitude, pixels)
+			drawTerrain(terrain, altitude, pixels)
+			dirty = false
+		}
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}
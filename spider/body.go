@@ -0,0 +1,162 @@
+package main
+
+import (
+	"math"
+
+	"github.com/sabith-th/games_with_go/ik"
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+const (
+	maxIterations = 10
+	// bodyHeight is how far the body rides above the average foot
+	bodyHeight = 60
+	// a planted foot further than stride from under its hip lifts, and lands
+	// stride ahead of where the hip will be once the swing is over
+	stride        = 30
+	swingDuration = 0.25
+	liftHeight    = 25
+	maxLifted     = 2
+	// bobSmoothing is how quickly the body follows the feet, per second
+	bobSmoothing = 8
+)
+
+var legLengths = []float32{45, 50, 45}
+
+// leg is one chain and the state of its foot. home is where the foot rests
+// relative to the body, far legs are the ones on the other side.
+type leg struct {
+	chain *ik.Chain
+	hip   float32
+	home  float32
+	far   bool
+
+	foot       vector2.Vector2
+	lifted     bool
+	swingTime  float32
+	swingStart vector2.Vector2
+	swingEnd   vector2.Vector2
+}
+
+// SpiderBody walks along ground on four three-link legs, two on each side.
+// Nothing is keyframed: a foot stays planted until the body has carried its
+// hip a stride past it, then swings to where the hip is headed.
+type SpiderBody struct {
+	pos    vector2.Vector2
+	speed  float32
+	ground func(x float32) float32
+	legs   []*leg
+}
+
+func newSpiderBody(x float32, ground func(x float32) float32) *SpiderBody {
+	s := &SpiderBody{pos: vector2.Vector2{X: x, Y: ground(x) - bodyHeight}, ground: ground}
+	// near and far legs are offset a little so they do not hide each other,
+	// and the feet start spread over a stride so the steps are staggered
+	homes := []struct {
+		hip, home, start float32
+		far              bool
+	}{
+		{12, 55, 15, false},
+		{-12, -40, 0, false},
+		{12, 45, -20, true},
+		{-12, -50, -8, true},
+	}
+	for _, h := range homes {
+		l := &leg{hip: h.hip, home: h.home, far: h.far}
+		fx := x + h.home + h.start
+		l.foot = vector2.Vector2{X: fx, Y: ground(fx)}
+		l.chain = ik.NewChain(s.hipPos(l), legLengths, vector2.Vector2{X: 0, Y: 1})
+		s.legs = append(s.legs, l)
+	}
+	return s
+}
+
+func (s *SpiderBody) hipPos(l *leg) vector2.Vector2 {
+	return vector2.Vector2{X: s.pos.X + l.hip, Y: s.pos.Y}
+}
+
+func (s *SpiderBody) numLifted() int {
+	n := 0
+	for _, l := range s.legs {
+		if l.lifted {
+			n++
+		}
+	}
+	return n
+}
+
+// canLift keeps the gait staggered: at most maxLifted feet are up at once
+// and never both feet on one side
+func (s *SpiderBody) canLift(l *leg) bool {
+	if s.numLifted() >= maxLifted {
+		return false
+	}
+	for _, other := range s.legs {
+		if other != l && other.lifted && other.far == l.far {
+			return false
+		}
+	}
+	return true
+}
+
+// update moves the body dt seconds along and steps the legs
+func (s *SpiderBody) update(dt float32) {
+	s.pos.X += s.speed * dt
+
+	for _, l := range s.legs {
+		if l.lifted {
+			l.swingTime += dt
+			t := l.swingTime / swingDuration
+			if t >= 1 {
+				l.foot = l.swingEnd
+				l.lifted = false
+				continue
+			}
+			l.foot = vector2.Lerp(l.swingStart, l.swingEnd, t)
+			l.foot.Y -= liftHeight * float32(math.Sin(math.Pi*float64(t)))
+			continue
+		}
+		home := s.pos.X + l.home
+		if float32(math.Abs(float64(l.foot.X-home))) > stride && s.canLift(l) {
+			// aim for where home will be when the foot comes down, plus
+			// a stride so it spends the next step drifting back past it
+			x := home + s.speed*swingDuration + stride
+			if s.speed < 0 {
+				x = home + s.speed*swingDuration - stride
+			}
+			l.lifted = true
+			l.swingTime = 0
+			l.swingStart = l.foot
+			l.swingEnd = vector2.Vector2{X: x, Y: s.ground(x)}
+		}
+	}
+
+	// the body settles towards its height above the average foot, which
+	// makes it bob as feet lift and land on uneven ground
+	var avg float32
+	for _, l := range s.legs {
+		avg += l.foot.Y
+	}
+	avg /= float32(len(s.legs))
+	target := avg - bodyHeight
+	s.pos.Y += (target - s.pos.Y) * float32(math.Min(1, float64(bobSmoothing*dt)))
+
+	for _, l := range s.legs {
+		s.solve(l)
+	}
+}
+
+// solve bends the leg from its hip to its foot. The joints are put back in
+// a raised pose first, with the knee up and the ankle out in front of the
+// foot, so FABRIK always bends the leg the same way.
+func (s *SpiderBody) solve(l *leg) {
+	joints := l.chain.Joints
+	side := float32(1)
+	if l.home < 0 {
+		side = -1
+	}
+	joints[0] = s.hipPos(l)
+	joints[1] = vector2.Add(joints[0], vector2.Mult(vector2.Normalize(vector2.Vector2{X: side, Y: -1}), legLengths[0]))
+	joints[2] = vector2.Add(joints[1], vector2.Mult(vector2.Normalize(vector2.Vector2{X: side, Y: 0.5}), legLengths[1]))
+	ik.FABRIK(l.chain, l.foot, maxIterations)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sabith-th/games_with_go/vector2"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	// the camera keeps the body this far from the left edge
+	cameraX = 300
+
+	walkSpeedStep = 20
+	maxWalkSpeed  = 240
+	frameTime     = 1.0 / 60
+)
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	skyColor    = color{15, 15, 25}
+	groundColor = color{60, 50, 45}
+	nearColor   = color{220, 220, 210}
+	farColor    = color{110, 110, 105}
+	bodyColor   = color{40, 10, 20}
+	eyeColor    = color{230, 30, 30}
+)
+
+// groundHeight is the surface under world position x, a few sines of
+// different lengths so it never quite repeats
+func groundHeight(x float32) float32 {
+	fx := float64(x)
+	return float32(470 + 25*math.Sin(fx*0.011) + 12*math.Sin(fx*0.037+1) + 5*math.Sin(fx*0.09))
+}
+
+func setPixel(x, y int, c color, pixels []byte) {
+	if x < 0 || x >= winWidth || y < 0 || y >= winHeight {
+		return
+	}
+	index := (y*winWidth + x) * 4
+	pixels[index] = c.r
+	pixels[index+1] = c.g
+	pixels[index+2] = c.b
+}
+
+// drawLine draws a line between two points with Bresenham's algorithm
+func drawLine(a, b vector2.Vector2, c color, pixels []byte) {
+	x0, y0 := int(a.X), int(a.Y)
+	x1, y1 := int(b.X), int(b.Y)
+	dx := x1 - x0
+	if dx < 0 {
+		dx = -dx
+	}
+	dy := y1 - y0
+	if dy > 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		setPixel(x0, y0, c, pixels)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func drawCircle(centre vector2.Vector2, radius int, c color, pixels []byte) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				setPixel(int(centre.X)+x, int(centre.Y)+y, c, pixels)
+			}
+		}
+	}
+}
+
+// drawScene draws the ground and the spider with the world scrolled so the
+// body stays at cameraX. The far legs go first so the body and near legs
+// cover them.
+func drawScene(s *SpiderBody, pixels []byte) {
+	scroll := s.pos.X - cameraX
+	toScreen := func(p vector2.Vector2) vector2.Vector2 {
+		return vector2.Vector2{X: p.X - scroll, Y: p.Y}
+	}
+
+	for x := 0; x < winWidth; x++ {
+		top := int(groundHeight(float32(x) + scroll))
+		for y := 0; y < winHeight; y++ {
+			c := skyColor
+			if y >= top {
+				c = groundColor
+			}
+			setPixel(x, y, c, pixels)
+		}
+	}
+
+	for _, far := range []bool{true, false} {
+		c := nearColor
+		if far {
+			c = farColor
+		}
+		for _, l := range s.legs {
+			if l.far != far {
+				continue
+			}
+			joints := l.chain.Joints
+			for i := 1; i < len(joints); i++ {
+				drawLine(toScreen(joints[i-1]), toScreen(joints[i]), c, pixels)
+			}
+		}
+		if far {
+			body := toScreen(s.pos)
+			drawCircle(body, 18, bodyColor, pixels)
+			drawCircle(vector2.Add(body, vector2.Vector2{X: 12, Y: -4}), 2, eyeColor, pixels)
+			drawCircle(vector2.Add(body, vector2.Vector2{X: 6, Y: -6}), 2, eyeColor, pixels)
+		}
+	}
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Spider", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	s := newSpiderBody(cameraX, groundHeight)
+	s.speed = 80
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN {
+					switch e.Keysym.Scancode {
+					case sdl.SCANCODE_EQUALS, sdl.SCANCODE_KP_PLUS:
+						s.speed = float32(math.Min(maxWalkSpeed, float64(s.speed+walkSpeedStep)))
+						fmt.Println("walk speed", s.speed)
+					case sdl.SCANCODE_MINUS, sdl.SCANCODE_KP_MINUS:
+						s.speed = float32(math.Max(-maxWalkSpeed, float64(s.speed-walkSpeedStep)))
+						fmt.Println("walk speed", s.speed)
+					}
+				}
+			}
+		}
+
+		s.update(frameTime)
+		drawScene(s, pixels)
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}
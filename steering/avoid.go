@@ -0,0 +1,81 @@
+package steering
+
+import "github.com/sabith-th/games_with_go/vector2"
+
+// AABB is an axis aligned box from Min to Max, an obstacle
+type AABB struct {
+	Min, Max Vec2
+}
+
+func (b AABB) center() Vec2 {
+	return vector2.Lerp(b.Min, b.Max, 0.5)
+}
+
+func (b AABB) contains(p Vec2) bool {
+	return p.X >= b.Min.X && p.X <= b.Max.X && p.Y >= b.Min.Y && p.Y <= b.Max.Y
+}
+
+// hit is how far along the segment from p, p+d the segment enters b, 0 to
+// 1, and whether it does at all. Each pair of sides cuts the segment to
+// where it is between them, it hits if anything is left.
+func (b AABB) hit(p, d Vec2) (float32, bool) {
+	enter, leave := float32(0), float32(1)
+	slab := func(p, d, min, max float32) bool {
+		if d == 0 {
+			return p >= min && p <= max
+		}
+		t0, t1 := (min-p)/d, (max-p)/d
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > enter {
+			enter = t0
+		}
+		if t1 < leave {
+			leave = t1
+		}
+		return enter <= leave
+	}
+	if !slab(p.X, d.X, b.Min.X, b.Max.X) || !slab(p.Y, d.Y, b.Min.Y, b.Max.Y) {
+		return 0, false
+	}
+	return enter, true
+}
+
+// AvoidObstacles steers away from the nearest obstacle the agent would run
+// into within lookAhead along velocity: sideways, away from the side of
+// the line the obstacle's middle is on, harder the closer it is, up to the
+// agent's speed. An agent already inside an obstacle is pushed straight out
+// from its middle. With nothing ahead it returns zero.
+func AvoidObstacles(agent, velocity Vec2, obstacles []AABB, lookAhead float32) Vec2 {
+	speed := velocity.Length()
+	for _, o := range obstacles {
+		if o.contains(agent) {
+			return toward(o.center(), agent, speed)
+		}
+	}
+	if speed == 0 || lookAhead <= 0 {
+		return Vec2{}
+	}
+
+	heading := vector2.Normalize(velocity)
+	ray := vector2.Mult(heading, lookAhead)
+	nearest, found := float32(1), false
+	var obstacle AABB
+	for _, o := range obstacles {
+		if t, ok := o.hit(agent, ray); ok && t <= nearest {
+			nearest, found, obstacle = t, true, o
+		}
+	}
+	if !found {
+		return Vec2{}
+	}
+
+	// side is a quarter turn from the heading, turned round if the
+	// obstacle's middle is on that side. One dead ahead keeps the first.
+	side := Vec2{X: -heading.Y, Y: heading.X}
+	if vector2.Cross(heading, vector2.Sub(obstacle.center(), agent)) > 0 {
+		side = vector2.Mult(side, -1)
+	}
+	return vector2.Mult(side, speed*(1-nearest))
+}
@@ -0,0 +1,99 @@
+// Package steering is Reynolds' steering behaviours for game agents. Each
+// behaviour returns the velocity the agent would like to have, the game
+// moves the agent's velocity towards it as fast as the agent can turn and
+// speed up. Behaviours are mixed with CombinedBehavior.
+package steering
+
+import (
+	"math"
+
+	"github.com/sabith-th/games_with_go/noise"
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+// Vec2 is a position or velocity in the game's units
+type Vec2 = vector2.Vector2
+
+const (
+	// wanderTurn is how fast, in radians a second, a wandering agent turns
+	// at most
+	wanderTurn = 3
+	// wanderScale spreads the noise a wander turns by over the world, an
+	// agent has to walk about 1/wanderScale to turn another way
+	wanderScale = 0.01
+)
+
+// toward is maxSpeed along from-to, nothing if the two are the same
+func toward(from, to Vec2, maxSpeed float32) Vec2 {
+	d := vector2.Sub(to, from)
+	if d.X == 0 && d.Y == 0 {
+		return Vec2{}
+	}
+	return vector2.Mult(vector2.Normalize(d), maxSpeed)
+}
+
+// Seek heads straight for target at maxSpeed, overshooting it and coming
+// back
+func Seek(agent, target Vec2, maxSpeed float32) Vec2 {
+	return toward(agent, target, maxSpeed)
+}
+
+// Flee heads straight away from target at maxSpeed
+func Flee(agent, target Vec2, maxSpeed float32) Vec2 {
+	return toward(target, agent, maxSpeed)
+}
+
+// Arrive heads for target, at maxSpeed until within slowRadius of it and
+// then slower the closer it gets, so it stops on target
+func Arrive(agent, target Vec2, slowRadius, maxSpeed float32) Vec2 {
+	d := vector2.Distance(agent, target)
+	if d < slowRadius {
+		maxSpeed *= d / slowRadius
+	}
+	return toward(agent, target, maxSpeed)
+}
+
+// Pursue heads for where target will be if it keeps going at
+// targetVelocity, looking as far ahead as the agent would take to get to
+// target now
+func Pursue(agent, target, targetVelocity Vec2, maxSpeed float32) Vec2 {
+	var ahead float32
+	if maxSpeed > 0 {
+		ahead = vector2.Distance(agent, target) / maxSpeed
+	}
+	return Seek(agent, vector2.Add(target, vector2.Mult(targetVelocity, ahead)), maxSpeed)
+}
+
+// Wander turns *wanderAngle a little over dt seconds and returns the unit
+// vector it points along, for the agent's speed to scale. The turn comes
+// from simplex noise at agent and the angle, so a wander is smooth and two
+// agents in different places go different ways.
+func Wander(agent Vec2, wanderAngle *float32, dt float32) Vec2 {
+	turn := noise.Simplex{}.At(agent.X*wanderScale+*wanderAngle, agent.Y*wanderScale)
+	if turn < -1 {
+		turn = -1
+	} else if turn > 1 {
+		turn = 1
+	}
+	*wanderAngle = float32(math.Remainder(float64(*wanderAngle+turn*wanderTurn*dt), 2*math.Pi))
+	s, c := math.Sincos(float64(*wanderAngle))
+	return Vec2{X: float32(c), Y: float32(s)}
+}
+
+// WeightedBehavior is what one behaviour returned and how much of it to
+// take
+type WeightedBehavior struct {
+	Steering Vec2
+	Weight   float32
+}
+
+// CombinedBehavior is the weighted sum of behaviors. Behaviours that have
+// nothing to say, an AvoidObstacles with nothing ahead, return zero and
+// leave the others as they are.
+func CombinedBehavior(behaviors []WeightedBehavior) Vec2 {
+	var sum Vec2
+	for _, b := range behaviors {
+		sum = vector2.Add(sum, vector2.Mult(b.Steering, b.Weight))
+	}
+	return sum
+}
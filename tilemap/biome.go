@@ -0,0 +1,149 @@
+package tilemap
+
+import "github.com/sabith-th/games_with_go/noise"
+
+const (
+	// climateFrequency scales tile positions before sampling temperature
+	// and moisture. It is well below frequency, so a biome spans many
+	// islands.
+	climateFrequency = 0.008
+	// blendWidth is how much of the unit square of (temperature, moisture)
+	// around each biome boundary is blended, in cells of the biome table.
+	// At 1 the blending never stops, at 0 the borders are hard.
+	blendWidth = 0.5
+)
+
+// the climate fields are two permutations of their own, so they do not
+// line up with the elevation or with each other
+var (
+	temperaturePerm = noise.NewPerm(1)
+	moisturePerm    = noise.NewPerm(2)
+)
+
+// Biome is the climate zone of a land tile
+type Biome uint8
+
+const (
+	Tundra Biome = iota
+	Taiga
+	Grassland
+	Shrubland
+	TemperateForest
+	TemperateRainforest
+	Desert
+	Savanna
+	TropicalForest
+	TropicalRainforest
+)
+
+var biomeColors = [...]color{
+	Tundra:              {190, 200, 190},
+	Taiga:               {60, 110, 80},
+	Grassland:           {140, 180, 80},
+	Shrubland:           {160, 160, 90},
+	TemperateForest:     {50, 130, 50},
+	TemperateRainforest: {30, 110, 60},
+	Desert:              {225, 200, 140},
+	Savanna:             {190, 180, 90},
+	TropicalForest:      {70, 140, 30},
+	TropicalRainforest:  {20, 100, 30},
+}
+
+// whittaker is the Whittaker diagram as a table, whittaker[temperature]
+// [moisture] from cold to hot and from dry to wet
+var whittaker = [4][4]Biome{
+	{Tundra, Tundra, Taiga, Taiga},
+	{Grassland, Shrubland, TemperateForest, TemperateForest},
+	{Desert, Grassland, TemperateForest, TemperateRainforest},
+	{Desert, Savanna, TropicalForest, TropicalRainforest},
+}
+
+// BiomeBlend is how much of up to four biomes a tile is: Weights[i] of
+// Biomes[i], the weights sum to 1. Away from any boundary a tile is all
+// one biome, the others weigh 0.
+type BiomeBlend struct {
+	Biomes  [4]Biome
+	Weights [4]float32
+}
+
+// Dominant is the biome that weighs the most
+func (b BiomeBlend) Dominant() Biome {
+	best := 0
+	for i, w := range b.Weights {
+		if w > b.Weights[best] {
+			best = i
+		}
+	}
+	return b.Biomes[best]
+}
+
+func (b BiomeBlend) color() color {
+	var r, g, bl float32
+	for i, w := range b.Weights {
+		c := biomeColors[b.Biomes[i]]
+		r += float32(c.r) * w
+		g += float32(c.g) * w
+		bl += float32(c.b) * w
+	}
+	return color{byte(r + 0.5), byte(g + 0.5), byte(bl + 0.5)}
+}
+
+// climate is the noise of perm at tile x, y taken to 0..1
+func climate(perm *noise.Perm, x, y int) float32 {
+	v := noise.Simplex{Perm: perm}.At(float32(x)*climateFrequency, float32(y)*climateFrequency)
+	return clamp01(v*0.5 + 0.5)
+}
+
+func clamp01(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// axis splits v, 0..1 along one side of the table, into the two rows or
+// columns it blends and how much the second one weighs. A cell's middle
+// is all that cell, the weight ramps up linearly over the blendWidth
+// either side of the boundary and is a half on it.
+func axis(v float32) (int, int, float32) {
+	n := len(whittaker)
+	// u is 0 in the middle of cell 0 and 1 in the middle of cell 1
+	u := v*float32(n) - 0.5
+	i := int(u)
+	if u < 0 {
+		i = -1
+	}
+	f := u - float32(i)
+	w := clamp01((f - (1-blendWidth)/2) / blendWidth)
+	lo, hi := i, i+1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > n-1 {
+		hi = n - 1
+	}
+	return lo, hi, w
+}
+
+// blendAt is the biome blend at temperature t and moisture m, both 0..1
+func blendAt(t, m float32) BiomeBlend {
+	t0, t1, wt := axis(t)
+	m0, m1, wm := axis(m)
+	return BiomeBlend{
+		Biomes: [4]Biome{whittaker[t0][m0], whittaker[t0][m1], whittaker[t1][m0], whittaker[t1][m1]},
+		Weights: [4]float32{
+			(1 - wt) * (1 - wm),
+			(1 - wt) * wm,
+			wt * (1 - wm),
+			wt * wm,
+		},
+	}
+}
+
+// biomeAt is the biome blend of tile x, y
+func biomeAt(x, y int) BiomeBlend {
+	return blendAt(climate(temperaturePerm, x, y), climate(moisturePerm, x, y))
+}
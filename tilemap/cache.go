@@ -0,0 +1,48 @@
+package tilemap
+
+import "container/list"
+
+// cachedChunk is an entry of a chunkCache's list
+type cachedChunk struct {
+	key   chunkKey
+	chunk *Chunk
+}
+
+// chunkCache keeps the size chunks used most recently. The list runs from
+// the most recently used chunk at the front to the least at the back, which
+// is the one dropped to make room.
+type chunkCache struct {
+	size    int
+	order   *list.List
+	entries map[chunkKey]*list.Element
+}
+
+func newChunkCache(size int) *chunkCache {
+	return &chunkCache{size: size, order: list.New(), entries: make(map[chunkKey]*list.Element, size)}
+}
+
+// get returns the chunk at key and marks it as just used
+func (c *chunkCache) get(key chunkKey) (*Chunk, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cachedChunk).chunk, true
+}
+
+// add caches chunk at key, dropping the least recently used chunk if the
+// cache is full
+func (c *chunkCache) add(key chunkKey, chunk *Chunk) {
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cachedChunk).chunk = chunk
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedChunk).key)
+	}
+	c.entries[key] = c.order.PushFront(&cachedChunk{key, chunk})
+}
@@ -0,0 +1,204 @@
+package tilemap
+
+import "github.com/sabith-th/games_with_go/noise"
+
+// ChunkSize is the width and height of a chunk in tiles
+const ChunkSize = 16
+
+const (
+	// frequency scales tile positions before sampling the noise, lower
+	// values give larger continents
+	frequency = 0.05
+	// DefaultCacheSize is how many chunks NewChunkManager keeps
+	DefaultCacheSize = 128
+)
+
+// TileType is the terrain of one tile
+type TileType uint8
+
+const (
+	DeepWater TileType = iota
+	ShallowWater
+	Sand
+	Grass
+	Forest
+	Mountain
+)
+
+type color struct {
+	r, g, b byte
+}
+
+var tileColors = [...]color{
+	DeepWater:    {20, 40, 120},
+	ShallowWater: {50, 100, 190},
+	Sand:         {210, 195, 130},
+	Grass:        {80, 160, 60},
+	Forest:       {30, 95, 40},
+	Mountain:     {130, 125, 120},
+}
+
+// tileFor is the terrain at noise value v, from deep water in the troughs
+// to mountains on the peaks
+func tileFor(v float32) TileType {
+	switch {
+	case v < -0.4:
+		return DeepWater
+	case v < -0.1:
+		return ShallowWater
+	case v < 0:
+		return Sand
+	case v < 0.4:
+		return Grass
+	case v < 0.7:
+		return Forest
+	default:
+		return Mountain
+	}
+}
+
+// Chunk is a ChunkSize*ChunkSize block of tiles, Tiles[y][x]. The terrain
+// comes from the elevation alone, Biomes[y][x] is the climate of the same
+// tile, which is what colors the land between the shore and the mountains.
+type Chunk struct {
+	Tiles  [ChunkSize][ChunkSize]TileType
+	Biomes [ChunkSize][ChunkSize]BiomeBlend
+}
+
+// color is the color of tile x, y of c. Water and mountains have their
+// own colors, the rest of the land is its biomes blended, so it changes
+// smoothly from one climate to the next.
+func (c *Chunk) color(x, y int) color {
+	switch t := c.Tiles[y][x]; t {
+	case DeepWater, ShallowWater, Mountain:
+		return tileColors[t]
+	default:
+		return c.Biomes[y][x].color()
+	}
+}
+
+// chunkKey is the position of a chunk in chunks, tile x, y is in chunk
+// x/ChunkSize, y/ChunkSize rounded down
+type chunkKey struct {
+	cx, cy int
+}
+
+// ChunkManager hands out the chunks of an endless map. The map is made up
+// as it is looked at, every chunk is worked out from the noise at its tiles
+// alone, so chunks can be made in any order and one that fell out of the
+// cache comes back the same.
+type ChunkManager struct {
+	cache *chunkCache
+}
+
+// NewChunkManager returns a manager that keeps the DefaultCacheSize chunks
+// used most recently
+func NewChunkManager() *ChunkManager {
+	return &ChunkManager{cache: newChunkCache(DefaultCacheSize)}
+}
+
+// GenerateChunk works out chunk cx, cy from the noise, without the cache
+func (m *ChunkManager) GenerateChunk(cx, cy int) *Chunk {
+	c := &Chunk{}
+	for y := 0; y < ChunkSize; y++ {
+		for x := 0; x < ChunkSize; x++ {
+			tx, ty := cx*ChunkSize+x, cy*ChunkSize+y
+			v := noise.Simplex{}.At(float32(tx)*frequency, float32(ty)*frequency)
+			c.Tiles[y][x] = tileFor(v)
+			c.Biomes[y][x] = biomeAt(tx, ty)
+		}
+	}
+	return c
+}
+
+// Chunk is chunk cx, cy, from the cache if it is there and generated and
+// cached otherwise
+func (m *ChunkManager) Chunk(cx, cy int) *Chunk {
+	key := chunkKey{cx, cy}
+	c, ok := m.cache.get(key)
+	if !ok {
+		c = m.GenerateChunk(cx, cy)
+		m.cache.add(key, c)
+	}
+	return c
+}
+
+// Tile is the terrain of tile x, y
+func (m *ChunkManager) Tile(x, y int) TileType {
+	cx, tx := floorDiv(x, ChunkSize)
+	cy, ty := floorDiv(y, ChunkSize)
+	return m.Chunk(cx, cy).Tiles[ty][tx]
+}
+
+// Biome is the biome blend of tile x, y
+func (m *ChunkManager) Biome(x, y int) BiomeBlend {
+	cx, tx := floorDiv(x, ChunkSize)
+	cy, ty := floorDiv(y, ChunkSize)
+	return m.Chunk(cx, cy).Biomes[ty][tx]
+}
+
+// floorDiv is a/b rounded down and the remainder, which stays within 0..b-1
+// for negative a too
+func floorDiv(a, b int) (int, int) {
+	q, r := a/b, a%b
+	if r < 0 {
+		q--
+		r += b
+	}
+	return q, r
+}
+
+// Camera is the part of the map shown: X, Y is the map pixel drawn in the
+// top left corner of a Width*Height view, every tile TileSize pixels wide
+// and high
+type Camera struct {
+	X, Y          int
+	Width, Height int
+	TileSize      int
+}
+
+// RenderVisibleChunks draws every tile cam sees into pixels, a
+// cam.Width*cam.Height ABGR8888 buffer. Only the chunks the view overlaps
+// are looked up, each once per frame.
+func (m *ChunkManager) RenderVisibleChunks(cam Camera, pixels []byte) {
+	size := cam.TileSize
+	chunkPixels := ChunkSize * size
+	firstX, _ := floorDiv(cam.X, chunkPixels)
+	firstY, _ := floorDiv(cam.Y, chunkPixels)
+	lastX, _ := floorDiv(cam.X+cam.Width-1, chunkPixels)
+	lastY, _ := floorDiv(cam.Y+cam.Height-1, chunkPixels)
+
+	for cy := firstY; cy <= lastY; cy++ {
+		for cx := firstX; cx <= lastX; cx++ {
+			chunk := m.Chunk(cx, cy)
+			// the chunk's top left corner on screen, it may start above or
+			// left of the view
+			sx, sy := cx*chunkPixels-cam.X, cy*chunkPixels-cam.Y
+			x0, x1 := clip(sx, chunkPixels, cam.Width)
+			y0, y1 := clip(sy, chunkPixels, cam.Height)
+			for y := y0; y < y1; y++ {
+				ty := (y - sy) / size
+				index := y * cam.Width * 4
+				for x := x0; x < x1; x++ {
+					c := chunk.color((x-sx)/size, ty)
+					i := index + x*4
+					pixels[i] = c.r
+					pixels[i+1] = c.g
+					pixels[i+2] = c.b
+				}
+			}
+		}
+	}
+}
+
+// clip is the part of start..start+length that is within 0..limit
+func clip(start, length, limit int) (int, int) {
+	lo, hi := start, start+length
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > limit {
+		hi = limit
+	}
+	return lo, hi
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sabith-th/games_with_go/bezier"
+	"github.com/sabith-th/games_with_go/tilemap"
+	"github.com/sabith-th/games_with_go/vector2"
+)
+
+// waypointInterval is how often a recording keeps where the camera is, in
+// seconds. Playback takes as long between waypoints, so the path is flown
+// at the pace it was recorded.
+const waypointInterval = 1
+
+// cameraPath records the camera's waypoints and flies the camera along a
+// closed Catmull-Rom spline through them, over and over, for flythroughs of
+// the map
+type cameraPath struct {
+	spline    bezier.CatmullRomSpline
+	recording bool
+	playing   bool
+	// elapsed is the time since the last waypoint while recording and the
+	// time into the loop while playing
+	elapsed float32
+}
+
+func position(cam tilemap.Camera) vector2.Vector2 {
+	return vector2.Vector2{X: float32(cam.X), Y: float32(cam.Y)}
+}
+
+// toggleRecording starts a new recording from where cam is, or stops the
+// one going
+func (c *cameraPath) toggleRecording(cam tilemap.Camera) {
+	if c.recording {
+		c.recording = false
+		fmt.Println("recorded", len(c.spline.Points), "waypoints")
+		return
+	}
+	c.playing = false
+	c.recording = true
+	c.spline = bezier.CatmullRomSpline{Points: []vector2.Vector2{position(cam)}, Closed: true}
+	c.elapsed = 0
+	fmt.Println("recording the camera path")
+}
+
+// togglePlayback starts flying the recorded path from its start, or stops.
+// It takes two waypoints to make a path.
+func (c *cameraPath) togglePlayback() {
+	if c.playing {
+		c.playing = false
+		return
+	}
+	if len(c.spline.Points) < 2 {
+		fmt.Println("no camera path, record one with F8")
+		return
+	}
+	c.recording = false
+	c.playing = true
+	c.elapsed = 0
+}
+
+// update records a waypoint every waypointInterval while recording, and
+// moves cam along the path while playing
+func (c *cameraPath) update(dt float32, cam *tilemap.Camera) {
+	switch {
+	case c.recording:
+		c.elapsed += dt
+		for c.elapsed >= waypointInterval {
+			c.elapsed -= waypointInterval
+			c.spline.Points = append(c.spline.Points, position(*cam))
+		}
+	case c.playing:
+		loop := float32(len(c.spline.Points)) * waypointInterval
+		c.elapsed += dt
+		for c.elapsed >= loop {
+			c.elapsed -= loop
+		}
+		p := c.spline.Evaluate(c.elapsed / loop)
+		cam.X, cam.Y = int(math.Round(float64(p.X))), int(math.Round(float64(p.Y)))
+	}
+}
+
+// status is shown in the corner while recording or playing
+func (c *cameraPath) status() string {
+	switch {
+	case c.recording:
+		return fmt.Sprint("REC ", len(c.spline.Points))
+	case c.playing:
+		return "PLAY"
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/gfx"
+	"github.com/sabith-th/games_with_go/tilemap"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	tileSize = 8
+	// scrollSpeed is how many pixels a held arrow key scrolls per frame,
+	// shift scrolls fastMultiplier times as fast
+	scrollSpeed    = 4
+	fastMultiplier = 4
+	// holding W runs the weather weatherFastForward times as fast
+	weatherFastForward = 30
+)
+
+// recColor is the color of the camera path's status
+var recColor = font.Color{R: 240, G: 60, B: 60}
+
+var scrollKeys = []struct {
+	sc     gfx.Scancode
+	dx, dy int
+}{
+	{gfx.KeyLeft, -1, 0},
+	{gfx.KeyRight, 1, 0},
+	{gfx.KeyUp, 0, -1},
+	{gfx.KeyDown, 0, 1},
+}
+
+func main() {
+	win, err := gfx.New("Tile World", winWidth, winHeight)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer win.Destroy()
+
+	chunks := tilemap.NewChunkManager()
+	// the camera starts with the world origin in the middle of the window
+	cam := tilemap.Camera{X: -winWidth / 2, Y: -winHeight / 2, Width: winWidth, Height: winHeight, TileSize: tileSize}
+	keyState := win.KeyboardState()
+	dirty := true
+	// the tiles are drawn into terrain when the camera moves, and every
+	// frame it is copied to the window with the weather over it
+	terrain := make([]byte, winWidth*winHeight*4)
+	weather := newWeatherSystem(time.Now().UnixNano())
+	// F8 records the camera's path and F9 flies it
+	var path cameraPath
+	last := time.Now()
+
+	for {
+		for _, event := range win.PollEvents() {
+			switch e := event.(type) {
+			case gfx.QuitEvent:
+				return
+			case gfx.KeyEvent:
+				if !e.Down || e.Repeat {
+					continue
+				}
+				switch e.Scancode {
+				case gfx.KeyEscape:
+					return
+				case gfx.KeyF8:
+					path.toggleRecording(cam)
+				case gfx.KeyF9:
+					path.togglePlayback()
+				}
+			}
+		}
+
+		now := time.Now()
+		dt := float32(now.Sub(last).Seconds())
+		last = now
+
+		speed := scrollSpeed
+		if keyState[gfx.KeyLShift] != 0 || keyState[gfx.KeyRShift] != 0 {
+			speed *= fastMultiplier
+		}
+		prevX, prevY := cam.X, cam.Y
+		for _, k := range scrollKeys {
+			if keyState[k.sc] != 0 {
+				// scrolling takes the camera back from a flythrough
+				path.playing = false
+				cam.X += k.dx * speed
+				cam.Y += k.dy * speed
+			}
+		}
+		path.update(dt, &cam)
+		scrollX, scrollY := cam.X-prevX, cam.Y-prevY
+		if scrollX != 0 || scrollY != 0 {
+			dirty = true
+		}
+
+		timeScale := float32(1)
+		if keyState[gfx.KeyW] != 0 {
+			timeScale = weatherFastForward
+		}
+		weather.Update(dt, timeScale, scrollX, scrollY, winWidth, winHeight)
+
+		// a still camera shows the same tiles, so they are only drawn again
+		// once it moves
+		if dirty {
+			chunks.RenderVisibleChunks(cam, terrain)
+			dirty = false
+		}
+		pixels := win.Pixels()
+		copy(pixels, terrain)
+		weather.Draw(pixels, winWidth, winHeight)
+		weather.DrawHUD(pixels, winWidth, winHeight)
+		if status := path.status(); status != "" {
+			w, _ := font.Size(status, 3)
+			font.Draw(status, winWidth-w-10, 10, 3, recColor, pixels, winWidth, winHeight)
+		}
+		err := win.Update(0, winHeight)
+		if err != nil {
+			fmt.Println(err)
+		}
+		err = win.Show()
+		if err != nil {
+			fmt.Println(err)
+		}
+		if !win.VSync() {
+			time.Sleep(16 * time.Millisecond)
+		}
+	}
+}
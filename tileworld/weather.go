@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/sabith-th/games_with_go/font"
+	"github.com/sabith-th/games_with_go/noise"
+)
+
+const (
+	// weatherRate is how far the weather moves along the noise every
+	// second, a change of weather takes around a minute
+	weatherRate = 0.02
+	// every field is weatherOctaves of fbm, fbmRange being the sum of their
+	// amplitudes. weatherContrast spreads the fields over more of 0..1, fbm
+	// rarely gets near its extremes.
+	weatherOctaves    = 3
+	weatherLacunarity = 2
+	weatherGain       = 0.5
+	fbmRange          = 1.75
+	weatherContrast   = 1.6
+
+	// it rains above rainCoverage and snows below snowTemperature
+	rainCoverage    = 0.7
+	snowTemperature = 0.2
+
+	maxParticles = 2000
+	// rainRate and snowRate are how many particles a second appear in the
+	// heaviest rain and snow
+	rainRate, snowRate = 1500, 400
+	// particles fall at rainFall and snowFall pixels a second, a full wind
+	// pushes them windPush pixels a second more
+	rainFall, snowFall = 300, 40
+	windPush           = 160
+	// rainLife and snowLife are how long a particle lasts in seconds
+	rainLife, snowLife = 0.5, 3
+	// rainStreak is the length of a rain drop in seconds of its movement
+	rainStreak = 0.02
+)
+
+// the fields are read from rows of the noise far enough apart to be
+// unrelated
+const (
+	coverageRow    = 0
+	windSpeedRow   = 17.3
+	windDirRow     = 41.9
+	temperatureRow = 83.1
+)
+
+var (
+	rainColor  = font.Color{R: 170, G: 190, B: 235}
+	snowColor  = font.Color{R: 250, G: 250, B: 255}
+	hudColor   = font.Color{R: 255, G: 255, B: 255}
+	hudShadow  = font.Color{R: 0, G: 0, B: 0}
+	compassDir = [8]string{"E", "SE", "S", "SW", "W", "NW", "N", "NE"}
+)
+
+type particle struct {
+	x, y float32
+	// fall scales the kind's fall speed, so not every drop moves as one
+	fall float32
+	life float32
+	snow bool
+}
+
+// WeatherSystem is weather over the whole map that changes with time.
+// Each of its values is fbm along a time axis, kept between 0 and 1, the
+// wind's direction being a turn of the circle. Rain and snow are particles
+// on the screen that move with the wind.
+type WeatherSystem struct {
+	cloudCoverage float32
+	windSpeed     float32
+	// windDirection is where the wind blows to in radians, 0 to the east
+	// and a quarter turn to the south, down the screen
+	windDirection float32
+	temperature   float32
+
+	t         float32
+	particles []particle
+	// spawnDebt is the part of a particle left over from the last frame's
+	// spawning, so light rain still falls at high frame rates
+	spawnDebt float32
+	rng       *rand.Rand
+}
+
+func newWeatherSystem(seed int64) *WeatherSystem {
+	w := &WeatherSystem{rng: rand.New(rand.NewSource(seed))}
+	w.sample()
+	return w
+}
+
+// weatherField is the field on row at time t, 0 to 1
+func weatherField(t, row float32) float32 {
+	v := noise.Fbm2(t, row, 1, weatherLacunarity, weatherGain, weatherOctaves) * noise.SnoiseScale / fbmRange
+	return clamp01(v*weatherContrast*0.5 + 0.5)
+}
+
+func clamp01(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func (w *WeatherSystem) sample() {
+	w.cloudCoverage = weatherField(w.t, coverageRow)
+	w.windSpeed = weatherField(w.t, windSpeedRow)
+	w.windDirection = weatherField(w.t, windDirRow) * 2 * math.Pi
+	w.temperature = weatherField(w.t, temperatureRow)
+}
+
+// snowing and raining never happen at once, in the cold it snows
+func (w *WeatherSystem) snowing() bool {
+	return w.temperature < snowTemperature
+}
+
+func (w *WeatherSystem) raining() bool {
+	return w.cloudCoverage > rainCoverage && !w.snowing()
+}
+
+// wind is how far the wind pushes a particle every second
+func (w *WeatherSystem) wind() (float32, float32) {
+	s := w.windSpeed * windPush
+	return s * float32(math.Cos(float64(w.windDirection))), s * float32(math.Sin(float64(w.windDirection)))
+}
+
+// velocity is how far p moves every second, falling down the screen and
+// blown along by the wind, so the rain turns as the wind does
+func (w *WeatherSystem) velocity(p *particle) (float32, float32) {
+	wx, wy := w.wind()
+	fall := float32(rainFall)
+	if p.snow {
+		fall = snowFall
+	}
+	return wx, wy + fall*p.fall
+}
+
+// Update moves the weather on by dt seconds, timeScale times as fast as
+// normal. The particles are on the screen, scrollX and scrollY are how far
+// the camera moved this frame so they stay put on the map.
+func (w *WeatherSystem) Update(dt, timeScale float32, scrollX, scrollY, width, height int) {
+	w.t += dt * timeScale * weatherRate
+	w.sample()
+
+	live := w.particles[:0]
+	for _, p := range w.particles {
+		vx, vy := w.velocity(&p)
+		p.x += vx*dt - float32(scrollX)
+		p.y += vy*dt - float32(scrollY)
+		p.life -= dt
+		if p.life > 0 && p.x >= 0 && p.y >= 0 && p.x < float32(width) && p.y < float32(height) {
+			live = append(live, p)
+		}
+	}
+	w.particles = live
+
+	// the heavier the weather the more particles, from none at the
+	// threshold to rainRate or snowRate at the extreme
+	var rate, life float32
+	snow := w.snowing()
+	switch {
+	case snow:
+		rate, life = snowRate*(snowTemperature-w.temperature)/snowTemperature, snowLife
+	case w.raining():
+		rate, life = rainRate*(w.cloudCoverage-rainCoverage)/(1-rainCoverage), rainLife
+	}
+	w.spawnDebt += rate * dt
+	for ; w.spawnDebt >= 1; w.spawnDebt-- {
+		if len(w.particles) >= maxParticles {
+			continue
+		}
+		w.particles = append(w.particles, particle{
+			x:    w.rng.Float32() * float32(width),
+			y:    w.rng.Float32() * float32(height),
+			fall: 0.7 + 0.6*w.rng.Float32(),
+			life: life * (0.5 + w.rng.Float32()),
+			snow: snow,
+		})
+	}
+}
+
+// Draw draws the rain and snow into a width*height ABGR8888 buffer, rain
+// as short streaks along the way it falls and snow as flakes
+func (w *WeatherSystem) Draw(pixels []byte, width, height int) {
+	set := func(x, y int, c font.Color) {
+		if x < 0 || y < 0 || x >= width || y >= height {
+			return
+		}
+		i := (y*width + x) * 4
+		pixels[i], pixels[i+1], pixels[i+2] = c.R, c.G, c.B
+	}
+	for i := range w.particles {
+		p := &w.particles[i]
+		x, y := int(p.x), int(p.y)
+		if p.snow {
+			set(x, y, snowColor)
+			set(x+1, y, snowColor)
+			set(x, y+1, snowColor)
+			set(x+1, y+1, snowColor)
+			continue
+		}
+		vx, vy := w.velocity(p)
+		const steps = 4
+		for s := 0; s < steps; s++ {
+			f := rainStreak * float32(s) / steps
+			set(int(p.x-vx*f), int(p.y-vy*f), rainColor)
+		}
+	}
+}
+
+// icons are the weather's glyphs: the sun or a cloud, then rain or snow
+func (w *WeatherSystem) icons() string {
+	icons := "☀"
+	if w.cloudCoverage > 0.4 {
+		icons = "☁"
+	}
+	if w.raining() {
+		icons += "☂"
+	}
+	if w.snowing() {
+		icons += "❄"
+	}
+	return icons
+}
+
+// compass is the nearest of the eight points of the compass to angle
+func compass(angle float32) string {
+	i := int(math.Floor(float64(angle)/(math.Pi/4)+0.5)) % len(compassDir)
+	if i < 0 {
+		i += len(compassDir)
+	}
+	return compassDir[i]
+}
+
+// DrawHUD draws the weather's icons in the top left corner with its
+// values below them, each over a shadow so it reads on any terrain
+func (w *WeatherSystem) DrawHUD(pixels []byte, width, height int) {
+	text := fmt.Sprintf("CLOUD %d%%  WIND %.1f -> %s  TEMP %.2f", int(w.cloudCoverage*100), w.windSpeed, compass(w.windDirection), w.temperature)
+	const x, y = 10, 10
+	icons := w.icons()
+	font.Draw(icons, x+2, y+2, 4, hudShadow, pixels, width, height)
+	font.Draw(icons, x, y, 4, hudColor, pixels, width, height)
+	font.Draw(text, x+1, y+45, 2, hudShadow, pixels, width, height)
+	font.Draw(text, x, y+44, 2, hudColor, pixels, width, height)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sabith-th/games_with_go/dungeon"
+	"github.com/sabith-th/games_with_go/gfx"
+	"github.com/sabith-th/games_with_go/minimap"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const tileSize = 20
+
+// level is a room with a pool in the middle and torches along the walls,
+// in the characters of the minimap tile types
+var level = `
+########################################
+#*.......*.........*.........*........*#
+#......................................#
+#.........~~~~~~~~~~~~~~~~~~~..........#
+#.......~~~~~~~~~~~~~~~~~~~~~~~~.......#
+#*.....~~~~~~~~~~~~~~~~~~~~~~~~~~.....*#
+#......~~~~~~~~~~~~~~~~~~~~~~~~~~......#
+#.......~~~~~~~~~~~~~~~~~~~~~~~~.......#
+#.........~~~~~~~~~~~~~~~~~~~..........#
+#......................................#
+#*....................................*#
+####################/###################
+`
+
+func parseLevel(s string) [][]minimap.TileType {
+	var tiles [][]minimap.TileType
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		tiles = append(tiles, []minimap.TileType(line))
+	}
+	return tiles
+}
+
+// torchlight animates the level until escape is pressed
+type torchlight struct {
+	renderer *dungeon.Renderer
+	quit     bool
+}
+
+func (t *torchlight) Update(in gfx.Input, dt float64) {
+	t.quit = in.Pressed(gfx.KeyEscape)
+	t.renderer.Animator.Update(float32(dt))
+}
+
+// DrawRows draws the tiles that changed, only their rows are sent to the
+// window
+func (t *torchlight) DrawRows(pixels []byte, w, h int) (start, end int) {
+	return t.renderer.Render(pixels)
+}
+
+func (t *torchlight) Draw(pixels []byte, w, h int) {
+	t.renderer.Render(pixels)
+}
+
+func (t *torchlight) Done() bool {
+	return t.quit
+}
+
+func main() {
+	renderer := dungeon.NewRenderer(winWidth, winHeight, tileSize)
+	renderer.SetLevel(parseLevel(level))
+	err := gfx.Run("Torchlight", winWidth, winHeight, &torchlight{renderer: renderer})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,63 @@
+package vector2
+
+import "math"
+
+// Vector2 a 2d vector
+type Vector2 struct {
+	X, Y float32
+}
+
+// Add adds two vectors and returns a new vector
+func Add(a, b Vector2) Vector2 {
+	return Vector2{a.X + b.X, a.Y + b.Y}
+}
+
+// Sub subtracts b from a and returns a new vector
+func Sub(a, b Vector2) Vector2 {
+	return Vector2{a.X - b.X, a.Y - b.Y}
+}
+
+// Mult multiplies a scalar to a vector and returns a new vector
+func Mult(a Vector2, b float32) Vector2 {
+	return Vector2{a.X * b, a.Y * b}
+}
+
+// Dot returns the dot product of two vectors
+func Dot(a, b Vector2) float32 {
+	return a.X*b.X + a.Y*b.Y
+}
+
+// Cross returns the z component of the cross product of two vectors
+func Cross(a, b Vector2) float32 {
+	return a.X*b.Y - a.Y*b.X
+}
+
+// Lerp returns the point pct of the way from a to b
+func Lerp(a, b Vector2, pct float32) Vector2 {
+	return Vector2{a.X + pct*(b.X-a.X), a.Y + pct*(b.Y-a.Y)}
+}
+
+// Length returns the magnitude of the given vector
+func (a Vector2) Length() float32 {
+	return float32(math.Sqrt(float64(a.X*a.X + a.Y*a.Y)))
+}
+
+// Distance returns the distance between two vectors
+func Distance(a, b Vector2) float32 {
+	xDiff := a.X - b.X
+	yDiff := a.Y - b.Y
+	return float32(math.Sqrt(float64(xDiff*xDiff + yDiff*yDiff)))
+}
+
+// DistanceSquared returns the squared distance between two vectors
+func DistanceSquared(a, b Vector2) float32 {
+	xDiff := a.X - b.X
+	yDiff := a.Y - b.Y
+	return xDiff*xDiff + yDiff*yDiff
+}
+
+// Normalize returns a new vector with unit length and same direction as given vector
+func Normalize(a Vector2) Vector2 {
+	length := a.Length()
+	return Vector2{a.X / length, a.Y / length}
+}
@@ -0,0 +1,135 @@
+package voronoi
+
+import (
+	"runtime"
+	"sync"
+)
+
+// The jump flood algorithm approximates the diagram in O(w*h*log(max(w,h)))
+// regardless of the number of seeds. A buffer holds, for every pixel, the
+// pixel index of the nearest seed found so far (-1 for none). Each pass lets
+// every pixel look at the 8 pixels step away and adopt their seed if it is
+// closer, halving step each time.
+
+func seedDistSq(x, y, seedPixel, w int) int {
+	sx := seedPixel % w
+	sy := seedPixel / w
+	return (sx-x)*(sx-x) + (sy-y)*(sy-y)
+}
+
+// JFAPass runs a single jump flood step over seedBuf in place. seedBuf holds
+// the pixel index (y*w+x) of the nearest known seed for each pixel or -1.
+func JFAPass(seedBuf []int, w, h, step int) {
+	prev := make([]int, len(seedBuf))
+	copy(prev, seedBuf)
+	jfaPass(seedBuf, prev, w, h, step)
+}
+
+// jfaPass reads src and writes dst, splitting the rows across all cpus since
+// every pixel of a pass is independent
+func jfaPass(dst, src []int, w, h, step int) {
+	numRoutines := runtime.NumCPU()
+	if numRoutines > h {
+		numRoutines = h
+	}
+	var wg sync.WaitGroup
+	wg.Add(numRoutines)
+	for i := 0; i < numRoutines; i++ {
+		go func(startY, endY int) {
+			defer wg.Done()
+			for y := startY; y < endY; y++ {
+				for x := 0; x < w; x++ {
+					dst[y*w+x] = jfaPixel(src, x, y, w, h, step)
+				}
+			}
+		}(i*h/numRoutines, (i+1)*h/numRoutines)
+	}
+	wg.Wait()
+}
+
+func jfaPixel(src []int, x, y, w, h, step int) int {
+	best := src[y*w+x]
+	bestDist := 0
+	if best >= 0 {
+		bestDist = seedDistSq(x, y, best, w)
+	}
+	for dy := -step; dy <= step; dy += step {
+		ny := y + dy
+		if ny < 0 || ny >= h {
+			continue
+		}
+		for dx := -step; dx <= step; dx += step {
+			nx := x + dx
+			if nx < 0 || nx >= w || (dx == 0 && dy == 0) {
+				continue
+			}
+			candidate := src[ny*w+nx]
+			if candidate < 0 || candidate == best {
+				continue
+			}
+			d := seedDistSq(x, y, candidate, w)
+			if best < 0 || d < bestDist || (d == bestDist && candidate < best) {
+				best = candidate
+				bestDist = d
+			}
+		}
+	}
+	return best
+}
+
+// JFA returns, for every pixel of a w*h area, the index into seedPoints of
+// its nearest seed as found by jump flooding, or -1 when there are no seeds.
+// When several seeds share a pixel the first one wins, and a pixel exactly
+// between two seeds may pick a different one than ComputeNaive. A final
+// extra pass at step 1 cleans up most of the pixels plain jump flooding gets
+// wrong.
+func JFA(seedPoints []Point, w, h int) []int {
+	seedBuf := make([]int, w*h)
+	for i := range seedBuf {
+		seedBuf[i] = -1
+	}
+	owner := make(map[int]int, len(seedPoints))
+	for i, p := range seedPoints {
+		if p.X < 0 || p.X >= w || p.Y < 0 || p.Y >= h {
+			continue
+		}
+		pixel := p.Y*w + p.X
+		if _, taken := owner[pixel]; !taken {
+			owner[pixel] = i
+			seedBuf[pixel] = pixel
+		}
+	}
+
+	size := w
+	if h > size {
+		size = h
+	}
+	step := 1
+	for step < size {
+		step *= 2
+	}
+	back := make([]int, len(seedBuf))
+	for step /= 2; step >= 1; step /= 2 {
+		jfaPass(back, seedBuf, w, h, step)
+		seedBuf, back = back, seedBuf
+	}
+	jfaPass(back, seedBuf, w, h, 1)
+	seedBuf = back
+
+	for i, pixel := range seedBuf {
+		if pixel >= 0 {
+			seedBuf[i] = owner[pixel]
+		}
+	}
+	return seedBuf
+}
+
+// ComputeJFA builds a Diagram using JFA. Gaps are not tracked, so Draw
+// outlines cells by comparing neighbouring pixels instead.
+func ComputeJFA(seeds []Seed, w, h int) *Diagram {
+	points := make([]Point, len(seeds))
+	for i, s := range seeds {
+		points[i] = s.Point
+	}
+	return &Diagram{W: w, H: h, Cells: JFA(points, w, h)}
+}
@@ -0,0 +1,182 @@
+package voronoi
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// BorderWidth is the largest gap, in pixels, between the distances to the two
+// nearest seeds for which Draw still treats a pixel as part of a cell border
+const BorderWidth = 1.5
+
+// Point is a pixel position
+type Point struct {
+	X, Y int
+}
+
+// Color is an rgb colour
+type Color struct {
+	R, G, B byte
+}
+
+// Seed is a site of the diagram, every pixel takes the colour of its nearest seed
+type Seed struct {
+	Point
+	Color Color
+}
+
+// RandomSeeds places n seeds with random bright colours inside a w*h area
+func RandomSeeds(n, w, h int, rng *rand.Rand) []Seed {
+	seeds := make([]Seed, n)
+	for i := range seeds {
+		seeds[i].X = rng.Intn(w)
+		seeds[i].Y = rng.Intn(h)
+		seeds[i].Color = Color{byte(64 + rng.Intn(192)), byte(64 + rng.Intn(192)), byte(64 + rng.Intn(192))}
+	}
+	return seeds
+}
+
+// Diagram assigns every pixel of a w*h area to its nearest seed
+type Diagram struct {
+	W, H int
+	// Cells holds the index of the nearest seed for each pixel, row by row,
+	// or -1 when there are no seeds
+	Cells []int
+	// Gaps holds the distance to the second nearest seed minus the distance
+	// to the nearest one, which drops towards zero along cell borders. It is
+	// nil when the method used to build the diagram doesn't track it.
+	Gaps []float32
+}
+
+func newDiagram(w, h int) *Diagram {
+	return &Diagram{w, h, make([]int, w*h), make([]float32, w*h)}
+}
+
+func distSq(x, y int, p Point) int {
+	dx := p.X - x
+	dy := p.Y - y
+	return dx*dx + dy*dy
+}
+
+// nearestTwo tracks the closest and second closest seed seen so far. Ties go
+// to the lower seed index so every search order picks the same cell.
+type nearestTwo struct {
+	index        int
+	best, second int
+}
+
+func newNearestTwo() nearestTwo {
+	return nearestTwo{-1, math.MaxInt64, math.MaxInt64}
+}
+
+func (n *nearestTwo) consider(i, d int) {
+	if d < n.best || (d == n.best && i < n.index) {
+		n.second = n.best
+		n.best = d
+		n.index = i
+	} else if d < n.second {
+		n.second = d
+	}
+}
+
+func (n *nearestTwo) gap() float32 {
+	if n.second == math.MaxInt64 {
+		return float32(math.Inf(1))
+	}
+	return float32(math.Sqrt(float64(n.second)) - math.Sqrt(float64(n.best)))
+}
+
+// ComputeNaive checks every seed for every pixel, O(w*h*n)
+func ComputeNaive(seeds []Seed, w, h int) *Diagram {
+	d := newDiagram(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			n := newNearestTwo()
+			for i, s := range seeds {
+				n.consider(i, distSq(x, y, s.Point))
+			}
+			d.Cells[y*w+x] = n.index
+			d.Gaps[y*w+x] = n.gap()
+		}
+	}
+	return d
+}
+
+// Compute builds the same diagram as ComputeNaive but with the seeds sorted
+// by x. Each pixel scans outwards from its own column in both directions and
+// stops on a side once the horizontal distance alone is further than the
+// second nearest seed found so far, so only a handful of seeds are visited.
+func Compute(seeds []Seed, w, h int) *Diagram {
+	order := make([]int, len(seeds))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return seeds[order[a]].X < seeds[order[b]].X
+	})
+	xs := make([]int, len(order))
+	for i, si := range order {
+		xs[i] = seeds[si].X
+	}
+
+	d := newDiagram(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			n := newNearestTwo()
+			right := sort.SearchInts(xs, x)
+			left := right - 1
+			for left >= 0 || right < len(xs) {
+				if right < len(xs) {
+					dx := xs[right] - x
+					if dx*dx <= n.second {
+						n.consider(order[right], distSq(x, y, seeds[order[right]].Point))
+						right++
+					} else {
+						right = len(xs)
+					}
+				}
+				if left >= 0 {
+					dx := x - xs[left]
+					if dx*dx <= n.second {
+						n.consider(order[left], distSq(x, y, seeds[order[left]].Point))
+						left--
+					} else {
+						left = -1
+					}
+				}
+			}
+			d.Cells[y*w+x] = n.index
+			d.Gaps[y*w+x] = n.gap()
+		}
+	}
+	return d
+}
+
+func isBorder(d *Diagram, x, y int) bool {
+	i := y*d.W + x
+	if d.Gaps != nil {
+		return d.Gaps[i] < BorderWidth
+	}
+	// without gaps fall back to comparing with the right and lower neighbour
+	return (x+1 < d.W && d.Cells[i+1] != d.Cells[i]) || (y+1 < d.H && d.Cells[i+d.W] != d.Cells[i])
+}
+
+// Draw fills a w*h*4 pixel buffer with the colour of each pixel's cell. When
+// borders is set, pixels on the edge between two cells get borderColor.
+func (d *Diagram) Draw(seeds []Seed, pixels []byte, borders bool, borderColor Color) {
+	for y := 0; y < d.H; y++ {
+		for x := 0; x < d.W; x++ {
+			i := y*d.W + x
+			var c Color
+			if borders && isBorder(d, x, y) {
+				c = borderColor
+			} else if d.Cells[i] >= 0 {
+				c = seeds[d.Cells[i]].Color
+			}
+			pixels[i*4] = c.R
+			pixels[i*4+1] = c.G
+			pixels[i*4+2] = c.B
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/sabith-th/games_with_go/hull"
+	"github.com/sabith-th/games_with_go/vector2"
+	"github.com/sabith-th/games_with_go/voronoi"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+func setPixel(x, y int, c voronoi.Color, pixels []byte) {
+	index := (y*winWidth + x) * 4
+	if index < len(pixels)-4 && index >= 0 {
+		pixels[index] = c.R
+		pixels[index+1] = c.G
+		pixels[index+2] = c.B
+	}
+}
+
+func drawSeeds(seeds []voronoi.Seed, pixels []byte) {
+	for _, s := range seeds {
+		for y := -1; y <= 1; y++ {
+			for x := -1; x <= 1; x++ {
+				setPixel(s.X+x, s.Y+y, voronoi.Color{}, pixels)
+			}
+		}
+	}
+}
+
+// drawLine draws a line between two points with Bresenham's algorithm
+func drawLine(a, b vector2.Vector2, c voronoi.Color, pixels []byte) {
+	x0, y0 := int(a.X), int(a.Y)
+	x1, y1 := int(b.X), int(b.Y)
+	dx := x1 - x0
+	if dx < 0 {
+		dx = -dx
+	}
+	dy := y1 - y0
+	if dy > 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		setPixel(x0, y0, c, pixels)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawHull outlines the convex hull of the seeds, timing whichever
+// algorithm is selected
+func drawHull(seeds []voronoi.Seed, graham bool, pixels []byte) {
+	points := make([]vector2.Vector2, len(seeds))
+	for i, s := range seeds {
+		points[i] = vector2.Vector2{X: float32(s.X), Y: float32(s.Y)}
+	}
+
+	startTime := time.Now()
+	var polygon []vector2.Vector2
+	if graham {
+		polygon = hull.GrahamScan(points)
+	} else {
+		polygon = hull.GiftWrapping(points)
+	}
+	elapsedTime := time.Since(startTime).Seconds() * 1000.0
+	if graham {
+		fmt.Println("graham scan:", elapsedTime, "ms")
+	} else {
+		fmt.Println("gift wrapping:", elapsedTime, "ms")
+	}
+
+	for i := range polygon {
+		drawLine(polygon[i], polygon[(i+1)%len(polygon)], voronoi.Color{R: 255, G: 255, B: 255}, pixels)
+	}
+}
+
+type method int
+
+const (
+	sortedScan method = iota
+	jumpFlood
+	naive
+	numMethods
+)
+
+var methodNames = [numMethods]string{"sorted scan", "jump flood", "naive"}
+
+func draw(seeds []voronoi.Seed, m method, borders bool, pixels []byte) {
+	startTime := time.Now()
+	var diagram *voronoi.Diagram
+	switch m {
+	case naive:
+		diagram = voronoi.ComputeNaive(seeds, winWidth, winHeight)
+	case jumpFlood:
+		diagram = voronoi.ComputeJFA(seeds, winWidth, winHeight)
+	default:
+		diagram = voronoi.Compute(seeds, winWidth, winHeight)
+	}
+	elapsedTime := time.Since(startTime).Seconds() * 1000.0
+	fmt.Println(methodNames[m]+":", elapsedTime, "ms")
+
+	diagram.Draw(seeds, pixels, borders, voronoi.Color{R: 20, G: 20, B: 20})
+	drawSeeds(seeds, pixels)
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Voronoi", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	numSeeds := 200
+	seeds := voronoi.RandomSeeds(numSeeds, winWidth, winHeight, rng)
+	m := sortedScan
+	borders := true
+	showHull := false
+	graham := true
+	dirty := true
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 {
+					switch e.Keysym.Scancode {
+					case sdl.SCANCODE_SPACE:
+						seeds = voronoi.RandomSeeds(numSeeds, winWidth, winHeight, rng)
+					case sdl.SCANCODE_N:
+						m = (m + 1) % numMethods
+					case sdl.SCANCODE_E:
+						borders = !borders
+					case sdl.SCANCODE_H:
+						showHull = !showHull
+					case sdl.SCANCODE_G:
+						graham = !graham
+					case sdl.SCANCODE_EQUALS, sdl.SCANCODE_KP_PLUS:
+						numSeeds *= 2
+						seeds = voronoi.RandomSeeds(numSeeds, winWidth, winHeight, rng)
+					case sdl.SCANCODE_MINUS, sdl.SCANCODE_KP_MINUS:
+						if numSeeds > 1 {
+							numSeeds /= 2
+						}
+						seeds = voronoi.RandomSeeds(numSeeds, winWidth, winHeight, rng)
+					}
+					dirty = true
+				}
+			}
+		}
+
+		if dirty {
+			draw(seeds, m, borders, pixels)
+			if showHull {
+				drawHull(seeds, graham, pixels)
+			}
+			dirty = false
+		}
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import "math"
+
+const (
+	// waveSpeed2 is c² in cells per step, it has to stay below 0.5 for the
+	// explicit update to be stable
+	waveSpeed2 = 0.25
+	damping    = 0.99
+
+	// depth and gravity give the shallow water model the same wave speed
+	// sqrt(g*depth) as the wave equation, stones deep enough to matter
+	// against depth make the crests visibly steepen
+	depth   = 10
+	gravity = waveSpeed2 / depth
+
+	// spongeWidth is how many cells from each edge the shallow water model
+	// damps waves away in, spongeDamping is the extra damping at the edge
+	spongeWidth   = 16
+	spongeDamping = 0.15
+)
+
+// surface is the height field of the water. The wave equation keeps the
+// previous heights to step from, the shallow water equations keep a
+// velocity field on a staggered grid instead: vx sits on the left and
+// right faces of each cell and vy on the top and bottom ones.
+type surface struct {
+	w, h    int
+	shallow bool
+
+	u, prev, next []float32
+	vx, vy        []float32
+	// advected values, swapped with the fields after each step
+	tmpU, tmpVx, tmpVy []float32
+}
+
+func newSurface(w, h int) *surface {
+	return &surface{
+		w:     w,
+		h:     h,
+		u:     make([]float32, w*h),
+		prev:  make([]float32, w*h),
+		next:  make([]float32, w*h),
+		vx:    make([]float32, (w+1)*h),
+		vy:    make([]float32, w*(h+1)),
+		tmpU:  make([]float32, w*h),
+		tmpVx: make([]float32, (w+1)*h),
+		tmpVy: make([]float32, w*(h+1)),
+	}
+}
+
+// setShallow switches model, the water keeps its shape and starts at rest
+func (s *surface) setShallow(shallow bool) {
+	s.shallow = shallow
+	copy(s.prev, s.u)
+	for i := range s.vx {
+		s.vx[i] = 0
+	}
+	for i := range s.vy {
+		s.vy[i] = 0
+	}
+}
+
+// drop pushes the water down in a smooth dip of the given radius around
+// cell x, y, as if a stone had just hit it
+func (s *surface) drop(x, y, radius int, height float32) {
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			cx, cy := x+dx, y+dy
+			if cx < 1 || cx >= s.w-1 || cy < 1 || cy >= s.h-1 {
+				continue
+			}
+			d := math.Sqrt(float64(dx*dx+dy*dy)) / float64(radius)
+			if d > 1 {
+				continue
+			}
+			v := height * float32(0.5+0.5*math.Cos(math.Pi*d))
+			s.u[cy*s.w+cx] -= v
+			s.prev[cy*s.w+cx] -= v
+		}
+	}
+}
+
+func (s *surface) step() {
+	if s.shallow {
+		s.stepShallow()
+	} else {
+		s.stepWave()
+	}
+}
+
+// stepWave advances u[t+1] = 2u[t] - u[t-1] + c²∇²u[t], damped, with Mur's
+// first order absorbing boundary on the edges so waves leave the window
+// instead of bouncing back
+func (s *surface) stepWave() {
+	w, h := s.w, s.h
+	u, prev, next := s.u, s.prev, s.next
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			laplacian := u[i-1] + u[i+1] + u[i-w] + u[i+w] - 4*u[i]
+			next[i] = (2*u[i] - prev[i] + waveSpeed2*laplacian) * damping
+		}
+	}
+
+	c := float32(math.Sqrt(waveSpeed2))
+	k := (c - 1) / (c + 1)
+	for y := 1; y < h-1; y++ {
+		left, right := y*w, y*w+w-1
+		next[left] = u[left+1] + k*(next[left+1]-u[left])
+		next[right] = u[right-1] + k*(next[right-1]-u[right])
+	}
+	for x := 0; x < w; x++ {
+		top, bottom := x, (h-1)*w+x
+		next[top] = u[top+w] + k*(next[top+w]-u[top])
+		next[bottom] = u[bottom-w] + k*(next[bottom-w]-u[bottom])
+	}
+
+	s.prev, s.u, s.next = u, next, prev
+}
+
+// stepShallow advances the shallow water equations. Heights and velocities
+// are first carried along the flow by semi-Lagrangian advection, which
+// is what the wave equation leaves out, then the velocity is accelerated
+// down the slope and the height follows the divergence of the flux. The
+// edges are walls, a sponge layer in front of them absorbs the waves.
+func (s *surface) stepShallow() {
+	w, h := s.w, s.h
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			px, py := float32(x), float32(y)
+			vx, vy := s.velocity(px, py)
+			s.tmpU[y*w+x] = sample(s.u, w, h, 0, 0, px-vx, py-vy)
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 1; x < w; x++ {
+			px, py := float32(x)-0.5, float32(y)
+			vx, vy := s.velocity(px, py)
+			s.tmpVx[y*(w+1)+x] = sample(s.vx, w+1, h, -0.5, 0, px-vx, py-vy)
+		}
+	}
+	for y := 1; y < h; y++ {
+		for x := 0; x < w; x++ {
+			px, py := float32(x), float32(y)-0.5
+			vx, vy := s.velocity(px, py)
+			s.tmpVy[y*w+x] = sample(s.vy, w, h+1, 0, -0.5, px-vx, py-vy)
+		}
+	}
+	s.u, s.tmpU = s.tmpU, s.u
+	s.vx, s.tmpVx = s.tmpVx, s.vx
+	s.vy, s.tmpVy = s.tmpVy, s.vy
+
+	u, vx, vy := s.u, s.vx, s.vy
+	for y := 0; y < h; y++ {
+		for x := 1; x < w; x++ {
+			vx[y*(w+1)+x] -= gravity * (u[y*w+x] - u[y*w+x-1])
+		}
+	}
+	for y := 1; y < h; y++ {
+		for x := 0; x < w; x++ {
+			vy[y*w+x] -= gravity * (u[y*w+x] - u[(y-1)*w+x])
+		}
+	}
+
+	// the flux through a face is its velocity times the water column
+	// above it, taken as the average of the cells on either side
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			var flux float32
+			if x > 0 {
+				flux -= vx[y*(w+1)+x] * (depth + (u[i-1]+u[i])/2)
+			}
+			if x < w-1 {
+				flux += vx[y*(w+1)+x+1] * (depth + (u[i]+u[i+1])/2)
+			}
+			if y > 0 {
+				flux -= vy[y*w+x] * (depth + (u[i-w]+u[i])/2)
+			}
+			if y < h-1 {
+				flux += vy[(y+1)*w+x] * (depth + (u[i]+u[i+w])/2)
+			}
+			s.tmpU[i] = u[i] - flux
+		}
+	}
+	s.u, s.tmpU = s.tmpU, s.u
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			d := damping * sponge(x, y, w, h)
+			s.u[y*w+x] *= d
+			vx[y*(w+1)+x] *= d
+			vy[y*w+x] *= d
+		}
+	}
+}
+
+// velocity interpolates the staggered velocity field at px, py in cells
+func (s *surface) velocity(px, py float32) (float32, float32) {
+	return sample(s.vx, s.w+1, s.h, -0.5, 0, px, py), sample(s.vy, s.w, s.h+1, 0, -0.5, px, py)
+}
+
+// sponge is the extra damping factor for a cell, 1 away from the edges and
+// falling to 1-spongeDamping on them
+func sponge(x, y, w, h int) float32 {
+	d := x
+	if w-1-x < d {
+		d = w - 1 - x
+	}
+	if y < d {
+		d = y
+	}
+	if h-1-y < d {
+		d = h - 1 - y
+	}
+	if d >= spongeWidth {
+		return 1
+	}
+	t := float32(spongeWidth-d) / spongeWidth
+	return 1 - spongeDamping*t*t
+}
+
+// sample bilinearly interpolates a fw*fh field whose value i, j sits at
+// i+ox, j+oy, clamping to the edges
+func sample(field []float32, fw, fh int, ox, oy, x, y float32) float32 {
+	x = clampf(x-ox, 0, float32(fw-1))
+	y = clampf(y-oy, 0, float32(fh-1))
+	x0, y0 := int(x), int(y)
+	x1, y1 := x0+1, y0+1
+	if x1 > fw-1 {
+		x1 = fw - 1
+	}
+	if y1 > fh-1 {
+		y1 = fh - 1
+	}
+	tx, ty := x-float32(x0), y-float32(y0)
+	top := field[y0*fw+x0] + tx*(field[y0*fw+x1]-field[y0*fw+x0])
+	bottom := field[y1*fw+x0] + tx*(field[y1*fw+x1]-field[y1*fw+x0])
+	return top + ty*(bottom-top)
+}
+
+func clampf(v, min, max float32) float32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
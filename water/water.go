@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const winWidth, winHeight int = 800, 600
+
+const (
+	cellSize   = 2
+	gridWidth  = winWidth / cellSize
+	gridHeight = winHeight / cellSize
+
+	stoneRadius = 8
+	stoneHeight = 5
+
+	// heights are drawn on a scale of ±heightRange, crests above
+	// foamHeight fade to white
+	heightRange = 0.8
+	foamHeight  = 0.4
+)
+
+type color struct {
+	r, g, b byte
+}
+
+var (
+	deepColor    = color{0, 25, 80}
+	shallowColor = color{90, 170, 235}
+	foamColor    = color{255, 255, 255}
+)
+
+func lerp(b1, b2 byte, pct float32) byte {
+	return byte(float32(b1) + pct*(float32(b2)-float32(b1)))
+}
+
+func colorlerp(c1, c2 color, pct float32) color {
+	return color{lerp(c1.r, c2.r, pct), lerp(c1.g, c2.g, pct), lerp(c1.b, c2.b, pct)}
+}
+
+func getGradient(c1, c2 color) []color {
+	result := make([]color, 256)
+	for i := range result {
+		pct := float32(i) / float32(255)
+		result[i] = colorlerp(c1, c2, pct)
+	}
+	return result
+}
+
+// waterColor maps a height to the blue gradient, with foam on the crests
+func waterColor(height float32, gradient []color) color {
+	t := clampf((height+heightRange)/(2*heightRange), 0, 1)
+	c := gradient[int(t*255)]
+	if height > foamHeight {
+		c = colorlerp(c, foamColor, clampf((height-foamHeight)/(heightRange-foamHeight), 0, 1))
+	}
+	return c
+}
+
+func draw(s *surface, gradient []color, pixels []byte) {
+	for y := 0; y < winHeight; y++ {
+		for x := 0; x < winWidth; x++ {
+			c := waterColor(s.u[(y/cellSize)*s.w+x/cellSize], gradient)
+			index := (y*winWidth + x) * 4
+			pixels[index] = c.r
+			pixels[index+1] = c.g
+			pixels[index+2] = c.b
+		}
+	}
+}
+
+func main() {
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow("Water", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer window.Destroy()
+
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Destroy()
+
+	tex, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STREAMING,
+		int32(winWidth), int32(winHeight))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer tex.Destroy()
+
+	pixels := make([]byte, winWidth*winHeight*4)
+	gradient := getGradient(deepColor, shallowColor)
+	s := newSurface(gridWidth, gridHeight)
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return
+			case *sdl.MouseButtonEvent:
+				if e.Type == sdl.MOUSEBUTTONDOWN && e.Button == sdl.BUTTON_LEFT {
+					s.drop(int(e.X)/cellSize, int(e.Y)/cellSize, stoneRadius, stoneHeight)
+				}
+			case *sdl.KeyboardEvent:
+				if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == sdl.SCANCODE_S {
+					s.setShallow(!s.shallow)
+					if s.shallow {
+						fmt.Println("shallow water equations")
+					} else {
+						fmt.Println("wave equation")
+					}
+				}
+			}
+		}
+
+		s.step()
+		draw(s, gradient, pixels)
+
+		tex.Update(nil, pixels, winWidth*4)
+		renderer.Copy(tex, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}